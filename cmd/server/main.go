@@ -2,22 +2,36 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"maps"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/AbubakarMahmood1/go-rate-limiter/internal/algorithms"
 	"github.com/AbubakarMahmood1/go-rate-limiter/internal/config"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/discovery"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/grpcserver"
 	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
 	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
-	"github.com/AbubakarMahmood1/go-rate-limiter/internal/store"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/keys"
 	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -27,60 +41,288 @@ func main() {
 		configFile = "config.yaml"
 	}
 
-	cfg := config.LoadOrDefault(configFile)
+	// Run on defaults without a config file, but never with an invalid one
+	cfg, err := config.Load(configFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		cfg = config.DefaultConfig()
+	} else if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Algorithms.Default == limiter.AlgorithmAuto {
+		cfg.Algorithms.Default = limiter.AutoAlgorithm(limitConfig(cfg.Limits.Default))
+	}
 	log.Printf("Loaded configuration: store=%s, algorithm=%s", cfg.Store, cfg.Algorithms.Default)
 
+	// Initialize metrics
+	metricsInstance := metrics.NewMetrics()
+
 	// Initialize store
 	var storeInstance limiter.Store
-	var err error
 
 	switch cfg.Store {
 	case "redis":
+		scriptModes := make(map[string]store.ScriptMode, len(cfg.Redis.Scripts.Paths))
+		for path, mode := range cfg.Redis.Scripts.Paths {
+			scriptModes[path], err = store.ParseScriptMode(mode)
+			if err != nil {
+				log.Fatalf("Invalid script mode for %s: %v", path, err)
+			}
+		}
+
 		redisConfig := store.RedisConfig{
-			Addresses: cfg.Redis.Addresses,
-			Password:  cfg.Redis.Password,
-			DB:        cfg.Redis.DB,
-			PoolSize:  cfg.Redis.PoolSize,
-			TTL:       cfg.Redis.TTL,
+			Mode:                 cfg.Redis.Mode,
+			Addresses:            cfg.Redis.Addresses,
+			SentinelMasterName:   cfg.Redis.SentinelMasterName,
+			SentinelAddresses:    cfg.Redis.SentinelAddresses,
+			Password:             cfg.Redis.Password,
+			DB:                   cfg.Redis.DB,
+			PoolSize:             cfg.Redis.PoolSize,
+			TTL:                  cfg.Redis.TTL,
+			KeyPrefix:            cfg.Redis.KeyPrefix,
+			Timeout:              cfg.Redis.Timeout,
+			ScriptModes:          scriptModes,
+			ScriptErrorThreshold: cfg.Redis.Scripts.ErrorThreshold,
+			ScriptCooldown:       cfg.Redis.Scripts.Cooldown,
+			Metrics:              metricsInstance,
+			OperationRetries:     cfg.Redis.Operations.Retries,
+			OperationTTL:         cfg.Redis.Operations.TTL,
 		}
-		storeInstance, err = store.NewRedisStore(redisConfig)
+		redisStore, err := store.NewRedisStore(redisConfig)
 		if err != nil {
 			log.Fatalf("Failed to initialize Redis store: %v", err)
 		}
-		log.Println("Using Redis store")
+		storeInstance = redisStore
+		if cfg.Redis.Cache.Enabled {
+			storeInstance = store.NewTieredStore(store.NewMemoryStore(), redisStore, cfg.Redis.Cache.SyncInterval)
+			log.Printf("Using Redis store with in-memory cache (sync every %s)", cfg.Redis.Cache.SyncInterval)
+		} else {
+			log.Printf("Using Redis store (%s)", redisConfig.ResolvedMode())
+		}
+	case "dynamodb":
+		storeInstance, err = newDynamoStore(cfg.DynamoDB)
+		if err != nil {
+			log.Fatalf("Failed to initialize DynamoDB store: %v", err)
+		}
+		log.Printf("Using DynamoDB store (table %s)", cfg.DynamoDB.Table)
 	default:
-		storeInstance = store.NewMemoryStore()
+		storeInstance = store.NewMemoryStore(store.WithStoreMetrics(metricsInstance))
 		log.Println("Using in-memory store")
 	}
 
 	defer storeInstance.Close()
 
-	// Initialize metrics
-	metricsInstance := metrics.NewMetrics()
+	// Discover instance topology from the StatefulSet and its headless service
+	discoveryCtx, stopDiscovery := context.WithCancel(context.Background())
+	defer stopDiscovery()
+	var discoverer *discovery.Discoverer
+	if cfg.Discovery.Enabled {
+		discoverer = discovery.New(discovery.Config{
+			Service:  cfg.Discovery.Service,
+			PortName: cfg.Discovery.PortName,
+			Refresh:  cfg.Discovery.Refresh,
+			Ramp:     cfg.Discovery.Ramp,
+		}, discovery.WithMetrics(metricsInstance))
+		discoverer.Subscribe(func(t discovery.Topology) {
+			log.Printf("Topology changed: instances=%d ordinal=%d", t.Instances, t.Ordinal)
+		})
+		discoverer.Start(discoveryCtx)
+	}
 
 	// Create rate limiters for each algorithm
-	limiters := make(map[string]limiter.RateLimiter)
+	if err := validateLimitConfig(cfg.Limits.Default); err != nil {
+		log.Fatalf("Invalid default limits: %v", err)
+	}
+	limiters, err := newLimiters(storeInstance, cfg.Limits.Default)
+	if err != nil {
+		log.Fatalf("Invalid limits: %v", err)
+	}
 
-	// Token Bucket
-	limiters["token_bucket"] = algorithms.NewTokenBucket(storeInstance, limiter.Config{
-		Limit:  cfg.Limits.Default.Requests,
-		Window: cfg.Limits.Default.Window,
-		Burst:  cfg.Limits.Default.Burst,
-	})
+	log.Printf("Initialized %d algorithms", len(limiters))
 
-	// Sliding Window Counter
-	limiters["sliding_window"] = algorithms.NewSlidingWindowCounter(storeInstance, limiter.Config{
-		Limit:  cfg.Limits.Default.Requests,
-		Window: cfg.Limits.Default.Window,
-	})
+	// Tiers get their own limiters per algorithm; checks naming another tier use the defaults
+	tierLimiters := make(map[string]map[string]limiter.RateLimiter, len(cfg.Limits.Tiers))
+	for name, tc := range cfg.Limits.Tiers {
+		if err := validateLimitConfig(tc); err != nil {
+			log.Fatalf("Invalid limits for tier %q: %v", name, err)
+		}
+		tierLimiters[name], err = newLimiters(storeInstance, tc)
+		if err != nil {
+			log.Fatalf("Invalid limits for tier %q: %v", name, err)
+		}
+	}
+	if len(tierLimiters) > 0 {
+		log.Printf("Initialized %d limit tiers", len(tierLimiters))
+	}
 
-	// Fixed Window Counter
-	limiters["fixed_window"] = algorithms.NewFixedWindowCounter(storeInstance, limiter.Config{
-		Limit:  cfg.Limits.Default.Requests,
-		Window: cfg.Limits.Default.Window,
-	})
+	// Serve token bucket checks from batches of tokens leased from the store, sparing it a
+	// round trip per check; wrapped first so every layer below sees the leased bucket
+	if cfg.Lease.Enabled {
+		lease := func(tl map[string]limiter.RateLimiter) {
+			bucket, ok := tl["token_bucket"].(*algorithms.TokenBucket)
+			if !ok {
+				return
+			}
+			leased, err := algorithms.NewLeasedLimiter(bucket, storeInstance, cfg.Lease.Size, algorithms.WithLeaseMetrics(metricsInstance))
+			if err != nil {
+				log.Fatalf("Invalid lease configuration: %v", err)
+			}
+			tl["token_bucket"] = leased
+		}
+		lease(limiters)
+		for _, tl := range tierLimiters {
+			lease(tl)
+		}
+		log.Printf("Token leasing enabled (lease size=%d)", cfg.Lease.Size)
+	}
 
-	log.Printf("Initialized %d algorithms", len(limiters))
+	// Let operators give single keys their own limits at runtime; wrapped first so shadowing,
+	// penalties and the other layers below apply to overridden keys too
+	var overrides *algorithms.Overrides
+	if cfg.Overrides.Enabled {
+		// Kept in the store when it can, so every instance enforces them and they survive restarts
+		if overrideStore, ok := storeInstance.(limiter.LimitOverrideStore); ok {
+			overrides = algorithms.NewStoreOverrides(overrideStore)
+		} else {
+			overrides = algorithms.NewOverrides()
+			log.Println("Store cannot keep limit overrides; holding them in memory on this instance")
+		}
+		build := func(c limiter.Config) (limiter.RateLimiter, error) {
+			return limiter.New(storeInstance, c)
+		}
+		wrap := func(l limiter.RateLimiter) limiter.RateLimiter {
+			overridden, err := algorithms.NewOverrideLimiter(l, overrides, build)
+			if err != nil {
+				log.Fatalf("Invalid override configuration: %v", err)
+			}
+			return overridden
+		}
+		for name, l := range limiters {
+			limiters[name] = wrap(l)
+		}
+		for _, tl := range tierLimiters {
+			for name, l := range tl {
+				tl[name] = wrap(l)
+			}
+		}
+		log.Println("Per-key limit overrides enabled")
+	}
+
+	// Compare algorithms on live traffic, enforcing only the primary of each pair
+	if cfg.Shadow.Enabled {
+		if err := validateShadowPairs(limiters, cfg.Shadow.Pairs); err != nil {
+			log.Fatalf("Invalid shadow configuration: %v", err)
+		}
+		shadowLimiters(limiters, cfg.Shadow.Pairs, metricsInstance)
+		for _, tl := range tierLimiters {
+			shadowLimiters(tl, cfg.Shadow.Pairs, metricsInstance)
+		}
+		for primary, shadow := range cfg.Shadow.Pairs {
+			log.Printf("Shadowing %s with %s", primary, shadow)
+		}
+	}
+
+	// Back off keys that keep retrying while denied; state is shared by every algorithm
+	var penalties *algorithms.Penalties
+	if cfg.Penalty.Enabled {
+		penalties, err = algorithms.NewPenalties(storeInstance, algorithms.PenaltyConfig{
+			Threshold: cfg.Penalty.Threshold,
+			Window:    cfg.Penalty.Window,
+			MaxLevel:  cfg.Penalty.MaxLevel,
+			Decay:     cfg.Penalty.Decay,
+		})
+		if err != nil {
+			log.Fatalf("Invalid penalty configuration: %v", err)
+		}
+		for name, l := range limiters {
+			limiters[name] = algorithms.NewPenaltyLimiter(l, penalties)
+		}
+		for _, tl := range tierLimiters {
+			for name, l := range tl {
+				tl[name] = algorithms.NewPenaltyLimiter(l, penalties)
+			}
+		}
+		log.Printf("Penalties enabled (threshold=%d per %s, max level=%d)", cfg.Penalty.Threshold, cfg.Penalty.Window, cfg.Penalty.MaxLevel)
+	}
+
+	// Enforce only a percentage of keys while rolling out limits, shadowing the rest
+	var rollout *algorithms.Rollout
+	if cfg.Rollout.Enabled {
+		rollout, err = algorithms.NewRollout(cfg.Rollout.Percent)
+		if err != nil {
+			log.Fatalf("Invalid rollout configuration: %v", err)
+		}
+		for name, l := range limiters {
+			limiters[name] = algorithms.NewRolloutLimiter(l, rollout)
+		}
+		for _, tl := range tierLimiters {
+			for name, l := range tl {
+				tl[name] = algorithms.NewRolloutLimiter(l, rollout)
+			}
+		}
+		log.Printf("Enforcement rollout enabled at %.2f%% of keys", rollout.Percent())
+	}
+
+	// Answer repeat denials of keys over their limit locally, sparing the store on hot keys
+	if cfg.DenyCache.Enabled {
+		wrap := func(name string, l limiter.RateLimiter) limiter.RateLimiter {
+			cached, err := algorithms.NewDenyCache(l, algorithms.DenyCacheConfig{
+				Staleness: cfg.DenyCache.Staleness,
+				Metrics:   metricsInstance,
+				Algorithm: name,
+			})
+			if err != nil {
+				log.Fatalf("Invalid deny cache configuration: %v", err)
+			}
+			return cached
+		}
+		for name, l := range limiters {
+			limiters[name] = wrap(name, l)
+		}
+		for _, tl := range tierLimiters {
+			for name, l := range tl {
+				tl[name] = wrap(name, l)
+			}
+		}
+		log.Printf("Deny cache enabled (staleness=%s)", cfg.DenyCache.Staleness)
+	}
+
+	// Let checks through while the store errors instead of failing them; wrapped outside the
+	// layers above so their store calls fail open too
+	if cfg.FailureMode == algorithms.FailOpen {
+		wrap := func(name string, l limiter.RateLimiter) limiter.RateLimiter {
+			return algorithms.NewFailOpenLimiter(l, algorithms.FailOpenConfig{
+				Metrics:   metricsInstance,
+				Algorithm: name,
+			})
+		}
+		for name, l := range limiters {
+			limiters[name] = wrap(name, l)
+		}
+		for _, tl := range tierLimiters {
+			for name, l := range tl {
+				tl[name] = wrap(name, l)
+			}
+		}
+		log.Println("Failure mode fail_open: checks are allowed while the store errors")
+	}
+
+	// Shrink per-key limits on downstream failures reported to /v1/feedback, growing them back on success
+	// Wrapped last so the feedback handler can reach the adaptive layer
+	if cfg.Adaptive.Enabled {
+		adaptiveConfig := algorithms.AdaptiveConfig{
+			Floor:    cfg.Adaptive.Floor,
+			Ceiling:  cfg.Adaptive.Ceiling,
+			Increase: cfg.Adaptive.Increase,
+			Decrease: cfg.Adaptive.Decrease,
+		}
+		for name, l := range limiters {
+			limiters[name], err = algorithms.NewAdaptiveLimiter(l, adaptiveConfig)
+			if err != nil {
+				log.Fatalf("Invalid adaptive configuration: %v", err)
+			}
+		}
+		log.Printf("Adaptive limits enabled (floor=%d, ceiling=%d)", adaptiveConfig.Floor, adaptiveConfig.Ceiling)
+	}
 
 	// Set Gin mode
 	if os.Getenv("GIN_MODE") == "" {
@@ -93,17 +335,212 @@ func main() {
 	router.Use(gin.Recovery())
 
 	// Create handlers
-	handler := handlers.NewRateLimitHandler(limiters, metricsInstance, cfg.Algorithms.Default)
+	headerConfig := handlers.HeaderConfig{
+		Disabled: cfg.Server.Headers.Disabled,
+		Include:  cfg.Server.Headers.Include,
+		Budget:   cfg.Server.Headers.Budget,
+		Priority: cfg.Server.Headers.Priority,
+		Style:    cfg.Server.Headers.Style,
+	}
+	if err := headerConfig.Validate(); err != nil {
+		log.Fatalf("Invalid header configuration: %v", err)
+	}
+	costs := handlers.Costs(cfg.Limits.Costs)
+	if err := costs.Validate(); err != nil {
+		log.Fatalf("Invalid resource costs: %v", err)
+	}
+	weights := handlers.Weights(cfg.Limits.Weights)
+	if err := weights.Validate(); err != nil {
+		log.Fatalf("Invalid resource weights: %v", err)
+	}
+	keyScheme, err := keys.ParseScheme(cfg.Server.KeyScheme)
+	if err != nil {
+		log.Fatalf("Invalid key scheme: %v", err)
+	}
+	// Both APIs cap counts alike
+	maxCount := cfg.Limits.MaxCount
+	if maxCount == 0 {
+		maxCount = handlers.DefaultMaxCount
+	}
+	handlerOpts := []handlers.Option{
+		handlers.WithKeyScheme(keyScheme),
+		handlers.WithStore(storeInstance),
+		handlers.WithHeaders(headerConfig),
+		handlers.WithSoftLimits(softLimits(cfg.Limits)),
+		handlers.WithTierLimiters(tierLimiters),
+		handlers.WithCosts(costs),
+		handlers.WithWeights(weights),
+		handlers.WithMaxCount(maxCount),
+		handlers.WithMetadata(handlers.MetadataConfig{
+			MaxKeys:  cfg.Metadata.MaxKeys,
+			MaxBytes: cfg.Metadata.MaxBytes,
+			Redact:   cfg.Metadata.Redact,
+		}),
+	}
+	if cfg.Dedup.Enabled {
+		// A limit of 1 per payload hash rejects identical resubmissions; the log measures the
+		// window from the first submission, where a fixed window would reset on its boundary
+		dedupLimiter := algorithms.NewSlidingWindowLog(storeInstance, limiter.Config{
+			Limit:  1,
+			Window: cfg.Dedup.Window,
+		})
+		handlerOpts = append(handlerOpts, handlers.WithDedup(dedupLimiter, cfg.Dedup.Fields))
+		log.Printf("Duplicate request guard enabled (window=%s)", cfg.Dedup.Window)
+	}
+	if cfg.RequestIDs.Enabled {
+		recorder, ok := storeInstance.(limiter.RequestRecorder)
+		if !ok {
+			log.Fatalf("Request IDs require a store that can record responses (memory or redis)")
+		}
+		handlerOpts = append(handlerOpts, handlers.WithRequestIDs(recorder, cfg.RequestIDs.TTL))
+		log.Printf("Idempotent request IDs enabled (ttl=%s)", cfg.RequestIDs.TTL)
+	}
+	if rollout != nil {
+		handlerOpts = append(handlerOpts, handlers.WithRollout(rollout))
+	}
+	if penalties != nil {
+		handlerOpts = append(handlerOpts, handlers.WithPenalties(penalties))
+	}
 
-	// Register routes
-	v1 := router.Group("/v1")
-	{
-		v1.POST("/check", handler.Check)
-		v1.GET("/status/:key", handler.GetStatus)
-		v1.POST("/reset/:key", handler.Reset)
+	// Deny keys that keep being denied outright for a while, sparing the limiters
+	if cfg.Ban.Enabled {
+		bans, err := algorithms.NewBans(storeInstance, algorithms.BanConfig{
+			Threshold: cfg.Ban.Threshold,
+			Window:    cfg.Ban.Window,
+			Duration:  cfg.Ban.Duration,
+			Allowlist: cfg.Ban.Allowlist,
+			Metrics:   metricsInstance,
+		})
+		if err != nil {
+			log.Fatalf("Invalid ban configuration: %v", err)
+		}
+		go bans.Watch(context.Background(), time.Minute)
+		handlerOpts = append(handlerOpts, handlers.WithBans(bans))
+		log.Printf("Auto-bans enabled (more than %d denials per %s bans for %s)", cfg.Ban.Threshold, cfg.Ban.Window, cfg.Ban.Duration)
+	}
+
+	if overrides != nil {
+		handlerOpts = append(handlerOpts, handlers.WithOverrides(overrides))
+	}
+
+	// Let several identifiers draw from one limit; the mapping lives in the store so every
+	// instance shares it and changes apply without a restart
+	if cfg.KeyGroups.Enabled {
+		keyGroups, ok := storeInstance.(limiter.KeyGroupStore)
+		if !ok {
+			log.Fatalf("Key groups require a store that can keep them (memory or redis)")
+		}
+		for group, identifiers := range cfg.KeyGroups.Groups {
+			for _, identifier := range identifiers {
+				if err := keyGroups.SetKeyGroupCtx(context.Background(), identifier, group); err != nil {
+					log.Fatalf("Failed to set key group of %s: %v", identifier, err)
+				}
+			}
+		}
+		handlerOpts = append(handlerOpts, handlers.WithKeyGroups(keyGroups))
+		log.Printf("Key groups enabled (%d configured)", len(cfg.KeyGroups.Groups))
+	}
+
+	// Hold quota for long-running work until it is committed or canceled
+	if cfg.Reserve.Enabled {
+		reservationStore, ok := storeInstance.(limiter.ReservationStore)
+		if !ok {
+			log.Fatalf("Reservations require a store that can hold them (memory or redis)")
+		}
+		handlerOpts = append(handlerOpts, handlers.WithReservations(reservationStore, cfg.Reserve.TTL))
+	}
+	if cfg.Server.ClientTimestamps {
+		handlerOpts = append(handlerOpts, handlers.WithClientTimestamps())
+		log.Println("Checks may carry a timestamp to be evaluated at")
 	}
 
-	router.GET("/health", handler.Health)
+	if cfg.Pools.Enabled {
+		tiers := make(map[string]algorithms.PoolPolicy, len(cfg.Pools.Tiers))
+		for name, tier := range cfg.Pools.Tiers {
+			tiers[name] = algorithms.PoolPolicy{
+				Limit:        tier.Limit,
+				Window:       tier.Window,
+				SeatFraction: tier.SeatFraction,
+			}
+		}
+		pool, err := algorithms.NewPool(storeInstance, tiers)
+		if err != nil {
+			log.Fatalf("Invalid pool configuration: %v", err)
+		}
+		handlerOpts = append(handlerOpts, handlers.WithPool(pool))
+		log.Printf("Pooled quotas enabled (%d tiers)", len(tiers))
+	}
+
+	// Org-wide limits checks can nest under with parent_identifier
+	if cfg.Hierarchy.Enabled {
+		parents, err := newLimiters(storeInstance, cfg.Hierarchy.Parent)
+		if err != nil {
+			log.Fatalf("Invalid hierarchy parent limits: %v", err)
+		}
+		handlerOpts = append(handlerOpts, handlers.WithParentLimiters(parents))
+		log.Printf("Hierarchical limits enabled (parent=%d/%s)", cfg.Hierarchy.Parent.Requests, cfg.Hierarchy.Parent.Window)
+	}
+
+	if cfg.History.Enabled {
+		history := store.NewHistory(storeInstance, cfg.History.Window)
+		handlerOpts = append(handlerOpts, handlers.WithHistory(history))
+		log.Printf("Usage history enabled (window=%s)", cfg.History.Window)
+	}
+
+	if cfg.Priority.Enabled {
+		priorities := handlers.Priorities(cfg.Priority.Reserve)
+		if err := priorities.Validate(); err != nil {
+			log.Fatalf("Invalid priority reserves: %v", err)
+		}
+		handlerOpts = append(handlerOpts, handlers.WithPriorities(priorities))
+		log.Printf("Priority classes enabled (normal reserve=%v, low reserve=%v)",
+			priorities[algorithms.PriorityNormal], priorities[algorithms.PriorityLow])
+	}
+
+	// Monthly (or daily, hourly) quotas on top of the window limits
+	if cfg.Quota.Enabled {
+		quota, err := algorithms.NewQuotaLimiter(storeInstance, cfg.Quota.Requests, cfg.Quota.Period)
+		if err != nil {
+			log.Fatalf("Invalid quota configuration: %v", err)
+		}
+		handlerOpts = append(handlerOpts, handlers.WithQuota(quota))
+		log.Printf("Quota enabled (%d requests per %s)", cfg.Quota.Requests, cfg.Quota.Period)
+	}
+
+	if cfg.UI.Enabled {
+		// Keep the password out of the config file where possible
+		uiConfig := handlers.UIConfig{Username: cfg.UI.Username, Password: cfg.UI.Password}
+		if v := os.Getenv("RATE_LIMITER_UI_PASSWORD"); v != "" {
+			uiConfig.Password = v
+		}
+		if err := uiConfig.Validate(); err != nil {
+			log.Fatalf("Invalid ui configuration: %v", err)
+		}
+		handlerOpts = append(handlerOpts, handlers.WithUI(uiConfig))
+		log.Println("Operator UI enabled at /ui")
+	}
+
+	// Read-only mode for incident response; the environment overrides the config file
+	readOnly := cfg.ReadOnly.Enabled
+	if v := os.Getenv("RATE_LIMITER_READ_ONLY"); v != "" {
+		readOnly, err = strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid RATE_LIMITER_READ_ONLY value %q: %v", v, err)
+		}
+	}
+	if err := handlers.ValidateBias(cfg.ReadOnly.Bias); err != nil {
+		log.Fatalf("Invalid read-only configuration: %v", err)
+	}
+	handlerOpts = append(handlerOpts, handlers.WithReadOnly(readOnly, cfg.ReadOnly.Bias))
+	if readOnly {
+		log.Printf("Starting in read-only mode (bias=%s)", cfg.ReadOnly.Bias)
+	}
+
+	handler := handlers.NewRateLimitHandler(limiters, metricsInstance, cfg.Algorithms.Default, handlerOpts...)
+	go handler.WatchReservations(context.Background(), time.Second)
+
+	// Register routes
+	handler.RegisterRoutes(router)
 
 	// Metrics endpoint
 	if cfg.Metrics.Enabled {
@@ -111,6 +548,58 @@ func main() {
 		log.Printf("Metrics enabled at %s", cfg.Metrics.Path)
 	}
 
+	// Configured limits are cluster-wide; with discovery and per-instance counts each instance
+	// enforces its share, following peer count changes over the ramp period
+	scale := func(c limiter.Config) limiter.Config { return c }
+	var limitsMu sync.Mutex
+	limits := cfg.Limits
+	if discoverer != nil && cfg.ScalesLimits() {
+		scale = discoverer.ScaleConfig
+		rescale := func() {
+			limitsMu.Lock()
+			defer limitsMu.Unlock()
+			if err := scaleLimits(limiters, tierLimiters, limits, scale); err != nil {
+				log.Printf("Failed to scale limits: %v", err)
+			}
+		}
+		rescale()
+		discoverer.Follow(discoveryCtx, time.Second, rescale)
+		log.Printf("Scaling limits across %.0f instances", discoverer.EffectiveInstances())
+	}
+
+	// Apply edited limits without a restart
+	if cfg.Reload.Enabled {
+		current := *cfg
+		watcher, err := config.Watch(configFile, func(next *config.Config) {
+			limitsMu.Lock()
+			defer limitsMu.Unlock()
+
+			// Only default and tier limits are applied in place
+			rest := *next
+			rest.Limits.Default, rest.Limits.Tiers = current.Limits.Default, current.Limits.Tiers
+			if !reflect.DeepEqual(rest, current) {
+				log.Println("Config file changed settings other than limits; they apply after a restart")
+				current = rest
+			}
+			if reflect.DeepEqual(next.Limits.Default, current.Limits.Default) && reflect.DeepEqual(next.Limits.Tiers, current.Limits.Tiers) {
+				return
+			}
+			if err := reloadLimits(limiters, tierLimiters, current.Limits, next.Limits, scale); err != nil {
+				log.Printf("Keeping previous limits: %v", err)
+				return
+			}
+			limits = next.Limits
+			handler.UpdateSoftLimits(softLimits(next.Limits))
+			current.Limits.Default, current.Limits.Tiers = next.Limits.Default, next.Limits.Tiers
+			log.Printf("Reloaded limits (default=%d/%s)", next.Limits.Default.Requests, next.Limits.Default.Window)
+		})
+		if err != nil {
+			log.Fatalf("Failed to watch config file: %v", err)
+		}
+		defer watcher.Close()
+		log.Printf("Reloading limits when %s changes", configFile)
+	}
+
 	// Create HTTP server
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
 	srv := &http.Server{
@@ -129,6 +618,27 @@ func main() {
 		}
 	}()
 
+	// Serve the same limiters over gRPC
+	var grpcSrv *grpc.Server
+	if cfg.GRPC.Enabled {
+		grpcAddr := fmt.Sprintf(":%d", cfg.GRPC.Port)
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC on %s: %v", grpcAddr, err)
+		}
+		grpcSrv = grpc.NewServer()
+		grpcserver.NewServer(limiters, metricsInstance, cfg.Algorithms.Default,
+			grpcserver.WithReadOnly(handler.IsReadOnly),
+			grpcserver.WithKeyScheme(keyScheme),
+			grpcserver.WithMaxCount(maxCount)).Register(grpcSrv)
+		go func() {
+			log.Printf("Starting gRPC server on %s", grpcAddr)
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Fatalf("Failed to start gRPC server: %v", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -143,6 +653,337 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+	if grpcSrv != nil {
+		grpcSrv.GracefulStop()
+	}
+
+	// No requests are in flight any more; release the limiters and the store they share
+	sets := []map[string]limiter.RateLimiter{limiters}
+	for _, tl := range tierLimiters {
+		sets = append(sets, tl)
+	}
+	if err := closeLimiters(sets...); err != nil {
+		log.Printf("Failed to close limiters: %v", err)
+	}
 
 	log.Println("Server stopped")
 }
+
+// closeLimiters closes every limiter in sets; wrappers close what they wrap, so limiters
+// shared between sets are closed more than once, which Close allows
+func closeLimiters(sets ...map[string]limiter.RateLimiter) error {
+	var errs []error
+	for _, set := range sets {
+		for name, l := range set {
+			if err := l.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateLimitConfig rejects limits the algorithm constructors cannot enforce
+func validateLimitConfig(lc config.LimitConfig) error {
+	if len(lc.Windows) == 0 && (lc.Requests <= 0 || lc.Window <= 0) {
+		return fmt.Errorf("requests and window must be positive, got %d/%s", lc.Requests, lc.Window)
+	}
+	if fill := lc.InitialFill; fill != nil && (*fill < 0 || *fill > 1) {
+		return fmt.Errorf("initial_fill %v must be between 0 and 1", *fill)
+	}
+	if tokens := lc.InitialTokens; tokens != nil && *tokens < 0 {
+		return fmt.Errorf("initial_tokens must not be negative, got %d", *tokens)
+	}
+	switch lc.Precision {
+	case "", limiter.PrecisionFloat, limiter.PrecisionFixed:
+	default:
+		return fmt.Errorf("precision %q must be %q or %q", lc.Precision, limiter.PrecisionFloat, limiter.PrecisionFixed)
+	}
+	if lc.MaxDebt < 0 {
+		return fmt.Errorf("max_debt must not be negative, got %d", lc.MaxDebt)
+	}
+	if lc.MaxDebt > 0 && lc.Precision == limiter.PrecisionFixed {
+		return fmt.Errorf("max_debt requires %q precision", limiter.PrecisionFloat)
+	}
+	if err := validateAlignment(lc); err != nil {
+		return err
+	}
+	if err := validateBuckets(lc); err != nil {
+		return err
+	}
+	switch lc.WeightMode {
+	case "", limiter.WeightLinear, limiter.WeightExponential, limiter.WeightNone:
+	default:
+		return fmt.Errorf("weight_mode %q must be %q, %q or %q", lc.WeightMode,
+			limiter.WeightLinear, limiter.WeightExponential, limiter.WeightNone)
+	}
+	if lc.ResetJitter != 0 && lc.ResetJitter < time.Second {
+		return fmt.Errorf("reset_jitter %s must be at least 1s", lc.ResetJitter)
+	}
+	for _, window := range limitWindows(lc) {
+		if lc.ResetJitter >= window {
+			return fmt.Errorf("reset_jitter %s must be less than the window %s", lc.ResetJitter, window)
+		}
+	}
+	if lc.SoftLimit < 0 || lc.SoftLimit > 100 {
+		return fmt.Errorf("soft_limit %v must be a percentage between 0 and 100", lc.SoftLimit)
+	}
+	if lc.JitterWindows && lc.ResetJitter == 0 {
+		return fmt.Errorf("jitter_windows requires reset_jitter")
+	}
+	return nil
+}
+
+// validateAlignment checks that a calendar alignment's window is the length of its unit,
+// so algorithms without calendar windows enforce roughly the same limit
+func validateAlignment(lc config.LimitConfig) error {
+	const day = 24 * time.Hour
+	switch lc.Alignment {
+	case "", limiter.AlignEpoch:
+		return nil
+	case limiter.AlignHour, limiter.AlignDay, limiter.AlignMonth:
+	default:
+		return fmt.Errorf("alignment %q must be %q, %q, %q or %q", lc.Alignment,
+			limiter.AlignEpoch, limiter.AlignHour, limiter.AlignDay, limiter.AlignMonth)
+	}
+	if len(lc.Windows) > 0 {
+		return fmt.Errorf("alignment %q is not supported with multiple windows", lc.Alignment)
+	}
+
+	ok := false
+	switch lc.Alignment {
+	case limiter.AlignHour:
+		ok = lc.Window == time.Hour
+	case limiter.AlignDay:
+		ok = lc.Window == day
+	case limiter.AlignMonth:
+		ok = lc.Window >= 28*day && lc.Window <= 31*day
+	}
+	if !ok {
+		return fmt.Errorf("window %s does not match %q alignment", lc.Window, lc.Alignment)
+	}
+	return nil
+}
+
+// validateBuckets checks that sliding window buckets evenly divide every window
+// Redis keys buckets by the second, so a bucket must be at least one
+func validateBuckets(lc config.LimitConfig) error {
+	if lc.Buckets < 0 {
+		return fmt.Errorf("buckets must not be negative, got %d", lc.Buckets)
+	}
+	if lc.Buckets <= 1 {
+		return nil
+	}
+
+	for _, window := range limitWindows(lc) {
+		if window%time.Duration(lc.Buckets) != 0 || window/time.Duration(lc.Buckets)%time.Second != 0 {
+			return fmt.Errorf("window %s does not divide into %d whole-second buckets", window, lc.Buckets)
+		}
+	}
+	return nil
+}
+
+// limitWindows returns every window lc enforces
+func limitWindows(lc config.LimitConfig) []time.Duration {
+	if len(lc.Windows) == 0 {
+		return []time.Duration{lc.Window}
+	}
+	windows := make([]time.Duration, len(lc.Windows))
+	for i, w := range lc.Windows {
+		windows[i] = w.Window
+	}
+	return windows
+}
+
+// validateShadowPairs checks that every shadow pair names two different configured algorithms
+func validateShadowPairs(limiters map[string]limiter.RateLimiter, pairs map[string]string) error {
+	if len(pairs) == 0 {
+		return fmt.Errorf("no algorithm pairs configured")
+	}
+	for primary, shadow := range pairs {
+		if _, ok := limiters[primary]; !ok {
+			return fmt.Errorf("unknown algorithm %q", primary)
+		}
+		if _, ok := limiters[shadow]; !ok {
+			return fmt.Errorf("unknown shadow algorithm %q for %q", shadow, primary)
+		}
+		if primary == shadow {
+			return fmt.Errorf("algorithm %q can not shadow itself", primary)
+		}
+	}
+	return nil
+}
+
+// shadowLimiters wraps each primary in limiters to be compared against its pair
+// Shadows are the unwrapped limiters, so a shadow that is itself a primary is not compared twice
+func shadowLimiters(limiters map[string]limiter.RateLimiter, pairs map[string]string, m *metrics.Metrics) {
+	bases := maps.Clone(limiters)
+	for primary, shadow := range pairs {
+		limiters[primary] = algorithms.NewShadowLimiter(bases[primary], bases[shadow], algorithms.ShadowConfig{
+			Primary: primary,
+			Shadow:  shadow,
+			Metrics: m,
+		})
+	}
+}
+
+// newDynamoStore connects to the configured DynamoDB table and checks that it is reachable
+func newDynamoStore(dc config.DynamoDBConfig) (*store.DynamoStore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if dc.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(dc.Region))
+	}
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := dynamodb.NewFromConfig(awsConfig, func(o *dynamodb.Options) {
+		if dc.Endpoint != "" {
+			o.BaseEndpoint = aws.String(dc.Endpoint)
+		}
+	})
+	ds := store.NewDynamoStore(dc.Table, client, store.WithDynamoTTL(dc.TTL))
+	if err := ds.Ping(ctx); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// newLimiters builds one limiter per registered algorithm enforcing lc
+// With lc.Windows set, each algorithm enforces every window through a composite limiter
+func newLimiters(s limiter.Store, lc config.LimitConfig) (map[string]limiter.RateLimiter, error) {
+	base := limitConfig(lc)
+
+	names := limiter.Algorithms()
+	limiters := make(map[string]limiter.RateLimiter, len(names))
+	for _, name := range names {
+		build := func(c limiter.Config) (limiter.RateLimiter, error) {
+			c.Algorithm = name
+			return limiter.New(s, c)
+		}
+		if len(lc.Windows) == 0 {
+			l, err := build(base)
+			if err != nil {
+				return nil, err
+			}
+			limiters[name] = l
+			continue
+		}
+
+		configs := make([]limiter.Config, len(lc.Windows))
+		for i, w := range lc.Windows {
+			configs[i] = base
+			configs[i].Limit = w.Requests
+			configs[i].Window = w.Window
+			configs[i].Burst = w.Burst
+		}
+		composite, err := algorithms.NewCompositeLimiter(configs, build)
+		if err != nil {
+			return nil, err
+		}
+		limiters[name] = composite
+	}
+	return limiters, nil
+}
+
+// limitConfig is the policy lc asks every algorithm to enforce
+func limitConfig(lc config.LimitConfig) limiter.Config {
+	return limiter.Config{
+		Limit:         lc.Requests,
+		Window:        lc.Window,
+		Burst:         lc.Burst,
+		InitialFill:   lc.InitialFill,
+		InitialTokens: lc.InitialTokens,
+		Precision:     lc.Precision,
+		MaxDebt:       lc.MaxDebt,
+		Alignment:     lc.Alignment,
+		Buckets:       lc.Buckets,
+		WeightMode:    lc.WeightMode,
+		WindowBurst:   lc.WindowBurst,
+		ResetJitter:   lc.ResetJitter,
+		JitterWindows: lc.JitterWindows,
+	}
+}
+
+// softLimits collects the soft limit percentage of the default limits and of each tier
+func softLimits(lc config.LimitsConfig) handlers.SoftLimits {
+	softLimits := handlers.SoftLimits{"": lc.Default.SoftLimit}
+	for name, tc := range lc.Tiers {
+		softLimits[name] = tc.SoftLimit
+	}
+	return softLimits
+}
+
+// reloadLimits switches the running default and tier limiters to next's limits, passed through scale
+// Everything is validated before any limiter changes, so an invalid file changes nothing
+func reloadLimits(limiters map[string]limiter.RateLimiter, tierLimiters map[string]map[string]limiter.RateLimiter, current, next config.LimitsConfig, scale func(limiter.Config) limiter.Config) error {
+	if err := validateReload(current.Default, next.Default); err != nil {
+		return fmt.Errorf("default limits: %w", err)
+	}
+	if len(next.Tiers) != len(tierLimiters) {
+		return fmt.Errorf("adding or removing tiers requires a restart")
+	}
+	for name, tc := range next.Tiers {
+		if _, ok := tierLimiters[name]; !ok {
+			return fmt.Errorf("adding or removing tiers requires a restart")
+		}
+		if err := validateReload(current.Tiers[name], tc); err != nil {
+			return fmt.Errorf("limits for tier %q: %w", name, err)
+		}
+	}
+
+	if err := updateLimiters(limiters, next.Default, scale); err != nil {
+		return err
+	}
+	for name, tl := range tierLimiters {
+		if err := updateLimiters(tl, next.Tiers[name], scale); err != nil {
+			return fmt.Errorf("tier %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// scaleLimits reapplies lc to the running default and tier limiters through scale
+// Config validation keeps multi-window limits, which can not be reconfigured, out of scaled configs
+func scaleLimits(limiters map[string]limiter.RateLimiter, tierLimiters map[string]map[string]limiter.RateLimiter, lc config.LimitsConfig, scale func(limiter.Config) limiter.Config) error {
+	if err := updateLimiters(limiters, lc.Default, scale); err != nil {
+		return err
+	}
+	for name, tl := range tierLimiters {
+		if err := updateLimiters(tl, lc.Tiers[name], scale); err != nil {
+			return fmt.Errorf("tier %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validateReload checks that a running limit can be switched from current to next in place
+// Multi-window limits are built as composites of one limiter per window and are rebuilt only on restart
+func validateReload(current, next config.LimitConfig) error {
+	if err := validateLimitConfig(next); err != nil {
+		return err
+	}
+	if len(current.Windows) > 0 || len(next.Windows) > 0 {
+		return fmt.Errorf("changing multi-window limits requires a restart")
+	}
+	return nil
+}
+
+// updateLimiters switches every algorithm in limiters to lc passed through scale
+func updateLimiters(limiters map[string]limiter.RateLimiter, lc config.LimitConfig, scale func(limiter.Config) limiter.Config) error {
+	c := scale(limitConfig(lc))
+	for name, l := range limiters {
+		reconfigurer, ok := l.(limiter.Reconfigurer)
+		if !ok {
+			return fmt.Errorf("%s can not be reconfigured", name)
+		}
+		if err := reconfigurer.UpdateConfig(c); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
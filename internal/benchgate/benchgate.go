@@ -0,0 +1,235 @@
+// Package benchgate compares benchmark runs against a committed baseline and
+// reports regressions in time or allocations per operation
+package benchgate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Result is one benchmark's summarized cost per operation
+type Result struct {
+	NsPerOp     float64 `json:"ns_per_op"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+}
+
+// Baseline is the committed reference the gate compares against
+type Baseline struct {
+	GOMAXPROCS int               `json:"gomaxprocs"`
+	Benchmarks map[string]Result `json:"benchmarks"`
+}
+
+// Samples holds every iteration's measurements for one benchmark
+type Samples struct {
+	NsPerOp     []float64
+	AllocsPerOp []float64
+}
+
+// Tolerance is the relative increase over the baseline each metric may show, e.g. 0.1 for 10%
+type Tolerance struct {
+	NsPerOp     float64
+	AllocsPerOp float64
+}
+
+// Regression is one benchmark that got worse than the tolerance allows, or did not run
+type Regression struct {
+	Name     string
+	Metric   string // "ns/op", "allocs/op" or "missing"
+	Baseline float64
+	Current  float64
+}
+
+// String describes the regression for the gate's report
+func (r Regression) String() string {
+	if r.Metric == "missing" {
+		return fmt.Sprintf("%s: in baseline but not run", r.Name)
+	}
+	return fmt.Sprintf("%s: %s %.1f -> %.1f (%+.1f%%)", r.Name, r.Metric, r.Baseline, r.Current, 100*(r.Current-r.Baseline)/r.Baseline)
+}
+
+// benchLine matches `go test -bench -benchmem` result lines; the name's -GOMAXPROCS suffix is dropped
+var benchLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+([\d.]+) ns/op(?:.*?\s([\d.]+) allocs/op)?`)
+
+// Parse collects the samples of every benchmark in go test -bench output
+// Benchmarks run with -count N contribute N samples each
+func Parse(r io.Reader) (map[string]*Samples, error) {
+	samples := make(map[string]*Samples)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := benchLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+
+		ns, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad ns/op in %q: %w", scanner.Text(), err)
+		}
+
+		s, ok := samples[m[1]]
+		if !ok {
+			s = &Samples{}
+			samples[m[1]] = s
+		}
+		s.NsPerOp = append(s.NsPerOp, ns)
+
+		if m[3] != "" {
+			allocs, err := strconv.ParseFloat(m[3], 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad allocs/op in %q: %w", scanner.Text(), err)
+			}
+			s.AllocsPerOp = append(s.AllocsPerOp, allocs)
+		}
+	}
+	return samples, scanner.Err()
+}
+
+// RejectOutliers drops samples outside Tukey's fences (1.5 interquartile ranges beyond the quartiles)
+// A noisy iteration, e.g. one that overlapped a GC or another process, then cannot move the median
+func RejectOutliers(values []float64) []float64 {
+	if len(values) < 4 {
+		return values
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	q1, q3 := quantile(sorted, 0.25), quantile(sorted, 0.75)
+	iqr := q3 - q1
+	low, high := q1-1.5*iqr, q3+1.5*iqr
+
+	kept := make([]float64, 0, len(sorted))
+	for _, v := range sorted {
+		if v >= low && v <= high {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// quantile interpolates the q-th quantile of sorted values
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(pos)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	frac := pos - float64(lower)
+	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
+}
+
+// median returns the middle of values, or 0 if there are none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return quantile(sorted, 0.5)
+}
+
+// Summarize reduces each benchmark's samples to the median after outlier rejection
+// Medians are rounded to 0.1 so baseline updates diff cleanly
+func Summarize(samples map[string]*Samples) map[string]Result {
+	results := make(map[string]Result, len(samples))
+	for name, s := range samples {
+		results[name] = Result{
+			NsPerOp:     round(median(RejectOutliers(s.NsPerOp))),
+			AllocsPerOp: round(median(RejectOutliers(s.AllocsPerOp))),
+		}
+	}
+	return results
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// Compare lists the benchmarks in baseline that regressed beyond tol in current, sorted by name
+// Benchmarks only in current are not regressions; they enter the baseline on the next update
+func Compare(baseline Baseline, current map[string]Result, tol Tolerance) []Regression {
+	var regressions []Regression
+	for _, name := range names(baseline.Benchmarks) {
+		base := baseline.Benchmarks[name]
+		cur, ok := current[name]
+		if !ok {
+			regressions = append(regressions, Regression{Name: name, Metric: "missing"})
+			continue
+		}
+		if exceeds(base.NsPerOp, cur.NsPerOp, tol.NsPerOp) {
+			regressions = append(regressions, Regression{Name: name, Metric: "ns/op", Baseline: base.NsPerOp, Current: cur.NsPerOp})
+		}
+		if exceeds(base.AllocsPerOp, cur.AllocsPerOp, tol.AllocsPerOp) {
+			regressions = append(regressions, Regression{Name: name, Metric: "allocs/op", Baseline: base.AllocsPerOp, Current: cur.AllocsPerOp})
+		}
+	}
+	return regressions
+}
+
+// exceeds reports whether current is more than tol above base
+// A zero base only regresses if current is non-zero, since no relative increase is defined
+func exceeds(base, current, tol float64) bool {
+	if base == 0 {
+		return current > 0
+	}
+	return current > base*(1+tol)
+}
+
+// names returns the sorted benchmark names in results
+func names(results map[string]Result) []string {
+	out := make([]string, 0, len(results))
+	for name := range results {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Added lists benchmarks in current that the baseline does not cover yet
+func Added(baseline Baseline, current map[string]Result) []string {
+	var added []string
+	for _, name := range names(current) {
+		if _, ok := baseline.Benchmarks[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	return added
+}
+
+// LoadBaseline reads a baseline written by WriteBaseline
+func LoadBaseline(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Baseline{}, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return Baseline{}, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// WriteBaseline writes b as indented JSON so baseline updates review well
+func WriteBaseline(path string, b Baseline) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Pattern builds a -bench regexp that matches exactly the named benchmarks
+func Pattern(benchmarks []string) string {
+	quoted := make([]string, len(benchmarks))
+	for i, name := range benchmarks {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
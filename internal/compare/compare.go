@@ -0,0 +1,268 @@
+// Package compare replays one workload against two limiter configurations and
+// reports where their decisions diverge, to dry-run algorithm or store migrations
+package compare
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+)
+
+// Target is one (algorithm, store) configuration under comparison
+type Target struct {
+	Algorithm string `json:"algorithm"`
+	Store     string `json:"store"`                // "memory" or "redis"
+	RedisAddr string `json:"redis_addr,omitempty"` // Required for the redis store
+}
+
+// String returns "algorithm/store"
+func (t Target) String() string {
+	return t.Algorithm + "/" + t.Store
+}
+
+// Report describes how two targets' decisions differ over the same events
+type Report struct {
+	A               Target          `json:"a"`
+	B               Target          `json:"b"`
+	Events          int             `json:"events"`
+	Divergences     int             `json:"divergences"`
+	AOnlyAllowed    int             `json:"a_only_allowed"`
+	BOnlyAllowed    int             `json:"b_only_allowed"`
+	Errors          int             `json:"errors"`
+	FirstDivergence *Divergence     `json:"first_divergence"`
+	RemainingDrift  Stats           `json:"remaining_drift"`
+	RetryAfterDiff  Stats           `json:"retry_after_diff_ms"` // Over events both targets denied
+	Timeline        []TimelinePoint `json:"timeline"`
+	Keys            []KeyReport     `json:"keys"` // Worst first
+}
+
+// Divergence is one event the targets decided differently
+type Divergence struct {
+	Index      int                 `json:"index"`
+	At         simulation.Duration `json:"at"`
+	Key        string              `json:"key"`
+	AAllowed   bool                `json:"a_allowed"`
+	BAllowed   bool                `json:"b_allowed"`
+	ARemaining int                 `json:"a_remaining"`
+	BRemaining int                 `json:"b_remaining"`
+}
+
+// Stats summarizes absolute differences
+type Stats struct {
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+// TimelinePoint aggregates differences over one bucket of simulated time
+type TimelinePoint struct {
+	At                simulation.Duration `json:"at"`
+	Events            int                 `json:"events"`
+	Divergences       int                 `json:"divergences"`
+	MaxRemainingDrift int                 `json:"max_remaining_drift"`
+}
+
+// KeyReport is the worst case seen for one key
+type KeyReport struct {
+	Key                 string  `json:"key"`
+	Events              int     `json:"events"`
+	Divergences         int     `json:"divergences"`
+	MaxRemainingDrift   int     `json:"max_remaining_drift"`
+	MaxRetryAfterDiffMs float64 `json:"max_retry_after_diff_ms"`
+}
+
+// Run replays events against both targets under config and diffs the decisions
+// Each target gets a fresh store and a manual clock so runs are deterministic
+// bucket sets the timeline resolution; zero uses config.Window
+func Run(ctx context.Context, a, b Target, config limiter.Config, start time.Time, events []simulation.Event, bucket time.Duration) (*Report, error) {
+	decisionsA, err := replay(ctx, a, config, start, events)
+	if err != nil {
+		return nil, fmt.Errorf("target A (%s): %w", a, err)
+	}
+	decisionsB, err := replay(ctx, b, config, start, events)
+	if err != nil {
+		return nil, fmt.Errorf("target B (%s): %w", b, err)
+	}
+
+	if bucket <= 0 {
+		bucket = config.Window
+	}
+
+	report := Diff(decisionsA, decisionsB, bucket)
+	report.A = a
+	report.B = b
+	return report, nil
+}
+
+// replay runs events against a freshly built target
+func replay(ctx context.Context, t Target, config limiter.Config, start time.Time, events []simulation.Event) ([]simulation.Decision, error) {
+	s, err := newStore(t)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	clock := simulation.NewManualClock(start)
+	rl, err := newLimiter(t.Algorithm, s, config, clock)
+	if err != nil {
+		return nil, err
+	}
+
+	return simulation.Run(ctx, rl, clock, start, events), nil
+}
+
+func newStore(t Target) (limiter.Store, error) {
+	switch t.Store {
+	case "", "memory":
+		return store.NewMemoryStore(), nil
+	case "redis":
+		if t.RedisAddr == "" {
+			return nil, fmt.Errorf("redis store needs an address")
+		}
+		return store.NewRedisStore(store.RedisConfig{Addresses: []string{t.RedisAddr}})
+	default:
+		return nil, fmt.Errorf("unknown store %q", t.Store)
+	}
+}
+
+func newLimiter(algorithm string, s limiter.Store, config limiter.Config, clock limiter.Clock) (limiter.RateLimiter, error) {
+	opt := algorithms.WithClock(clock)
+	switch algorithm {
+	case "token_bucket":
+		return algorithms.NewTokenBucket(s, config, opt), nil
+	case "sliding_window":
+		return algorithms.NewSlidingWindowCounter(s, config, opt), nil
+	case "sliding_window_log":
+		return algorithms.NewSlidingWindowLog(s, config, opt), nil
+	case "fixed_window":
+		return algorithms.NewFixedWindowCounter(s, config, opt), nil
+	case "leaky_bucket":
+		return algorithms.NewLeakyBucket(s, config, opt), nil
+	case "gcra":
+		return algorithms.NewGCRA(s, config, opt), nil
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", algorithm)
+	}
+}
+
+// Diff compares two decision sequences for the same events
+func Diff(a, b []simulation.Decision, bucket time.Duration) *Report {
+	report := &Report{Events: len(a)}
+	keys := make(map[string]*KeyReport)
+	timeline := make(map[simulation.Duration]*TimelinePoint)
+
+	var driftSum, retrySum float64
+	var retryCount int
+
+	for i := range a {
+		da, db := a[i], b[i]
+
+		kr, ok := keys[da.Key]
+		if !ok {
+			kr = &KeyReport{Key: da.Key}
+			keys[da.Key] = kr
+		}
+		kr.Events++
+
+		slot := da.At - da.At%simulation.Duration(bucket)
+		tp, ok := timeline[slot]
+		if !ok {
+			tp = &TimelinePoint{At: slot}
+			timeline[slot] = tp
+		}
+		tp.Events++
+
+		if da.Err != nil || db.Err != nil {
+			report.Errors++
+			continue
+		}
+
+		drift := abs(da.Remaining - db.Remaining)
+		driftSum += float64(drift)
+		report.RemainingDrift.Max = math.Max(report.RemainingDrift.Max, float64(drift))
+		kr.MaxRemainingDrift = max(kr.MaxRemainingDrift, drift)
+		tp.MaxRemainingDrift = max(tp.MaxRemainingDrift, drift)
+
+		if !da.Allowed && !db.Allowed {
+			diff := math.Abs(float64(da.RetryAfter-db.RetryAfter)) / float64(time.Millisecond)
+			retrySum += diff
+			retryCount++
+			report.RetryAfterDiff.Max = math.Max(report.RetryAfterDiff.Max, diff)
+			kr.MaxRetryAfterDiffMs = math.Max(kr.MaxRetryAfterDiffMs, diff)
+		}
+
+		if da.Allowed == db.Allowed {
+			continue
+		}
+
+		report.Divergences++
+		kr.Divergences++
+		tp.Divergences++
+		if da.Allowed {
+			report.AOnlyAllowed++
+		} else {
+			report.BOnlyAllowed++
+		}
+
+		if report.FirstDivergence == nil {
+			report.FirstDivergence = &Divergence{
+				Index:      i,
+				At:         da.At,
+				Key:        da.Key,
+				AAllowed:   da.Allowed,
+				BAllowed:   db.Allowed,
+				ARemaining: da.Remaining,
+				BRemaining: db.Remaining,
+			}
+		}
+	}
+
+	if compared := report.Events - report.Errors; compared > 0 {
+		report.RemainingDrift.Mean = round2(driftSum / float64(compared))
+	}
+	if retryCount > 0 {
+		report.RetryAfterDiff.Mean = round2(retrySum / float64(retryCount))
+	}
+
+	report.Timeline = make([]TimelinePoint, 0, len(timeline))
+	for _, tp := range timeline {
+		report.Timeline = append(report.Timeline, *tp)
+	}
+	sort.Slice(report.Timeline, func(i, j int) bool {
+		return report.Timeline[i].At < report.Timeline[j].At
+	})
+
+	report.Keys = make([]KeyReport, 0, len(keys))
+	for _, kr := range keys {
+		report.Keys = append(report.Keys, *kr)
+	}
+	sort.Slice(report.Keys, func(i, j int) bool {
+		ki, kj := report.Keys[i], report.Keys[j]
+		if ki.Divergences != kj.Divergences {
+			return ki.Divergences > kj.Divergences
+		}
+		if ki.MaxRemainingDrift != kj.MaxRemainingDrift {
+			return ki.MaxRemainingDrift > kj.MaxRemainingDrift
+		}
+		return ki.Key < kj.Key
+	})
+
+	return report
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
@@ -0,0 +1,56 @@
+package compare
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxSummaryKeys bounds the worst-keys section of the summary
+const maxSummaryKeys = 5
+
+// Summary renders the report for humans
+func (r *Report) Summary() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Compared %s (A) with %s (B) over %d events\n", r.A, r.B, r.Events)
+
+	pct := 0.0
+	if r.Events > 0 {
+		pct = float64(r.Divergences) / float64(r.Events) * 100
+	}
+	fmt.Fprintf(&b, "Divergent decisions: %d (%.1f%%); allowed only by A: %d, only by B: %d\n",
+		r.Divergences, pct, r.AOnlyAllowed, r.BOnlyAllowed)
+	if r.Errors > 0 {
+		fmt.Fprintf(&b, "Events skipped on errors: %d\n", r.Errors)
+	}
+
+	if d := r.FirstDivergence; d != nil {
+		fmt.Fprintf(&b, "First divergence: event %d at +%s key=%s (A %s, B %s)\n",
+			d.Index, time.Duration(d.At), d.Key, verdict(d.AAllowed), verdict(d.BAllowed))
+	} else {
+		b.WriteString("First divergence: none\n")
+	}
+
+	fmt.Fprintf(&b, "Remaining drift: max %.0f, mean %.2f\n", r.RemainingDrift.Max, r.RemainingDrift.Mean)
+	fmt.Fprintf(&b, "RetryAfter difference when both denied: max %.0fms, mean %.2fms\n", r.RetryAfterDiff.Max, r.RetryAfterDiff.Mean)
+
+	b.WriteString("Worst keys:\n")
+	for i, k := range r.Keys {
+		if i == maxSummaryKeys {
+			fmt.Fprintf(&b, "  ... %d more\n", len(r.Keys)-maxSummaryKeys)
+			break
+		}
+		fmt.Fprintf(&b, "  %s: %d/%d divergent, max drift %d, max retry diff %.0fms\n",
+			k.Key, k.Divergences, k.Events, k.MaxRemainingDrift, k.MaxRetryAfterDiffMs)
+	}
+
+	return b.String()
+}
+
+func verdict(allowed bool) string {
+	if allowed {
+		return "allowed"
+	}
+	return "denied"
+}
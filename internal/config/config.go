@@ -2,20 +2,51 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"time"
 
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/keys"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Server     ServerConfig         `yaml:"server"`
-	Redis      RedisConfig          `yaml:"redis"`
-	Algorithms AlgorithmsConfig     `yaml:"algorithms"`
-	Limits     LimitsConfig         `yaml:"limits"`
-	Metrics    MetricsConfig        `yaml:"metrics"`
-	Store      string               `yaml:"store"` // "memory" or "redis"
+	Server     ServerConfig     `yaml:"server"`
+	Redis      RedisConfig      `yaml:"redis"`
+	DynamoDB   DynamoDBConfig   `yaml:"dynamodb"`
+	Algorithms AlgorithmsConfig `yaml:"algorithms"`
+	Limits     LimitsConfig     `yaml:"limits"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+	Dedup      DedupConfig      `yaml:"dedup"`
+	RequestIDs RequestIDsConfig `yaml:"request_ids"`
+	Rollout    RolloutConfig    `yaml:"rollout"`
+	Discovery  DiscoveryConfig  `yaml:"discovery"`
+	ReadOnly   ReadOnlyConfig   `yaml:"read_only"`
+	History    HistoryConfig    `yaml:"history"`
+	Pools      PoolsConfig      `yaml:"pools"`
+	Adaptive   AdaptiveConfig   `yaml:"adaptive"`
+	Hierarchy  HierarchyConfig  `yaml:"hierarchy"`
+	Metadata   MetadataConfig   `yaml:"metadata"`
+	UI         UIConfig         `yaml:"ui"`
+	Priority   PriorityConfig   `yaml:"priority"`
+	Quota      QuotaConfig      `yaml:"quota"`
+	DenyCache  DenyCacheConfig  `yaml:"deny_cache"`
+	Lease      LeaseConfig      `yaml:"lease"`
+	Shadow     ShadowConfig     `yaml:"shadow"`
+	Reload     ReloadConfig     `yaml:"reload"`
+	Penalty    PenaltyConfig    `yaml:"penalty"`
+	Ban        BanConfig        `yaml:"ban"`
+	Overrides  OverridesConfig  `yaml:"overrides"`
+	KeyGroups  KeyGroupsConfig  `yaml:"key_groups"`
+	Reserve    ReserveConfig    `yaml:"reserve"`
+	GRPC       GRPCConfig       `yaml:"grpc"`
+	Store      string           `yaml:"store"` // "memory", "redis" or "dynamodb"
+
+	// FailureMode decides checks when the store errors: "fail_closed" returns the error
+	// (default), "fail_open" allows the check so a store outage does not block clients
+	FailureMode string `yaml:"failure_mode"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -24,26 +55,119 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
 	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	Headers      HeadersConfig `yaml:"headers"`
+
+	// ClientTimestamps lets checks carry a timestamp they are evaluated at, for replaying logs
+	// and batch pipelines. Off by default: a client that can date its requests can pick the
+	// window they count against
+	ClientTimestamps bool `yaml:"client_timestamps"`
+
+	// KeyScheme builds limiter keys from identifiers and resources: escaped (default) escapes
+	// ':' and '%' in identifiers, legacy joins them as-is to keep pre-escaping keys
+	KeyScheme string `yaml:"key_scheme"`
+}
+
+// HeadersConfig controls which rate limit headers responses carry
+type HeadersConfig struct {
+	Disabled bool     `yaml:"disabled"` // Omit rate limit headers entirely (body-only)
+	Include  []string `yaml:"include"`  // Subset of: limit, remaining, reset, retry_after, policy, warning (empty = all but policy)
+	Budget   int      `yaml:"budget"`   // Max bytes of rate limit headers per response (0 = unlimited)
+	Priority []string `yaml:"priority"` // Order optional groups are kept under the budget (default: retry_after, warning, policy)
+	Style    string   `yaml:"style"`    // Names of limit/remaining/reset: legacy (X-RateLimit-*), draft (RateLimit-*) or both
 }
 
 // RedisConfig holds Redis connection configuration
 type RedisConfig struct {
-	Addresses []string      `yaml:"addresses"`
-	Password  string        `yaml:"password"`
-	DB        int           `yaml:"db"`
-	PoolSize  int           `yaml:"pool_size"`
-	TTL       time.Duration `yaml:"ttl"`
+	Mode       string           `yaml:"mode"` // single, cluster or sentinel (empty = sentinel if a master name is set, else by address count)
+	Addresses  []string         `yaml:"addresses"`
+	Password   string           `yaml:"password"`
+	DB         int              `yaml:"db"`
+	PoolSize   int              `yaml:"pool_size"`
+	TTL        time.Duration    `yaml:"ttl"`
+	KeyPrefix  string           `yaml:"key_prefix"` // Prepended to every key (e.g. "prod:") so apps sharing a Redis do not collide
+	Timeout    time.Duration    `yaml:"timeout"`    // Deadline for each command (0 = the request's deadline only)
+	Scripts    ScriptsConfig    `yaml:"scripts"`
+	Operations OperationsConfig `yaml:"operations"`
+	Cache      RedisCacheConfig `yaml:"cache"`
+
+	// Sentinel mode asks these sentinels for the master named here and follows failovers
+	SentinelMasterName string   `yaml:"sentinel_master_name"`
+	SentinelAddresses  []string `yaml:"sentinel_addresses"`
+}
+
+// validateMode checks that the addresses mode needs are set, resolving an empty mode as the
+// store does
+func (r RedisConfig) validateMode() error {
+	switch {
+	case r.Mode == "sentinel" || (r.Mode == "" && r.SentinelMasterName != ""):
+		if r.SentinelMasterName == "" {
+			return fmt.Errorf("redis.mode sentinel requires redis.sentinel_master_name")
+		}
+		if len(r.SentinelAddresses) == 0 {
+			return fmt.Errorf("redis.mode sentinel requires redis.sentinel_addresses")
+		}
+	case r.Mode == "" || r.Mode == "cluster":
+		if len(r.Addresses) == 0 {
+			return fmt.Errorf("store \"redis\" requires redis.addresses")
+		}
+	case r.Mode == "single":
+		if len(r.Addresses) != 1 {
+			return fmt.Errorf("redis.mode single requires exactly one of redis.addresses, got %d", len(r.Addresses))
+		}
+	default:
+		return fmt.Errorf("unknown redis.mode %q (want single, cluster or sentinel)", r.Mode)
+	}
+	return nil
+}
+
+// RedisCacheConfig holds the in-memory cache in front of Redis
+type RedisCacheConfig struct {
+	Enabled      bool          `yaml:"enabled"`       // Serve token buckets and window reads from memory, reconciling with Redis
+	SyncInterval time.Duration `yaml:"sync_interval"` // Reconcile period, and so how stale cached state can be (default 100ms)
+}
+
+// DynamoDBConfig holds DynamoDB store configuration
+// Credentials come from the usual AWS sources: the environment, shared config, or the Lambda role
+type DynamoDBConfig struct {
+	Table    string        `yaml:"table"`    // Table with string partition key "pk" and number sort key "sk"
+	Region   string        `yaml:"region"`   // AWS region (empty = from the environment)
+	Endpoint string        `yaml:"endpoint"` // Endpoint override, e.g. http://localhost:8000 for DynamoDB Local
+	TTL      time.Duration `yaml:"ttl"`      // Expiry of window and token items after their last write
+}
+
+// ScriptsConfig holds the rollout of Lua-scripted store paths
+type ScriptsConfig struct {
+	Paths          map[string]string `yaml:"paths"`           // Path name -> "off", "shadow" or "on"
+	ErrorThreshold int               `yaml:"error_threshold"` // Consecutive script errors before demotion
+	Cooldown       time.Duration     `yaml:"cooldown"`        // Time on legacy before retrying a demoted path
+}
+
+// OperationsConfig holds exactly-once handling of retried mutating store calls
+type OperationsConfig struct {
+	Retries int           `yaml:"retries"` // Retries of a timed-out mutating script (0 = default 2, negative = none)
+	TTL     time.Duration `yaml:"ttl"`     // How long a script's result is kept to replay retries (default 10s)
 }
 
 // AlgorithmsConfig holds algorithm configuration
 type AlgorithmsConfig struct {
-	Default string `yaml:"default"` // "token_bucket", "sliding_window", "fixed_window"
+	Default string `yaml:"default"` // Any registered algorithm: "token_bucket", "sliding_window", "sliding_window_log", "fixed_window", "leaky_bucket", "gcra", one added with limiter.Register, or "auto" to pick from the default limits
 }
 
 // LimitsConfig holds rate limiting configuration
 type LimitsConfig struct {
 	Default LimitConfig            `yaml:"default"`
 	Tiers   map[string]LimitConfig `yaml:"tiers"`
+
+	// Costs maps resource names or globs (e.g. "api.reports.*") to the units a check consumes
+	// when the request sends no count; unlisted resources cost 1
+	Costs map[string]int `yaml:"costs"`
+
+	// Weights maps resource prefixes (e.g. "api.export") to a multiplier on every check's
+	// count, explicit or from Costs; the longest matching prefix applies, unlisted resources weigh 1
+	Weights map[string]int `yaml:"weights"`
+
+	// MaxCount is the largest count a check may send (0 = handlers.DefaultMaxCount)
+	MaxCount int `yaml:"max_count"`
 }
 
 // LimitConfig represents a rate limit configuration
@@ -51,6 +175,218 @@ type LimitConfig struct {
 	Requests int           `yaml:"requests"` // Max requests
 	Window   time.Duration `yaml:"window"`   // Time window
 	Burst    int           `yaml:"burst"`    // Burst capacity (for token bucket)
+
+	// Fraction of capacity (0-1) a new token bucket key starts with; unset starts full
+	InitialFill *float64 `yaml:"initial_fill"`
+
+	// Tokens a new token bucket key starts with, e.g. 0 to start empty; overrides initial_fill
+	InitialTokens *int `yaml:"initial_tokens"`
+
+	// Token bucket accounting: "float" (default) or "fixed" integer milli-tokens
+	Precision string `yaml:"precision"`
+
+	// Tokens a token bucket request may overdraw by, e.g. 2x burst for batch jobs; the key
+	// is then denied until refill repays the debt (0 = no debt)
+	MaxDebt int `yaml:"max_debt"`
+
+	// Fixed window boundaries: "epoch" (default), or "hour", "day" or "month" to reset at the
+	// start of each UTC calendar unit. window must still be set, to the unit's length (28-31
+	// days for month), for the algorithms that have no calendar windows
+	Alignment string `yaml:"alignment"`
+
+	// Sliding window counter sub-windows, e.g. 60 for an hour in one-minute buckets, so a
+	// burst ages out gradually rather than weighing on the key for a whole window (default 1)
+	Buckets int `yaml:"buckets"`
+
+	// How much of the sliding window's oldest bucket still counts as the window slides off
+	// it: "linear" (default), "exponential" to hold it near full weight for longer, or
+	// "none" to count it in full until it has left, trading accuracy for strictness
+	WeightMode string `yaml:"weight_mode"`
+
+	// Requests fixed and sliding windows admit above requests in a window before denying,
+	// while remaining counts down to requests (default 0 = none)
+	WindowBurst int `yaml:"window_burst"`
+
+	// Fixed window resets are reported up to reset_jitter later per key, by a stable offset,
+	// so denied clients do not all retry at once. jitter_windows also shifts each key's
+	// real window boundaries by its offset, so the reported reset is exact
+	ResetJitter   time.Duration `yaml:"reset_jitter"`
+	JitterWindows bool          `yaml:"jitter_windows"`
+
+	// Percentage of the limit (0-100) after which allowed checks carry a warning, so clients
+	// can back off before they are denied (0 = no warning)
+	SoftLimit float64 `yaml:"soft_limit"`
+
+	// Windows lists limits that must all hold, e.g. 100/min and 1000/hour
+	// When set, requests, window and burst above are not used
+	Windows []WindowConfig `yaml:"windows"`
+}
+
+// WindowConfig is one limit of a multi-window policy
+type WindowConfig struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+	Burst    int           `yaml:"burst"` // Burst capacity (for token bucket, leaky bucket, GCRA)
+}
+
+// MetadataConfig holds limits on the correlation metadata accepted with checks
+type MetadataConfig struct {
+	MaxKeys  int      `yaml:"max_keys"`  // Most entries per request (default 16)
+	MaxBytes int      `yaml:"max_bytes"` // Most bytes of keys and values per request (default 1024)
+	Redact   []string `yaml:"redact"`    // Keys whose values are replaced before logging or hooks
+}
+
+// DedupConfig holds duplicate request guard configuration
+type DedupConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Window  time.Duration `yaml:"window"` // How long an identical payload is rejected
+	Fields  []string      `yaml:"fields"` // Request fields included in the hash (empty = all)
+}
+
+// RequestIDsConfig holds idempotent check configuration
+type RequestIDsConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"` // How long a check's response is replayed to retries with its request_id
+}
+
+// RolloutConfig holds gradual enforcement rollout configuration
+type RolloutConfig struct {
+	Enabled bool    `yaml:"enabled"`
+	Percent float64 `yaml:"percent"` // Percentage of keys enforced (0-100); the rest run in shadow mode
+}
+
+// ShadowConfig holds algorithm pairs compared on live traffic
+type ShadowConfig struct {
+	Enabled bool              `yaml:"enabled"`
+	Pairs   map[string]string `yaml:"pairs"` // Enforced algorithm -> algorithm evaluated alongside it
+}
+
+// AdaptiveConfig holds AIMD limit adjustment configuration
+type AdaptiveConfig struct {
+	Enabled  bool    `yaml:"enabled"`
+	Floor    int     `yaml:"floor"`    // Lowest effective limit
+	Ceiling  int     `yaml:"ceiling"`  // Highest effective limit (default: limits.default.requests)
+	Increase int     `yaml:"increase"` // Added to the limit per success
+	Decrease float64 `yaml:"decrease"` // Limit multiplier per failure, in (0, 1)
+}
+
+// PenaltyConfig holds escalating penalties for keys that keep retrying while denied
+type PenaltyConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Threshold int           `yaml:"threshold"` // Denials allowed within window before the penalty level rises
+	Window    time.Duration `yaml:"window"`
+	MaxLevel  int           `yaml:"max_level"` // Level L multiplies retry times by 2^L
+	Decay     time.Duration `yaml:"decay"`     // Quiet time after a penalty before the level resets
+}
+
+// BanConfig holds temporary bans of keys that keep being denied
+type BanConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Threshold int           `yaml:"threshold"` // Denials allowed within window; the next one bans the key
+	Window    time.Duration `yaml:"window"`
+	Duration  time.Duration `yaml:"duration"`  // How long a ban lasts
+	Allowlist []string      `yaml:"allowlist"` // Keys or globs (e.g. "monitoring:*") that are never banned
+}
+
+// ReserveConfig holds two-phase reservations over POST /v1/reserve, /v1/commit/:id and
+// /v1/cancel/:id
+type ReserveConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"` // How long quota is held before it returns to the key (default 5m)
+}
+
+// OverridesConfig holds per-key limits set at runtime over PUT /v1/limits/:key
+type OverridesConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// KeyGroupsConfig holds identifiers that share one limit, also managed over /v1/groups
+type KeyGroupsConfig struct {
+	Enabled bool                `yaml:"enabled"`
+	Groups  map[string][]string `yaml:"groups"` // Group -> member identifiers, written to the store at startup
+}
+
+// GRPCConfig holds the gRPC server mirroring the HTTP check, status and reset endpoints
+type GRPCConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Port    int  `yaml:"port"`
+}
+
+// ReloadConfig holds reloading of limits when the config file changes
+type ReloadConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// LeaseConfig holds local leasing of token bucket tokens from the store
+type LeaseConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Size    int  `yaml:"size"` // Tokens taken from the store per lease (default 10)
+}
+
+// DenyCacheConfig holds local caching of denials for hot keys
+type DenyCacheConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Staleness time.Duration `yaml:"staleness"` // Longest a denial is answered locally before asking the store again
+}
+
+// HierarchyConfig holds the parent limit that per-identifier limits nest under
+type HierarchyConfig struct {
+	Enabled bool        `yaml:"enabled"`
+	Parent  LimitConfig `yaml:"parent"` // Limit shared by every identifier with the same parent_identifier
+}
+
+// DiscoveryConfig holds Kubernetes topology discovery configuration
+type DiscoveryConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Service  string        `yaml:"service"`   // Headless service DNS name
+	PortName string        `yaml:"port_name"` // Optional SRV port name
+	Refresh  time.Duration `yaml:"refresh"`   // Peer re-resolution interval
+	Ramp     time.Duration `yaml:"ramp"`      // How long scaled limits take to follow a peer count change
+}
+
+// PoolsConfig holds pooled org quota configuration
+type PoolsConfig struct {
+	Enabled bool                      `yaml:"enabled"`
+	Tiers   map[string]PoolTierConfig `yaml:"tiers"`
+}
+
+// PoolTierConfig is the pooled quota for orgs on a tier
+type PoolTierConfig struct {
+	Limit        int           `yaml:"limit"`         // Requests per window for the whole org
+	Window       time.Duration `yaml:"window"`        // Quota window
+	SeatFraction float64       `yaml:"seat_fraction"` // Largest share of the pool one seat may use (0-1]
+}
+
+// HistoryConfig holds per-key usage history configuration
+type HistoryConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Window  time.Duration `yaml:"window"` // Size of each history window
+}
+
+// ReadOnlyConfig holds incident-response read-only mode configuration
+type ReadOnlyConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Bias    string `yaml:"bias"` // "allow" or "deny" when a check cannot read limit state
+}
+
+// UIConfig holds the built-in operator page configuration
+type UIConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"` // RATE_LIMITER_UI_PASSWORD overrides
+}
+
+// PriorityConfig holds the capacity reserved from lower priority checks
+type PriorityConfig struct {
+	Enabled bool               `yaml:"enabled"`
+	Reserve map[string]float64 `yaml:"reserve"` // "normal" or "low" -> fraction of each limit (0-1) held back from it
+}
+
+// QuotaConfig holds the long-horizon quota stacked on every check
+type QuotaConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Requests int64  `yaml:"requests"` // Requests per key per period
+	Period   string `yaml:"period"`   // "hour", "day" or "month" (UTC calendar)
 }
 
 // MetricsConfig holds metrics configuration
@@ -103,16 +439,219 @@ func Load(filename string) (*Config, error) {
 	if config.Metrics.Port == 0 {
 		config.Metrics.Port = config.Server.Port
 	}
+	if config.GRPC.Port == 0 {
+		config.GRPC.Port = 9090
+	}
 	if config.Redis.PoolSize == 0 {
 		config.Redis.PoolSize = 100
 	}
 	if config.Redis.TTL == 0 {
 		config.Redis.TTL = 24 * time.Hour
 	}
+	if config.Redis.Scripts.ErrorThreshold == 0 {
+		config.Redis.Scripts.ErrorThreshold = 5
+	}
+	if config.Redis.Scripts.Cooldown == 0 {
+		config.Redis.Scripts.Cooldown = 1 * time.Minute
+	}
+	if config.Redis.Cache.SyncInterval == 0 {
+		config.Redis.Cache.SyncInterval = 100 * time.Millisecond
+	}
+	if config.Discovery.Refresh == 0 {
+		config.Discovery.Refresh = 30 * time.Second
+	}
+	if config.ReadOnly.Bias == "" {
+		config.ReadOnly.Bias = "allow"
+	}
+	if config.History.Window == 0 {
+		config.History.Window = 1 * time.Minute
+	}
+	if config.Adaptive.Floor == 0 {
+		config.Adaptive.Floor = 1
+	}
+	if config.Adaptive.Ceiling == 0 {
+		config.Adaptive.Ceiling = config.Limits.Default.Requests
+	}
+	if config.Adaptive.Increase == 0 {
+		config.Adaptive.Increase = 1
+	}
+	if config.Adaptive.Decrease == 0 {
+		config.Adaptive.Decrease = 0.5
+	}
+	if config.Hierarchy.Parent.Requests == 0 {
+		config.Hierarchy.Parent.Requests = 10 * config.Limits.Default.Requests
+	}
+	if config.Hierarchy.Parent.Window == 0 {
+		config.Hierarchy.Parent.Window = config.Limits.Default.Window
+	}
+	if config.Dedup.Window == 0 {
+		config.Dedup.Window = 10 * time.Second
+	}
+	if config.RequestIDs.TTL == 0 {
+		config.RequestIDs.TTL = time.Minute
+	}
+	if config.Lease.Size == 0 {
+		config.Lease.Size = 10
+	}
+	if config.FailureMode == "" {
+		config.FailureMode = "fail_closed"
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	for _, warning := range config.Warnings() {
+		log.Printf("Config warning: %s", warning)
+	}
 
 	return &config, nil
 }
 
+// Validate checks the values Load cannot default, so a bad file fails at startup instead of
+// misbehaving at runtime (a zero window, for one, makes token buckets refill infinitely fast)
+func (c *Config) Validate() error {
+	switch c.Store {
+	case "memory":
+	case "redis":
+		if err := c.Redis.validateMode(); err != nil {
+			return err
+		}
+		if c.Redis.Timeout < 0 {
+			return fmt.Errorf("redis.timeout must not be negative, got %s", c.Redis.Timeout)
+		}
+		if c.Redis.Cache.SyncInterval < 0 {
+			return fmt.Errorf("redis.cache.sync_interval must not be negative, got %s", c.Redis.Cache.SyncInterval)
+		}
+	case "dynamodb":
+		if c.DynamoDB.Table == "" {
+			return fmt.Errorf("store %q requires dynamodb.table", c.Store)
+		}
+		if c.DynamoDB.TTL < 0 {
+			return fmt.Errorf("dynamodb.ttl must not be negative, got %s", c.DynamoDB.TTL)
+		}
+	default:
+		return fmt.Errorf("unknown store %q (valid: memory, redis, dynamodb)", c.Store)
+	}
+
+	if _, err := keys.ParseScheme(c.Server.KeyScheme); err != nil {
+		return fmt.Errorf("server.key_scheme: %w", err)
+	}
+
+	switch c.FailureMode {
+	case "", "fail_closed", "fail_open":
+	default:
+		return fmt.Errorf("unknown failure_mode %q (valid: fail_closed, fail_open)", c.FailureMode)
+	}
+
+	// Algorithms register themselves on import, so this sees whatever the binary links in
+	if c.Algorithms.Default != limiter.AlgorithmAuto && !limiter.Registered(c.Algorithms.Default) {
+		return fmt.Errorf("algorithms.default %q is not registered (registered: %v)", c.Algorithms.Default, limiter.Algorithms())
+	}
+
+	if c.Limits.MaxCount < 0 {
+		return fmt.Errorf("limits.max_count must not be negative, got %d", c.Limits.MaxCount)
+	}
+
+	if c.RequestIDs.TTL < 0 {
+		return fmt.Errorf("request_ids.ttl must not be negative, got %s", c.RequestIDs.TTL)
+	}
+
+	if c.Lease.Size < 0 {
+		return fmt.Errorf("lease.size must be positive, got %d", c.Lease.Size)
+	}
+
+	members := make(map[string]string)
+	for group, identifiers := range c.KeyGroups.Groups {
+		for _, identifier := range identifiers {
+			if other, ok := members[identifier]; ok && other != group {
+				return fmt.Errorf("key_groups: identifier %q is in both %q and %q", identifier, other, group)
+			}
+			members[identifier] = group
+		}
+	}
+
+	if c.GRPC.Enabled && c.GRPC.Port == c.Server.Port {
+		return fmt.Errorf("grpc.port %d is already the server port", c.GRPC.Port)
+	}
+
+	if err := c.Limits.Default.Validate(); err != nil {
+		return fmt.Errorf("limits.default: %w", err)
+	}
+	for name, tc := range c.Limits.Tiers {
+		if err := tc.Validate(); err != nil {
+			return fmt.Errorf("limits.tiers.%s: %w", name, err)
+		}
+	}
+
+	// Multi-window limits are composites that can not be reconfigured as the peer count changes
+	if c.ScalesLimits() {
+		if len(c.Limits.Default.Windows) > 0 {
+			return fmt.Errorf("limits.default: multi-window limits can not be scaled by discovery")
+		}
+		for name, tc := range c.Limits.Tiers {
+			if len(tc.Windows) > 0 {
+				return fmt.Errorf("limits.tiers.%s: multi-window limits can not be scaled by discovery", name)
+			}
+		}
+	}
+	if c.Hierarchy.Enabled {
+		if err := c.Hierarchy.Parent.Validate(); err != nil {
+			return fmt.Errorf("hierarchy.parent: %w", err)
+		}
+	}
+	return nil
+}
+
+// ScalesLimits reports whether each instance enforces only its share of the configured limits
+// Only the memory store counts per instance; shared stores already enforce limits cluster-wide
+func (c *Config) ScalesLimits() bool {
+	return c.Discovery.Enabled && c.Store == "memory"
+}
+
+// Validate checks that a limit's windows are positive and its counts non-negative
+func (lc LimitConfig) Validate() error {
+	if len(lc.Windows) == 0 && lc.Window <= 0 {
+		return fmt.Errorf("window must be positive, got %s", lc.Window)
+	}
+	if lc.Requests < 0 {
+		return fmt.Errorf("requests must not be negative, got %d", lc.Requests)
+	}
+	if lc.Burst < 0 {
+		return fmt.Errorf("burst must not be negative, got %d", lc.Burst)
+	}
+	if lc.WindowBurst < 0 {
+		return fmt.Errorf("window_burst must not be negative, got %d", lc.WindowBurst)
+	}
+	for i, w := range lc.Windows {
+		if w.Window <= 0 {
+			return fmt.Errorf("windows[%d]: window must be positive, got %s", i, w.Window)
+		}
+		if w.Requests < 0 {
+			return fmt.Errorf("windows[%d]: requests must not be negative, got %d", i, w.Requests)
+		}
+		if w.Burst < 0 {
+			return fmt.Errorf("windows[%d]: burst must not be negative, got %d", i, w.Burst)
+		}
+	}
+	return nil
+}
+
+// Warnings lists values that are valid but probably not what was meant
+func (c *Config) Warnings() []string {
+	var warnings []string
+	warn := func(path string, lc LimitConfig) {
+		if lc.Burst > 0 && lc.Burst < lc.Requests {
+			warnings = append(warnings, fmt.Sprintf("%s: burst %d is below requests %d, so bursts can never use the whole limit", path, lc.Burst, lc.Requests))
+		}
+	}
+
+	warn("limits.default", c.Limits.Default)
+	for name, tc := range c.Limits.Tiers {
+		warn("limits.tiers."+name, tc)
+	}
+	return warnings
+}
+
 // LoadOrDefault loads configuration from file or returns default config
 func LoadOrDefault(filename string) *Config {
 	config, err := Load(filename)
@@ -137,6 +676,10 @@ func DefaultConfig() *Config {
 			DB:        0,
 			PoolSize:  100,
 			TTL:       24 * time.Hour,
+			Scripts: ScriptsConfig{
+				ErrorThreshold: 5,
+				Cooldown:       1 * time.Minute,
+			},
 		},
 		Algorithms: AlgorithmsConfig{
 			Default: "token_bucket",
@@ -165,6 +708,41 @@ func DefaultConfig() *Config {
 			Path:    "/metrics",
 			Port:    8080,
 		},
-		Store: "memory",
+		Dedup: DedupConfig{
+			Enabled: false,
+			Window:  10 * time.Second,
+		},
+		RequestIDs: RequestIDsConfig{
+			Enabled: false,
+			TTL:     time.Minute,
+		},
+		History: HistoryConfig{
+			Enabled: false,
+			Window:  1 * time.Minute,
+		},
+		ReadOnly: ReadOnlyConfig{
+			Enabled: false,
+			Bias:    "allow",
+		},
+		Hierarchy: HierarchyConfig{
+			Enabled: false,
+			Parent: LimitConfig{
+				Requests: 1000,
+				Window:   1 * time.Minute,
+			},
+		},
+		Lease: LeaseConfig{
+			Enabled: false,
+			Size:    10,
+		},
+		Adaptive: AdaptiveConfig{
+			Enabled:  false,
+			Floor:    1,
+			Ceiling:  100,
+			Increase: 1,
+			Decrease: 0.5,
+		},
+		Store:       "memory",
+		FailureMode: "fail_closed",
 	}
 }
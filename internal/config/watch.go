@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long a config file must go unchanged before it is reloaded, so an
+// editor's truncate-then-write is read once, whole
+const watchSettle = 100 * time.Millisecond
+
+// Watcher reloads a config file when it changes
+type Watcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// Watch calls onChange with the newly loaded config each time filename changes
+// The file's directory is watched rather than the file itself, so editors and config
+// mounts that replace the file by renaming over it are followed. A file that fails to
+// load is logged and skipped, keeping the previous config; so are saves that change nothing
+func Watch(filename string, onChange func(*Config)) (*Watcher, error) {
+	current, err := Load(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+	if err := fw.Add(filepath.Dir(filename)); err != nil {
+		fw.Close()
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	w := &Watcher{watcher: fw, done: make(chan struct{})}
+	go w.run(filename, current, onChange)
+	return w, nil
+}
+
+// run reloads filename after each burst of changes until the watcher is closed
+func (w *Watcher) run(filename string, current *Config, onChange func(*Config)) {
+	defer close(w.done)
+
+	name := filepath.Clean(filename)
+	settle := time.NewTimer(watchSettle)
+	settle.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				settle.Stop()
+				return
+			}
+			if filepath.Clean(event.Name) == name && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				settle.Reset(watchSettle)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				settle.Stop()
+				return
+			}
+			log.Printf("Config watch error: %v", err)
+		case <-settle.C:
+			next, err := Load(filename)
+			if err != nil {
+				log.Printf("Keeping previous config: %v", err)
+				continue
+			}
+			if reflect.DeepEqual(next, current) {
+				continue
+			}
+			current = next
+			onChange(next)
+		}
+	}
+}
+
+// Close stops watching; onChange is not called once Close returns
+func (w *Watcher) Close() error {
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
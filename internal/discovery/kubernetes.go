@@ -0,0 +1,271 @@
+// Package discovery derives the server's instance topology from its environment
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Resolver looks up SRV records (satisfied by *net.Resolver)
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// Topology describes this instance's place among its peers
+type Topology struct {
+	Instances int // Number of peers behind the headless service (including this one)
+	Ordinal   int // StatefulSet ordinal of this instance (-1 if unknown)
+}
+
+// Config holds Kubernetes discovery configuration
+type Config struct {
+	Service  string        // Headless service DNS name, e.g. "rate-limiter.default.svc.cluster.local"
+	PortName string        // Optional SRV port name, e.g. "http" (empty looks up the service name directly)
+	Refresh  time.Duration // How often to re-resolve peers
+	Ramp     time.Duration // How long scaled limits take to follow a peer count change
+}
+
+// Discoverer periodically resolves the StatefulSet topology
+// The instance ordinal comes from the pod hostname ("name-3" -> 3) and the
+// peer count from SRV records of the headless service
+type Discoverer struct {
+	config   Config
+	resolver Resolver
+	hostname func() (string, error)
+	now      func() time.Time
+	metrics  *metrics.Metrics
+
+	topology    Topology
+	rampFrom    float64   // Effective instance count when the last change happened
+	changedAt   time.Time // When the instance count last changed
+	subscribers []func(Topology)
+	mu          sync.RWMutex
+}
+
+// Option configures a Discoverer
+type Option func(*Discoverer)
+
+// WithResolver overrides the DNS resolver
+func WithResolver(r Resolver) Option {
+	return func(d *Discoverer) { d.resolver = r }
+}
+
+// WithHostname overrides how the pod hostname is read
+func WithHostname(fn func() (string, error)) Option {
+	return func(d *Discoverer) { d.hostname = fn }
+}
+
+// WithNow overrides the time source used for ramping
+func WithNow(fn func() time.Time) Option {
+	return func(d *Discoverer) { d.now = fn }
+}
+
+// WithMetrics exports the discovered topology as gauges
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(d *Discoverer) { d.metrics = m }
+}
+
+// New creates a discoverer; call Refresh or Start to populate the topology
+func New(config Config, opts ...Option) *Discoverer {
+	if config.Refresh == 0 {
+		config.Refresh = 30 * time.Second
+	}
+
+	d := &Discoverer{
+		config:   config,
+		resolver: net.DefaultResolver,
+		hostname: os.Hostname,
+		now:      time.Now,
+		topology: Topology{Instances: 1, Ordinal: -1},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.rampFrom = float64(d.topology.Instances)
+	return d
+}
+
+// Subscribe registers fn to be called whenever the topology changes
+func (d *Discoverer) Subscribe(fn func(Topology)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscribers = append(d.subscribers, fn)
+}
+
+// Topology returns the most recently discovered topology
+func (d *Discoverer) Topology() Topology {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.topology
+}
+
+// Refresh re-reads the hostname and resolves the headless service once
+func (d *Discoverer) Refresh(ctx context.Context) error {
+	ordinal := -1
+	if host, err := d.hostname(); err == nil {
+		ordinal = parseOrdinal(host)
+	}
+
+	service, proto := "", ""
+	if d.config.PortName != "" {
+		service, proto = d.config.PortName, "tcp"
+	}
+	_, records, err := d.resolver.LookupSRV(ctx, service, proto, d.config.Service)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", d.config.Service, err)
+	}
+
+	// Each pod may publish several ports; count distinct targets
+	targets := make(map[string]struct{}, len(records))
+	for _, r := range records {
+		targets[strings.TrimSuffix(r.Target, ".")] = struct{}{}
+	}
+	instances := len(targets)
+	if instances == 0 {
+		instances = 1
+	}
+
+	d.update(Topology{Instances: instances, Ordinal: ordinal})
+	return nil
+}
+
+// Start refreshes the topology until ctx is canceled
+func (d *Discoverer) Start(ctx context.Context) {
+	if err := d.Refresh(ctx); err != nil {
+		log.Printf("Topology discovery failed: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(d.config.Refresh)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := d.Refresh(ctx); err != nil {
+					log.Printf("Topology discovery failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// EffectiveInstances returns the instance count used for scaling
+// After a change it moves linearly from the old count to the new one over the
+// configured ramp period, so scaled limits never step instantly
+func (d *Discoverer) EffectiveInstances() float64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.effectiveInstances()
+}
+
+// effectiveInstances computes the ramped instance count; callers hold d.mu
+func (d *Discoverer) effectiveInstances() float64 {
+	current := float64(d.topology.Instances)
+	if d.config.Ramp <= 0 {
+		return current
+	}
+
+	progress := float64(d.now().Sub(d.changedAt)) / float64(d.config.Ramp)
+	if progress >= 1 {
+		return current
+	}
+	if progress < 0 {
+		progress = 0
+	}
+
+	return d.rampFrom + (current-d.rampFrom)*progress
+}
+
+// ScaleLimit returns this instance's share of a cluster-wide limit
+func (d *Discoverer) ScaleLimit(limit int) int {
+	scaled := int(math.Ceil(float64(limit) / d.EffectiveInstances()))
+	if scaled < 1 && limit > 0 {
+		scaled = 1
+	}
+	return scaled
+}
+
+// ScaleConfig returns config with its cluster-wide limit and burst scaled to this instance's share
+func (d *Discoverer) ScaleConfig(config limiter.Config) limiter.Config {
+	config.Limit = d.ScaleLimit(config.Limit)
+	if config.Burst > 0 {
+		config.Burst = d.ScaleLimit(config.Burst)
+	}
+	return config
+}
+
+// Follow calls apply whenever the effective instance count changes, until ctx is canceled
+// The count is polled every interval, so a ramp moves scaled limits in steps at most interval apart
+func (d *Discoverer) Follow(ctx context.Context, interval time.Duration, apply func()) {
+	last := d.EffectiveInstances()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if current := d.EffectiveInstances(); current != last {
+					last = current
+					apply()
+				}
+			}
+		}
+	}()
+}
+
+func (d *Discoverer) update(t Topology) {
+	d.mu.Lock()
+	changed := t != d.topology
+	if t.Instances != d.topology.Instances {
+		// Start the ramp from wherever the previous ramp had reached
+		d.rampFrom = d.effectiveInstances()
+		d.changedAt = d.now()
+	}
+	d.topology = t
+	subscribers := append([]func(Topology){}, d.subscribers...)
+	d.mu.Unlock()
+
+	if d.metrics != nil {
+		d.metrics.RecordTopology(t.Instances, t.Ordinal)
+	}
+
+	if changed {
+		for _, fn := range subscribers {
+			fn(t)
+		}
+	}
+}
+
+// parseOrdinal extracts the StatefulSet ordinal from a pod hostname ("web-2" -> 2)
+func parseOrdinal(hostname string) int {
+	// Hostnames may be fully qualified; the pod name is the first label
+	name := strings.SplitN(hostname, ".", 2)[0]
+
+	idx := strings.LastIndex(name, "-")
+	if idx < 0 || idx == len(name)-1 {
+		return -1
+	}
+
+	ordinal, err := strconv.Atoi(name[idx+1:])
+	if err != nil || ordinal < 0 {
+		return -1
+	}
+	return ordinal
+}
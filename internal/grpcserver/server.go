@@ -0,0 +1,205 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	ratelimitv1 "github.com/AbubakarMahmood1/go-rate-limiter/api/ratelimit/v1"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/keys"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server serves the rate limit API over gRPC
+// It mirrors the HTTP check, status and reset endpoints over the same limiters and metrics
+type Server struct {
+	ratelimitv1.UnimplementedRateLimitServiceServer
+
+	limiters         map[string]limiter.RateLimiter // algorithm name -> limiter
+	metrics          *metrics.Metrics
+	defaultAlgorithm string
+	readOnly         func() bool // Reports read-only mode (nil = never read-only)
+	keyScheme        keys.Scheme // Builds limiter keys from identifier and resource
+	maxCount         int         // Largest count a check may send (0 = limiter.MaxN)
+}
+
+// Option configures optional Server behavior
+type Option func(*Server)
+
+// WithReadOnly follows the HTTP server's read-only mode: while readOnly reports true,
+// checks are answered from peeks without consuming and resets are refused
+func WithReadOnly(readOnly func() bool) Option {
+	return func(s *Server) {
+		s.readOnly = readOnly
+	}
+}
+
+// WithKeyScheme sets how limiter keys are built, matching the HTTP handler's scheme so
+// both APIs count a client under the same key
+func WithKeyScheme(scheme keys.Scheme) Option {
+	return func(s *Server) {
+		s.keyScheme = scheme
+	}
+}
+
+// WithMaxCount caps the count a check may send, as the HTTP API's limits.max_count does
+func WithMaxCount(max int) Option {
+	return func(s *Server) {
+		s.maxCount = max
+	}
+}
+
+// NewServer creates a gRPC server for limiters, recording decisions in metrics
+func NewServer(limiters map[string]limiter.RateLimiter, metrics *metrics.Metrics, defaultAlgorithm string, opts ...Option) *Server {
+	s := &Server{
+		limiters:         limiters,
+		metrics:          metrics,
+		defaultAlgorithm: defaultAlgorithm,
+		keyScheme:        keys.Escaped{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register mounts the service on gs
+func (s *Server) Register(gs *grpc.Server) {
+	ratelimitv1.RegisterRateLimitServiceServer(gs, s)
+}
+
+// Check consumes count requests for identifier:resource
+func (s *Server) Check(ctx context.Context, req *ratelimitv1.CheckRequest) (*ratelimitv1.CheckResponse, error) {
+	start := time.Now()
+
+	if req.GetResource() == "" || req.GetIdentifier() == "" {
+		return nil, status.Error(codes.InvalidArgument, "resource and identifier are required")
+	}
+	count := 1
+	if req.Count != nil {
+		count = int(req.GetCount())
+		if count < 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "count must be positive, got %d", count)
+		}
+		if s.maxCount > 0 && count > s.maxCount {
+			return nil, status.Errorf(codes.InvalidArgument, "count must be at most %d, got %d", s.maxCount, count)
+		}
+	}
+
+	algorithm, rl, err := s.resolveLimiter(req.GetAlgorithm())
+	if err != nil {
+		return nil, err
+	}
+
+	// In read-only mode decide from current state without consuming
+	key, err := s.keyScheme.Join(req.GetIdentifier(), req.GetResource())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var allowed bool
+	var info *limiter.LimitInfo
+	if s.isReadOnly() {
+		peeker, ok := rl.(limiter.Peeker)
+		if !ok {
+			return nil, status.Error(codes.Unavailable, "server is in read-only mode")
+		}
+		allowed, info, err = peeker.Peek(ctx, key, count)
+	} else {
+		allowed, info, err = rl.AllowNCtx(ctx, key, count)
+	}
+	if err != nil {
+		return nil, limiterError(err, "rate limit check failed")
+	}
+
+	keyPrefix := strings.Split(req.GetResource(), ".")[0]
+	s.metrics.RecordRequest(algorithm, keyPrefix, allowed, time.Since(start).Seconds())
+	s.metrics.RecordRemaining(algorithm, keyPrefix, info.Remaining)
+
+	return checkResponse(allowed, info), nil
+}
+
+// GetStatus reads a key's limit state without consuming anything
+func (s *Server) GetStatus(ctx context.Context, req *ratelimitv1.GetStatusRequest) (*ratelimitv1.CheckResponse, error) {
+	if req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+	_, rl, err := s.resolveLimiter(req.GetAlgorithm())
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := rl.StatusCtx(ctx, req.GetKey())
+	if err != nil {
+		return nil, limiterError(err, "status check failed")
+	}
+	return checkResponse(info.Remaining > 0, info), nil
+}
+
+// Reset clears a key's limit state
+func (s *Server) Reset(ctx context.Context, req *ratelimitv1.ResetRequest) (*ratelimitv1.ResetResponse, error) {
+	if req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+	if s.isReadOnly() {
+		return nil, status.Error(codes.Unavailable, "server is in read-only mode")
+	}
+	_, rl, err := s.resolveLimiter(req.GetAlgorithm())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rl.ResetCtx(ctx, req.GetKey()); err != nil {
+		return nil, limiterError(err, "reset failed")
+	}
+	return &ratelimitv1.ResetResponse{}, nil
+}
+
+// isReadOnly reports whether the server is in read-only mode
+func (s *Server) isReadOnly() bool {
+	return s.readOnly != nil && s.readOnly()
+}
+
+// resolveLimiter returns the limiter for algorithm, or the default algorithm's when empty
+func (s *Server) resolveLimiter(algorithm string) (string, limiter.RateLimiter, error) {
+	if algorithm == "" {
+		algorithm = s.defaultAlgorithm
+	}
+	rl, ok := s.limiters[algorithm]
+	if !ok {
+		return "", nil, status.Errorf(codes.InvalidArgument, "invalid algorithm %q", algorithm)
+	}
+	return algorithm, rl, nil
+}
+
+// limiterError converts a failed limiter or store call to a status, like the HTTP API
+func limiterError(err error, message string) error {
+	switch {
+	case errors.Is(err, limiter.ErrStoreUnavailable), errors.Is(err, limiter.ErrClosed):
+		return status.Error(codes.Unavailable, "store unavailable")
+	case errors.Is(err, limiter.ErrInvalidN), errors.Is(err, limiter.ErrKeyTooLong), errors.Is(err, limiter.ErrRequestExceedsCapacity):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
+
+// checkResponse converts a decision to its wire form
+func checkResponse(allowed bool, info *limiter.LimitInfo) *ratelimitv1.CheckResponse {
+	resp := &ratelimitv1.CheckResponse{
+		Allowed:   allowed,
+		Limit:     int32(info.Limit),
+		Remaining: int32(info.Remaining),
+		ResetAt:   timestamppb.New(info.ResetAt),
+	}
+	if info.RetryAfter != nil {
+		retryAfter := int64(info.RetryAfterSeconds())
+		resp.RetryAfter = &retryAfter
+	}
+	return resp
+}
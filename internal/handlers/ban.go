@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/gin-gonic/gin"
+)
+
+// WithBans temporarily bans keys that keep being denied; checks of banned keys are denied
+// without consulting the limiter
+func WithBans(bans *algorithms.Bans) Option {
+	return func(h *RateLimitHandler) {
+		h.bans = bans
+	}
+}
+
+// BanResponse describes a banned key
+type BanResponse struct {
+	Key   string `json:"key"`
+	Until string `json:"until"`
+}
+
+// banInfo returns the denial for key if it is banned, or nil if it is not or bans are off
+// rl supplies the limit reported alongside the ban
+func (h *RateLimitHandler) banInfo(ctx context.Context, rl limiter.RateLimiter, key string) (*limiter.LimitInfo, error) {
+	if h.bans == nil {
+		return nil, nil
+	}
+	ban, err := h.bans.Banned(ctx, key)
+	if err != nil || ban == nil {
+		return nil, err
+	}
+
+	info := &limiter.LimitInfo{ResetAt: ban.Until, RetryAfter: &ban.RetryAfter, Reason: limiter.ReasonBanned}
+	if d, ok := rl.(limiter.Describer); ok {
+		info.Limit = d.Config().Limit
+	}
+	return info, nil
+}
+
+// recordDenial counts a denial of key toward a ban, extending info to the ban if this
+// denial placed one. Best effort: a failed write leaves the denial as the limiter made it
+func (h *RateLimitHandler) recordDenial(ctx context.Context, key string, info *limiter.LimitInfo) bool {
+	if h.bans == nil {
+		return false
+	}
+	ban, err := h.bans.Deny(ctx, key)
+	if err != nil || ban == nil {
+		return false
+	}
+
+	info.Remaining = 0
+	info.ResetAt = ban.Until
+	info.RetryAfter = &ban.RetryAfter
+	info.Reason = limiter.ReasonBanned
+	return true
+}
+
+// ListBans handles GET /v1/bans - keys currently banned
+func (h *RateLimitHandler) ListBans(c *gin.Context) {
+	if h.bans == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "bans not enabled"})
+		return
+	}
+
+	bans, err := h.bans.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "listing bans failed"})
+		return
+	}
+
+	resp := make([]BanResponse, len(bans))
+	for i, ban := range bans {
+		resp[i] = BanResponse{Key: ban.Key, Until: ban.Until.Format(time.RFC3339)}
+	}
+	c.JSON(http.StatusOK, gin.H{"bans": resp})
+}
+
+// Unban handles POST /v1/unban/:key - lift a key's ban
+func (h *RateLimitHandler) Unban(c *gin.Context) {
+	if h.bans == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "bans not enabled"})
+		return
+	}
+
+	key := c.Param("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+
+	banned, err := h.bans.Unban(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unban failed"})
+		return
+	}
+	if !banned {
+		c.JSON(http.StatusNotFound, gin.H{"error": "key is not banned"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "key unbanned successfully"})
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// maxBatchChecks bounds the checks in one batch request
+const maxBatchChecks = 100
+
+// BatchCheckResult is the outcome of one check in a batch
+// Error and Code are set instead of the check fields when that check alone failed
+type BatchCheckResult struct {
+	*CheckResponse
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+// batchCheck is a validated check in a batch, with the limiter enforcing it before any quota
+type batchCheck struct {
+	req       CheckRequest
+	key       string
+	group     string // Key group the identifier shares limits with (empty = none)
+	algorithm string
+	tier      string
+	cost      int
+	limiter   limiter.RateLimiter
+}
+
+// batchGroup collects the checks of a batch enforced by the same limiter
+type batchGroup struct {
+	limiter limiter.RateLimiter
+	indices []int // Positions of the group's checks in the batch
+}
+
+// BatchCheckRequest is the object form of a batch, for options that apply to all its checks
+type BatchCheckRequest struct {
+	Checks []CheckRequest `json:"checks"`
+
+	// Optional: consume every check or none, as one decision (all checks must share an
+	// algorithm and tier whose limiter supports all-or-nothing checks)
+	Atomic bool `json:"atomic"`
+}
+
+// CheckBatch handles POST /v1/check/batch - check many keys at once
+// The body is an array of check requests, or a BatchCheckRequest; the response is an array
+// of results in the same order, with 200 whatever the decisions. Checks are independent:
+// one denied or failing does not affect the others. Checks under the same algorithm and
+// tier reach the store in one round trip where the limiter supports it. Parent
+// identifiers, priorities and timestamps are per-check features of /v1/check and are
+// rejected here
+func (h *RateLimitHandler) CheckBatch(c *gin.Context) {
+	start := time.Now()
+
+	var body json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var batch BatchCheckRequest
+	var err error
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "{") {
+		err = json.Unmarshal(body, &batch)
+	} else {
+		err = json.Unmarshal(body, &batch.Checks)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reqs := batch.Checks
+	if len(reqs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "batch is empty"})
+		return
+	}
+	if len(reqs) > maxBatchChecks {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("batch has %d checks, max %d", len(reqs), maxBatchChecks)})
+		return
+	}
+
+	if batch.Atomic {
+		h.checkAtomic(c, reqs, start)
+		return
+	}
+
+	ctx := c.Request.Context()
+	readOnly := h.IsReadOnly()
+	results := make([]BatchCheckResult, len(reqs))
+	checks := make([]batchCheck, len(reqs))
+
+	// Group checks by limiter, in order of first appearance
+	var groups []*batchGroup
+	grouped := make(map[limiter.RateLimiter]*batchGroup)
+	for i, req := range reqs {
+		check, err := h.resolveBatchCheck(req)
+		if err != nil {
+			results[i] = BatchCheckResult{Error: err.Error(), Code: CodeInvalidRequest}
+			continue
+		}
+		if check.key, check.group, err = h.limiterKey(ctx, req.Identifier, req.Resource); err != nil {
+			results[i] = batchError(err)
+			continue
+		}
+		checks[i] = check
+
+		// Banned keys are denied without consulting the limiter
+		info, err := h.banInfo(ctx, check.limiter, check.key)
+		if err != nil {
+			results[i] = batchError(err)
+			continue
+		}
+		if info != nil {
+			results[i] = h.batchResult(ctx, check, false, true, readOnly, info, start)
+			continue
+		}
+
+		g, ok := grouped[check.limiter]
+		if !ok {
+			g = &batchGroup{limiter: check.limiter}
+			grouped[check.limiter] = g
+			groups = append(groups, g)
+		}
+		g.indices = append(g.indices, i)
+	}
+
+	for _, g := range groups {
+		// In read-only mode decide each check from current state without consuming
+		if readOnly {
+			for _, i := range g.indices {
+				allowed, info := h.peekDecision(ctx, h.withQuota(g.limiter), checks[i].key, checks[i].cost)
+				results[i] = h.batchResult(ctx, checks[i], allowed, false, true, info, start)
+			}
+			continue
+		}
+
+		requests := make([]limiter.BatchRequest, len(g.indices))
+		for j, i := range g.indices {
+			requests[j] = limiter.BatchRequest{Key: checks[i].key, N: checks[i].cost}
+		}
+		decisions, err := algorithms.AllowBatch(ctx, h.withQuota(g.limiter), requests)
+		if err != nil {
+			for _, i := range g.indices {
+				results[i] = batchError(err)
+			}
+			continue
+		}
+
+		for j, d := range decisions {
+			i := g.indices[j]
+			if d.Err != nil {
+				results[i] = batchError(d.Err)
+				continue
+			}
+			// Count the denial toward a ban; the one that places it reports the ban
+			banned := !d.Allowed && h.recordDenial(ctx, checks[i].key, d.Info)
+			results[i] = h.batchResult(ctx, checks[i], d.Allowed, banned, false, d.Info, start)
+		}
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// checkAtomic decides an atomic batch: every check is consumed, or none is
+// Unlike plain batches a check that fails fails the whole batch, with 400 for invalid
+// checks. A banned key, or one without room, denies every check; the results report each
+// key's own state, so the keys that decided carry retry_after
+func (h *RateLimitHandler) checkAtomic(c *gin.Context, reqs []CheckRequest, start time.Time) {
+	ctx := c.Request.Context()
+
+	checks := make([]batchCheck, len(reqs))
+	keys := make([]string, len(reqs))
+	ns := make([]int, len(reqs))
+	for i, req := range reqs {
+		check, err := h.resolveBatchCheck(req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("check %d: %s", i, err), "index": i})
+			return
+		}
+		if i > 0 && check.limiter != checks[0].limiter {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "atomic batches must share one algorithm and tier"})
+			return
+		}
+		if check.key, check.group, err = h.limiterKey(ctx, req.Identifier, req.Resource); err != nil {
+			writeLimiterError(c, err, "key group lookup failed")
+			return
+		}
+		checks[i] = check
+		keys[i] = check.key
+		ns[i] = check.cost
+	}
+
+	atomic, ok := h.withQuota(checks[0].limiter).(limiter.AtomicLimiter)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "algorithm does not support atomic batches"})
+		return
+	}
+
+	// Banned keys deny the batch without consulting the limiter
+	infos := make([]*limiter.LimitInfo, len(checks))
+	banned := make([]bool, len(checks))
+	anyBanned := false
+	for i, check := range checks {
+		info, err := h.banInfo(ctx, check.limiter, check.key)
+		if err != nil {
+			writeLimiterError(c, err, "ban check failed")
+			return
+		}
+		infos[i], banned[i] = info, info != nil
+		anyBanned = anyBanned || banned[i]
+	}
+
+	var allowed bool
+	readOnly := h.IsReadOnly()
+	switch {
+	case anyBanned:
+		for i, check := range checks {
+			if banned[i] {
+				continue
+			}
+			info, err := h.withQuota(check.limiter).StatusCtx(ctx, check.key)
+			if err != nil {
+				writeLimiterError(c, err, "status check failed")
+				return
+			}
+			infos[i] = info
+		}
+	case readOnly:
+		// Decide from current state without consuming; every check must fit on its own
+		allowed = true
+		for i, check := range checks {
+			var fits bool
+			fits, infos[i] = h.peekDecision(ctx, h.withQuota(check.limiter), check.key, check.cost)
+			allowed = allowed && fits
+		}
+	default:
+		var err error
+		allowed, infos, err = atomic.AllowAllCtx(ctx, keys, ns)
+		if err != nil {
+			writeLimiterError(c, err, "rate limit check failed")
+			return
+		}
+		// Count denials toward bans for the keys that lacked room
+		if !allowed {
+			for i, check := range checks {
+				if infos[i].RetryAfter != nil {
+					banned[i] = h.recordDenial(ctx, check.key, infos[i])
+				}
+			}
+		}
+	}
+
+	results := make([]BatchCheckResult, len(checks))
+	for i, check := range checks {
+		results[i] = h.batchResult(ctx, check, allowed, banned[i], readOnly, infos[i], start)
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// resolveBatchCheck validates one check of a batch and resolves its cost and limiter
+func (h *RateLimitHandler) resolveBatchCheck(req CheckRequest) (batchCheck, error) {
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return batchCheck{}, err
+	}
+	if err := h.validateMetadata(req.Metadata); err != nil {
+		return batchCheck{}, err
+	}
+	switch {
+	case req.ParentIdentifier != "":
+		return batchCheck{}, errors.New("parent_identifier is not supported in batch checks")
+	case req.Priority != "":
+		return batchCheck{}, errors.New("priority is not supported in batch checks")
+	case req.Timestamp != nil:
+		return batchCheck{}, errors.New("timestamp is not supported in batch checks")
+	case req.RequestID != "":
+		return batchCheck{}, errors.New("request_id is not supported in batch checks")
+	}
+
+	cost, err := h.checkCost(req)
+	if err != nil {
+		return batchCheck{}, err
+	}
+	algorithm, tier, limiterInstance, ok := h.resolveLimiter(req.Algorithm, req.Tier)
+	if !ok {
+		return batchCheck{}, errors.New("invalid algorithm")
+	}
+
+	return batchCheck{
+		req:       req,
+		algorithm: algorithm,
+		tier:      tier,
+		cost:      cost,
+		limiter:   limiterInstance,
+	}, nil
+}
+
+// batchResult records one decided check of a batch as /v1/check would and builds its result
+func (h *RateLimitHandler) batchResult(ctx context.Context, check batchCheck, allowed, banned, readOnly bool, info *limiter.LimitInfo, start time.Time) BatchCheckResult {
+	if info.Policy == "" {
+		info.Policy = check.tier
+	}
+
+	keyPrefix := strings.Split(check.req.Resource, ".")[0]
+
+	// Warn allowed checks past the soft limit; read-only checks consumed nothing and are not warned
+	var warning bool
+	if allowed && !readOnly {
+		var crossed bool
+		warning, crossed = h.softLimit(check.tier, info, check.cost)
+		if crossed {
+			h.metrics.RecordSoftLimit(keyPrefix)
+		}
+	}
+
+	h.metrics.RecordRequest(check.algorithm, keyPrefix, allowed, time.Since(start).Seconds())
+	h.metrics.RecordRemaining(check.algorithm, keyPrefix, info.Remaining)
+
+	// Record usage history; best effort so reporting never fails a check
+	if h.history != nil && !readOnly {
+		h.history.Record(ctx, check.key, allowed, start)
+	}
+
+	h.recordDecision(ctx, Decision{
+		Key:       check.key,
+		Algorithm: check.algorithm,
+		Allowed:   allowed,
+		ReadOnly:  readOnly,
+		Info:      info,
+		Metadata:  check.req.Metadata,
+		At:        start,
+	})
+
+	resp := &CheckResponse{
+		Allowed:   allowed,
+		Limit:     info.Limit,
+		Remaining: info.Remaining,
+		Used:      info.Used,
+		Window:    int(info.Window.Seconds()),
+		ResetAt:   info.ResetAt.Format(time.RFC3339),
+		Policy:    info.Policy,
+		Tier:      check.tier,
+		Group:     check.group,
+		Cost:      check.cost,
+		Warning:   warning,
+		Banned:    banned,
+		Quota:     h.quotaStatus(ctx, check.key),
+	}
+	if info.RetryAfter != nil {
+		retrySeconds := info.RetryAfterSeconds()
+		resp.RetryAfter = &retrySeconds
+	}
+	if !allowed {
+		resp.Reason = info.Reason
+	}
+	return BatchCheckResult{CheckResponse: resp}
+}
+
+// batchError reports a check of a batch that failed, with the codes writeLimiterError uses
+func batchError(err error) BatchCheckResult {
+	switch {
+	case isUnavailable(err):
+		return BatchCheckResult{Error: "store unavailable", Code: CodeStoreUnavailable}
+	case isInvalidRequest(err):
+		return BatchCheckResult{Error: err.Error(), Code: CodeInvalidRequest}
+	default:
+		return BatchCheckResult{Error: "rate limit check failed", Code: CodeStoreError}
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// defaultCost is what a check consumes when neither the request nor the cost table sets it
+const defaultCost = 1
+
+// Costs maps resources to the units one check consumes, so clients need not know that
+// api.search is ten times as expensive as api.ping. Keys are resource names or glob
+// patterns such as "api.reports.*"
+type Costs map[string]int
+
+// WithCosts sets the cost table used for checks that do not send a count
+func WithCosts(costs Costs) Option {
+	return func(h *RateLimitHandler) {
+		h.costs = costs
+	}
+}
+
+// Validate checks that every pattern is well formed and every cost consumes something
+func (c Costs) Validate() error {
+	for pattern, cost := range c {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid cost pattern %q: %w", pattern, err)
+		}
+		if cost < 1 {
+			return fmt.Errorf("cost of %q must be at least 1, got %d", pattern, cost)
+		}
+	}
+	return nil
+}
+
+// resolve returns the cost of a check on resource
+// An exact entry wins over patterns, and the longest matching pattern wins over shorter ones
+func (c Costs) resolve(resource string) int {
+	if cost, ok := c[resource]; ok {
+		return cost
+	}
+
+	cost, best := defaultCost, ""
+	for pattern, patternCost := range c {
+		if !strings.ContainsAny(pattern, "*?[") {
+			continue
+		}
+		if ok, _ := path.Match(pattern, resource); !ok {
+			continue
+		}
+		// Ties break lexically so the answer does not depend on map order
+		if best == "" || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			cost, best = patternCost, pattern
+		}
+	}
+	return cost
+}
+
+// Weights maps resource prefixes to a multiplier on every check's count, so an expensive
+// endpoint drains the same limit faster whatever the client sends. "api.export" weighs
+// api.export and everything under it, such as api.export.csv, but not api.exporter
+type Weights map[string]int
+
+// WithWeights sets the resource weights checks are multiplied by
+func WithWeights(weights Weights) Option {
+	return func(h *RateLimitHandler) {
+		h.weights = weights
+	}
+}
+
+// Validate checks that every weight is at least 1
+func (w Weights) Validate() error {
+	for prefix, weight := range w {
+		if prefix == "" {
+			return fmt.Errorf("weight prefix must not be empty")
+		}
+		if weight < 1 {
+			return fmt.Errorf("weight of %q must be at least 1, got %d", prefix, weight)
+		}
+	}
+	return nil
+}
+
+// resolve returns the weight of resource: that of its longest matching prefix, or 1
+func (w Weights) resolve(resource string) int {
+	weight, best := 1, ""
+	for prefix, prefixWeight := range w {
+		if resource != prefix && !strings.HasPrefix(resource, prefix+".") {
+			continue
+		}
+		if len(prefix) > len(best) {
+			weight, best = prefixWeight, prefix
+		}
+	}
+	return weight
+}
+
+// DefaultMaxCount is the largest count a check may send unless WithMaxCount sets another
+const DefaultMaxCount = 1_000_000
+
+// WithMaxCount caps the count a check may send, so a client can not ask for absurd amounts
+// (0 = DefaultMaxCount). Configured costs and weights are not capped
+func WithMaxCount(max int) Option {
+	return func(h *RateLimitHandler) {
+		h.maxCount = max
+	}
+}
+
+// errInvalidCount is returned for an explicit count that would consume nothing or refund
+var errInvalidCount = errors.New("count must be at least 1")
+
+// checkCost returns the units a check consumes: the request's count if sent, else the
+// resource's configured cost, multiplied by the resource's weight
+func (h *RateLimitHandler) checkCost(req CheckRequest) (int, error) {
+	count := h.costs.resolve(req.Resource)
+	if req.Count != nil {
+		if err := h.checkCount(*req.Count); err != nil {
+			return 0, err
+		}
+		count = *req.Count
+	}
+	return count * h.weights.resolve(req.Resource), nil
+}
+
+// checkCount rejects an explicit count below 1 or above the configured maximum
+func (h *RateLimitHandler) checkCount(count int) error {
+	if count < 1 {
+		return errInvalidCount
+	}
+	maxCount := h.maxCount
+	if maxCount == 0 {
+		maxCount = DefaultMaxCount
+	}
+	if count > maxCount {
+		return fmt.Errorf("count must be at most %d, got %d", maxCount, count)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// dedupGuard rejects identical request payloads seen within a short window
+// It is a duplicate-submission guard, not a rate limit: every unique payload
+// hash gets a limit of one per window
+type dedupGuard struct {
+	limiter limiter.RateLimiter
+	fields  []string // Top-level body fields included in the hash (empty = all)
+}
+
+// payloadHash computes a stable hash of the configured fields of a JSON body
+// Fields are re-encoded through encoding/json so key order and whitespace
+// differences between otherwise identical payloads do not matter
+func (d *dedupGuard) payloadHash(body []byte) (string, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", fmt.Errorf("failed to decode payload: %w", err)
+	}
+
+	// Metadata is correlation context and must never decide whether a payload repeats
+	delete(payload, metadataField)
+
+	selected := payload
+	if len(d.fields) > 0 {
+		selected = make(map[string]interface{}, len(d.fields))
+		for _, field := range d.fields {
+			if v, ok := payload[field]; ok {
+				selected[field] = v
+			}
+		}
+	}
+
+	canonical, err := json.Marshal(selected)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// isDuplicate reports whether the payload has already been seen in the window
+func (d *dedupGuard) isDuplicate(ctx context.Context, body []byte) (bool, error) {
+	hash, err := d.payloadHash(body)
+	if err != nil {
+		return false, err
+	}
+
+	allowed, _, err := d.limiter.AllowCtx(ctx, "dedup:"+hash)
+	if err != nil {
+		return false, err
+	}
+	return !allowed, nil
+}
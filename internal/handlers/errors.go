@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Stable error codes carried by APIError; clients branch on these, never on the message
+const (
+	CodeInvalidRequest   = "INVALID_REQUEST"   // Malformed or unsupported request
+	CodeInvalidAlgorithm = "INVALID_ALGORITHM" // Algorithm not configured on this server
+	CodeDuplicateRequest = "DUPLICATE_REQUEST" // Identical check payload within the dedup window
+	CodeInProgress       = "IN_PROGRESS"       // A check with the same request ID is still running
+	CodeRateLimited      = "RATE_LIMITED"      // Check denied; the body also carries retry_after
+	CodeReadOnly         = "READ_ONLY"         // Mutation refused in read-only mode
+	CodeStoreUnavailable = "STORE_UNAVAILABLE" // Store unreachable or timed out
+	CodeStoreError       = "STORE_ERROR"       // Limiter or store call failed otherwise
+)
+
+// APIError is the body of an error response
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"` // Context for the error, such as the algorithm that failed
+}
+
+// writeError aborts the request with status and an APIError body
+func writeError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, APIError{Code: code, Message: message})
+}
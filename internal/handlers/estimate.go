@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/gin-gonic/gin"
+)
+
+// EstimateRequest represents a wait estimate query
+type EstimateRequest struct {
+	Key       string `form:"key" binding:"required"`
+	N         *int   `form:"n"`         // Optional: requests to estimate for (default: 1)
+	Algorithm string `form:"algorithm"` // Optional: algorithm to estimate under (default: configured default)
+}
+
+// EstimateResponse reports how long until a check for N requests would be allowed
+type EstimateResponse struct {
+	Key       string `json:"key"`
+	N         int    `json:"n"`
+	Algorithm string `json:"algorithm"`
+	WaitMs    int64  `json:"wait_ms"` // Zero only if the check would be allowed now
+}
+
+// Estimate handles GET /v1/estimate - how long until N requests for a key would be allowed
+// Nothing is consumed. Returns 400 if N exceeds the limit, since no wait would allow it
+func (h *RateLimitHandler) Estimate(c *gin.Context) {
+	var req EstimateRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	n := 1
+	if req.N != nil {
+		n = *req.N
+	}
+
+	algorithm := req.Algorithm
+	if algorithm == "" {
+		algorithm = h.defaultAlgorithm
+	}
+	limiterInstance, ok := h.limiters[algorithm]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid algorithm"})
+		return
+	}
+
+	// Wrapped limiters that do not estimate themselves are estimated from a peek
+	var wait time.Duration
+	var err error
+	switch l := limiterInstance.(type) {
+	case limiter.WaitEstimator:
+		wait, err = l.EstimateWait(req.Key, n)
+	case limiter.Peeker:
+		wait, err = algorithms.EstimateWait(c.Request.Context(), l, req.Key, n)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "algorithm does not support wait estimates"})
+		return
+	}
+	if err != nil {
+		writeLimiterError(c, err, "wait estimate failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, EstimateResponse{
+		Key:       req.Key,
+		N:         n,
+		Algorithm: algorithm,
+		WaitMs:    (wait + time.Millisecond - 1).Milliseconds(), // Rounded up, so only "now" is zero
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/gin-gonic/gin"
+)
+
+// FeedbackRequest reports the outcome of a downstream call made under a limit
+type FeedbackRequest struct {
+	Resource   string `json:"resource" binding:"required"`
+	Identifier string `json:"identifier" binding:"required"`
+	Algorithm  string `json:"algorithm"`                  // Optional: override default algorithm
+	Success    *bool  `json:"success" binding:"required"` // false shrinks the key's limit, true grows it back
+}
+
+// Feedback handles POST /v1/feedback - adjust a key's adaptive limit from a downstream outcome
+func (h *RateLimitHandler) Feedback(c *gin.Context) {
+	var req FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	algorithm := req.Algorithm
+	if algorithm == "" {
+		algorithm = h.defaultAlgorithm
+	}
+
+	limiterInstance, ok := h.limiters[algorithm]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid algorithm"})
+		return
+	}
+
+	adaptive, ok := limiterInstance.(*algorithms.AdaptiveLimiter)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "adaptive limits are not enabled"})
+		return
+	}
+
+	key, err := h.keyScheme.Join(req.Identifier, req.Resource)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := adaptive.ReportOutcome(key, *req.Success)
+	h.metrics.RecordAdaptiveLimit(algorithm, key, limit)
+
+	c.JSON(http.StatusOK, gin.H{
+		"key":       key,
+		"algorithm": algorithm,
+		"limit":     limit,
+	})
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/gin-gonic/gin"
+)
+
+// Names of the rate limit headers selectable in HeaderConfig
+const (
+	HeaderLimit      = "limit"       // X-RateLimit-Limit or RateLimit-Limit
+	HeaderRemaining  = "remaining"   // X-RateLimit-Remaining or RateLimit-Remaining
+	HeaderUsed       = "used"        // X-RateLimit-Used
+	HeaderWindow     = "window"      // X-RateLimit-Window: seconds the limit applies over
+	HeaderReset      = "reset"       // X-RateLimit-Reset: Unix time, or RateLimit-Reset: seconds from now
+	HeaderRetryAfter = "retry_after" // Retry-After
+	HeaderPolicy     = "policy"      // X-RateLimit-Policy: the algorithm that decided
+	HeaderWarning    = "warning"     // X-RateLimit-Warning: set past the soft limit, or when the decision did not consume quota
+)
+
+// Header styles selectable in HeaderConfig, naming limit, remaining and reset
+const (
+	HeaderStyleLegacy = "legacy" // X-RateLimit-Limit/Remaining/Reset, reset as a Unix time
+	HeaderStyleDraft  = "draft"  // RateLimit-Limit/Remaining/Reset of the IETF draft, reset in delta-seconds
+	HeaderStyleBoth   = "both"   // Both sets, for clients migrating from one to the other
+)
+
+var headerStyles = []string{HeaderStyleLegacy, HeaderStyleDraft, HeaderStyleBoth}
+
+var allHeaders = []string{HeaderLimit, HeaderRemaining, HeaderUsed, HeaderReset, HeaderWindow, HeaderRetryAfter, HeaderPolicy, HeaderWarning}
+
+// defaultHeaders are emitted when HeaderConfig.Include is empty
+// Warning is only sent when there is something to warn about
+var defaultHeaders = []string{HeaderLimit, HeaderRemaining, HeaderUsed, HeaderReset, HeaderWindow, HeaderRetryAfter, HeaderWarning}
+
+// readOnlyWarning is the X-RateLimit-Warning value sent for checks answered in read-only mode
+const readOnlyWarning = "read-only mode; quota was not consumed"
+
+// coreHeaders are always written ahead of the budgeted groups
+var coreHeaders = []string{HeaderLimit, HeaderRemaining, HeaderReset}
+
+// defaultHeaderPriority orders the optional groups when HeaderConfig.Priority is empty
+var defaultHeaderPriority = []string{HeaderRetryAfter, HeaderWarning, HeaderUsed, HeaderWindow, HeaderPolicy}
+
+// coreHeaderReserve is the most bytes the core trio can take in style, so budgets below it are rejected
+// Each value is at most 20 digits; each header line adds ": " and CRLF
+func coreHeaderReserve(style string) int {
+	legacy := len("X-RateLimit-Limit") + len("X-RateLimit-Remaining") + len("X-RateLimit-Reset") + 3*(20+4)
+	draft := len("RateLimit-Limit") + len("RateLimit-Remaining") + len("RateLimit-Reset") + 3*(20+4)
+	switch style {
+	case HeaderStyleDraft:
+		return draft
+	case HeaderStyleBoth:
+		return legacy + draft
+	}
+	return legacy
+}
+
+// HeaderConfig controls which rate limit headers are emitted
+type HeaderConfig struct {
+	Disabled bool     // Emit no rate limit headers at all (body-only responses)
+	Include  []string // Header names to emit (empty = limit, remaining, used, reset, window, retry_after, warning)
+
+	// Style names limit, remaining and reset: legacy (default), draft or both. The other
+	// headers have no draft equivalent and keep their X-RateLimit names in every style
+	Style string
+
+	// Budget caps the bytes of rate limit headers per response, for proxies with small
+	// header limits (0 = unlimited). limit/remaining/reset are always sent; the other
+	// groups follow in Priority order until the next one would not fit
+	Budget   int
+	Priority []string // Order of the optional groups (default: retry_after, warning, used, window, policy)
+}
+
+// Validate checks that every included header name and the style are known and the budget
+// fits the core headers
+func (hc HeaderConfig) Validate() error {
+	if hc.Style != "" && !contains(headerStyles, hc.Style) {
+		return fmt.Errorf("unknown header style %q (valid: %v)", hc.Style, headerStyles)
+	}
+	for _, name := range hc.Include {
+		if !isKnownHeader(name) {
+			return fmt.Errorf("unknown rate limit header %q (valid: %v)", name, allHeaders)
+		}
+	}
+	for _, name := range hc.Priority {
+		if !isKnownHeader(name) || isCoreHeader(name) {
+			return fmt.Errorf("unknown header group %q in priority (valid: %v)", name, defaultHeaderPriority)
+		}
+	}
+	if hc.Budget < 0 {
+		return fmt.Errorf("header budget must not be negative, got %d", hc.Budget)
+	}
+	if reserve := coreHeaderReserve(hc.Style); hc.Budget > 0 && hc.Budget < reserve {
+		return fmt.Errorf("header budget %d is below the %d bytes reserved for limit, remaining and reset", hc.Budget, reserve)
+	}
+	return nil
+}
+
+// priority returns the optional groups in emission order, listed ones first
+func (hc HeaderConfig) priority() []string {
+	order := append([]string(nil), hc.Priority...)
+	for _, name := range defaultHeaderPriority {
+		if !contains(order, name) {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// WithHeaders restricts the rate limit headers written on check responses
+func WithHeaders(cfg HeaderConfig) Option {
+	return func(h *RateLimitHandler) {
+		h.headers = make(map[string]bool, len(allHeaders))
+		h.headerBudget = cfg.Budget
+		h.headerPriority = cfg.priority()
+		h.headerStyle = cfg.Style
+		if cfg.Disabled {
+			return
+		}
+
+		include := cfg.Include
+		if len(include) == 0 {
+			include = defaultHeaders
+		}
+		for _, name := range include {
+			h.headers[name] = true
+		}
+	}
+}
+
+// headerEnabled reports whether the named header should be written
+func (h *RateLimitHandler) headerEnabled(name string) bool {
+	if h.headers == nil {
+		return contains(defaultHeaders, name)
+	}
+	return h.headers[name]
+}
+
+// responseHeader is one header line
+type responseHeader struct {
+	name  string
+	value string
+}
+
+// size is the bytes the header takes on the wire: "name: value\r\n"
+func (rh responseHeader) size() int {
+	return len(rh.name) + len(rh.value) + 4
+}
+
+// headerGroup returns the headers for one selectable name, or nil if it has nothing to say
+// now is the time the decision was made, which the draft reset counts from
+func headerGroup(name, style, algorithm string, info *limiter.LimitInfo, warning string, now time.Time) []responseHeader {
+	switch name {
+	case HeaderLimit:
+		return styledHeader(style, "Limit", fmt.Sprintf("%d", info.Limit), fmt.Sprintf("%d", info.Limit))
+	case HeaderRemaining:
+		return styledHeader(style, "Remaining", fmt.Sprintf("%d", info.Remaining), fmt.Sprintf("%d", info.Remaining))
+	case HeaderUsed:
+		return []responseHeader{{"X-RateLimit-Used", fmt.Sprintf("%d", info.Used)}}
+	case HeaderReset:
+		return styledHeader(style, "Reset", fmt.Sprintf("%d", info.ResetAt.Unix()), fmt.Sprintf("%d", resetDelta(info.ResetAt, now)))
+	case HeaderWindow:
+		if info.Window <= 0 {
+			return nil
+		}
+		return []responseHeader{{"X-RateLimit-Window", fmt.Sprintf("%d", int(info.Window.Seconds()))}}
+	case HeaderRetryAfter:
+		if info.RetryAfter == nil {
+			return nil
+		}
+		return []responseHeader{{"Retry-After", fmt.Sprintf("%d", info.RetryAfterSeconds())}}
+	case HeaderPolicy:
+		return []responseHeader{{"X-RateLimit-Policy", algorithm}}
+	case HeaderWarning:
+		if warning == "" {
+			return nil
+		}
+		return []responseHeader{{"X-RateLimit-Warning", warning}}
+	}
+	return nil
+}
+
+// styledHeader returns the X-RateLimit-<field> and/or RateLimit-<field> header for style
+func styledHeader(style, field, legacy, draft string) []responseHeader {
+	switch style {
+	case HeaderStyleDraft:
+		return []responseHeader{{"RateLimit-" + field, draft}}
+	case HeaderStyleBoth:
+		return []responseHeader{{"X-RateLimit-" + field, legacy}, {"RateLimit-" + field, draft}}
+	}
+	return []responseHeader{{"X-RateLimit-" + field, legacy}}
+}
+
+// resetDelta is the whole seconds from now until resetAt, rounded up so a client waiting
+// that long never returns early
+func resetDelta(resetAt, now time.Time) int64 {
+	d := resetAt.Sub(now)
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+// headerTime is when a check was decided: its timestamp when it carried one, else now
+func headerTime(ctx context.Context) time.Time {
+	if at, ok := limiter.RequestTime(ctx); ok {
+		return at
+	}
+	return time.Now()
+}
+
+// writeRateLimitHeaders sets the enabled rate limit headers from info, within the header budget
+// warning is the X-RateLimit-Warning value, if there is one
+func (h *RateLimitHandler) writeRateLimitHeaders(c *gin.Context, algorithm string, info *limiter.LimitInfo, warning string) {
+	now := headerTime(c.Request.Context())
+	used := 0
+	for _, name := range coreHeaders {
+		if !h.headerEnabled(name) {
+			continue
+		}
+		for _, rh := range headerGroup(name, h.headerStyle, algorithm, info, warning, now) {
+			c.Header(rh.name, rh.value)
+			used += rh.size()
+		}
+	}
+
+	priority := h.headerPriority
+	if priority == nil {
+		priority = defaultHeaderPriority
+	}
+
+	var omitted []string
+	for _, name := range priority {
+		if !h.headerEnabled(name) {
+			continue
+		}
+		group := headerGroup(name, h.headerStyle, algorithm, info, warning, now)
+		if len(group) == 0 {
+			continue
+		}
+
+		size := 0
+		for _, rh := range group {
+			size += rh.size()
+		}
+		// Once a group does not fit, lower-priority groups are dropped too so truncation is predictable
+		if omitted != nil || (h.headerBudget > 0 && used+size > h.headerBudget) {
+			omitted = append(omitted, name)
+			h.metrics.RecordHeaderTruncation(name)
+			continue
+		}
+
+		for _, rh := range group {
+			c.Header(rh.name, rh.value)
+		}
+		used += size
+	}
+
+	if omitted != nil && gin.IsDebugging() {
+		log.Printf("Header budget %d bytes reached after %d; omitted: %s", h.headerBudget, used, strings.Join(omitted, ", "))
+	}
+}
+
+func isKnownHeader(name string) bool {
+	return contains(allHeaders, name)
+}
+
+func isCoreHeader(name string) bool {
+	return contains(coreHeaders, name)
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultHistoryWindows is how many windows GetHistory returns when no range is given
+const defaultHistoryWindows = 24
+
+// WithHistory records allowed and attempted counts for every check in h
+func WithHistory(h *store.History) Option {
+	return func(rh *RateLimitHandler) {
+		rh.history = h
+	}
+}
+
+// HistoryRequest represents a usage history query
+// Either from/to (RFC3339) or the number of trailing windows may be given
+type HistoryRequest struct {
+	From    string `form:"from"`
+	To      string `form:"to"`
+	Windows int    `form:"windows"`
+}
+
+// GetHistory handles GET /v1/history/:key - per-window allowed/attempted counts
+func (h *RateLimitHandler) GetHistory(c *gin.Context) {
+	if h.history == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "history is not enabled"})
+		return
+	}
+
+	key := c.Param("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+
+	var req HistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	to := time.Now()
+	if req.To != "" {
+		t, err := time.Parse(time.RFC3339, req.To)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to: " + err.Error()})
+			return
+		}
+		to = t
+	}
+
+	windows := req.Windows
+	if windows <= 0 {
+		windows = defaultHistoryWindows
+	}
+	from := to.Add(-time.Duration(windows-1) * h.history.Window())
+	if req.From != "" {
+		t, err := time.Parse(time.RFC3339, req.From)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from: " + err.Error()})
+			return
+		}
+		from = t
+	}
+
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	entries, err := h.history.Query(c.Request.Context(), key, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "history query failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"key":     key,
+		"window":  h.history.Window().String(),
+		"from":    from.Truncate(h.history.Window()).Format(time.RFC3339),
+		"to":      to.Format(time.RFC3339),
+		"history": entries,
+	})
+}
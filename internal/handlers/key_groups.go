@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/keys"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/gin-gonic/gin"
+)
+
+// WithKeyGroups resolves identifiers through groups before keys are built, so identifiers
+// in one group draw from the group's shared limits
+func WithKeyGroups(groups limiter.KeyGroupStore) Option {
+	return func(h *RateLimitHandler) {
+		h.keyGroups = groups
+	}
+}
+
+// WithKeyScheme sets how limiter keys are built from identifiers and resources
+// (default keys.Escaped; keys.Legacy keeps keys from before escaping)
+func WithKeyScheme(scheme keys.Scheme) Option {
+	return func(h *RateLimitHandler) {
+		h.keyScheme = scheme
+	}
+}
+
+// groupKeyPrefix keeps group keys apart from identifiers that happen to share a name
+const groupKeyPrefix = "group:"
+
+// KeyGroupRequest puts an identifier in a group
+type KeyGroupRequest struct {
+	Group string `json:"group" binding:"required"`
+}
+
+// limiterKey returns the key checks by identifier on resource are limited under: its
+// group's key if it is in a group, else its own. group is empty if it is in none
+func (h *RateLimitHandler) limiterKey(ctx context.Context, identifier, resource string) (key, group string, err error) {
+	if h.keyGroups != nil {
+		group, ok, err := h.keyGroups.KeyGroupCtx(ctx, identifier)
+		if err != nil {
+			return "", "", err
+		}
+		if ok {
+			key, err := h.keyScheme.Join(group, resource)
+			return groupKeyPrefix + key, group, err
+		}
+	}
+	key, err = h.keyScheme.Join(identifier, resource)
+	return key, "", err
+}
+
+// resolveKey maps a status or reset key (identifier:resource) onto the key it is limited
+// under, so any member of a group reports and resets the group's state
+func (h *RateLimitHandler) resolveKey(ctx context.Context, key string) (string, string, error) {
+	if h.keyGroups == nil {
+		return key, "", nil
+	}
+	identifier, resource, ok := h.keyScheme.Split(key)
+	if !ok {
+		return key, "", nil
+	}
+	return h.limiterKey(ctx, identifier, resource)
+}
+
+// GetKeyGroup handles GET /v1/groups/:identifier - the group an identifier is in
+func (h *RateLimitHandler) GetKeyGroup(c *gin.Context) {
+	if h.keyGroups == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "key groups not enabled"})
+		return
+	}
+
+	identifier := c.Param("identifier")
+	group, ok, err := h.keyGroups.KeyGroupCtx(c.Request.Context(), identifier)
+	if err != nil {
+		writeLimiterError(c, err, "failed to get key group")
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "identifier is not in a group"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"identifier": identifier, "group": group})
+}
+
+// SetKeyGroup handles PUT /v1/groups/:identifier - share a group's limits, moving the
+// identifier out of any group it was in
+func (h *RateLimitHandler) SetKeyGroup(c *gin.Context) {
+	if h.keyGroups == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "key groups not enabled"})
+		return
+	}
+
+	var req KeyGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	identifier := c.Param("identifier")
+	if err := h.keyGroups.SetKeyGroupCtx(c.Request.Context(), identifier, req.Group); err != nil {
+		writeLimiterError(c, err, "failed to set key group")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"identifier": identifier, "group": req.Group})
+}
+
+// DeleteKeyGroup handles DELETE /v1/groups/:identifier - return an identifier to its own
+// limits; its next check starts from its own state, not the group's
+func (h *RateLimitHandler) DeleteKeyGroup(c *gin.Context) {
+	if h.keyGroups == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "key groups not enabled"})
+		return
+	}
+
+	removed, err := h.keyGroups.DeleteKeyGroupCtx(c.Request.Context(), c.Param("identifier"))
+	if err != nil {
+		writeLimiterError(c, err, "failed to remove from key group")
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "identifier is not in a group"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "removed from group"})
+}
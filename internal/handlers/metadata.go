@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/gin-gonic/gin"
+)
+
+// metadataField is the check request body field carrying limiter.Metadata
+const metadataField = "metadata"
+
+// MetadataConfig limits and redacts the metadata accepted on checks
+type MetadataConfig struct {
+	MaxKeys  int      // Most entries per request (0 = limiter.DefaultMetadataMaxKeys)
+	MaxBytes int      // Most bytes of keys and values per request (0 = limiter.DefaultMetadataMaxBytes)
+	Redact   []string // Keys whose values are replaced before reaching any consumer
+}
+
+// WithMetadata sets the limits and redaction list for check metadata
+func WithMetadata(cfg MetadataConfig) Option {
+	return func(h *RateLimitHandler) {
+		h.metadata = cfg
+	}
+}
+
+// Decision is one check outcome, as delivered to decision hooks
+type Decision struct {
+	Key       string
+	Algorithm string
+	Allowed   bool
+	ReadOnly  bool // The decision was made from a peek and consumed nothing
+	Info      *limiter.LimitInfo
+	Metadata  limiter.Metadata // Redacted copy of the request's metadata
+	At        time.Time
+}
+
+// DecisionHook observes check decisions; it runs synchronously on the request path
+type DecisionHook func(ctx context.Context, d Decision)
+
+// WithDecisionHook calls hook after every check decision
+func WithDecisionHook(hook DecisionHook) Option {
+	return func(h *RateLimitHandler) {
+		h.hooks = append(h.hooks, hook)
+	}
+}
+
+// validateMetadata checks m against the configured limits
+func (h *RateLimitHandler) validateMetadata(m limiter.Metadata) error {
+	maxKeys := h.metadata.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = limiter.DefaultMetadataMaxKeys
+	}
+	maxBytes := h.metadata.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = limiter.DefaultMetadataMaxBytes
+	}
+	return m.Validate(maxKeys, maxBytes)
+}
+
+// recordDecision hands d to every decision hook and the debug decision log
+// Metadata is redacted once here so no consumer sees the raw values
+func (h *RateLimitHandler) recordDecision(ctx context.Context, d Decision) {
+	if len(h.hooks) == 0 && !gin.IsDebugging() {
+		return
+	}
+
+	d.Metadata = d.Metadata.Redact(h.metadata.Redact)
+	for _, hook := range h.hooks {
+		hook(ctx, d)
+	}
+
+	if gin.IsDebugging() {
+		log.Printf("Decision key=%s algorithm=%s allowed=%t remaining=%d metadata=%v", d.Key, d.Algorithm, d.Allowed, d.Info.Remaining, d.Metadata)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels metrics for requests that matched no registered route
+const unmatchedRoute = "unmatched"
+
+// MiddlewareOption configures GinMiddleware
+type MiddlewareOption func(*ginMiddleware)
+
+type ginMiddleware struct {
+	metrics   *metrics.Metrics
+	algorithm string
+	skip      func(*gin.Context) bool
+	style     string
+}
+
+// WithMiddlewareMetrics records each decision in m under algorithm, labelled by route
+func WithMiddlewareMetrics(m *metrics.Metrics, algorithm string) MiddlewareOption {
+	return func(mw *ginMiddleware) {
+		mw.metrics = m
+		mw.algorithm = algorithm
+	}
+}
+
+// WithSkip lets requests for which skip returns true through without consuming quota
+func WithSkip(skip func(*gin.Context) bool) MiddlewareOption {
+	return func(mw *ginMiddleware) {
+		mw.skip = skip
+	}
+}
+
+// WithMiddlewareHeaderStyle names the limit, remaining and reset headers as HeaderConfig.Style does
+func WithMiddlewareHeaderStyle(style string) MiddlewareOption {
+	return func(mw *ginMiddleware) {
+		mw.style = style
+	}
+}
+
+// GinMiddleware rate limits the routes it is mounted on, keying each request with keyFunc
+// Denied requests are aborted with 429 and the same headers and body as POST /v1/check
+func GinMiddleware(rl limiter.RateLimiter, keyFunc func(*gin.Context) string, opts ...MiddlewareOption) gin.HandlerFunc {
+	mw := &ginMiddleware{}
+	for _, opt := range opts {
+		opt(mw)
+	}
+
+	return func(c *gin.Context) {
+		if mw.skip != nil && mw.skip(c) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		allowed, info, err := rl.AllowNCtx(c.Request.Context(), keyFunc(c), 1)
+		if err != nil {
+			writeLimiterError(c, err, "rate limit check failed")
+			return
+		}
+
+		if mw.metrics != nil {
+			route := c.FullPath()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			mw.metrics.RecordRequest(mw.algorithm, route, allowed, time.Since(start).Seconds())
+		}
+
+		now := headerTime(c.Request.Context())
+		for _, name := range defaultHeaders {
+			for _, rh := range headerGroup(name, mw.style, mw.algorithm, info, "", now) {
+				c.Header(rh.name, rh.value)
+			}
+		}
+
+		if !allowed {
+			resp := CheckResponse{
+				Allowed:   false,
+				Limit:     info.Limit,
+				Remaining: info.Remaining,
+				ResetAt:   info.ResetAt.Format(time.RFC3339),
+				Reason:    info.Reason,
+			}
+			if info.RetryAfter != nil {
+				retrySeconds := info.RetryAfterSeconds()
+				resp.RetryAfter = &retrySeconds
+			}
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, resp)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/gin-gonic/gin"
+)
+
+// WithOverrides enables per-key limit overrides set over HTTP
+// The limiters must already be wrapped in OverrideLimiters sharing overrides
+func WithOverrides(overrides *algorithms.Overrides) Option {
+	return func(h *RateLimitHandler) {
+		h.overrides = overrides
+	}
+}
+
+// OverrideRequest sets the limit for one key, replacing the configured policy's
+type OverrideRequest struct {
+	Limit  int    `json:"limit" binding:"required,gt=0"`
+	Window string `json:"window" binding:"required"` // Go duration, e.g. "1m"
+	Burst  int    `json:"burst" binding:"gte=0"`     // Optional: token bucket capacity (default: limit)
+	TTL    string `json:"ttl"`                       // Optional: Go duration after which the override lapses
+}
+
+// OverrideStatus reports the override a key's limit comes from
+type OverrideStatus struct {
+	Limit     int    `json:"limit"`
+	Window    string `json:"window"`
+	Burst     int    `json:"burst"`
+	ExpiresAt string `json:"expires_at,omitempty"` // When the override lapses; empty if never
+}
+
+// newOverrideStatus describes override for a response
+func newOverrideStatus(override limiter.LimitOverride) *OverrideStatus {
+	status := &OverrideStatus{Limit: override.Limit, Window: override.Window.String(), Burst: override.Burst}
+	if !override.ExpiresAt.IsZero() {
+		status.ExpiresAt = override.ExpiresAt.Format(time.RFC3339)
+	}
+	return status
+}
+
+// overrideStatus reads the key's override for a status response, or nil if it has none
+// The override is informational, so a failed read leaves it out rather than failing the request
+func (h *RateLimitHandler) overrideStatus(ctx context.Context, key string) *OverrideStatus {
+	if h.overrides == nil {
+		return nil
+	}
+	override, ok, err := h.overrides.KeyLimitCtx(ctx, key)
+	if err != nil || !ok {
+		return nil
+	}
+	return newOverrideStatus(override)
+}
+
+// SetOverride handles PUT /v1/limits/:key (and POST /v1/override/:key) - limit one key
+// differently from the rest
+func (h *RateLimitHandler) SetOverride(c *gin.Context) {
+	if h.overrides == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "overrides not enabled"})
+		return
+	}
+
+	var req OverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := time.ParseDuration(req.Window)
+	if err != nil || window <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid window: must be a positive duration"})
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ttl: must be a positive duration"})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	key := c.Param("key")
+	config := limiter.Config{Limit: req.Limit, Window: window, Burst: req.Burst}
+	if err := h.overrides.SetKeyLimitCtx(ctx, key, config, ttl); err != nil {
+		writeLimiterError(c, err, "failed to set override")
+		return
+	}
+
+	// Read back the override as stored, so expires_at is the time it will lapse
+	override, ok, err := h.overrides.KeyLimitCtx(ctx, key)
+	if err != nil || !ok {
+		override = limiter.LimitOverride{Limit: config.Limit, Window: config.Window, Burst: config.Burst}
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Key string `json:"key"`
+		*OverrideStatus
+	}{key, newOverrideStatus(override)})
+}
+
+// DeleteOverride handles DELETE /v1/limits/:key (and /v1/override/:key) - return a key to
+// the configured policy
+func (h *RateLimitHandler) DeleteOverride(c *gin.Context) {
+	if h.overrides == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "overrides not enabled"})
+		return
+	}
+
+	cleared, err := h.overrides.ClearKeyLimitCtx(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		writeLimiterError(c, err, "failed to remove override")
+		return
+	}
+	if !cleared {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no override for key"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "override removed"})
+}
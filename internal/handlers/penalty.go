@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+)
+
+// WithPenalties reports keys' penalty levels in status responses
+// The penalties themselves are applied by the limiters, wrapped with algorithms.NewPenaltyLimiter
+func WithPenalties(penalties *algorithms.Penalties) Option {
+	return func(h *RateLimitHandler) {
+		h.penalties = penalties
+	}
+}
+
+// PenaltyStatus reports a key's escalating penalty
+type PenaltyStatus struct {
+	Level      int    `json:"level"`
+	Multiplier int    `json:"multiplier"`      // Factor applied to retry times
+	Until      string `json:"until,omitempty"` // When the current penalty ends, if the key is penalized
+}
+
+// penaltyStatus reads the key's penalty for a response, or nil if penalties are off
+// The level is informational, so a failed read leaves it out rather than failing the request
+func (h *RateLimitHandler) penaltyStatus(ctx context.Context, key string) *PenaltyStatus {
+	if h.penalties == nil {
+		return nil
+	}
+	penalty, err := h.penalties.Status(ctx, key)
+	if err != nil {
+		return nil
+	}
+
+	status := &PenaltyStatus{Level: penalty.Level, Multiplier: penalty.Multiplier}
+	if !penalty.Until.IsZero() {
+		status.Until = penalty.Until.Format(time.RFC3339)
+	}
+	return status
+}
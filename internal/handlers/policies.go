@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// mimeMarkdown is the Accept type that selects the human-readable policy rendering
+const mimeMarkdown = "text/markdown"
+
+// PolicyRequest represents a policy documentation query
+type PolicyRequest struct {
+	Resource  string `form:"resource" binding:"required"` // Resource the policy applies to
+	Tier      string `form:"tier"`                        // Optional: plan tier (default limits if unknown)
+	Algorithm string `form:"algorithm"`                   // Optional: override default algorithm
+}
+
+// Policy describes the limit a limiter enforces
+type Policy struct {
+	Algorithm string `json:"algorithm"`
+	Limit     int    `json:"limit"`
+	Window    string `json:"window"`
+	Burst     int    `json:"burst,omitempty"`
+	Precision string `json:"precision,omitempty"`
+	MaxDebt   int    `json:"max_debt,omitempty"`
+	Alignment string `json:"alignment,omitempty"` // UTC calendar unit fixed windows reset on
+	Buckets   int    `json:"buckets,omitempty"`   // Sub-windows a sliding window counter ages out by
+	Weighting string `json:"weighting,omitempty"` // How the oldest sliding window bucket is weighed, if not linear
+
+	// Spread of per-key fixed window reset offsets, and whether they move the windows themselves
+	ResetJitter   string `json:"reset_jitter,omitempty"`
+	JitterWindows bool   `json:"jitter_windows,omitempty"`
+}
+
+// PolicyResponse represents the policy a check for a resource would be held to
+type PolicyResponse struct {
+	Resource string `json:"resource"`
+	Tier     string `json:"tier,omitempty"` // Tier whose limits apply; empty for the default limits
+	Policy
+	Parent         *Policy  `json:"parent,omitempty"`          // Shared limit applied when parent_identifier is sent
+	RolloutPercent *float64 `json:"rollout_percent,omitempty"` // Percentage of keys enforced, if rolling out
+	ReadOnly       bool     `json:"read_only"`
+}
+
+// GetPolicies handles GET /v1/policies - the resolved policy for a resource
+// Resolution goes through the same limiter lookup as checks, so the answer matches enforcement
+func (h *RateLimitHandler) GetPolicies(c *gin.Context) {
+	var req PolicyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	algorithm, tier, limiterInstance, ok := h.resolveLimiter(req.Algorithm, req.Tier)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid algorithm"})
+		return
+	}
+
+	policy, ok := describe(algorithm, limiterInstance)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "policy not available for algorithm"})
+		return
+	}
+
+	resp := PolicyResponse{
+		Resource: req.Resource,
+		Tier:     tier,
+		Policy:   policy,
+		ReadOnly: h.IsReadOnly(),
+	}
+
+	if parent, ok := h.parents[algorithm]; ok {
+		if parentPolicy, ok := describe(algorithm, parent); ok {
+			resp.Parent = &parentPolicy
+		}
+	}
+
+	if h.rollout != nil {
+		percent := h.rollout.Percent()
+		resp.RolloutPercent = &percent
+	}
+
+	if c.NegotiateFormat(binding.MIMEJSON, mimeMarkdown) == mimeMarkdown {
+		c.Data(http.StatusOK, mimeMarkdown+"; charset=utf-8", []byte(resp.Markdown()))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// describe builds the policy of a limiter registered under algorithm
+func describe(algorithm string, l limiter.RateLimiter) (Policy, bool) {
+	d, ok := l.(limiter.Describer)
+	if !ok {
+		return Policy{}, false
+	}
+
+	config := d.Config()
+	if config.Limit == 0 {
+		return Policy{}, false
+	}
+
+	policy := Policy{
+		Algorithm: algorithm,
+		Limit:     config.Limit,
+		Window:    config.Window.String(),
+		Burst:     config.Burst,
+		Precision: config.Precision,
+		MaxDebt:   config.MaxDebt,
+		Alignment: alignment(config.Alignment),
+		Buckets:   config.Buckets,
+		Weighting: config.WeightMode,
+	}
+	if config.ResetJitter > 0 {
+		policy.ResetJitter = config.ResetJitter.String()
+		policy.JitterWindows = config.JitterWindows
+	}
+	return policy, true
+}
+
+// Markdown renders the policy as a table for pasting into client documentation
+func (p PolicyResponse) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Rate limit policy for `%s`\n\n", p.Resource)
+	b.WriteString("| Setting | Value |\n")
+	b.WriteString("| --- | --- |\n")
+	if p.Tier != "" {
+		fmt.Fprintf(&b, "| Tier | %s |\n", p.Tier)
+	}
+	writePolicyRows(&b, "", p.Policy)
+	if p.Parent != nil {
+		writePolicyRows(&b, "Parent ", *p.Parent)
+	}
+	if p.RolloutPercent != nil {
+		fmt.Fprintf(&b, "| Enforced for | %g%% of keys |\n", *p.RolloutPercent)
+	}
+	if p.ReadOnly {
+		b.WriteString("| Read-only | yes, checks do not consume quota |\n")
+	}
+	return b.String()
+}
+
+// writePolicyRows writes the table rows for one policy, labelled with prefix
+func writePolicyRows(b *strings.Builder, prefix string, p Policy) {
+	fmt.Fprintf(b, "| %sAlgorithm | %s |\n", prefix, p.Algorithm)
+	fmt.Fprintf(b, "| %sLimit | %d per %s |\n", prefix, p.Limit, p.Window)
+	if p.Burst > 0 {
+		fmt.Fprintf(b, "| %sBurst | %d |\n", prefix, p.Burst)
+	}
+	if p.Precision != "" {
+		fmt.Fprintf(b, "| %sPrecision | %s |\n", prefix, p.Precision)
+	}
+	if p.MaxDebt > 0 {
+		fmt.Fprintf(b, "| %sMax debt | %d |\n", prefix, p.MaxDebt)
+	}
+	if p.Buckets > 0 {
+		fmt.Fprintf(b, "| %sBuckets | %d |\n", prefix, p.Buckets)
+	}
+	if p.Weighting != "" {
+		fmt.Fprintf(b, "| %sWeighting | %s |\n", prefix, p.Weighting)
+	}
+	if p.Alignment != "" {
+		fmt.Fprintf(b, "| %sResets | at the start of each %s (UTC) |\n", prefix, p.Alignment)
+	}
+	if p.ResetJitter != "" {
+		shifted := "reported reset only"
+		if p.JitterWindows {
+			shifted = "windows shifted per key"
+		}
+		fmt.Fprintf(b, "| %sReset jitter | up to %s per key (%s) |\n", prefix, p.ResetJitter, shifted)
+	}
+}
+
+// alignment returns the calendar alignment worth documenting; epoch windows are the default
+func alignment(a string) string {
+	if a == limiter.AlignEpoch {
+		return ""
+	}
+	return a
+}
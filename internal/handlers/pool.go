@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/gin-gonic/gin"
+)
+
+// WithPool enables pooled org quotas with per-seat caps
+func WithPool(p *algorithms.Pool) Option {
+	return func(h *RateLimitHandler) {
+		h.pool = p
+	}
+}
+
+// PoolCheckRequest represents a pooled quota check for one seat of an org
+type PoolCheckRequest struct {
+	Org   string `json:"org" binding:"required"`
+	Seat  string `json:"seat" binding:"required"`
+	Tier  string `json:"tier" binding:"required"`
+	Count *int   `json:"count"` // Optional: number of requests to consume (default: 1)
+}
+
+// PoolStatusRequest represents a pooled quota status query
+type PoolStatusRequest struct {
+	Tier string `form:"tier" binding:"required"`
+}
+
+// PoolResponse reports org and seat usage
+type PoolResponse struct {
+	Allowed       bool   `json:"allowed"`
+	OrgLimit      int    `json:"org_limit"`
+	OrgRemaining  int    `json:"org_remaining"`
+	SeatLimit     int    `json:"seat_limit"`
+	SeatUsed      int    `json:"seat_used"`
+	SeatRemaining int    `json:"seat_remaining"`
+	ResetAt       string `json:"reset_at"`
+	RetryAfter    *int   `json:"retry_after,omitempty"` // Seconds to wait before retrying
+}
+
+// PoolOverrideRequest replaces an org's tier policy
+type PoolOverrideRequest struct {
+	Limit        int     `json:"limit" binding:"required,gt=0"`
+	Window       string  `json:"window" binding:"required"` // Go duration, e.g. "1h"
+	SeatFraction float64 `json:"seat_fraction" binding:"required,gt=0,lte=1"`
+}
+
+// PoolCheck handles POST /v1/pool/check - consume from an org pool on behalf of a seat
+func (h *RateLimitHandler) PoolCheck(c *gin.Context) {
+	start := time.Now()
+
+	if h.pool == nil {
+		writeError(c, http.StatusNotFound, CodeInvalidRequest, "pooled quotas not enabled")
+		return
+	}
+
+	var req PoolCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	count := 1
+	if req.Count != nil {
+		if err := h.checkCount(*req.Count); err != nil {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+			return
+		}
+		count = *req.Count
+	}
+
+	// In read-only mode answer from current usage without consuming
+	check := h.pool.AllowN
+	if h.IsReadOnly() {
+		check = h.pool.Peek
+	}
+
+	allowed, info, err := check(c.Request.Context(), req.Org, req.Tier, req.Seat, count)
+	if errors.Is(err, algorithms.ErrUnknownTier) {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeLimiterError(c, err, "pool check failed")
+		return
+	}
+
+	h.metrics.RecordRequest("pool", req.Tier, allowed, time.Since(start).Seconds())
+
+	resp := poolResponse(allowed, info)
+	if !allowed {
+		c.JSON(http.StatusTooManyRequests, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// PoolStatus handles GET /v1/pool/status/:org/:seat - org remaining and the seat's consumption
+func (h *RateLimitHandler) PoolStatus(c *gin.Context) {
+	if h.pool == nil {
+		writeError(c, http.StatusNotFound, CodeInvalidRequest, "pooled quotas not enabled")
+		return
+	}
+
+	var req PoolStatusRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	info, err := h.pool.Status(c.Request.Context(), c.Param("org"), req.Tier, c.Param("seat"))
+	if errors.Is(err, algorithms.ErrUnknownTier) {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeLimiterError(c, err, "pool status failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, poolResponse(info.SeatRemaining > 0 && info.OrgRemaining > 0, info))
+}
+
+// SetPoolOverride handles PUT /v1/pool/overrides/:org - replace an org's tier policy
+func (h *RateLimitHandler) SetPoolOverride(c *gin.Context) {
+	if h.pool == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pooled quotas not enabled"})
+		return
+	}
+
+	var req PoolOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := time.ParseDuration(req.Window)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid window: " + err.Error()})
+		return
+	}
+
+	policy := algorithms.PoolPolicy{
+		Limit:        req.Limit,
+		Window:       window,
+		SeatFraction: req.SeatFraction,
+	}
+	if err := h.pool.SetOverride(c.Param("org"), policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"org":           c.Param("org"),
+		"limit":         policy.Limit,
+		"window":        policy.Window.String(),
+		"seat_fraction": policy.SeatFraction,
+		"seat_limit":    policy.SeatLimit(),
+	})
+}
+
+// DeletePoolOverride handles DELETE /v1/pool/overrides/:org - return an org to its tier policy
+func (h *RateLimitHandler) DeletePoolOverride(c *gin.Context) {
+	if h.pool == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pooled quotas not enabled"})
+		return
+	}
+
+	h.pool.ClearOverride(c.Param("org"))
+	c.JSON(http.StatusOK, gin.H{"message": "pool override removed"})
+}
+
+func poolResponse(allowed bool, info *algorithms.PoolInfo) PoolResponse {
+	resp := PoolResponse{
+		Allowed:       allowed,
+		OrgLimit:      info.OrgLimit,
+		OrgRemaining:  info.OrgRemaining,
+		SeatLimit:     info.SeatLimit,
+		SeatUsed:      info.SeatUsed,
+		SeatRemaining: info.SeatRemaining,
+		ResetAt:       info.ResetAt.Format(time.RFC3339),
+	}
+	if info.RetryAfter != nil {
+		retrySeconds := info.RetryAfterSeconds()
+		resp.RetryAfter = &retrySeconds
+	}
+	return resp
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+)
+
+// Priority check results recorded in metrics
+const (
+	priorityAllowed = "allowed"
+	priorityDenied  = "denied"
+	priorityShed    = "shed"
+)
+
+// Priorities maps a priority class to the fraction of each limit held back from it, so
+// that as a limit runs out low priority checks are denied before normal ones, and normal
+// before high. High priority may use the whole limit
+type Priorities map[string]float64
+
+// WithPriorities sets the capacity reserved from lower priority checks
+func WithPriorities(priorities Priorities) Option {
+	return func(h *RateLimitHandler) {
+		h.priorities = priorities
+	}
+}
+
+// Validate checks that only normal and low priorities reserve capacity, that each
+// reserve leaves some capacity usable, and that low never gets more than normal
+func (p Priorities) Validate() error {
+	for priority, reserve := range p {
+		if priority != algorithms.PriorityNormal && priority != algorithms.PriorityLow {
+			return fmt.Errorf("priority reserve for %q: only %q and %q can be reserved from",
+				priority, algorithms.PriorityNormal, algorithms.PriorityLow)
+		}
+		if reserve < 0 || reserve >= 1 {
+			return fmt.Errorf("priority reserve for %q must be in [0, 1), got %v", priority, reserve)
+		}
+	}
+	if p[algorithms.PriorityLow] < p[algorithms.PriorityNormal] {
+		return fmt.Errorf("low priority reserve %v must be at least the normal reserve %v",
+			p[algorithms.PriorityLow], p[algorithms.PriorityNormal])
+	}
+	return nil
+}
+
+// resolve returns a check's priority class, defaulting to normal, and the reserve held back from it
+func (p Priorities) resolve(priority string) (string, float64, error) {
+	switch priority {
+	case "":
+		priority = algorithms.PriorityNormal
+	case algorithms.PriorityHigh, algorithms.PriorityNormal, algorithms.PriorityLow:
+	default:
+		return "", 0, fmt.Errorf("invalid priority %q: must be %s, %s or %s", priority,
+			algorithms.PriorityHigh, algorithms.PriorityNormal, algorithms.PriorityLow)
+	}
+	return priority, p[priority], nil
+}
+
+// priorityResult names the outcome of a check for the priority metrics
+func priorityResult(allowed, shed bool) string {
+	switch {
+	case allowed:
+		return priorityAllowed
+	case shed:
+		return priorityShed
+	default:
+		return priorityDenied
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+)
+
+// WithQuota stacks a long-horizon quota on every check, e.g. 1,000,000 requests per month
+// on top of the per-minute limits: a request must fit both, and is counted against the
+// quota only if the algorithm's limit allows it
+func WithQuota(quota *algorithms.QuotaLimiter) Option {
+	return func(h *RateLimitHandler) {
+		h.quota = quota
+	}
+}
+
+// QuotaStatus reports a key's usage of the quota in the current period
+type QuotaStatus struct {
+	Used    int64  `json:"used"`
+	Limit   int64  `json:"limit"`
+	Period  string `json:"period"`   // ID of the current period, e.g. "2024-06"
+	ResetAt string `json:"reset_at"` // When the period ends
+}
+
+// withQuota nests rl under the quota, keyed the same, if a quota is configured
+func (h *RateLimitHandler) withQuota(rl limiter.RateLimiter) limiter.RateLimiter {
+	if h.quota == nil {
+		return rl
+	}
+	return algorithms.NewHierarchical(h.quota, rl, func(key string) string { return key })
+}
+
+// quotaStatus reads the key's quota usage for a response, or nil if there is no quota
+// Usage is informational, so a failed read leaves it out rather than failing the request
+func (h *RateLimitHandler) quotaStatus(ctx context.Context, key string) *QuotaStatus {
+	if h.quota == nil {
+		return nil
+	}
+	usage, err := h.quota.Usage(ctx, key)
+	if err != nil {
+		return nil
+	}
+	return &QuotaStatus{
+		Used:    usage.Used,
+		Limit:   usage.Limit,
+		Period:  usage.Period,
+		ResetAt: usage.ResetAt.Format(time.RFC3339),
+	}
+}
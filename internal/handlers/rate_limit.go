@@ -1,14 +1,21 @@
 package handlers
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/keys"
 	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RateLimitHandler handles rate limiting HTTP requests
@@ -16,15 +23,104 @@ type RateLimitHandler struct {
 	limiters         map[string]limiter.RateLimiter // algorithm name -> limiter
 	metrics          *metrics.Metrics
 	defaultAlgorithm string // default algorithm name
+	dedup            *dedupGuard
+	requestIDs       *requestIDs // Replays responses to retried request IDs (nil = disabled)
+	rollout          *algorithms.Rollout
+	headers          map[string]bool // Enabled rate limit headers (nil = defaults)
+	headerBudget     int             // Max bytes of rate limit headers (0 = unlimited)
+	headerPriority   []string        // Emission order of the optional header groups
+	headerStyle      string          // Names of the core headers: legacy, draft or both ("" = legacy)
+	readOnly         atomic.Bool     // Refuse mutations and answer checks from peeks
+	readOnlyBias     string          // Decision when a read-only check cannot peek
+	history          *store.History  // Per-window usage recorder (nil = disabled)
+	pool             *algorithms.Pool
+	parents          map[string]limiter.RateLimiter            // algorithm name -> parent limiter (nil = no hierarchy)
+	tiers            map[string]map[string]limiter.RateLimiter // tier -> algorithm name -> limiter
+	metadata         MetadataConfig
+	costs            Costs                    // Resource -> units consumed per check (nil = 1 each)
+	weights          Weights                  // Resource prefix -> multiplier on each check's count (nil = 1 each)
+	maxCount         int                      // Largest count a check may send (0 = DefaultMaxCount)
+	priorities       Priorities               // Priority -> fraction of each limit held back (nil = none)
+	quota            *algorithms.QuotaLimiter // Long-horizon quota stacked on every check (nil = none)
+	penalties        *algorithms.Penalties    // Repeat offender tracking, reported in status (nil = none)
+	bans             *algorithms.Bans         // Temporary bans of keys that keep being denied (nil = none)
+	overrides        *algorithms.Overrides    // Per-key limits set at runtime (nil = disabled)
+	keyGroups        limiter.KeyGroupStore    // Identifier -> group sharing its limits (nil = disabled)
+	keyScheme        keys.Scheme              // Builds limiter keys from identifier and resource
+	reservations     *algorithms.Reservations // Two-phase quota holds (nil = disabled)
+	store            limiter.Store            // Pinged by health checks (nil = not checked)
+	softLimits       atomic.Pointer[SoftLimits]
+	ui               *UIConfig // Operator page settings (nil = disabled)
+	hooks            []DecisionHook
+	tracer           trace.Tracer // Traces requests as server spans (nil = not traced)
+	clientTimestamps bool         // Evaluate checks as of their timestamp field
+}
+
+// Option configures optional RateLimitHandler behavior
+type Option func(*RateLimitHandler)
+
+// WithDedup rejects identical check payloads within the window enforced by rl, a limit of 1
+// that should count the window from each payload's first check, as a sliding window log does
+// Only the listed top-level body fields are hashed; an empty list hashes the whole body
+func WithDedup(rl limiter.RateLimiter, fields []string) Option {
+	return func(h *RateLimitHandler) {
+		h.dedup = &dedupGuard{limiter: rl, fields: fields}
+	}
+}
+
+// WithRequestIDs enables request_id on checks: a retry with the same ID within ttl replays
+// the first response from recorder instead of consuming again
+func WithRequestIDs(recorder limiter.RequestRecorder, ttl time.Duration) Option {
+	return func(h *RateLimitHandler) {
+		h.requestIDs = &requestIDs{recorder: recorder, ttl: ttl}
+	}
+}
+
+// WithParentLimiters enables parent_identifier on checks, nesting each key under the
+// matching algorithm's limiter in parents
+func WithParentLimiters(parents map[string]limiter.RateLimiter) Option {
+	return func(h *RateLimitHandler) {
+		h.parents = parents
+	}
+}
+
+// WithTierLimiters lets checks select limits by tier; tiers maps each tier name to
+// its limiter per algorithm. Checks with no tier, or a tier not in tiers, use the default limits
+func WithTierLimiters(tiers map[string]map[string]limiter.RateLimiter) Option {
+	return func(h *RateLimitHandler) {
+		h.tiers = tiers
+	}
+}
+
+// WithClientTimestamps evaluates checks carrying a timestamp as of that time rather than now
+// Without it such checks are rejected, since accepting client timestamps is a trust decision
+func WithClientTimestamps() Option {
+	return func(h *RateLimitHandler) {
+		h.clientTimestamps = true
+	}
+}
+
+// WithStore makes health checks ping s, reporting the node unhealthy while it is unreachable
+func WithStore(s limiter.Store) Option {
+	return func(h *RateLimitHandler) {
+		h.store = s
+	}
 }
 
 // NewRateLimitHandler creates a new rate limit handler
-func NewRateLimitHandler(limiters map[string]limiter.RateLimiter, metrics *metrics.Metrics, defaultAlgorithm string) *RateLimitHandler {
-	return &RateLimitHandler{
+func NewRateLimitHandler(limiters map[string]limiter.RateLimiter, metrics *metrics.Metrics, defaultAlgorithm string, opts ...Option) *RateLimitHandler {
+	h := &RateLimitHandler{
 		limiters:         limiters,
 		metrics:          metrics,
 		defaultAlgorithm: defaultAlgorithm,
+		readOnlyBias:     BiasAllow,
+		keyScheme:        keys.Escaped{},
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.metrics.RecordReadOnly(h.IsReadOnly())
+	return h
 }
 
 // CheckRequest represents a rate limit check request
@@ -32,7 +128,23 @@ type CheckRequest struct {
 	Resource   string `json:"resource" binding:"required"`   // Resource being accessed (e.g., "api.users.create")
 	Identifier string `json:"identifier" binding:"required"` // User/client identifier
 	Algorithm  string `json:"algorithm"`                     // Optional: override default algorithm
-	Count      int    `json:"count"`                         // Optional: number of tokens to consume (default: 1)
+	Count      *int   `json:"count"`                         // Optional: number of tokens to consume (default: the resource's cost)
+	Tier       string `json:"tier"`                          // Optional: plan tier whose limits apply (default limits if unknown)
+	Priority   string `json:"priority"`                      // Optional: high, normal or low (default: normal)
+
+	// Optional: org or account the identifier belongs to; the check must also fit its shared limit
+	ParentIdentifier string `json:"parent_identifier"`
+
+	// Optional: correlation context (trace ID, gateway request ID, tenant) passed to decision
+	// consumers; never affects the decision or the key
+	Metadata limiter.Metadata `json:"metadata"`
+
+	// Optional: RFC 3339 time to evaluate the check as of, when client timestamps are enabled
+	Timestamp *time.Time `json:"timestamp"`
+
+	// Optional: ID of the logical operation, when request IDs are enabled; retries with the
+	// same ID replay the first response instead of consuming again
+	RequestID string `json:"request_id" binding:"max=128"`
 }
 
 // CheckResponse represents a rate limit check response
@@ -40,8 +152,27 @@ type CheckResponse struct {
 	Allowed    bool   `json:"allowed"`
 	Limit      int    `json:"limit"`
 	Remaining  int    `json:"remaining"`
+	Used       int    `json:"used"`   // Requests counted against the limit; limit - remaining
+	Window     int    `json:"window"` // Seconds the limit applies over, for client-side pacing
 	ResetAt    string `json:"reset_at"`
 	RetryAfter *int   `json:"retry_after,omitempty"` // Seconds to wait before retrying
+	Reason     string `json:"reason,omitempty"`      // Why the check was denied, e.g. window_exhausted
+	Policy     string `json:"policy,omitempty"`      // Window that decided under multi-window limits, else the tier
+	Tier       string `json:"tier,omitempty"`        // Tier whose limits applied; empty for the default limits
+	Group      string `json:"group,omitempty"`       // Key group whose shared limits applied
+	Cost       int    `json:"cost"`                  // Units the check consumed, or would have
+	Warning    bool   `json:"warning,omitempty"`     // Allowed, but the key is past its soft limit
+	Banned     bool   `json:"banned,omitempty"`      // Denied by a temporary ban; reset_at is when it ends
+	Code       string `json:"code,omitempty"`        // RATE_LIMITED on 429 responses
+
+	// Usage of the long-horizon quota, when one is configured
+	Quota *QuotaStatus `json:"quota,omitempty"`
+
+	// The key's escalating penalty for retrying while denied, on status responses when penalties are on
+	Penalty *PenaltyStatus `json:"penalty,omitempty"`
+
+	// The runtime override limit and window come from, on status responses for overridden keys
+	Override *OverrideStatus `json:"override,omitempty"`
 }
 
 // Check handles POST /v1/check - check if request is allowed
@@ -49,89 +180,281 @@ func (h *RateLimitHandler) Check(c *gin.Context) {
 	start := time.Now()
 
 	var req CheckRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if err := h.validateMetadata(req.Metadata); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	cost, err := h.checkCost(req)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	priority, reserve, err := h.priorities.resolve(req.Priority)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
-	// Default to 1 token if not specified
-	if req.Count == 0 {
-		req.Count = 1
+	// Evaluate the check as of its timestamp; every limiter below reads it from the context
+	if req.Timestamp != nil {
+		if !h.clientTimestamps {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "client timestamps are not enabled")
+			return
+		}
+		c.Request = c.Request.WithContext(limiter.WithRequestTime(c.Request.Context(), *req.Timestamp))
 	}
 
-	// Select algorithm
-	algorithm := req.Algorithm
-	if algorithm == "" {
-		algorithm = h.defaultAlgorithm
+	readOnly := h.IsReadOnly()
+
+	// Replay the response to an earlier check with the same request ID
+	// Skipped in read-only mode, where checks consume nothing and recording is a write
+	var claim *requestClaim
+	if req.RequestID != "" {
+		if h.requestIDs == nil {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "request IDs are not enabled")
+			return
+		}
+		if !readOnly {
+			id, err := h.requestID(req)
+			if err != nil {
+				writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+				return
+			}
+			var replay *recordedCheck
+			claim, replay, err = h.requestIDs.claim(c.Request.Context(), id)
+			if err != nil {
+				writeLimiterError(c, err, "request ID check failed")
+				return
+			}
+			if claim == nil {
+				h.metrics.RecordRequestIDReplay(replay != nil)
+				if replay == nil {
+					writeError(c, http.StatusConflict, CodeInProgress, "a check with this request ID is in progress")
+					return
+				}
+				replay.write(c)
+				return
+			}
+			defer claim.release(c.Request.Context())
+		}
 	}
 
-	limiterInstance, ok := h.limiters[algorithm]
+	// Reject identical payloads submitted within the dedup window
+	// Skipped in read-only mode since recording the payload is a write
+	if h.dedup != nil && !readOnly {
+		body := c.MustGet(gin.BodyBytesKey).([]byte)
+		duplicate, err := h.dedup.isDuplicate(c.Request.Context(), body)
+		if err != nil {
+			writeLimiterError(c, err, "duplicate check failed")
+			return
+		}
+		if duplicate {
+			writeError(c, http.StatusConflict, CodeDuplicateRequest, "duplicate request")
+			return
+		}
+	}
+
+	// Select algorithm and tier
+	algorithm, tier, limiterInstance, ok := h.resolveLimiter(req.Algorithm, req.Tier)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid algorithm"})
+		writeError(c, http.StatusBadRequest, CodeInvalidAlgorithm, "invalid algorithm")
+		return
+	}
+
+	// Create rate limit key, shared with the identifier's group if it is in one
+	key, group, err := h.limiterKey(c.Request.Context(), req.Identifier, req.Resource)
+	if err != nil {
+		writeLimiterError(c, err, "key group lookup failed")
 		return
 	}
 
-	// Create rate limit key
-	key := req.Identifier + ":" + req.Resource
+	// Nest the key under its parent's shared limit
+	if req.ParentIdentifier != "" {
+		parent, ok := h.parents[algorithm]
+		if !ok {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, "hierarchical limits are not enabled")
+			return
+		}
+		parentKey, err := h.keyScheme.Join(req.ParentIdentifier, req.Resource)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+			return
+		}
+		parentKey = parentKeyPrefix + parentKey
+		limiterInstance = algorithms.NewHierarchical(parent, limiterInstance, func(string) string { return parentKey })
+	}
+	limiterInstance = h.withQuota(limiterInstance)
+
+	// Hold back the end of the limit from lower priorities
+	var prioritized *algorithms.PriorityLimiter
+	if reserve > 0 {
+		prioritized, err = algorithms.NewPriorityLimiter(limiterInstance, reserve)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+			return
+		}
+		limiterInstance = prioritized
+	}
 
-	// Check rate limit
-	allowed, info, err := limiterInstance.AllowN(key, req.Count)
+	// Banned keys are denied without consulting the limiter
+	info, err := h.banInfo(c.Request.Context(), limiterInstance, key)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "rate limit check failed"})
+		writeLimiterError(c, err, "ban check failed")
 		return
 	}
+	banned := info != nil
+
+	// Check rate limit; in read-only mode decide from current state without consuming
+	var allowed, shed bool
+	switch {
+	case banned:
+	case readOnly:
+		allowed, info = h.peekDecision(c.Request.Context(), limiterInstance, key, cost)
+	default:
+		if prioritized != nil {
+			allowed, shed, info, err = prioritized.AllowNShed(c.Request.Context(), key, cost)
+		} else {
+			allowed, info, err = limiterInstance.AllowNCtx(c.Request.Context(), key, cost)
+		}
+		if err != nil {
+			writeLimiterError(c, err, "rate limit check failed")
+			return
+		}
+		// Count the denial toward a ban; the one that places it reports the ban
+		if !allowed {
+			banned = h.recordDenial(c.Request.Context(), key, info)
+		}
+	}
+
+	if info.Policy == "" {
+		info.Policy = tier
+	}
+
+	// Warn allowed checks past the soft limit; read-only checks consumed nothing and are not warned
+	var warning bool
+	if allowed && !readOnly {
+		var crossed bool
+		warning, crossed = h.softLimit(tier, info, cost)
+		if crossed {
+			h.metrics.RecordSoftLimit(strings.Split(req.Resource, ".")[0])
+		}
+	}
 
 	// Record metrics
 	latency := time.Since(start).Seconds()
 	keyPrefix := strings.Split(req.Resource, ".")[0]
 	h.metrics.RecordRequest(algorithm, keyPrefix, allowed, latency)
+	h.metrics.RecordRemaining(algorithm, keyPrefix, info.Remaining)
+	h.metrics.RecordPriority(priority, priorityResult(allowed, shed))
+
+	// Record usage history; best effort so reporting never fails a check
+	if h.history != nil && !readOnly {
+		h.history.Record(c.Request.Context(), key, allowed, start)
+	}
+
+	h.recordDecision(c.Request.Context(), Decision{
+		Key:       key,
+		Algorithm: algorithm,
+		Allowed:   allowed,
+		ReadOnly:  readOnly,
+		Info:      info,
+		Metadata:  req.Metadata,
+		At:        start,
+	})
 
 	// Build response
 	resp := CheckResponse{
 		Allowed:   allowed,
 		Limit:     info.Limit,
 		Remaining: info.Remaining,
+		Used:      info.Used,
+		Window:    int(info.Window.Seconds()),
 		ResetAt:   info.ResetAt.Format(time.RFC3339),
+		Policy:    info.Policy,
+		Tier:      tier,
+		Group:     group,
+		Cost:      cost,
+		Warning:   warning,
+		Banned:    banned,
+		Quota:     h.quotaStatus(c.Request.Context(), key),
 	}
 
 	if info.RetryAfter != nil {
-		retrySeconds := int(info.RetryAfter.Seconds())
+		retrySeconds := info.RetryAfterSeconds()
 		resp.RetryAfter = &retrySeconds
 	}
 
 	// Set standard rate limit headers
-	c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", info.Limit))
-	c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", info.Remaining))
-	c.Header("X-RateLimit-Reset", fmt.Sprintf("%d", info.ResetAt.Unix()))
-	if info.RetryAfter != nil {
-		c.Header("Retry-After", fmt.Sprintf("%d", int(info.RetryAfter.Seconds())))
+	var warningHeader string
+	switch {
+	case readOnly:
+		warningHeader = readOnlyWarning
+	case warning:
+		warningHeader = softLimitWarning
 	}
+	var before http.Header
+	if claim != nil {
+		before = c.Writer.Header().Clone()
+	}
+	h.writeRateLimitHeaders(c, algorithm, info, warningHeader)
 
 	// Return 429 if rate limited
+	status := http.StatusOK
 	if !allowed {
-		c.JSON(http.StatusTooManyRequests, resp)
-		return
+		resp.Reason = info.Reason
+		resp.Code = CodeRateLimited
+		status = http.StatusTooManyRequests
 	}
 
-	c.JSON(http.StatusOK, resp)
+	// Keep the response for retries of the same request ID
+	if claim != nil {
+		claim.record(c, status, resp, before)
+	}
+	c.JSON(status, resp)
+}
+
+// resolveLimiter returns the algorithm, tier and limiter a check is enforced by
+// An empty algorithm resolves to the default. A tier without its own limits resolves to
+// the default limits and is reported as the empty tier. ok is false if the algorithm is not configured
+func (h *RateLimitHandler) resolveLimiter(algorithm, tier string) (string, string, limiter.RateLimiter, bool) {
+	if algorithm == "" {
+		algorithm = h.defaultAlgorithm
+	}
+	if tierLimiters, ok := h.tiers[tier]; ok && tier != "" {
+		if limiterInstance, ok := tierLimiters[algorithm]; ok {
+			return algorithm, tier, limiterInstance, true
+		}
+	}
+	limiterInstance, ok := h.limiters[algorithm]
+	return algorithm, "", limiterInstance, ok
 }
 
+// parentKeyPrefix keeps parent keys apart from identifiers that happen to share a name
+const parentKeyPrefix = "parent:"
+
+// AllAlgorithms requests status under every configured algorithm
+const AllAlgorithms = "all"
+
 // StatusRequest represents a status check request
 type StatusRequest struct {
-	Algorithm string `form:"algorithm"` // Optional: algorithm to check
+	Algorithm string `form:"algorithm"` // Optional: algorithm to check, or "all"
 }
 
 // GetStatus handles GET /v1/status/:key - get current limit status
 func (h *RateLimitHandler) GetStatus(c *gin.Context) {
 	key := c.Param("key")
 	if key == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "key is required")
 		return
 	}
 
 	var req StatusRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -141,40 +464,101 @@ func (h *RateLimitHandler) GetStatus(c *gin.Context) {
 		algorithm = h.defaultAlgorithm
 	}
 
+	// Members of a key group report the group's state
+	key, group, err := h.resolveKey(c.Request.Context(), key)
+	if err != nil {
+		writeLimiterError(c, err, "key group lookup failed")
+		return
+	}
+
+	if algorithm == AllAlgorithms {
+		h.getStatusAll(c, key, group)
+		return
+	}
+
 	limiterInstance, ok := h.limiters[algorithm]
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid algorithm"})
+		writeError(c, http.StatusBadRequest, CodeInvalidAlgorithm, "invalid algorithm")
 		return
 	}
 
-	// Check current status without consuming tokens
-	allowed, info, err := limiterInstance.AllowN(key, 0)
+	// Read the current status without consuming or writing anything
+	info, err := h.withQuota(limiterInstance).StatusCtx(c.Request.Context(), key)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "status check failed"})
+		writeLimiterError(c, err, "status check failed")
 		return
 	}
 
 	resp := CheckResponse{
-		Allowed:   allowed,
+		Allowed:   info.Remaining > 0,
 		Limit:     info.Limit,
 		Remaining: info.Remaining,
+		Used:      info.Used,
+		Window:    int(info.Window.Seconds()),
 		ResetAt:   info.ResetAt.Format(time.RFC3339),
+		Policy:    info.Policy,
+		Group:     group,
+		Quota:     h.quotaStatus(c.Request.Context(), key),
+		Penalty:   h.penaltyStatus(c.Request.Context(), key),
+		Override:  h.overrideStatus(c.Request.Context(), key),
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
+// getStatusAll reports the status of key under every configured algorithm
+// Each limiter is peeked so comparing algorithms never consumes quota
+func (h *RateLimitHandler) getStatusAll(c *gin.Context, key, group string) {
+	statuses := make(map[string]CheckResponse, len(h.limiters))
+	for name, limiterInstance := range h.limiters {
+		peeker, ok := limiterInstance.(limiter.Peeker)
+		if !ok {
+			continue
+		}
+
+		allowed, info, err := peeker.Peek(c.Request.Context(), key, 1)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, APIError{Code: CodeStoreError, Message: "status check failed", Details: gin.H{"algorithm": name}})
+			return
+		}
+
+		statuses[name] = CheckResponse{
+			Allowed:   allowed,
+			Limit:     info.Limit,
+			Remaining: info.Remaining,
+			Used:      info.Used,
+			Window:    int(info.Window.Seconds()),
+			ResetAt:   info.ResetAt.Format(time.RFC3339),
+		}
+	}
+
+	resp := gin.H{
+		"key":        key,
+		"algorithms": statuses,
+	}
+	if group != "" {
+		resp["group"] = group
+	}
+	if quota := h.quotaStatus(c.Request.Context(), key); quota != nil {
+		resp["quota"] = quota
+	}
+	if penalty := h.penaltyStatus(c.Request.Context(), key); penalty != nil {
+		resp["penalty"] = penalty
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 // Reset handles POST /v1/reset/:key - reset limits for a key
 func (h *RateLimitHandler) Reset(c *gin.Context) {
 	key := c.Param("key")
 	if key == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "key is required")
 		return
 	}
 
 	var req StatusRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -186,23 +570,124 @@ func (h *RateLimitHandler) Reset(c *gin.Context) {
 
 	limiterInstance, ok := h.limiters[algorithm]
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid algorithm"})
+		writeError(c, http.StatusBadRequest, CodeInvalidAlgorithm, "invalid algorithm")
+		return
+	}
+
+	// Resetting a member of a key group resets the group
+	key, _, err := h.resolveKey(c.Request.Context(), key)
+	if err != nil {
+		writeLimiterError(c, err, "key group lookup failed")
 		return
 	}
 
 	// Reset the limit
-	if err := limiterInstance.Reset(key); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "reset failed"})
+	if err := limiterInstance.ResetCtx(c.Request.Context(), key); err != nil {
+		writeLimiterError(c, err, "reset failed")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "rate limit reset successfully"})
 }
 
+// ResetPrefixRequest resets every key under a prefix
+type ResetPrefixRequest struct {
+	Prefix    string `json:"prefix" binding:"required"` // Key prefix, e.g. "tenant42:"
+	Algorithm string `json:"algorithm"`                 // Optional: algorithm to reset (default: configured default)
+}
+
+// ResetPrefix handles POST /v1/reset-prefix - reset limits for every key starting with a prefix
+func (h *RateLimitHandler) ResetPrefix(c *gin.Context) {
+	var req ResetPrefixRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	algorithm := req.Algorithm
+	if algorithm == "" {
+		algorithm = h.defaultAlgorithm
+	}
+	limiterInstance, ok := h.limiters[algorithm]
+	if !ok {
+		writeError(c, http.StatusBadRequest, CodeInvalidAlgorithm, "invalid algorithm")
+		return
+	}
+	resetter, ok := limiterInstance.(limiter.PrefixResetter)
+	if !ok {
+		writeError(c, http.StatusNotImplemented, CodeInvalidRequest, "algorithm does not support prefix resets")
+		return
+	}
+
+	reset, err := resetter.ResetPrefixCtx(c.Request.Context(), req.Prefix)
+	if errors.Is(err, errors.ErrUnsupported) {
+		writeError(c, http.StatusNotImplemented, CodeInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeLimiterError(c, err, "prefix reset failed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"prefix": req.Prefix, "algorithm": algorithm, "reset": reset})
+}
+
+// writeLimiterError responds to a failed limiter or store call
+// Unreachable stores are 503s, invalid input 400s and anything else a 500 with message
+func writeLimiterError(c *gin.Context, err error, message string) {
+	switch {
+	case isUnavailable(err):
+		writeError(c, http.StatusServiceUnavailable, CodeStoreUnavailable, "store unavailable")
+	case isInvalidRequest(err):
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, CodeStoreError, message)
+	}
+}
+
+// isUnavailable reports whether err's limiter could not reach its store, because the store is
+// down or the limiter was closed on shutdown; retrying against another instance may succeed
+func isUnavailable(err error) bool {
+	return errors.Is(err, limiter.ErrStoreUnavailable) || errors.Is(err, limiter.ErrClosed)
+}
+
+// isInvalidRequest reports whether a limiter rejected err's request itself, rather than failing
+func isInvalidRequest(err error) bool {
+	return errors.Is(err, limiter.ErrInvalidN) || errors.Is(err, limiter.ErrKeyTooLong) || errors.Is(err, limiter.ErrRequestExceedsCapacity) ||
+		errors.Is(err, limiter.ErrKeysNotColocated) || errors.Is(err, limiter.ErrEmptyPrefix) || errors.Is(err, keys.ErrInvalidPart)
+}
+
+// storePingTimeout bounds the store ping in health checks, so a hung store fails the check
+// instead of the load balancer's probe timing out
+const storePingTimeout = time.Second
+
 // Health handles GET /health - health check
+// Returns 503 while the store is unreachable, so load balancers drain the node
 func (h *RateLimitHandler) Health(c *gin.Context) {
+	if h.store != nil {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), storePingTimeout)
+		defer cancel()
+		if err := h.store.Ping(ctx); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":    "unhealthy",
+				"store":     err.Error(),
+				"time":      time.Now().Format(time.RFC3339),
+				"read_only": h.IsReadOnly(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "healthy",
+		"time":      time.Now().Format(time.RFC3339),
+		"read_only": h.IsReadOnly(),
+	})
+}
+
+// GetVersion handles GET /version - server version and operating mode
+func (h *RateLimitHandler) GetVersion(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status": "healthy",
-		"time":   time.Now().Format(time.RFC3339),
+		"version":   Version,
+		"read_only": h.IsReadOnly(),
 	})
 }
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/gin-gonic/gin"
+)
+
+// Decisions applied by checks in read-only mode when limit state cannot be read
+const (
+	BiasAllow = "allow"
+	BiasDeny  = "deny"
+)
+
+// WithReadOnly sets the initial read-only state and the fallback bias for checks
+// In read-only mode mutating endpoints return 503 and checks are answered from
+// a non-consuming peek; when no peek is possible the bias decides
+func WithReadOnly(enabled bool, bias string) Option {
+	return func(h *RateLimitHandler) {
+		h.readOnly.Store(enabled)
+		h.readOnlyBias = bias
+	}
+}
+
+// ValidateBias checks a read-only bias value
+func ValidateBias(bias string) error {
+	if bias != BiasAllow && bias != BiasDeny {
+		return fmt.Errorf("read-only bias must be %q or %q, got %q", BiasAllow, BiasDeny, bias)
+	}
+	return nil
+}
+
+// IsReadOnly reports whether the handler is refusing mutations
+func (h *RateLimitHandler) IsReadOnly() bool {
+	return h.readOnly.Load()
+}
+
+// setReadOnly switches read-only mode and updates the metric
+func (h *RateLimitHandler) setReadOnly(enabled bool) {
+	h.readOnly.Store(enabled)
+	h.metrics.RecordReadOnly(enabled)
+}
+
+// RequireWritable rejects the request with 503 while in read-only mode
+// Mount it in front of every route that mutates limiter or admin state
+func (h *RateLimitHandler) RequireWritable(c *gin.Context) {
+	if h.IsReadOnly() {
+		writeError(c, http.StatusServiceUnavailable, CodeReadOnly, "server is in read-only mode")
+		return
+	}
+	c.Next()
+}
+
+// peekDecision answers a check without mutating state
+// Falls back to the configured bias when the limiter cannot peek or the read fails
+func (h *RateLimitHandler) peekDecision(ctx context.Context, rl limiter.RateLimiter, key string, n int) (bool, *limiter.LimitInfo) {
+	if peeker, ok := rl.(limiter.Peeker); ok {
+		if allowed, info, err := peeker.Peek(ctx, key, n); err == nil {
+			return allowed, info
+		}
+	}
+
+	info := &limiter.LimitInfo{ResetAt: time.Now()}
+	if h.readOnlyBias == BiasDeny {
+		retryAfter := time.Second
+		info.RetryAfter = &retryAfter
+		return false, info
+	}
+	return true, info
+}
+
+// ReadOnlyRequest represents a read-only mode change
+type ReadOnlyRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// GetReadOnly handles GET /v1/read-only - current read-only state
+func (h *RateLimitHandler) GetReadOnly(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"read_only": h.IsReadOnly(),
+		"bias":      h.readOnlyBias,
+	})
+}
+
+// SetReadOnly handles PUT /v1/read-only - toggle read-only mode
+// Deliberately not guarded by RequireWritable so read-only mode can be turned off
+func (h *RateLimitHandler) SetReadOnly(c *gin.Context) {
+	var req ReadOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.setReadOnly(*req.Enabled)
+	c.JSON(http.StatusOK, gin.H{
+		"read_only": h.IsReadOnly(),
+		"bias":      h.readOnlyBias,
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderIdempotentReplayed marks a check response replayed for a retried request ID
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+// requestIDs replays check responses to retries carrying the same request ID, so a retried
+// check is not charged twice
+type requestIDs struct {
+	recorder limiter.RequestRecorder
+	ttl      time.Duration // How long a response is replayed for
+}
+
+// recordedCheck is a check response kept under its request ID
+type recordedCheck struct {
+	Status  int           `json:"status"`
+	Headers http.Header   `json:"headers,omitempty"` // Rate limit headers the check set
+	Body    CheckResponse `json:"body"`
+}
+
+// requestClaim is a check's claim on its request ID, settled by recording the check's
+// response or, if the check fails, by releasing the ID
+type requestClaim struct {
+	ids     *requestIDs
+	id      string
+	settled bool
+}
+
+// requestID scopes a client's request ID to the identifier and resource it was sent for
+func (h *RateLimitHandler) requestID(req CheckRequest) (string, error) {
+	key, err := h.keyScheme.Join(req.Identifier, req.Resource)
+	if err != nil {
+		return "", err
+	}
+	return key + ":" + req.RequestID, nil
+}
+
+// claim claims id for a check. When id was seen before, claim is nil and replay is the
+// response to send again, or nil while the first check is still running
+func (r *requestIDs) claim(ctx context.Context, id string) (*requestClaim, *recordedCheck, error) {
+	claimed, result, err := r.recorder.ClaimRequestCtx(ctx, id, r.ttl)
+	switch {
+	case err != nil:
+		return nil, nil, err
+	case claimed:
+		return &requestClaim{ids: r, id: id}, nil, nil
+	case result == nil:
+		return nil, nil, nil
+	}
+
+	var replay recordedCheck
+	if err := json.Unmarshal(result, &replay); err != nil {
+		return nil, nil, err
+	}
+	return nil, &replay, nil
+}
+
+// record keeps the check's response for retries, with the headers set on c since before
+// Best effort: a response that cannot be recorded releases the ID, so a retry checks again
+func (rc *requestClaim) record(c *gin.Context, status int, resp CheckResponse, before http.Header) {
+	rc.settled = true
+
+	headers := make(http.Header)
+	for name, values := range c.Writer.Header() {
+		if !slices.Equal(before[name], values) {
+			headers[name] = values
+		}
+	}
+	data, err := json.Marshal(recordedCheck{Status: status, Headers: headers, Body: resp})
+	if err == nil {
+		err = rc.ids.recorder.RecordRequestCtx(c.Request.Context(), rc.id, data, rc.ids.ttl)
+	}
+	if err != nil {
+		log.Printf("Failed to record response for request ID: %v", err)
+		rc.ids.recorder.ReleaseRequestCtx(c.Request.Context(), rc.id)
+	}
+}
+
+// release frees the ID of a check that ended without a response to record
+func (rc *requestClaim) release(ctx context.Context) {
+	if rc == nil || rc.settled {
+		return
+	}
+	if err := rc.ids.recorder.ReleaseRequestCtx(ctx, rc.id); err != nil {
+		log.Printf("Failed to release request ID: %v", err)
+	}
+}
+
+// write sends a recorded response again
+func (rec *recordedCheck) write(c *gin.Context) {
+	for name, values := range rec.Headers {
+		c.Writer.Header()[name] = values
+	}
+	c.Header(HeaderIdempotentReplayed, "true")
+	c.JSON(rec.Status, rec.Body)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/gin-gonic/gin"
+)
+
+// WithReservations enables the two-phase reserve, commit and cancel endpoints, holding
+// reservations in s for ttl (0 = algorithms.DefaultReservationTTL)
+func WithReservations(s limiter.ReservationStore, ttl time.Duration, opts ...algorithms.Option) Option {
+	return func(h *RateLimitHandler) {
+		h.reservations = algorithms.NewReservations(s, ttl, h.reservationLimiter, opts...)
+	}
+}
+
+// WatchReservations returns the quota of expired reservations every interval until ctx is
+// done. It returns at once when reservations are not enabled
+func (h *RateLimitHandler) WatchReservations(ctx context.Context, interval time.Duration) {
+	if h.reservations != nil {
+		h.reservations.Watch(ctx, interval)
+	}
+}
+
+// ReserveRequest represents a request to hold quota for work that may fail
+type ReserveRequest struct {
+	Resource   string `json:"resource" binding:"required"`
+	Identifier string `json:"identifier" binding:"required"`
+	Algorithm  string `json:"algorithm"` // Optional: override default algorithm
+	Count      *int   `json:"count"`     // Optional: units to reserve (default: the resource's cost)
+	Tier       string `json:"tier"`      // Optional: plan tier whose limits apply
+}
+
+// ReserveResponse is a check response for the reserved quota, with the reservation when allowed
+type ReserveResponse struct {
+	CheckResponse
+	ReservationID string `json:"reservation_id,omitempty"`
+	ExpiresAt     string `json:"expires_at,omitempty"` // After this the reservation can not be committed
+}
+
+// ReservationResponse describes a committed or canceled reservation
+type ReservationResponse struct {
+	ReservationID string `json:"reservation_id"`
+	Key           string `json:"key"`
+	Count         int    `json:"count"`
+	Status        string `json:"status"` // "committed" or "canceled"
+}
+
+// reservationLimiter resolves the limiter a reservation was made under, by its
+// reservationName
+func (h *RateLimitHandler) reservationLimiter(name string) (limiter.RateLimiter, bool) {
+	tier, algorithm, ok := strings.Cut(name, "/")
+	if !ok {
+		tier, algorithm = "", name
+	}
+	_, _, rl, ok := h.resolveLimiter(algorithm, tier)
+	if !ok {
+		return nil, false
+	}
+	return h.withQuota(rl), true
+}
+
+// reservationName names the limiter for algorithm and tier, for reservationLimiter
+func reservationName(algorithm, tier string) string {
+	if tier == "" {
+		return algorithm
+	}
+	return tier + "/" + algorithm
+}
+
+// Reserve handles POST /v1/reserve - take quota now, to be committed or canceled later
+// Allowed reservations answer 200 with a reservation ID; denials answer 429 as /v1/check
+// does. Quota not committed or canceled within the TTL is returned to the key
+func (h *RateLimitHandler) Reserve(c *gin.Context) {
+	if h.reservations == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reservations not enabled"})
+		return
+	}
+	start := time.Now()
+
+	var req ReserveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cost, err := h.checkCost(CheckRequest{Resource: req.Resource, Count: req.Count})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	algorithm, tier, limiterInstance, ok := h.resolveLimiter(req.Algorithm, req.Tier)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid algorithm"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	key, err := h.keyScheme.Join(req.Identifier, req.Resource)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Banned keys are denied without consulting the limiter
+	info, err := h.banInfo(ctx, limiterInstance, key)
+	if err != nil {
+		writeLimiterError(c, err, "ban check failed")
+		return
+	}
+	banned := info != nil
+
+	var res *limiter.Reservation
+	allowed := false
+	if !banned {
+		allowed, info, res, err = h.reservations.Reserve(ctx, reservationName(algorithm, tier), key, cost)
+		if err != nil {
+			writeLimiterError(c, err, "reservation failed")
+			return
+		}
+		if !allowed {
+			banned = h.recordDenial(ctx, key, info)
+		}
+	}
+
+	h.metrics.RecordRequest(algorithm, strings.Split(req.Resource, ".")[0], allowed, time.Since(start).Seconds())
+	h.writeRateLimitHeaders(c, algorithm, info, "")
+
+	resp := ReserveResponse{CheckResponse: CheckResponse{
+		Allowed:   allowed,
+		Limit:     info.Limit,
+		Remaining: info.Remaining,
+		Used:      info.Used,
+		Window:    int(info.Window.Seconds()),
+		ResetAt:   info.ResetAt.Format(time.RFC3339),
+		Policy:    info.Policy,
+		Tier:      tier,
+		Cost:      cost,
+		Banned:    banned,
+	}}
+	if info.RetryAfter != nil {
+		retrySeconds := info.RetryAfterSeconds()
+		resp.RetryAfter = &retrySeconds
+	}
+	if !allowed {
+		c.JSON(http.StatusTooManyRequests, resp)
+		return
+	}
+
+	resp.ReservationID = res.ID
+	resp.ExpiresAt = res.ExpiresAt.Format(time.RFC3339)
+	c.JSON(http.StatusOK, resp)
+}
+
+// CommitReservation handles POST /v1/commit/:id - keep a reservation's quota as consumed
+// Returns 404 for reservations already committed, canceled or expired
+func (h *RateLimitHandler) CommitReservation(c *gin.Context) {
+	h.finishReservation(c, "committed", h.reservations.Commit)
+}
+
+// CancelReservation handles POST /v1/cancel/:id - return a reservation's quota to its key
+// Returns 404 for reservations already committed, canceled or expired
+func (h *RateLimitHandler) CancelReservation(c *gin.Context) {
+	h.finishReservation(c, "canceled", h.reservations.Cancel)
+}
+
+// finishReservation takes back the reservation named in the path with finish
+func (h *RateLimitHandler) finishReservation(c *gin.Context, status string, finish func(context.Context, string) (*limiter.Reservation, error)) {
+	if h.reservations == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reservations not enabled"})
+		return
+	}
+
+	res, err := finish(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, limiter.ErrReservationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeLimiterError(c, err, "reservation update failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, ReservationResponse{ReservationID: res.ID, Key: res.Key, Count: res.N, Status: status})
+}
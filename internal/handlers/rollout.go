@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/gin-gonic/gin"
+)
+
+// WithRollout exposes the enforcement rollout percentage through the admin endpoints
+func WithRollout(r *algorithms.Rollout) Option {
+	return func(h *RateLimitHandler) {
+		h.rollout = r
+	}
+}
+
+// RolloutRequest represents a rollout percentage update
+type RolloutRequest struct {
+	Percent *float64 `json:"percent" binding:"required"` // Percentage of keys enforced (0-100)
+}
+
+// GetRollout handles GET /v1/rollout - current enforcement percentage
+func (h *RateLimitHandler) GetRollout(c *gin.Context) {
+	if h.rollout == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rollout not enabled"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"percent": h.rollout.Percent()})
+}
+
+// SetRollout handles PUT /v1/rollout - ramp the enforcement percentage
+func (h *RateLimitHandler) SetRollout(c *gin.Context) {
+	if h.rollout == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rollout not enabled"})
+		return
+	}
+
+	var req RolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.rollout.SetPercent(*req.Percent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"percent": h.rollout.Percent()})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// Version is the server version reported by /version
+// Overridden at build time with -ldflags "-X github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers.Version=..."
+var Version = "dev"
+
+// RegisterRoutes mounts the API on r
+// Routes that mutate limiter or admin state go through RequireWritable
+func (h *RateLimitHandler) RegisterRoutes(r gin.IRouter) {
+	if h.tracer != nil {
+		r = r.Group("", h.traceRequest)
+	}
+
+	v1 := r.Group("/v1")
+	{
+		v1.POST("/check", h.Check)
+		v1.POST("/check/batch", h.CheckBatch)
+		v1.POST("/reserve", h.RequireWritable, h.Reserve)
+		v1.POST("/commit/:id", h.RequireWritable, h.CommitReservation)
+		v1.POST("/cancel/:id", h.RequireWritable, h.CancelReservation)
+		v1.GET("/status/:key", h.GetStatus)
+		v1.GET("/usage/:key", h.GetUsage)
+		v1.GET("/estimate", h.Estimate)
+		v1.GET("/history/:key", h.GetHistory)
+		v1.GET("/policies", h.GetPolicies)
+		v1.POST("/reset/:key", h.RequireWritable, h.Reset)
+		v1.POST("/reset-prefix", h.RequireWritable, h.ResetPrefix)
+		v1.POST("/feedback", h.RequireWritable, h.Feedback)
+		v1.GET("/bans", h.ListBans)
+		v1.POST("/unban/:key", h.RequireWritable, h.Unban)
+		v1.PUT("/limits/:key", h.RequireWritable, h.SetOverride)
+		v1.DELETE("/limits/:key", h.RequireWritable, h.DeleteOverride)
+		v1.POST("/override/:key", h.RequireWritable, h.SetOverride)
+		v1.DELETE("/override/:key", h.RequireWritable, h.DeleteOverride)
+		v1.GET("/groups/:identifier", h.GetKeyGroup)
+		v1.PUT("/groups/:identifier", h.RequireWritable, h.SetKeyGroup)
+		v1.DELETE("/groups/:identifier", h.RequireWritable, h.DeleteKeyGroup)
+		v1.GET("/rollout", h.GetRollout)
+		v1.PUT("/rollout", h.RequireWritable, h.SetRollout)
+		v1.GET("/read-only", h.GetReadOnly)
+		v1.PUT("/read-only", h.SetReadOnly)
+		v1.POST("/pool/check", h.PoolCheck)
+		v1.GET("/pool/status/:org/:seat", h.PoolStatus)
+		v1.PUT("/pool/overrides/:org", h.RequireWritable, h.SetPoolOverride)
+		v1.DELETE("/pool/overrides/:org", h.RequireWritable, h.DeletePoolOverride)
+	}
+
+	r.GET("/health", h.Health)
+	r.GET("/version", h.GetVersion)
+
+	if h.ui != nil {
+		h.registerUI(r)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// softLimitWarning is the X-RateLimit-Warning value sent once a key crosses its soft limit
+const softLimitWarning = "soft limit reached; slow down before requests are denied"
+
+// SoftLimits maps a tier to the percentage of its limit after which allowed checks carry
+// a warning, so clients can back off before they are denied. The empty tier is the
+// default limits; tiers not listed, and a percentage of 0, send no warning
+type SoftLimits map[string]float64
+
+// WithSoftLimits warns checks that have used at least their tier's soft limit
+func WithSoftLimits(softLimits SoftLimits) Option {
+	return func(h *RateLimitHandler) {
+		h.softLimits.Store(&softLimits)
+	}
+}
+
+// UpdateSoftLimits replaces the soft limits, e.g. when the config is reloaded
+func (h *RateLimitHandler) UpdateSoftLimits(softLimits SoftLimits) {
+	h.softLimits.Store(&softLimits)
+}
+
+// softLimit reports whether an allowed check of cost units left the key at or above its
+// tier's soft limit, and whether it was this check that crossed it
+// It is computed from the reported usage, so it works the same for every algorithm
+func (h *RateLimitHandler) softLimit(tier string, info *limiter.LimitInfo, cost int) (warning, crossed bool) {
+	softLimits := h.softLimits.Load()
+	if softLimits == nil || info == nil || info.Limit <= 0 {
+		return false, false
+	}
+	percent := (*softLimits)[tier]
+	if percent <= 0 {
+		return false, false
+	}
+
+	threshold := percent / 100 * float64(info.Limit)
+	used := float64(info.Limit - info.Remaining)
+	if used < threshold {
+		return false, false
+	}
+	return true, used-float64(cost) < threshold
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer traces each request as a server span, continuing the caller's trace when the
+// request carries W3C traceparent headers. Limiters built with algorithms.WithTracer open
+// their spans as its children
+func WithTracer(tracer trace.Tracer) Option {
+	return func(h *RateLimitHandler) {
+		h.tracer = tracer
+	}
+}
+
+// traceRequest runs the request under a server span continuing its incoming trace context
+func (h *RateLimitHandler) traceRequest(c *gin.Context) {
+	ctx := propagation.TraceContext{}.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := h.tracer.Start(ctx, c.Request.Method+" "+c.FullPath(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("http.route", c.FullPath())))
+	defer span.End()
+
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(attribute.Int("http.status_code", status))
+	if status >= 500 {
+		span.SetStatus(codes.Error, "")
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// uiTemplate is the operator page; it only renders configuration, and all state is
+// fetched by the page itself from the /v1 API
+var uiTemplate = template.Must(template.ParseFS(uiFiles, "ui/index.html"))
+
+// UIConfig enables the built-in operator page at /ui
+type UIConfig struct {
+	Username string
+	Password string
+}
+
+// Validate checks that the page can only be reached with credentials
+func (uc UIConfig) Validate() error {
+	if uc.Username == "" || uc.Password == "" {
+		return errors.New("ui requires a username and password")
+	}
+	return nil
+}
+
+// WithUI serves the operator page at /ui behind basic auth
+// The page is a client of the /v1 routes, so it cannot do anything the API does not allow
+func WithUI(cfg UIConfig) Option {
+	return func(h *RateLimitHandler) {
+		h.ui = &cfg
+	}
+}
+
+// uiPage is the data the operator page is rendered with
+type uiPage struct {
+	Version          string
+	Algorithms       []string
+	DefaultAlgorithm string
+	Tiers            []string
+	History          bool // Whether /v1/history is available for key lookups
+}
+
+// registerUI mounts the operator page and its assets on r
+func (h *RateLimitHandler) registerUI(r gin.IRouter) {
+	static, err := fs.Sub(uiFiles, "ui/static")
+	if err != nil {
+		panic(err) // The embedded tree is fixed at build time
+	}
+
+	ui := r.Group("/ui", gin.BasicAuth(gin.Accounts{h.ui.Username: h.ui.Password}))
+	ui.GET("", h.UI)
+	ui.GET("/", h.UI)
+	ui.StaticFS("/static", http.FS(static))
+}
+
+// UI handles GET /ui - the operator page
+func (h *RateLimitHandler) UI(c *gin.Context) {
+	page := uiPage{
+		Version:          Version,
+		Algorithms:       sortedKeys(h.limiters),
+		DefaultAlgorithm: h.defaultAlgorithm,
+		Tiers:            sortedKeys(h.tiers),
+		History:          h.history != nil,
+	}
+
+	var b bytes.Buffer
+	if err := uiTemplate.Execute(&b, page); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render page"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", b.Bytes())
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
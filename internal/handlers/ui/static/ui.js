@@ -0,0 +1,87 @@
+// The UI is a plain client of the /v1 API: every action below is an ordinary API call,
+// so it is subject to the same read-only guard and logging as any other caller.
+(function () {
+  "use strict";
+
+  function $(id) { return document.getElementById(id); }
+
+  async function call(method, url, body) {
+    const opts = { method: method, headers: { "Accept": "application/json" } };
+    if (body !== undefined) {
+      opts.headers["Content-Type"] = "application/json";
+      opts.body = JSON.stringify(body);
+    }
+    const resp = await fetch(url, opts);
+    const data = await resp.json().catch(function () { return {}; });
+    return { status: resp.status, data: data };
+  }
+
+  function show(el, result) {
+    el.textContent = result.status + "\n" + JSON.stringify(result.data, null, 2);
+  }
+
+  function row(cells, header) {
+    const tr = document.createElement("tr");
+    cells.forEach(function (text) {
+      const cell = document.createElement(header ? "th" : "td");
+      cell.textContent = text;
+      tr.appendChild(cell);
+    });
+    return tr;
+  }
+
+  async function refreshReadOnly() {
+    const result = await call("GET", "/v1/read-only");
+    $("read-only-state").textContent = result.data.read_only ? "read-only" : "";
+  }
+
+  $("policy").addEventListener("submit", async function (e) {
+    e.preventDefault();
+    const params = new URLSearchParams(new FormData(this));
+    show($("policy-result"), await call("GET", this.getAttribute("action") + "?" + params));
+  });
+
+  $("lookup").addEventListener("submit", async function (e) {
+    e.preventDefault();
+    const key = encodeURIComponent(new FormData(this).get("key"));
+
+    const status = await call("GET", this.dataset.status + key + "?algorithm=all");
+    const table = $("status-result");
+    table.replaceChildren(row(["Algorithm", "Allowed", "Remaining", "Limit", "Resets"], true));
+    Object.entries(status.data.algorithms || {}).sort().forEach(function ([name, s]) {
+      const tr = row([name, s.allowed ? "yes" : "no", s.remaining, s.limit, s.reset_at]);
+      if (!s.allowed) tr.className = "denied";
+      table.appendChild(tr);
+    });
+
+    if (this.dataset.history) {
+      const history = await call("GET", this.dataset.history + key);
+      const table = $("history-result");
+      table.replaceChildren(row(["Window", "Attempted", "Allowed", "Denied"], true));
+      (history.data.history || []).forEach(function (h) {
+        const denied = h.attempted - h.allowed;
+        const tr = row([h.start, h.attempted, h.allowed, denied]);
+        if (denied > 0) tr.className = "denied";
+        table.appendChild(tr);
+      });
+    }
+  });
+
+  $("reset").addEventListener("submit", async function (e) {
+    e.preventDefault();
+    const form = new FormData(this);
+    if (!confirm("Reset " + form.get("key") + "?")) return;
+    const url = this.dataset.endpoint + encodeURIComponent(form.get("key")) +
+      "?algorithm=" + encodeURIComponent(form.get("algorithm"));
+    show($("admin-result"), await call("POST", url));
+  });
+
+  $("read-only").addEventListener("submit", async function (e) {
+    e.preventDefault();
+    const enabled = e.submitter.value === "true";
+    show($("admin-result"), await call("PUT", this.dataset.endpoint, { enabled: enabled }));
+    refreshReadOnly();
+  });
+
+  refreshReadOnly();
+})();
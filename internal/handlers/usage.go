@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageRequest represents a usage query
+type UsageRequest struct {
+	Algorithm string `form:"algorithm"` // Optional: algorithm to report under (default: configured default)
+}
+
+// UsageResponse reports what a key has consumed in its current window
+type UsageResponse struct {
+	Key         string  `json:"key"`
+	Algorithm   string  `json:"algorithm"`
+	Group       string  `json:"group,omitempty"`
+	Used        float64 `json:"used"` // Fractional for token buckets and sliding windows
+	Limit       int     `json:"limit"`
+	WindowStart string  `json:"window_start"`
+	WindowEnd   string  `json:"window_end"`
+}
+
+// GetUsage handles GET /v1/usage/:key - consumption for a key in its current window
+// Unlike GetStatus it reports what was used rather than what is left, with the window's bounds
+func (h *RateLimitHandler) GetUsage(c *gin.Context) {
+	key := c.Param("key")
+	if key == "" {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, "key is required")
+		return
+	}
+
+	var req UsageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	algorithm := req.Algorithm
+	if algorithm == "" {
+		algorithm = h.defaultAlgorithm
+	}
+	limiterInstance, ok := h.limiters[algorithm]
+	if !ok {
+		writeError(c, http.StatusBadRequest, CodeInvalidAlgorithm, "invalid algorithm")
+		return
+	}
+
+	// Members of a key group report the group's usage
+	key, group, err := h.resolveKey(c.Request.Context(), key)
+	if err != nil {
+		writeLimiterError(c, err, "key group lookup failed")
+		return
+	}
+
+	// Wrapped limiters that do not report usage themselves are reported from their status
+	var usage *limiter.Usage
+	if reporter, ok := limiterInstance.(limiter.UsageReporter); ok {
+		usage, err = reporter.Usage(c.Request.Context(), key)
+	} else {
+		usage, err = usageFromStatus(c, limiterInstance, key)
+	}
+	if err != nil {
+		writeLimiterError(c, err, "usage check failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, UsageResponse{
+		Key:         key,
+		Algorithm:   algorithm,
+		Group:       group,
+		Used:        usage.Used,
+		Limit:       usage.Limit,
+		WindowStart: usage.WindowStart.Format(time.RFC3339),
+		WindowEnd:   usage.WindowEnd.Format(time.RFC3339),
+	})
+}
+
+// usageFromStatus derives usage from a limiter's status, taking the window as the one
+// ending when the limit resets
+func usageFromStatus(c *gin.Context, rl limiter.RateLimiter, key string) (*limiter.Usage, error) {
+	info, err := rl.StatusCtx(c.Request.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+	return &limiter.Usage{
+		Used:        float64(info.Used),
+		Limit:       info.Limit,
+		WindowStart: info.ResetAt.Add(-info.Window),
+		WindowEnd:   info.ResetAt,
+	}, nil
+}
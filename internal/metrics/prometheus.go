@@ -7,18 +7,44 @@ import (
 
 // Metrics holds all Prometheus metrics for the rate limiter
 type Metrics struct {
-	RequestsTotal   *prometheus.CounterVec
-	RequestsAllowed *prometheus.CounterVec
-	RequestsDenied  *prometheus.CounterVec
-	Latency         *prometheus.HistogramVec
-	RedisErrors     *prometheus.CounterVec
-	StoreOperations *prometheus.HistogramVec
+	RequestsTotal    *prometheus.CounterVec
+	RequestsAllowed  *prometheus.CounterVec
+	RequestsDenied   *prometheus.CounterVec
+	Latency          *prometheus.HistogramVec
+	RedisErrors      *prometheus.CounterVec
+	StoreOperations  *prometheus.HistogramVec
+	Instances        prometheus.Gauge
+	InstanceOrdinal  prometheus.Gauge
+	ReadOnly         prometheus.Gauge
+	ScriptErrors     *prometheus.CounterVec
+	ScriptDemotions  *prometheus.CounterVec
+	ScriptDemoted    *prometheus.GaugeVec
+	ScriptMismatches *prometheus.CounterVec
+	AdaptiveLimit    *prometheus.GaugeVec
+	HeaderTruncation *prometheus.CounterVec
+	PriorityRequests *prometheus.CounterVec
+	DenyCache        *prometheus.CounterVec
+	FailOpen         *prometheus.CounterVec
+	Leases           *prometheus.CounterVec
+	RequestIDReplays *prometheus.CounterVec
+	ShadowDivergence *prometheus.CounterVec
+	SoftLimit        *prometheus.CounterVec
+	ActiveBans       prometheus.Gauge
+	RemainingGauge   *prometheus.GaugeVec
 }
 
 // NewMetrics creates and registers Prometheus metrics
 func NewMetrics() *Metrics {
+	return NewMetricsWithRegistry(prometheus.DefaultRegisterer)
+}
+
+// NewMetricsWithRegistry creates Prometheus metrics registered with reg
+// Useful in tests where the default registry would panic on duplicate registration
+func NewMetricsWithRegistry(reg prometheus.Registerer) *Metrics {
+	factory := promauto.With(reg)
+
 	return &Metrics{
-		RequestsTotal: promauto.NewCounterVec(
+		RequestsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "rate_limiter_requests_total",
 				Help: "Total number of rate limit check requests",
@@ -26,7 +52,7 @@ func NewMetrics() *Metrics {
 			[]string{"algorithm", "key_prefix"},
 		),
 
-		RequestsAllowed: promauto.NewCounterVec(
+		RequestsAllowed: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "rate_limiter_requests_allowed",
 				Help: "Number of requests allowed",
@@ -34,7 +60,7 @@ func NewMetrics() *Metrics {
 			[]string{"algorithm", "key_prefix"},
 		),
 
-		RequestsDenied: promauto.NewCounterVec(
+		RequestsDenied: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "rate_limiter_requests_denied",
 				Help: "Number of requests denied",
@@ -42,7 +68,7 @@ func NewMetrics() *Metrics {
 			[]string{"algorithm", "key_prefix"},
 		),
 
-		Latency: promauto.NewHistogramVec(
+		Latency: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "rate_limiter_latency_seconds",
 				Help:    "Request latency in seconds",
@@ -51,7 +77,7 @@ func NewMetrics() *Metrics {
 			[]string{"algorithm", "operation"},
 		),
 
-		RedisErrors: promauto.NewCounterVec(
+		RedisErrors: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "rate_limiter_redis_errors_total",
 				Help: "Total number of Redis errors",
@@ -59,7 +85,7 @@ func NewMetrics() *Metrics {
 			[]string{"operation"},
 		),
 
-		StoreOperations: promauto.NewHistogramVec(
+		StoreOperations: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "rate_limiter_store_operations_seconds",
 				Help:    "Store operation latency in seconds",
@@ -67,6 +93,146 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"store_type", "operation"},
 		),
+
+		Instances: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rate_limiter_discovered_instances",
+				Help: "Number of rate limiter instances discovered from the headless service",
+			},
+		),
+
+		InstanceOrdinal: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rate_limiter_instance_ordinal",
+				Help: "StatefulSet ordinal of this instance (-1 if unknown)",
+			},
+		),
+
+		ReadOnly: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rate_limiter_read_only",
+				Help: "Whether the server is in read-only mode (1) or not (0)",
+			},
+		),
+
+		ScriptErrors: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_script_errors_total",
+				Help: "Total number of Lua script errors by store path",
+			},
+			[]string{"path"},
+		),
+
+		ScriptDemotions: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_script_demotions_total",
+				Help: "Number of times a Lua store path was demoted to its legacy implementation",
+			},
+			[]string{"path"},
+		),
+
+		ScriptDemoted: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rate_limiter_script_demoted",
+				Help: "Whether a Lua store path is currently demoted to legacy (1) or not (0)",
+			},
+			[]string{"path"},
+		),
+
+		ScriptMismatches: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_script_shadow_mismatches_total",
+				Help: "Number of shadow-mode comparisons where the Lua and legacy paths disagreed",
+			},
+			[]string{"path"},
+		),
+
+		AdaptiveLimit: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rate_limiter_adaptive_limit",
+				Help: "Current AIMD effective limit for keys that have received feedback",
+			},
+			[]string{"algorithm", "key"},
+		),
+
+		HeaderTruncation: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_header_truncations_total",
+				Help: "Number of times a rate limit header group was omitted to stay within the header budget",
+			},
+			[]string{"group"},
+		),
+
+		PriorityRequests: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_priority_requests_total",
+				Help: "Number of checks by priority class and result (allowed, denied, or shed to keep capacity for higher priorities)",
+			},
+			[]string{"priority", "result"},
+		),
+
+		DenyCache: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_deny_cache_decisions_total",
+				Help: "Number of decisions by hot key deny caches, by where they were served from (cache or store)",
+			},
+			[]string{"algorithm", "source"},
+		),
+
+		FailOpen: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_fail_open_total",
+				Help: "Number of checks allowed because the store failed while failure_mode is fail_open",
+			},
+			[]string{"algorithm"},
+		),
+
+		Leases: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_lease_checks_total",
+				Help: "Number of leased token bucket checks, by whether they were served from a local lease (hit) or leased from the store (miss)",
+			},
+			[]string{"result"},
+		),
+
+		RequestIDReplays: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_request_id_duplicates_total",
+				Help: "Number of checks whose request ID was already seen, by result (replayed, or in_progress while the first check ran)",
+			},
+			[]string{"result"},
+		),
+
+		ShadowDivergence: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_shadow_divergence_total",
+				Help: "Number of checks a shadow algorithm decided differently from the enforced one, by which of the two allowed",
+			},
+			[]string{"primary", "shadow", "allowed"},
+		),
+
+		SoftLimit: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limiter_soft_limit_exceeded_total",
+				Help: "Number of times a key entered the warning zone above its soft limit",
+			},
+			[]string{"key_prefix"},
+		),
+
+		ActiveBans: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rate_limiter_active_bans",
+				Help: "Number of keys currently banned for repeated denials",
+			},
+		),
+
+		RemainingGauge: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rate_limiter_remaining",
+				Help: "Requests remaining after the latest check, by algorithm and key prefix",
+			},
+			[]string{"algorithm", "key_prefix"},
+		),
 	}
 }
 
@@ -83,6 +249,12 @@ func (m *Metrics) RecordRequest(algorithm, keyPrefix string, allowed bool, laten
 	m.Latency.WithLabelValues(algorithm, "check").Observe(latency)
 }
 
+// RecordRemaining records the requests a key had left after a check
+// Labelled by key prefix rather than key, so the latest check of any key under a prefix sets it
+func (m *Metrics) RecordRemaining(algorithm, keyPrefix string, remaining int) {
+	m.RemainingGauge.WithLabelValues(algorithm, keyPrefix).Set(float64(remaining))
+}
+
 // RecordRedisError records a Redis error
 func (m *Metrics) RecordRedisError(operation string) {
 	m.RedisErrors.WithLabelValues(operation).Inc()
@@ -92,3 +264,114 @@ func (m *Metrics) RecordRedisError(operation string) {
 func (m *Metrics) RecordStoreOperation(storeType, operation string, latency float64) {
 	m.StoreOperations.WithLabelValues(storeType, operation).Observe(latency)
 }
+
+// RecordTopology records the discovered instance topology
+func (m *Metrics) RecordTopology(instances, ordinal int) {
+	m.Instances.Set(float64(instances))
+	m.InstanceOrdinal.Set(float64(ordinal))
+}
+
+// RecordReadOnly records whether the server is in read-only mode
+func (m *Metrics) RecordReadOnly(enabled bool) {
+	if enabled {
+		m.ReadOnly.Set(1)
+	} else {
+		m.ReadOnly.Set(0)
+	}
+}
+
+// RecordScriptError records a Lua script error on a store path
+func (m *Metrics) RecordScriptError(path string) {
+	m.ScriptErrors.WithLabelValues(path).Inc()
+}
+
+// RecordScriptDemotion records a Lua store path falling back to legacy
+func (m *Metrics) RecordScriptDemotion(path string) {
+	m.ScriptDemotions.WithLabelValues(path).Inc()
+}
+
+// RecordScriptDemoted records whether a Lua store path is currently demoted
+func (m *Metrics) RecordScriptDemoted(path string, demoted bool) {
+	if demoted {
+		m.ScriptDemoted.WithLabelValues(path).Set(1)
+	} else {
+		m.ScriptDemoted.WithLabelValues(path).Set(0)
+	}
+}
+
+// RecordScriptMismatch records a shadow-mode disagreement between Lua and legacy paths
+func (m *Metrics) RecordScriptMismatch(path string) {
+	m.ScriptMismatches.WithLabelValues(path).Inc()
+}
+
+// RecordAdaptiveLimit records the effective limit of a key after feedback
+func (m *Metrics) RecordAdaptiveLimit(algorithm, key string, limit int) {
+	m.AdaptiveLimit.WithLabelValues(algorithm, key).Set(float64(limit))
+}
+
+// RecordHeaderTruncation records a header group dropped to fit the header budget
+func (m *Metrics) RecordHeaderTruncation(group string) {
+	m.HeaderTruncation.WithLabelValues(group).Inc()
+}
+
+// RecordPriority records the result of a check for a priority class
+func (m *Metrics) RecordPriority(priority, result string) {
+	m.PriorityRequests.WithLabelValues(priority, result).Inc()
+}
+
+// RecordDenyCache records a decision made by a deny cache, served locally or by the store
+func (m *Metrics) RecordDenyCache(algorithm string, cached bool) {
+	source := "store"
+	if cached {
+		source = "cache"
+	}
+	m.DenyCache.WithLabelValues(algorithm, source).Inc()
+}
+
+// RecordFailOpen records a check allowed because the store failed
+func (m *Metrics) RecordFailOpen(algorithm string) {
+	m.FailOpen.WithLabelValues(algorithm).Inc()
+}
+
+// RecordLease records a leased check, served from a local lease or by leasing from the store
+func (m *Metrics) RecordLease(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.Leases.WithLabelValues(result).Inc()
+}
+
+// RecordRequestIDReplay records a check whose request ID was already seen, replayed or
+// refused while the first check with the ID was still running
+func (m *Metrics) RecordRequestIDReplay(replayed bool) {
+	result := "in_progress"
+	if replayed {
+		result = "replayed"
+	}
+	m.RequestIDReplays.WithLabelValues(result).Inc()
+}
+
+// RecordShadowDivergence records a check the primary and shadow algorithms decided differently
+func (m *Metrics) RecordShadowDivergence(primary, shadow string, primaryAllowed bool) {
+	allowed := "shadow"
+	if primaryAllowed {
+		allowed = "primary"
+	}
+	m.ShadowDivergence.WithLabelValues(primary, shadow, allowed).Inc()
+}
+
+// RecordSoftLimit records a key crossing its soft limit
+func (m *Metrics) RecordSoftLimit(keyPrefix string) {
+	m.SoftLimit.WithLabelValues(keyPrefix).Inc()
+}
+
+// RecordActiveBans records the number of keys currently banned
+func (m *Metrics) RecordActiveBans(n int) {
+	m.ActiveBans.Set(float64(n))
+}
+
+// RecordBan records a key being banned, counted until the next RecordActiveBans
+func (m *Metrics) RecordBan() {
+	m.ActiveBans.Inc()
+}
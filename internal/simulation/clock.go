@@ -0,0 +1,15 @@
+package simulation
+
+import (
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+)
+
+// ManualClock is a limiter.Clock that only moves when told to
+type ManualClock = limitertest.FakeClock
+
+// NewManualClock creates a clock stopped at start
+func NewManualClock(start time.Time) *ManualClock {
+	return limitertest.NewFakeClock(start)
+}
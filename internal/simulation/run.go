@@ -0,0 +1,45 @@
+package simulation
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Decision is a limiter's answer to one event
+type Decision struct {
+	Event
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // Zero when allowed
+	Err        error
+}
+
+// Run replays events against rl, moving clock to each event's time first
+// rl must have been built to read time from clock
+func Run(ctx context.Context, rl limiter.RateLimiter, clock *ManualClock, start time.Time, events []Event) []Decision {
+	decisions := make([]Decision, 0, len(events))
+	for _, e := range events {
+		clock.Set(start.Add(time.Duration(e.At)))
+
+		n := e.N
+		if n == 0 {
+			n = 1
+		}
+
+		d := Decision{Event: e}
+		allowed, info, err := rl.AllowNCtx(ctx, e.Key, n)
+		if err != nil {
+			d.Err = err
+		} else {
+			d.Allowed = allowed
+			d.Remaining = info.Remaining
+			if info.RetryAfter != nil {
+				d.RetryAfter = *info.RetryAfter
+			}
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions
+}
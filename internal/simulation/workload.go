@@ -0,0 +1,125 @@
+package simulation
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// DefaultStart is where simulated time begins when a workload does not say
+var DefaultStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Duration is a time.Duration that reads and writes JSON as a Go duration string ("1.5s")
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a duration string, or a number of nanoseconds
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		var ns int64
+		if err := json.Unmarshal(b, &ns); err != nil {
+			return fmt.Errorf("duration must be a string like \"1s\" or nanoseconds: %s", b)
+		}
+		*d = Duration(ns)
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Event is one request in a workload, at an offset from the start
+type Event struct {
+	At  Duration `json:"at"`
+	Key string   `json:"key"`
+	N   int      `json:"n,omitempty"` // Requests consumed (default 1)
+}
+
+// Stream is a run of evenly spaced requests for one key
+type Stream struct {
+	Key      string   `json:"key"`
+	Start    Duration `json:"start"`    // Offset of the first request
+	Count    int      `json:"count"`    // Number of requests
+	Interval Duration `json:"interval"` // Spacing between requests
+	N        int      `json:"n,omitempty"`
+}
+
+// Spec is a synthetic workload built from streams
+type Spec struct {
+	Start   time.Time `json:"start,omitempty"` // Default DefaultStart
+	Streams []Stream  `json:"streams"`
+}
+
+// Events expands the spec into events ordered by time
+// Events at the same offset keep stream order
+func (s Spec) Events() []Event {
+	var events []Event
+	for _, stream := range s.Streams {
+		for i := 0; i < stream.Count; i++ {
+			events = append(events, Event{
+				At:  stream.Start + Duration(i)*stream.Interval,
+				Key: stream.Key,
+				N:   stream.N,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At < events[j].At
+	})
+	return events
+}
+
+// StartTime returns the spec's start, or DefaultStart
+func (s Spec) StartTime() time.Time {
+	if s.Start.IsZero() {
+		return DefaultStart
+	}
+	return s.Start
+}
+
+// ReadSpec decodes a JSON workload spec
+func ReadSpec(r io.Reader) (Spec, error) {
+	var spec Spec
+	if err := json.NewDecoder(r).Decode(&spec); err != nil {
+		return Spec{}, fmt.Errorf("failed to decode workload spec: %w", err)
+	}
+	return spec, nil
+}
+
+// ReadEvents decodes a replay log of one JSON event per line, ordering it by time
+func ReadEvents(r io.Reader) ([]Event, error) {
+	var events []Event
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At < events[j].At
+	})
+	return events, nil
+}
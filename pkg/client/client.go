@@ -0,0 +1,241 @@
+// Package client provides a Go SDK for the rate limiter HTTP API
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Client talks to a rate limiter server over HTTP
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient overrides the underlying HTTP client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// New creates a client for the server at baseURL (e.g. "http://localhost:8080")
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CheckRequest mirrors the server's /v1/check request body
+type CheckRequest struct {
+	Resource   string `json:"resource"`
+	Identifier string `json:"identifier"`
+	Algorithm  string `json:"algorithm,omitempty"`
+	Count      int    `json:"count,omitempty"`
+}
+
+// checkResponse mirrors the server's check/status response body
+type checkResponse struct {
+	Allowed    bool   `json:"allowed"`
+	Limit      int    `json:"limit"`
+	Remaining  int    `json:"remaining"`
+	Used       int    `json:"used"`
+	Window     int    `json:"window"`
+	ResetAt    string `json:"reset_at"`
+	RetryAfter *int   `json:"retry_after,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Policy     string `json:"policy,omitempty"`
+}
+
+// errorResponse mirrors the server's error body
+type errorResponse struct {
+	Code    string `json:"code"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// Check asks the server whether req is allowed
+// A denial is reported as *ErrRateLimited; the returned LimitInfo is nil in that case
+func (c *Client) Check(ctx context.Context, req CheckRequest) (*limiter.LimitInfo, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	return c.do(ctx, http.MethodPost, "/v1/check", body)
+}
+
+// Status returns the current limit state for key without consuming it
+func (c *Client) Status(ctx context.Context, key, algorithm string) (*limiter.LimitInfo, error) {
+	path := "/v1/status/" + url.PathEscape(key)
+	if algorithm != "" {
+		path += "?algorithm=" + url.QueryEscape(algorithm)
+	}
+	return c.do(ctx, http.MethodGet, path, nil)
+}
+
+// Do runs fn only when req is allowed, otherwise it returns the typed denial
+// Errors returned by fn are passed through unchanged
+func (c *Client) Do(ctx context.Context, req CheckRequest, fn func(ctx context.Context) error) error {
+	if _, err := c.Check(ctx, req); err != nil {
+		return err
+	}
+	return fn(ctx)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte) (*limiter.LimitInfo, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		var cr checkResponse
+		if err := json.Unmarshal(data, &cr); err != nil {
+			return nil, fmt.Errorf("malformed response body: %w", err)
+		}
+		return cr.limitInfo(), nil
+
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// Headers carry the same state as the body, so a malformed body still yields a usable error
+		info := limitInfoFromHeaders(resp.Header)
+		var cr checkResponse
+		if err := json.Unmarshal(data, &cr); err == nil {
+			info = *cr.limitInfo()
+		}
+		return nil, &ErrRateLimited{Info: info}
+
+	case resp.StatusCode == http.StatusServiceUnavailable:
+		return nil, &ErrUnavailable{
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Message:    parseError(data).message(),
+		}
+
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		er := parseError(data)
+		return nil, &ErrInvalidRequest{
+			StatusCode: resp.StatusCode,
+			Code:       er.Code,
+			Message:    er.message(),
+		}
+
+	default:
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, parseError(data).message())
+	}
+}
+
+func (cr *checkResponse) limitInfo() *limiter.LimitInfo {
+	info := &limiter.LimitInfo{
+		Limit:     cr.Limit,
+		Remaining: cr.Remaining,
+		Used:      cr.Used,
+		Window:    time.Duration(cr.Window) * time.Second,
+		Policy:    cr.Policy,
+		Reason:    cr.Reason,
+	}
+	if t, err := time.Parse(time.RFC3339, cr.ResetAt); err == nil {
+		info.ResetAt = t
+	}
+	if cr.RetryAfter != nil {
+		retryAfter := time.Duration(*cr.RetryAfter) * time.Second
+		info.RetryAfter = &retryAfter
+	}
+	return info
+}
+
+func limitInfoFromHeaders(h http.Header) limiter.LimitInfo {
+	var info limiter.LimitInfo
+	info.Limit, _ = strconv.Atoi(headerOr(h, "X-RateLimit-Limit", "RateLimit-Limit"))
+	info.Remaining, _ = strconv.Atoi(headerOr(h, "X-RateLimit-Remaining", "RateLimit-Remaining"))
+	info.Used, _ = strconv.Atoi(h.Get("X-RateLimit-Used"))
+	if window, err := strconv.Atoi(h.Get("X-RateLimit-Window")); err == nil {
+		info.Window = time.Duration(window) * time.Second
+	}
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		info.ResetAt = time.Unix(reset, 0)
+	} else if delta, err := strconv.ParseInt(h.Get("RateLimit-Reset"), 10, 64); err == nil {
+		// The draft style sends seconds until reset
+		info.ResetAt = time.Now().Add(time.Duration(delta) * time.Second)
+	}
+	if h.Get("Retry-After") != "" {
+		retryAfter := parseRetryAfter(h.Get("Retry-After"))
+		info.RetryAfter = &retryAfter
+	}
+	return info
+}
+
+// headerOr returns the legacy header, or the draft one for servers sending only that style
+func headerOr(h http.Header, legacy, draft string) string {
+	if v := h.Get(legacy); v != "" {
+		return v
+	}
+	return h.Get(draft)
+}
+
+// parseRetryAfter accepts both delta-seconds and HTTP-date forms
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if wait := time.Until(t); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+func parseError(data []byte) errorResponse {
+	var er errorResponse
+	if err := json.Unmarshal(data, &er); err != nil {
+		er.Message = strings.TrimSpace(string(data))
+	}
+	return er
+}
+
+func (er errorResponse) message() string {
+	if er.Message != "" {
+		return er.Message
+	}
+	return er.Error
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// ErrRateLimited is returned when the server denies a check with 429
+type ErrRateLimited struct {
+	Info limiter.LimitInfo // Limit state parsed from the response
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("rate limited: retry after %s", e.RetryAfter())
+}
+
+// RetryAfter returns how long to wait before retrying
+// Falls back to the time until reset when the server sent no explicit value
+func (e *ErrRateLimited) RetryAfter() time.Duration {
+	if e.Info.RetryAfter != nil {
+		return *e.Info.RetryAfter
+	}
+	if wait := time.Until(e.Info.ResetAt); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// ErrUnavailable is returned when the server responds with 503
+type ErrUnavailable struct {
+	RetryAfter time.Duration // Server-provided Retry-After (0 if absent)
+	Message    string
+}
+
+func (e *ErrUnavailable) Error() string {
+	if e.Message == "" {
+		return "rate limiter unavailable"
+	}
+	return fmt.Sprintf("rate limiter unavailable: %s", e.Message)
+}
+
+// ErrInvalidRequest is returned when the server rejects a request as invalid (4xx other than 429)
+type ErrInvalidRequest struct {
+	StatusCode int
+	Code       string // Machine-readable code from the server, if any
+	Message    string
+}
+
+func (e *ErrInvalidRequest) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("invalid request (%s): %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("invalid request: %s", e.Message)
+}
@@ -0,0 +1,40 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/client"
+)
+
+func ExampleClient_Do() {
+	c := client.New("http://localhost:8080")
+
+	req := client.CheckRequest{Resource: "api.export", Identifier: "user-123"}
+	err := c.Do(context.Background(), req, func(ctx context.Context) error {
+		// Expensive work runs only when the limiter allows it
+		return nil
+	})
+
+	var limited *client.ErrRateLimited
+	if errors.As(err, &limited) {
+		fmt.Printf("slow down, retry in %s\n", limited.RetryAfter())
+	}
+}
+
+func ExampleErrUnavailable() {
+	c := client.New("http://localhost:8080")
+
+	_, err := c.Check(context.Background(), client.CheckRequest{Resource: "api.search", Identifier: "user-123"})
+
+	var unavailable *client.ErrUnavailable
+	if errors.As(err, &unavailable) {
+		wait := unavailable.RetryAfter
+		if wait == 0 {
+			wait = time.Second
+		}
+		fmt.Printf("limiter down, backing off %s\n", wait)
+	}
+}
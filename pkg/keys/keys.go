@@ -0,0 +1,106 @@
+// Package keys builds rate limit keys from requests, for use as a middleware keyFunc
+package keys
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// FromIP returns the client IP of r
+// X-Forwarded-For and X-Real-IP are only honoured when the connection comes from one of
+// trustedProxies (IPs or CIDRs, e.g. "10.0.0.0/8"); otherwise anyone could pick their own
+// key by setting the header. X-Forwarded-For is read right to left, skipping trusted
+// proxies, so entries a client prepended are ignored. Falls back to RemoteAddr
+func FromIP(r *http.Request, trustedProxies []string) string {
+	remote := remoteIP(r.RemoteAddr)
+	peer, err := netip.ParseAddr(remote)
+	if err != nil {
+		return remote
+	}
+	peer = peer.Unmap()
+
+	trusted := parseTrusted(trustedProxies)
+	if !isTrusted(trusted, peer) {
+		return peer.String()
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// A malformed hop cannot be trusted to have come from a proxy; stop at
+				// the last address known to be genuine
+				break
+			}
+			client = hop.Unmap()
+			if !isTrusted(trusted, client) {
+				break
+			}
+		}
+		return client.String()
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap().String()
+	}
+	return peer.String()
+}
+
+// FromCIDR collapses ip into the network containing it, e.g. FromCIDR("203.0.113.7", 24) is
+// "203.0.113.0/24", so a whole subnet shares one limit
+// maskBits is clamped to the address length, so 64 keeps IPv4 addresses whole; an ip that
+// does not parse is returned unchanged
+func FromCIDR(ip string, maskBits int) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap().WithZone("")
+
+	maskBits = max(0, min(maskBits, addr.BitLen()))
+	prefix, err := addr.Prefix(maskBits)
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
+}
+
+// remoteIP strips the port from a RemoteAddr
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// parseTrusted parses proxies given as IPs or CIDRs, skipping any that do not parse
+func parseTrusted(proxies []string) []netip.Prefix {
+	trusted := make([]netip.Prefix, 0, len(proxies))
+	for _, p := range proxies {
+		p = strings.TrimSpace(p)
+		if prefix, err := netip.ParsePrefix(p); err == nil {
+			trusted = append(trusted, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(p); err == nil {
+			addr = addr.Unmap()
+			trusted = append(trusted, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return trusted
+}
+
+func isTrusted(trusted []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
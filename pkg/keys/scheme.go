@@ -0,0 +1,109 @@
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Separator joins a key's identifier and resource
+const Separator = ":"
+
+// ErrInvalidPart means an identifier or resource can not be made into a key: it holds
+// control characters, or a resource holds the separator
+var ErrInvalidPart = errors.New("invalid key part")
+
+// Scheme builds the limiter key a check by identifier on resource is counted under, and
+// splits keys back into the two. A scheme must never map two different pairs onto one key,
+// or one client could spend another's limit
+type Scheme interface {
+	// Join returns the key for identifier on resource, or an error wrapping ErrInvalidPart
+	// or limiter.ErrKeyTooLong
+	Join(identifier, resource string) (string, error)
+	// Split returns the identifier and resource key was joined from
+	Split(key string) (identifier, resource string, ok bool)
+}
+
+// Escaped is the default scheme: identifier:resource with '%' and ':' in the identifier
+// percent-escaped, so "alice:admin" on "reset" can not share a key with "alice" on
+// "admin:reset". Resources must not contain ':', and neither part control characters.
+// Identifiers without '%' or ':' get the same keys as under Legacy
+type Escaped struct{}
+
+var (
+	identifierEscaper   = strings.NewReplacer("%", "%25", ":", "%3A")
+	identifierUnescaper = strings.NewReplacer("%3A", ":", "%25", "%")
+)
+
+// Join returns the key for identifier on resource
+func (Escaped) Join(identifier, resource string) (string, error) {
+	if err := validPart("identifier", identifier); err != nil {
+		return "", err
+	}
+	if err := validPart("resource", resource); err != nil {
+		return "", err
+	}
+	if strings.Contains(resource, Separator) {
+		return "", fmt.Errorf("%w: resource %q contains %q", ErrInvalidPart, resource, Separator)
+	}
+	return checkLength(identifierEscaper.Replace(identifier) + Separator + resource)
+}
+
+// Split returns the identifier and resource key was joined from
+func (Escaped) Split(key string) (string, string, bool) {
+	i := strings.LastIndex(key, Separator)
+	if i < 0 {
+		return "", "", false
+	}
+	return identifierUnescaper.Replace(key[:i]), key[i+1:], true
+}
+
+// Legacy joins identifier and resource unescaped, as keys were built before Escaped
+// Pairs containing ':' can collide; it exists to keep existing keys through a cutover
+type Legacy struct{}
+
+// Join returns the key for identifier on resource
+func (Legacy) Join(identifier, resource string) (string, error) {
+	return checkLength(identifier + Separator + resource)
+}
+
+// Split returns the identifier and resource key was joined from, taking the resource as
+// what follows the last separator
+func (Legacy) Split(key string) (string, string, bool) {
+	i := strings.LastIndex(key, Separator)
+	if i < 0 {
+		return "", "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// ParseScheme returns the scheme named name: "escaped" (or empty) or "legacy"
+func ParseScheme(name string) (Scheme, error) {
+	switch name {
+	case "", "escaped":
+		return Escaped{}, nil
+	case "legacy":
+		return Legacy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown key scheme %q (valid: escaped, legacy)", name)
+	}
+}
+
+// validPart rejects parts holding control characters
+func validPart(name, part string) error {
+	if i := strings.IndexFunc(part, unicode.IsControl); i >= 0 {
+		return fmt.Errorf("%w: %s contains control character %U", ErrInvalidPart, name, []rune(part[i:])[0])
+	}
+	return nil
+}
+
+// checkLength rejects keys longer than limiters accept
+func checkLength(key string) (string, error) {
+	if len(key) > limiter.MaxKeyLength {
+		return "", fmt.Errorf("%w: %d bytes, max %d", limiter.ErrKeyTooLong, len(key), limiter.MaxKeyLength)
+	}
+	return key, nil
+}
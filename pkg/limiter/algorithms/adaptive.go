@@ -0,0 +1,240 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// AdaptiveConfig configures additive-increase/multiplicative-decrease limit adjustment
+type AdaptiveConfig struct {
+	Floor    int     // Lowest effective limit (>= 1)
+	Ceiling  int     // Highest effective limit, and where keys start
+	Increase int     // Added to the limit on each success (default 1)
+	Decrease float64 // Limit is multiplied by this on each failure, in (0, 1) (default 0.5)
+}
+
+// Validate checks the config, filling in defaults for Increase and Decrease
+func (c *AdaptiveConfig) Validate() error {
+	if c.Increase == 0 {
+		c.Increase = 1
+	}
+	if c.Decrease == 0 {
+		c.Decrease = 0.5
+	}
+
+	if c.Floor < 1 {
+		return fmt.Errorf("adaptive floor must be at least 1, got %d", c.Floor)
+	}
+	if c.Ceiling < c.Floor {
+		return fmt.Errorf("adaptive ceiling %d is below floor %d", c.Ceiling, c.Floor)
+	}
+	if c.Increase < 0 {
+		return fmt.Errorf("adaptive increase must be positive, got %d", c.Increase)
+	}
+	if c.Decrease <= 0 || c.Decrease >= 1 {
+		return fmt.Errorf("adaptive decrease must be between 0 and 1, got %v", c.Decrease)
+	}
+	return nil
+}
+
+// AdaptiveLimiter shrinks and grows the wrapped limiter's effective limit per key
+// from downstream feedback: each failure multiplies the limit by Decrease and each
+// success adds Increase, staying within [Floor, Ceiling]
+//
+// Requests are admitted only while the wrapped limiter's usage is below the
+// effective limit. Wrapped limiters that can peek are checked before consuming;
+// others consume first, so a request denied by the effective limit still uses quota
+type AdaptiveLimiter struct {
+	base   limiter.RateLimiter
+	config AdaptiveConfig
+	clock  limiter.Clock
+	limits map[string]int // Keys below the ceiling -> effective limit
+	mu     sync.RWMutex
+	closer
+}
+
+// NewAdaptiveLimiter wraps base with AIMD limit adjustment
+func NewAdaptiveLimiter(base limiter.RateLimiter, config AdaptiveConfig, opts ...Option) (*AdaptiveLimiter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &AdaptiveLimiter{
+		base:   base,
+		config: config,
+		clock:  applyOptions(opts).clock,
+		limits: make(map[string]int),
+	}, nil
+}
+
+// Limit returns the current effective limit for key
+func (al *AdaptiveLimiter) Limit(key string) int {
+	al.mu.RLock()
+	defer al.mu.RUnlock()
+	return al.limitLocked(key)
+}
+
+func (al *AdaptiveLimiter) limitLocked(key string) int {
+	if limit, ok := al.limits[key]; ok {
+		return limit
+	}
+	return al.config.Ceiling
+}
+
+// ReportOutcome adjusts the effective limit for key after a downstream call
+// and returns the new limit
+func (al *AdaptiveLimiter) ReportOutcome(key string, success bool) int {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	limit := al.limitLocked(key)
+	if success {
+		limit += al.config.Increase
+	} else {
+		limit = int(float64(limit) * al.config.Decrease)
+	}
+	limit = max(al.config.Floor, min(al.config.Ceiling, limit))
+
+	// Only keys held below the ceiling need state
+	if limit == al.config.Ceiling {
+		delete(al.limits, key)
+	} else {
+		al.limits[key] = limit
+	}
+	return limit
+}
+
+// Allow checks if a single request is allowed
+func (al *AdaptiveLimiter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return al.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed under the effective limit
+func (al *AdaptiveLimiter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return al.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (al *AdaptiveLimiter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return al.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed under the effective limit, honoring ctx
+func (al *AdaptiveLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := al.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	limit := al.Limit(key)
+
+	if peeker, ok := al.base.(limiter.Peeker); ok {
+		allowed, info, err := peeker.Peek(ctx, key, n)
+		if err != nil {
+			return false, nil, err
+		}
+		if !allowed || info.Limit-info.Remaining+n > limit {
+			return al.clamp(false, info, limit)
+		}
+	}
+
+	allowed, info, err := al.base.AllowNCtx(ctx, key, n)
+	if err != nil {
+		return false, nil, err
+	}
+	// info reflects usage after consuming, so compare without adding n again
+	if allowed && info.Limit-info.Remaining > limit {
+		allowed = false
+	}
+	return al.clamp(allowed, info, limit)
+}
+
+// Peek reports whether N requests would be allowed under the effective limit
+// Returns an error if the wrapped limiter cannot peek
+func (al *AdaptiveLimiter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	peeker, ok := al.base.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("wrapped limiter does not support peek")
+	}
+
+	allowed, info, err := peeker.Peek(ctx, key, n)
+	if err != nil {
+		return false, nil, err
+	}
+	limit := al.Limit(key)
+	return al.clamp(allowed && info.Limit-info.Remaining+n <= limit, info, limit)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (al *AdaptiveLimiter) Status(key string) (*limiter.LimitInfo, error) {
+	return al.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (al *AdaptiveLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := al.Peek(ctx, key, 0)
+	return info, err
+}
+
+// clamp reports info against the effective limit instead of the wrapped limiter's
+func (al *AdaptiveLimiter) clamp(allowed bool, info *limiter.LimitInfo, limit int) (bool, *limiter.LimitInfo, error) {
+	used := info.Limit - info.Remaining
+	info.Limit = limit
+	info.Remaining = max(0, limit-used)
+
+	// Denied by the effective limit alone: usage frees up by the wrapped limiter's reset
+	if !allowed && info.RetryAfter == nil {
+		retryAfter := max(0, info.ResetAt.Sub(al.clock.Now()))
+		info.RetryAfter = &retryAfter
+		info.Reason = limiter.ReasonLimitLowered
+	}
+	return allowed, info, nil
+}
+
+// Config returns the wrapped limiter's policy, or a zero Config if it cannot describe itself
+// Per-key effective limits are reported by Limit
+func (al *AdaptiveLimiter) Config() limiter.Config {
+	if d, ok := al.base.(limiter.Describer); ok {
+		return d.Config()
+	}
+	return limiter.Config{}
+}
+
+// UpdateConfig updates the wrapped limiter's policy; effective limits already lowered by feedback are kept
+// Returns an error if the wrapped limiter can not be reconfigured
+func (al *AdaptiveLimiter) UpdateConfig(config limiter.Config) error {
+	reconfigurer, ok := al.base.(limiter.Reconfigurer)
+	if !ok {
+		return fmt.Errorf("wrapped limiter does not support reconfiguration")
+	}
+	if err := reconfigurer.UpdateConfig(config); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Reset resets the rate limit for a key
+// The effective limit is kept since it tracks downstream health, not usage
+func (al *AdaptiveLimiter) Reset(key string) error {
+	return al.base.Reset(key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (al *AdaptiveLimiter) ResetCtx(ctx context.Context, key string) error {
+	return al.base.ResetCtx(ctx, key)
+}
+
+// ResetPrefix resets every key starting with prefix, returning how many had state
+func (al *AdaptiveLimiter) ResetPrefix(prefix string) (int, error) {
+	return al.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (al *AdaptiveLimiter) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	return resetBasePrefix(ctx, al.base, prefix)
+}
+
+// Close closes the wrapped limiter
+func (al *AdaptiveLimiter) Close() error {
+	return al.closeWith(al.base.Close)
+}
@@ -0,0 +1,75 @@
+package algorithms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// AllowAll consumes ns[i] tokens from each keys[i] if every bucket has them, or none at all
+func (tb *TokenBucket) AllowAll(keys []string, ns []int) (bool, []*limiter.LimitInfo, error) {
+	return tb.AllowAllCtx(context.Background(), keys, ns)
+}
+
+// AllowAllCtx is AllowAll with a context
+// The store must implement limiter.AtomicTokenConsumer, and the bucket use float precision
+// without debt. A key given more than once is checked once, for the sum of its counts, and
+// reports the same LimitInfo at each of its positions
+func (tb *TokenBucket) AllowAllCtx(ctx context.Context, keys []string, ns []int) (bool, []*limiter.LimitInfo, error) {
+	if err := tb.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	if len(keys) != len(ns) {
+		return false, nil, fmt.Errorf("%w: %d keys but %d counts", limiter.ErrInvalidN, len(keys), len(ns))
+	}
+
+	// Merge repeated keys, since each key is one step of the atomic call
+	var steps []limiter.TokenRequest
+	positions := make([]int, len(keys)) // Step each key position is checked by
+	seen := make(map[string]int, len(keys))
+	for i, key := range keys {
+		if err := validateRequest(key, ns[i]); err != nil {
+			return false, nil, err
+		}
+		j, ok := seen[key]
+		if !ok {
+			j = len(steps)
+			seen[key] = j
+			steps = append(steps, limiter.TokenRequest{Key: key})
+		}
+		steps[j].N += ns[i]
+		positions[i] = j
+	}
+	if len(steps) == 0 {
+		return true, nil, nil
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	consumer, ok := tb.store.(limiter.AtomicTokenConsumer)
+	if !ok {
+		return false, nil, errors.New("store does not support all-or-nothing checks")
+	}
+	if tb.fixed || tb.maxDebt > 0 {
+		return false, nil, errors.New("all-or-nothing checks need float precision without debt")
+	}
+
+	now := requestTime(ctx, tb.clock)
+	allowed, results, err := consumer.ConsumeTokensAllCtx(ctx, steps, tb.capacity, tb.refillRate, tb.initial, now)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to consume tokens: %w", err)
+	}
+
+	stepInfos := make([]*limiter.LimitInfo, len(results))
+	for j, r := range results {
+		stepInfos[j] = tb.limitInfo(r.Allowed, steps[j].N, r.Tokens, r.RetryAfter, now)
+	}
+	infos := make([]*limiter.LimitInfo, len(keys))
+	for i, j := range positions {
+		infos[i] = stepInfos[j]
+	}
+	return allowed, infos, nil
+}
@@ -0,0 +1,189 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// BanConfig configures temporary bans of keys that keep being denied
+type BanConfig struct {
+	Threshold int           // Denials allowed within Window; the next one bans the key
+	Window    time.Duration // Span denials are counted over
+	Duration  time.Duration // How long a ban lasts
+
+	// Allowlist holds keys, or glob patterns such as "monitoring:*", that are never banned
+	Allowlist []string
+
+	Metrics BanMetrics // Optional: tracks the number of active bans
+}
+
+// BanMetrics records the number of active bans
+type BanMetrics interface {
+	RecordBan()             // A key was banned
+	RecordActiveBans(n int) // n keys are banned now
+}
+
+// Validate checks the config
+func (c BanConfig) Validate() error {
+	if c.Threshold < 1 {
+		return fmt.Errorf("ban threshold must be positive, got %d", c.Threshold)
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("ban window must be positive, got %s", c.Window)
+	}
+	if c.Duration <= 0 {
+		return fmt.Errorf("ban duration must be positive, got %s", c.Duration)
+	}
+	for _, pattern := range c.Allowlist {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid ban allowlist pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Ban is a key's temporary ban
+type Ban struct {
+	Key        string
+	Until      time.Time     // When the ban ends
+	RetryAfter time.Duration // Time left on the ban when it was read
+}
+
+// Bans temporarily bans keys that keep being denied, so abusive clients stop costing a
+// limiter round trip per check
+//
+// A key denied more than Threshold times within Window is banned for Duration. Checking
+// whether a key is banned is a single store read; callers check it first and deny banned
+// keys without asking the limiter. Bans are kept in the store with a TTL, so they hold
+// across instances and lapse on their own
+type Bans struct {
+	banner    limiter.Banner
+	policy    limiter.BanPolicy
+	allowlist []string
+	metrics   BanMetrics
+	clock     limiter.Clock
+}
+
+// NewBans creates bans kept in store, which must implement limiter.Banner
+func NewBans(store limiter.Store, config BanConfig, opts ...Option) (*Bans, error) {
+	banner, ok := store.(limiter.Banner)
+	if !ok {
+		return nil, fmt.Errorf("bans require a store that can ban keys")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Bans{
+		banner: banner,
+		policy: limiter.BanPolicy{
+			Threshold: config.Threshold,
+			Window:    config.Window,
+			Duration:  config.Duration,
+		},
+		allowlist: config.Allowlist,
+		metrics:   config.Metrics,
+		clock:     applyOptions(opts).clock,
+	}, nil
+}
+
+// Allowlisted reports whether key is exempt from bans
+func (b *Bans) Allowlisted(key string) bool {
+	for _, pattern := range b.allowlist {
+		if pattern == key {
+			return true
+		}
+		if strings.ContainsAny(pattern, "*?[") {
+			if ok, _ := path.Match(pattern, key); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Banned returns key's ban, or nil if it is not banned
+// Allowlisted keys are never banned and cost no store read
+func (b *Bans) Banned(ctx context.Context, key string) (*Ban, error) {
+	if b.Allowlisted(key) {
+		return nil, nil
+	}
+	now := b.clock.Now()
+	until, err := b.banner.BannedCtx(ctx, key, now)
+	if err != nil || until.IsZero() {
+		return nil, err
+	}
+	return &Ban{Key: key, Until: until, RetryAfter: until.Sub(now)}, nil
+}
+
+// Deny records a denial of key, returning its ban if this denial banned it
+func (b *Bans) Deny(ctx context.Context, key string) (*Ban, error) {
+	if b.Allowlisted(key) {
+		return nil, nil
+	}
+	now := b.clock.Now()
+	until, err := b.banner.RecordDenialCtx(ctx, key, now, b.policy)
+	if err != nil || until.IsZero() {
+		return nil, err
+	}
+	if b.metrics != nil {
+		b.metrics.RecordBan()
+	}
+	return &Ban{Key: key, Until: until, RetryAfter: until.Sub(now)}, nil
+}
+
+// Unban lifts key's ban and forgets its denials, reporting whether it was banned
+func (b *Bans) Unban(ctx context.Context, key string) (bool, error) {
+	banned, err := b.banner.UnbanCtx(ctx, key, b.clock.Now())
+	if err != nil {
+		return false, err
+	}
+	if banned && b.metrics != nil {
+		// Best effort: the ban is lifted whether or not the recount succeeds
+		_, _ = b.List(ctx)
+	}
+	return banned, nil
+}
+
+// List returns every banned key, ordered by key, and refreshes the active bans metric
+func (b *Bans) List(ctx context.Context) ([]Ban, error) {
+	now := b.clock.Now()
+	banned, err := b.banner.BansCtx(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make([]Ban, 0, len(banned))
+	for key, until := range banned {
+		bans = append(bans, Ban{Key: key, Until: until, RetryAfter: until.Sub(now)})
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Key < bans[j].Key })
+
+	if b.metrics != nil {
+		b.metrics.RecordActiveBans(len(bans))
+	}
+	return bans, nil
+}
+
+// Watch refreshes the active bans metric every interval until ctx is done, so bans that
+// lapse on their own are no longer counted
+func (b *Bans) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Best effort: a failed count leaves the metric as it was until the next tick
+			_, _ = b.List(ctx)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// AllowBatch checks each request against rl as AllowNCtx would, returning results in order
+// Limiters implementing limiter.BatchLimiter check the batch in one store round trip; any
+// other limiter is checked a request at a time. A check that fails is reported on its result
+func AllowBatch(ctx context.Context, rl limiter.RateLimiter, requests []limiter.BatchRequest) ([]limiter.BatchResult, error) {
+	if b, ok := rl.(limiter.BatchLimiter); ok {
+		return b.AllowBatchCtx(ctx, requests)
+	}
+	return allowEach(ctx, rl, requests)
+}
+
+// allowEach checks requests one at a time
+func allowEach(ctx context.Context, rl limiter.RateLimiter, requests []limiter.BatchRequest) ([]limiter.BatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]limiter.BatchResult, len(requests))
+	for i, r := range requests {
+		allowed, info, err := rl.AllowNCtx(ctx, r.Key, r.N)
+		results[i] = limiter.BatchResult{Allowed: allowed, Info: info, Err: err}
+	}
+	return results, nil
+}
+
+// AllowBatch checks many keys, in one store round trip where the store supports it
+func (tb *TokenBucket) AllowBatch(requests []limiter.BatchRequest) ([]limiter.BatchResult, error) {
+	return tb.AllowBatchCtx(context.Background(), requests)
+}
+
+// AllowBatchCtx is AllowBatch with a context
+// Stores implementing limiter.BatchTokenConsumer run every step in one round trip; fixed
+// precision and debt have no batched step, so those buckets check a request at a time
+func (tb *TokenBucket) AllowBatchCtx(ctx context.Context, requests []limiter.BatchRequest) ([]limiter.BatchResult, error) {
+	if err := tb.ready(ctx); err != nil {
+		return nil, err
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	consumer, ok := tb.store.(limiter.BatchTokenConsumer)
+	if !ok || tb.fixed || tb.maxDebt > 0 {
+		results := make([]limiter.BatchResult, len(requests))
+		for i, r := range requests {
+			allowed, info, err := tb.evaluate(ctx, r.Key, r.N, true)
+			results[i] = limiter.BatchResult{Allowed: allowed, Info: info, Err: err}
+		}
+		return results, nil
+	}
+
+	// Invalid requests fail on their own and are not sent
+	results := make([]limiter.BatchResult, len(requests))
+	steps := make([]limiter.TokenRequest, 0, len(requests))
+	indices := make([]int, 0, len(requests))
+	for i, r := range requests {
+		if err := validateRequest(r.Key, r.N); err != nil {
+			results[i].Err = err
+			continue
+		}
+		steps = append(steps, limiter.TokenRequest{Key: r.Key, N: r.N})
+		indices = append(indices, i)
+	}
+	if len(steps) == 0 {
+		return results, nil
+	}
+
+	now := requestTime(ctx, tb.clock)
+	stepResults, err := consumer.ConsumeTokensBatchCtx(ctx, steps, tb.capacity, tb.refillRate, tb.initial, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume tokens: %w", err)
+	}
+
+	for j, step := range stepResults {
+		i := indices[j]
+		if step.Err != nil {
+			results[i].Err = fmt.Errorf("failed to consume tokens: %w", step.Err)
+			continue
+		}
+		results[i] = limiter.BatchResult{
+			Allowed: step.Allowed,
+			Info:    tb.limitInfo(step.Allowed, steps[j].N, step.Tokens, step.RetryAfter, now),
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,44 @@
+package algorithms
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// closer tracks whether a limiter has been closed, so checks made after Close fail with
+// limiter.ErrClosed instead of reaching a store that may be gone
+type closer struct {
+	closed    atomic.Bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// closeWith marks the limiter closed and runs release once; later calls return its result
+func (c *closer) closeWith(release func() error) error {
+	c.closeOnce.Do(func() {
+		c.closed.Store(true)
+		c.closeErr = release()
+	})
+	return c.closeErr
+}
+
+// ready returns limiter.ErrClosed once the limiter is closed, else ctx's error
+func (c *closer) ready(ctx context.Context) error {
+	if c.closed.Load() {
+		return limiter.ErrClosed
+	}
+	return ctx.Err()
+}
+
+// closeAll closes each limiter, joining their errors
+func closeAll(limiters ...limiter.RateLimiter) error {
+	var errs []error
+	for _, l := range limiters {
+		errs = append(errs, l.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,288 @@
+package algorithms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// compositeKeyPrefix namespaces each window's state so members sharing a store do not collide
+const compositeKeyPrefix = "composite:"
+
+// CompositeLimiter enforces several policies at once, e.g. 100/min AND 1000/hour
+// A request is allowed only if every window permits it
+//
+// Windows are consumed in order. If a later window denies, the earlier ones are
+// refunded when every member is a limiter.Refunder; otherwise all windows are peeked
+// first so none is consumed unless all have room. Checks are serialized in-process
+type CompositeLimiter struct {
+	members    []compositeMember
+	refundable bool
+	mu         sync.Mutex
+	closer
+}
+
+type compositeMember struct {
+	limiter limiter.RateLimiter
+	config  limiter.Config
+	policy  string // "limit/window", reported in LimitInfo.Policy
+	prefix  string
+}
+
+// NewCompositeLimiter builds one limiter per config with build and combines them
+// Every config needs a positive limit and window, and no two may share a window
+func NewCompositeLimiter(configs []limiter.Config, build func(limiter.Config) (limiter.RateLimiter, error)) (*CompositeLimiter, error) {
+	if len(configs) == 0 {
+		return nil, errors.New("composite limiter needs at least one window")
+	}
+
+	c := &CompositeLimiter{refundable: true}
+	seen := make(map[time.Duration]bool, len(configs))
+	for _, config := range configs {
+		if config.Limit <= 0 || config.Window <= 0 {
+			return nil, fmt.Errorf("composite window needs a positive limit and window, got %d/%s", config.Limit, config.Window)
+		}
+		if seen[config.Window] {
+			return nil, fmt.Errorf("composite window %s is configured more than once", config.Window)
+		}
+		seen[config.Window] = true
+
+		l, err := build(config)
+		if err != nil {
+			return nil, fmt.Errorf("composite window %s: %w", config.Window, err)
+		}
+		if _, ok := l.(limiter.Refunder); !ok {
+			c.refundable = false
+		}
+		c.members = append(c.members, compositeMember{
+			limiter: l,
+			config:  config,
+			policy:  fmt.Sprintf("%d/%s", config.Limit, config.Window),
+			prefix:  compositeKeyPrefix + config.Window.String() + ":",
+		})
+	}
+	return c, nil
+}
+
+// multiKeyPrefix namespaces each limiter's state in a MultiLimiter by its position
+const multiKeyPrefix = "multi:"
+
+// NewMultiLimiter combines limiters that are already built, e.g. a tight per-minute limit and
+// a loose per-hour one, so a key must fit all of them. It behaves as a CompositeLimiter over
+// limiters in the order given; each one's keys are prefixed with its position, so limiters
+// sharing a store do not collide. Panics if given no limiters
+func NewMultiLimiter(limiters ...limiter.RateLimiter) limiter.RateLimiter {
+	if len(limiters) == 0 {
+		panic("multi limiter needs at least one limiter")
+	}
+
+	c := &CompositeLimiter{refundable: true}
+	for i, l := range limiters {
+		if _, ok := l.(limiter.Refunder); !ok {
+			c.refundable = false
+		}
+		m := compositeMember{limiter: l, prefix: fmt.Sprintf("%s%d:", multiKeyPrefix, i)}
+		if d, ok := l.(limiter.Describer); ok {
+			m.config = d.Config()
+			m.policy = fmt.Sprintf("%d/%s", m.config.Limit, m.config.Window)
+		}
+		c.members = append(c.members, m)
+	}
+	return c
+}
+
+// Allow checks if a single request is allowed
+func (c *CompositeLimiter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return c.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed by every window
+func (c *CompositeLimiter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return c.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (c *CompositeLimiter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return c.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed by every window, honoring ctx
+// A denial reports the window that denied; an allow reports the window with the least room
+func (c *CompositeLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := c.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Without a refund path, only start consuming once every window has room
+	if !c.refundable {
+		allowed, info, err := c.peek(ctx, key, n)
+		if err != nil || !allowed {
+			return allowed, info, err
+		}
+	}
+
+	infos := make([]*limiter.LimitInfo, 0, len(c.members))
+	for i, m := range c.members {
+		allowed, info, err := m.limiter.AllowNCtx(ctx, m.prefix+key, n)
+		if err != nil || !allowed {
+			if refundErr := c.refund(ctx, key, n, c.members[:i]); refundErr != nil {
+				return false, nil, fmt.Errorf("failed to refund windows after %s denial: %w", m.policy, refundErr)
+			}
+			if info != nil {
+				info.Policy = m.policy
+			}
+			return allowed, info, err
+		}
+		info.Policy = m.policy
+		infos = append(infos, info)
+	}
+
+	return true, tightest(infos), nil
+}
+
+// Peek reports whether N requests would be allowed by every window without consuming anything
+// Returns an error if any window cannot peek
+func (c *CompositeLimiter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := c.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.peek(ctx, key, n)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (c *CompositeLimiter) Status(key string) (*limiter.LimitInfo, error) {
+	return c.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (c *CompositeLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := c.Peek(ctx, key, 0)
+	return info, err
+}
+
+// peek checks every window in order; callers must hold c.mu
+func (c *CompositeLimiter) peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	infos := make([]*limiter.LimitInfo, 0, len(c.members))
+	for _, m := range c.members {
+		peeker, ok := m.limiter.(limiter.Peeker)
+		if !ok {
+			return false, nil, fmt.Errorf("window %s does not support peek", m.policy)
+		}
+
+		allowed, info, err := peeker.Peek(ctx, m.prefix+key, n)
+		if err != nil {
+			return false, nil, err
+		}
+		info.Policy = m.policy
+		if !allowed {
+			return false, info, nil
+		}
+		infos = append(infos, info)
+	}
+	return true, tightest(infos), nil
+}
+
+// refund returns n requests to each of consumed; a no-op unless every member can refund
+func (c *CompositeLimiter) refund(ctx context.Context, key string, n int, consumed []compositeMember) error {
+	if !c.refundable {
+		return nil
+	}
+	for _, m := range consumed {
+		if err := m.limiter.(limiter.Refunder).Refund(ctx, m.prefix+key, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tightest returns the window with the fewest requests remaining, preferring the later reset on ties
+func tightest(infos []*limiter.LimitInfo) *limiter.LimitInfo {
+	tight := infos[0]
+	for _, info := range infos[1:] {
+		if info.Remaining < tight.Remaining || (info.Remaining == tight.Remaining && info.ResetAt.After(tight.ResetAt)) {
+			tight = info
+		}
+	}
+	return tight
+}
+
+// Configs returns the policies of every window, in evaluation order
+func (c *CompositeLimiter) Configs() []limiter.Config {
+	configs := make([]limiter.Config, len(c.members))
+	for i, m := range c.members {
+		configs[i] = m.config
+	}
+	return configs
+}
+
+// Config returns the first window's policy; Configs lists all of them
+func (c *CompositeLimiter) Config() limiter.Config {
+	if d, ok := c.members[0].limiter.(limiter.Describer); ok {
+		return d.Config()
+	}
+	return c.members[0].config
+}
+
+// Reset resets every window for a key
+func (c *CompositeLimiter) Reset(key string) error {
+	return c.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets every window for a key, honoring ctx
+func (c *CompositeLimiter) ResetCtx(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, m := range c.members {
+		if err := m.limiter.ResetCtx(ctx, m.prefix+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResetPrefix resets every window of every key starting with prefix, returning how many
+// keys had state in the window that had most
+func (c *CompositeLimiter) ResetPrefix(prefix string) (int, error) {
+	return c.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (c *CompositeLimiter) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, limiter.ErrEmptyPrefix
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reset := 0
+	for _, m := range c.members {
+		n, err := resetBasePrefix(ctx, m.limiter, m.prefix+prefix)
+		if err != nil {
+			return 0, err
+		}
+		reset = max(reset, n)
+	}
+	return reset, nil
+}
+
+// Close closes every member limiter
+func (c *CompositeLimiter) Close() error {
+	return c.closeWith(func() error {
+		limiters := make([]limiter.RateLimiter, len(c.members))
+		for i, m := range c.members {
+			limiters[i] = m.limiter
+		}
+		return closeAll(limiters...)
+	})
+}
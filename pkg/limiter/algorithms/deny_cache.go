@@ -0,0 +1,272 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// minDenyCacheSweep is how many cached keys are held before expired ones are swept
+const minDenyCacheSweep = 1024
+
+// DenyCacheConfig configures local caching of denials for hot keys
+type DenyCacheConfig struct {
+	// Staleness is the longest a cached denial is served without asking the wrapped limiter
+	// again, which bounds how long a reset or refund can go unnoticed
+	Staleness time.Duration
+
+	Metrics   DenyCacheMetrics // Optional: counts decisions served from the cache and from the store
+	Algorithm string           // Algorithm label for metrics
+}
+
+// DenyCacheMetrics counts deny cache decisions by where they were served from
+type DenyCacheMetrics interface {
+	RecordDenyCache(algorithm string, cached bool)
+}
+
+// Validate checks the config
+func (c DenyCacheConfig) Validate() error {
+	if c.Staleness <= 0 {
+		return fmt.Errorf("deny cache staleness must be positive, got %s", c.Staleness)
+	}
+	return nil
+}
+
+// DenyCache answers repeat denials for keys that are over their limit from local memory,
+// so a key checked hundreds of thousands of times a second costs one store round trip
+// per Staleness instead of one per check
+//
+// When the wrapped limiter denies n requests with a RetryAfter, the key is denied locally
+// for checks of n or more until then, or for Staleness if that is sooner. Only denials are
+// cached, so the cache can never admit a request the wrapped limiter would deny; at worst
+// a key that was reset or refunded keeps being denied for up to Staleness
+type DenyCache struct {
+	base    limiter.RateLimiter
+	config  DenyCacheConfig
+	clock   limiter.Clock
+	entries map[string]deniedKey
+	sweepAt int
+	mu      sync.RWMutex
+	closer
+}
+
+// deniedKey is a cached denial
+type deniedKey struct {
+	n       int       // Smallest request size known to be denied
+	until   time.Time // When the denial stops being served from the cache
+	retryAt time.Time // When the wrapped limiter said to retry
+	info    limiter.LimitInfo
+}
+
+// NewDenyCache wraps base, caching its denials for up to config.Staleness
+func NewDenyCache(base limiter.RateLimiter, config DenyCacheConfig, opts ...Option) (*DenyCache, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &DenyCache{
+		base:    base,
+		config:  config,
+		clock:   applyOptions(opts).clock,
+		entries: make(map[string]deniedKey),
+		sweepAt: minDenyCacheSweep,
+	}, nil
+}
+
+// Allow checks if a single request is allowed
+func (dc *DenyCache) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return dc.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (dc *DenyCache) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return dc.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (dc *DenyCache) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return dc.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, from the cache if the key is known to be
+// over its limit and from the wrapped limiter otherwise, honoring ctx
+func (dc *DenyCache) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	allowed, _, info, err := dc.AllowNCached(ctx, key, n)
+	return allowed, info, err
+}
+
+// AllowNCached is AllowNCtx, also reporting whether the decision came from the cache
+func (dc *DenyCache) AllowNCached(ctx context.Context, key string, n int) (allowed, cached bool, info *limiter.LimitInfo, err error) {
+	if err := dc.ready(ctx); err != nil {
+		return false, false, nil, err
+	}
+	if info, ok := dc.lookup(key, n); ok {
+		dc.record(true)
+		return false, true, info, nil
+	}
+
+	allowed, info, err = dc.base.AllowNCtx(ctx, key, n)
+	if err != nil {
+		return false, false, nil, err
+	}
+	dc.record(false)
+	if !allowed {
+		dc.store(key, n, info)
+	}
+	return allowed, false, info, nil
+}
+
+// Peek reports whether N requests would be allowed, from the cache if it holds a denial
+// Returns an error if the wrapped limiter cannot peek
+func (dc *DenyCache) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if info, ok := dc.lookup(key, n); ok {
+		return false, info, nil
+	}
+
+	peeker, ok := dc.base.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("wrapped limiter does not support peek")
+	}
+	return peeker.Peek(ctx, key, n)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (dc *DenyCache) Status(key string) (*limiter.LimitInfo, error) {
+	return dc.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (dc *DenyCache) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := dc.Peek(ctx, key, 0)
+	return info, err
+}
+
+// lookup returns the cached denial covering n requests for key, if there is one
+// Zero-sized checks read status and always go to the wrapped limiter
+func (dc *DenyCache) lookup(key string, n int) (*limiter.LimitInfo, bool) {
+	if n <= 0 {
+		return nil, false
+	}
+
+	dc.mu.RLock()
+	entry, ok := dc.entries[key]
+	dc.mu.RUnlock()
+
+	now := dc.clock.Now()
+	if !ok || n < entry.n || !now.Before(entry.until) {
+		return nil, false
+	}
+
+	info := entry.info
+	retryAfter := entry.retryAt.Sub(now)
+	info.RetryAfter = &retryAfter
+	return &info, true
+}
+
+// store caches a denial of n requests for key
+// Denials without a RetryAfter give no time the key is sure to stay denied and are not cached
+func (dc *DenyCache) store(key string, n int, info *limiter.LimitInfo) {
+	if info == nil || info.RetryAfter == nil || *info.RetryAfter <= 0 {
+		return
+	}
+
+	now := dc.clock.Now()
+	until := now.Add(min(*info.RetryAfter, dc.config.Staleness))
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	// A live denial of fewer requests already covers this one
+	if entry, ok := dc.entries[key]; ok && entry.n < n && now.Before(entry.until) {
+		return
+	}
+
+	cached := *info
+	cached.RetryAfter = nil
+	dc.entries[key] = deniedKey{n: n, until: until, retryAt: now.Add(*info.RetryAfter), info: cached}
+
+	if len(dc.entries) >= dc.sweepAt {
+		for k, entry := range dc.entries {
+			if !now.Before(entry.until) {
+				delete(dc.entries, k)
+			}
+		}
+		dc.sweepAt = max(minDenyCacheSweep, 2*len(dc.entries))
+	}
+}
+
+// record counts a decision by where it was served from
+func (dc *DenyCache) record(cached bool) {
+	if dc.config.Metrics != nil {
+		dc.config.Metrics.RecordDenyCache(dc.config.Algorithm, cached)
+	}
+}
+
+// Config returns the wrapped limiter's policy, or a zero Config if it cannot describe itself
+func (dc *DenyCache) Config() limiter.Config {
+	if d, ok := dc.base.(limiter.Describer); ok {
+		return d.Config()
+	}
+	return limiter.Config{}
+}
+
+// UpdateConfig updates the wrapped limiter's policy and forgets cached denials, which were
+// decided under the old one. Returns an error if the wrapped limiter can not be reconfigured
+func (dc *DenyCache) UpdateConfig(config limiter.Config) error {
+	reconfigurer, ok := dc.base.(limiter.Reconfigurer)
+	if !ok {
+		return fmt.Errorf("wrapped limiter does not support reconfiguration")
+	}
+	if err := reconfigurer.UpdateConfig(config); err != nil {
+		return err
+	}
+	dc.mu.Lock()
+	clear(dc.entries)
+	dc.mu.Unlock()
+	return nil
+}
+
+// Reset resets the rate limit for a key and forgets its cached denial on this instance
+// Other instances keep serving theirs for up to Staleness
+func (dc *DenyCache) Reset(key string) error {
+	return dc.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (dc *DenyCache) ResetCtx(ctx context.Context, key string) error {
+	dc.mu.Lock()
+	delete(dc.entries, key)
+	dc.mu.Unlock()
+	return dc.base.ResetCtx(ctx, key)
+}
+
+// ResetPrefix resets every key starting with prefix and forgets their cached denials on
+// this instance, returning how many had state
+func (dc *DenyCache) ResetPrefix(prefix string) (int, error) {
+	return dc.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (dc *DenyCache) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	dc.mu.Lock()
+	for key := range dc.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(dc.entries, key)
+		}
+	}
+	dc.mu.Unlock()
+	return resetBasePrefix(ctx, dc.base, prefix)
+}
+
+// Close drops the cached denials and closes the wrapped limiter
+func (dc *DenyCache) Close() error {
+	return dc.closeWith(func() error {
+		dc.mu.Lock()
+		clear(dc.entries)
+		dc.mu.Unlock()
+		return dc.base.Close()
+	})
+}
@@ -0,0 +1,7 @@
+// Package algorithms implements the rate limiting algorithms and the limiters composed from
+// them: tiers of limits, quotas, pools, bans and the like
+//
+// Each constructor takes a limiter.Store from package store, so the same limiter runs
+// in-process on a MemoryStore or shared across instances on Redis. Importing the package
+// registers the built-in algorithms with limiter.New
+package algorithms
@@ -0,0 +1,64 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// EstimateWait returns how long until p would allow n requests for key, zero if it would now
+// It peeks, so nothing is consumed, and relies on the RetryAfter peeks report. Fails with
+// limiter.ErrRequestExceedsCapacity if n is more than p's limit, which no wait would allow
+// The estimate holds only until another request for key is allowed
+func EstimateWait(ctx context.Context, p limiter.Peeker, key string, n int) (time.Duration, error) {
+	return estimateWait(ctx, p, key, n, 0)
+}
+
+// estimateWait is EstimateWait for limiters that may overdraw their limit by up to slack
+func estimateWait(ctx context.Context, p limiter.Peeker, key string, n, slack int) (time.Duration, error) {
+	allowed, info, err := p.Peek(ctx, key, n)
+	if err != nil {
+		return 0, err
+	}
+	if capacity := info.Limit + slack; n > capacity {
+		return 0, fmt.Errorf("%w: estimate for %d requests, capacity %d", limiter.ErrRequestExceedsCapacity, n, capacity)
+	}
+	if allowed || info.RetryAfter == nil {
+		return 0, nil
+	}
+	return max(*info.RetryAfter, 0), nil
+}
+
+// EstimateWait returns how long until n tokens will have refilled for key
+func (tb *TokenBucket) EstimateWait(key string, n int) (time.Duration, error) {
+	return estimateWait(context.Background(), tb, key, n, tb.maxDebt)
+}
+
+// EstimateWait returns how long until the window holding key's count resets, if n more
+// requests do not fit in the current one
+func (fwc *FixedWindowCounter) EstimateWait(key string, n int) (time.Duration, error) {
+	return EstimateWait(context.Background(), fwc, key, n)
+}
+
+// EstimateWait returns how long until enough of the previous window's weight ages out
+// for n more requests
+func (swc *SlidingWindowCounter) EstimateWait(key string, n int) (time.Duration, error) {
+	return EstimateWait(context.Background(), swc, key, n)
+}
+
+// EstimateWait returns how long until enough logged requests expire for n more
+func (swl *SlidingWindowLog) EstimateWait(key string, n int) (time.Duration, error) {
+	return EstimateWait(context.Background(), swl, key, n)
+}
+
+// EstimateWait returns how long until the bucket drains enough to hold n more requests
+func (lb *LeakyBucket) EstimateWait(key string, n int) (time.Duration, error) {
+	return EstimateWait(context.Background(), lb, key, n)
+}
+
+// EstimateWait returns how long until n more requests arrive within the burst tolerance
+func (g *GCRA) EstimateWait(key string, n int) (time.Duration, error) {
+	return EstimateWait(context.Background(), g, key, n)
+}
@@ -0,0 +1,45 @@
+package algorithms_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+)
+
+func ExampleNewTokenBucket() {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 2, Window: time.Minute})
+	for i := 0; i < 3; i++ {
+		allowed, info, err := tb.Allow("user:42")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(allowed, info.Remaining)
+	}
+	// Output:
+	// true 1
+	// true 0
+	// false 0
+}
+
+func Example_new() {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	// Importing package algorithms registers the built-in algorithms by name
+	rl, err := limiter.New(s, limiter.Config{Algorithm: algorithms.FixedWindowCounterAlgorithm, Limit: 100, Window: time.Minute})
+	if err != nil {
+		panic(err)
+	}
+	allowed, info, err := rl.AllowN("user:42", 1)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(allowed, info.Limit)
+	// Output: true 100
+}
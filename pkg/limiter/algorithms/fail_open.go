@@ -0,0 +1,170 @@
+package algorithms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Failure modes decide what a check gets when the store errors
+const (
+	FailClosed = "fail_closed" // Return the store's error, so the check fails (default)
+	FailOpen   = "fail_open"   // Allow the check, trading enforcement for availability
+)
+
+// FailOpenConfig configures a FailOpenLimiter
+type FailOpenConfig struct {
+	Metrics   FailOpenMetrics // Optional: counts checks allowed because the store failed
+	Algorithm string          // Algorithm label for metrics
+}
+
+// FailOpenMetrics counts checks allowed by a FailOpenLimiter despite a store error
+type FailOpenMetrics interface {
+	RecordFailOpen(algorithm string)
+}
+
+// FailOpenLimiter allows checks the wrapped limiter fails with a store error, so an
+// outage of the store stops enforcement instead of blocking every client
+//
+// Only errors wrapping limiter.ErrStoreUnavailable are turned into allows: invalid
+// requests, misconfiguration, a closed limiter and the caller's own cancellation still
+// return their error. Allowed checks report the limiter's configured limit with nothing
+// used, since the real state could not be read
+type FailOpenLimiter struct {
+	base   limiter.RateLimiter
+	config FailOpenConfig
+	clock  limiter.Clock
+	closer
+}
+
+// NewFailOpenLimiter wraps base so that store errors allow the check
+func NewFailOpenLimiter(base limiter.RateLimiter, config FailOpenConfig, opts ...Option) *FailOpenLimiter {
+	return &FailOpenLimiter{
+		base:   base,
+		config: config,
+		clock:  applyOptions(opts).clock,
+	}
+}
+
+// Allow checks if a single request is allowed
+func (fl *FailOpenLimiter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return fl.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed, allowing them if the store fails
+func (fl *FailOpenLimiter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return fl.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (fl *FailOpenLimiter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return fl.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, allowing them if the store fails
+func (fl *FailOpenLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := fl.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	allowed, info, err := fl.base.AllowNCtx(ctx, key, n)
+	return fl.failOpen(ctx, allowed, info, err)
+}
+
+// Peek reports whether N requests would be allowed, allowing them if the store fails
+// Returns an error if the wrapped limiter cannot peek
+func (fl *FailOpenLimiter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := fl.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	peeker, ok := fl.base.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("wrapped limiter does not support peek")
+	}
+
+	allowed, info, err := peeker.Peek(ctx, key, n)
+	return fl.failOpen(ctx, allowed, info, err)
+}
+
+// Status reports the current limit state for key without consuming anything
+// Store errors are returned as is: a status read has nothing to let through
+func (fl *FailOpenLimiter) Status(key string) (*limiter.LimitInfo, error) {
+	return fl.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (fl *FailOpenLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	if err := fl.ready(ctx); err != nil {
+		return nil, err
+	}
+	return fl.base.StatusCtx(ctx, key)
+}
+
+// failOpen turns a store error into an allow, passing other results through
+func (fl *FailOpenLimiter) failOpen(ctx context.Context, allowed bool, info *limiter.LimitInfo, err error) (bool, *limiter.LimitInfo, error) {
+	if err == nil || !storeFailure(ctx, err) {
+		return allowed, info, err
+	}
+
+	if fl.config.Metrics != nil {
+		fl.config.Metrics.RecordFailOpen(fl.config.Algorithm)
+	}
+	config := fl.Config()
+	return true, &limiter.LimitInfo{
+		Limit:     config.Limit,
+		Remaining: config.Limit,
+		ResetAt:   fl.clock.Now().Add(config.Window),
+		Window:    config.Window,
+	}, nil
+}
+
+// storeFailure reports whether err means the store could not be reached, as the stores
+// report outages, rather than the caller giving up or anything wrong with the request or
+// the limiter's own setup
+func storeFailure(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && errors.Is(err, limiter.ErrStoreUnavailable)
+}
+
+// Config returns the wrapped limiter's policy, or a zero Config if it cannot describe itself
+func (fl *FailOpenLimiter) Config() limiter.Config {
+	if d, ok := fl.base.(limiter.Describer); ok {
+		return d.Config()
+	}
+	return limiter.Config{}
+}
+
+// UpdateConfig updates the wrapped limiter's policy
+// Returns an error if the wrapped limiter can not be reconfigured
+func (fl *FailOpenLimiter) UpdateConfig(config limiter.Config) error {
+	reconfigurer, ok := fl.base.(limiter.Reconfigurer)
+	if !ok {
+		return fmt.Errorf("wrapped limiter does not support reconfiguration")
+	}
+	return reconfigurer.UpdateConfig(config)
+}
+
+// Reset resets the rate limit for a key
+func (fl *FailOpenLimiter) Reset(key string) error {
+	return fl.base.Reset(key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (fl *FailOpenLimiter) ResetCtx(ctx context.Context, key string) error {
+	return fl.base.ResetCtx(ctx, key)
+}
+
+// ResetPrefix resets every key starting with prefix, returning how many had state
+func (fl *FailOpenLimiter) ResetPrefix(prefix string) (int, error) {
+	return fl.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (fl *FailOpenLimiter) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	return resetBasePrefix(ctx, fl.base, prefix)
+}
+
+// Close closes the wrapped limiter
+func (fl *FailOpenLimiter) Close() error {
+	return fl.closeWith(fl.base.Close)
+}
@@ -0,0 +1,325 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FixedWindowCounter implements fixed window counter algorithm
+// Simple and fast - divides time into fixed windows
+// Trade-off: allows bursts at window boundaries (2x limit possible)
+// Lowest memory usage and highest performance
+type FixedWindowCounter struct {
+	store     limiter.Store
+	clock     limiter.Clock
+	tracer    trace.Tracer
+	limit     int
+	burst     int // Requests admitted above limit in a window
+	window    time.Duration
+	alignment string        // Calendar unit windows follow (empty or AlignEpoch = Window-long spans)
+	jitter    time.Duration // Spread of per-key reset offsets (0 = none)
+	shift     bool          // Whether offsets move the window boundaries, not just the reported reset
+	mu        sync.RWMutex
+	closer
+}
+
+// NewFixedWindowCounter creates a new fixed window counter rate limiter
+func NewFixedWindowCounter(store limiter.Store, config limiter.Config, opts ...Option) *FixedWindowCounter {
+	o := applyOptions(opts)
+	return &FixedWindowCounter{
+		store:     store,
+		clock:     o.clock,
+		tracer:    o.tracer,
+		limit:     config.Limit,
+		burst:     config.WindowBurst,
+		window:    config.Window,
+		alignment: config.Alignment,
+		jitter:    config.ResetJitter,
+		shift:     config.JitterWindows,
+	}
+}
+
+// Allow checks if a single request is allowed
+func (fwc *FixedWindowCounter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return fwc.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (fwc *FixedWindowCounter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return fwc.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (fwc *FixedWindowCounter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return fwc.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, honoring ctx
+func (fwc *FixedWindowCounter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := fwc.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	ctx, span := startAllowSpan(ctx, fwc.tracer, "fixed_window", key, n)
+
+	fwc.mu.Lock()
+	defer fwc.mu.Unlock()
+
+	// Zero requests only read the window's count
+	return span.end(fwc.evaluate(ctx, key, n, n > 0))
+}
+
+// AllowNAt checks if N requests are allowed as of at instead of the limiter's clock,
+// counting them in the window containing at
+func (fwc *FixedWindowCounter) AllowNAt(key string, n int, at time.Time) (bool, *limiter.LimitInfo, error) {
+	return fwc.AllowNCtx(limiter.WithRequestTime(context.Background(), at), key, n)
+}
+
+// Peek reports whether N requests would be allowed without consuming anything
+func (fwc *FixedWindowCounter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := fwc.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	fwc.mu.RLock()
+	defer fwc.mu.RUnlock()
+	return fwc.evaluate(ctx, key, n, false)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (fwc *FixedWindowCounter) Status(key string) (*limiter.LimitInfo, error) {
+	return fwc.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (fwc *FixedWindowCounter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := fwc.Peek(ctx, key, 0)
+	return info, err
+}
+
+// Usage reports the requests counted in key's current window and the window's bounds
+func (fwc *FixedWindowCounter) Usage(ctx context.Context, key string) (*limiter.Usage, error) {
+	if err := fwc.ready(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateRequest(key, 0); err != nil {
+		return nil, err
+	}
+
+	fwc.mu.RLock()
+	defer fwc.mu.RUnlock()
+
+	now := requestTime(ctx, fwc.clock)
+	currentWindow, resetAt := fwc.keyWindowBounds(key, now)
+	count, err := fwc.count(ctx, key, currentWindow, now)
+	if err != nil {
+		return nil, err
+	}
+	return &limiter.Usage{
+		Used:        float64(count),
+		Limit:       fwc.limit,
+		WindowStart: currentWindow,
+		WindowEnd:   resetAt,
+	}, nil
+}
+
+// evaluate checks N requests against the current window, consuming them only when consume is set
+// Callers must hold fwc.mu
+func (fwc *FixedWindowCounter) evaluate(ctx context.Context, key string, n int, consume bool) (bool, *limiter.LimitInfo, error) {
+	if err := validateRequest(key, n); err != nil {
+		return false, nil, err
+	}
+
+	now := requestTime(ctx, fwc.clock)
+	currentWindow, resetAt := fwc.keyWindowBounds(key, now)
+
+	// Get current count for this window
+	currentCount, err := fwc.count(ctx, key, currentWindow, now)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Check if request allowed, letting the burst take the window over its limit
+	allowed := currentCount+int64(n) <= int64(fwc.limit+fwc.burst)
+
+	if allowed && consume {
+		// Count all n requests in the window
+		newCount, err := fwc.increment(ctx, key, currentWindow, resetAt, n)
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to increment: %w", err)
+		}
+		currentCount = newCount
+	}
+
+	remaining := fwc.limit - int(currentCount)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	info := &limiter.LimitInfo{
+		Limit:     fwc.limit,
+		Remaining: remaining,
+		Used:      fwc.limit - remaining,
+		ResetAt:   resetAt,
+		Window:    fwc.window,
+	}
+
+	// Calculate retry after if denied
+	if !allowed {
+		retryAfter := resetAt.Sub(now)
+		info.RetryAfter = &retryAfter
+		info.Reason = denyReason(n, fwc.limit+fwc.burst, limiter.ReasonWindowExhausted)
+	}
+
+	return allowed, info, nil
+}
+
+// increment counts n requests in the window from start to end, keeping it that long where
+// the store supports pruning; otherwise a memory store drops windows after a day and Redis
+// after its TTL, which hour, day and month windows can outlast
+func (fwc *FixedWindowCounter) increment(ctx context.Context, key string, start, end time.Time, n int) (int64, error) {
+	if pruner, ok := fwc.store.(limiter.WindowPruner); ok {
+		return pruner.IncrementPruneCtx(ctx, key, start, int64(n), end.Sub(start))
+	}
+	return fwc.store.IncrementByCtx(ctx, key, start, int64(n))
+}
+
+// count returns the requests counted in the window starting at window
+func (fwc *FixedWindowCounter) count(ctx context.Context, key string, window, now time.Time) (int64, error) {
+	windows, err := fwc.store.GetWindowsCtx(ctx, key, window, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get windows: %w", err)
+	}
+
+	var count int64
+	for _, w := range windows {
+		if w.Timestamp.Equal(window) {
+			count = w.Count
+		}
+	}
+	return count, nil
+}
+
+// windowBounds returns the start of the window containing now and the start of the next
+// Calendar windows are computed in UTC, so they never shift with daylight saving time
+func (fwc *FixedWindowCounter) windowBounds(now time.Time) (time.Time, time.Time) {
+	u := now.UTC()
+	switch fwc.alignment {
+	case limiter.AlignHour:
+		start := time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), 0, 0, 0, time.UTC)
+		return start, start.Add(time.Hour)
+	case limiter.AlignDay:
+		start := time.Date(u.Year(), u.Month(), u.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	case limiter.AlignMonth:
+		// From the 1st, AddDate cannot overflow into the month after next
+		start := time.Date(u.Year(), u.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start := now.Truncate(fwc.window)
+		return start, start.Add(fwc.window)
+	}
+}
+
+// keyWindowBounds returns the start of key's current window and when to report it resets
+// Shifted windows start offset later for the key; otherwise only the reported reset moves
+func (fwc *FixedWindowCounter) keyWindowBounds(key string, now time.Time) (time.Time, time.Time) {
+	offset := resetOffset(key, fwc.jitter)
+	if offset == 0 {
+		return fwc.windowBounds(now)
+	}
+	if !fwc.shift {
+		start, end := fwc.windowBounds(now)
+		return start, end.Add(offset)
+	}
+	start, end := fwc.windowBounds(now.Add(-offset))
+	return start.Add(offset), end.Add(offset)
+}
+
+// resetOffset returns key's stable reset offset: a whole number of seconds below spread,
+// spread evenly over keys. Whole seconds keep shifted windows on the second boundaries
+// stores key windows by
+func resetOffset(key string, spread time.Duration) time.Duration {
+	seconds := uint64(spread / time.Second)
+	if seconds == 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return time.Duration(h.Sum64()%seconds) * time.Second
+}
+
+// Config returns the policy this limiter enforces
+func (fwc *FixedWindowCounter) Config() limiter.Config {
+	fwc.mu.RLock()
+	defer fwc.mu.RUnlock()
+
+	return limiter.Config{
+		Algorithm:     "fixed_window",
+		Limit:         fwc.limit,
+		Window:        fwc.window,
+		WindowBurst:   fwc.burst,
+		Alignment:     fwc.alignment,
+		ResetJitter:   fwc.jitter,
+		JitterWindows: fwc.shift,
+	}
+}
+
+// UpdateConfig replaces the policy this counter enforces, keeping counts already in the store
+func (fwc *FixedWindowCounter) UpdateConfig(config limiter.Config) error {
+	if err := validateUpdate(config); err != nil {
+		return err
+	}
+	next := NewFixedWindowCounter(fwc.store, config)
+
+	fwc.mu.Lock()
+	defer fwc.mu.Unlock()
+	fwc.limit = next.limit
+	fwc.burst = next.burst
+	fwc.window = next.window
+	fwc.alignment = next.alignment
+	fwc.jitter = next.jitter
+	fwc.shift = next.shift
+	return nil
+}
+
+// Reset resets the rate limit for a key
+func (fwc *FixedWindowCounter) Reset(key string) error {
+	return fwc.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (fwc *FixedWindowCounter) ResetCtx(ctx context.Context, key string) error {
+	if err := fwc.ready(ctx); err != nil {
+		return err
+	}
+	fwc.mu.Lock()
+	defer fwc.mu.Unlock()
+	return fwc.store.DeleteCtx(ctx, key)
+}
+
+// ResetPrefix resets every key starting with prefix, returning how many had state
+func (fwc *FixedWindowCounter) ResetPrefix(prefix string) (int, error) {
+	return fwc.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (fwc *FixedWindowCounter) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	if err := fwc.ready(ctx); err != nil {
+		return 0, err
+	}
+	fwc.mu.Lock()
+	defer fwc.mu.Unlock()
+	return resetPrefix(ctx, fwc.store, "", prefix)
+}
+
+// Close closes the store; later checks fail with limiter.ErrClosed
+func (fwc *FixedWindowCounter) Close() error {
+	return fwc.closeWith(fwc.store.Close)
+}
@@ -0,0 +1,274 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// gcraKeyPrefix keeps theoretical arrival times apart from token bucket state sharing the same store
+const gcraKeyPrefix = "gcra:"
+
+// GCRA implements the generic cell rate algorithm
+// Each key stores only its theoretical arrival time (TAT): when the next request
+// would be on schedule at the steady rate. Requests may arrive early by up to
+// the burst tolerance; anything earlier is denied
+type GCRA struct {
+	store    limiter.Store
+	clock    limiter.Clock
+	tracer   trace.Tracer
+	burst    int           // Requests that may arrive at once
+	interval time.Duration // Emission interval: time per request at the steady rate
+	limit    int
+	window   time.Duration
+	mu       sync.RWMutex
+	closer
+}
+
+// NewGCRA creates a new GCRA rate limiter
+// The steady rate is Limit per Window; Burst (Limit if unset) requests may arrive back to back
+func NewGCRA(store limiter.Store, config limiter.Config, opts ...Option) *GCRA {
+	burst := config.Burst
+	if burst == 0 {
+		burst = config.Limit
+	}
+
+	o := applyOptions(opts)
+	return &GCRA{
+		store:    store,
+		clock:    o.clock,
+		tracer:   o.tracer,
+		burst:    burst,
+		interval: config.Window / time.Duration(config.Limit),
+		limit:    config.Limit,
+		window:   config.Window,
+	}
+}
+
+// Allow checks if a single request is allowed
+func (g *GCRA) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return g.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (g *GCRA) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return g.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (g *GCRA) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return g.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, honoring ctx
+func (g *GCRA) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := g.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	ctx, span := startAllowSpan(ctx, g.tracer, "gcra", key, n)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Zero requests only read the key's theoretical arrival time
+	return span.end(g.evaluate(ctx, key, n, n > 0))
+}
+
+// AllowNAt checks if N requests are allowed as of at instead of the limiter's clock
+// An arrival time already past at is kept, so earlier requests never free up burst
+func (g *GCRA) AllowNAt(key string, n int, at time.Time) (bool, *limiter.LimitInfo, error) {
+	return g.AllowNCtx(limiter.WithRequestTime(context.Background(), at), key, n)
+}
+
+// Peek reports whether N requests would be allowed without advancing the TAT
+func (g *GCRA) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := g.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.evaluate(ctx, key, n, false)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (g *GCRA) Status(key string) (*limiter.LimitInfo, error) {
+	return g.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (g *GCRA) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := g.Peek(ctx, key, 0)
+	return info, err
+}
+
+// evaluate checks N requests against the TAT, advancing it only when consume is set
+// Callers must hold g.mu
+func (g *GCRA) evaluate(ctx context.Context, key string, n int, consume bool) (bool, *limiter.LimitInfo, error) {
+	if err := validateRequest(key, n); err != nil {
+		return false, nil, err
+	}
+
+	now := requestTime(ctx, g.clock)
+	storeKey := gcraKeyPrefix + key
+
+	tat, err := g.getTAT(ctx, storeKey, now)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// A TAT in the past means the key is idle; idle time does not bank extra burst
+	if tat.Before(now) {
+		tat = now
+	}
+
+	// The furthest ahead of schedule a key may run
+	tolerance := time.Duration(g.burst) * g.interval
+
+	// More than the burst never fits, and is not multiplied out where it could overflow
+	newTAT := tat
+	allowed := n <= g.burst
+	if allowed {
+		newTAT = tat.Add(time.Duration(n) * g.interval)
+		allowed = newTAT.Sub(now) <= tolerance
+	}
+
+	if allowed && consume {
+		tat = newTAT
+
+		// Microseconds since the epoch fit exactly in the float64 token field
+		if err := g.store.SetTokensCtx(ctx, storeKey, float64(tat.UnixMicro()), now); err != nil {
+			return false, nil, fmt.Errorf("failed to update arrival time: %w", err)
+		}
+	}
+
+	remaining := int((tolerance - tat.Sub(now)) / g.interval)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	info := &limiter.LimitInfo{
+		Limit:     g.burst,
+		Remaining: remaining,
+		Used:      g.burst - remaining,
+		ResetAt:   tat, // Full burst is available again once the schedule catches up
+		Window:    g.window,
+	}
+
+	if !allowed {
+		retryAfter := newTAT.Sub(now) - tolerance
+		if n > g.burst {
+			// n can never fit; report the time to recover a full burst
+			retryAfter = tolerance
+		}
+		info.RetryAfter = &retryAfter
+		info.Reason = denyReason(n, g.burst, limiter.ReasonRateExceeded)
+	}
+
+	return allowed, info, nil
+}
+
+// getTAT loads the theoretical arrival time for a key, defaulting to now for new keys
+func (g *GCRA) getTAT(ctx context.Context, storeKey string, now time.Time) (time.Time, error) {
+	micros, _, found, err := g.store.GetTokensCtx(ctx, storeKey)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get arrival time: %w", err)
+	}
+	if !found {
+		return now, nil
+	}
+	return time.UnixMicro(int64(micros)), nil
+}
+
+// Config returns the policy this limiter enforces
+func (g *GCRA) Config() limiter.Config {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return limiter.Config{Algorithm: "gcra", Limit: g.limit, Window: g.window, Burst: g.burst}
+}
+
+// UpdateConfig replaces the policy this limiter enforces, keeping arrival times already in the store
+func (g *GCRA) UpdateConfig(config limiter.Config) error {
+	if err := validateUpdate(config); err != nil {
+		return err
+	}
+	next := NewGCRA(g.store, config)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.burst = next.burst
+	g.interval = next.interval
+	g.limit = next.limit
+	g.window = next.window
+	return nil
+}
+
+// Refund moves key's TAT back by n emission intervals
+// The TAT never moves before now, so a refund cannot bank burst beyond the tolerance
+func (g *GCRA) Refund(ctx context.Context, key string, n int) error {
+	if err := g.ready(ctx); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := requestTime(ctx, g.clock)
+	storeKey := gcraKeyPrefix + key
+
+	tat, err := g.getTAT(ctx, storeKey, now)
+	if err != nil {
+		return err
+	}
+
+	tat = tat.Add(-time.Duration(n) * g.interval)
+	if tat.Before(now) {
+		tat = now
+	}
+
+	if err := g.store.SetTokensCtx(ctx, storeKey, float64(tat.UnixMicro()), now); err != nil {
+		return fmt.Errorf("failed to update arrival time: %w", err)
+	}
+	return nil
+}
+
+// Reset resets the rate limit for a key
+func (g *GCRA) Reset(key string) error {
+	return g.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (g *GCRA) ResetCtx(ctx context.Context, key string) error {
+	if err := g.ready(ctx); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.store.DeleteCtx(ctx, gcraKeyPrefix+key)
+}
+
+// ResetPrefix resets every key starting with prefix, returning how many had state
+func (g *GCRA) ResetPrefix(prefix string) (int, error) {
+	return g.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (g *GCRA) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	if err := g.ready(ctx); err != nil {
+		return 0, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return resetPrefix(ctx, g.store, gcraKeyPrefix, prefix)
+}
+
+// Close closes the store; later checks fail with limiter.ErrClosed
+func (g *GCRA) Close() error {
+	return g.closeWith(g.store.Close)
+}
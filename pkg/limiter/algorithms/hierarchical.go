@@ -0,0 +1,168 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Hierarchical nests a per-key limit under a shared parent limit, e.g. each user
+// at 100/min inside an org capped at 1000/min. A request must fit both levels
+//
+// The child is consumed first, then the parent. If the parent denies, the child's
+// requests are refunded when the child is a limiter.Refunder; otherwise the parent
+// is peeked beforehand so the child is only consumed when the parent has room
+type Hierarchical struct {
+	parent    limiter.RateLimiter
+	child     limiter.RateLimiter
+	parentKey func(key string) string
+	closer
+}
+
+// NewHierarchical nests child under parent; parentKeyFn maps a child key to its parent's key
+func NewHierarchical(parent, child limiter.RateLimiter, parentKeyFn func(key string) string) *Hierarchical {
+	return &Hierarchical{
+		parent:    parent,
+		child:     child,
+		parentKey: parentKeyFn,
+	}
+}
+
+// Allow checks if a single request is allowed
+func (h *Hierarchical) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return h.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed at both levels
+func (h *Hierarchical) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return h.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (h *Hierarchical) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return h.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed at both levels, honoring ctx
+// A denial reports the level that denied
+func (h *Hierarchical) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := h.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	parentKey := h.parentKey(key)
+	refunder, canRefund := h.child.(limiter.Refunder)
+
+	// Without a refund path, avoid consuming the child when the parent is already full
+	if peeker, ok := h.parent.(limiter.Peeker); ok && !canRefund {
+		allowed, info, err := peeker.Peek(ctx, parentKey, n)
+		if err != nil {
+			return false, nil, err
+		}
+		if !allowed {
+			return false, info, nil
+		}
+	}
+
+	allowed, childInfo, err := h.child.AllowNCtx(ctx, key, n)
+	if err != nil || !allowed {
+		return allowed, childInfo, err
+	}
+
+	allowed, parentInfo, err := h.parent.AllowNCtx(ctx, parentKey, n)
+	if err != nil || !allowed {
+		if canRefund {
+			if refundErr := refunder.Refund(ctx, key, n); refundErr != nil {
+				return false, nil, fmt.Errorf("failed to refund child after parent denial: %w", refundErr)
+			}
+		}
+		return allowed, parentInfo, err
+	}
+
+	return true, tighter(childInfo, parentInfo), nil
+}
+
+// Peek reports whether N requests would be allowed at both levels without consuming anything
+// Returns an error if either level cannot peek
+func (h *Hierarchical) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	childPeeker, ok := h.child.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("child limiter does not support peek")
+	}
+	parentPeeker, ok := h.parent.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("parent limiter does not support peek")
+	}
+
+	allowed, childInfo, err := childPeeker.Peek(ctx, key, n)
+	if err != nil || !allowed {
+		return allowed, childInfo, err
+	}
+
+	allowed, parentInfo, err := parentPeeker.Peek(ctx, h.parentKey(key), n)
+	if err != nil || !allowed {
+		return allowed, parentInfo, err
+	}
+
+	return true, tighter(childInfo, parentInfo), nil
+}
+
+// Status reports the current limit state for key without consuming anything
+func (h *Hierarchical) Status(key string) (*limiter.LimitInfo, error) {
+	return h.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (h *Hierarchical) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := h.Peek(ctx, key, 0)
+	return info, err
+}
+
+// tighter returns whichever level has fewer requests remaining
+func tighter(a, b *limiter.LimitInfo) *limiter.LimitInfo {
+	if b.Remaining < a.Remaining {
+		return b
+	}
+	return a
+}
+
+// Config returns the child's policy, or a zero Config if it cannot describe itself
+func (h *Hierarchical) Config() limiter.Config {
+	if d, ok := h.child.(limiter.Describer); ok {
+		return d.Config()
+	}
+	return limiter.Config{}
+}
+
+// Refund returns n requests to the key at both levels
+// Both levels must be limiter.Refunders, or nothing is returned
+func (h *Hierarchical) Refund(ctx context.Context, key string, n int) error {
+	child, ok := h.child.(limiter.Refunder)
+	if !ok {
+		return fmt.Errorf("child limiter cannot refund")
+	}
+	parent, ok := h.parent.(limiter.Refunder)
+	if !ok {
+		return fmt.Errorf("parent limiter cannot refund")
+	}
+	if err := child.Refund(ctx, key, n); err != nil {
+		return err
+	}
+	return parent.Refund(ctx, h.parentKey(key), n)
+}
+
+// Reset resets the child limit for a key
+// The parent is shared with the key's siblings and is left alone
+func (h *Hierarchical) Reset(key string) error {
+	return h.child.Reset(key)
+}
+
+// ResetCtx resets the child limit for a key, honoring ctx
+func (h *Hierarchical) ResetCtx(ctx context.Context, key string) error {
+	return h.child.ResetCtx(ctx, key)
+}
+
+// Close closes the parent and child limiters
+func (h *Hierarchical) Close() error {
+	return h.closeWith(func() error { return closeAll(h.parent, h.child) })
+}
@@ -0,0 +1,271 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// leakyKeyPrefix keeps water levels apart from token bucket state sharing the same store
+const leakyKeyPrefix = "leaky:"
+
+// LeakyBucket implements the leaky bucket rate limiting algorithm
+// Each request adds water to a bucket that drains at a constant rate
+// Requests are denied once the bucket would overflow, giving strictly smoothed output
+type LeakyBucket struct {
+	store     limiter.Store
+	clock     limiter.Clock
+	tracer    trace.Tracer
+	capacity  int     // Maximum water the bucket holds
+	drainRate float64 // Water drained per second
+	limit     int
+	window    time.Duration
+	mu        sync.RWMutex
+	closer
+}
+
+// NewLeakyBucket creates a new leaky bucket rate limiter
+// The bucket holds Burst requests (Limit if unset) and drains at Limit/Window per second
+func NewLeakyBucket(store limiter.Store, config limiter.Config, opts ...Option) *LeakyBucket {
+	capacity := config.Burst
+	if capacity == 0 {
+		capacity = config.Limit
+	}
+
+	o := applyOptions(opts)
+	return &LeakyBucket{
+		store:     store,
+		clock:     o.clock,
+		tracer:    o.tracer,
+		capacity:  capacity,
+		drainRate: float64(config.Limit) / config.Window.Seconds(),
+		limit:     config.Limit,
+		window:    config.Window,
+	}
+}
+
+// Allow checks if a single request is allowed
+func (lb *LeakyBucket) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return lb.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (lb *LeakyBucket) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return lb.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (lb *LeakyBucket) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return lb.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, honoring ctx
+func (lb *LeakyBucket) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := lb.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	ctx, span := startAllowSpan(ctx, lb.tracer, "leaky_bucket", key, n)
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	// Zero requests only read the level, without storing the drain
+	return span.end(lb.evaluate(ctx, key, n, n > 0))
+}
+
+// AllowNAt checks if N requests are allowed as of at instead of the limiter's clock,
+// draining up to at. A request older than the last drain is checked against the
+// stored level without rewinding it
+func (lb *LeakyBucket) AllowNAt(key string, n int, at time.Time) (bool, *limiter.LimitInfo, error) {
+	return lb.AllowNCtx(limiter.WithRequestTime(context.Background(), at), key, n)
+}
+
+// Peek reports whether N requests would be allowed without adding any water
+func (lb *LeakyBucket) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := lb.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	return lb.evaluate(ctx, key, n, false)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (lb *LeakyBucket) Status(key string) (*limiter.LimitInfo, error) {
+	return lb.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (lb *LeakyBucket) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := lb.Peek(ctx, key, 0)
+	return info, err
+}
+
+// evaluate checks N requests against the free space in the bucket, filling it only when consume is set
+// Callers must hold lb.mu
+func (lb *LeakyBucket) evaluate(ctx context.Context, key string, n int, consume bool) (bool, *limiter.LimitInfo, error) {
+	if err := validateRequest(key, n); err != nil {
+		return false, nil, err
+	}
+
+	now := requestTime(ctx, lb.clock)
+	storeKey := leakyKeyPrefix + key
+
+	// Water level and last drain time share the token bucket storage
+	level, lastDrain, found, err := lb.store.GetTokensCtx(ctx, storeKey)
+	if err != nil {
+		// Unreadable state must not pass for an empty bucket
+		return false, nil, fmt.Errorf("failed to get water level: %w", err)
+	}
+	if !found {
+		// First request - start with an empty bucket
+		level = 0
+		lastDrain = now
+	}
+
+	// Drain for the time elapsed since the last update
+	level, lastDrain = lb.drain(level, lastDrain, now)
+
+	allowed := level+float64(n) <= float64(lb.capacity)
+	if allowed && consume {
+		level += float64(n)
+	}
+
+	if consume {
+		if err := lb.store.SetTokensCtx(ctx, storeKey, level, lastDrain); err != nil {
+			return false, nil, fmt.Errorf("failed to update water level: %w", err)
+		}
+	}
+
+	remaining := int(float64(lb.capacity) - level)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	info := &limiter.LimitInfo{
+		Limit:     lb.capacity,
+		Remaining: remaining,
+		Used:      lb.capacity - remaining,
+		ResetAt:   now.Add(lb.drainDuration(level)), // When the bucket is empty again
+		Window:    lb.window,
+	}
+
+	// If denied, wait until enough water has drained for the pending request
+	if !allowed {
+		overflow := level + float64(n) - float64(lb.capacity)
+		if n > lb.capacity {
+			// n can never fit; report the time to drain a full bucket
+			overflow = float64(lb.capacity)
+		}
+		retryAfter := lb.drainDuration(overflow)
+		info.RetryAfter = &retryAfter
+		info.Reason = denyReason(n, lb.capacity, limiter.ReasonBucketFull)
+	}
+
+	return allowed, info, nil
+}
+
+// drain lowers level by the water drained between lastDrain and now, returning the drain
+// time to store. A request dated before the last drain leaves both as they were
+func (lb *LeakyBucket) drain(level float64, lastDrain, now time.Time) (float64, time.Time) {
+	if !now.After(lastDrain) {
+		return level, lastDrain
+	}
+	return max(level-now.Sub(lastDrain).Seconds()*lb.drainRate, 0), now
+}
+
+// drainDuration returns how long the bucket takes to drain the given amount of water
+func (lb *LeakyBucket) drainDuration(water float64) time.Duration {
+	return time.Duration(water / lb.drainRate * float64(time.Second))
+}
+
+// Config returns the policy this limiter enforces
+func (lb *LeakyBucket) Config() limiter.Config {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	return limiter.Config{Algorithm: "leaky_bucket", Limit: lb.limit, Window: lb.window, Burst: lb.capacity}
+}
+
+// UpdateConfig replaces the policy this bucket enforces, keeping levels already in the store
+func (lb *LeakyBucket) UpdateConfig(config limiter.Config) error {
+	if err := validateUpdate(config); err != nil {
+		return err
+	}
+	next := NewLeakyBucket(lb.store, config)
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.capacity = next.capacity
+	lb.drainRate = next.drainRate
+	lb.limit = next.limit
+	lb.window = next.window
+	return nil
+}
+
+// Refund drains n requests' worth of water from key's bucket
+func (lb *LeakyBucket) Refund(ctx context.Context, key string, n int) error {
+	if err := lb.ready(ctx); err != nil {
+		return err
+	}
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	now := requestTime(ctx, lb.clock)
+	storeKey := leakyKeyPrefix + key
+
+	level, lastDrain, _, err := lb.store.GetTokensCtx(ctx, storeKey)
+	if err != nil {
+		return fmt.Errorf("failed to get water level: %w", err)
+	}
+
+	level, lastDrain = lb.drain(level, lastDrain, now)
+	level = max(level-float64(n), 0)
+
+	if err := lb.store.SetTokensCtx(ctx, storeKey, level, lastDrain); err != nil {
+		return fmt.Errorf("failed to update water level: %w", err)
+	}
+	return nil
+}
+
+// Reset resets the rate limit for a key
+func (lb *LeakyBucket) Reset(key string) error {
+	return lb.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (lb *LeakyBucket) ResetCtx(ctx context.Context, key string) error {
+	if err := lb.ready(ctx); err != nil {
+		return err
+	}
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.store.DeleteCtx(ctx, leakyKeyPrefix+key)
+}
+
+// ResetPrefix resets every key starting with prefix, returning how many had state
+func (lb *LeakyBucket) ResetPrefix(prefix string) (int, error) {
+	return lb.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (lb *LeakyBucket) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	if err := lb.ready(ctx); err != nil {
+		return 0, err
+	}
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return resetPrefix(ctx, lb.store, leakyKeyPrefix, prefix)
+}
+
+// Close closes the store; later checks fail with limiter.ErrClosed
+func (lb *LeakyBucket) Close() error {
+	return lb.closeWith(lb.store.Close)
+}
@@ -0,0 +1,298 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// minLeaseSweep is how many leased keys are held before expired leases are swept
+const minLeaseSweep = 1024
+
+// LeaseMetrics counts leased checks by whether they were served from a local lease
+type LeaseMetrics interface {
+	RecordLease(hit bool)
+}
+
+// WithLeaseMetrics makes a LeasedLimiter count checks served locally (hits) and checks
+// that had to lease from the store (misses)
+func WithLeaseMetrics(m LeaseMetrics) Option {
+	return func(o *options) {
+		o.leaseMetrics = m
+	}
+}
+
+// LeasedLimiter serves a token bucket's checks from batches of tokens leased from the
+// shared store, so an instance under heavy traffic makes one store round trip per lease
+// instead of one per check
+//
+// A lease atomically takes leaseSize tokens from the key's bucket, or just what the check
+// needs when fewer are left, and hands them out in-process until it drains. Instances can
+// never together admit more than the bucket grants, but leased tokens are spent later than
+// they were taken: around a lease boundary each instance can admit up to leaseSize more
+// than the bucket would at that moment, and tokens leased by an idle instance are unavailable
+// to the others until its lease expires. A lease expires once the bucket would have refilled
+// it, which bounds how stale its tokens get
+type LeasedLimiter struct {
+	base      limiter.RateLimiter
+	store     limiter.Store
+	leaseSize int
+	clock     limiter.Clock
+	metrics   LeaseMetrics
+	leases    map[string]*lease
+	sweepAt   int
+	mu        sync.Mutex
+	closer
+}
+
+// lease is the tokens an instance holds for a key
+type lease struct {
+	tokens    int       // Leased tokens not yet handed out
+	remaining int       // Tokens left in the shared bucket when the lease was taken
+	expiresAt time.Time // When unused tokens are abandoned
+	mu        sync.Mutex
+}
+
+// NewLeasedLimiter serves base's checks from leases of leaseSize tokens taken from store
+// base must be a float precision token bucket keeping its state in store; status, peeks,
+// resets and reconfiguration go to it
+func NewLeasedLimiter(base limiter.RateLimiter, store limiter.Store, leaseSize int, opts ...Option) (*LeasedLimiter, error) {
+	if leaseSize <= 0 {
+		return nil, fmt.Errorf("lease size must be positive, got %d", leaseSize)
+	}
+	d, ok := base.(limiter.Describer)
+	if !ok {
+		return nil, fmt.Errorf("leased limiter requires a token bucket, got a limiter that cannot describe itself")
+	}
+	if config := d.Config(); config.Algorithm != "token_bucket" || config.Precision == limiter.PrecisionFixed {
+		return nil, fmt.Errorf("leased limiter requires a float precision token bucket, got %s", config.Algorithm)
+	}
+
+	o := applyOptions(opts)
+	return &LeasedLimiter{
+		base:      base,
+		store:     store,
+		leaseSize: leaseSize,
+		clock:     o.clock,
+		metrics:   o.leaseMetrics,
+		leases:    make(map[string]*lease),
+		sweepAt:   minLeaseSweep,
+	}, nil
+}
+
+// Allow checks if a single request is allowed
+func (ll *LeasedLimiter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return ll.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (ll *LeasedLimiter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return ll.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (ll *LeasedLimiter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return ll.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, from the key's lease if it holds enough
+// tokens and otherwise by leasing more from the store, honoring ctx
+func (ll *LeasedLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := ll.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	if err := validateRequest(key, n); err != nil {
+		return false, nil, err
+	}
+
+	config := ll.Config()
+	capacity := config.Burst
+	if capacity == 0 {
+		capacity = config.Limit
+	}
+	refillRate := float64(config.Limit) / config.Window.Seconds()
+	now := requestTime(ctx, ll.clock)
+
+	l := ll.lease(key)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !now.Before(l.expiresAt) {
+		l.tokens = 0
+	}
+	if l.tokens >= n {
+		l.tokens -= n
+		ll.record(true)
+		return true, ll.limitInfo(l, capacity, refillRate, config.Window, now), nil
+	}
+	ll.record(false)
+
+	// Lease a full batch, or failing that just what this check still needs
+	need := n - l.tokens
+	initial := float64(capacity)
+	if config.InitialFill != nil {
+		initial = *config.InitialFill * float64(capacity)
+	}
+	size := max(need, min(ll.leaseSize, capacity))
+	allowed, tokens, retryAfter, err := ll.store.ConsumeTokensCtx(ctx, key, size, capacity, refillRate, initial, now)
+	if err == nil && !allowed && size > need && tokens >= float64(need) {
+		size = need
+		allowed, tokens, retryAfter, err = ll.store.ConsumeTokensCtx(ctx, key, size, capacity, refillRate, initial, now)
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to lease tokens: %w", err)
+	}
+
+	l.remaining = max(int(tokens), 0)
+	if !allowed {
+		info := ll.limitInfo(l, capacity, refillRate, config.Window, now)
+		info.RetryAfter = &retryAfter
+		info.Reason = denyReason(n, capacity, limiter.ReasonInsufficientTokens)
+		return false, info, nil
+	}
+
+	l.tokens += size - n
+	l.expiresAt = now.Add(leaseTTL(size, refillRate))
+	return true, ll.limitInfo(l, capacity, refillRate, config.Window, now), nil
+}
+
+// leaseTTL is how long the bucket takes to refill a lease of size tokens, after which the
+// lease's unused tokens are abandoned
+func leaseTTL(size int, refillRate float64) time.Duration {
+	seconds := float64(size) / refillRate
+	if refillRate <= 0 || seconds >= maxRefillWait.Seconds() {
+		return maxRefillWait
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// lease returns key's lease, creating it if the key holds none
+func (ll *LeasedLimiter) lease(key string) *lease {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	l, ok := ll.leases[key]
+	if !ok {
+		ll.sweep()
+		l = &lease{}
+		ll.leases[key] = l
+	}
+	return l
+}
+
+// sweep drops expired leases once enough keys are held, so keys that stop being checked
+// do not stay in memory. Callers must hold ll.mu
+func (ll *LeasedLimiter) sweep() {
+	if len(ll.leases) < ll.sweepAt {
+		return
+	}
+	now := ll.clock.Now()
+	for key, l := range ll.leases {
+		if l.mu.TryLock() {
+			if !now.Before(l.expiresAt) {
+				delete(ll.leases, key)
+			}
+			l.mu.Unlock()
+		}
+	}
+	ll.sweepAt = max(2*len(ll.leases), minLeaseSweep)
+}
+
+// limitInfo reports the key's state as seen by this instance: the shared bucket as of
+// its last lease, plus the leased tokens not yet handed out
+func (ll *LeasedLimiter) limitInfo(l *lease, capacity int, refillRate float64, window time.Duration, now time.Time) *limiter.LimitInfo {
+	remaining := min(l.remaining+l.tokens, capacity)
+	return &limiter.LimitInfo{
+		Limit:     capacity,
+		Remaining: remaining,
+		Used:      capacity - remaining,
+		ResetAt:   now.Add(refillWait(float64(capacity-remaining), refillRate)),
+		Window:    window,
+	}
+}
+
+// record counts a check served from a lease (hit) or by leasing from the store (miss)
+func (ll *LeasedLimiter) record(hit bool) {
+	if ll.metrics != nil {
+		ll.metrics.RecordLease(hit)
+	}
+}
+
+// Peek reports whether N requests would be allowed by the shared bucket
+// Tokens leased out, by this instance or others, count as taken
+func (ll *LeasedLimiter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	peeker, ok := ll.base.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("wrapped limiter does not support peek")
+	}
+	return peeker.Peek(ctx, key, n)
+}
+
+// Status reports the shared bucket's state for key without consuming anything
+func (ll *LeasedLimiter) Status(key string) (*limiter.LimitInfo, error) {
+	return ll.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (ll *LeasedLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	if err := ll.ready(ctx); err != nil {
+		return nil, err
+	}
+	return ll.base.StatusCtx(ctx, key)
+}
+
+// Config returns the wrapped bucket's policy
+func (ll *LeasedLimiter) Config() limiter.Config {
+	return ll.base.(limiter.Describer).Config()
+}
+
+// UpdateConfig updates the wrapped bucket's policy; leases already taken are kept
+// Returns an error if the wrapped limiter can not be reconfigured
+func (ll *LeasedLimiter) UpdateConfig(config limiter.Config) error {
+	reconfigurer, ok := ll.base.(limiter.Reconfigurer)
+	if !ok {
+		return fmt.Errorf("wrapped limiter does not support reconfiguration")
+	}
+	return reconfigurer.UpdateConfig(config)
+}
+
+// Reset resets the rate limit for a key, dropping this instance's lease
+// Leases other instances hold are spent as usual
+func (ll *LeasedLimiter) Reset(key string) error {
+	return ll.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (ll *LeasedLimiter) ResetCtx(ctx context.Context, key string) error {
+	ll.mu.Lock()
+	delete(ll.leases, key)
+	ll.mu.Unlock()
+	return ll.base.ResetCtx(ctx, key)
+}
+
+// ResetPrefix resets every key starting with prefix, dropping this instance's leases for
+// them, and returns how many had state
+func (ll *LeasedLimiter) ResetPrefix(prefix string) (int, error) {
+	return ll.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (ll *LeasedLimiter) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	ll.mu.Lock()
+	for key := range ll.leases {
+		if strings.HasPrefix(key, prefix) {
+			delete(ll.leases, key)
+		}
+	}
+	ll.mu.Unlock()
+	return resetBasePrefix(ctx, ll.base, prefix)
+}
+
+// Close closes the wrapped limiter; unused leased tokens are abandoned
+func (ll *LeasedLimiter) Close() error {
+	return ll.closeWith(ll.base.Close)
+}
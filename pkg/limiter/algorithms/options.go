@@ -0,0 +1,135 @@
+package algorithms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures optional limiter behavior shared by the algorithm constructors
+type Option func(*options)
+
+type options struct {
+	clock         limiter.Clock
+	maxWait       time.Duration
+	tracer        trace.Tracer
+	initialTokens *int
+	leaseMetrics  LeaseMetrics
+}
+
+// WithClock makes the limiter read time from clock instead of the wall clock
+func WithClock(clock limiter.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithMaxWait bounds how long limiters that can block, such as the token bucket, wait for
+// requests to be allowed; longer waits fail at once with *ErrMaxWaitExceeded (0 = unbounded)
+func WithMaxWait(d time.Duration) Option {
+	return func(o *options) {
+		o.maxWait = d
+	}
+}
+
+// WithTracer traces each AllowN call as a limiter.AllowN span, with a child span for each
+// store call it makes (nil = no tracing)
+func WithTracer(tracer trace.Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// WithInitialTokens starts token bucket keys seen for the first time with n tokens, clamped
+// to the bucket's capacity, instead of full. It takes precedence over Config.InitialTokens
+// and Config.InitialFill
+// and is written with the key's first step, so concurrent first requests share one start
+func WithInitialTokens(n int) Option {
+	return func(o *options) {
+		o.initialTokens = &n
+	}
+}
+
+// WithStartEmpty starts token bucket keys seen for the first time with no tokens, so they
+// earn their requests at the refill rate; for endpoints where fresh keys signal abuse
+func WithStartEmpty() Option {
+	return WithInitialTokens(0)
+}
+
+// applyOptions resolves opts over the defaults
+func applyOptions(opts []Option) options {
+	o := options{clock: limiter.SystemClock{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// requestTime is the time a check made under ctx is evaluated at: the time set with
+// limiter.WithRequestTime, or else clock's. Both are in UTC, since stores key windows by
+// time.Time and would otherwise keep apart the same instant in different zones
+func requestTime(ctx context.Context, clock limiter.Clock) time.Time {
+	if at, ok := limiter.RequestTime(ctx); ok {
+		return at.UTC()
+	}
+	return clock.Now().UTC()
+}
+
+// validateRequest checks a request for n against key
+// A request larger than the limit is not invalid here: checks deny it like any other
+func validateRequest(key string, n int) error {
+	if n < 0 || n > limiter.MaxN {
+		return fmt.Errorf("%w: %d", limiter.ErrInvalidN, n)
+	}
+	if len(key) > limiter.MaxKeyLength {
+		return fmt.Errorf("%w: %d bytes, max %d", limiter.ErrKeyTooLong, len(key), limiter.MaxKeyLength)
+	}
+	return nil
+}
+
+// resetPrefix deletes from s the state of every key starting with prefix, which the
+// limiter stores under keyPrefix
+func resetPrefix(ctx context.Context, s limiter.Store, keyPrefix, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, limiter.ErrEmptyPrefix
+	}
+	deleter, ok := s.(limiter.PrefixDeleter)
+	if !ok {
+		return 0, fmt.Errorf("store does not support prefix resets: %w", errors.ErrUnsupported)
+	}
+	return deleter.DeletePrefixCtx(ctx, keyPrefix+prefix)
+}
+
+// resetBasePrefix resets every key starting with prefix in a wrapped limiter
+func resetBasePrefix(ctx context.Context, base limiter.RateLimiter, prefix string) (int, error) {
+	resetter, ok := base.(limiter.PrefixResetter)
+	if !ok {
+		return 0, fmt.Errorf("wrapped limiter does not support prefix resets: %w", errors.ErrUnsupported)
+	}
+	return resetter.ResetPrefixCtx(ctx, prefix)
+}
+
+// denyReason returns reason for a denied request of n, or ReasonBurstExceeded when n is
+// more than capacity and so could never be admitted
+func denyReason(n, capacity int, reason string) string {
+	if n > capacity {
+		return limiter.ReasonBurstExceeded
+	}
+	return reason
+}
+
+// validateUpdate checks a config a running limiter is asked to switch to
+// Constructors trust their callers to validate; updates arrive at runtime, so check the basics
+func validateUpdate(config limiter.Config) error {
+	if config.Limit <= 0 {
+		return fmt.Errorf("limit must be positive, got %d", config.Limit)
+	}
+	if config.Window <= 0 {
+		return fmt.Errorf("window must be positive, got %s", config.Window)
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Overrides holds per-key limits set at runtime, tighter for abusive clients or looser for
+// partners. One set is shared by every OverrideLimiter, so an override applies whichever
+// algorithm or tier checks the key
+type Overrides struct {
+	store limiter.LimitOverrideStore // Where overrides are kept; read before every check
+	clock limiter.Clock
+}
+
+// NewOverrides creates an empty override set held in memory on this instance
+func NewOverrides(opts ...Option) *Overrides {
+	return NewStoreOverrides(&localOverrides{limits: make(map[string]limiter.LimitOverride)}, opts...)
+}
+
+// NewStoreOverrides creates an override set kept in s, shared by every instance using s and,
+// with Redis, surviving restarts
+func NewStoreOverrides(s limiter.LimitOverrideStore, opts ...Option) *Overrides {
+	o := applyOptions(opts)
+	return &Overrides{store: s, clock: o.clock}
+}
+
+// SetKeyLimit makes key use config's limit, window and burst instead of the configured policy
+// Other settings, such as token bucket precision, still come from the policy
+func (o *Overrides) SetKeyLimit(key string, config limiter.Config) error {
+	return o.SetKeyLimitCtx(context.Background(), key, config, 0)
+}
+
+// SetKeyLimitCtx is SetKeyLimit with a context; the override lapses after ttl (0 = never)
+func (o *Overrides) SetKeyLimitCtx(ctx context.Context, key string, config limiter.Config, ttl time.Duration) error {
+	if key == "" {
+		return fmt.Errorf("override needs a key")
+	}
+	if err := validateUpdate(config); err != nil {
+		return err
+	}
+	if config.Burst < 0 {
+		return fmt.Errorf("burst must not be negative, got %d", config.Burst)
+	}
+	if ttl < 0 {
+		return fmt.Errorf("override ttl must not be negative, got %s", ttl)
+	}
+
+	now := o.clock.Now()
+	override := limiter.LimitOverride{Limit: config.Limit, Window: config.Window, Burst: config.Burst}
+	if ttl > 0 {
+		override.ExpiresAt = now.Add(ttl)
+	}
+	return o.store.SetLimitOverrideCtx(ctx, key, override, now)
+}
+
+// ClearKeyLimit returns key to the configured policy, reporting whether it had an override
+func (o *Overrides) ClearKeyLimit(key string) (bool, error) {
+	return o.ClearKeyLimitCtx(context.Background(), key)
+}
+
+// ClearKeyLimitCtx is ClearKeyLimit with a context
+func (o *Overrides) ClearKeyLimitCtx(ctx context.Context, key string) (bool, error) {
+	return o.store.DeleteLimitOverrideCtx(ctx, key, o.clock.Now())
+}
+
+// KeyLimit returns key's override, if it has one
+func (o *Overrides) KeyLimit(key string) (limiter.LimitOverride, bool, error) {
+	return o.KeyLimitCtx(context.Background(), key)
+}
+
+// KeyLimitCtx is KeyLimit with a context
+func (o *Overrides) KeyLimitCtx(ctx context.Context, key string) (limiter.LimitOverride, bool, error) {
+	return o.store.LimitOverrideCtx(ctx, key, o.clock.Now())
+}
+
+// localOverrides keeps overrides in memory for NewOverrides, for stores that cannot keep them
+type localOverrides struct {
+	limits map[string]limiter.LimitOverride
+	mu     sync.RWMutex
+}
+
+func (lo *localOverrides) SetLimitOverrideCtx(ctx context.Context, key string, override limiter.LimitOverride, now time.Time) error {
+	lo.mu.Lock()
+	defer lo.mu.Unlock()
+	lo.limits[key] = override
+	return nil
+}
+
+func (lo *localOverrides) LimitOverrideCtx(ctx context.Context, key string, now time.Time) (limiter.LimitOverride, bool, error) {
+	lo.mu.RLock()
+	defer lo.mu.RUnlock()
+	override, ok := lo.limits[key]
+	if !ok || (!override.ExpiresAt.IsZero() && !now.Before(override.ExpiresAt)) {
+		return limiter.LimitOverride{}, false, nil
+	}
+	return override, true, nil
+}
+
+func (lo *localOverrides) DeleteLimitOverrideCtx(ctx context.Context, key string, now time.Time) (bool, error) {
+	_, ok, _ := lo.LimitOverrideCtx(ctx, key, now)
+	lo.mu.Lock()
+	defer lo.mu.Unlock()
+	delete(lo.limits, key)
+	return ok, nil
+}
+
+// OverrideLimiter checks keys with an override against a limiter built for that override,
+// and every other key against the wrapped limiter
+// Overridden limiters share the wrapped limiter's store and keys, so a key's usage carries
+// over when an override is set or cleared. A multi-window policy is replaced by the single
+// overriding window
+type OverrideLimiter struct {
+	base      limiter.RateLimiter
+	overrides *Overrides
+	build     func(limiter.Config) (limiter.RateLimiter, error)
+
+	built map[string]overridden // key -> limiter enforcing its override
+	mu    sync.Mutex
+	closer
+}
+
+// overridden is a limiter built for one key and the config it was built from
+type overridden struct {
+	config  limiter.Config
+	limiter limiter.RateLimiter
+}
+
+// NewOverrideLimiter wraps base so keys in overrides are limited by their own policy
+// build creates the limiter for an override from base's config with the override's limit,
+// window and burst; base must be able to describe its config
+func NewOverrideLimiter(base limiter.RateLimiter, overrides *Overrides, build func(limiter.Config) (limiter.RateLimiter, error)) (*OverrideLimiter, error) {
+	if _, ok := base.(limiter.Describer); !ok {
+		return nil, fmt.Errorf("overridden limiter must describe its config")
+	}
+	return &OverrideLimiter{
+		base:      base,
+		overrides: overrides,
+		build:     build,
+		built:     make(map[string]overridden),
+	}, nil
+}
+
+// limiterFor returns the limiter enforcing key's policy
+// Override limiters are built on first use and rebuilt when the override or the wrapped
+// limiter's config changes
+func (ol *OverrideLimiter) limiterFor(ctx context.Context, key string) (limiter.RateLimiter, error) {
+	override, ok, err := ol.overrides.KeyLimitCtx(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+
+	if !ok {
+		delete(ol.built, key)
+		return ol.base, nil
+	}
+
+	config := ol.base.(limiter.Describer).Config()
+	config.Limit, config.Window, config.Burst = override.Limit, override.Window, override.Burst
+
+	if o, ok := ol.built[key]; ok && o.config == config {
+		return o.limiter, nil
+	}
+	l, err := ol.build(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build override for %q: %w", key, err)
+	}
+	ol.built[key] = overridden{config: config, limiter: l}
+	return l, nil
+}
+
+// Allow checks if a single request is allowed
+func (ol *OverrideLimiter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return ol.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed under key's policy
+func (ol *OverrideLimiter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return ol.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (ol *OverrideLimiter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return ol.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed under key's policy, honoring ctx
+func (ol *OverrideLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := ol.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	l, err := ol.limiterFor(ctx, key)
+	if err != nil {
+		return false, nil, err
+	}
+	return l.AllowNCtx(ctx, key, n)
+}
+
+// Peek reports whether N requests would be allowed under key's policy
+// Returns an error if the limiter for key cannot peek
+func (ol *OverrideLimiter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	l, err := ol.limiterFor(ctx, key)
+	if err != nil {
+		return false, nil, err
+	}
+	peeker, ok := l.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("wrapped limiter does not support peek")
+	}
+	return peeker.Peek(ctx, key, n)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (ol *OverrideLimiter) Status(key string) (*limiter.LimitInfo, error) {
+	return ol.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (ol *OverrideLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := ol.Peek(ctx, key, 0)
+	return info, err
+}
+
+// Refund returns n requests to key under its policy
+// Returns an error if the limiter for key cannot refund
+func (ol *OverrideLimiter) Refund(ctx context.Context, key string, n int) error {
+	l, err := ol.limiterFor(ctx, key)
+	if err != nil {
+		return err
+	}
+	refunder, ok := l.(limiter.Refunder)
+	if !ok {
+		return fmt.Errorf("wrapped limiter does not support refunds")
+	}
+	return refunder.Refund(ctx, key, n)
+}
+
+// SetLimit makes key use config's limit, window and burst, for every limiter sharing the overrides
+func (ol *OverrideLimiter) SetLimit(key string, config limiter.Config) error {
+	return ol.SetLimitCtx(context.Background(), key, config, 0)
+}
+
+// SetLimitCtx is SetLimit with a context; the override lapses after ttl (0 = never)
+func (ol *OverrideLimiter) SetLimitCtx(ctx context.Context, key string, config limiter.Config, ttl time.Duration) error {
+	return ol.overrides.SetKeyLimitCtx(ctx, key, config, ttl)
+}
+
+// ClearLimit returns key to the configured policy
+func (ol *OverrideLimiter) ClearLimit(key string) error {
+	return ol.ClearLimitCtx(context.Background(), key)
+}
+
+// ClearLimitCtx is ClearLimit with a context
+func (ol *OverrideLimiter) ClearLimitCtx(ctx context.Context, key string) error {
+	_, err := ol.overrides.ClearKeyLimitCtx(ctx, key)
+	return err
+}
+
+// Config returns the wrapped limiter's policy, which keys without an override follow
+func (ol *OverrideLimiter) Config() limiter.Config {
+	return ol.base.(limiter.Describer).Config()
+}
+
+// UpdateConfig updates the wrapped limiter's policy
+// Overridden keys keep their limit, window and burst and pick up the rest on their next check
+func (ol *OverrideLimiter) UpdateConfig(config limiter.Config) error {
+	reconfigurer, ok := ol.base.(limiter.Reconfigurer)
+	if !ok {
+		return fmt.Errorf("wrapped limiter does not support reconfiguration")
+	}
+	return reconfigurer.UpdateConfig(config)
+}
+
+// Reset resets the rate limit for a key
+func (ol *OverrideLimiter) Reset(key string) error {
+	return ol.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key under its policy, honoring ctx
+func (ol *OverrideLimiter) ResetCtx(ctx context.Context, key string) error {
+	l, err := ol.limiterFor(ctx, key)
+	if err != nil {
+		return err
+	}
+	return l.ResetCtx(ctx, key)
+}
+
+// Close closes the wrapped limiter and the limiters built for overridden keys
+func (ol *OverrideLimiter) Close() error {
+	return ol.closeWith(func() error {
+		ol.mu.Lock()
+		defer ol.mu.Unlock()
+		limiters := []limiter.RateLimiter{ol.base}
+		for key, o := range ol.built {
+			limiters = append(limiters, o.limiter)
+			delete(ol.built, key)
+		}
+		return closeAll(limiters...)
+	})
+}
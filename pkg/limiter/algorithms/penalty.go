@@ -0,0 +1,270 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// penaltyKeyPrefix namespaces offender state, which is shared by every algorithm checking a key
+const penaltyKeyPrefix = "offender:"
+
+// maxPenaltyLevel bounds PenaltyConfig.MaxLevel, a 1024x multiplier
+const maxPenaltyLevel = 10
+
+// PenaltyConfig configures escalating penalties for keys that keep retrying while denied
+type PenaltyConfig struct {
+	Threshold int           // Denials allowed within Window before the key's level rises
+	Window    time.Duration // Span denials are counted over
+	MaxLevel  int           // Highest level; level L multiplies retry times by 2^L
+	Decay     time.Duration // Quiet time after a penalty ends before the level drops back to zero
+}
+
+// Validate checks the config
+func (c PenaltyConfig) Validate() error {
+	if c.Threshold < 0 {
+		return fmt.Errorf("penalty threshold must not be negative, got %d", c.Threshold)
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("penalty window must be positive, got %s", c.Window)
+	}
+	if c.MaxLevel < 1 || c.MaxLevel > maxPenaltyLevel {
+		return fmt.Errorf("penalty max level must be between 1 and %d, got %d", maxPenaltyLevel, c.MaxLevel)
+	}
+	if c.Decay <= 0 {
+		return fmt.Errorf("penalty decay must be positive, got %s", c.Decay)
+	}
+	return nil
+}
+
+// Penalties tracks keys that keep checking while denied and escalates their retry times
+//
+// Each denial of a key is an offense. A key with more than Threshold offenses within Window
+// goes up a level, up to MaxLevel, and every later denial tells it to wait 2^level times
+// the limiter's retry time; until that wait is over its checks are denied without asking
+// the limiter. A key that stays quiet for Decay after its penalty ends is back at level zero.
+// State is kept in the store, so penalties hold across instances and algorithms
+type Penalties struct {
+	store     limiter.Store
+	penalizer limiter.Penalizer
+	policy    limiter.PenaltyPolicy
+	clock     limiter.Clock
+}
+
+// PenaltyStatus is a key's current penalty
+type PenaltyStatus struct {
+	Level      int
+	Multiplier int       // 2^Level
+	Until      time.Time // When the current penalty ends (zero if not penalized)
+}
+
+// NewPenalties creates penalties kept in store, which must implement limiter.Penalizer
+func NewPenalties(store limiter.Store, config PenaltyConfig, opts ...Option) (*Penalties, error) {
+	penalizer, ok := store.(limiter.Penalizer)
+	if !ok {
+		return nil, fmt.Errorf("penalties require a store that tracks offenders")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Penalties{
+		store:     store,
+		penalizer: penalizer,
+		policy: limiter.PenaltyPolicy{
+			Threshold: config.Threshold,
+			Window:    config.Window,
+			MaxLevel:  config.MaxLevel,
+			Decay:     config.Decay,
+		},
+		clock: applyOptions(opts).clock,
+	}, nil
+}
+
+// Status returns key's current penalty
+func (p *Penalties) Status(ctx context.Context, key string) (PenaltyStatus, error) {
+	now := p.clock.Now()
+	level, until, err := p.penalizer.PenaltyCtx(ctx, penaltyKeyPrefix+key, now, p.policy.Decay)
+	if err != nil {
+		return PenaltyStatus{}, err
+	}
+
+	status := PenaltyStatus{Level: level, Multiplier: 1 << level}
+	if now.Before(until) {
+		status.Until = until
+	}
+	return status, nil
+}
+
+// Reset clears key's penalty and offenses
+func (p *Penalties) Reset(ctx context.Context, key string) error {
+	return p.store.DeleteCtx(ctx, penaltyKeyPrefix+key)
+}
+
+// penalized returns when key's current penalty ends, or false if it is not penalized
+func (p *Penalties) penalized(ctx context.Context, key string, now time.Time) (time.Time, bool, error) {
+	_, until, err := p.penalizer.PenaltyCtx(ctx, penaltyKeyPrefix+key, now, p.policy.Decay)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return until, now.Before(until), nil
+}
+
+// offend records a denial of key that asked it to retry after retryAfter, returning when
+// its penalty ends
+func (p *Penalties) offend(ctx context.Context, key string, now time.Time, retryAfter time.Duration) (time.Time, error) {
+	_, until, err := p.penalizer.PenalizeCtx(ctx, penaltyKeyPrefix+key, now, retryAfter, p.policy)
+	return until, err
+}
+
+// PenaltyLimiter applies Penalties to the decisions of the wrapped limiter
+type PenaltyLimiter struct {
+	base      limiter.RateLimiter
+	penalties *Penalties
+	closer
+}
+
+// NewPenaltyLimiter wraps base so repeat offenders are penalized
+func NewPenaltyLimiter(base limiter.RateLimiter, penalties *Penalties) *PenaltyLimiter {
+	return &PenaltyLimiter{
+		base:      base,
+		penalties: penalties,
+	}
+}
+
+// Allow checks if a single request is allowed
+func (pl *PenaltyLimiter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return pl.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (pl *PenaltyLimiter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return pl.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (pl *PenaltyLimiter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return pl.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, honoring ctx
+// A penalized key is denied until its penalty ends, and each denial is counted against it;
+// denials by the wrapped limiter report the penalized retry time
+func (pl *PenaltyLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := pl.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	// Status reads are not offenses
+	if n <= 0 {
+		return pl.base.AllowNCtx(ctx, key, n)
+	}
+
+	now := pl.penalties.clock.Now()
+	until, penalized, err := pl.penalties.penalized(ctx, key, now)
+	if err != nil {
+		return false, nil, err
+	}
+	if penalized {
+		// Retrying during a penalty counts toward the next level without extending this one
+		if until, err = pl.penalties.offend(ctx, key, now, 0); err != nil {
+			return false, nil, err
+		}
+		return false, pl.penaltyInfo(ctx, key, n, now, until), nil
+	}
+
+	allowed, info, err := pl.base.AllowNCtx(ctx, key, n)
+	if err != nil || allowed || info == nil || info.RetryAfter == nil {
+		return allowed, info, err
+	}
+
+	if until, err = pl.penalties.offend(ctx, key, now, *info.RetryAfter); err != nil {
+		return false, nil, err
+	}
+	retryAfter := until.Sub(now)
+	info.RetryAfter = &retryAfter
+	return false, info, nil
+}
+
+// Peek reports whether N requests would be allowed, denying penalized keys
+// Returns an error if the wrapped limiter cannot peek
+func (pl *PenaltyLimiter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	peeker, ok := pl.base.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("wrapped limiter does not support peek")
+	}
+
+	now := pl.penalties.clock.Now()
+	until, penalized, err := pl.penalties.penalized(ctx, key, now)
+	if err != nil {
+		return false, nil, err
+	}
+	if penalized && n > 0 {
+		return false, pl.penaltyInfo(ctx, key, n, now, until), nil
+	}
+	return peeker.Peek(ctx, key, n)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (pl *PenaltyLimiter) Status(key string) (*limiter.LimitInfo, error) {
+	return pl.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (pl *PenaltyLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := pl.Peek(ctx, key, 0)
+	return info, err
+}
+
+// penaltyInfo describes a denial by penalty, with the wrapped limiter's state where it can
+// be read without consuming anything
+func (pl *PenaltyLimiter) penaltyInfo(ctx context.Context, key string, n int, now, until time.Time) *limiter.LimitInfo {
+	info := &limiter.LimitInfo{Limit: pl.Config().Limit, ResetAt: until}
+	if peeker, ok := pl.base.(limiter.Peeker); ok {
+		if _, peeked, err := peeker.Peek(ctx, key, n); err == nil && peeked != nil {
+			info = peeked
+		}
+	}
+
+	retryAfter := until.Sub(now)
+	info.RetryAfter = &retryAfter
+	info.Reason = limiter.ReasonPenalized
+	return info
+}
+
+// Config returns the wrapped limiter's policy, or a zero Config if it cannot describe itself
+func (pl *PenaltyLimiter) Config() limiter.Config {
+	if d, ok := pl.base.(limiter.Describer); ok {
+		return d.Config()
+	}
+	return limiter.Config{}
+}
+
+// UpdateConfig updates the wrapped limiter's policy
+// Returns an error if the wrapped limiter can not be reconfigured
+func (pl *PenaltyLimiter) UpdateConfig(config limiter.Config) error {
+	reconfigurer, ok := pl.base.(limiter.Reconfigurer)
+	if !ok {
+		return fmt.Errorf("wrapped limiter does not support reconfiguration")
+	}
+	return reconfigurer.UpdateConfig(config)
+}
+
+// Reset resets the rate limit for a key
+func (pl *PenaltyLimiter) Reset(key string) error {
+	return pl.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key and clears its penalty, honoring ctx
+func (pl *PenaltyLimiter) ResetCtx(ctx context.Context, key string) error {
+	if err := pl.base.ResetCtx(ctx, key); err != nil {
+		return err
+	}
+	return pl.penalties.Reset(ctx, key)
+}
+
+// Close closes the wrapped limiter
+func (pl *PenaltyLimiter) Close() error {
+	return pl.closeWith(pl.base.Close)
+}
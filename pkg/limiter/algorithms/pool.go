@@ -0,0 +1,275 @@
+package algorithms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/keys"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Store key prefixes for pooled usage, kept apart from other algorithms' state
+const (
+	poolOrgPrefix  = "pool:org:"
+	poolSeatPrefix = "pool:seat:"
+)
+
+// ErrUnknownTier is returned when a pool check names a tier with no policy
+var ErrUnknownTier = errors.New("unknown pool tier")
+
+// PoolPolicy describes an organization's pooled quota
+type PoolPolicy struct {
+	Limit        int           `json:"limit"`         // Requests the whole org may make per window
+	Window       time.Duration `json:"window"`        // Fixed window the quota resets on
+	SeatFraction float64       `json:"seat_fraction"` // Largest share of Limit one seat may use (0-1]
+}
+
+// Validate checks the policy is usable
+func (p PoolPolicy) Validate() error {
+	if p.Limit <= 0 {
+		return fmt.Errorf("pool limit must be positive, got %d", p.Limit)
+	}
+	if p.Window <= 0 {
+		return fmt.Errorf("pool window must be positive, got %s", p.Window)
+	}
+	if p.SeatFraction <= 0 || p.SeatFraction > 1 {
+		return fmt.Errorf("seat fraction must be in (0, 1], got %v", p.SeatFraction)
+	}
+	return nil
+}
+
+// SeatLimit returns the per-seat cap derived from the org limit
+func (p PoolPolicy) SeatLimit() int {
+	limit := int(math.Floor(float64(p.Limit) * p.SeatFraction))
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// PoolInfo reports pooled usage for an org and one of its seats
+type PoolInfo struct {
+	OrgLimit      int
+	OrgRemaining  int
+	SeatLimit     int
+	SeatUsed      int
+	SeatRemaining int
+	ResetAt       time.Time
+	RetryAfter    *time.Duration
+}
+
+// RetryAfterSeconds is RetryAfter in whole seconds, rounded up as LimitInfo's is
+func (info *PoolInfo) RetryAfterSeconds() int {
+	return (&limiter.LimitInfo{RetryAfter: info.RetryAfter}).RetryAfterSeconds()
+}
+
+// Pool enforces an organization-wide quota shared by its seats, with a per-seat fairness cap
+// The org counter is authoritative; a request is admitted only if both the org and the
+// seat have room. Both are window counters incremented atomically in the store and refunded
+// when either is over, so instances sharing a store never admit past either limit
+type Pool struct {
+	store     limiter.Store
+	clock     limiter.Clock
+	tiers     map[string]PoolPolicy
+	overrides map[string]PoolPolicy // org -> policy replacing its tier's
+	mu        sync.RWMutex          // Guards overrides
+}
+
+// NewPool creates a pooled limiter with a policy per tier
+func NewPool(store limiter.Store, tiers map[string]PoolPolicy, opts ...Option) (*Pool, error) {
+	for name, policy := range tiers {
+		if err := policy.Validate(); err != nil {
+			return nil, fmt.Errorf("tier %s: %w", name, err)
+		}
+	}
+
+	return &Pool{
+		store:     store,
+		clock:     applyOptions(opts).clock,
+		tiers:     tiers,
+		overrides: make(map[string]PoolPolicy),
+	}, nil
+}
+
+// SetOverride replaces the tier policy for one org
+func (p *Pool) SetOverride(org string, policy PoolPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.overrides[org] = policy
+	return nil
+}
+
+// ClearOverride returns an org to its tier policy
+func (p *Pool) ClearOverride(org string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.overrides, org)
+}
+
+// Policy returns the policy in force for an org on a tier
+func (p *Pool) Policy(org, tier string) (PoolPolicy, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.policy(org, tier)
+}
+
+// policy resolves the org's policy; callers must hold p.mu
+func (p *Pool) policy(org, tier string) (PoolPolicy, error) {
+	if policy, ok := p.overrides[org]; ok {
+		return policy, nil
+	}
+	if policy, ok := p.tiers[tier]; ok {
+		return policy, nil
+	}
+	return PoolPolicy{}, fmt.Errorf("%w: %q", ErrUnknownTier, tier)
+}
+
+// AllowN checks whether a seat may make N requests against its org's pool, consuming them if so
+func (p *Pool) AllowN(ctx context.Context, org, tier, seat string, n int) (bool, *PoolInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+	return p.evaluate(ctx, org, tier, seat, n, true)
+}
+
+// Peek reports whether a seat could make N requests without consuming anything
+func (p *Pool) Peek(ctx context.Context, org, tier, seat string, n int) (bool, *PoolInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+	return p.evaluate(ctx, org, tier, seat, n, false)
+}
+
+// Status returns the org's remaining quota and the seat's own consumption
+func (p *Pool) Status(ctx context.Context, org, tier, seat string) (*PoolInfo, error) {
+	_, info, err := p.Peek(ctx, org, tier, seat, 0)
+	return info, err
+}
+
+// evaluate checks N requests against both counters, counting them only when consume is set
+func (p *Pool) evaluate(ctx context.Context, org, tier, seat string, n int, consume bool) (bool, *PoolInfo, error) {
+	// The org is escaped and seats may not contain ':', so no two org and seat pairs share
+	// a seat counter and one org can never spend another's seat cap
+	seatPart, err := keys.Escaped{}.Join(org, seat)
+	if err != nil {
+		return false, nil, err
+	}
+	orgKey := poolOrgPrefix + org
+	seatKey := poolSeatPrefix + seatPart
+	// A negative n would hand quota back to both counters
+	if err := validateRequest(seatKey, n); err != nil {
+		return false, nil, err
+	}
+
+	policy, err := p.Policy(org, tier)
+	if err != nil {
+		return false, nil, err
+	}
+
+	now := p.clock.Now()
+	windowStart := now.Truncate(policy.Window)
+	seatLimit := policy.SeatLimit()
+
+	var allowed bool
+	var orgUsed, seatUsed int
+	if consume {
+		allowed, orgUsed, seatUsed, err = p.consume(ctx, orgKey, seatKey, windowStart, policy, n)
+	} else {
+		if orgUsed, err = p.used(ctx, orgKey, windowStart); err == nil {
+			seatUsed, err = p.used(ctx, seatKey, windowStart)
+		}
+		allowed = orgUsed+n <= policy.Limit && seatUsed+n <= seatLimit
+	}
+	if err != nil {
+		return false, nil, err
+	}
+
+	resetAt := windowStart.Add(policy.Window)
+	info := &PoolInfo{
+		OrgLimit:      policy.Limit,
+		OrgRemaining:  max(0, policy.Limit-orgUsed),
+		SeatLimit:     seatLimit,
+		SeatUsed:      seatUsed,
+		SeatRemaining: max(0, seatLimit-seatUsed),
+		ResetAt:       resetAt,
+	}
+
+	if !allowed {
+		retryAfter := resetAt.Sub(now)
+		info.RetryAfter = &retryAfter
+	}
+
+	return allowed, info, nil
+}
+
+// consume counts n against the seat, then the org, refunding whatever was counted when
+// either goes over its limit or a write fails. Each increment is atomic in the store, so
+// concurrent checks, from any instance, can only be refused while another's excess is
+// refunded, never admitted past a limit. It returns the usage each counter is left with
+func (p *Pool) consume(ctx context.Context, orgKey, seatKey string, windowStart time.Time, policy PoolPolicy, n int) (bool, int, int, error) {
+	seatCount, err := p.increment(ctx, seatKey, windowStart, n, policy.Window)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("failed to update seat usage: %w", err)
+	}
+	if seatCount > int64(policy.SeatLimit()) {
+		refundErr := p.refund(ctx, seatKey, windowStart, n, policy.Window)
+		orgUsed, err := p.used(ctx, orgKey, windowStart)
+		return false, orgUsed, int(seatCount) - n, errors.Join(err, refundErr)
+	}
+
+	orgCount, err := p.increment(ctx, orgKey, windowStart, n, policy.Window)
+	if err != nil {
+		refundErr := p.refund(ctx, seatKey, windowStart, n, policy.Window)
+		return false, 0, 0, errors.Join(fmt.Errorf("failed to update org usage: %w", err), refundErr)
+	}
+	if orgCount > int64(policy.Limit) {
+		err := errors.Join(
+			p.refund(ctx, orgKey, windowStart, n, policy.Window),
+			p.refund(ctx, seatKey, windowStart, n, policy.Window),
+		)
+		return false, int(orgCount) - n, int(seatCount) - n, err
+	}
+	return true, int(orgCount), int(seatCount), nil
+}
+
+// refund takes back n counted against a key by a check that was refused
+// A failed refund leaves the quota counted until the window ends, so it is reported
+func (p *Pool) refund(ctx context.Context, key string, windowStart time.Time, n int, window time.Duration) error {
+	if _, err := p.increment(ctx, key, windowStart, -n, window); err != nil {
+		return fmt.Errorf("failed to refund pool usage: %w", err)
+	}
+	return nil
+}
+
+// increment adds delta to a key's count in the window starting at windowStart, keeping the
+// window for its whole length where the store can prune, as stores drop older windows
+func (p *Pool) increment(ctx context.Context, key string, windowStart time.Time, delta int, window time.Duration) (int64, error) {
+	if pruner, ok := p.store.(limiter.WindowPruner); ok {
+		return pruner.IncrementPruneCtx(ctx, key, windowStart, int64(delta), window)
+	}
+	return p.store.IncrementByCtx(ctx, key, windowStart, int64(delta))
+}
+
+// used returns the usage recorded for a key in the window starting at windowStart
+func (p *Pool) used(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	windows, err := p.store.GetWindowsCtx(ctx, key, windowStart, windowStart)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pool usage: %w", err)
+	}
+
+	var count int64
+	for _, w := range windows {
+		if w.Timestamp.Equal(windowStart) {
+			count = w.Count
+		}
+	}
+	return int(count), nil
+}
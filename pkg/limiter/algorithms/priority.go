@@ -0,0 +1,149 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Priority classes, highest first
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// PriorityLimiter holds back the last part of a limiter's capacity from one priority
+// class, so that when a limit is nearly exhausted lower priorities are shed first.
+// A request is shed, without consuming anything, when it would leave less than the
+// reserved fraction of the limit remaining, even though the limiter would allow it
+//
+// The reserve is checked with a peek before the request is consumed, so concurrent
+// requests can dip slightly into the reserve; it is a shedding policy, not a hard limit
+type PriorityLimiter struct {
+	limiter limiter.RateLimiter
+	reserve float64 // Fraction of the limit held back (0-1)
+	clock   limiter.Clock
+	closer
+}
+
+// NewPriorityLimiter reserves the given fraction of rl's limit from the requests it checks
+// rl must implement limiter.Peeker, which every algorithm in this package does
+func NewPriorityLimiter(rl limiter.RateLimiter, reserve float64, opts ...Option) (*PriorityLimiter, error) {
+	if reserve < 0 || reserve >= 1 {
+		return nil, fmt.Errorf("priority reserve %v must be in [0, 1)", reserve)
+	}
+	if _, ok := rl.(limiter.Peeker); !ok && reserve > 0 {
+		return nil, fmt.Errorf("priority reserve requires a limiter that can peek")
+	}
+	return &PriorityLimiter{
+		limiter: rl,
+		reserve: reserve,
+		clock:   applyOptions(opts).clock,
+	}, nil
+}
+
+// Allow checks if a single request is allowed
+func (p *PriorityLimiter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return p.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (p *PriorityLimiter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return p.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (p *PriorityLimiter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return p.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed without dipping into the reserve, honoring ctx
+func (p *PriorityLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	allowed, _, info, err := p.AllowNShed(ctx, key, n)
+	return allowed, info, err
+}
+
+// AllowNShed is AllowNCtx, also reporting whether a denial was the reserve shedding the
+// request rather than the underlying limit
+func (p *PriorityLimiter) AllowNShed(ctx context.Context, key string, n int) (allowed, shed bool, info *limiter.LimitInfo, err error) {
+	if err := p.ready(ctx); err != nil {
+		return false, false, nil, err
+	}
+	if p.reserve == 0 {
+		allowed, info, err = p.limiter.AllowNCtx(ctx, key, n)
+		return allowed, false, info, err
+	}
+
+	allowed, info, err = p.limiter.(limiter.Peeker).Peek(ctx, key, n)
+	if err != nil || !allowed {
+		return allowed, false, info, err
+	}
+
+	if info.Remaining-n < p.reserved(info.Limit) {
+		// Capacity above the reserve comes back no later than the limit fully resets
+		retryAfter := max(info.ResetAt.Sub(p.clock.Now()), 0)
+		info.RetryAfter = &retryAfter
+		info.Reason = limiter.ReasonReserved
+		return false, true, info, nil
+	}
+
+	allowed, info, err = p.limiter.AllowNCtx(ctx, key, n)
+	return allowed, false, info, err
+}
+
+// reserved returns how much of limit is held back, rounded up so a small limit still
+// keeps something back
+func (p *PriorityLimiter) reserved(limit int) int {
+	return int(math.Ceil(p.reserve * float64(limit)))
+}
+
+// Peek reports whether N requests would be allowed, including the reserve, without consuming
+func (p *PriorityLimiter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	peeker, ok := p.limiter.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("limiter does not support peeking")
+	}
+
+	allowed, info, err := peeker.Peek(ctx, key, n)
+	if err != nil || !allowed || p.reserve == 0 {
+		return allowed, info, err
+	}
+	return info.Remaining-n >= p.reserved(info.Limit), info, nil
+}
+
+// Status reports the current limit state for key without consuming anything
+func (p *PriorityLimiter) Status(key string) (*limiter.LimitInfo, error) {
+	return p.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (p *PriorityLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := p.Peek(ctx, key, 0)
+	return info, err
+}
+
+// Config returns the policy of the wrapped limiter
+func (p *PriorityLimiter) Config() limiter.Config {
+	if d, ok := p.limiter.(limiter.Describer); ok {
+		return d.Config()
+	}
+	return limiter.Config{}
+}
+
+// Reset resets the rate limit for a key
+func (p *PriorityLimiter) Reset(key string) error {
+	return p.limiter.Reset(key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (p *PriorityLimiter) ResetCtx(ctx context.Context, key string) error {
+	return p.limiter.ResetCtx(ctx, key)
+}
+
+// Close closes the wrapped limiter
+func (p *PriorityLimiter) Close() error {
+	return p.closeWith(p.limiter.Close)
+}
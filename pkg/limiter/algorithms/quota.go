@@ -0,0 +1,208 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// quotaGrace is how long a period's counter outlives the period, so usage can still be
+// read for a while after it resets and clock skew between instances cannot lose it
+const quotaGrace = time.Hour
+
+// QuotaLimiter enforces a long-horizon quota such as 1,000,000 requests per calendar month
+//
+// Each key has one counter per period that only grows, stored under the key and the
+// period's ID (e.g. "user:2024-06"), so a new period starts a new counter instead of
+// resetting a shared one. Counters expire shortly after their period ends. Periods are
+// UTC calendar hours, days or months, as for aligned fixed windows
+type QuotaLimiter struct {
+	store   limiter.Store
+	counter limiter.QuotaCounter
+	limit   int64
+	period  string
+	clock   limiter.Clock
+	closer
+}
+
+// QuotaUsage is a key's consumption of its quota in the current period
+type QuotaUsage struct {
+	Used    int64
+	Limit   int64
+	Period  string    // ID of the current period, e.g. "2024-06"
+	ResetAt time.Time // When the current period ends and usage starts again from zero
+}
+
+// NewQuotaLimiter creates a quota of limit requests per period, one of limiter.AlignHour,
+// limiter.AlignDay or limiter.AlignMonth. store must implement limiter.QuotaCounter
+func NewQuotaLimiter(store limiter.Store, limit int64, period string, opts ...Option) (*QuotaLimiter, error) {
+	counter, ok := store.(limiter.QuotaCounter)
+	if !ok {
+		return nil, fmt.Errorf("quota limits require a store that keeps quota counters")
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("quota limit must be positive, got %d", limit)
+	}
+	switch period {
+	case limiter.AlignHour, limiter.AlignDay, limiter.AlignMonth:
+	default:
+		return nil, fmt.Errorf("quota period must be %q, %q or %q, got %q",
+			limiter.AlignHour, limiter.AlignDay, limiter.AlignMonth, period)
+	}
+
+	return &QuotaLimiter{
+		store:   store,
+		counter: counter,
+		limit:   limit,
+		period:  period,
+		clock:   applyOptions(opts).clock,
+	}, nil
+}
+
+// Allow checks if a single request is allowed
+func (q *QuotaLimiter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return q.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (q *QuotaLimiter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return q.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (q *QuotaLimiter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return q.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests fit in the rest of the period's quota, honoring ctx
+// N = 0 reads usage without writing
+func (q *QuotaLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := validateRequest(key, n); err != nil {
+		return false, nil, err
+	}
+	return q.add(ctx, key, n, n == 0)
+}
+
+// Peek reports whether N requests would be allowed without consuming anything
+func (q *QuotaLimiter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := validateRequest(key, n); err != nil {
+		return false, nil, err
+	}
+	return q.add(ctx, key, n, true)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (q *QuotaLimiter) Status(key string) (*limiter.LimitInfo, error) {
+	return q.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (q *QuotaLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := q.Peek(ctx, key, 0)
+	return info, err
+}
+
+// Refund returns n previously consumed requests to the key's current period
+// It is the only way a count is taken back; checks refuse negative n
+func (q *QuotaLimiter) Refund(ctx context.Context, key string, n int) error {
+	if err := validateRequest(key, n); err != nil {
+		return err
+	}
+	_, _, err := q.add(ctx, key, -n, false)
+	return err
+}
+
+// Usage reports how much of the quota the key has used in the current period
+func (q *QuotaLimiter) Usage(ctx context.Context, key string) (QuotaUsage, error) {
+	now := q.clock.Now()
+	id, _, end := q.bounds(now)
+
+	_, used, err := q.counter.AddQuotaCtx(ctx, q.storeKey(key, id), 0, q.limit, end.Sub(now)+quotaGrace, true)
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+	return QuotaUsage{Used: used, Limit: q.limit, Period: id, ResetAt: end}, nil
+}
+
+// add applies n to the key's counter for the current period
+func (q *QuotaLimiter) add(ctx context.Context, key string, n int, dry bool) (bool, *limiter.LimitInfo, error) {
+	if err := q.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	now := q.clock.Now()
+	id, start, end := q.bounds(now)
+
+	allowed, used, err := q.counter.AddQuotaCtx(ctx, q.storeKey(key, id), int64(n), q.limit, end.Sub(now)+quotaGrace, dry)
+	if err != nil {
+		return false, nil, err
+	}
+
+	info := &limiter.LimitInfo{
+		Limit:     int(q.limit),
+		Remaining: int(max(0, q.limit-used)),
+		Used:      int(min(used, q.limit)),
+		ResetAt:   end,
+		Window:    end.Sub(start),
+		Policy:    fmt.Sprintf("%d/%s", q.limit, q.period),
+	}
+	if !allowed {
+		retryAfter := end.Sub(now)
+		info.RetryAfter = &retryAfter
+		info.Reason = denyReason(n, int(q.limit), limiter.ReasonQuotaExhausted)
+	}
+	return allowed, info, nil
+}
+
+// bounds returns the ID of the period containing now, when it started and when it ends
+func (q *QuotaLimiter) bounds(now time.Time) (id string, start, end time.Time) {
+	now = now.UTC()
+	switch q.period {
+	case limiter.AlignHour:
+		start = now.Truncate(time.Hour)
+		return start.Format("2006-01-02T15"), start, start.Add(time.Hour)
+	case limiter.AlignDay:
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01-02"), start, start.AddDate(0, 0, 1)
+	default:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01"), start, start.AddDate(0, 1, 0)
+	}
+}
+
+// storeKey returns the store key holding a key's counter for period id
+func (q *QuotaLimiter) storeKey(key, id string) string {
+	return key + ":" + id
+}
+
+// Config returns the quota as a Config whose Window is the current period's length
+func (q *QuotaLimiter) Config() limiter.Config {
+	_, start, end := q.bounds(q.clock.Now())
+	return limiter.Config{
+		Algorithm: "quota",
+		Limit:     int(q.limit),
+		Window:    end.Sub(start),
+		Alignment: q.period,
+	}
+}
+
+// Reset clears the key's usage in the current period
+func (q *QuotaLimiter) Reset(key string) error {
+	return q.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx clears the key's usage in the current period, honoring ctx
+// Earlier periods are left to expire
+func (q *QuotaLimiter) ResetCtx(ctx context.Context, key string) error {
+	if err := q.ready(ctx); err != nil {
+		return err
+	}
+	id, _, _ := q.bounds(q.clock.Now())
+	return q.store.DeleteCtx(ctx, q.storeKey(key, id))
+}
+
+// Close closes the store; later checks fail with limiter.ErrClosed
+func (q *QuotaLimiter) Close() error {
+	return q.closeWith(q.store.Close)
+}
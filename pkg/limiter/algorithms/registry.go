@@ -0,0 +1,51 @@
+package algorithms
+
+import "github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+
+// Names the built-in algorithms are registered under
+const (
+	TokenBucketAlgorithm          = "token_bucket"
+	SlidingWindowCounterAlgorithm = "sliding_window"
+	SlidingWindowLogAlgorithm     = "sliding_window_log"
+	FixedWindowCounterAlgorithm   = "fixed_window"
+	LeakyBucketAlgorithm          = "leaky_bucket"
+	GCRAAlgorithm                 = "gcra"
+)
+
+// Builtin lists the built-in algorithm names in the order they are documented
+var Builtin = []string{
+	TokenBucketAlgorithm,
+	SlidingWindowCounterAlgorithm,
+	SlidingWindowLogAlgorithm,
+	FixedWindowCounterAlgorithm,
+	LeakyBucketAlgorithm,
+	GCRAAlgorithm,
+}
+
+func init() {
+	factories := map[string]limiter.Factory{
+		TokenBucketAlgorithm: func(s limiter.Store, c limiter.Config) (limiter.RateLimiter, error) {
+			return NewTokenBucket(s, c), nil
+		},
+		SlidingWindowCounterAlgorithm: func(s limiter.Store, c limiter.Config) (limiter.RateLimiter, error) {
+			return NewSlidingWindowCounter(s, c), nil
+		},
+		SlidingWindowLogAlgorithm: func(s limiter.Store, c limiter.Config) (limiter.RateLimiter, error) {
+			return NewSlidingWindowLog(s, c), nil
+		},
+		FixedWindowCounterAlgorithm: func(s limiter.Store, c limiter.Config) (limiter.RateLimiter, error) {
+			return NewFixedWindowCounter(s, c), nil
+		},
+		LeakyBucketAlgorithm: func(s limiter.Store, c limiter.Config) (limiter.RateLimiter, error) {
+			return NewLeakyBucket(s, c), nil
+		},
+		GCRAAlgorithm: func(s limiter.Store, c limiter.Config) (limiter.RateLimiter, error) {
+			return NewGCRA(s, c), nil
+		},
+	}
+	for name, factory := range factories {
+		if err := limiter.Register(name, factory); err != nil {
+			panic(err)
+		}
+	}
+}
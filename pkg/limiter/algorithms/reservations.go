@@ -0,0 +1,173 @@
+package algorithms
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// DefaultReservationTTL is how long a reservation is held when no TTL is given
+const DefaultReservationTTL = 5 * time.Minute
+
+// reclaimBatch bounds the expired reservations taken back per store call
+const reclaimBatch = 100
+
+// Reservations holds quota for long-running work in two phases: Reserve takes it up front,
+// then Commit keeps it once the work is done or Cancel returns it if the work failed
+//
+// Unlike TokenBucket.Reserve, reservations live in the store under an ID, so any instance
+// can commit or cancel them, and they expire: a reservation neither committed nor canceled
+// within its TTL can no longer be committed, and Reclaim returns its quota
+type Reservations struct {
+	store   limiter.ReservationStore
+	ttl     time.Duration
+	limiter func(name string) (limiter.RateLimiter, bool)
+	clock   limiter.Clock
+}
+
+// NewReservations creates reservations kept in store for ttl (0 = DefaultReservationTTL)
+// lookup resolves the limiter name a reservation was made under, to return its quota
+func NewReservations(store limiter.ReservationStore, ttl time.Duration, lookup func(name string) (limiter.RateLimiter, bool), opts ...Option) *Reservations {
+	if ttl <= 0 {
+		ttl = DefaultReservationTTL
+	}
+	return &Reservations{
+		store:   store,
+		ttl:     ttl,
+		limiter: lookup,
+		clock:   applyOptions(opts).clock,
+	}
+}
+
+// TTL returns how long a reservation is held before it expires
+func (r *Reservations) TTL() time.Duration {
+	return r.ttl
+}
+
+// Reserve takes n requests for key from the limiter named name, which must be able to
+// refund them, and holds them under a new reservation
+// A denial returns the limiter's LimitInfo and no reservation
+func (r *Reservations) Reserve(ctx context.Context, name, key string, n int) (bool, *limiter.LimitInfo, *limiter.Reservation, error) {
+	rl, ok := r.limiter(name)
+	if !ok {
+		return false, nil, nil, fmt.Errorf("unknown limiter %q", name)
+	}
+	refunder, ok := rl.(limiter.Refunder)
+	if !ok {
+		return false, nil, nil, fmt.Errorf("limiter %q cannot return reserved quota", name)
+	}
+
+	id, err := newReservationID()
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	allowed, info, err := rl.AllowNCtx(ctx, key, n)
+	if err != nil || !allowed {
+		return false, info, nil, err
+	}
+
+	res := limiter.Reservation{ID: id, Limiter: name, Key: key, N: n, ExpiresAt: r.clock.Now().Add(r.ttl)}
+	if err := r.store.AddReservationCtx(ctx, res); err != nil {
+		// Best effort: the quota was taken but can not be held, so give it back
+		_ = refunder.Refund(ctx, key, n)
+		return false, nil, nil, fmt.Errorf("failed to hold reservation: %w", err)
+	}
+	return true, info, &res, nil
+}
+
+// Commit keeps a reservation's quota as consumed
+// Fails with limiter.ErrReservationNotFound once it was committed, canceled or has expired
+func (r *Reservations) Commit(ctx context.Context, id string) (*limiter.Reservation, error) {
+	res, ok, err := r.store.TakeReservationCtx(ctx, id, r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, limiter.ErrReservationNotFound
+	}
+	return &res, nil
+}
+
+// Cancel returns a reservation's quota to its limiter
+// Fails with limiter.ErrReservationNotFound once it was committed, canceled or has expired;
+// an expired reservation's quota is returned by Reclaim instead
+func (r *Reservations) Cancel(ctx context.Context, id string) (*limiter.Reservation, error) {
+	res, ok, err := r.store.TakeReservationCtx(ctx, id, r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, limiter.ErrReservationNotFound
+	}
+	if err := r.refund(ctx, res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Reclaim returns the quota of every expired reservation and reports how many there were
+// Each is taken from the store once, so instances reclaiming together never double refund
+func (r *Reservations) Reclaim(ctx context.Context) (int, error) {
+	reclaimed := 0
+	for {
+		expired, err := r.store.TakeExpiredReservationsCtx(ctx, r.clock.Now(), reclaimBatch)
+		if err != nil {
+			return reclaimed, err
+		}
+		for _, res := range expired {
+			// Best effort: a limiter no longer configured has nothing to return to
+			if err := r.refund(ctx, res); err == nil {
+				reclaimed++
+			}
+		}
+		if len(expired) < reclaimBatch {
+			return reclaimed, nil
+		}
+	}
+}
+
+// Watch reclaims expired reservations every interval until ctx is done
+func (r *Reservations) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Best effort: reservations left expired are reclaimed on the next tick
+			_, _ = r.Reclaim(ctx)
+		}
+	}
+}
+
+// refund returns res's quota to the limiter it was taken from
+func (r *Reservations) refund(ctx context.Context, res limiter.Reservation) error {
+	rl, ok := r.limiter(res.Limiter)
+	if !ok {
+		return fmt.Errorf("unknown limiter %q", res.Limiter)
+	}
+	refunder, ok := rl.(limiter.Refunder)
+	if !ok {
+		return fmt.Errorf("limiter %q cannot return reserved quota", res.Limiter)
+	}
+	if err := refunder.Refund(ctx, res.Key, res.N); err != nil {
+		return fmt.Errorf("failed to return reserved quota: %w", err)
+	}
+	return nil
+}
+
+// newReservationID returns a random reservation ID
+func newReservationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate reservation ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,178 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Rollout decides which keys are subject to enforcement during a gradual rollout
+// A hash of the key is compared against the percentage, so a key's decision is
+// stable for a given percentage and keys only ever move from shadow to enforced
+// as the percentage ramps up
+type Rollout struct {
+	percent float64 // Percentage of keys enforced (0-100)
+	mu      sync.RWMutex
+}
+
+// NewRollout creates a rollout enforcing the given percentage of keys
+func NewRollout(percent float64) (*Rollout, error) {
+	r := &Rollout{}
+	if err := r.SetPercent(percent); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetPercent changes the percentage of enforced keys
+func (r *Rollout) SetPercent(percent float64) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("rollout percent must be between 0 and 100, got %v", percent)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.percent = percent
+	return nil
+}
+
+// Percent returns the current percentage of enforced keys
+func (r *Rollout) Percent() float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.percent
+}
+
+// Enforced reports whether limits are enforced for key
+func (r *Rollout) Enforced(key string) bool {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	// Map the key onto [0, 100) with 0.01% resolution
+	bucket := float64(h.Sum32()%10000) / 100
+
+	return bucket < r.Percent()
+}
+
+// RolloutLimiter enforces the wrapped limiter only for keys selected by a Rollout
+// Keys outside the rollout run in shadow mode: the limiter is still evaluated
+// (so its state reflects real traffic) but denials are turned into allows
+type RolloutLimiter struct {
+	base    limiter.RateLimiter
+	rollout *Rollout
+	closer
+}
+
+// NewRolloutLimiter wraps base so that only keys selected by rollout are enforced
+func NewRolloutLimiter(base limiter.RateLimiter, rollout *Rollout) *RolloutLimiter {
+	return &RolloutLimiter{
+		base:    base,
+		rollout: rollout,
+	}
+}
+
+// Allow checks if a single request is allowed
+func (rl *RolloutLimiter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return rl.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed, enforcing only keys in the rollout
+func (rl *RolloutLimiter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return rl.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (rl *RolloutLimiter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return rl.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, enforcing only keys in the rollout
+func (rl *RolloutLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := rl.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	allowed, info, err := rl.base.AllowNCtx(ctx, key, n)
+	return rl.shadow(key, allowed, info, err)
+}
+
+// Peek reports whether N requests would be allowed, shadowing keys outside the rollout
+// Returns an error if the wrapped limiter cannot peek
+func (rl *RolloutLimiter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	peeker, ok := rl.base.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("wrapped limiter does not support peek")
+	}
+
+	allowed, info, err := peeker.Peek(ctx, key, n)
+	return rl.shadow(key, allowed, info, err)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (rl *RolloutLimiter) Status(key string) (*limiter.LimitInfo, error) {
+	return rl.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (rl *RolloutLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := rl.Peek(ctx, key, 0)
+	return info, err
+}
+
+// shadow turns denials for keys outside the rollout into allows
+// The real limit state is still reported so shadowed keys remain observable
+func (rl *RolloutLimiter) shadow(key string, allowed bool, info *limiter.LimitInfo, err error) (bool, *limiter.LimitInfo, error) {
+	if err != nil || allowed || rl.rollout.Enforced(key) {
+		return allowed, info, err
+	}
+
+	info.RetryAfter = nil
+	info.Reason = ""
+	return true, info, nil
+}
+
+// Config returns the wrapped limiter's policy, or a zero Config if it cannot describe itself
+func (rl *RolloutLimiter) Config() limiter.Config {
+	if d, ok := rl.base.(limiter.Describer); ok {
+		return d.Config()
+	}
+	return limiter.Config{}
+}
+
+// UpdateConfig updates the wrapped limiter's policy
+// Returns an error if the wrapped limiter can not be reconfigured
+func (rl *RolloutLimiter) UpdateConfig(config limiter.Config) error {
+	reconfigurer, ok := rl.base.(limiter.Reconfigurer)
+	if !ok {
+		return fmt.Errorf("wrapped limiter does not support reconfiguration")
+	}
+	if err := reconfigurer.UpdateConfig(config); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Reset resets the rate limit for a key
+func (rl *RolloutLimiter) Reset(key string) error {
+	return rl.base.Reset(key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (rl *RolloutLimiter) ResetCtx(ctx context.Context, key string) error {
+	return rl.base.ResetCtx(ctx, key)
+}
+
+// ResetPrefix resets every key starting with prefix, returning how many had state
+func (rl *RolloutLimiter) ResetPrefix(prefix string) (int, error) {
+	return rl.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (rl *RolloutLimiter) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	return resetBasePrefix(ctx, rl.base, prefix)
+}
+
+// Close closes the wrapped limiter
+func (rl *RolloutLimiter) Close() error {
+	return rl.closeWith(rl.base.Close)
+}
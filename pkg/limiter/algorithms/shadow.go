@@ -0,0 +1,148 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// ShadowKeyPrefix namespaces the keys a shadow limiter is evaluated under, so its state
+// never collides with the state of the limiter being enforced
+const ShadowKeyPrefix = "shadow:"
+
+// ShadowConfig names the two sides of a shadow comparison for metrics
+type ShadowConfig struct {
+	Primary string        // Name of the enforced algorithm
+	Shadow  string        // Name of the compared algorithm
+	Metrics ShadowMetrics // Optional: counts divergent decisions
+}
+
+// ShadowMetrics counts decisions a shadow comparison's two sides made differently
+type ShadowMetrics interface {
+	RecordShadowDivergence(primary, shadow string, primaryAllowed bool)
+}
+
+// ShadowLimiter enforces a primary limiter while evaluating a second one on the same
+// traffic, to measure how often switching algorithms would change decisions. The
+// shadow runs after the primary under a namespaced key; its decision and errors never
+// affect the result. Each check the two decide differently is counted by which allowed
+type ShadowLimiter struct {
+	primary limiter.RateLimiter
+	shadow  limiter.RateLimiter
+	config  ShadowConfig
+	closer
+}
+
+// NewShadowLimiter enforces primary and compares every decision against shadow
+func NewShadowLimiter(primary, shadow limiter.RateLimiter, config ShadowConfig) *ShadowLimiter {
+	return &ShadowLimiter{
+		primary: primary,
+		shadow:  shadow,
+		config:  config,
+	}
+}
+
+// Allow checks if a single request is allowed
+func (sl *ShadowLimiter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return sl.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed by the primary
+func (sl *ShadowLimiter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return sl.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (sl *ShadowLimiter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return sl.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed by the primary, then evaluates the shadow
+// and records whether it agreed, honoring ctx
+func (sl *ShadowLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := sl.ready(ctx); err != nil {
+		return false, nil, err
+	}
+	allowed, info, err := sl.primary.AllowNCtx(ctx, key, n)
+	if err != nil {
+		return allowed, info, err
+	}
+
+	// Status reads consume nothing and are not decisions worth comparing
+	if n > 0 {
+		if shadowAllowed, _, shadowErr := sl.shadow.AllowNCtx(ctx, ShadowKeyPrefix+key, n); shadowErr == nil && shadowAllowed != allowed {
+			sl.recordDivergence(allowed)
+		}
+	}
+	return allowed, info, nil
+}
+
+// recordDivergence counts a check the two limiters decided differently
+func (sl *ShadowLimiter) recordDivergence(primaryAllowed bool) {
+	if sl.config.Metrics != nil {
+		sl.config.Metrics.RecordShadowDivergence(sl.config.Primary, sl.config.Shadow, primaryAllowed)
+	}
+}
+
+// Peek reports whether N requests would be allowed by the primary
+// Returns an error if the primary cannot peek
+func (sl *ShadowLimiter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	peeker, ok := sl.primary.(limiter.Peeker)
+	if !ok {
+		return false, nil, fmt.Errorf("primary limiter does not support peek")
+	}
+	return peeker.Peek(ctx, key, n)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (sl *ShadowLimiter) Status(key string) (*limiter.LimitInfo, error) {
+	return sl.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (sl *ShadowLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := sl.Peek(ctx, key, 0)
+	return info, err
+}
+
+// Config returns the primary's policy, or a zero Config if it cannot describe itself
+func (sl *ShadowLimiter) Config() limiter.Config {
+	if d, ok := sl.primary.(limiter.Describer); ok {
+		return d.Config()
+	}
+	return limiter.Config{}
+}
+
+// UpdateConfig updates the primary's policy; the shadow keeps its own
+// Returns an error if the primary can not be reconfigured
+func (sl *ShadowLimiter) UpdateConfig(config limiter.Config) error {
+	reconfigurer, ok := sl.primary.(limiter.Reconfigurer)
+	if !ok {
+		return fmt.Errorf("primary does not support reconfiguration")
+	}
+	if err := reconfigurer.UpdateConfig(config); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Reset resets the rate limit for a key
+func (sl *ShadowLimiter) Reset(key string) error {
+	return sl.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key under both limiters, honoring ctx
+// Only the primary's error is returned, as for checks
+func (sl *ShadowLimiter) ResetCtx(ctx context.Context, key string) error {
+	if err := sl.primary.ResetCtx(ctx, key); err != nil {
+		return err
+	}
+	_ = sl.shadow.ResetCtx(ctx, ShadowKeyPrefix+key)
+	return nil
+}
+
+// Close closes the primary and shadow limiters
+func (sl *ShadowLimiter) Close() error {
+	return sl.closeWith(func() error { return closeAll(sl.primary, sl.shadow) })
+}
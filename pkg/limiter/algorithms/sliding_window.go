@@ -0,0 +1,393 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SlidingWindowCounter implements sliding window counter algorithm
+// Hybrid approach that combines fixed windows with weighted counting
+// Provides good accuracy with better memory efficiency than sliding window log
+//
+// The window is divided into buckets. Requests in the buckets fully inside the sliding
+// window count in full, and those in the oldest bucket, which the window is sliding off,
+// count in proportion to the part of it still inside. One bucket is the classic scheme of
+// interpolating between the current and previous window; more buckets cost one counter
+// each but let a burst age out gradually instead of weighing on the key for a whole window
+// Config.WeightMode trades that interpolation's accuracy for strictness
+type SlidingWindowCounter struct {
+	store   limiter.Store
+	clock   limiter.Clock
+	tracer  trace.Tracer
+	limit   int
+	burst   int // Requests admitted above limit in a window
+	window  time.Duration
+	buckets int
+	mode    weighting
+	mu      sync.RWMutex
+	closer
+}
+
+// NewSlidingWindowCounter creates a new sliding window counter rate limiter
+func NewSlidingWindowCounter(store limiter.Store, config limiter.Config, opts ...Option) *SlidingWindowCounter {
+	o := applyOptions(opts)
+	return &SlidingWindowCounter{
+		store:   store,
+		clock:   o.clock,
+		tracer:  o.tracer,
+		limit:   config.Limit,
+		burst:   config.WindowBurst,
+		window:  config.Window,
+		buckets: max(config.Buckets, 1),
+		mode:    weighting(config.WeightMode),
+	}
+}
+
+// Allow checks if a single request is allowed
+func (swc *SlidingWindowCounter) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return swc.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (swc *SlidingWindowCounter) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return swc.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (swc *SlidingWindowCounter) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return swc.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, honoring ctx
+func (swc *SlidingWindowCounter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := swc.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	ctx, span := startAllowSpan(ctx, swc.tracer, "sliding_window", key, n)
+
+	swc.mu.Lock()
+	defer swc.mu.Unlock()
+
+	// Zero requests only read the buckets, so status polls never add to them
+	return span.end(swc.evaluate(ctx, key, n, n > 0))
+}
+
+// AllowNAt checks if N requests are allowed as of at instead of the limiter's clock,
+// counting the windows that overlap the one ending at at
+func (swc *SlidingWindowCounter) AllowNAt(key string, n int, at time.Time) (bool, *limiter.LimitInfo, error) {
+	return swc.AllowNCtx(limiter.WithRequestTime(context.Background(), at), key, n)
+}
+
+// Peek reports whether N requests would be allowed without consuming anything
+func (swc *SlidingWindowCounter) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := swc.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	swc.mu.RLock()
+	defer swc.mu.RUnlock()
+	return swc.evaluate(ctx, key, n, false)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (swc *SlidingWindowCounter) Status(key string) (*limiter.LimitInfo, error) {
+	return swc.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (swc *SlidingWindowCounter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := swc.Peek(ctx, key, 0)
+	return info, err
+}
+
+// Usage reports key's weighted count over the sliding window ending now
+func (swc *SlidingWindowCounter) Usage(ctx context.Context, key string) (*limiter.Usage, error) {
+	if err := swc.ready(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateRequest(key, 0); err != nil {
+		return nil, err
+	}
+
+	swc.mu.RLock()
+	defer swc.mu.RUnlock()
+
+	now := requestTime(ctx, swc.clock)
+	var buf [2]int64
+	counts, slid, _, err := swc.counts(ctx, key, now, &buf)
+	if err != nil {
+		return nil, err
+	}
+	return &limiter.Usage{
+		Used:        weighted(counts, swc.mode.weight(slid)),
+		Limit:       swc.limit,
+		WindowStart: now.Add(-swc.window),
+		WindowEnd:   now,
+	}, nil
+}
+
+// evaluate checks N requests against the weighted window count, consuming them only when consume is set
+// Callers must hold swc.mu
+func (swc *SlidingWindowCounter) evaluate(ctx context.Context, key string, n int, consume bool) (bool, *limiter.LimitInfo, error) {
+	if err := validateRequest(key, n); err != nil {
+		return false, nil, err
+	}
+
+	now := requestTime(ctx, swc.clock)
+	var buf [2]int64
+	counts, slid, currentBucket, err := swc.counts(ctx, key, now, &buf)
+	if err != nil {
+		return false, nil, err
+	}
+	weight := swc.mode.weight(slid)
+	weightedCount := weighted(counts, weight)
+
+	// Check if request allowed, letting the burst take the window over its limit
+	allowed := weightedCount+float64(n) <= float64(swc.limit+swc.burst)
+
+	if allowed && consume {
+		newCount, err := swc.increment(ctx, key, currentBucket, n)
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to increment: %w", err)
+		}
+		counts[swc.buckets] = newCount
+		weightedCount = weighted(counts, weight)
+	}
+
+	remaining := int(float64(swc.limit) - weightedCount)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	// The oldest bucket has slid out of the window by the start of the next bucket
+	size := swc.bucketSize()
+	resetAt := currentBucket.Add(size)
+
+	info := &limiter.LimitInfo{
+		Limit:     swc.limit,
+		Remaining: remaining,
+		Used:      swc.limit - remaining,
+		ResetAt:   resetAt,
+		Window:    swc.window,
+	}
+
+	// Calculate retry after if denied
+	if !allowed {
+		// With one bucket, retry once the current window has ended
+		retryAfter := resetAt.Sub(now)
+		if swc.buckets > 1 {
+			retryAfter = agingDelay(counts, swc.mode, slid, size, weightedCount+float64(n)-float64(swc.limit+swc.burst))
+		}
+		info.RetryAfter = &retryAfter
+		info.Reason = denyReason(n, swc.limit+swc.burst, limiter.ReasonWindowExhausted)
+	}
+
+	return allowed, info, nil
+}
+
+// counts reads each bucket's requests at now, oldest first and ending with the current
+// bucket, with the fraction of the oldest bucket the window has slid past and when the
+// current bucket started
+// The default two buckets are read into buf, which callers keep on the stack so the hot
+// path stays allocation free
+func (swc *SlidingWindowCounter) counts(ctx context.Context, key string, now time.Time, buf *[2]int64) ([]int64, float64, time.Time, error) {
+	size := swc.bucketSize()
+
+	// The current bucket, and the oldest one that is partly inside the window
+	currentBucket := now.Truncate(size)
+	oldestBucket := currentBucket.Add(-swc.window)
+
+	// Redis and DynamoDB key windows by the Unix second they start in, so a bucket that does
+	// not start on a whole second comes back earlier than oldestBucket; read from its second
+	windows, err := swc.store.GetWindowsCtx(ctx, key, oldestBucket.Truncate(time.Second), now)
+	if err != nil {
+		return nil, 0, time.Time{}, fmt.Errorf("failed to get windows: %w", err)
+	}
+
+	counts := buf[:]
+	if swc.buckets > 1 {
+		counts = make([]int64, swc.buckets+1)
+	}
+	for _, w := range windows {
+		if i, ok := swc.bucketIndex(w.Timestamp, oldestBucket, size, len(counts)); ok {
+			counts[i] = w.Count
+		}
+	}
+
+	return counts, float64(now.Sub(currentBucket)) / float64(size), currentBucket, nil
+}
+
+// bucketSize returns the span of one bucket
+func (swc *SlidingWindowCounter) bucketSize() time.Duration {
+	return swc.window / time.Duration(swc.buckets)
+}
+
+// bucketIndex returns which of n buckets starting at oldest, size apart, a stored window
+// starting at ts is, whether the store kept its exact start or only the Unix second of it
+// ok is false for windows that are not one of the buckets
+func (swc *SlidingWindowCounter) bucketIndex(ts, oldest time.Time, size time.Duration, n int) (int, bool) {
+	i := int(ts.Sub(oldest) / size)
+	for _, j := range []int{i, i + 1} {
+		start := oldest.Add(time.Duration(j) * size)
+		if j >= 0 && j < n && (ts.Equal(start) || ts.Equal(start.Truncate(time.Second))) {
+			return j, true
+		}
+	}
+	return 0, false
+}
+
+// increment counts n requests in bucket, pruning buckets that have left the window where
+// the store supports it. Buckets are read back as far as one window before the current one,
+// so they are kept for a window and a bucket, two windows with the default single bucket;
+// stores expire keys no sooner, whatever their own TTL
+func (swc *SlidingWindowCounter) increment(ctx context.Context, key string, bucket time.Time, n int) (int64, error) {
+	if pruner, ok := swc.store.(limiter.WindowPruner); ok {
+		return pruner.IncrementPruneCtx(ctx, key, bucket, int64(n), swc.window+swc.bucketSize())
+	}
+	return swc.store.IncrementByCtx(ctx, key, bucket, int64(n))
+}
+
+// weighted returns the requests counted against the window: every bucket in full except
+// the oldest, which counts in proportion to weight
+func weighted(counts []int64, weight float64) float64 {
+	total := float64(counts[0]) * weight
+	for _, c := range counts[1:] {
+		total += float64(c)
+	}
+	return total
+}
+
+// agingDelay returns how long until excess requests have slid out of the window, assuming
+// no more arrive. Each bucket ages out as mode weighs it over one bucket span, oldest first,
+// and the window has already slid past the given fraction of the oldest. Rounded up to the
+// millisecond so a retry at that time is not denied again
+func agingDelay(counts []int64, mode weighting, slid float64, size time.Duration, excess float64) time.Duration {
+	var delay time.Duration
+	for i, c := range counts {
+		from := 0.0
+		if i == 0 {
+			from = slid
+		}
+		w := mode.weight(from)
+		if c > 0 && float64(c)*w >= excess {
+			delay += time.Duration((mode.slid(w-excess/float64(c)) - from) * float64(size))
+			break
+		}
+		excess -= float64(c) * w
+		delay += time.Duration((1 - from) * float64(size))
+	}
+	return (delay + time.Millisecond - 1).Truncate(time.Millisecond)
+}
+
+// weighting is a sliding window weight mode, one of the limiter.Weight constants
+type weighting string
+
+// exponentialRate is how sharply WeightExponential holds the oldest bucket near full
+// weight; at 3 it still counts for over 80% halfway through its span
+const exponentialRate = 3.0
+
+// weight returns the fraction of the oldest bucket's requests that still count once the
+// window has slid past the given fraction of it
+func (m weighting) weight(slid float64) float64 {
+	switch m {
+	case limiter.WeightNone:
+		if slid < 1 {
+			return 1
+		}
+		return 0
+	case limiter.WeightExponential:
+		return (1 - math.Exp(-exponentialRate*(1-slid))) / (1 - math.Exp(-exponentialRate))
+	default:
+		return 1 - slid
+	}
+}
+
+// slid is the inverse of weight: how far the window must slide past the oldest bucket
+// for it to count for weight
+func (m weighting) slid(weight float64) float64 {
+	switch m {
+	case limiter.WeightNone:
+		if weight < 1 {
+			return 1
+		}
+		return 0
+	case limiter.WeightExponential:
+		return 1 + math.Log(1-weight*(1-math.Exp(-exponentialRate)))/exponentialRate
+	default:
+		return 1 - weight
+	}
+}
+
+// Config returns the policy this limiter enforces
+func (swc *SlidingWindowCounter) Config() limiter.Config {
+	swc.mu.RLock()
+	defer swc.mu.RUnlock()
+
+	config := limiter.Config{Algorithm: "sliding_window", Limit: swc.limit, Window: swc.window, WindowBurst: swc.burst}
+	if swc.buckets > 1 {
+		config.Buckets = swc.buckets
+	}
+	if swc.mode != "" && swc.mode != limiter.WeightLinear {
+		config.WeightMode = string(swc.mode)
+	}
+	return config
+}
+
+// UpdateConfig replaces the policy this counter enforces, keeping counts already in the store
+func (swc *SlidingWindowCounter) UpdateConfig(config limiter.Config) error {
+	if err := validateUpdate(config); err != nil {
+		return err
+	}
+	next := NewSlidingWindowCounter(swc.store, config)
+
+	swc.mu.Lock()
+	defer swc.mu.Unlock()
+	swc.limit = next.limit
+	swc.burst = next.burst
+	swc.window = next.window
+	swc.buckets = next.buckets
+	swc.mode = next.mode
+	return nil
+}
+
+// Reset resets the rate limit for a key
+func (swc *SlidingWindowCounter) Reset(key string) error {
+	return swc.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (swc *SlidingWindowCounter) ResetCtx(ctx context.Context, key string) error {
+	if err := swc.ready(ctx); err != nil {
+		return err
+	}
+	swc.mu.Lock()
+	defer swc.mu.Unlock()
+	return swc.store.DeleteCtx(ctx, key)
+}
+
+// ResetPrefix resets every key starting with prefix, returning how many had state
+func (swc *SlidingWindowCounter) ResetPrefix(prefix string) (int, error) {
+	return swc.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (swc *SlidingWindowCounter) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	if err := swc.ready(ctx); err != nil {
+		return 0, err
+	}
+	swc.mu.Lock()
+	defer swc.mu.Unlock()
+	return resetPrefix(ctx, swc.store, "", prefix)
+}
+
+// Close closes the store; later checks fail with limiter.ErrClosed
+func (swc *SlidingWindowCounter) Close() error {
+	return swc.closeWith(swc.store.Close)
+}
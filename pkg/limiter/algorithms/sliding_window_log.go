@@ -0,0 +1,251 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SlidingWindowLog implements the sliding window log algorithm
+// Stores a timestamp per request and counts exactly how many fall in the trailing window
+// Most accurate algorithm, at the cost of memory proportional to the limit per key
+type SlidingWindowLog struct {
+	store  limiter.Store
+	clock  limiter.Clock
+	tracer trace.Tracer
+	limit  int
+	window time.Duration
+	mu     sync.RWMutex
+	closer
+}
+
+// NewSlidingWindowLog creates a new sliding window log rate limiter
+func NewSlidingWindowLog(store limiter.Store, config limiter.Config, opts ...Option) *SlidingWindowLog {
+	o := applyOptions(opts)
+	return &SlidingWindowLog{
+		store:  store,
+		clock:  o.clock,
+		tracer: o.tracer,
+		limit:  config.Limit,
+		window: config.Window,
+	}
+}
+
+// Allow checks if a single request is allowed
+func (swl *SlidingWindowLog) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return swl.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (swl *SlidingWindowLog) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return swl.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (swl *SlidingWindowLog) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return swl.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, honoring ctx
+func (swl *SlidingWindowLog) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := swl.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	ctx, span := startAllowSpan(ctx, swl.tracer, "sliding_window_log", key, n)
+
+	swl.mu.Lock()
+	defer swl.mu.Unlock()
+
+	// Zero requests only read the log
+	return span.end(swl.evaluate(ctx, key, n, n > 0))
+}
+
+// AllowNAt checks if N requests are allowed as of at instead of the limiter's clock,
+// counting the logged requests in the window ending at at
+func (swl *SlidingWindowLog) AllowNAt(key string, n int, at time.Time) (bool, *limiter.LimitInfo, error) {
+	return swl.AllowNCtx(limiter.WithRequestTime(context.Background(), at), key, n)
+}
+
+// Peek reports whether N requests would be allowed without consuming anything
+func (swl *SlidingWindowLog) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := swl.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	swl.mu.RLock()
+	defer swl.mu.RUnlock()
+	return swl.evaluate(ctx, key, n, false)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (swl *SlidingWindowLog) Status(key string) (*limiter.LimitInfo, error) {
+	return swl.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (swl *SlidingWindowLog) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := swl.Peek(ctx, key, 0)
+	return info, err
+}
+
+// evaluate checks N requests against the logged requests, consuming them only when consume is set
+// Callers must hold swl.mu
+func (swl *SlidingWindowLog) evaluate(ctx context.Context, key string, n int, consume bool) (bool, *limiter.LimitInfo, error) {
+	if err := validateRequest(key, n); err != nil {
+		return false, nil, err
+	}
+
+	now := requestTime(ctx, swl.clock)
+
+	allowed, timestamps, err := swl.check(ctx, key, now, n, consume)
+	if err != nil {
+		return false, nil, err
+	}
+
+	remaining := swl.limit - len(timestamps)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	// The log is empty again once the newest entry expires
+	resetAt := now
+	if len(timestamps) > 0 {
+		resetAt = timestamps[len(timestamps)-1].Add(swl.window)
+	}
+
+	info := &limiter.LimitInfo{
+		Limit:     swl.limit,
+		Remaining: remaining,
+		Used:      swl.limit - remaining,
+		ResetAt:   resetAt,
+		Window:    swl.window,
+	}
+
+	if !allowed {
+		retryAfter := swl.retryAfter(timestamps, n, now)
+		info.RetryAfter = &retryAfter
+		info.Reason = denyReason(n, swl.limit, limiter.ReasonWindowExhausted)
+	}
+
+	return allowed, info, nil
+}
+
+// check trims the log, counts the in-window entries and appends n more if they fit
+// Returns the in-window timestamps afterwards; nothing is written unless consume is set
+func (swl *SlidingWindowLog) check(ctx context.Context, key string, now time.Time, n int, consume bool) (bool, []time.Time, error) {
+	// Let stores that can do this atomically close the race between instances
+	if la, ok := swl.store.(limiter.LogAllower); ok && consume {
+		allowed, timestamps, err := la.AllowLogCtx(ctx, key, now, swl.window, swl.limit, n)
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to check log: %w", err)
+		}
+		return allowed, timestamps, nil
+	}
+
+	windowStart := now.Add(-swl.window)
+
+	// Evict entries that have slid out of the window
+	if consume {
+		if err := swl.store.TrimTimestampsCtx(ctx, key, windowStart); err != nil {
+			return false, nil, fmt.Errorf("failed to trim timestamps: %w", err)
+		}
+	}
+
+	timestamps, err := swl.store.GetTimestampsCtx(ctx, key, windowStart, now)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to get timestamps: %w", err)
+	}
+
+	allowed := len(timestamps)+n <= swl.limit
+	if allowed && consume && n > 0 {
+		if err := swl.store.AddTimestampsCtx(ctx, key, now, n, swl.window); err != nil {
+			return false, nil, fmt.Errorf("failed to add timestamps: %w", err)
+		}
+		for i := 0; i < n; i++ {
+			timestamps = append(timestamps, now)
+		}
+	}
+
+	return allowed, timestamps, nil
+}
+
+// retryAfter returns how long until enough logged entries expire for n more requests
+func (swl *SlidingWindowLog) retryAfter(timestamps []time.Time, n int, now time.Time) time.Duration {
+	// The request fits once (count + n - limit) of the oldest entries have expired
+	mustExpire := len(timestamps) + n - swl.limit
+	if mustExpire <= 0 {
+		return 0
+	}
+	if mustExpire > len(timestamps) {
+		// n exceeds the limit on its own; it can never fit, so report a full window
+		return swl.window
+	}
+
+	retryAfter := timestamps[mustExpire-1].Add(swl.window).Sub(now)
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+	return retryAfter
+}
+
+// Config returns the policy this limiter enforces
+func (swl *SlidingWindowLog) Config() limiter.Config {
+	swl.mu.RLock()
+	defer swl.mu.RUnlock()
+
+	return limiter.Config{Algorithm: "sliding_window_log", Limit: swl.limit, Window: swl.window}
+}
+
+// UpdateConfig replaces the policy this log enforces, keeping entries already in the store
+func (swl *SlidingWindowLog) UpdateConfig(config limiter.Config) error {
+	if err := validateUpdate(config); err != nil {
+		return err
+	}
+	next := NewSlidingWindowLog(swl.store, config)
+
+	swl.mu.Lock()
+	defer swl.mu.Unlock()
+	swl.limit = next.limit
+	swl.window = next.window
+	return nil
+}
+
+// Reset resets the rate limit for a key
+func (swl *SlidingWindowLog) Reset(key string) error {
+	return swl.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (swl *SlidingWindowLog) ResetCtx(ctx context.Context, key string) error {
+	if err := swl.ready(ctx); err != nil {
+		return err
+	}
+	swl.mu.Lock()
+	defer swl.mu.Unlock()
+	return swl.store.DeleteCtx(ctx, key)
+}
+
+// ResetPrefix resets every key starting with prefix, returning how many had state
+func (swl *SlidingWindowLog) ResetPrefix(prefix string) (int, error) {
+	return swl.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (swl *SlidingWindowLog) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	if err := swl.ready(ctx); err != nil {
+		return 0, err
+	}
+	swl.mu.Lock()
+	defer swl.mu.Unlock()
+	return resetPrefix(ctx, swl.store, "", prefix)
+}
+
+// Close closes the store; later checks fail with limiter.ErrClosed
+func (swl *SlidingWindowLog) Close() error {
+	return swl.closeWith(swl.store.Close)
+}
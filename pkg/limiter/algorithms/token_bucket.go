@@ -0,0 +1,451 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TokenBucket implements the token bucket rate limiting algorithm
+// Tokens are added at a constant rate, and each request consumes one token
+// Provides smooth rate limiting with burst handling
+type TokenBucket struct {
+	store      limiter.Store
+	clock      limiter.Clock
+	tracer     trace.Tracer
+	capacity   int           // Maximum tokens in bucket
+	refillRate float64       // Tokens added per second
+	window     time.Duration // Period Limit tokens refill over, reported in LimitInfo
+	initial    float64       // Tokens a key starts with when first seen
+	seed       *int          // Initial tokens set by WithInitialTokens, kept across config updates
+	limit      int           // Tokens refilled per window (fixed precision)
+	fixed      bool          // Account in integer milli-tokens
+	maxDebt    int           // Tokens a request may overdraw by (float precision only)
+	maxWait    time.Duration // Longest WaitN or Reserve will make a caller wait (0 = unbounded)
+	mu         sync.RWMutex  // Protects in-memory operations
+	closer
+}
+
+// fixedPrefix namespaces milli-token balances so they are never read as float tokens
+const fixedPrefix = "milli:"
+
+// defaultTokenBucketWindow replaces a window that is not positive, which would make the
+// refill rate infinite
+const defaultTokenBucketWindow = time.Second
+
+// maxRefillWait bounds reported waits for buckets that refill too slowly to represent,
+// such as ones with a limit of zero
+const maxRefillWait = 100 * 365 * 24 * time.Hour
+
+// NewTokenBucket creates a new token bucket rate limiter
+func NewTokenBucket(store limiter.Store, config limiter.Config, opts ...Option) *TokenBucket {
+	capacity := config.Burst
+	if capacity == 0 {
+		capacity = config.Limit
+	}
+
+	window := config.Window
+	if window <= 0 {
+		log.Printf("Token bucket window %s is not positive, using %s", config.Window, defaultTokenBucketWindow)
+		window = defaultTokenBucketWindow
+	}
+
+	// Calculate refill rate: tokens per second
+	refillRate := float64(config.Limit) / window.Seconds()
+
+	o := applyOptions(opts)
+
+	// New keys start full unless initial tokens or an initial fill fraction are configured
+	initial := float64(capacity)
+	switch {
+	case o.initialTokens != nil:
+		initial = float64(min(max(*o.initialTokens, 0), capacity))
+	case config.InitialTokens != nil:
+		initial = float64(min(max(*config.InitialTokens, 0), capacity))
+	case config.InitialFill != nil:
+		fill := math.Max(0, math.Min(1, *config.InitialFill))
+		initial = fill * float64(capacity)
+	}
+
+	// Debt is only kept in float balances
+	fixed := config.Precision == limiter.PrecisionFixed
+	maxDebt := max(config.MaxDebt, 0)
+	if fixed {
+		maxDebt = 0
+	}
+
+	return &TokenBucket{
+		store:      store,
+		clock:      o.clock,
+		tracer:     o.tracer,
+		capacity:   capacity,
+		refillRate: refillRate,
+		window:     window,
+		initial:    initial,
+		seed:       o.initialTokens,
+		limit:      config.Limit,
+		fixed:      fixed,
+		maxDebt:    maxDebt,
+		maxWait:    o.maxWait,
+	}
+}
+
+// Allow checks if a single request is allowed
+func (tb *TokenBucket) Allow(key string) (bool, *limiter.LimitInfo, error) {
+	return tb.AllowN(key, 1)
+}
+
+// AllowN checks if N requests are allowed
+func (tb *TokenBucket) AllowN(key string, n int) (bool, *limiter.LimitInfo, error) {
+	return tb.AllowNCtx(context.Background(), key, n)
+}
+
+// AllowCtx checks if a single request is allowed, honoring ctx
+func (tb *TokenBucket) AllowCtx(ctx context.Context, key string) (bool, *limiter.LimitInfo, error) {
+	return tb.AllowNCtx(ctx, key, 1)
+}
+
+// AllowNCtx checks if N requests are allowed, honoring ctx
+func (tb *TokenBucket) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := tb.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	ctx, span := startAllowSpan(ctx, tb.tracer, "token_bucket", key, n)
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	// Zero requests only read the bucket, leaving its tokens and refill time as they are
+	return span.end(tb.evaluate(ctx, key, n, n > 0))
+}
+
+// AllowNAt checks if N requests are allowed as of at instead of the limiter's clock,
+// refilling up to at. A request older than the key's last refill is checked against the
+// stored tokens without rewinding them
+func (tb *TokenBucket) AllowNAt(key string, n int, at time.Time) (bool, *limiter.LimitInfo, error) {
+	return tb.AllowNCtx(limiter.WithRequestTime(context.Background(), at), key, n)
+}
+
+// Peek reports whether N requests would be allowed without consuming anything
+func (tb *TokenBucket) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	if err := tb.ready(ctx); err != nil {
+		return false, nil, err
+	}
+
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+	return tb.evaluate(ctx, key, n, false)
+}
+
+// Status reports the current limit state for key without consuming anything
+func (tb *TokenBucket) Status(key string) (*limiter.LimitInfo, error) {
+	return tb.StatusCtx(context.Background(), key)
+}
+
+// StatusCtx is Status with a context
+func (tb *TokenBucket) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	_, info, err := tb.Peek(ctx, key, 0)
+	return info, err
+}
+
+// Usage reports the tokens drawn from key's bucket that have not refilled yet, fractions
+// included. The window ends when the bucket will be full again and spans the time it takes
+// to refill Limit tokens
+func (tb *TokenBucket) Usage(ctx context.Context, key string) (*limiter.Usage, error) {
+	if err := tb.ready(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateRequest(key, 0); err != nil {
+		return nil, err
+	}
+
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+
+	now := requestTime(ctx, tb.clock)
+	var tokens float64
+	if tb.fixed {
+		var err error
+		if _, tokens, _, err = tb.consumeFixed(ctx, key, 0, now, true); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if _, tokens, _, err = tb.peek(ctx, key, 0, now); err != nil {
+			return nil, err
+		}
+	}
+
+	used := float64(tb.capacity) - tokens
+	end := now.Add(refillWait(used, tb.refillRate))
+	return &limiter.Usage{
+		Used:        used,
+		Limit:       tb.capacity,
+		WindowStart: end.Add(-tb.window),
+		WindowEnd:   end,
+	}, nil
+}
+
+// evaluate checks N requests against the available tokens, consuming them only when consume is set
+// Consuming goes through the store's atomic ConsumeTokens so instances sharing a store cannot over-admit
+// Callers must hold tb.mu
+func (tb *TokenBucket) evaluate(ctx context.Context, key string, n int, consume bool) (bool, *limiter.LimitInfo, error) {
+	if err := validateRequest(key, n); err != nil {
+		return false, nil, err
+	}
+
+	now := requestTime(ctx, tb.clock)
+
+	var allowed bool
+	var tokens float64
+	var retryAfter time.Duration
+	switch {
+	case tb.fixed:
+		var err error
+		allowed, tokens, retryAfter, err = tb.consumeFixed(ctx, key, n, now, !consume)
+		if err != nil {
+			return false, nil, err
+		}
+	case consume:
+		var err error
+		allowed, tokens, retryAfter, err = tb.consume(ctx, key, n, now)
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to consume tokens: %w", err)
+		}
+	default:
+		var err error
+		allowed, tokens, retryAfter, err = tb.peek(ctx, key, n, now)
+		if err != nil {
+			return false, nil, err
+		}
+	}
+
+	return allowed, tb.limitInfo(allowed, n, tokens, retryAfter, now), nil
+}
+
+// limitInfo reports a step of n that left tokens in the bucket at now
+func (tb *TokenBucket) limitInfo(allowed bool, n int, tokens float64, retryAfter time.Duration, now time.Time) *limiter.LimitInfo {
+	// Calculate reset time (when bucket will be full again)
+	resetAt := now.Add(refillWait(float64(tb.capacity)-tokens, tb.refillRate))
+
+	// A bucket in debt has nothing remaining, however deep the debt
+	// Used is the tokens drawn from the bucket that have not refilled yet
+	remaining := max(int(tokens), 0)
+	info := &limiter.LimitInfo{
+		Limit:     tb.capacity,
+		Remaining: remaining,
+		Used:      tb.capacity - remaining,
+		ResetAt:   resetAt,
+		Window:    tb.window,
+	}
+
+	// If denied, report when enough tokens will have refilled
+	// A bucket that never refills waits forever, which the store's arithmetic can overflow on
+	if !allowed {
+		if tb.refillRate <= 0 || retryAfter < 0 || retryAfter > maxRefillWait {
+			retryAfter = maxRefillWait
+		}
+		info.RetryAfter = &retryAfter
+		info.Reason = denyReason(n, tb.capacity+tb.maxDebt, limiter.ReasonInsufficientTokens)
+	}
+	return info
+}
+
+// refillWait returns how long refilling tokens takes at rate tokens per second, keeping
+// fractions of a second, as a finite, non-negative duration
+func refillWait(tokens, rate float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	seconds := tokens / rate
+	if rate <= 0 || seconds >= maxRefillWait.Seconds() {
+		return maxRefillWait
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// consume takes n tokens through the store, overdrawing when debt is allowed
+func (tb *TokenBucket) consume(ctx context.Context, key string, n int, now time.Time) (bool, float64, time.Duration, error) {
+	if tb.maxDebt == 0 {
+		return tb.store.ConsumeTokensCtx(ctx, key, n, tb.capacity, tb.refillRate, tb.initial, now)
+	}
+
+	consumer, ok := tb.store.(limiter.DebtTokenConsumer)
+	if !ok {
+		return false, 0, 0, fmt.Errorf("store does not support token debt")
+	}
+	return consumer.ConsumeTokensDebtCtx(ctx, key, n, tb.capacity, tb.refillRate, tb.initial, tb.maxDebt, now)
+}
+
+// consumeFixed runs a step in integer milli-tokens, writing nothing when dry
+func (tb *TokenBucket) consumeFixed(ctx context.Context, key string, n int, now time.Time, dry bool) (bool, float64, time.Duration, error) {
+	consumer, ok := tb.store.(limiter.MilliTokenConsumer)
+	if !ok {
+		return false, 0, 0, fmt.Errorf("store does not support fixed-point token accounting")
+	}
+
+	initial := int64(math.Round(tb.initial * 1000))
+	allowed, milliTokens, retryAfter, err := consumer.ConsumeMilliTokensCtx(ctx, fixedPrefix+key, n, tb.capacity, tb.limit, tb.window, initial, now, dry)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("failed to consume tokens: %w", err)
+	}
+	// Peeks report the balance before the request, like the float path
+	if dry && allowed {
+		milliTokens += int64(n) * 1000
+	}
+	return allowed, float64(milliTokens) / 1000, retryAfter, nil
+}
+
+// peek computes the bucket as of now without writing it back
+// State the store can not read fails the peek rather than passing for a full bucket
+func (tb *TokenBucket) peek(ctx context.Context, key string, n int, now time.Time) (bool, float64, time.Duration, error) {
+	// Get current tokens and last refill time
+	tokens, lastRefill, found, err := tb.store.GetTokensCtx(ctx, key)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("failed to get tokens: %w", err)
+	}
+	if !found {
+		// First request, or the first since a reset - the key starts with its initial tokens
+		tokens = tb.initial
+		lastRefill = now
+	}
+
+	// Calculate tokens to add based on time elapsed; a clock that jumped back adds none,
+	// as in the stores, rather than draining the bucket
+	if elapsed := now.Sub(lastRefill).Seconds(); elapsed > 0 {
+		tokens += elapsed * tb.refillRate
+	}
+
+	// Cap at capacity
+	if tokens > float64(tb.capacity) {
+		tokens = float64(tb.capacity)
+	}
+
+	// Check if enough tokens available, counting any debt that may be taken on
+	need := math.Max(0, float64(n-tb.maxDebt))
+	if tokens >= need {
+		return true, tokens, 0, nil
+	}
+
+	// Rounded up to the millisecond, matching the stores' ConsumeTokens
+	tokensNeeded := need - tokens
+	retryAfter := time.Duration(math.Ceil(tokensNeeded/tb.refillRate*1000)) * time.Millisecond
+	return false, tokens, retryAfter, nil
+}
+
+// Config returns the policy this limiter enforces
+// InitialFill is reported only when new keys start below capacity
+func (tb *TokenBucket) Config() limiter.Config {
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+
+	config := limiter.Config{
+		Algorithm: "token_bucket",
+		Limit:     tb.limit,
+		Window:    tb.window,
+		Burst:     tb.capacity,
+		Precision: limiter.PrecisionFloat,
+		MaxDebt:   tb.maxDebt,
+	}
+	if tb.fixed {
+		config.Precision = limiter.PrecisionFixed
+	}
+	if tb.initial < float64(tb.capacity) {
+		fill := tb.initial / float64(tb.capacity)
+		config.InitialFill = &fill
+	}
+	return config
+}
+
+// UpdateConfig replaces the policy this bucket enforces, keeping balances already in the store
+// Switching precision starts keys afresh, since the two are stored under different keys
+func (tb *TokenBucket) UpdateConfig(config limiter.Config) error {
+	if err := validateUpdate(config); err != nil {
+		return err
+	}
+	var opts []Option
+	if tb.seed != nil {
+		opts = append(opts, WithInitialTokens(*tb.seed))
+	}
+	next := NewTokenBucket(tb.store, config, opts...)
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.capacity = next.capacity
+	tb.refillRate = next.refillRate
+	tb.window = next.window
+	tb.initial = next.initial
+	tb.limit = next.limit
+	tb.fixed = next.fixed
+	tb.maxDebt = next.maxDebt
+	return nil
+}
+
+// Refund returns n consumed tokens to key, capped at capacity
+func (tb *TokenBucket) Refund(ctx context.Context, key string, n int) error {
+	if err := tb.ready(ctx); err != nil {
+		return err
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := requestTime(ctx, tb.clock)
+	if tb.fixed {
+		_, _, _, err := tb.consumeFixed(ctx, key, -n, now, false)
+		return err
+	}
+	if _, _, _, err := tb.store.ConsumeTokensCtx(ctx, key, -n, tb.capacity, tb.refillRate, tb.initial, now); err != nil {
+		return fmt.Errorf("failed to refund tokens: %w", err)
+	}
+	return nil
+}
+
+// Reset resets the rate limit for a key
+func (tb *TokenBucket) Reset(key string) error {
+	return tb.ResetCtx(context.Background(), key)
+}
+
+// ResetCtx resets the rate limit for a key, honoring ctx
+func (tb *TokenBucket) ResetCtx(ctx context.Context, key string) error {
+	if err := tb.ready(ctx); err != nil {
+		return err
+	}
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	if tb.fixed {
+		return tb.store.DeleteCtx(ctx, fixedPrefix+key)
+	}
+	return tb.store.DeleteCtx(ctx, key)
+}
+
+// ResetPrefix resets every key starting with prefix, returning how many had state
+func (tb *TokenBucket) ResetPrefix(prefix string) (int, error) {
+	return tb.ResetPrefixCtx(context.Background(), prefix)
+}
+
+// ResetPrefixCtx is ResetPrefix with a context
+func (tb *TokenBucket) ResetPrefixCtx(ctx context.Context, prefix string) (int, error) {
+	if err := tb.ready(ctx); err != nil {
+		return 0, err
+	}
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	keyPrefix := ""
+	if tb.fixed {
+		keyPrefix = fixedPrefix
+	}
+	return resetPrefix(ctx, tb.store, keyPrefix, prefix)
+}
+
+// Close closes the store; later checks fail with limiter.ErrClosed
+func (tb *TokenBucket) Close() error {
+	return tb.closeWith(tb.store.Close)
+}
@@ -0,0 +1,49 @@
+package algorithms
+
+import (
+	"context"
+	"strings"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// allowSpan traces one AllowN call
+type allowSpan struct {
+	trace.Span
+}
+
+// startAllowSpan opens a limiter.AllowN span under ctx, or a no-op span when tracer is nil
+// Store calls made under the returned context open their own spans as its children
+func startAllowSpan(ctx context.Context, tracer trace.Tracer, algorithm, key string, n int) (context.Context, allowSpan) {
+	if tracer == nil {
+		return ctx, allowSpan{trace.SpanFromContext(context.Background())}
+	}
+
+	ctx, span := tracer.Start(ctx, "limiter.AllowN", trace.WithAttributes(
+		attribute.String("algorithm", algorithm),
+		attribute.String("key_prefix", keyPrefix(key)),
+		attribute.Int("n", n),
+	))
+	return ctx, allowSpan{span}
+}
+
+// end records the decision on the span and ends it, passing the decision through
+func (s allowSpan) end(allowed bool, info *limiter.LimitInfo, err error) (bool, *limiter.LimitInfo, error) {
+	if err != nil {
+		s.RecordError(err)
+		s.SetStatus(codes.Error, err.Error())
+	} else {
+		s.SetAttributes(attribute.Bool("allowed", allowed))
+	}
+	s.End()
+	return allowed, info, err
+}
+
+// keyPrefix returns key up to its first colon, which keeps whole keys out of traces
+func keyPrefix(key string) string {
+	prefix, _, _ := strings.Cut(key, ":")
+	return prefix
+}
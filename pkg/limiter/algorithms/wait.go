@@ -0,0 +1,166 @@
+package algorithms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// ErrMaxWaitExceeded is returned when requests would take longer than the limiter's max
+// wait to be allowed (see WithMaxWait). Nothing is consumed
+type ErrMaxWaitExceeded struct {
+	Wait    time.Duration // How long the caller would have had to wait in all
+	MaxWait time.Duration
+}
+
+func (e *ErrMaxWaitExceeded) Error() string {
+	return fmt.Sprintf("wait of %s exceeds max wait %s", e.Wait, e.MaxWait)
+}
+
+// Wait blocks until a token is available for key and consumes it
+func (tb *TokenBucket) Wait(ctx context.Context, key string) error {
+	return tb.WaitN(ctx, key, 1)
+}
+
+// WaitN blocks until n tokens are available for key and consumes them
+// Returns ctx's error if it is done first, without sleeping at all when its deadline
+// is known to arrive before the tokens would, and *ErrMaxWaitExceeded, also without
+// sleeping, once the wait would run past the max wait
+func (tb *TokenBucket) WaitN(ctx context.Context, key string, n int) error {
+	if n > tb.capacity+tb.maxDebt {
+		return fmt.Errorf("%w: wait for %d tokens, bucket capacity %d", limiter.ErrRequestExceedsCapacity, n, tb.capacity+tb.maxDebt)
+	}
+
+	start := tb.clock.Now()
+	for {
+		allowed, info, err := tb.AllowNCtx(ctx, key, n)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return nil
+		}
+
+		wait := *info.RetryAfter
+		if total := tb.clock.Now().Sub(start) + wait; tb.maxWait > 0 && total > tb.maxWait {
+			return &ErrMaxWaitExceeded{Wait: total, MaxWait: tb.maxWait}
+		}
+		if deadline, ok := ctx.Deadline(); ok && tb.clock.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("wait of %s would exceed context deadline: %w", wait, context.DeadlineExceeded)
+		}
+
+		// Another caller may take the tokens first, so check again after sleeping
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Reservation holds tokens taken ahead of time by Reserve
+type Reservation struct {
+	tb       *TokenBucket
+	key      string
+	n        int
+	actAt    time.Time // When the reserved tokens will have refilled
+	mu       sync.Mutex
+	canceled bool
+}
+
+// Reserve takes n tokens for key now, without blocking, and reports how long the caller
+// must wait before acting on them. The bucket may go into debt, so later requests wait
+// behind the reservation. A reservation that would wait longer than the max wait takes
+// nothing and returns *ErrMaxWaitExceeded. Reservations are not atomic across instances
+// sharing a store
+func (tb *TokenBucket) Reserve(key string, n int) (*Reservation, error) {
+	if tb.fixed {
+		return nil, errors.New("reservations require float precision")
+	}
+	if n > tb.capacity {
+		return nil, fmt.Errorf("%w: reservation of %d tokens, bucket capacity %d", limiter.ErrRequestExceedsCapacity, n, tb.capacity)
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	ctx := context.Background()
+	now := tb.clock.Now()
+	tokens, err := tb.tokensAt(ctx, key, now)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens -= float64(n)
+	var delay time.Duration
+	if tokens < 0 {
+		// Rounded up to the millisecond, matching RetryAfter
+		delay = time.Duration(math.Ceil(-tokens/tb.refillRate*1000)) * time.Millisecond
+	}
+	if tb.maxWait > 0 && delay > tb.maxWait {
+		return nil, &ErrMaxWaitExceeded{Wait: delay, MaxWait: tb.maxWait}
+	}
+
+	if err := tb.store.SetTokensCtx(ctx, key, tokens, now); err != nil {
+		return nil, fmt.Errorf("failed to reserve tokens: %w", err)
+	}
+
+	return &Reservation{tb: tb, key: key, n: n, actAt: now.Add(delay)}, nil
+}
+
+// tokensAt returns key's balance refilled up to now, which is negative while in debt
+// Callers must hold tb.mu
+func (tb *TokenBucket) tokensAt(ctx context.Context, key string, now time.Time) (float64, error) {
+	tokens, lastRefill, found, err := tb.store.GetTokensCtx(ctx, key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tokens: %w", err)
+	}
+	if !found {
+		return tb.initial, nil
+	}
+
+	if elapsed := now.Sub(lastRefill).Seconds(); elapsed > 0 {
+		tokens += elapsed * tb.refillRate
+	}
+	return math.Min(tokens, float64(tb.capacity)), nil
+}
+
+// Delay returns how long from now the caller must wait before acting on the reservation
+func (r *Reservation) Delay() time.Duration {
+	return max(0, r.actAt.Sub(r.tb.clock.Now()))
+}
+
+// Cancel returns the reserved tokens to the bucket, capped at capacity
+// Canceling more than once has no further effect
+func (r *Reservation) Cancel() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.canceled {
+		return nil
+	}
+
+	tb := r.tb
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	ctx := context.Background()
+	now := tb.clock.Now()
+	tokens, err := tb.tokensAt(ctx, r.key, now)
+	if err != nil {
+		return err
+	}
+
+	tokens = math.Min(tokens+float64(r.n), float64(tb.capacity))
+	if err := tb.store.SetTokensCtx(ctx, r.key, tokens, now); err != nil {
+		return fmt.Errorf("failed to return reserved tokens: %w", err)
+	}
+	r.canceled = true
+	return nil
+}
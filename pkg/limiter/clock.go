@@ -0,0 +1,43 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the current time
+// Limiters read time through a Clock so simulations and tests can control it
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+// Now returns time.Now()
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ClockFunc adapts a function such as time.Now to a Clock
+type ClockFunc func() time.Time
+
+// Now calls f
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+type requestTimeKey struct{}
+
+// WithRequestTime makes limiters evaluate checks made under ctx as of at instead of their
+// clock, for replaying logs or processing events with their original timestamps
+// at is kept in UTC, so one instant sent with different offsets lands in the same window
+func WithRequestTime(ctx context.Context, at time.Time) context.Context {
+	return context.WithValue(ctx, requestTimeKey{}, at.UTC())
+}
+
+// RequestTime returns the time set by WithRequestTime on ctx, if any
+func RequestTime(ctx context.Context) (time.Time, bool) {
+	at, ok := ctx.Value(requestTimeKey{}).(time.Time)
+	return at, ok
+}
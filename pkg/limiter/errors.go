@@ -0,0 +1,41 @@
+package limiter
+
+import "errors"
+
+// MaxKeyLength is the longest key, in bytes, limiters accept
+const MaxKeyLength = 1024
+
+// MaxN is the most requests one check may ask for; anything larger is far beyond any
+// limit and would overflow the algorithms' arithmetic
+const MaxN = 1_000_000_000
+
+// Errors limiters and stores wrap their failures in, so callers can tell them apart with errors.Is
+var (
+	// ErrStoreUnavailable means the store could not be reached or did not answer
+	ErrStoreUnavailable = errors.New("store unavailable")
+
+	// ErrInvalidN means a check asked for a negative number of requests, or more than MaxN
+	ErrInvalidN = errors.New("invalid request count")
+
+	// ErrKeyTooLong means a key is longer than MaxKeyLength
+	ErrKeyTooLong = errors.New("key too long")
+
+	// ErrEmptyPrefix means a prefix reset was asked for no prefix, which would reset every key
+	ErrEmptyPrefix = errors.New("empty key prefix")
+
+	// ErrRequestExceedsCapacity means a wait, reservation or wait estimate asked for more
+	// requests than the limit could ever allow at once, so it could never succeed. Checks
+	// deny such requests instead
+	ErrRequestExceedsCapacity = errors.New("request exceeds capacity")
+
+	// ErrKeysNotColocated means an all-or-nothing check spanned keys a Redis Cluster keeps
+	// on different nodes; such keys must share a {hash tag}
+	ErrKeysNotColocated = errors.New("keys not colocated")
+
+	// ErrClosed means a limiter was used after Close
+	ErrClosed = errors.New("limiter closed")
+
+	// ErrReservationNotFound means a reservation was never made, was already committed or
+	// canceled, or has expired
+	ErrReservationNotFound = errors.New("reservation not found")
+)
@@ -0,0 +1,61 @@
+package limiter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Metadata is caller-supplied context carried alongside a decision, such as a
+// gateway request ID, trace ID or tenant. It is passed to decision consumers but
+// never influences the decision, the key, or metric labels
+type Metadata map[string]string
+
+// Limits applied to Metadata when none are configured
+const (
+	DefaultMetadataMaxKeys  = 16
+	DefaultMetadataMaxBytes = 1024 // Sum of key and value lengths
+)
+
+// Redacted replaces the value of redacted metadata keys
+const Redacted = "[REDACTED]"
+
+// Validate checks that m has at most maxKeys entries totalling at most maxBytes
+// Keys must be non-empty
+func (m Metadata) Validate(maxKeys, maxBytes int) error {
+	if len(m) > maxKeys {
+		return fmt.Errorf("metadata has %d keys, at most %d allowed", len(m), maxKeys)
+	}
+
+	size := 0
+	for k, v := range m {
+		if k == "" {
+			return errors.New("metadata keys must not be empty")
+		}
+		size += len(k) + len(v)
+	}
+	if size > maxBytes {
+		return fmt.Errorf("metadata is %d bytes, at most %d allowed", size, maxBytes)
+	}
+	return nil
+}
+
+// Redact returns a copy of m with the values of the listed keys replaced by Redacted
+// Keys match case-insensitively; m itself is not modified
+func (m Metadata) Redact(keys []string) Metadata {
+	if m == nil {
+		return nil
+	}
+
+	redacted := make(Metadata, len(m))
+	for k, v := range m {
+		redacted[k] = v
+		for _, r := range keys {
+			if strings.EqualFold(k, r) {
+				redacted[k] = Redacted
+				break
+			}
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,141 @@
+package limiter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Factory builds a limiter enforcing config on store
+type Factory func(store Store, config Config) (RateLimiter, error)
+
+// ErrUnknownAlgorithm is returned by New for an algorithm no factory is registered under
+var ErrUnknownAlgorithm = errors.New("unknown algorithm")
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes an algorithm available to New under name
+// The built-in algorithms register themselves when their package is imported; registering
+// a name twice is an error so one algorithm can not silently replace another
+func Register(name string, factory Factory) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("algorithm registration needs a name and a factory")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("algorithm %q is already registered", name)
+	}
+	registry[name] = factory
+	return nil
+}
+
+// AlgorithmAuto is the algorithm name that lets AutoAlgorithm choose for the config
+const AlgorithmAuto = "auto"
+
+// AutoLargeWindow is the window from which AutoAlgorithm prefers a fixed window
+const AutoLargeWindow = time.Hour
+
+// AutoAlgorithm picks a built-in algorithm suited to config:
+//   - token_bucket when Burst is above Limit, as the bucket admits bursts the windows do not
+//   - fixed_window for windows of AutoLargeWindow or longer, which need one counter per key
+//     and where a boundary burst is small next to the window
+//   - sliding_window otherwise, keeping short windows accurate across boundaries
+func AutoAlgorithm(config Config) string {
+	switch {
+	case config.Burst > config.Limit:
+		return "token_bucket"
+	case config.Window >= AutoLargeWindow:
+		return "fixed_window"
+	default:
+		return "sliding_window"
+	}
+}
+
+// AlgorithmFor builds the limiter AutoAlgorithm picks for config on store
+func AlgorithmFor(store Store, config Config) (RateLimiter, error) {
+	config.Algorithm = AutoAlgorithm(config)
+	return New(store, config)
+}
+
+// New builds a limiter running config.Algorithm on store, after validating config
+// AlgorithmAuto builds what AutoAlgorithm picks
+func New(store Store, config Config) (RateLimiter, error) {
+	if config.Algorithm == AlgorithmAuto {
+		config.Algorithm = AutoAlgorithm(config)
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[config.Algorithm]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q (registered: %v)", ErrUnknownAlgorithm, config.Algorithm, Algorithms())
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", config.Algorithm, err)
+	}
+	if config.Burst > 0 && config.Burst < config.Limit {
+		log.Printf("%s burst %d is below the limit %d, so a full window's requests can never be admitted at once", config.Algorithm, config.Burst, config.Limit)
+	}
+	return factory(store, config)
+}
+
+// Algorithms returns the names New can build, sorted
+func Algorithms() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Registered reports whether a factory is registered under name
+func Registered(name string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := registry[name]
+	return ok
+}
+
+// Validate checks that config describes a limit an algorithm can enforce
+func (c Config) Validate() error {
+	if c.Limit <= 0 || c.Window <= 0 {
+		return fmt.Errorf("limit and window must be positive, got %d/%s", c.Limit, c.Window)
+	}
+	if c.Burst < 0 {
+		return fmt.Errorf("burst must not be negative, got %d", c.Burst)
+	}
+	if c.WindowBurst < 0 {
+		return fmt.Errorf("window burst must not be negative, got %d", c.WindowBurst)
+	}
+	if fill := c.InitialFill; fill != nil && (*fill < 0 || *fill > 1) {
+		return fmt.Errorf("initial fill %v must be between 0 and 1", *fill)
+	}
+	if tokens := c.InitialTokens; tokens != nil && *tokens < 0 {
+		return fmt.Errorf("initial tokens must not be negative, got %d", *tokens)
+	}
+	switch c.Precision {
+	case "", PrecisionFloat, PrecisionFixed:
+	default:
+		return fmt.Errorf("precision %q must be %q or %q", c.Precision, PrecisionFloat, PrecisionFixed)
+	}
+	if c.MaxDebt < 0 {
+		return fmt.Errorf("max debt must not be negative, got %d", c.MaxDebt)
+	}
+	if c.MaxDebt > 0 && c.Precision == PrecisionFixed {
+		return fmt.Errorf("max debt requires %q precision", PrecisionFloat)
+	}
+	return nil
+}
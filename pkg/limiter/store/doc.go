@@ -0,0 +1,6 @@
+// Package store implements limiter.Store in memory, on Redis and on DynamoDB
+//
+// Beyond the Store interface each store implements the optional capabilities in package
+// limiter it can make atomic, such as limiter.BatchTokenConsumer; limiters check for them
+// and fall back to plain Store calls when a store lacks one
+package store
@@ -0,0 +1,622 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// DynamoStore implements a DynamoDB-backed store for deployments that can not keep a Redis
+// connection warm, such as AWS Lambda
+//
+// DynamoDB has no server-side scripts, so every operation is built from single-item writes,
+// which DynamoDB applies atomically:
+//   - window and log counts are UpdateItem ADDs, so concurrent increments never lose a count
+//   - token buckets are read, stepped here, and written back on condition that the item's
+//     version has not changed, retrying from the read when another writer got there first
+//
+// Items carry an expires_at attribute (Unix seconds) for DynamoDB's TTL to delete them.
+// TTL deletion is lazy, so reads ignore items that have expired but are still present
+//
+// The table needs a string partition key "pk" and a number sort key "sk", with TTL
+// enabled on "expires_at"
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+	ctx    context.Context
+	ttl    time.Duration // Expiry of window and token items written without one
+}
+
+// DynamoOption configures a DynamoStore
+type DynamoOption func(*DynamoStore)
+
+// WithDynamoTTL sets how long window and token items live after their last write (default 24h)
+func WithDynamoTTL(ttl time.Duration) DynamoOption {
+	return func(ds *DynamoStore) {
+		if ttl > 0 {
+			ds.ttl = ttl
+		}
+	}
+}
+
+// NewDynamoStore creates a store keeping its state in tableName
+// It makes no calls; use Ping to check that the table is reachable
+func NewDynamoStore(tableName string, client *dynamodb.Client, opts ...DynamoOption) *DynamoStore {
+	ds := &DynamoStore{
+		client: client,
+		table:  tableName,
+		ctx:    context.Background(),
+		ttl:    24 * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(ds)
+	}
+	return ds
+}
+
+// Attribute names; "#"-prefixed placeholders keep them clear of DynamoDB's reserved words
+const (
+	dynamoPK        = "pk"
+	dynamoSK        = "sk"
+	dynamoCount     = "count"
+	dynamoExpiresAt = "expires_at"
+	dynamoVersion   = "version"
+
+	dynamoTokens          = "tokens"
+	dynamoLastRefill      = "last_refill_ns"
+	dynamoMilliTokens     = "milli_tokens"
+	dynamoMilliLastRefill = "milli_last_refill_ns"
+)
+
+// maxDynamoBatch is the most requests one BatchWriteItem accepts
+const maxDynamoBatch = 25
+
+func dynamoKey(pk string, sk int64) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		dynamoPK: &types.AttributeValueMemberS{Value: pk},
+		dynamoSK: numberValue(sk),
+	}
+}
+
+func numberValue(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+func floatValue(f float64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatFloat(f, 'g', -1, 64)}
+}
+
+// numberAttribute reads a number attribute, reporting whether it was present
+func numberAttribute(item map[string]types.AttributeValue, name string) (string, bool) {
+	v, ok := item[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return "", false
+	}
+	return v.Value, true
+}
+
+func intAttribute(item map[string]types.AttributeValue, name string) (int64, bool) {
+	s, ok := numberAttribute(item, name)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
+func floatAttribute(item map[string]types.AttributeValue, name string) (float64, bool) {
+	s, ok := numberAttribute(item, name)
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+// expired reports whether an item's TTL has passed, though DynamoDB has not yet deleted it
+func expired(item map[string]types.AttributeValue, now time.Time) bool {
+	expiresAt, ok := intAttribute(item, dynamoExpiresAt)
+	return ok && expiresAt <= now.Unix()
+}
+
+// dynamoError wraps a failed DynamoDB call
+// Anything but a client fault, such as a missing table or a malformed request, means
+// DynamoDB could not serve the call and is reported as limiter.ErrStoreUnavailable.
+// Throttling is a client fault in DynamoDB's terms but an outage in ours
+func dynamoError(ctx context.Context, operation string, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s failed: %w", operation, err)
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorFault() == smithy.FaultClient {
+		switch apiErr.ErrorCode() {
+		case "ProvisionedThroughputExceededException", "ThrottlingException", "RequestLimitExceeded":
+		default:
+			return fmt.Errorf("%s failed: %w", operation, err)
+		}
+	}
+	return fmt.Errorf("%s failed: %w: %w", operation, limiter.ErrStoreUnavailable, err)
+}
+
+// Increment increments the counter for a key at a specific window
+func (ds *DynamoStore) Increment(key string, window time.Time) (int64, error) {
+	return ds.IncrementCtx(ds.ctx, key, window)
+}
+
+// IncrementCtx is Increment with a context
+func (ds *DynamoStore) IncrementCtx(ctx context.Context, key string, window time.Time) (int64, error) {
+	return ds.IncrementByCtx(ctx, key, window, 1)
+}
+
+// IncrementBy adds delta to the counter for a key at a specific window
+func (ds *DynamoStore) IncrementBy(key string, window time.Time, delta int64) (int64, error) {
+	return ds.IncrementByCtx(ds.ctx, key, window, delta)
+}
+
+// IncrementByCtx is IncrementBy with a context
+func (ds *DynamoStore) IncrementByCtx(ctx context.Context, key string, window time.Time, delta int64) (int64, error) {
+	ctx, span := startSpan(ctx, "store.Increment", "dynamodb")
+	defer span.End()
+
+	count, err := ds.add(ctx, "window:"+key, window.Unix(), int(delta), ds.ttl)
+	if err != nil {
+		return 0, dynamoError(ctx, "increment", err)
+	}
+	return count, nil
+}
+
+// IncrementPruneCtx is IncrementByCtx, keeping the window for at least retain even if the
+// store TTL is shorter. Each window is its own item and expires on its own, so there is
+// nothing older to prune
+func (ds *DynamoStore) IncrementPruneCtx(ctx context.Context, key string, window time.Time, delta int64, retain time.Duration) (int64, error) {
+	ctx, span := startSpan(ctx, "store.Increment", "dynamodb")
+	defer span.End()
+
+	count, err := ds.add(ctx, "window:"+key, window.Unix(), int(delta), max(ds.ttl, retain))
+	if err != nil {
+		return 0, dynamoError(ctx, "increment", err)
+	}
+	return count, nil
+}
+
+// add atomically adds n to the count of one item, creating it if needed, and returns the new count
+func (ds *DynamoStore) add(ctx context.Context, pk string, sk int64, n int, ttl time.Duration) (int64, error) {
+	out, err := ds.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(ds.table),
+		Key:              dynamoKey(pk, sk),
+		UpdateExpression: aws.String("ADD #count :n SET #expires_at = :expires_at"),
+		ExpressionAttributeNames: map[string]string{
+			"#count":      dynamoCount,
+			"#expires_at": dynamoExpiresAt,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":n":          numberValue(int64(n)),
+			":expires_at": numberValue(time.Now().Add(ttl).Unix()),
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	count, _ := intAttribute(out.Attributes, dynamoCount)
+	return count, nil
+}
+
+// query returns the unexpired items under pk with a sort key in [from, to]
+func (ds *DynamoStore) query(ctx context.Context, pk string, from, to int64, now time.Time) ([]map[string]types.AttributeValue, error) {
+	if to < from {
+		return nil, nil // BETWEEN rejects an empty range
+	}
+
+	paginator := dynamodb.NewQueryPaginator(ds.client, &dynamodb.QueryInput{
+		TableName:              aws.String(ds.table),
+		KeyConditionExpression: aws.String("#pk = :pk AND #sk BETWEEN :from AND :to"),
+		ExpressionAttributeNames: map[string]string{
+			"#pk": dynamoPK,
+			"#sk": dynamoSK,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":   &types.AttributeValueMemberS{Value: pk},
+			":from": numberValue(from),
+			":to":   numberValue(to),
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	var items []map[string]types.AttributeValue
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if !expired(item, now) {
+				items = append(items, item)
+			}
+		}
+	}
+	return items, nil
+}
+
+// GetWindows returns all windows for a key within a time range
+func (ds *DynamoStore) GetWindows(key string, from, to time.Time) ([]limiter.Window, error) {
+	return ds.GetWindowsCtx(ds.ctx, key, from, to)
+}
+
+// GetWindowsCtx is GetWindows with a context
+func (ds *DynamoStore) GetWindowsCtx(ctx context.Context, key string, from, to time.Time) ([]limiter.Window, error) {
+	ctx, span := startSpan(ctx, "store.GetWindows", "dynamodb")
+	defer span.End()
+
+	// Windows start on whole seconds; from.Unix() rounds down, so one starting before from is dropped below
+	items, err := ds.query(ctx, "window:"+key, from.Unix(), to.Unix(), time.Now())
+	if err != nil {
+		return nil, dynamoError(ctx, "get windows", err)
+	}
+
+	windows := make([]limiter.Window, 0, len(items))
+	for _, item := range items {
+		sk, _ := intAttribute(item, dynamoSK)
+		count, _ := intAttribute(item, dynamoCount)
+
+		t := time.Unix(sk, 0)
+		if t.Before(from) {
+			continue
+		}
+		windows = append(windows, limiter.Window{Timestamp: t, Count: count})
+	}
+	return windows, nil
+}
+
+// dynamoTokenItem is a token bucket item as read, with the version a write must still find
+type dynamoTokenItem struct {
+	version     int64 // 0 if there is no item
+	tokens      float64
+	lastRefill  time.Time
+	found       bool // Whether the float balance is present and unexpired
+	milliTokens int64
+	milliRefill time.Time
+	milliFound  bool // Whether the fixed-point balance is present and unexpired
+}
+
+// getTokenState reads key's token bucket item with a strongly consistent read
+func (ds *DynamoStore) getTokenState(ctx context.Context, key string, now time.Time) (dynamoTokenItem, error) {
+	out, err := ds.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(ds.table),
+		Key:            dynamoKey("tokens:"+key, 0),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return dynamoTokenItem{}, err
+	}
+
+	var state dynamoTokenItem
+	item := out.Item
+	state.version, _ = intAttribute(item, dynamoVersion)
+	if item == nil || expired(item, now) {
+		return state, nil
+	}
+
+	var refillOK, milliRefillOK bool
+	var refill, milliRefill int64
+	state.tokens, state.found = floatAttribute(item, dynamoTokens)
+	refill, refillOK = intAttribute(item, dynamoLastRefill)
+	state.found = state.found && refillOK
+	state.lastRefill = time.Unix(0, refill)
+
+	state.milliTokens, state.milliFound = intAttribute(item, dynamoMilliTokens)
+	milliRefill, milliRefillOK = intAttribute(item, dynamoMilliLastRefill)
+	state.milliFound = state.milliFound && milliRefillOK
+	state.milliRefill = time.Unix(0, milliRefill)
+	return state, nil
+}
+
+// errVersionChanged means another writer updated a token item between its read and write
+var errVersionChanged = errors.New("token item changed concurrently")
+
+// putTokenFields writes fields to key's token item and bumps its version
+// With expected >= 0 the write only happens if the item is still at that version (0 = absent)
+func (ds *DynamoStore) putTokenFields(ctx context.Context, key string, fields map[string]types.AttributeValue, expected int64) error {
+	names := map[string]string{
+		"#expires_at": dynamoExpiresAt,
+		"#version":    dynamoVersion,
+	}
+	values := map[string]types.AttributeValue{
+		":expires_at": numberValue(time.Now().Add(ds.ttl).Unix()),
+		":one":        numberValue(1),
+	}
+
+	update := "SET #expires_at = :expires_at"
+	i := 0
+	for name, value := range fields {
+		placeholder := fmt.Sprintf("f%d", i)
+		names["#"+placeholder] = name
+		values[":"+placeholder] = value
+		update += fmt.Sprintf(", #%s = :%s", placeholder, placeholder)
+		i++
+	}
+	update += " ADD #version :one"
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(ds.table),
+		Key:                       dynamoKey("tokens:"+key, 0),
+		UpdateExpression:          aws.String(update),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+	switch {
+	case expected == 0:
+		input.ConditionExpression = aws.String("attribute_not_exists(#version)")
+	case expected > 0:
+		input.ConditionExpression = aws.String("#version = :expected")
+		values[":expected"] = numberValue(expected)
+	}
+
+	_, err := ds.client.UpdateItem(ctx, input)
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return errVersionChanged
+	}
+	return err
+}
+
+// updateTokens runs step on key's token item and writes back the fields it returns, on
+// condition the item did not change in between. A step returning nil fields writes nothing.
+// Conflicting writes are retried from the read up to maxMilliTokenRetries times
+func (ds *DynamoStore) updateTokens(ctx context.Context, key string, now time.Time, step func(dynamoTokenItem) map[string]types.AttributeValue) error {
+	for i := 0; i < maxMilliTokenRetries; i++ {
+		state, err := ds.getTokenState(ctx, key, now)
+		if err != nil {
+			return err
+		}
+
+		fields := step(state)
+		if fields == nil {
+			return nil
+		}
+
+		err = ds.putTokenFields(ctx, key, fields, state.version)
+		if !errors.Is(err, errVersionChanged) {
+			return err
+		}
+	}
+	return fmt.Errorf("too much contention on %s", key)
+}
+
+// SetTokens sets the token count and last refill time for token bucket
+func (ds *DynamoStore) SetTokens(key string, tokens float64, lastRefill time.Time) error {
+	return ds.SetTokensCtx(ds.ctx, key, tokens, lastRefill)
+}
+
+// SetTokensCtx is SetTokens with a context
+func (ds *DynamoStore) SetTokensCtx(ctx context.Context, key string, tokens float64, lastRefill time.Time) error {
+	ctx, span := startSpan(ctx, "store.SetTokens", "dynamodb")
+	defer span.End()
+
+	err := ds.putTokenFields(ctx, key, map[string]types.AttributeValue{
+		dynamoTokens:     floatValue(tokens),
+		dynamoLastRefill: numberValue(lastRefill.UnixNano()),
+	}, -1)
+	if err != nil {
+		return dynamoError(ctx, "set tokens", err)
+	}
+	return nil
+}
+
+// GetTokens gets the token count and last refill time for token bucket
+func (ds *DynamoStore) GetTokens(key string) (tokens float64, lastRefill time.Time, found bool, err error) {
+	return ds.GetTokensCtx(ds.ctx, key)
+}
+
+// GetTokensCtx is GetTokens with a context
+func (ds *DynamoStore) GetTokensCtx(ctx context.Context, key string) (tokens float64, lastRefill time.Time, found bool, err error) {
+	ctx, span := startSpan(ctx, "store.GetTokens", "dynamodb")
+	defer span.End()
+
+	state, err := ds.getTokenState(ctx, key, time.Now())
+	if err != nil {
+		return 0, time.Time{}, false, dynamoError(ctx, "get tokens", err)
+	}
+	if !state.found {
+		return 0, time.Time{}, false, nil
+	}
+	return state.tokens, state.lastRefill, true, nil
+}
+
+// ConsumeTokens atomically refills a token bucket to now, then takes n tokens if available
+func (ds *DynamoStore) ConsumeTokens(key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (bool, float64, time.Duration, error) {
+	return ds.ConsumeTokensCtx(ds.ctx, key, n, capacity, refillRate, initial, now)
+}
+
+// ConsumeTokensCtx is ConsumeTokens with a context
+func (ds *DynamoStore) ConsumeTokensCtx(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (bool, float64, time.Duration, error) {
+	ctx, span := startSpan(ctx, "store.ConsumeTokens", "dynamodb")
+	defer span.End()
+
+	return ds.ConsumeTokensDebtCtx(ctx, key, n, capacity, refillRate, initial, 0, now)
+}
+
+// ConsumeTokensDebtCtx is ConsumeTokensCtx, letting the balance go as low as -maxDebt
+func (ds *DynamoStore) ConsumeTokensDebtCtx(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, maxDebt int, now time.Time) (bool, float64, time.Duration, error) {
+	var allowed bool
+	var tokens float64
+	var retryAfter time.Duration
+	err := ds.updateTokens(ctx, key, now, func(state dynamoTokenItem) map[string]types.AttributeValue {
+		var lastRefill time.Time
+		allowed, tokens, lastRefill, retryAfter = consumeTokens(state.tokens, state.lastRefill, state.found, n, capacity, refillRate, initial, maxDebt, now)
+		return map[string]types.AttributeValue{
+			dynamoTokens:     floatValue(tokens),
+			dynamoLastRefill: numberValue(lastRefill.UnixNano()),
+		}
+	})
+	if err != nil {
+		return false, 0, 0, dynamoError(ctx, "consume tokens", err)
+	}
+	return allowed, tokens, retryAfter, nil
+}
+
+// ConsumeMilliTokensCtx runs a fixed-point token bucket step under the same version check
+// The refill time is kept in nanoseconds so carried fractions survive
+func (ds *DynamoStore) ConsumeMilliTokensCtx(ctx context.Context, key string, n, capacity, limit int, window time.Duration, initial int64, now time.Time, dry bool) (bool, int64, time.Duration, error) {
+	var allowed bool
+	var tokens int64
+	var retryAfter time.Duration
+	err := ds.updateTokens(ctx, key, now, func(state dynamoTokenItem) map[string]types.AttributeValue {
+		var lastRefill time.Time
+		allowed, tokens, lastRefill, retryAfter = consumeMilliTokens(state.milliTokens, state.milliRefill, state.milliFound, n, capacity, limit, window, initial, now)
+		if dry {
+			return nil
+		}
+		return map[string]types.AttributeValue{
+			dynamoMilliTokens:     numberValue(tokens),
+			dynamoMilliLastRefill: numberValue(lastRefill.UnixNano()),
+		}
+	})
+	if err != nil {
+		return false, 0, 0, dynamoError(ctx, "consume milli-tokens", err)
+	}
+	return allowed, tokens, retryAfter, nil
+}
+
+// AddTimestamps records n request timestamps for a key
+func (ds *DynamoStore) AddTimestamps(key string, ts time.Time, n int, ttl time.Duration) error {
+	return ds.AddTimestampsCtx(ds.ctx, key, ts, n, ttl)
+}
+
+// AddTimestampsCtx is AddTimestamps with a context
+// Requests logged in the same microsecond share one item counting them
+func (ds *DynamoStore) AddTimestampsCtx(ctx context.Context, key string, ts time.Time, n int, ttl time.Duration) error {
+	ctx, span := startSpan(ctx, "store.AddTimestamps", "dynamodb")
+	defer span.End()
+
+	if _, err := ds.add(ctx, "log:"+key, ts.UnixMicro(), n, ttl); err != nil {
+		return dynamoError(ctx, "add timestamps", err)
+	}
+	return nil
+}
+
+// GetTimestamps returns the logged timestamps for a key within a time range, oldest first
+func (ds *DynamoStore) GetTimestamps(key string, from, to time.Time) ([]time.Time, error) {
+	return ds.GetTimestampsCtx(ds.ctx, key, from, to)
+}
+
+// GetTimestampsCtx is GetTimestamps with a context
+func (ds *DynamoStore) GetTimestampsCtx(ctx context.Context, key string, from, to time.Time) ([]time.Time, error) {
+	ctx, span := startSpan(ctx, "store.GetTimestamps", "dynamodb")
+	defer span.End()
+
+	items, err := ds.query(ctx, "log:"+key, from.UnixMicro(), to.UnixMicro(), time.Now())
+	if err != nil {
+		return nil, dynamoError(ctx, "get timestamps", err)
+	}
+
+	// Query returns items in sort key order, so the log comes out oldest first
+	timestamps := make([]time.Time, 0, len(items))
+	for _, item := range items {
+		sk, _ := intAttribute(item, dynamoSK)
+		count, _ := intAttribute(item, dynamoCount)
+		for i := int64(0); i < count; i++ {
+			timestamps = append(timestamps, time.UnixMicro(sk))
+		}
+	}
+	return timestamps, nil
+}
+
+// TrimTimestamps removes logged timestamps older than before
+func (ds *DynamoStore) TrimTimestamps(key string, before time.Time) error {
+	return ds.TrimTimestampsCtx(ds.ctx, key, before)
+}
+
+// TrimTimestampsCtx is TrimTimestamps with a context
+func (ds *DynamoStore) TrimTimestampsCtx(ctx context.Context, key string, before time.Time) error {
+	ctx, span := startSpan(ctx, "store.TrimTimestamps", "dynamodb")
+	defer span.End()
+
+	// Entries exactly at before are kept
+	if err := ds.deleteRange(ctx, "log:"+key, 0, before.UnixMicro()-1); err != nil {
+		return dynamoError(ctx, "trim timestamps", err)
+	}
+	return nil
+}
+
+// deleteRange deletes the items under pk with a sort key in [from, to], expired or not
+func (ds *DynamoStore) deleteRange(ctx context.Context, pk string, from, to int64) error {
+	// Expired items are deleted too, so query with a time that expires nothing
+	items, err := ds.query(ctx, pk, from, to, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	requests := make([]types.WriteRequest, 0, len(items))
+	for _, item := range items {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{
+				dynamoPK: item[dynamoPK],
+				dynamoSK: item[dynamoSK],
+			}},
+		})
+	}
+	return ds.batchWrite(ctx, requests)
+}
+
+// batchWrite sends requests in batches, resending whatever DynamoDB leaves unprocessed
+func (ds *DynamoStore) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	for len(requests) > 0 {
+		batch := requests[:min(len(requests), maxDynamoBatch)]
+		requests = requests[len(batch):]
+
+		out, err := ds.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{ds.table: batch},
+		})
+		if err != nil {
+			return err
+		}
+		requests = append(requests, out.UnprocessedItems[ds.table]...)
+	}
+	return nil
+}
+
+// Delete removes all data for a key
+func (ds *DynamoStore) Delete(key string) error {
+	return ds.DeleteCtx(ds.ctx, key)
+}
+
+// DeleteCtx is Delete with a context
+func (ds *DynamoStore) DeleteCtx(ctx context.Context, key string) error {
+	ctx, span := startSpan(ctx, "store.Delete", "dynamodb")
+	defer span.End()
+
+	for _, pk := range []string{"window:" + key, "log:" + key, "tokens:" + key} {
+		if err := ds.deleteRange(ctx, pk, 0, maxSortKey); err != nil {
+			return dynamoError(ctx, "delete", err)
+		}
+	}
+	return nil
+}
+
+// maxSortKey is above every window start and log timestamp a key can have
+const maxSortKey = 1<<63 - 1
+
+// Ping reports whether the table is reachable
+func (ds *DynamoStore) Ping(ctx context.Context) error {
+	_, err := ds.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(ds.table)})
+	if err != nil {
+		return dynamoError(ctx, "dynamodb ping", err)
+	}
+	return nil
+}
+
+// Close releases nothing; the DynamoDB client has no connection of its own to close
+func (ds *DynamoStore) Close() error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// Key prefixes for history counters, kept apart from limiter state so resets do not erase usage
+const (
+	historyAllowedPrefix   = "history:allowed:"
+	historyAttemptedPrefix = "history:attempted:"
+)
+
+// HistoryEntry holds the request counts for a key in one history window
+type HistoryEntry struct {
+	Start     time.Time `json:"start"`
+	Allowed   int64     `json:"allowed"`
+	Attempted int64     `json:"attempted"`
+}
+
+// History records per-window allowed and attempted request counts for usage reporting
+// Counts are kept as window counters in the underlying store, so retention follows
+// the store's window retention (24h for memory, the configured TTL for Redis)
+type History struct {
+	store  limiter.Store
+	window time.Duration
+}
+
+// NewHistory creates a history recorder bucketing requests into windows of the given size
+func NewHistory(store limiter.Store, window time.Duration) *History {
+	return &History{
+		store:  store,
+		window: window,
+	}
+}
+
+// Window returns the history window size
+func (h *History) Window() time.Duration {
+	return h.window
+}
+
+// Record counts one request for key at now, and counts it as allowed if it was
+func (h *History) Record(ctx context.Context, key string, allowed bool, now time.Time) error {
+	window := now.Truncate(h.window)
+
+	if _, err := h.store.IncrementCtx(ctx, historyAttemptedPrefix+key, window); err != nil {
+		return fmt.Errorf("failed to record attempt: %w", err)
+	}
+	if allowed {
+		if _, err := h.store.IncrementCtx(ctx, historyAllowedPrefix+key, window); err != nil {
+			return fmt.Errorf("failed to record allow: %w", err)
+		}
+	}
+	return nil
+}
+
+// Query returns the recorded windows for key overlapping [from, to], oldest first
+// Windows with no requests are omitted
+func (h *History) Query(ctx context.Context, key string, from, to time.Time) ([]HistoryEntry, error) {
+	from = from.Truncate(h.window)
+
+	attempted, err := h.store.GetWindowsCtx(ctx, historyAttemptedPrefix+key, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attempts: %w", err)
+	}
+	allowed, err := h.store.GetWindowsCtx(ctx, historyAllowedPrefix+key, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allows: %w", err)
+	}
+
+	// Stores may return windows with differing time locations, so index by Unix time
+	entries := make(map[int64]*HistoryEntry, len(attempted))
+	entry := func(t time.Time) *HistoryEntry {
+		e, ok := entries[t.UnixNano()]
+		if !ok {
+			e = &HistoryEntry{Start: t}
+			entries[t.UnixNano()] = e
+		}
+		return e
+	}
+	for _, w := range attempted {
+		entry(w.Timestamp).Attempted = w.Count
+	}
+	for _, w := range allowed {
+		entry(w.Timestamp).Allowed = w.Count
+	}
+
+	history := make([]HistoryEntry, 0, len(entries))
+	for _, e := range entries {
+		history = append(history, *e)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Start.Before(history[j].Start)
+	})
+	return history, nil
+}
@@ -0,0 +1,20 @@
+package store
+
+import "time"
+
+// OperationMetrics records how long store calls take
+type OperationMetrics interface {
+	RecordStoreOperation(storeType, operation string, latency float64)
+}
+
+// timeOperation starts timing a store call and returns the func that records it, for the
+// caller to defer. Nothing is timed without metrics
+func timeOperation(m OperationMetrics, storeType, operation string) func() {
+	if m == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		m.RecordStoreOperation(storeType, operation, time.Since(start).Seconds())
+	}
+}
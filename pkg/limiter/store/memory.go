@@ -0,0 +1,1214 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// MemoryStore implements an in-memory store for rate limiting
+// Good for single-instance deployments and testing
+// Uses sync.Map for concurrent access
+type MemoryStore struct {
+	// counters stores window-based counters (for fixed/sliding window)
+	counters sync.Map // map[string]map[time.Time]int64
+
+	// tokens stores token bucket state
+	tokens sync.Map // map[string]*tokenState
+
+	// logs stores request timestamps (for sliding window log)
+	logs sync.Map // map[string]*timestampLog
+
+	// quotas stores long-lived usage counters (for quota limits)
+	quotas sync.Map // map[string]*quotaCount
+
+	// penalties stores repeat offender state (for penalty policies)
+	penalties sync.Map // map[string]*penaltyState
+
+	// bans stores denial counts and temporary bans (for auto-bans)
+	bans sync.Map // map[string]*banState
+
+	// limits stores per-key limit overrides set at runtime
+	limits sync.Map // map[string]limitEntry
+
+	// groups maps identifiers onto the limit groups they share
+	groups sync.Map // map[string]string
+
+	// requests stores responses by request ID, for replaying retried requests
+	requests sync.Map // map[string]*requestEntry
+
+	// reservations stores two-phase reservations by ID until they are taken back
+	reservations   map[string]limiter.Reservation
+	reservationsMu sync.Mutex
+
+	// ops remembers recent mutating calls made under an operation ID
+	ops *operationLog
+
+	// prefix namespaces every key, as RedisConfig.KeyPrefix does for Redis
+	prefix string
+
+	// metrics times store calls (optional)
+	metrics OperationMetrics
+
+	// mu protects cleanup operations
+	mu sync.RWMutex
+
+	// stop ends the cleanup goroutine on Close
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+type tokenState struct {
+	tokens     float64
+	lastRefill time.Time
+	mu         sync.RWMutex
+}
+
+type windowCounts struct {
+	data   map[time.Time]int64
+	retain time.Duration // Longest span of windows a caller has asked to keep
+	mu     sync.RWMutex
+}
+
+type timestampLog struct {
+	entries   []time.Time // Sorted oldest first
+	expiresAt time.Time   // Wall time after which the log is gone, like a Redis TTL
+	mu        sync.RWMutex
+}
+
+type quotaCount struct {
+	used      int64
+	expiresAt time.Time // Wall time after which the counter is gone, like a Redis expiry
+	mu        sync.Mutex
+}
+
+type penaltyState struct {
+	offenses  int       // Denials counted since start
+	start     time.Time // When the current count began
+	level     int
+	until     time.Time // When the current penalty ends
+	expiresAt time.Time // Wall time after which the state is gone, like a Redis expiry
+	mu        sync.Mutex
+}
+
+type banState struct {
+	denials   int       // Denials counted since start
+	start     time.Time // When the current count began
+	until     time.Time // When the current ban ends
+	expiresAt time.Time // Wall time after which the state is gone, like a Redis expiry
+	mu        sync.Mutex
+}
+
+type limitEntry struct {
+	override  limiter.LimitOverride
+	expiresAt time.Time // Wall time after which the override is gone (zero = never), like a Redis expiry
+}
+
+type requestEntry struct {
+	result    []byte    // Recorded response; nil while the claiming request runs
+	expiresAt time.Time // Wall time after which the ID is forgotten, like a Redis expiry
+}
+
+// live reports whether the override still applies at now
+func (le limitEntry) live(now time.Time) bool {
+	if le.expiresAt.IsZero() {
+		return true
+	}
+	return time.Now().Before(le.expiresAt) && now.Before(le.override.ExpiresAt)
+}
+
+// live returns the log's entries, or none once it has expired
+// Callers must hold tl.mu
+func (tl *timestampLog) live() []time.Time {
+	if time.Now().After(tl.expiresAt) {
+		return nil
+	}
+	return tl.entries
+}
+
+// MemoryOption configures a MemoryStore
+type MemoryOption func(*MemoryStore)
+
+// WithKeyPrefix prepends prefix (e.g. "prod:") to every key the store keeps
+func WithKeyPrefix(prefix string) MemoryOption {
+	return func(ms *MemoryStore) {
+		ms.prefix = prefix
+	}
+}
+
+// WithStoreMetrics records the latency of the store's calls under store type "memory"
+func WithStoreMetrics(m OperationMetrics) MemoryOption {
+	return func(ms *MemoryStore) {
+		ms.metrics = m
+	}
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore(opts ...MemoryOption) *MemoryStore {
+	ms := &MemoryStore{
+		ops:          newOperationLog(DefaultOperationTTL),
+		reservations: make(map[string]limiter.Reservation),
+		stop:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(ms)
+	}
+	// Start background cleanup goroutine
+	go ms.cleanup()
+	return ms
+}
+
+// Increment increments the counter for a key at a specific window
+func (ms *MemoryStore) Increment(key string, window time.Time) (int64, error) {
+	return ms.IncrementCtx(context.Background(), key, window)
+}
+
+// IncrementCtx is Increment with a context
+func (ms *MemoryStore) IncrementCtx(ctx context.Context, key string, window time.Time) (int64, error) {
+	return ms.IncrementByCtx(ctx, key, window, 1)
+}
+
+// IncrementBy adds delta to the counter for a key at a specific window
+func (ms *MemoryStore) IncrementBy(key string, window time.Time, delta int64) (int64, error) {
+	return ms.IncrementByCtx(context.Background(), key, window, delta)
+}
+
+// IncrementByCtx is IncrementBy with a context
+// Under an operation ID a repeated increment returns the first count instead of counting again
+func (ms *MemoryStore) IncrementByCtx(ctx context.Context, key string, window time.Time, delta int64) (int64, error) {
+	defer ms.timed("increment")()
+	key = ms.prefix + key
+	ctx, span := startSpan(ctx, "store.Increment", "memory")
+	defer span.End()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return do(ms.ops, "window:"+key, operationStep(ctx, "increment", int(delta)), func() int64 {
+		return ms.increment(key, window, delta, 0)
+	}), nil
+}
+
+// IncrementPruneCtx is IncrementByCtx, also dropping windows that started more than retain
+// before window. The key's windows are kept by cleanup for at least retain
+func (ms *MemoryStore) IncrementPruneCtx(ctx context.Context, key string, window time.Time, delta int64, retain time.Duration) (int64, error) {
+	defer ms.timed("increment")()
+	key = ms.prefix + key
+	ctx, span := startSpan(ctx, "store.Increment", "memory")
+	defer span.End()
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return do(ms.ops, "window:"+key, operationStep(ctx, "increment", int(delta)), func() int64 {
+		return ms.increment(key, window, delta, retain)
+	}), nil
+}
+
+// increment adds delta to a window's counter
+// A positive retain drops windows older than it and extends how long cleanup keeps them
+func (ms *MemoryStore) increment(key string, window time.Time, delta int64, retain time.Duration) int64 {
+	// Load or create window counts for this key
+	val, _ := ms.counters.LoadOrStore(key, &windowCounts{
+		data: make(map[time.Time]int64),
+	})
+
+	wc := val.(*windowCounts)
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	wc.prune(window, retain)
+	wc.data[window] += delta
+	return wc.data[window]
+}
+
+// prune drops windows that started more than retain before window and extends how long
+// cleanup keeps them; a zero retain keeps everything. Callers must hold wc.mu
+func (wc *windowCounts) prune(window time.Time, retain time.Duration) {
+	if retain <= 0 {
+		return
+	}
+	cutoff := window.Add(-retain)
+	for t := range wc.data {
+		if t.Before(cutoff) {
+			delete(wc.data, t)
+		}
+	}
+	wc.retain = max(wc.retain, retain)
+}
+
+// setWindow sets a window's counter to a count read from another store, pruning as increment does
+func (ms *MemoryStore) setWindow(key string, window time.Time, count int64, retain time.Duration) {
+	val, _ := ms.counters.LoadOrStore(ms.prefix+key, &windowCounts{
+		data: make(map[time.Time]int64),
+	})
+
+	wc := val.(*windowCounts)
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	wc.prune(window, retain)
+	wc.data[window] = count
+}
+
+// replaceWindows replaces all of a key's windows with ones read from another store
+func (ms *MemoryStore) replaceWindows(key string, windows []limiter.Window) {
+	wc := &windowCounts{data: make(map[time.Time]int64, len(windows))}
+	for _, w := range windows {
+		wc.data[w.Timestamp] = w.Count
+	}
+	ms.counters.Store(ms.prefix+key, wc)
+}
+
+// deleteWindows removes a key's windows, leaving its other data
+func (ms *MemoryStore) deleteWindows(key string) {
+	ms.counters.Delete(ms.prefix + key)
+}
+
+// deleteTokens removes a key's token bucket, leaving its other data
+func (ms *MemoryStore) deleteTokens(key string) {
+	ms.tokens.Delete(ms.prefix + key)
+}
+
+// GetWindows returns all windows for a key within a time range
+func (ms *MemoryStore) GetWindows(key string, from, to time.Time) ([]limiter.Window, error) {
+	return ms.GetWindowsCtx(context.Background(), key, from, to)
+}
+
+// GetWindowsCtx is GetWindows with a context
+func (ms *MemoryStore) GetWindowsCtx(ctx context.Context, key string, from, to time.Time) ([]limiter.Window, error) {
+	defer ms.timed("get_windows")()
+	key = ms.prefix + key
+	ctx, span := startSpan(ctx, "store.GetWindows", "memory")
+	defer span.End()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	val, ok := ms.counters.Load(key)
+	if !ok {
+		return []limiter.Window{}, nil
+	}
+
+	wc := val.(*windowCounts)
+	wc.mu.RLock()
+	defer wc.mu.RUnlock()
+
+	windows := make([]limiter.Window, 0)
+	for t, count := range wc.data {
+		if (t.Equal(from) || t.After(from)) && (t.Equal(to) || t.Before(to)) {
+			windows = append(windows, limiter.Window{
+				Timestamp: t,
+				Count:     count,
+			})
+		}
+	}
+
+	return windows, nil
+}
+
+// SetTokens sets the token count and last refill time for token bucket
+func (ms *MemoryStore) SetTokens(key string, tokens float64, lastRefill time.Time) error {
+	return ms.SetTokensCtx(context.Background(), key, tokens, lastRefill)
+}
+
+// SetTokensCtx is SetTokens with a context
+func (ms *MemoryStore) SetTokensCtx(ctx context.Context, key string, tokens float64, lastRefill time.Time) error {
+	defer ms.timed("set_tokens")()
+	key = ms.prefix + key
+	ctx, span := startSpan(ctx, "store.SetTokens", "memory")
+	defer span.End()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	val, _ := ms.tokens.LoadOrStore(key, &tokenState{})
+	ts := val.(*tokenState)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.tokens = tokens
+	ts.lastRefill = lastRefill
+	return nil
+}
+
+// GetTokens gets the token count and last refill time for token bucket
+func (ms *MemoryStore) GetTokens(key string) (tokens float64, lastRefill time.Time, found bool, err error) {
+	return ms.GetTokensCtx(context.Background(), key)
+}
+
+// GetTokensCtx is GetTokens with a context
+// A bucket whose first step is still running has no refill time yet and is not found
+func (ms *MemoryStore) GetTokensCtx(ctx context.Context, key string) (tokens float64, lastRefill time.Time, found bool, err error) {
+	defer ms.timed("get_tokens")()
+	key = ms.prefix + key
+	ctx, span := startSpan(ctx, "store.GetTokens", "memory")
+	defer span.End()
+
+	if err := ctx.Err(); err != nil {
+		return 0, time.Time{}, false, err
+	}
+
+	val, ok := ms.tokens.Load(key)
+	if !ok {
+		return 0, time.Time{}, false, nil
+	}
+
+	ts := val.(*tokenState)
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	return ts.tokens, ts.lastRefill, !ts.lastRefill.IsZero(), nil
+}
+
+// ConsumeTokens atomically refills a token bucket and takes n tokens if available
+func (ms *MemoryStore) ConsumeTokens(key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (bool, float64, time.Duration, error) {
+	return ms.ConsumeTokensCtx(context.Background(), key, n, capacity, refillRate, initial, now)
+}
+
+// ConsumeTokensCtx is ConsumeTokens with a context
+// Under an operation ID a repeated consume replays the first result instead of taking tokens again
+func (ms *MemoryStore) ConsumeTokensCtx(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (bool, float64, time.Duration, error) {
+	ctx, span := startSpan(ctx, "store.ConsumeTokens", "memory")
+	defer span.End()
+
+	return ms.ConsumeTokensDebtCtx(ctx, key, n, capacity, refillRate, initial, 0, now)
+}
+
+// ConsumeTokensDebtCtx is ConsumeTokensCtx, letting the balance go as low as -maxDebt
+func (ms *MemoryStore) ConsumeTokensDebtCtx(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, maxDebt int, now time.Time) (bool, float64, time.Duration, error) {
+	defer ms.timed("consume_tokens")()
+	key = ms.prefix + key
+	if err := ctx.Err(); err != nil {
+		return false, 0, 0, err
+	}
+
+	result := do(ms.ops, "tokens:"+key, operationStep(ctx, "consume", n), func() tokenResult {
+		return ms.consumeTokens(key, n, capacity, refillRate, initial, maxDebt, now)
+	})
+	return result.Allowed, result.Tokens, result.RetryAfter, nil
+}
+
+// ConsumeTokensBatchCtx runs a token bucket step for each request in one pass
+func (ms *MemoryStore) ConsumeTokensBatchCtx(ctx context.Context, requests []limiter.TokenRequest, capacity int, refillRate float64, initial float64, now time.Time) ([]limiter.TokenResult, error) {
+	defer ms.timed("consume_tokens_batch")()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]limiter.TokenResult, len(requests))
+	for i, r := range requests {
+		key := ms.prefix + r.Key
+		result := do(ms.ops, "tokens:"+key, operationStep(ctx, "consume", r.N), func() tokenResult {
+			return ms.consumeTokens(key, r.N, capacity, refillRate, initial, 0, now)
+		})
+		results[i] = limiter.TokenResult{Allowed: result.Allowed, Tokens: result.Tokens, RetryAfter: result.RetryAfter}
+	}
+	return results, nil
+}
+
+// ConsumeTokensAllCtx runs a token bucket step for every request, or for none
+// The keys' locks are taken in sorted order, so atomic steps on overlapping keys cannot deadlock
+func (ms *MemoryStore) ConsumeTokensAllCtx(ctx context.Context, requests []limiter.TokenRequest, capacity int, refillRate float64, initial float64, now time.Time) (bool, []limiter.TokenResult, error) {
+	defer ms.timed("consume_tokens_all")()
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+
+	states := make([]*tokenState, len(requests))
+	order := make([]int, len(requests))
+	for i, r := range requests {
+		val, _ := ms.tokens.LoadOrStore(ms.prefix+r.Key, &tokenState{})
+		states[i] = val.(*tokenState)
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return requests[order[a]].Key < requests[order[b]].Key })
+	for _, i := range order {
+		states[i].mu.Lock()
+		defer states[i].mu.Unlock()
+	}
+
+	allowed := true
+	results := make([]limiter.TokenResult, len(requests))
+	next := make([]tokenState, len(requests))
+	for i, r := range requests {
+		ts := states[i]
+		ok, tokens, lastRefill, retryAfter := consumeTokens(ts.tokens, ts.lastRefill, !ts.lastRefill.IsZero(), r.N, capacity, refillRate, initial, 0, now)
+		results[i] = limiter.TokenResult{Allowed: ok, Tokens: tokens, RetryAfter: retryAfter}
+		next[i].tokens, next[i].lastRefill = tokens, lastRefill
+		allowed = allowed && ok
+	}
+	if !allowed {
+		// Report the balances the steps would have started from, with nothing taken
+		for i, r := range requests {
+			if results[i].Allowed {
+				results[i].Tokens += float64(r.N)
+			}
+		}
+		return false, results, nil
+	}
+
+	for i, ts := range states {
+		ts.tokens = next[i].tokens
+		ts.lastRefill = next[i].lastRefill
+	}
+	return true, results, nil
+}
+
+// consumeTokens refills a token bucket and takes n tokens if available
+func (ms *MemoryStore) consumeTokens(key string, n int, capacity int, refillRate float64, initial float64, maxDebt int, now time.Time) tokenResult {
+	val, _ := ms.tokens.LoadOrStore(key, &tokenState{})
+	ts := val.(*tokenState)
+
+	// Holding the state lock across read and write makes the step atomic
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	found := !ts.lastRefill.IsZero()
+	allowed, tokens, lastRefill, retryAfter := consumeTokens(ts.tokens, ts.lastRefill, found, n, capacity, refillRate, initial, maxDebt, now)
+
+	ts.tokens = tokens
+	ts.lastRefill = lastRefill
+	return tokenResult{Allowed: allowed, Tokens: tokens, RetryAfter: retryAfter}
+}
+
+// ConsumeMilliTokensCtx runs a fixed-point token bucket step, atomic under the key's lock
+// Milli-token balances are kept in the float token state, which holds them exactly
+func (ms *MemoryStore) ConsumeMilliTokensCtx(ctx context.Context, key string, n, capacity, limit int, window time.Duration, initial int64, now time.Time, dry bool) (bool, int64, time.Duration, error) {
+	defer ms.timed("consume_milli_tokens")()
+	key = ms.prefix + key
+	if err := ctx.Err(); err != nil {
+		return false, 0, 0, err
+	}
+
+	if dry {
+		val, ok := ms.tokens.Load(key)
+		if !ok {
+			allowed, tokens, _, retryAfter := consumeMilliTokens(0, time.Time{}, false, n, capacity, limit, window, initial, now)
+			return allowed, tokens, retryAfter, nil
+		}
+		ts := val.(*tokenState)
+		ts.mu.RLock()
+		defer ts.mu.RUnlock()
+
+		allowed, tokens, _, retryAfter := consumeMilliTokens(int64(ts.tokens), ts.lastRefill, !ts.lastRefill.IsZero(), n, capacity, limit, window, initial, now)
+		return allowed, tokens, retryAfter, nil
+	}
+
+	val, _ := ms.tokens.LoadOrStore(key, &tokenState{})
+	ts := val.(*tokenState)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	found := !ts.lastRefill.IsZero()
+	allowed, tokens, lastRefill, retryAfter := consumeMilliTokens(int64(ts.tokens), ts.lastRefill, found, n, capacity, limit, window, initial, now)
+
+	ts.tokens = float64(tokens)
+	ts.lastRefill = lastRefill
+	return allowed, tokens, retryAfter, nil
+}
+
+// AddTimestamps records n request timestamps for a key
+func (ms *MemoryStore) AddTimestamps(key string, ts time.Time, n int, ttl time.Duration) error {
+	return ms.AddTimestampsCtx(context.Background(), key, ts, n, ttl)
+}
+
+// AddTimestampsCtx is AddTimestamps with a context
+// Under an operation ID a repeated add records nothing more
+func (ms *MemoryStore) AddTimestampsCtx(ctx context.Context, key string, ts time.Time, n int, ttl time.Duration) error {
+	defer ms.timed("add_timestamps")()
+	key = ms.prefix + key
+	ctx, span := startSpan(ctx, "store.AddTimestamps", "memory")
+	defer span.End()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	do(ms.ops, "log:"+key, operationStep(ctx, "add", n), func() struct{} {
+		ms.addTimestamps(key, ts, n, ttl)
+		return struct{}{}
+	})
+	return nil
+}
+
+// addTimestamps inserts n copies of ts into a key's log
+func (ms *MemoryStore) addTimestamps(key string, ts time.Time, n int, ttl time.Duration) {
+	val, _ := ms.logs.LoadOrStore(key, &timestampLog{})
+	tl := val.(*timestampLog)
+
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	// An expired log starts over, as the key would have on Redis
+	tl.entries = tl.live()
+
+	// Entries normally arrive in order; insert at the right position if not
+	idx := sort.Search(len(tl.entries), func(i int) bool {
+		return tl.entries[i].After(ts)
+	})
+	added := make([]time.Time, n)
+	for i := range added {
+		added[i] = ts
+	}
+	tl.entries = append(tl.entries[:idx], append(added, tl.entries[idx:]...)...)
+	// Expiry follows wall time whatever clock ts came from, so cleanup and reads agree on it
+	tl.expiresAt = time.Now().Add(ttl)
+}
+
+// GetTimestamps returns the logged timestamps for a key within a time range, oldest first
+func (ms *MemoryStore) GetTimestamps(key string, from, to time.Time) ([]time.Time, error) {
+	return ms.GetTimestampsCtx(context.Background(), key, from, to)
+}
+
+// GetTimestampsCtx is GetTimestamps with a context
+func (ms *MemoryStore) GetTimestampsCtx(ctx context.Context, key string, from, to time.Time) ([]time.Time, error) {
+	defer ms.timed("get_timestamps")()
+	key = ms.prefix + key
+	ctx, span := startSpan(ctx, "store.GetTimestamps", "memory")
+	defer span.End()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	val, ok := ms.logs.Load(key)
+	if !ok {
+		return []time.Time{}, nil
+	}
+
+	tl := val.(*timestampLog)
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+
+	entries := tl.live()
+	timestamps := make([]time.Time, 0, len(entries))
+	for _, t := range entries {
+		if (t.Equal(from) || t.After(from)) && (t.Equal(to) || t.Before(to)) {
+			timestamps = append(timestamps, t)
+		}
+	}
+
+	return timestamps, nil
+}
+
+// TrimTimestamps removes logged timestamps older than before
+func (ms *MemoryStore) TrimTimestamps(key string, before time.Time) error {
+	return ms.TrimTimestampsCtx(context.Background(), key, before)
+}
+
+// TrimTimestampsCtx is TrimTimestamps with a context
+func (ms *MemoryStore) TrimTimestampsCtx(ctx context.Context, key string, before time.Time) error {
+	defer ms.timed("trim_timestamps")()
+	key = ms.prefix + key
+	ctx, span := startSpan(ctx, "store.TrimTimestamps", "memory")
+	defer span.End()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	val, ok := ms.logs.Load(key)
+	if !ok {
+		return nil
+	}
+
+	tl := val.(*timestampLog)
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	tl.trim(before)
+	return nil
+}
+
+// trim drops entries older than before
+// Callers must hold tl.mu for writing
+func (tl *timestampLog) trim(before time.Time) {
+	entries := tl.live()
+	idx := sort.Search(len(entries), func(i int) bool {
+		return !entries[i].Before(before)
+	})
+	tl.entries = append([]time.Time(nil), entries[idx:]...)
+}
+
+// CountTimestampsCtx removes logged timestamps older than from, then counts those up to to
+func (ms *MemoryStore) CountTimestampsCtx(ctx context.Context, key string, from, to time.Time) (int64, error) {
+	defer ms.timed("count_timestamps")()
+	key = ms.prefix + key
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	val, ok := ms.logs.Load(key)
+	if !ok {
+		return 0, nil
+	}
+
+	tl := val.(*timestampLog)
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	tl.trim(from)
+	count := sort.Search(len(tl.entries), func(i int) bool {
+		return tl.entries[i].After(to)
+	})
+	return int64(count), nil
+}
+
+// quotaResult is the outcome of one quota step, replayed for repeated operations
+type quotaResult struct {
+	Allowed bool
+	Used    int64
+}
+
+// AddQuotaCtx adds n to a quota counter if it stays within limit, atomic under the key's lock
+// Under an operation ID a repeated add replays the first result instead of counting again
+func (ms *MemoryStore) AddQuotaCtx(ctx context.Context, key string, n, limit int64, ttl time.Duration, dry bool) (bool, int64, error) {
+	defer ms.timed("add_quota")()
+	key = ms.prefix + key
+	if err := ctx.Err(); err != nil {
+		return false, 0, err
+	}
+
+	if dry {
+		var used int64
+		if val, ok := ms.quotas.Load(key); ok {
+			qc := val.(*quotaCount)
+			qc.mu.Lock()
+			if time.Now().Before(qc.expiresAt) {
+				used = qc.used
+			}
+			qc.mu.Unlock()
+		}
+		return n < 0 || used+n <= limit, used, nil
+	}
+
+	result := do(ms.ops, "quota:"+key, operationStep(ctx, "quota", int(n)), func() quotaResult {
+		return ms.addQuota(key, n, limit, ttl)
+	})
+	return result.Allowed, result.Used, nil
+}
+
+// addQuota adds n to a key's quota counter if it stays within limit
+func (ms *MemoryStore) addQuota(key string, n, limit int64, ttl time.Duration) quotaResult {
+	val, _ := ms.quotas.LoadOrStore(key, &quotaCount{})
+	qc := val.(*quotaCount)
+
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	// An expired counter starts over, as the key would have on Redis
+	if !time.Now().Before(qc.expiresAt) {
+		qc.used = 0
+	}
+
+	if n >= 0 && qc.used+n > limit {
+		return quotaResult{Allowed: false, Used: qc.used}
+	}
+	if n != 0 {
+		qc.used = max(0, qc.used+n)
+		// Expiry follows wall time whatever clock the period came from, as for timestamp logs
+		qc.expiresAt = time.Now().Add(ttl)
+	}
+	return quotaResult{Allowed: true, Used: qc.used}
+}
+
+// penaltyResult is the outcome of one penalty step, replayed for repeated operations
+type penaltyResult struct {
+	Level int
+	Until time.Time
+}
+
+// PenalizeCtx records a denial of key, escalating its penalty level, atomic under the key's lock
+// Under an operation ID a repeated denial replays the first result instead of counting again
+func (ms *MemoryStore) PenalizeCtx(ctx context.Context, key string, now time.Time, retryAfter time.Duration, policy limiter.PenaltyPolicy) (int, time.Time, error) {
+	key = ms.prefix + key
+	if err := ctx.Err(); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	result := do(ms.ops, "penalty:"+key, operationStep(ctx, "penalty", 1), func() penaltyResult {
+		return ms.penalize(key, now, retryAfter, policy)
+	})
+	return result.Level, result.Until, nil
+}
+
+// penalize records a denial of key at now
+func (ms *MemoryStore) penalize(key string, now time.Time, retryAfter time.Duration, policy limiter.PenaltyPolicy) penaltyResult {
+	val, _ := ms.penalties.LoadOrStore(key, &penaltyState{})
+	ps := val.(*penaltyState)
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	switch {
+	case !time.Now().Before(ps.expiresAt) || !now.Before(ps.until.Add(policy.Decay)):
+		// Quiet for long enough: the key starts over
+		ps.offenses, ps.start, ps.level, ps.until = 0, now, 0, time.Time{}
+	case now.Sub(ps.start) >= policy.Window:
+		ps.offenses, ps.start = 0, now
+	}
+
+	ps.offenses++
+	if ps.offenses > policy.Threshold {
+		ps.level = min(ps.level+1, policy.MaxLevel)
+		ps.offenses, ps.start = 0, now
+	}
+	if until := now.Add(retryAfter << ps.level); until.After(ps.until) {
+		ps.until = until
+	}
+	// Expiry follows wall time whatever clock the denial came from, as for timestamp logs
+	ps.expiresAt = time.Now().Add(ps.until.Sub(now) + policy.Decay)
+	return penaltyResult{Level: ps.level, Until: ps.until}
+}
+
+// PenaltyCtx returns key's penalty level and when its penalty ends
+func (ms *MemoryStore) PenaltyCtx(ctx context.Context, key string, now time.Time, decay time.Duration) (int, time.Time, error) {
+	key = ms.prefix + key
+	if err := ctx.Err(); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	val, ok := ms.penalties.Load(key)
+	if !ok {
+		return 0, time.Time{}, nil
+	}
+	ps := val.(*penaltyState)
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if !time.Now().Before(ps.expiresAt) || !now.Before(ps.until.Add(decay)) {
+		return 0, time.Time{}, nil
+	}
+	return ps.level, ps.until, nil
+}
+
+// RecordDenialCtx records a denial of key, banning it once it passes the policy's threshold,
+// atomic under the key's lock
+// Under an operation ID a repeated denial replays the first result instead of counting again
+func (ms *MemoryStore) RecordDenialCtx(ctx context.Context, key string, now time.Time, policy limiter.BanPolicy) (time.Time, error) {
+	key = ms.prefix + key
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	until := do(ms.ops, "ban:"+key, operationStep(ctx, "ban", 1), func() time.Time {
+		return ms.recordDenial(key, now, policy)
+	})
+	return until, nil
+}
+
+// recordDenial records a denial of key at now
+func (ms *MemoryStore) recordDenial(key string, now time.Time, policy limiter.BanPolicy) time.Time {
+	val, _ := ms.bans.LoadOrStore(key, &banState{})
+	bs := val.(*banState)
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if !time.Now().Before(bs.expiresAt) || now.Sub(bs.start) >= policy.Window {
+		bs.denials, bs.start = 0, now
+	}
+	if !now.Before(bs.until) {
+		bs.until = time.Time{}
+	}
+
+	bs.denials++
+	if bs.denials > policy.Threshold {
+		bs.until = now.Add(policy.Duration)
+		bs.denials, bs.start = 0, now
+	}
+	// Expiry follows wall time whatever clock the denial came from, as for timestamp logs
+	bs.expiresAt = time.Now().Add(max(bs.start.Add(policy.Window).Sub(now), bs.until.Sub(now)))
+	return bs.until
+}
+
+// BannedCtx returns when key's ban ends, or the zero time if it is not banned
+func (ms *MemoryStore) BannedCtx(ctx context.Context, key string, now time.Time) (time.Time, error) {
+	key = ms.prefix + key
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	val, ok := ms.bans.Load(key)
+	if !ok {
+		return time.Time{}, nil
+	}
+	bs := val.(*banState)
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if !time.Now().Before(bs.expiresAt) || !now.Before(bs.until) {
+		return time.Time{}, nil
+	}
+	return bs.until, nil
+}
+
+// UnbanCtx lifts key's ban and forgets its denials, reporting whether it was banned
+func (ms *MemoryStore) UnbanCtx(ctx context.Context, key string, now time.Time) (bool, error) {
+	until, err := ms.BannedCtx(ctx, key, now)
+	if err != nil {
+		return false, err
+	}
+	ms.bans.Delete(ms.prefix + key)
+	return !until.IsZero(), nil
+}
+
+// BansCtx returns every banned key and when its ban ends
+func (ms *MemoryStore) BansCtx(ctx context.Context, now time.Time) (map[string]time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	bans := make(map[string]time.Time)
+	wall := time.Now()
+	ms.bans.Range(func(key, val interface{}) bool {
+		bs := val.(*banState)
+		bs.mu.Lock()
+		if wall.Before(bs.expiresAt) && now.Before(bs.until) {
+			bans[strings.TrimPrefix(key.(string), ms.prefix)] = bs.until
+		}
+		bs.mu.Unlock()
+		return true
+	})
+	return bans, nil
+}
+
+// SetLimitOverrideCtx sets key's limit override, replacing any it had
+func (ms *MemoryStore) SetLimitOverrideCtx(ctx context.Context, key string, override limiter.LimitOverride, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entry := limitEntry{override: override}
+	if !override.ExpiresAt.IsZero() {
+		// Expiry follows wall time whatever clock the override came from, as for bans
+		entry.expiresAt = time.Now().Add(override.ExpiresAt.Sub(now))
+	}
+	ms.limits.Store(ms.prefix+key, entry)
+	return nil
+}
+
+// LimitOverrideCtx returns key's limit override, if it has one at now
+func (ms *MemoryStore) LimitOverrideCtx(ctx context.Context, key string, now time.Time) (limiter.LimitOverride, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return limiter.LimitOverride{}, false, err
+	}
+
+	val, ok := ms.limits.Load(ms.prefix + key)
+	if !ok || !val.(limitEntry).live(now) {
+		return limiter.LimitOverride{}, false, nil
+	}
+	return val.(limitEntry).override, true, nil
+}
+
+// DeleteLimitOverrideCtx removes key's limit override, reporting whether it had one at now
+func (ms *MemoryStore) DeleteLimitOverrideCtx(ctx context.Context, key string, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	val, ok := ms.limits.LoadAndDelete(ms.prefix + key)
+	return ok && val.(limitEntry).live(now), nil
+}
+
+// SetKeyGroupCtx puts identifier in group, replacing any group it was in
+func (ms *MemoryStore) SetKeyGroupCtx(ctx context.Context, identifier, group string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ms.groups.Store(ms.prefix+identifier, group)
+	return nil
+}
+
+// KeyGroupCtx returns the group identifier is in, if any
+func (ms *MemoryStore) KeyGroupCtx(ctx context.Context, identifier string) (string, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+
+	val, ok := ms.groups.Load(ms.prefix + identifier)
+	if !ok {
+		return "", false, nil
+	}
+	return val.(string), true, nil
+}
+
+// DeleteKeyGroupCtx takes identifier out of its group, reporting whether it was in one
+func (ms *MemoryStore) DeleteKeyGroupCtx(ctx context.Context, identifier string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	_, ok := ms.groups.LoadAndDelete(ms.prefix + identifier)
+	return ok, nil
+}
+
+// ClaimRequestCtx claims id for ttl, or returns the result recorded for it if it is claimed
+func (ms *MemoryStore) ClaimRequestCtx(ctx context.Context, id string, ttl time.Duration) (bool, []byte, error) {
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+
+	key := ms.prefix + id
+	claim := &requestEntry{expiresAt: time.Now().Add(ttl)}
+	for {
+		val, loaded := ms.requests.LoadOrStore(key, claim)
+		if !loaded {
+			return true, nil, nil
+		}
+		entry := val.(*requestEntry)
+		if time.Now().Before(entry.expiresAt) {
+			return false, entry.result, nil
+		}
+		// Forgotten, but not yet cleaned up; only one of several claims replaces it
+		if ms.requests.CompareAndSwap(key, entry, claim) {
+			return true, nil, nil
+		}
+	}
+}
+
+// RecordRequestCtx stores the result of the request that claimed id, kept for ttl
+func (ms *MemoryStore) RecordRequestCtx(ctx context.Context, id string, result []byte, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.requests.Store(ms.prefix+id, &requestEntry{result: result, expiresAt: time.Now().Add(ttl)})
+	return nil
+}
+
+// ReleaseRequestCtx forgets id
+func (ms *MemoryStore) ReleaseRequestCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.requests.Delete(ms.prefix + id)
+	return nil
+}
+
+// AddReservationCtx records r until it is taken back
+func (ms *MemoryStore) AddReservationCtx(ctx context.Context, r limiter.Reservation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ms.reservationsMu.Lock()
+	defer ms.reservationsMu.Unlock()
+	ms.reservations[r.ID] = r
+	return nil
+}
+
+// TakeReservationCtx removes and returns the reservation with id if it has not expired
+func (ms *MemoryStore) TakeReservationCtx(ctx context.Context, id string, now time.Time) (limiter.Reservation, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return limiter.Reservation{}, false, err
+	}
+
+	ms.reservationsMu.Lock()
+	defer ms.reservationsMu.Unlock()
+	r, ok := ms.reservations[id]
+	if !ok || !now.Before(r.ExpiresAt) {
+		return limiter.Reservation{}, false, nil
+	}
+	delete(ms.reservations, id)
+	return r, true, nil
+}
+
+// TakeExpiredReservationsCtx removes and returns up to limit reservations expired at now
+func (ms *MemoryStore) TakeExpiredReservationsCtx(ctx context.Context, now time.Time, limit int) ([]limiter.Reservation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ms.reservationsMu.Lock()
+	defer ms.reservationsMu.Unlock()
+	var expired []limiter.Reservation
+	for _, r := range ms.reservations {
+		if !now.Before(r.ExpiresAt) {
+			expired = append(expired, r)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ExpiresAt.Before(expired[j].ExpiresAt) })
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	for _, r := range expired {
+		delete(ms.reservations, r.ID)
+	}
+	return expired, nil
+}
+
+// Delete removes all data for a key
+func (ms *MemoryStore) Delete(key string) error {
+	return ms.DeleteCtx(context.Background(), key)
+}
+
+// DeleteCtx is Delete with a context
+func (ms *MemoryStore) DeleteCtx(ctx context.Context, key string) error {
+	defer ms.timed("delete")()
+	key = ms.prefix + key
+	ctx, span := startSpan(ctx, "store.Delete", "memory")
+	defer span.End()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ms.counters.Delete(key)
+	ms.tokens.Delete(key)
+	ms.logs.Delete(key)
+	ms.quotas.Delete(key)
+	ms.penalties.Delete(key)
+	ms.bans.Delete(key)
+	return nil
+}
+
+// DeletePrefixCtx deletes the state of every key starting with prefix, as DeleteCtx would,
+// returning how many keys had state
+func (ms *MemoryStore) DeletePrefixCtx(ctx context.Context, prefix string) (int, error) {
+	defer ms.timed("delete_prefix")()
+	if prefix == "" {
+		return 0, limiter.ErrEmptyPrefix
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	prefix = ms.prefix + prefix
+	deleted := make(map[string]struct{})
+	for _, m := range []*sync.Map{&ms.counters, &ms.tokens, &ms.logs, &ms.quotas, &ms.penalties, &ms.bans} {
+		m.Range(func(k, _ any) bool {
+			if key := k.(string); strings.HasPrefix(key, prefix) {
+				m.Delete(key)
+				deleted[key] = struct{}{}
+			}
+			return true
+		})
+	}
+	return len(deleted), nil
+}
+
+// Ping reports whether the store is reachable, which in-process memory always is
+func (ms *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close stops the background cleanup; the data stays readable. Closing twice is a no-op
+func (ms *MemoryStore) Close() error {
+	ms.closeOnce.Do(func() {
+		close(ms.stop)
+	})
+	return nil
+}
+
+// cleanup periodically removes old window data to prevent memory leaks
+func (ms *MemoryStore) cleanup() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ms.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// Remove windows older than 24 hours, or than the span a caller asked to keep
+		now := time.Now()
+
+		ms.counters.Range(func(key, val interface{}) bool {
+			wc := val.(*windowCounts)
+			wc.mu.Lock()
+			cutoff := now.Add(-max(24*time.Hour, wc.retain))
+			for t := range wc.data {
+				if t.Before(cutoff) {
+					delete(wc.data, t)
+				}
+			}
+			wc.mu.Unlock()
+			return true
+		})
+
+		// Remove timestamp logs that have not been written within their TTL
+		ms.logs.Range(func(key, val interface{}) bool {
+			tl := val.(*timestampLog)
+			tl.mu.RLock()
+			expired := now.After(tl.expiresAt)
+			tl.mu.RUnlock()
+			if expired {
+				ms.logs.Delete(key)
+			}
+			return true
+		})
+
+		// Remove quota counters whose period is over
+		ms.quotas.Range(func(key, val interface{}) bool {
+			qc := val.(*quotaCount)
+			qc.mu.Lock()
+			expired := !now.Before(qc.expiresAt)
+			qc.mu.Unlock()
+			if expired {
+				ms.quotas.Delete(key)
+			}
+			return true
+		})
+
+		// Remove offenders whose penalty has decayed
+		ms.penalties.Range(func(key, val interface{}) bool {
+			ps := val.(*penaltyState)
+			ps.mu.Lock()
+			expired := !now.Before(ps.expiresAt)
+			ps.mu.Unlock()
+			if expired {
+				ms.penalties.Delete(key)
+			}
+			return true
+		})
+
+		// Remove denial counts and bans that have run out
+		ms.bans.Range(func(key, val interface{}) bool {
+			bs := val.(*banState)
+			bs.mu.Lock()
+			expired := !now.Before(bs.expiresAt)
+			bs.mu.Unlock()
+			if expired {
+				ms.bans.Delete(key)
+			}
+			return true
+		})
+
+		// Remove limit overrides that have lapsed
+		ms.limits.Range(func(key, val interface{}) bool {
+			le := val.(limitEntry)
+			if !le.expiresAt.IsZero() && !now.Before(le.expiresAt) {
+				ms.limits.Delete(key)
+			}
+			return true
+		})
+
+		// Remove request IDs past their TTL
+		ms.requests.Range(func(key, val interface{}) bool {
+			if !now.Before(val.(*requestEntry).expiresAt) {
+				ms.requests.CompareAndDelete(key, val)
+			}
+			return true
+		})
+
+		ms.ops.expire(now)
+	}
+}
+
+// timed starts timing a call of operation; defer the returned func
+func (ms *MemoryStore) timed(operation string) func() {
+	return timeOperation(ms.metrics, "memory", operation)
+}
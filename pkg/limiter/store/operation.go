@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultOperationTTL is how long a mutating call's result is remembered for replay
+const DefaultOperationTTL = 10 * time.Second
+
+// DefaultOperationRetries is how many times a timed-out mutating call is retried
+const DefaultOperationRetries = 2
+
+// operationIDKey is the context key carrying an operation ID
+type operationIDKey struct{}
+
+// WithOperationID returns a context whose mutating store calls are applied at most once
+// for id: a repeated call with the same id and arguments replays the first result instead
+// of consuming again, for as long as the store remembers the operation
+func WithOperationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, operationIDKey{}, id)
+}
+
+// OperationID returns the operation ID carried by ctx, or "" if there is none
+func OperationID(ctx context.Context) string {
+	id, _ := ctx.Value(operationIDKey{}).(string)
+	return id
+}
+
+// NewOperationID returns a random operation ID
+func NewOperationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate operation ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RetryOperation runs fn, retrying up to retries more times while it fails with a timeout
+// Every attempt sees the same operation ID, taken from ctx or generated once, so an
+// attempt that executed but lost its response is replayed rather than applied again
+func RetryOperation(ctx context.Context, retries int, fn func(ctx context.Context) error) error {
+	if OperationID(ctx) == "" {
+		id, err := NewOperationID()
+		if err != nil {
+			return err
+		}
+		ctx = WithOperationID(ctx, id)
+	}
+
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if err = fn(ctx); err == nil || !isTimeout(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// isTimeout reports whether err is a network timeout, after which the call may or may not
+// have executed. The caller's own deadline is not one: it will not be met by retrying
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// operationStep names one mutating call within an operation
+// A single operation may touch a key more than once, e.g. consuming then refunding, so
+// the call and its amount are part of what is deduplicated
+func operationStep(ctx context.Context, call string, n int) string {
+	id := OperationID(ctx)
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s:%d", id, call, n)
+}
+
+// operationLog remembers the results of recent operations for the memory store
+type operationLog struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]operationEntry
+}
+
+type operationEntry struct {
+	result    any
+	expiresAt time.Time
+}
+
+// newOperationLog creates an operation log remembering results for ttl
+func newOperationLog(ttl time.Duration) *operationLog {
+	return &operationLog{ttl: ttl, entries: make(map[string]operationEntry)}
+}
+
+// do runs apply unless step was already applied, in which case its result is replayed
+// Steps of the same operation on one key are serialized so a retry racing the original
+// cannot apply twice. An empty step is not an operation and always applies
+func do[T any](ol *operationLog, key, step string, apply func() T) T {
+	if step == "" {
+		return apply()
+	}
+
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+
+	id := key + "\x00" + step
+	now := time.Now()
+	if entry, ok := ol.entries[id]; ok && now.Before(entry.expiresAt) {
+		return entry.result.(T)
+	}
+
+	result := apply()
+	ol.entries[id] = operationEntry{result: result, expiresAt: now.Add(ol.ttl)}
+	return result
+}
+
+// expire drops operations past their TTL
+func (ol *operationLog) expire(now time.Time) {
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+	for id, entry := range ol.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(ol.entries, id)
+		}
+	}
+}
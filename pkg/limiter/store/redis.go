@@ -0,0 +1,1339 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore implements a Redis-backed store for distributed rate limiting
+// Uses Lua scripts for atomic operations
+// Supports Redis Cluster for horizontal scaling
+type RedisStore struct {
+	client redis.UniversalClient
+	ctx    context.Context
+	ttl    time.Duration // TTL for keys to prevent memory leaks
+	prefix string        // Prepended to every key, so apps sharing a Redis can not collide
+
+	logGate   *ScriptGate // Gates the atomic sliding window log script
+	tokenGate *ScriptGate // Gates the atomic token bucket script
+
+	opRetries int           // Retries of a timed-out mutating script (0 = none)
+	opTTL     time.Duration // How long a mutating script's result is kept for replay
+
+	metrics RedisMetrics // Optional: counts failed Redis calls and times calls by operation
+
+	closeOnce sync.Once
+}
+
+// RedisMetrics records failed Redis calls, store call latency and the health of the
+// store's Lua script paths
+type RedisMetrics interface {
+	ScriptMetrics
+	OperationMetrics
+	RecordRedisError(operation string)
+}
+
+// RedisConfig holds Redis connection configuration
+type RedisConfig struct {
+	// Mode is how to reach Redis: RedisModeSingle, RedisModeCluster or RedisModeSentinel.
+	// Empty picks sentinel when SentinelMasterName is set, else single for one address
+	// and cluster for several
+	Mode      string
+	Addresses []string // Server (single) or seed node (cluster) addresses; unused under sentinel
+	Password  string
+	DB        int
+	PoolSize  int
+	TTL       time.Duration
+
+	// Sentinel mode asks these sentinels for the current master of SentinelMasterName and
+	// follows it across failovers
+	SentinelMasterName string
+	SentinelAddresses  []string
+
+	// KeyPrefix namespaces every key the store writes (e.g. "prod:" makes "prod:window:user"),
+	// so environments or apps sharing one Redis keep separate limits
+	KeyPrefix string
+
+	// Timeout is the deadline for each Redis command, on top of any deadline the caller's
+	// context already carries (0 = the caller's deadline only)
+	Timeout time.Duration
+
+	// Lua path rollout, keyed by ScriptPath* (missing = off)
+	ScriptModes          map[string]ScriptMode
+	ScriptErrorThreshold int           // Consecutive script errors before demotion to legacy
+	ScriptCooldown       time.Duration // Time on legacy before a demoted path is retried
+	Metrics              RedisMetrics
+
+	// Mutating scripts that time out are retried under one operation ID, which the script
+	// records for OperationTTL so an attempt that already ran is replayed, not reapplied
+	OperationRetries int           // Retries per mutating script (0 = DefaultOperationRetries, negative = none)
+	OperationTTL     time.Duration // How long results are kept for replay (0 = DefaultOperationTTL)
+}
+
+// Redis deployment modes for RedisConfig.Mode
+const (
+	RedisModeSingle   = "single"
+	RedisModeCluster  = "cluster"
+	RedisModeSentinel = "sentinel"
+)
+
+// ResolvedMode returns the mode config connects in, resolving an empty Mode
+func (config RedisConfig) ResolvedMode() string {
+	switch {
+	case config.Mode != "":
+		return config.Mode
+	case config.SentinelMasterName != "":
+		return RedisModeSentinel
+	case len(config.Addresses) == 1:
+		return RedisModeSingle
+	default:
+		return RedisModeCluster
+	}
+}
+
+// NewRedisClient creates the client for config's mode without connecting
+// Single and sentinel modes return a *redis.Client, cluster mode a *redis.ClusterClient
+func NewRedisClient(config RedisConfig) (redis.UniversalClient, error) {
+	switch mode := config.ResolvedMode(); mode {
+	case RedisModeSingle:
+		if len(config.Addresses) != 1 {
+			return nil, fmt.Errorf("redis mode %q needs exactly one address, got %d", mode, len(config.Addresses))
+		}
+		return redis.NewClient(&redis.Options{
+			Addr:                  config.Addresses[0],
+			Password:              config.Password,
+			DB:                    config.DB,
+			PoolSize:              config.PoolSize,
+			ContextTimeoutEnabled: true,
+		}), nil
+	case RedisModeCluster:
+		if len(config.Addresses) == 0 {
+			return nil, fmt.Errorf("redis mode %q needs at least one address", mode)
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:                 config.Addresses,
+			Password:              config.Password,
+			PoolSize:              config.PoolSize,
+			ContextTimeoutEnabled: true,
+		}), nil
+	case RedisModeSentinel:
+		if config.SentinelMasterName == "" {
+			return nil, fmt.Errorf("redis mode %q needs a sentinel master name", mode)
+		}
+		if len(config.SentinelAddresses) == 0 {
+			return nil, fmt.Errorf("redis mode %q needs at least one sentinel address", mode)
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:            config.SentinelMasterName,
+			SentinelAddrs:         config.SentinelAddresses,
+			Password:              config.Password,
+			DB:                    config.DB,
+			PoolSize:              config.PoolSize,
+			ContextTimeoutEnabled: true,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q (want %q, %q or %q)", mode, RedisModeSingle, RedisModeCluster, RedisModeSentinel)
+	}
+}
+
+// NewRedisStore creates a new Redis store
+func NewRedisStore(config RedisConfig) (*RedisStore, error) {
+	client, err := NewRedisClient(config)
+	if err != nil {
+		return nil, err
+	}
+	client.AddHook(contextHook{timeout: config.Timeout})
+
+	ctx := context.Background()
+
+	// Test connection
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	ttl := config.TTL
+	if ttl == 0 {
+		ttl = 24 * time.Hour // Default TTL
+	}
+
+	// Only enable Lua paths on servers that can run them
+	modes := config.ScriptModes
+	if len(modes) > 0 {
+		if err := ProbeScripts(ctx, client); err != nil {
+			log.Printf("Lua script probe failed, using legacy store paths: %v", err)
+			modes = nil
+		}
+	}
+
+	gate := func(path string) *ScriptGate {
+		return NewScriptGate(path, ScriptGateConfig{
+			Mode:           modes[path],
+			ErrorThreshold: config.ScriptErrorThreshold,
+			Cooldown:       config.ScriptCooldown,
+			Metrics:        config.Metrics,
+		})
+	}
+
+	opRetries := config.OperationRetries
+	if opRetries == 0 {
+		opRetries = DefaultOperationRetries
+	}
+	opTTL := config.OperationTTL
+	if opTTL == 0 {
+		opTTL = DefaultOperationTTL
+	}
+
+	return &RedisStore{
+		client:    client,
+		ctx:       ctx,
+		ttl:       ttl,
+		prefix:    config.KeyPrefix,
+		logGate:   gate(ScriptPathSlidingLog),
+		tokenGate: gate(ScriptPathTokenBucket),
+		opRetries: max(opRetries, 0),
+		opTTL:     opTTL,
+		metrics:   config.Metrics,
+	}, nil
+}
+
+// recordError counts a failed Redis call of operation
+func (rs *RedisStore) recordError(operation string) {
+	if rs.metrics != nil {
+		rs.metrics.RecordRedisError(operation)
+	}
+}
+
+// Lua script for atomic increment by a delta with expiry
+var incrementScript = redis.NewScript(opReplay + `
+	local key = KEYS[1]
+	local window = ARGV[1]
+	local ttl = tonumber(ARGV[2])
+	local delta = tonumber(ARGV[3])
+
+	local field = window
+	local count = redis.call('HINCRBY', key, field, delta)
+
+	if count == delta then
+		redis.call('EXPIRE', key, ttl)
+	end
+
+	local result = count
+` + opRecord)
+
+// Increment increments the counter for a key at a specific window
+func (rs *RedisStore) Increment(key string, window time.Time) (int64, error) {
+	return rs.IncrementCtx(rs.ctx, key, window)
+}
+
+// IncrementCtx is Increment with a context
+func (rs *RedisStore) IncrementCtx(ctx context.Context, key string, window time.Time) (int64, error) {
+	return rs.IncrementByCtx(ctx, key, window, 1)
+}
+
+// IncrementBy adds delta to the counter for a key at a specific window
+func (rs *RedisStore) IncrementBy(key string, window time.Time, delta int64) (int64, error) {
+	return rs.IncrementByCtx(rs.ctx, key, window, delta)
+}
+
+// IncrementByCtx is IncrementBy with a context
+func (rs *RedisStore) IncrementByCtx(ctx context.Context, key string, window time.Time, delta int64) (int64, error) {
+	defer rs.timed("increment")()
+	ctx, span := startSpan(ctx, "store.Increment", "redis")
+	defer span.End()
+
+	windowKey := fmt.Sprintf("%swindow:%s", rs.prefix, key)
+	windowStr := strconv.FormatInt(window.Unix(), 10)
+
+	var result interface{}
+	err := rs.retryOperation(ctx, func(ctx context.Context) (err error) {
+		result, err = incrementScript.Run(
+			ctx,
+			rs.client,
+			rs.scriptKeys(ctx, windowKey, "increment", int(delta)),
+			windowStr,
+			int(rs.ttl.Seconds()),
+			delta,
+			rs.opTTL.Milliseconds(),
+		).Result()
+		return err
+	})
+	if err != nil {
+		rs.recordError("increment")
+		return 0, fmt.Errorf("increment failed: %w", err)
+	}
+
+	count, ok := result.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected result type: %T", result)
+	}
+
+	return count, nil
+}
+
+// Lua script for atomic increment that also drops windows older than a retention span
+// Pruning scans the hash, so it only runs when a window's first request creates its field
+var incrementPruneScript = redis.NewScript(opReplay + `
+	local key = KEYS[1]
+	local window = ARGV[1]
+	local ttl = tonumber(ARGV[2])
+	local retain = tonumber(ARGV[3])
+	local delta = tonumber(ARGV[4])
+
+	local count = redis.call('HINCRBY', key, window, delta)
+
+	if count == delta then
+		local cutoff = tonumber(window) - retain
+		for _, field in ipairs(redis.call('HKEYS', key)) do
+			local t = tonumber(field)
+			if t and t < cutoff then
+				redis.call('HDEL', key, field)
+			end
+		end
+		redis.call('EXPIRE', key, math.max(ttl, retain))
+	end
+
+	local result = count
+` + opRecord)
+
+// IncrementPruneCtx is IncrementByCtx, also dropping windows that started more than retain
+// before window. The key's TTL is extended to retain if that is longer than the store TTL
+func (rs *RedisStore) IncrementPruneCtx(ctx context.Context, key string, window time.Time, delta int64, retain time.Duration) (int64, error) {
+	defer rs.timed("increment")()
+	ctx, span := startSpan(ctx, "store.Increment", "redis")
+	defer span.End()
+
+	windowKey := fmt.Sprintf("%swindow:%s", rs.prefix, key)
+	windowStr := strconv.FormatInt(window.Unix(), 10)
+
+	var result interface{}
+	err := rs.retryOperation(ctx, func(ctx context.Context) (err error) {
+		result, err = incrementPruneScript.Run(
+			ctx,
+			rs.client,
+			rs.scriptKeys(ctx, windowKey, "increment", int(delta)),
+			windowStr,
+			int(rs.ttl.Seconds()),
+			int(math.Ceil(retain.Seconds())),
+			delta,
+			rs.opTTL.Milliseconds(),
+		).Result()
+		return err
+	})
+	if err != nil {
+		rs.recordError("increment")
+		return 0, fmt.Errorf("increment failed: %w", err)
+	}
+
+	count, ok := result.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected result type: %T", result)
+	}
+
+	return count, nil
+}
+
+// GetWindows returns all windows for a key within a time range
+func (rs *RedisStore) GetWindows(key string, from, to time.Time) ([]limiter.Window, error) {
+	return rs.GetWindowsCtx(rs.ctx, key, from, to)
+}
+
+// GetWindowsCtx is GetWindows with a context
+func (rs *RedisStore) GetWindowsCtx(ctx context.Context, key string, from, to time.Time) ([]limiter.Window, error) {
+	defer rs.timed("get_windows")()
+	ctx, span := startSpan(ctx, "store.GetWindows", "redis")
+	defer span.End()
+
+	windowKey := fmt.Sprintf("%swindow:%s", rs.prefix, key)
+
+	// Get all fields and values from the hash
+	result, err := rs.client.HGetAll(ctx, windowKey).Result()
+	if err != nil {
+		rs.recordError("get_windows")
+		return nil, fmt.Errorf("failed to get windows: %w", err)
+	}
+
+	windows := make([]limiter.Window, 0)
+	for field, value := range result {
+		timestamp, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		t := time.Unix(timestamp, 0)
+		if (t.Equal(from) || t.After(from)) && (t.Equal(to) || t.Before(to)) {
+			count, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			windows = append(windows, limiter.Window{
+				Timestamp: t,
+				Count:     count,
+			})
+		}
+	}
+
+	return windows, nil
+}
+
+// SetTokens sets the token count and last refill time for token bucket
+func (rs *RedisStore) SetTokens(key string, tokens float64, lastRefill time.Time) error {
+	return rs.SetTokensCtx(rs.ctx, key, tokens, lastRefill)
+}
+
+// SetTokensCtx is SetTokens with a context
+func (rs *RedisStore) SetTokensCtx(ctx context.Context, key string, tokens float64, lastRefill time.Time) error {
+	defer rs.timed("set_tokens")()
+	ctx, span := startSpan(ctx, "store.SetTokens", "redis")
+	defer span.End()
+
+	tokenKey := fmt.Sprintf("%stokens:%s", rs.prefix, key)
+
+	pipe := rs.client.Pipeline()
+	pipe.HSet(ctx, tokenKey, "tokens", tokens)
+	pipe.HSet(ctx, tokenKey, "last_refill", lastRefill.Unix())
+	pipe.Expire(ctx, tokenKey, rs.ttl)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		rs.recordError("set_tokens")
+		return fmt.Errorf("failed to set tokens: %w", err)
+	}
+
+	return nil
+}
+
+// GetTokens gets the token count and last refill time for token bucket
+func (rs *RedisStore) GetTokens(key string) (tokens float64, lastRefill time.Time, found bool, err error) {
+	return rs.GetTokensCtx(rs.ctx, key)
+}
+
+// GetTokensCtx is GetTokens with a context
+func (rs *RedisStore) GetTokensCtx(ctx context.Context, key string) (tokens float64, lastRefill time.Time, found bool, err error) {
+	defer rs.timed("get_tokens")()
+	ctx, span := startSpan(ctx, "store.GetTokens", "redis")
+	defer span.End()
+
+	tokenKey := fmt.Sprintf("%stokens:%s", rs.prefix, key)
+
+	result, err := rs.client.HGetAll(ctx, tokenKey).Result()
+	if err != nil {
+		rs.recordError("get_tokens")
+		return 0, time.Time{}, false, fmt.Errorf("failed to get tokens: %w", err)
+	}
+
+	if len(result) == 0 {
+		return 0, time.Time{}, false, nil
+	}
+
+	tokensStr, ok := result["tokens"]
+	if ok {
+		tokens, _ = strconv.ParseFloat(tokensStr, 64)
+	}
+
+	lastRefillStr, ok := result["last_refill"]
+	if ok {
+		lastRefillUnix, _ := strconv.ParseInt(lastRefillStr, 10, 64)
+		lastRefill = time.Unix(lastRefillUnix, 0)
+	}
+
+	return tokens, lastRefill, true, nil
+}
+
+// maxMilliTokenRetries bounds optimistic transaction retries under contention
+const maxMilliTokenRetries = 10
+
+// ConsumeMilliTokensCtx runs a fixed-point token bucket step in a WATCH/MULTI transaction
+// Lua numbers are doubles, so the integer math runs here and the write is retried if the
+// key changed underneath it. The refill time is kept in nanoseconds so carried fractions survive
+func (rs *RedisStore) ConsumeMilliTokensCtx(ctx context.Context, key string, n, capacity, limit int, window time.Duration, initial int64, now time.Time, dry bool) (bool, int64, time.Duration, error) {
+	defer rs.timed("consume_milli_tokens")()
+	tokenKey := fmt.Sprintf("%stokens:%s", rs.prefix, key)
+
+	var allowed bool
+	var tokens int64
+	var retryAfter time.Duration
+	step := func(tx *redis.Tx) error {
+		result, err := tx.HMGet(ctx, tokenKey, "milli_tokens", "last_refill_ns").Result()
+		if err != nil {
+			return err
+		}
+
+		var lastRefill time.Time
+		found := result[0] != nil && result[1] != nil
+		if found {
+			tokens, _ = strconv.ParseInt(result[0].(string), 10, 64)
+			ns, _ := strconv.ParseInt(result[1].(string), 10, 64)
+			lastRefill = time.Unix(0, ns)
+		}
+
+		allowed, tokens, lastRefill, retryAfter = consumeMilliTokens(tokens, lastRefill, found, n, capacity, limit, window, initial, now)
+		if dry {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, tokenKey, "milli_tokens", tokens, "last_refill_ns", lastRefill.UnixNano())
+			pipe.Expire(ctx, tokenKey, rs.ttl)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxMilliTokenRetries; i++ {
+		err := rs.client.Watch(ctx, step, tokenKey)
+		if err == nil {
+			return allowed, tokens, retryAfter, nil
+		}
+		if !errors.Is(err, redis.TxFailedErr) {
+			return false, 0, 0, fmt.Errorf("failed to consume milli-tokens: %w", err)
+		}
+	}
+	return false, 0, 0, fmt.Errorf("failed to consume milli-tokens: too much contention on %s", key)
+}
+
+// Lua script for adding n timestamps to a sorted set with expiry
+// Scores are Unix microseconds, which float64 represents exactly
+var addTimestampsScript = redis.NewScript(opReplay + `
+	local key = KEYS[1]
+	local score = ARGV[1]
+	local n = tonumber(ARGV[2])
+	local nonce = ARGV[3]
+	local ttl = tonumber(ARGV[4])
+
+	for i = 1, n do
+		redis.call('ZADD', key, score, score .. '-' .. nonce .. '-' .. i)
+	end
+	redis.call('PEXPIRE', key, ttl)
+
+	local result = n
+` + opRecord)
+
+// AddTimestamps records n request timestamps for a key
+func (rs *RedisStore) AddTimestamps(key string, ts time.Time, n int, ttl time.Duration) error {
+	return rs.AddTimestampsCtx(rs.ctx, key, ts, n, ttl)
+}
+
+// AddTimestampsCtx is AddTimestamps with a context
+func (rs *RedisStore) AddTimestampsCtx(ctx context.Context, key string, ts time.Time, n int, ttl time.Duration) error {
+	defer rs.timed("add_timestamps")()
+	ctx, span := startSpan(ctx, "store.AddTimestamps", "redis")
+	defer span.End()
+
+	logKey := fmt.Sprintf("%slog:%s", rs.prefix, key)
+
+	// Members must be unique within the set, even for identical timestamps from other instances
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate member nonce: %w", err)
+	}
+
+	err := rs.retryOperation(ctx, func(ctx context.Context) error {
+		return addTimestampsScript.Run(
+			ctx,
+			rs.client,
+			rs.scriptKeys(ctx, logKey, "add", n),
+			ts.UnixMicro(),
+			n,
+			hex.EncodeToString(nonce),
+			ttl.Milliseconds(),
+			rs.opTTL.Milliseconds(),
+		).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("add timestamps failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetTimestamps returns the logged timestamps for a key within a time range, oldest first
+func (rs *RedisStore) GetTimestamps(key string, from, to time.Time) ([]time.Time, error) {
+	return rs.GetTimestampsCtx(rs.ctx, key, from, to)
+}
+
+// GetTimestampsCtx is GetTimestamps with a context
+func (rs *RedisStore) GetTimestampsCtx(ctx context.Context, key string, from, to time.Time) ([]time.Time, error) {
+	defer rs.timed("get_timestamps")()
+	ctx, span := startSpan(ctx, "store.GetTimestamps", "redis")
+	defer span.End()
+
+	logKey := fmt.Sprintf("%slog:%s", rs.prefix, key)
+
+	result, err := rs.client.ZRangeByScoreWithScores(ctx, logKey, &redis.ZRangeBy{
+		Min: strconv.FormatInt(from.UnixMicro(), 10),
+		Max: strconv.FormatInt(to.UnixMicro(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timestamps: %w", err)
+	}
+
+	timestamps := make([]time.Time, 0, len(result))
+	for _, z := range result {
+		timestamps = append(timestamps, time.UnixMicro(int64(z.Score)))
+	}
+
+	return timestamps, nil
+}
+
+// TrimTimestamps removes logged timestamps older than before
+func (rs *RedisStore) TrimTimestamps(key string, before time.Time) error {
+	return rs.TrimTimestampsCtx(rs.ctx, key, before)
+}
+
+// TrimTimestampsCtx is TrimTimestamps with a context
+func (rs *RedisStore) TrimTimestampsCtx(ctx context.Context, key string, before time.Time) error {
+	defer rs.timed("trim_timestamps")()
+	ctx, span := startSpan(ctx, "store.TrimTimestamps", "redis")
+	defer span.End()
+
+	logKey := fmt.Sprintf("%slog:%s", rs.prefix, key)
+
+	// "(" makes the upper bound exclusive so entries exactly at before are kept
+	max := "(" + strconv.FormatInt(before.UnixMicro(), 10)
+	if err := rs.client.ZRemRangeByScore(ctx, logKey, "-inf", max).Err(); err != nil {
+		return fmt.Errorf("failed to trim timestamps: %w", err)
+	}
+
+	return nil
+}
+
+// Lua script that trims a timestamp log and counts what is left in one step
+var countTimestampsScript = redis.NewScript(`
+	local key = KEYS[1]
+	redis.call('ZREMRANGEBYSCORE', key, '-inf', '(' .. ARGV[1])
+	return redis.call('ZCOUNT', key, ARGV[1], ARGV[2])
+`)
+
+// CountTimestampsCtx removes logged timestamps older than from, then counts those up to to
+// Trimming is idempotent, so a retried call needs no operation ID
+func (rs *RedisStore) CountTimestampsCtx(ctx context.Context, key string, from, to time.Time) (int64, error) {
+	defer rs.timed("count_timestamps")()
+	logKey := fmt.Sprintf("%slog:%s", rs.prefix, key)
+
+	count, err := countTimestampsScript.Run(
+		ctx,
+		rs.client,
+		[]string{logKey},
+		from.UnixMicro(),
+		to.UnixMicro(),
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count timestamps: %w", err)
+	}
+
+	return count, nil
+}
+
+// Lua script that adds to a quota counter if it stays within the limit
+// With dry set nothing is written. Returns {allowed, used}
+var addQuotaScript = redis.NewScript(opReplay + `
+	local key = KEYS[1]
+	local n = tonumber(ARGV[1])
+	local limit = tonumber(ARGV[2])
+	local ttl = tonumber(ARGV[3])
+	local dry = ARGV[4] == '1'
+
+	local used = tonumber(redis.call('GET', key) or '0')
+	local allowed = 0
+	if n < 0 or used + n <= limit then
+		allowed = 1
+		if not dry and n ~= 0 then
+			used = math.max(0, used + n)
+			redis.call('SET', key, used, 'PX', ttl)
+		end
+	end
+
+	local result = {allowed, used}
+` + opRecord)
+
+// AddQuotaCtx adds n to a quota counter if it stays within limit
+// Under an operation ID a repeated add replays the first result instead of counting again
+func (rs *RedisStore) AddQuotaCtx(ctx context.Context, key string, n, limit int64, ttl time.Duration, dry bool) (bool, int64, error) {
+	defer rs.timed("add_quota")()
+	quotaKey := fmt.Sprintf("%squota:%s", rs.prefix, key)
+
+	dryArg := "0"
+	if dry {
+		dryArg = "1"
+	}
+
+	var raw []interface{}
+	err := rs.retryOperation(ctx, func(ctx context.Context) (err error) {
+		// Dry runs write nothing, so there is nothing to deduplicate
+		keys := []string{quotaKey}
+		if !dry {
+			keys = rs.scriptKeys(ctx, quotaKey, "quota", int(n))
+		}
+		raw, err = addQuotaScript.Run(
+			ctx,
+			rs.client,
+			keys,
+			n,
+			limit,
+			max(ttl.Milliseconds(), 1),
+			dryArg,
+			rs.opTTL.Milliseconds(),
+		).Slice()
+		return err
+	})
+	if err != nil {
+		return false, 0, fmt.Errorf("quota update failed: %w", err)
+	}
+	if len(raw) != 2 {
+		return false, 0, fmt.Errorf("unexpected quota result length: %d", len(raw))
+	}
+
+	allowed, ok1 := raw[0].(int64)
+	used, ok2 := raw[1].(int64)
+	if !ok1 || !ok2 {
+		return false, 0, fmt.Errorf("unexpected quota result types: %T, %T", raw[0], raw[1])
+	}
+
+	return allowed == 1, used, nil
+}
+
+// Lua script that records a denial of a key, escalating its penalty level
+// Times are Unix milliseconds. Returns {level, until}
+var penalizeScript = redis.NewScript(opReplay + `
+	local key = KEYS[1]
+	local now = tonumber(ARGV[1])
+	local retry = tonumber(ARGV[2])
+	local threshold = tonumber(ARGV[3])
+	local window = tonumber(ARGV[4])
+	local maxLevel = tonumber(ARGV[5])
+	local decay = tonumber(ARGV[6])
+
+	local state = redis.call('HMGET', key, 'offenses', 'start', 'level', 'until')
+	local offenses = tonumber(state[1] or '0')
+	local start = tonumber(state[2] or '0')
+	local level = tonumber(state[3] or '0')
+	local untilMs = tonumber(state[4] or '0')
+
+	if now >= untilMs + decay then
+		offenses, start, level, untilMs = 0, now, 0, 0
+	elseif now - start >= window then
+		offenses, start = 0, now
+	end
+
+	offenses = offenses + 1
+	if offenses > threshold then
+		level = math.min(level + 1, maxLevel)
+		offenses, start = 0, now
+	end
+	untilMs = math.max(untilMs, now + retry * 2 ^ level)
+
+	redis.call('HSET', key, 'offenses', offenses, 'start', start, 'level', level, 'until', untilMs)
+	redis.call('PEXPIRE', key, math.max(untilMs - now + decay, 1))
+
+	local result = {level, untilMs}
+` + opRecord)
+
+// PenalizeCtx records a denial of key, escalating its penalty level
+// Under an operation ID a repeated denial replays the first result instead of counting again
+func (rs *RedisStore) PenalizeCtx(ctx context.Context, key string, now time.Time, retryAfter time.Duration, policy limiter.PenaltyPolicy) (int, time.Time, error) {
+	penaltyKey := fmt.Sprintf("%spenalty:%s", rs.prefix, key)
+
+	var raw []interface{}
+	err := rs.retryOperation(ctx, func(ctx context.Context) (err error) {
+		raw, err = penalizeScript.Run(
+			ctx,
+			rs.client,
+			rs.scriptKeys(ctx, penaltyKey, "penalty", 1),
+			now.UnixMilli(),
+			retryAfter.Milliseconds(),
+			policy.Threshold,
+			policy.Window.Milliseconds(),
+			policy.MaxLevel,
+			policy.Decay.Milliseconds(),
+			rs.opTTL.Milliseconds(),
+		).Slice()
+		return err
+	})
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("penalty update failed: %w", err)
+	}
+	return parsePenalty(raw)
+}
+
+// PenaltyCtx returns key's penalty level and when its penalty ends
+func (rs *RedisStore) PenaltyCtx(ctx context.Context, key string, now time.Time, decay time.Duration) (int, time.Time, error) {
+	penaltyKey := fmt.Sprintf("%spenalty:%s", rs.prefix, key)
+
+	state, err := rs.client.HMGet(ctx, penaltyKey, "level", "until").Result()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to get penalty: %w", err)
+	}
+	if state[0] == nil || state[1] == nil {
+		return 0, time.Time{}, nil
+	}
+
+	level, err1 := strconv.Atoi(state[0].(string))
+	untilMs, err2 := strconv.ParseInt(state[1].(string), 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to parse penalty: %v, %v", state[0], state[1])
+	}
+
+	until := time.UnixMilli(untilMs)
+	if !now.Before(until.Add(decay)) {
+		return 0, time.Time{}, nil
+	}
+	return level, until, nil
+}
+
+// parsePenalty reads the {level, until} result of the penalty script
+func parsePenalty(raw []interface{}) (int, time.Time, error) {
+	if len(raw) != 2 {
+		return 0, time.Time{}, fmt.Errorf("unexpected penalty result length: %d", len(raw))
+	}
+	level, ok1 := raw[0].(int64)
+	untilMs, ok2 := raw[1].(int64)
+	if !ok1 || !ok2 {
+		return 0, time.Time{}, fmt.Errorf("unexpected penalty result types: %T, %T", raw[0], raw[1])
+	}
+	return int(level), time.UnixMilli(untilMs), nil
+}
+
+// Lua script that records a denial of a key, banning it once it passes the threshold
+// Times are Unix milliseconds. Returns until (0 if the key is not banned)
+var recordDenialScript = redis.NewScript(opReplay + `
+	local key = KEYS[1]
+	local now = tonumber(ARGV[1])
+	local threshold = tonumber(ARGV[2])
+	local window = tonumber(ARGV[3])
+	local duration = tonumber(ARGV[4])
+
+	local state = redis.call('HMGET', key, 'denials', 'start', 'until')
+	local denials = tonumber(state[1] or '0')
+	local start = tonumber(state[2] or '0')
+	local untilMs = tonumber(state[3] or '0')
+
+	if now - start >= window then
+		denials, start = 0, now
+	end
+	if now >= untilMs then
+		untilMs = 0
+	end
+
+	denials = denials + 1
+	if denials > threshold then
+		untilMs = now + duration
+		denials, start = 0, now
+	end
+
+	redis.call('HSET', key, 'denials', denials, 'start', start, 'until', untilMs)
+	redis.call('PEXPIRE', key, math.max(start + window - now, untilMs - now, 1))
+
+	local result = untilMs
+` + opRecord)
+
+// banKeyPrefix namespaces denial counts and bans; BansCtx scans for it
+const banKeyPrefix = "ban:"
+
+// RecordDenialCtx records a denial of key, banning it once it passes the policy's threshold
+// Under an operation ID a repeated denial replays the first result instead of counting again
+func (rs *RedisStore) RecordDenialCtx(ctx context.Context, key string, now time.Time, policy limiter.BanPolicy) (time.Time, error) {
+	banKey := rs.prefix + banKeyPrefix + key
+
+	var untilMs int64
+	err := rs.retryOperation(ctx, func(ctx context.Context) (err error) {
+		untilMs, err = recordDenialScript.Run(
+			ctx,
+			rs.client,
+			rs.scriptKeys(ctx, banKey, "ban", 1),
+			now.UnixMilli(),
+			policy.Threshold,
+			policy.Window.Milliseconds(),
+			policy.Duration.Milliseconds(),
+			rs.opTTL.Milliseconds(),
+		).Int64()
+		return err
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ban update failed: %w", err)
+	}
+	return banUntil(untilMs, now), nil
+}
+
+// BannedCtx returns when key's ban ends, or the zero time if it is not banned
+func (rs *RedisStore) BannedCtx(ctx context.Context, key string, now time.Time) (time.Time, error) {
+	untilMs, err := rs.client.HGet(ctx, rs.prefix+banKeyPrefix+key, "until").Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get ban: %w", err)
+	}
+	return banUntil(untilMs, now), nil
+}
+
+// UnbanCtx lifts key's ban and forgets its denials, reporting whether it was banned
+func (rs *RedisStore) UnbanCtx(ctx context.Context, key string, now time.Time) (bool, error) {
+	banKey := rs.prefix + banKeyPrefix + key
+
+	pipe := rs.client.TxPipeline()
+	get := pipe.HGet(ctx, banKey, "until")
+	pipe.Del(ctx, banKey)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return false, fmt.Errorf("failed to unban: %w", err)
+	}
+
+	untilMs, err := get.Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to parse ban: %w", err)
+	}
+	return !banUntil(untilMs, now).IsZero(), nil
+}
+
+// BansCtx returns every banned key and when its ban ends
+// Keys are found with SCAN, on every master under Redis Cluster
+func (rs *RedisStore) BansCtx(ctx context.Context, now time.Time) (map[string]time.Time, error) {
+	bans := make(map[string]time.Time)
+	var mu sync.Mutex
+
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, escapeGlob(rs.prefix+banKeyPrefix)+"*", 100).Iterator()
+		for iter.Next(ctx) {
+			banKey := iter.Val()
+			untilMs, err := client.HGet(ctx, banKey, "until").Int64()
+			if errors.Is(err, redis.Nil) {
+				continue // Expired since the scan found it
+			}
+			if err != nil {
+				return err
+			}
+			if until := banUntil(untilMs, now); !until.IsZero() {
+				mu.Lock()
+				bans[strings.TrimPrefix(banKey, rs.prefix+banKeyPrefix)] = until
+				mu.Unlock()
+			}
+		}
+		return iter.Err()
+	}
+
+	var err error
+	if cluster, ok := rs.client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			return scan(ctx, master)
+		})
+	} else {
+		err = scan(ctx, rs.client)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bans: %w", err)
+	}
+	return bans, nil
+}
+
+// escapeGlob escapes the characters SCAN's MATCH treats as pattern syntax
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// banUntil converts a stored ban end to a time, zero unless it is still in force at now
+func banUntil(untilMs int64, now time.Time) time.Time {
+	until := time.UnixMilli(untilMs)
+	if untilMs == 0 || !now.Before(until) {
+		return time.Time{}
+	}
+	return until
+}
+
+// limitKeyPrefix namespaces per-key limit overrides
+const limitKeyPrefix = "limit:"
+
+// SetLimitOverrideCtx sets key's limit override, replacing any it had
+// The override's hash expires with it, so lapsed overrides leave nothing behind
+func (rs *RedisStore) SetLimitOverrideCtx(ctx context.Context, key string, override limiter.LimitOverride, now time.Time) error {
+	limitKey := rs.prefix + limitKeyPrefix + key
+
+	var expiresMs int64
+	if !override.ExpiresAt.IsZero() {
+		expiresMs = override.ExpiresAt.UnixMilli()
+	}
+
+	pipe := rs.client.TxPipeline()
+	pipe.HSet(ctx, limitKey,
+		"limit", override.Limit,
+		"window", int64(override.Window),
+		"burst", override.Burst,
+		"expires", expiresMs,
+	)
+	if expiresMs != 0 {
+		pipe.PExpire(ctx, limitKey, max(override.ExpiresAt.Sub(now), time.Millisecond))
+	} else {
+		pipe.Persist(ctx, limitKey)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to set limit override: %w", err)
+	}
+	return nil
+}
+
+// LimitOverrideCtx returns key's limit override, if it has one at now
+func (rs *RedisStore) LimitOverrideCtx(ctx context.Context, key string, now time.Time) (limiter.LimitOverride, bool, error) {
+	fields, err := rs.client.HGetAll(ctx, rs.prefix+limitKeyPrefix+key).Result()
+	if err != nil {
+		return limiter.LimitOverride{}, false, fmt.Errorf("failed to get limit override: %w", err)
+	}
+	return parseLimitOverride(fields, now)
+}
+
+// DeleteLimitOverrideCtx removes key's limit override, reporting whether it had one at now
+func (rs *RedisStore) DeleteLimitOverrideCtx(ctx context.Context, key string, now time.Time) (bool, error) {
+	limitKey := rs.prefix + limitKeyPrefix + key
+
+	pipe := rs.client.TxPipeline()
+	get := pipe.HGetAll(ctx, limitKey)
+	pipe.Del(ctx, limitKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to delete limit override: %w", err)
+	}
+
+	_, ok, err := parseLimitOverride(get.Val(), now)
+	return ok, err
+}
+
+// groupKeyPrefix namespaces identifiers' limit groups
+const groupKeyPrefix = "keygroup:"
+
+// SetKeyGroupCtx puts identifier in group, replacing any group it was in
+func (rs *RedisStore) SetKeyGroupCtx(ctx context.Context, identifier, group string) error {
+	if err := rs.client.Set(ctx, rs.prefix+groupKeyPrefix+identifier, group, 0).Err(); err != nil {
+		return fmt.Errorf("failed to set key group: %w", err)
+	}
+	return nil
+}
+
+// KeyGroupCtx returns the group identifier is in, if any
+func (rs *RedisStore) KeyGroupCtx(ctx context.Context, identifier string) (string, bool, error) {
+	group, err := rs.client.Get(ctx, rs.prefix+groupKeyPrefix+identifier).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get key group: %w", err)
+	}
+	return group, true, nil
+}
+
+// DeleteKeyGroupCtx takes identifier out of its group, reporting whether it was in one
+func (rs *RedisStore) DeleteKeyGroupCtx(ctx context.Context, identifier string) (bool, error) {
+	deleted, err := rs.client.Del(ctx, rs.prefix+groupKeyPrefix+identifier).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete key group: %w", err)
+	}
+	return deleted > 0, nil
+}
+
+// parseLimitOverride reads an override hash, reporting false if it is missing or lapsed at now
+func parseLimitOverride(fields map[string]string, now time.Time) (limiter.LimitOverride, bool, error) {
+	if len(fields) == 0 {
+		return limiter.LimitOverride{}, false, nil
+	}
+
+	var values [4]int64
+	for i, name := range []string{"limit", "window", "burst", "expires"} {
+		v, err := strconv.ParseInt(fields[name], 10, 64)
+		if err != nil {
+			return limiter.LimitOverride{}, false, fmt.Errorf("failed to parse limit override %s: %w", name, err)
+		}
+		values[i] = v
+	}
+
+	override := limiter.LimitOverride{
+		Limit:  int(values[0]),
+		Window: time.Duration(values[1]),
+		Burst:  int(values[2]),
+	}
+	if values[3] != 0 {
+		override.ExpiresAt = time.UnixMilli(values[3])
+		if !now.Before(override.ExpiresAt) {
+			return limiter.LimitOverride{}, false, nil
+		}
+	}
+	return override, true, nil
+}
+
+// requestKeyPrefix namespaces request IDs claimed for replay
+const requestKeyPrefix = "request:"
+
+// Lua script that claims request ID KEYS[1] for ARGV[1] milliseconds unless it is claimed
+// Returns nil when claimed, else the recorded result, empty while the claim is running
+var claimRequestScript = redis.NewScript(`
+	local result = redis.call('GET', KEYS[1])
+	if result then
+		return result
+	end
+	redis.call('SET', KEYS[1], '', 'PX', ARGV[1])
+	return false
+`)
+
+// ClaimRequestCtx claims id for ttl, or returns the result recorded for it if it is claimed
+// Claiming and reading run in one script, so two identical retries cannot both claim
+func (rs *RedisStore) ClaimRequestCtx(ctx context.Context, id string, ttl time.Duration) (bool, []byte, error) {
+	result, err := claimRequestScript.Run(ctx, rs.client, []string{rs.prefix + requestKeyPrefix + id}, max(ttl.Milliseconds(), 1)).Text()
+	if errors.Is(err, redis.Nil) {
+		return true, nil, nil
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to claim request: %w", err)
+	}
+	if result == "" {
+		return false, nil, nil
+	}
+	return false, []byte(result), nil
+}
+
+// RecordRequestCtx stores the result of the request that claimed id, kept for ttl
+func (rs *RedisStore) RecordRequestCtx(ctx context.Context, id string, result []byte, ttl time.Duration) error {
+	if err := rs.client.Set(ctx, rs.prefix+requestKeyPrefix+id, result, max(ttl, time.Millisecond)).Err(); err != nil {
+		return fmt.Errorf("failed to record request: %w", err)
+	}
+	return nil
+}
+
+// ReleaseRequestCtx forgets id
+func (rs *RedisStore) ReleaseRequestCtx(ctx context.Context, id string) error {
+	if err := rs.client.Del(ctx, rs.prefix+requestKeyPrefix+id).Err(); err != nil {
+		return fmt.Errorf("failed to release request: %w", err)
+	}
+	return nil
+}
+
+// Reservations are kept in two keys sharing a hash tag, so the scripts below can run
+// under Redis Cluster: a sorted set of IDs by expiry and a hash of IDs to their JSON
+const (
+	reservationExpiryKey = "{reservations}:expiry"
+	reservationDataKey   = "{reservations}:data"
+)
+
+// Lua script that takes back a reservation that has not expired
+// Returns its JSON, or nil for unknown, taken and expired reservations
+var takeReservationScript = redis.NewScript(`
+	local expires = redis.call('ZSCORE', KEYS[1], ARGV[1])
+	if not expires or tonumber(expires) <= tonumber(ARGV[2]) then
+		return false
+	end
+	local data = redis.call('HGET', KEYS[2], ARGV[1])
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	redis.call('HDEL', KEYS[2], ARGV[1])
+	return data
+`)
+
+// Lua script that takes back up to ARGV[2] reservations expired at ARGV[1]
+// Returns their JSON, soonest expired first
+var takeExpiredReservationsScript = redis.NewScript(`
+	local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+	local taken = {}
+	for i, id in ipairs(ids) do
+		taken[i] = redis.call('HGET', KEYS[2], id) or ''
+		redis.call('ZREM', KEYS[1], id)
+		redis.call('HDEL', KEYS[2], id)
+	end
+	return taken
+`)
+
+// reservationKeys returns the KEYS of the reservation scripts
+func (rs *RedisStore) reservationKeys() []string {
+	return []string{rs.prefix + reservationExpiryKey, rs.prefix + reservationDataKey}
+}
+
+// AddReservationCtx records r until it is taken back
+// Adding the same reservation again overwrites it, so a retried call needs no operation ID
+func (rs *RedisStore) AddReservationCtx(ctx context.Context, r limiter.Reservation) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode reservation: %w", err)
+	}
+
+	keys := rs.reservationKeys()
+	pipe := rs.client.TxPipeline()
+	pipe.ZAdd(ctx, keys[0], redis.Z{Score: float64(r.ExpiresAt.UnixMilli()), Member: r.ID})
+	pipe.HSet(ctx, keys[1], r.ID, data)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add reservation: %w", err)
+	}
+	return nil
+}
+
+// TakeReservationCtx removes and returns the reservation with id if it has not expired
+func (rs *RedisStore) TakeReservationCtx(ctx context.Context, id string, now time.Time) (limiter.Reservation, bool, error) {
+	data, err := takeReservationScript.Run(ctx, rs.client, rs.reservationKeys(), id, now.UnixMilli()).Text()
+	if errors.Is(err, redis.Nil) {
+		return limiter.Reservation{}, false, nil
+	}
+	if err != nil {
+		return limiter.Reservation{}, false, fmt.Errorf("failed to take reservation: %w", err)
+	}
+
+	var r limiter.Reservation
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		return limiter.Reservation{}, false, fmt.Errorf("failed to decode reservation: %w", err)
+	}
+	return r, true, nil
+}
+
+// TakeExpiredReservationsCtx removes and returns up to limit reservations expired at now
+func (rs *RedisStore) TakeExpiredReservationsCtx(ctx context.Context, now time.Time, limit int) ([]limiter.Reservation, error) {
+	taken, err := takeExpiredReservationsScript.Run(ctx, rs.client, rs.reservationKeys(), now.UnixMilli(), limit).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to take expired reservations: %w", err)
+	}
+
+	expired := make([]limiter.Reservation, 0, len(taken))
+	for _, data := range taken {
+		var r limiter.Reservation
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			continue // Its data was lost; there is nothing to return
+		}
+		expired = append(expired, r)
+	}
+	return expired, nil
+}
+
+// Delete removes all data for a key
+func (rs *RedisStore) Delete(key string) error {
+	return rs.DeleteCtx(rs.ctx, key)
+}
+
+// DeleteCtx is Delete with a context
+func (rs *RedisStore) DeleteCtx(ctx context.Context, key string) error {
+	defer rs.timed("delete")()
+	ctx, span := startSpan(ctx, "store.Delete", "redis")
+	defer span.End()
+
+	windowKey := fmt.Sprintf("%swindow:%s", rs.prefix, key)
+	tokenKey := fmt.Sprintf("%stokens:%s", rs.prefix, key)
+	logKey := fmt.Sprintf("%slog:%s", rs.prefix, key)
+	quotaKey := fmt.Sprintf("%squota:%s", rs.prefix, key)
+	penaltyKey := fmt.Sprintf("%spenalty:%s", rs.prefix, key)
+	banKey := rs.prefix + banKeyPrefix + key
+
+	pipe := rs.client.Pipeline()
+	pipe.Del(ctx, windowKey)
+	pipe.Del(ctx, tokenKey)
+	pipe.Del(ctx, logKey)
+	pipe.Del(ctx, quotaKey)
+	pipe.Del(ctx, penaltyKey)
+	pipe.Del(ctx, banKey)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		rs.recordError("delete")
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+
+	return nil
+}
+
+// stateKeyPrefixes are the kinds of key DeleteCtx removes, each followed by the limiter key
+var stateKeyPrefixes = []string{"window:", "tokens:", "log:", "quota:", "penalty:", banKeyPrefix}
+
+// DeletePrefixCtx deletes the state of every key starting with prefix, as DeleteCtx would,
+// returning how many keys had state
+// Keys are found with SCAN, on every master under Redis Cluster, and deleted one by one so
+// no command spans hash slots; keys written while it runs may survive
+func (rs *RedisStore) DeletePrefixCtx(ctx context.Context, prefix string) (int, error) {
+	defer rs.timed("delete_prefix")()
+	if prefix == "" {
+		return 0, limiter.ErrEmptyPrefix
+	}
+
+	deleted := make(map[string]struct{})
+	var mu sync.Mutex
+
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		for _, kind := range stateKeyPrefixes {
+			iter := client.Scan(ctx, 0, escapeGlob(rs.prefix+kind+prefix)+"*", 100).Iterator()
+			var keys []string
+			for iter.Next(ctx) {
+				keys = append(keys, iter.Val())
+			}
+			if err := iter.Err(); err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				continue
+			}
+
+			pipe := client.Pipeline()
+			dels := make([]*redis.IntCmd, len(keys))
+			for i, key := range keys {
+				dels[i] = pipe.Del(ctx, key)
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+
+			mu.Lock()
+			for i, key := range keys {
+				if dels[i].Val() > 0 {
+					deleted[strings.TrimPrefix(key, rs.prefix+kind)] = struct{}{}
+				}
+			}
+			mu.Unlock()
+		}
+		return nil
+	}
+
+	var err error
+	if cluster, ok := rs.client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			return scan(ctx, master)
+		})
+	} else {
+		err = scan(ctx, rs.client)
+	}
+	if err != nil {
+		rs.recordError("delete_prefix")
+		return 0, fmt.Errorf("failed to delete prefix: %w", err)
+	}
+	return len(deleted), nil
+}
+
+// Ping reports whether Redis is reachable
+func (rs *RedisStore) Ping(ctx context.Context) error {
+	if err := rs.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis ping failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Redis connection; closing twice is a no-op
+func (rs *RedisStore) Close() error {
+	var err error
+	rs.closeOnce.Do(func() {
+		err = rs.client.Close()
+	})
+	return err
+}
+
+// timed starts timing a call of operation; defer the returned func
+func (rs *RedisStore) timed(operation string) func() {
+	return timeOperation(rs.metrics, "redis", operation)
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/redis/go-redis/v9"
+)
+
+// contextHook makes Redis commands honor their caller's context
+// Commands are not sent once the context is done, and each runs under the store's timeout
+// (go-redis applies deadlines, not cancellation, to a command in flight). A command cut short
+// while the caller's context is done fails with an error wrapping ctx.Err(), and one that
+// failed for any other reason but a Redis reply wraps limiter.ErrStoreUnavailable, so
+// errors.Is can tell cancellation from an unreachable server. The underlying network
+// error is kept too, so operation retries still see timeouts
+type contextHook struct {
+	timeout time.Duration // Deadline for each command (0 = none beyond the caller's)
+}
+
+func (h contextHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h contextHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := ctx.Err(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+
+		callCtx, cancel := h.bound(ctx)
+		defer cancel()
+		return h.settle(ctx, next(callCtx, cmd), cmd)
+	}
+}
+
+func (h contextHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := ctx.Err(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+
+		callCtx, cancel := h.bound(ctx)
+		defer cancel()
+		return h.settle(ctx, next(callCtx, cmds), cmds...)
+	}
+}
+
+// bound applies the store's timeout to ctx
+func (h contextHook) bound(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, h.timeout)
+}
+
+// settle wraps a failed call's error, and its commands', in the reason they failed
+// Errors Redis replied with are left alone. Otherwise, a call cut short while the caller's
+// context is done wraps ctx.Err(), and any other failure wraps limiter.ErrStoreUnavailable
+func (h contextHook) settle(ctx context.Context, err error, cmds ...redis.Cmder) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil {
+			cmd.SetErr(wrapFailure(ctx, cmdErr))
+		}
+	}
+	return wrapFailure(ctx, err)
+}
+
+// wrapFailure wraps err in the reason a call failed; see settle
+func wrapFailure(ctx context.Context, err error) error {
+	var reply redis.Error
+	switch {
+	case errors.As(err, &reply):
+		return err
+	case ctx.Err() != nil:
+		return fmt.Errorf("%w: %w", ctx.Err(), err)
+	default:
+		return fmt.Errorf("%w: %w", limiter.ErrStoreUnavailable, err)
+	}
+}
@@ -0,0 +1,688 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/redis/go-redis/v9"
+)
+
+// Gated Lua paths, used as keys in RedisConfig.ScriptModes
+const (
+	ScriptPathSlidingLog  = "sliding_window_log"
+	ScriptPathTokenBucket = "token_bucket"
+)
+
+// minScriptRedisVersion is the oldest server the gated scripts are enabled on
+// Redis 5 replicates script effects rather than the script body by default
+const minScriptRedisVersion = "5.0.0"
+
+// probeScript checks that the server evaluates Lua at all
+var probeScript = redis.NewScript(`return 1`)
+
+// ProbeScripts checks that the server can run the gated Lua paths
+// It evaluates a trivial script and checks the server version
+func ProbeScripts(ctx context.Context, client redis.UniversalClient) error {
+	if err := probeScript.Run(ctx, client, nil).Err(); err != nil {
+		return fmt.Errorf("script evaluation failed: %w", err)
+	}
+
+	info, err := client.Info(ctx, "server").Result()
+	if err != nil {
+		return fmt.Errorf("failed to read server info: %w", err)
+	}
+
+	version := parseRedisVersion(info)
+	if version == "" {
+		return fmt.Errorf("server did not report redis_version")
+	}
+	if compareVersions(version, minScriptRedisVersion) < 0 {
+		return fmt.Errorf("redis %s is older than required %s", version, minScriptRedisVersion)
+	}
+
+	return nil
+}
+
+// parseRedisVersion extracts redis_version from INFO output
+func parseRedisVersion(info string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "redis_version:"); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// compareVersions compares dotted numeric versions, returning -1, 0 or 1
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Lua script for the sliding window log: trim, count and conditionally append in one step
+// With dry set nothing is written, which lets shadow mode compare against the legacy path
+// Returns {allowed, score...} with the in-window scores oldest first
+var slidingLogScript = redis.NewScript(opReplay + `
+	local key = KEYS[1]
+	local now = ARGV[1]
+	local windowStart = ARGV[2]
+	local limit = tonumber(ARGV[3])
+	local n = tonumber(ARGV[4])
+	local nonce = ARGV[5]
+	local ttl = tonumber(ARGV[6])
+	local dry = ARGV[7] == '1'
+
+	if not dry then
+		redis.call('ZREMRANGEBYSCORE', key, '-inf', '(' .. windowStart)
+	end
+
+	local scores = {}
+	local entries = redis.call('ZRANGEBYSCORE', key, windowStart, now, 'WITHSCORES')
+	for i = 2, #entries, 2 do
+		table.insert(scores, entries[i])
+	end
+
+	local allowed = #scores + n <= limit
+	if allowed and n > 0 then
+		for i = 1, n do
+			if not dry then
+				redis.call('ZADD', key, now, now .. '-' .. nonce .. '-' .. i)
+			end
+			table.insert(scores, now)
+		end
+		if not dry then
+			redis.call('PEXPIRE', key, ttl)
+		end
+	end
+
+	local result = {allowed and 1 or 0}
+	for _, score in ipairs(scores) do
+		table.insert(result, score)
+	end
+` + opRecord)
+
+// logResult is the outcome of a sliding window log check
+type logResult struct {
+	Allowed    bool
+	Timestamps []time.Time
+}
+
+// AllowLogCtx trims, counts and conditionally appends to a timestamp log
+// The Lua path does this atomically; the legacy path issues separate commands
+func (rs *RedisStore) AllowLogCtx(ctx context.Context, key string, now time.Time, window time.Duration, limit, n int) (bool, []time.Time, error) {
+	defer rs.timed("allow_log")()
+	legacy := func() (logResult, error) {
+		return rs.allowLogLegacy(ctx, key, now, window, limit, n)
+	}
+	script := func() (logResult, error) {
+		return rs.allowLogScript(ctx, key, now, window, limit, n, rs.logGate.Mode() == ScriptShadow)
+	}
+	equal := func(a, b logResult) bool {
+		return a.Allowed == b.Allowed && len(a.Timestamps) == len(b.Timestamps)
+	}
+
+	result, err := RunGated(rs.logGate, legacy, script, equal)
+	if err != nil {
+		return false, nil, err
+	}
+	return result.Allowed, result.Timestamps, nil
+}
+
+// allowLogLegacy checks the log with separate trim, range and add commands
+func (rs *RedisStore) allowLogLegacy(ctx context.Context, key string, now time.Time, window time.Duration, limit, n int) (logResult, error) {
+	windowStart := now.Add(-window)
+
+	if err := rs.TrimTimestampsCtx(ctx, key, windowStart); err != nil {
+		return logResult{}, err
+	}
+
+	timestamps, err := rs.GetTimestampsCtx(ctx, key, windowStart, now)
+	if err != nil {
+		return logResult{}, err
+	}
+
+	allowed := len(timestamps)+n <= limit
+	if allowed && n > 0 {
+		if err := rs.AddTimestampsCtx(ctx, key, now, n, window); err != nil {
+			return logResult{}, err
+		}
+		for i := 0; i < n; i++ {
+			timestamps = append(timestamps, now)
+		}
+	}
+
+	return logResult{Allowed: allowed, Timestamps: timestamps}, nil
+}
+
+// allowLogScript checks the log with slidingLogScript
+func (rs *RedisStore) allowLogScript(ctx context.Context, key string, now time.Time, window time.Duration, limit, n int, dry bool) (logResult, error) {
+	logKey := fmt.Sprintf("%slog:%s", rs.prefix, key)
+
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return logResult{}, fmt.Errorf("failed to generate member nonce: %w", err)
+	}
+
+	dryArg := "0"
+	if dry {
+		dryArg = "1"
+	}
+
+	var raw []interface{}
+	err := rs.retryOperation(ctx, func(ctx context.Context) (err error) {
+		// Dry runs write nothing, so there is nothing to deduplicate
+		keys := []string{logKey}
+		if !dry {
+			keys = rs.scriptKeys(ctx, logKey, "allow_log", n)
+		}
+
+		raw, err = slidingLogScript.Run(
+			ctx,
+			rs.client,
+			keys,
+			now.UnixMicro(),
+			now.Add(-window).UnixMicro(),
+			limit,
+			n,
+			hex.EncodeToString(nonce),
+			window.Milliseconds(),
+			dryArg,
+			rs.opTTL.Milliseconds(),
+		).Slice()
+		return err
+	})
+	if err != nil {
+		return logResult{}, fmt.Errorf("sliding log script failed: %w", err)
+	}
+	if len(raw) == 0 {
+		return logResult{}, fmt.Errorf("sliding log script returned no result")
+	}
+
+	allowed, ok := raw[0].(int64)
+	if !ok {
+		return logResult{}, fmt.Errorf("unexpected result type: %T", raw[0])
+	}
+
+	timestamps := make([]time.Time, 0, len(raw)-1)
+	for _, v := range raw[1:] {
+		score, err := strconv.ParseInt(fmt.Sprint(v), 10, 64)
+		if err != nil {
+			return logResult{}, fmt.Errorf("unexpected score %v: %w", v, err)
+		}
+		timestamps = append(timestamps, time.UnixMicro(score))
+	}
+
+	return logResult{Allowed: allowed == 1, Timestamps: timestamps}, nil
+}
+
+// Lua script for the token bucket: refill, check and take tokens in one step
+// last_refill stays in Unix seconds so GetTokens can read the same hash
+// With dry set nothing is written, which lets shadow mode compare against the legacy path
+// Returns {allowed, tokens, retryAfterMillis}; tokens is a string to keep its fraction
+var consumeTokensScript = redis.NewScript(opReplay + `
+	local key = KEYS[1]
+	local n = tonumber(ARGV[1])
+	local capacity = tonumber(ARGV[2])
+	local rate = tonumber(ARGV[3])
+	local initial = tonumber(ARGV[4])
+	local now = tonumber(ARGV[5])
+	local ttl = tonumber(ARGV[6])
+	local dry = ARGV[7] == '1'
+	local maxDebt = tonumber(ARGV[8])
+
+	local state = redis.call('HMGET', key, 'tokens', 'last_refill')
+	local tokens = tonumber(state[1])
+	local last = tonumber(state[2])
+	if last == nil then
+		tokens = initial
+		last = now
+	elseif tokens == nil then
+		tokens = 0
+	end
+
+	-- A step dated before the last refill uses the stored balance and keeps its time
+	local elapsed = now - last
+	if elapsed > 0 then
+		tokens = tokens + elapsed * rate
+		last = now
+	end
+	tokens = math.min(tokens, capacity)
+
+	-- Up to maxDebt may be overdrawn from a balance that is not already negative
+	local need = math.max(0, n - maxDebt)
+	local allowed = 0
+	local retry = 0
+	if n < 0 or tokens >= need then
+		allowed = 1
+		tokens = math.min(tokens - n, capacity)
+	else
+		retry = math.ceil((need - tokens) / rate * 1000)
+	end
+
+	if not dry then
+		redis.call('HSET', key, 'tokens', tostring(tokens), 'last_refill', math.floor(last))
+		redis.call('EXPIRE', key, ttl)
+	end
+
+	local result = {allowed, tostring(tokens), retry}
+` + opRecord)
+
+// tokenResult is the outcome of a token bucket step
+type tokenResult struct {
+	Allowed    bool
+	Tokens     float64
+	RetryAfter time.Duration
+}
+
+// ConsumeTokens atomically refills a token bucket and takes n tokens if available
+func (rs *RedisStore) ConsumeTokens(key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (bool, float64, time.Duration, error) {
+	return rs.ConsumeTokensCtx(rs.ctx, key, n, capacity, refillRate, initial, now)
+}
+
+// ConsumeTokensCtx is ConsumeTokens with a context
+// The Lua path does this atomically; the legacy path reads and writes in separate round trips
+func (rs *RedisStore) ConsumeTokensCtx(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (bool, float64, time.Duration, error) {
+	ctx, span := startSpan(ctx, "store.ConsumeTokens", "redis")
+	defer span.End()
+
+	return rs.ConsumeTokensDebtCtx(ctx, key, n, capacity, refillRate, initial, 0, now)
+}
+
+// ConsumeTokensDebtCtx is ConsumeTokensCtx, letting the balance go as low as -maxDebt
+func (rs *RedisStore) ConsumeTokensDebtCtx(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, maxDebt int, now time.Time) (bool, float64, time.Duration, error) {
+	defer rs.timed("consume_tokens")()
+	legacy := func() (tokenResult, error) {
+		tokens, lastRefill, found, err := rs.GetTokensCtx(ctx, key)
+		if err != nil {
+			return tokenResult{}, err
+		}
+
+		allowed, tokens, lastRefill, retryAfter := consumeTokens(tokens, lastRefill, found, n, capacity, refillRate, initial, maxDebt, now)
+		if err := rs.SetTokensCtx(ctx, key, tokens, lastRefill); err != nil {
+			return tokenResult{}, err
+		}
+		return tokenResult{Allowed: allowed, Tokens: tokens, RetryAfter: retryAfter}, nil
+	}
+	script := func() (tokenResult, error) {
+		return rs.consumeTokensScript(ctx, key, n, capacity, refillRate, initial, maxDebt, now, rs.tokenGate.Mode() == ScriptShadow)
+	}
+	equal := func(a, b tokenResult) bool {
+		// Seconds-resolution refill times make the token counts drift slightly; compare decisions
+		return a.Allowed == b.Allowed && int(a.Tokens) == int(b.Tokens)
+	}
+
+	result, err := RunGated(rs.tokenGate, legacy, script, equal)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	return result.Allowed, result.Tokens, result.RetryAfter, nil
+}
+
+// consumeTokensScript runs consumeTokensScript
+func (rs *RedisStore) consumeTokensScript(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, maxDebt int, now time.Time, dry bool) (tokenResult, error) {
+	tokenKey := fmt.Sprintf("%stokens:%s", rs.prefix, key)
+
+	dryArg := "0"
+	if dry {
+		dryArg = "1"
+	}
+
+	var raw []interface{}
+	err := rs.retryOperation(ctx, func(ctx context.Context) (err error) {
+		// Dry runs write nothing, so there is nothing to deduplicate
+		keys := []string{tokenKey}
+		if !dry {
+			keys = rs.scriptKeys(ctx, tokenKey, "consume", n)
+		}
+
+		raw, err = consumeTokensScript.Run(
+			ctx,
+			rs.client,
+			keys,
+			n,
+			capacity,
+			refillRate,
+			initial,
+			float64(now.UnixMicro())/1e6,
+			int(rs.ttl.Seconds()),
+			dryArg,
+			maxDebt,
+			rs.opTTL.Milliseconds(),
+		).Slice()
+		return err
+	})
+	if err != nil {
+		return tokenResult{}, fmt.Errorf("consume tokens script failed: %w", err)
+	}
+	return parseTokenResult(raw)
+}
+
+// parseTokenResult reads the {allowed, tokens, retryAfterMillis} result of consumeTokensScript
+func parseTokenResult(raw []interface{}) (tokenResult, error) {
+	if len(raw) != 3 {
+		return tokenResult{}, fmt.Errorf("consume tokens script returned %d values", len(raw))
+	}
+
+	allowed, ok := raw[0].(int64)
+	if !ok {
+		return tokenResult{}, fmt.Errorf("unexpected result type: %T", raw[0])
+	}
+	tokens, err := strconv.ParseFloat(fmt.Sprint(raw[1]), 64)
+	if err != nil {
+		return tokenResult{}, fmt.Errorf("unexpected tokens %v: %w", raw[1], err)
+	}
+	retryMillis, ok := raw[2].(int64)
+	if !ok {
+		return tokenResult{}, fmt.Errorf("unexpected result type: %T", raw[2])
+	}
+
+	return tokenResult{
+		Allowed:    allowed == 1,
+		Tokens:     tokens,
+		RetryAfter: time.Duration(retryMillis) * time.Millisecond,
+	}, nil
+}
+
+// ConsumeTokensBatchCtx runs a token bucket step for each request
+// With the token bucket script on, every step is sent in one pipeline, each atomic on its
+// own key. Otherwise, or while the script path is demoted, the steps run one at a time
+// through ConsumeTokensCtx. Pipelined steps are not retried on timeouts
+func (rs *RedisStore) ConsumeTokensBatchCtx(ctx context.Context, requests []limiter.TokenRequest, capacity int, refillRate float64, initial float64, now time.Time) ([]limiter.TokenResult, error) {
+	defer rs.timed("consume_tokens_batch")()
+	ctx, span := startSpan(ctx, "store.ConsumeTokensBatch", "redis")
+	defer span.End()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if rs.tokenGate.Mode() != ScriptOn || !rs.tokenGate.allowScript() {
+		results := make([]limiter.TokenResult, len(requests))
+		for i, r := range requests {
+			allowed, tokens, retryAfter, err := rs.ConsumeTokensCtx(ctx, r.Key, r.N, capacity, refillRate, initial, now)
+			results[i] = limiter.TokenResult{Allowed: allowed, Tokens: tokens, RetryAfter: retryAfter, Err: err}
+		}
+		return results, nil
+	}
+
+	results := rs.consumeTokensPipelined(ctx, requests, capacity, refillRate, initial, now)
+	var scriptErr error
+	for _, r := range results {
+		if r.Err != nil {
+			scriptErr = r.Err
+			break
+		}
+	}
+	rs.tokenGate.recordResult(scriptErr)
+	return results, nil
+}
+
+// consumeTokensPipelined runs consumeTokensScript for each request in one pipeline
+func (rs *RedisStore) consumeTokensPipelined(ctx context.Context, requests []limiter.TokenRequest, capacity int, refillRate float64, initial float64, now time.Time) []limiter.TokenResult {
+	cmds := make([]*redis.Cmd, len(requests))
+	send := func(indices []int) {
+		pipe := rs.client.Pipeline()
+		for _, i := range indices {
+			r := requests[i]
+			tokenKey := fmt.Sprintf("%stokens:%s", rs.prefix, r.Key)
+			cmds[i] = consumeTokensScript.EvalSha(
+				ctx,
+				pipe,
+				rs.scriptKeys(ctx, tokenKey, "consume", r.N),
+				r.N,
+				capacity,
+				refillRate,
+				initial,
+				float64(now.UnixMicro())/1e6,
+				int(rs.ttl.Seconds()),
+				"0",
+				0,
+				rs.opTTL.Milliseconds(),
+			)
+		}
+		// Failures are per step and read from each command
+		_, _ = pipe.Exec(ctx)
+	}
+
+	all := make([]int, len(requests))
+	for i := range all {
+		all[i] = i
+	}
+	send(all)
+
+	// A server that has not cached the script yet runs none of its steps; load it and resend them
+	var missing []int
+	for i, cmd := range cmds {
+		if redis.HasErrorPrefix(cmd.Err(), "NOSCRIPT") {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) > 0 {
+		if err := consumeTokensScript.Load(ctx, rs.client).Err(); err == nil {
+			send(missing)
+		}
+	}
+
+	results := make([]limiter.TokenResult, len(requests))
+	for i, cmd := range cmds {
+		raw, err := cmd.Slice()
+		if err != nil {
+			results[i].Err = fmt.Errorf("consume tokens script failed: %w", err)
+			continue
+		}
+		result, err := parseTokenResult(raw)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i] = limiter.TokenResult{Allowed: result.Allowed, Tokens: result.Tokens, RetryAfter: result.RetryAfter}
+	}
+	return results
+}
+
+// Lua script for all-or-nothing token bucket steps on several keys: refill every bucket,
+// then take each KEYS[i]'s ARGV[5+i] tokens only if all of them have enough
+// Returns {allowed, allowed1, tokens1, retry1, ...}, each key as consumeTokensScript reports it
+var consumeTokensAllScript = redis.NewScript(`
+	local capacity = tonumber(ARGV[1])
+	local rate = tonumber(ARGV[2])
+	local initial = tonumber(ARGV[3])
+	local now = tonumber(ARGV[4])
+	local ttl = tonumber(ARGV[5])
+
+	local balances = {}
+	local lasts = {}
+	local all = 1
+	local result = {0}
+	for i, key in ipairs(KEYS) do
+		local n = tonumber(ARGV[5 + i])
+		local state = redis.call('HMGET', key, 'tokens', 'last_refill')
+		local tokens = tonumber(state[1])
+		local last = tonumber(state[2])
+		if last == nil then
+			tokens = initial
+			last = now
+		elseif tokens == nil then
+			tokens = 0
+		end
+
+		local elapsed = now - last
+		if elapsed > 0 then
+			tokens = tokens + elapsed * rate
+			last = now
+		end
+		tokens = math.min(tokens, capacity)
+
+		local allowed = 0
+		local retry = 0
+		if tokens >= n then
+			allowed = 1
+		else
+			all = 0
+			retry = math.ceil((n - tokens) / rate * 1000)
+		end
+		balances[i] = tokens
+		lasts[i] = last
+		table.insert(result, allowed)
+		table.insert(result, tostring(tokens))
+		table.insert(result, retry)
+	end
+
+	if all == 1 then
+		for i, key in ipairs(KEYS) do
+			local tokens = balances[i] - tonumber(ARGV[5 + i])
+			redis.call('HSET', key, 'tokens', tostring(tokens), 'last_refill', math.floor(lasts[i]))
+			redis.call('EXPIRE', key, ttl)
+			result[3 * i] = tostring(tokens)
+		end
+	end
+	result[1] = all
+	return result
+`)
+
+// ConsumeTokensAllCtx runs a token bucket step for every request, or for none, in one script
+// The script path runs whatever RedisConfig.ScriptModes says, since the legacy commands
+// cannot be atomic across keys. Under Redis Cluster every key must carry the same
+// {hash tag}, so they share a slot; other keys fail with limiter.ErrKeysNotColocated
+func (rs *RedisStore) ConsumeTokensAllCtx(ctx context.Context, requests []limiter.TokenRequest, capacity int, refillRate float64, initial float64, now time.Time) (bool, []limiter.TokenResult, error) {
+	defer rs.timed("consume_tokens_all")()
+	ctx, span := startSpan(ctx, "store.ConsumeTokensAll", "redis")
+	defer span.End()
+
+	keys := make([]string, len(requests))
+	args := []interface{}{capacity, refillRate, initial, float64(now.UnixMicro()) / 1e6, int(rs.ttl.Seconds())}
+	for i, r := range requests {
+		keys[i] = fmt.Sprintf("%stokens:%s", rs.prefix, r.Key)
+		args = append(args, r.N)
+	}
+	if _, cluster := rs.client.(*redis.ClusterClient); cluster && !sameHashTag(keys) {
+		return false, nil, fmt.Errorf("%w: give every key the same {hash tag}", limiter.ErrKeysNotColocated)
+	}
+
+	raw, err := consumeTokensAllScript.Run(ctx, rs.client, keys, args...).Slice()
+	if err != nil {
+		rs.recordError("consume_tokens_all")
+		return false, nil, fmt.Errorf("consume tokens script failed: %w", err)
+	}
+	if len(raw) != 1+3*len(requests) {
+		return false, nil, fmt.Errorf("consume tokens script returned %d values", len(raw))
+	}
+
+	results := make([]limiter.TokenResult, len(requests))
+	for i := range requests {
+		result, err := parseTokenResult(raw[1+3*i : 4+3*i])
+		if err != nil {
+			return false, nil, err
+		}
+		results[i] = limiter.TokenResult{Allowed: result.Allowed, Tokens: result.Tokens, RetryAfter: result.RetryAfter}
+	}
+	allowed, _ := raw[0].(int64)
+	return allowed == 1, results, nil
+}
+
+// sameHashTag reports whether every key has the same non-empty {hash tag}, which is what
+// Redis Cluster hashes to place a key
+func sameHashTag(keys []string) bool {
+	var tag string
+	for i, key := range keys {
+		open := strings.IndexByte(key, '{')
+		if open < 0 {
+			return false
+		}
+		end := strings.IndexByte(key[open+1:], '}')
+		if end <= 0 {
+			return false
+		}
+		if k := key[open+1 : open+1+end]; i == 0 {
+			tag = k
+		} else if k != tag {
+			return false
+		}
+	}
+	return true
+}
+
+// opReplay starts a mutating script that is applied at most once per operation
+// KEYS[2], when given, is the operation's key; a script run again under it returns the
+// recorded result without touching KEYS[1]
+const opReplay = `
+	local op = KEYS[2]
+	if op then
+		local done = redis.call('GET', op)
+		if done then
+			return cjson.decode(done)
+		end
+	end
+`
+
+// opRecord ends a script started with opReplay, returning its local result after
+// recording it under the operation for ARGV[#ARGV] milliseconds
+const opRecord = `
+	if op then
+		redis.call('SET', op, cjson.encode(result), 'PX', ARGV[#ARGV])
+	end
+	return result
+`
+
+// retryOperation runs a mutating script call, retrying timeouts under one operation ID
+// With retries disabled it runs once, deduplicated only if ctx already carries an ID
+func (rs *RedisStore) retryOperation(ctx context.Context, fn func(ctx context.Context) error) error {
+	if rs.opRetries == 0 {
+		return fn(ctx)
+	}
+	return RetryOperation(ctx, rs.opRetries, fn)
+}
+
+// scriptKeys returns the KEYS for a mutating script on dataKey: the data key, followed by
+// the operation key when ctx carries an operation ID
+func (rs *RedisStore) scriptKeys(ctx context.Context, dataKey, call string, n int) []string {
+	step := operationStep(ctx, call, n)
+	if step == "" {
+		return []string{dataKey}
+	}
+	opKey, ok := operationKey(dataKey, step)
+	if !ok {
+		return []string{dataKey}
+	}
+	return []string{dataKey, opKey}
+}
+
+// operationKey returns the key recording step on dataKey, in the same cluster slot
+// A data key without a hash tag is wrapped in one, and one with a tag is extended so the
+// tag still decides the slot. ok is false for keys with stray braces, which can not be
+// placed reliably and so run without deduplication
+func operationKey(dataKey, step string) (string, bool) {
+	open := strings.IndexByte(dataKey, '{')
+	if open < 0 {
+		if strings.IndexByte(dataKey, '}') >= 0 {
+			return "", false
+		}
+		return "{" + dataKey + "}:op:" + step, true
+	}
+
+	if end := strings.IndexByte(dataKey[open+1:], '}'); end > 0 {
+		return dataKey + ":op:" + step, true
+	}
+	return "", false
+}
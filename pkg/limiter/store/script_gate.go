@@ -0,0 +1,245 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ScriptMode controls whether a Lua-scripted store path is used
+type ScriptMode int
+
+const (
+	// ScriptOff always runs the legacy multi-command path
+	ScriptOff ScriptMode = iota
+	// ScriptShadow runs both paths, compares them, and returns the legacy result
+	ScriptShadow
+	// ScriptOn runs the script path, falling back to legacy when demoted
+	ScriptOn
+)
+
+// String returns the config name of the mode
+func (m ScriptMode) String() string {
+	switch m {
+	case ScriptShadow:
+		return "shadow"
+	case ScriptOn:
+		return "on"
+	default:
+		return "off"
+	}
+}
+
+// ParseScriptMode parses "off", "shadow" or "on"; empty means off
+func ParseScriptMode(s string) (ScriptMode, error) {
+	switch s {
+	case "", "off":
+		return ScriptOff, nil
+	case "shadow":
+		return ScriptShadow, nil
+	case "on":
+		return ScriptOn, nil
+	default:
+		return ScriptOff, fmt.Errorf("unknown script mode %q (want off, shadow or on)", s)
+	}
+}
+
+// ScriptGateConfig configures a ScriptGate
+type ScriptGateConfig struct {
+	Mode           ScriptMode
+	ErrorThreshold int              // Consecutive script errors before demotion (default 5)
+	Cooldown       time.Duration    // How long a demoted path stays on legacy before retrying (default 1m)
+	SampleEvery    int              // Log one in SampleEvery shadow mismatches (default 100)
+	Metrics        ScriptMetrics    // Optional
+	Now            func() time.Time // Optional clock, for tests
+}
+
+// ScriptMetrics records the health of gated Lua script paths
+type ScriptMetrics interface {
+	RecordScriptError(path string)
+	RecordScriptDemotion(path string)
+	RecordScriptDemoted(path string, demoted bool)
+	RecordScriptMismatch(path string)
+}
+
+// ScriptGate guards one Lua-scripted path behind a feature flag
+// Script errors past the threshold demote the path to legacy; after the cooldown
+// a single trial call decides whether it recovers
+type ScriptGate struct {
+	path   string
+	config ScriptGateConfig
+
+	mu          sync.Mutex
+	failures    int       // Consecutive script errors
+	demotedAt   time.Time // Zero while the script path is healthy
+	trialActive bool      // A post-cooldown trial call is in flight
+	mismatches  int64
+}
+
+// NewScriptGate creates a gate for the named path
+func NewScriptGate(path string, config ScriptGateConfig) *ScriptGate {
+	if config.ErrorThreshold <= 0 {
+		config.ErrorThreshold = 5
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 1 * time.Minute
+	}
+	if config.SampleEvery <= 0 {
+		config.SampleEvery = 100
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &ScriptGate{
+		path:   path,
+		config: config,
+	}
+}
+
+// Mode returns the configured mode
+func (g *ScriptGate) Mode() ScriptMode {
+	return g.config.Mode
+}
+
+// Demoted reports whether script errors have pushed the path back to legacy
+func (g *ScriptGate) Demoted() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return !g.demotedAt.IsZero()
+}
+
+// Mismatches returns the number of shadow comparisons that disagreed
+func (g *ScriptGate) Mismatches() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.mismatches
+}
+
+// allowScript reports whether the script path should run now
+// Once the cooldown has elapsed a single caller is let through as a trial
+func (g *ScriptGate) allowScript() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.demotedAt.IsZero() {
+		return true
+	}
+	if g.trialActive || g.config.Now().Sub(g.demotedAt) < g.config.Cooldown {
+		return false
+	}
+	g.trialActive = true
+	return true
+}
+
+// recordResult tracks script outcomes, demoting or recovering the path
+// Errors other than script errors say nothing about the script, so they end a trial without a verdict
+func (g *ScriptGate) recordResult(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	wasTrial := g.trialActive
+	g.trialActive = false
+	if err != nil && !isScriptError(err) {
+		return
+	}
+
+	if err == nil {
+		g.failures = 0
+		if !g.demotedAt.IsZero() {
+			g.demotedAt = time.Time{}
+			log.Printf("Lua path %s recovered; script path re-enabled", g.path)
+			g.recordDemoted(false)
+		}
+		return
+	}
+
+	if g.config.Metrics != nil {
+		g.config.Metrics.RecordScriptError(g.path)
+	}
+
+	if wasTrial {
+		// Still failing; wait out another cooldown
+		g.demotedAt = g.config.Now()
+		log.Printf("Lua path %s still failing after cooldown: %v", g.path, err)
+		return
+	}
+
+	g.failures++
+	if g.demotedAt.IsZero() && g.failures >= g.config.ErrorThreshold {
+		g.demotedAt = g.config.Now()
+		log.Printf("Lua path %s demoted to legacy after %d consecutive script errors: %v", g.path, g.failures, err)
+		if g.config.Metrics != nil {
+			g.config.Metrics.RecordScriptDemotion(g.path)
+		}
+		g.recordDemoted(true)
+	}
+}
+
+// recordDemoted updates the demoted gauge; callers must hold g.mu
+func (g *ScriptGate) recordDemoted(demoted bool) {
+	if g.config.Metrics != nil {
+		g.config.Metrics.RecordScriptDemoted(g.path, demoted)
+	}
+}
+
+// recordMismatch counts a shadow disagreement and logs a sample of them
+func (g *ScriptGate) recordMismatch(legacy, script interface{}) {
+	g.mu.Lock()
+	g.mismatches++
+	n := g.mismatches
+	g.mu.Unlock()
+
+	if g.config.Metrics != nil {
+		g.config.Metrics.RecordScriptMismatch(g.path)
+	}
+	if (n-1)%int64(g.config.SampleEvery) == 0 {
+		log.Printf("Lua path %s shadow mismatch #%d: legacy=%+v script=%+v", g.path, n, legacy, script)
+	}
+}
+
+// isScriptError reports whether err is Redis rejecting the script itself (NOSCRIPT, an error
+// raised while running it) rather than a failed round trip, after which the script may have applied
+func isScriptError(err error) bool {
+	var reply redis.Error
+	return errors.As(err, &reply) && !errors.Is(err, redis.Nil)
+}
+
+// RunGated runs the legacy or script implementation of a path according to the gate
+// In shadow mode script must not mutate state, since legacy runs after it and its result is returned
+// In on mode a script error falls back to legacy for that call; other errors, such as timeouts
+// and cancellations, are returned as they are, since rerunning a mutation could apply it twice
+func RunGated[T any](g *ScriptGate, legacy, script func() (T, error), equal func(legacy, script T) bool) (T, error) {
+	switch g.config.Mode {
+	case ScriptShadow:
+		if !g.allowScript() {
+			return legacy()
+		}
+		scriptResult, scriptErr := script()
+		g.recordResult(scriptErr)
+
+		legacyResult, err := legacy()
+		if err == nil && scriptErr == nil && !equal(legacyResult, scriptResult) {
+			g.recordMismatch(legacyResult, scriptResult)
+		}
+		return legacyResult, err
+
+	case ScriptOn:
+		if !g.allowScript() {
+			return legacy()
+		}
+		result, err := script()
+		g.recordResult(err)
+		if err != nil && isScriptError(err) {
+			return legacy()
+		}
+		return result, err
+
+	default:
+		return legacy()
+	}
+}
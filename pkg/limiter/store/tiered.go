@@ -0,0 +1,584 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// DefaultSyncInterval is how often a TieredStore reconciles with its shared store by default
+const DefaultSyncInterval = 100 * time.Millisecond
+
+// TieredBackend is the shared store behind a TieredStore; *RedisStore is one
+type TieredBackend interface {
+	limiter.Store
+	limiter.DebtTokenConsumer
+	limiter.WindowPruner
+	limiter.TimestampCounter
+	limiter.LogAllower
+	limiter.QuotaCounter
+	limiter.Penalizer
+	limiter.Banner
+	limiter.ReservationStore
+	limiter.LimitOverrideStore
+	limiter.KeyGroupStore
+	limiter.RequestRecorder
+	limiter.PrefixDeleter
+}
+
+// TieredStore serves hot token buckets and windows from an in-memory L1 in front of a
+// shared L2 such as Redis, trading accuracy across instances for latency
+//
+// Token buckets are cached: a key's first step on an instance runs in L2 and loads the
+// result into L1, later steps run in L1 only, and every sync interval the tokens taken
+// locally are taken from L2 and L1 is reset to L2's balance. Window counters are write-through:
+// every Increment runs in L2, so fixed windows stay exact, and its count is patched into
+// L1, while GetWindows is served from L1 for a sync interval after it was last read from L2.
+// Token reads, SetTokens and Delete follow the same split; timestamp logs, quotas,
+// penalties, bans, reservations, limit overrides, key groups and request IDs always go to L2.
+//
+// The staleness window is the sync interval: within it an instance does not see tokens
+// taken or windows counted by other instances, so N instances can together admit up to N
+// times a bucket's available tokens before they reconcile. Local overshoot is charged to
+// L2 as token debt, which every instance then waits out; steps pushed while L2 is already
+// in debt are forgiven. While L2 is unreachable cached keys keep being served from L1 and their steps are pushed once it
+// is back
+type TieredStore struct {
+	l1           *MemoryStore
+	l2           TieredBackend
+	syncInterval time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*tieredBucket  // Token buckets cached in L1
+	windows map[string]*tieredWindows // Window ranges cached in L1
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// tieredBucket is a token bucket cached in L1
+type tieredBucket struct {
+	capacity   int
+	refillRate float64
+	initial    float64
+	pending    int       // Tokens taken in L1 since the last sync, not yet taken from L2
+	now        time.Time // Time of the latest step, at which pending is taken from L2
+	touched    bool      // Stepped since the last sync; idle buckets are dropped from L1
+	dropped    bool      // No longer cached; steps that were waiting on mu go to L2
+	mu         sync.Mutex
+}
+
+// tieredWindows is a range of a key's windows cached in L1
+type tieredWindows struct {
+	from    time.Time // Start of the range read from L2
+	fetched time.Time // Wall time it was read
+}
+
+// NewTieredStore creates a store caching l2 in l1, reconciling them every syncInterval
+// (DefaultSyncInterval if not positive). The store owns both and closes them on Close
+func NewTieredStore(l1 *MemoryStore, l2 TieredBackend, syncInterval time.Duration) *TieredStore {
+	if syncInterval <= 0 {
+		syncInterval = DefaultSyncInterval
+	}
+	ts := &TieredStore{
+		l1:           l1,
+		l2:           l2,
+		syncInterval: syncInterval,
+		buckets:      make(map[string]*tieredBucket),
+		windows:      make(map[string]*tieredWindows),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go ts.run()
+	return ts
+}
+
+// SyncInterval returns how often the store reconciles with L2, which bounds how stale L1 is
+func (ts *TieredStore) SyncInterval() time.Duration {
+	return ts.syncInterval
+}
+
+// run syncs every interval until Close
+func (ts *TieredStore) run() {
+	defer close(ts.done)
+	ticker := time.NewTicker(ts.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ts.stop:
+			return
+		case <-ticker.C:
+			ts.Sync(context.Background())
+		}
+	}
+}
+
+// Sync reconciles L1 with L2 now rather than at the next interval
+// Tokens taken locally are taken from L2 and each cached bucket is reset to L2's balance.
+// Buckets not stepped since the last sync and stale window ranges are dropped from L1.
+// A bucket that fails to sync keeps its local steps for the next attempt
+func (ts *TieredStore) Sync(ctx context.Context) error {
+	ts.mu.Lock()
+	buckets := make(map[string]*tieredBucket, len(ts.buckets))
+	for key, b := range ts.buckets {
+		buckets[key] = b
+	}
+	for key, w := range ts.windows {
+		if time.Since(w.fetched) >= ts.syncInterval {
+			delete(ts.windows, key)
+			ts.l1.deleteWindows(key)
+		}
+	}
+	ts.mu.Unlock()
+
+	var errs []error
+	for key, b := range buckets {
+		if err := ts.syncBucket(ctx, key, b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncBucket pushes a bucket's local steps to L2 and resets L1 to L2's balance
+func (ts *TieredStore) syncBucket(ctx context.Context, key string, b *tieredBucket) error {
+	b.mu.Lock()
+	pending, now := b.pending, b.now
+	if b.dropped || (!b.touched && pending == 0) {
+		// Forget idle buckets; their next step loads them from L2 again
+		ts.dropBucket(key, b)
+		b.mu.Unlock()
+		return nil
+	}
+	b.pending, b.touched = 0, false
+	b.mu.Unlock()
+
+	var tokens float64
+	lastRefill, found := now, true
+	var err error
+	if pending != 0 {
+		var allowed bool
+		allowed, tokens, _, err = ts.l2.ConsumeTokensDebtCtx(ctx, key, pending, b.capacity, b.refillRate, b.initial, b.capacity, now)
+		if err == nil && !allowed {
+			// L2 is already in debt or would owe more than a bucket; the steps are forgiven
+			tokens, lastRefill, found, err = ts.l2.GetTokensCtx(ctx, key)
+		}
+	} else {
+		tokens, lastRefill, found, err = ts.l2.GetTokensCtx(ctx, key)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.dropped:
+		return nil
+	case err != nil:
+		b.pending += pending
+		return err
+	case !found:
+		// Gone from L2 (reset or expired); the next step loads it again
+		ts.dropBucket(key, b)
+		return nil
+	}
+	// Steps taken locally while L2 was being read still count against the new balance
+	return ts.l1.SetTokensCtx(ctx, key, tokens-float64(b.pending), lastRefill)
+}
+
+// bucket returns the cached bucket for key, or nil if it is not cached
+// Callers must check dropped once they hold its lock
+func (ts *TieredStore) bucket(key string) *tieredBucket {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.buckets[key]
+}
+
+// dropBucket stops caching b and removes its tokens from L1; callers must hold b.mu
+func (ts *TieredStore) dropBucket(key string, b *tieredBucket) {
+	b.dropped = true
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.buckets[key] == b {
+		delete(ts.buckets, key)
+		ts.l1.deleteTokens(key)
+	}
+}
+
+// Increment increments the counter for a key at a specific window
+func (ts *TieredStore) Increment(key string, window time.Time) (int64, error) {
+	return ts.IncrementCtx(context.Background(), key, window)
+}
+
+// IncrementCtx is Increment with a context
+func (ts *TieredStore) IncrementCtx(ctx context.Context, key string, window time.Time) (int64, error) {
+	return ts.IncrementByCtx(ctx, key, window, 1)
+}
+
+// IncrementBy adds delta to the counter for a key at a specific window
+func (ts *TieredStore) IncrementBy(key string, window time.Time, delta int64) (int64, error) {
+	return ts.IncrementByCtx(context.Background(), key, window, delta)
+}
+
+// IncrementByCtx counts in L2 and patches the count into L1 if the key's windows are cached
+func (ts *TieredStore) IncrementByCtx(ctx context.Context, key string, window time.Time, delta int64) (int64, error) {
+	count, err := ts.l2.IncrementByCtx(ctx, key, window, delta)
+	if err != nil {
+		return 0, err
+	}
+	ts.patchWindow(key, window, count, 0)
+	return count, nil
+}
+
+// IncrementPruneCtx is IncrementByCtx, also dropping windows older than retain in both tiers
+func (ts *TieredStore) IncrementPruneCtx(ctx context.Context, key string, window time.Time, delta int64, retain time.Duration) (int64, error) {
+	count, err := ts.l2.IncrementPruneCtx(ctx, key, window, delta, retain)
+	if err != nil {
+		return 0, err
+	}
+	ts.patchWindow(key, window, count, retain)
+	return count, nil
+}
+
+// patchWindow sets a window counted in L2 in L1, if the key's windows are cached
+func (ts *TieredStore) patchWindow(key string, window time.Time, count int64, retain time.Duration) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if _, ok := ts.windows[key]; ok {
+		ts.l1.setWindow(key, window, count, retain)
+	}
+}
+
+// GetWindows returns all windows for a key within a time range
+func (ts *TieredStore) GetWindows(key string, from, to time.Time) ([]limiter.Window, error) {
+	return ts.GetWindowsCtx(context.Background(), key, from, to)
+}
+
+// GetWindowsCtx serves windows from L1 when the range was read from L2 within the sync interval
+func (ts *TieredStore) GetWindowsCtx(ctx context.Context, key string, from, to time.Time) ([]limiter.Window, error) {
+	ts.mu.Lock()
+	w, ok := ts.windows[key]
+	fresh := ok && !from.Before(w.from) && time.Since(w.fetched) < ts.syncInterval
+	ts.mu.Unlock()
+	if fresh {
+		return ts.l1.GetWindowsCtx(ctx, key, from, to)
+	}
+
+	windows, err := ts.l2.GetWindowsCtx(ctx, key, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.l1.replaceWindows(key, windows)
+	ts.windows[key] = &tieredWindows{from: from, fetched: time.Now()}
+	return windows, nil
+}
+
+// SetTokens sets the token count and last refill time for token bucket
+func (ts *TieredStore) SetTokens(key string, tokens float64, lastRefill time.Time) error {
+	return ts.SetTokensCtx(context.Background(), key, tokens, lastRefill)
+}
+
+// SetTokensCtx writes through to L2, replacing the cached bucket and its unsynced steps
+func (ts *TieredStore) SetTokensCtx(ctx context.Context, key string, tokens float64, lastRefill time.Time) error {
+	if err := ts.l2.SetTokensCtx(ctx, key, tokens, lastRefill); err != nil {
+		return err
+	}
+	b := ts.bucket(key)
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.dropped {
+		return nil
+	}
+	b.pending = 0
+	return ts.l1.SetTokensCtx(ctx, key, tokens, lastRefill)
+}
+
+// GetTokens gets the token count and last refill time for token bucket
+func (ts *TieredStore) GetTokens(key string) (tokens float64, lastRefill time.Time, found bool, err error) {
+	return ts.GetTokensCtx(context.Background(), key)
+}
+
+// GetTokensCtx reads a cached bucket from L1 and any other from L2
+func (ts *TieredStore) GetTokensCtx(ctx context.Context, key string) (tokens float64, lastRefill time.Time, found bool, err error) {
+	if b := ts.bucket(key); b != nil {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if !b.dropped {
+			return ts.l1.GetTokensCtx(ctx, key)
+		}
+	}
+	return ts.l2.GetTokensCtx(ctx, key)
+}
+
+// ConsumeTokens refills a token bucket and takes n tokens if available
+func (ts *TieredStore) ConsumeTokens(key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (bool, float64, time.Duration, error) {
+	return ts.ConsumeTokensCtx(context.Background(), key, n, capacity, refillRate, initial, now)
+}
+
+// ConsumeTokensCtx steps a cached bucket in L1, to be pushed to L2 at the next sync
+// A key's first step on this instance runs in L2 and caches the result
+func (ts *TieredStore) ConsumeTokensCtx(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (bool, float64, time.Duration, error) {
+	if b := ts.bucket(key); b != nil {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.dropped {
+			return ts.consumeUncached(ctx, key, n, capacity, refillRate, initial, now)
+		}
+
+		allowed, tokens, retryAfter, err := ts.l1.ConsumeTokensCtx(ctx, key, n, capacity, refillRate, initial, now)
+		if err != nil {
+			return false, 0, 0, err
+		}
+		if allowed {
+			b.pending += n
+		}
+		if now.After(b.now) {
+			b.now = now
+		}
+		b.touched = true
+		return allowed, tokens, retryAfter, nil
+	}
+	return ts.consumeUncached(ctx, key, n, capacity, refillRate, initial, now)
+}
+
+// consumeUncached takes a step in L2 and caches the bucket in L1
+func (ts *TieredStore) consumeUncached(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (bool, float64, time.Duration, error) {
+	allowed, tokens, retryAfter, err := ts.l2.ConsumeTokensCtx(ctx, key, n, capacity, refillRate, initial, now)
+	if err != nil {
+		return false, 0, 0, err
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	// A concurrent first step may have cached the bucket already; its state is as fresh
+	if _, ok := ts.buckets[key]; !ok {
+		if err := ts.l1.SetTokensCtx(ctx, key, tokens, now); err != nil {
+			return false, 0, 0, err
+		}
+		ts.buckets[key] = &tieredBucket{capacity: capacity, refillRate: refillRate, initial: initial, now: now, touched: true}
+	}
+	return allowed, tokens, retryAfter, nil
+}
+
+// ConsumeTokensDebtCtx runs in L2; overdrawn buckets are not cached
+func (ts *TieredStore) ConsumeTokensDebtCtx(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, maxDebt int, now time.Time) (bool, float64, time.Duration, error) {
+	return ts.l2.ConsumeTokensDebtCtx(ctx, key, n, capacity, refillRate, initial, maxDebt, now)
+}
+
+// AddTimestamps records n request timestamps for a key (sliding window log)
+func (ts *TieredStore) AddTimestamps(key string, t time.Time, n int, ttl time.Duration) error {
+	return ts.AddTimestampsCtx(context.Background(), key, t, n, ttl)
+}
+
+// AddTimestampsCtx is AddTimestamps with a context, in L2
+func (ts *TieredStore) AddTimestampsCtx(ctx context.Context, key string, t time.Time, n int, ttl time.Duration) error {
+	return ts.l2.AddTimestampsCtx(ctx, key, t, n, ttl)
+}
+
+// GetTimestamps returns the logged timestamps for a key within a time range, oldest first
+func (ts *TieredStore) GetTimestamps(key string, from, to time.Time) ([]time.Time, error) {
+	return ts.GetTimestampsCtx(context.Background(), key, from, to)
+}
+
+// GetTimestampsCtx is GetTimestamps with a context, from L2
+func (ts *TieredStore) GetTimestampsCtx(ctx context.Context, key string, from, to time.Time) ([]time.Time, error) {
+	return ts.l2.GetTimestampsCtx(ctx, key, from, to)
+}
+
+// TrimTimestamps removes logged timestamps older than before
+func (ts *TieredStore) TrimTimestamps(key string, before time.Time) error {
+	return ts.TrimTimestampsCtx(context.Background(), key, before)
+}
+
+// TrimTimestampsCtx is TrimTimestamps with a context, in L2
+func (ts *TieredStore) TrimTimestampsCtx(ctx context.Context, key string, before time.Time) error {
+	return ts.l2.TrimTimestampsCtx(ctx, key, before)
+}
+
+// CountTimestampsCtx counts a timestamp log in L2
+func (ts *TieredStore) CountTimestampsCtx(ctx context.Context, key string, from, to time.Time) (int64, error) {
+	return ts.l2.CountTimestampsCtx(ctx, key, from, to)
+}
+
+// AllowLogCtx checks and appends to a timestamp log in L2
+func (ts *TieredStore) AllowLogCtx(ctx context.Context, key string, now time.Time, window time.Duration, limit, n int) (bool, []time.Time, error) {
+	return ts.l2.AllowLogCtx(ctx, key, now, window, limit, n)
+}
+
+// AddQuotaCtx adds to a quota counter in L2
+func (ts *TieredStore) AddQuotaCtx(ctx context.Context, key string, n, limit int64, ttl time.Duration, dry bool) (bool, int64, error) {
+	return ts.l2.AddQuotaCtx(ctx, key, n, limit, ttl, dry)
+}
+
+// PenalizeCtx records a denial for penalties in L2
+func (ts *TieredStore) PenalizeCtx(ctx context.Context, key string, now time.Time, retryAfter time.Duration, policy limiter.PenaltyPolicy) (int, time.Time, error) {
+	return ts.l2.PenalizeCtx(ctx, key, now, retryAfter, policy)
+}
+
+// PenaltyCtx reads a key's penalty from L2
+func (ts *TieredStore) PenaltyCtx(ctx context.Context, key string, now time.Time, decay time.Duration) (int, time.Time, error) {
+	return ts.l2.PenaltyCtx(ctx, key, now, decay)
+}
+
+// RecordDenialCtx records a denial for bans in L2
+func (ts *TieredStore) RecordDenialCtx(ctx context.Context, key string, now time.Time, policy limiter.BanPolicy) (time.Time, error) {
+	return ts.l2.RecordDenialCtx(ctx, key, now, policy)
+}
+
+// BannedCtx reads a key's ban from L2
+func (ts *TieredStore) BannedCtx(ctx context.Context, key string, now time.Time) (time.Time, error) {
+	return ts.l2.BannedCtx(ctx, key, now)
+}
+
+// UnbanCtx lifts a key's ban in L2
+func (ts *TieredStore) UnbanCtx(ctx context.Context, key string, now time.Time) (bool, error) {
+	return ts.l2.UnbanCtx(ctx, key, now)
+}
+
+// BansCtx lists the bans in L2
+func (ts *TieredStore) BansCtx(ctx context.Context, now time.Time) (map[string]time.Time, error) {
+	return ts.l2.BansCtx(ctx, now)
+}
+
+// SetLimitOverrideCtx sets a key's limit override in L2
+func (ts *TieredStore) SetLimitOverrideCtx(ctx context.Context, key string, override limiter.LimitOverride, now time.Time) error {
+	return ts.l2.SetLimitOverrideCtx(ctx, key, override, now)
+}
+
+// LimitOverrideCtx reads a key's limit override from L2
+func (ts *TieredStore) LimitOverrideCtx(ctx context.Context, key string, now time.Time) (limiter.LimitOverride, bool, error) {
+	return ts.l2.LimitOverrideCtx(ctx, key, now)
+}
+
+// DeleteLimitOverrideCtx removes a key's limit override from L2
+func (ts *TieredStore) DeleteLimitOverrideCtx(ctx context.Context, key string, now time.Time) (bool, error) {
+	return ts.l2.DeleteLimitOverrideCtx(ctx, key, now)
+}
+
+// SetKeyGroupCtx puts an identifier in a group in L2
+func (ts *TieredStore) SetKeyGroupCtx(ctx context.Context, identifier, group string) error {
+	return ts.l2.SetKeyGroupCtx(ctx, identifier, group)
+}
+
+// KeyGroupCtx reads an identifier's group from L2
+func (ts *TieredStore) KeyGroupCtx(ctx context.Context, identifier string) (string, bool, error) {
+	return ts.l2.KeyGroupCtx(ctx, identifier)
+}
+
+// DeleteKeyGroupCtx takes an identifier out of its group in L2
+func (ts *TieredStore) DeleteKeyGroupCtx(ctx context.Context, identifier string) (bool, error) {
+	return ts.l2.DeleteKeyGroupCtx(ctx, identifier)
+}
+
+// ClaimRequestCtx claims a request ID in L2
+func (ts *TieredStore) ClaimRequestCtx(ctx context.Context, id string, ttl time.Duration) (bool, []byte, error) {
+	return ts.l2.ClaimRequestCtx(ctx, id, ttl)
+}
+
+// RecordRequestCtx records a request's result in L2
+func (ts *TieredStore) RecordRequestCtx(ctx context.Context, id string, result []byte, ttl time.Duration) error {
+	return ts.l2.RecordRequestCtx(ctx, id, result, ttl)
+}
+
+// ReleaseRequestCtx forgets a request ID in L2
+func (ts *TieredStore) ReleaseRequestCtx(ctx context.Context, id string) error {
+	return ts.l2.ReleaseRequestCtx(ctx, id)
+}
+
+// AddReservationCtx records a reservation in L2
+func (ts *TieredStore) AddReservationCtx(ctx context.Context, r limiter.Reservation) error {
+	return ts.l2.AddReservationCtx(ctx, r)
+}
+
+// TakeReservationCtx takes back a reservation from L2
+func (ts *TieredStore) TakeReservationCtx(ctx context.Context, id string, now time.Time) (limiter.Reservation, bool, error) {
+	return ts.l2.TakeReservationCtx(ctx, id, now)
+}
+
+// TakeExpiredReservationsCtx takes back expired reservations from L2
+func (ts *TieredStore) TakeExpiredReservationsCtx(ctx context.Context, now time.Time, limit int) ([]limiter.Reservation, error) {
+	return ts.l2.TakeExpiredReservationsCtx(ctx, now, limit)
+}
+
+// Delete removes all data for a key
+func (ts *TieredStore) Delete(key string) error {
+	return ts.DeleteCtx(context.Background(), key)
+}
+
+// DeleteCtx deletes the key in L2 and drops it from L1, with any unsynced steps
+func (ts *TieredStore) DeleteCtx(ctx context.Context, key string) error {
+	if err := ts.l2.DeleteCtx(ctx, key); err != nil {
+		return err
+	}
+
+	if b := ts.bucket(key); b != nil {
+		b.mu.Lock()
+		ts.dropBucket(key, b)
+		b.mu.Unlock()
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	delete(ts.windows, key)
+	return ts.l1.DeleteCtx(ctx, key)
+}
+
+// DeletePrefixCtx deletes the state of every key starting with prefix from L2, then drops
+// what L1 caches for them, returning how many keys L2 had state for
+func (ts *TieredStore) DeletePrefixCtx(ctx context.Context, prefix string) (int, error) {
+	deleted, err := ts.l2.DeletePrefixCtx(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	ts.mu.Lock()
+	var buckets []string
+	for key := range ts.buckets {
+		if strings.HasPrefix(key, prefix) {
+			buckets = append(buckets, key)
+		}
+	}
+	for key := range ts.windows {
+		if strings.HasPrefix(key, prefix) {
+			delete(ts.windows, key)
+		}
+	}
+	ts.mu.Unlock()
+
+	for _, key := range buckets {
+		if b := ts.bucket(key); b != nil {
+			b.mu.Lock()
+			ts.dropBucket(key, b)
+			b.mu.Unlock()
+		}
+	}
+	if _, err := ts.l1.DeletePrefixCtx(ctx, prefix); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// Ping reports whether L2 is reachable
+func (ts *TieredStore) Ping(ctx context.Context) error {
+	return ts.l2.Ping(ctx)
+}
+
+// Close stops syncing, pushes the remaining local steps to L2, and closes both tiers
+func (ts *TieredStore) Close() error {
+	var err error
+	ts.closeOnce.Do(func() {
+		close(ts.stop)
+		<-ts.done
+		err = errors.Join(ts.Sync(context.Background()), ts.l1.Close(), ts.l2.Close())
+	})
+	return err
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// consumeTokens applies one token bucket step shared by the stores' ConsumeTokens paths
+// found reports whether the key had state; unknown keys start with initial tokens.
+// With maxDebt set, n tokens may be taken while the balance is not negative, as long as it
+// ends no lower than -maxDebt; the balance is then negative until refill repays it.
+// It returns the refill time to store, which never moves back: a step dated before the
+// last refill is taken from the stored balance without rewinding it
+func consumeTokens(tokens float64, lastRefill time.Time, found bool, n, capacity int, refillRate, initial float64, maxDebt int, now time.Time) (bool, float64, time.Time, time.Duration) {
+	if !found {
+		tokens = initial
+		lastRefill = now
+	}
+
+	// Refill for the time elapsed, capped at capacity
+	if elapsed := now.Sub(lastRefill).Seconds(); elapsed > 0 {
+		tokens += elapsed * refillRate
+		lastRefill = now
+	}
+	tokens = math.Min(tokens, float64(capacity))
+
+	// A negative n refunds tokens, still capped at capacity, even while in debt
+	need := math.Max(0, float64(n-maxDebt))
+	if n < 0 || tokens >= need {
+		return true, math.Min(tokens-float64(n), float64(capacity)), lastRefill, 0
+	}
+
+	// Round up to the millisecond the Lua path reports in
+	wait := (need - tokens) / refillRate
+	retryAfter := time.Duration(math.Ceil(wait*1000)) * time.Millisecond
+	return false, tokens, lastRefill, retryAfter
+}
+
+// milli is the number of milli-tokens in a token
+const milli = 1000
+
+// consumeMilliTokens is consumeTokens in integer milli-tokens
+// Refills credit whole milli-tokens and advance lastRefill only by the time they took,
+// so fractions carry over to the next step instead of being rounded away. As there,
+// lastRefill never moves back
+func consumeMilliTokens(tokens int64, lastRefill time.Time, found bool, n, capacity, limit int, window time.Duration, initial int64, now time.Time) (bool, int64, time.Time, time.Duration) {
+	if !found {
+		tokens = initial
+		lastRefill = now
+	}
+
+	capacityMilli := int64(capacity) * milli
+	rate := uint64(limit) * milli // Milli-tokens per window
+
+	if elapsed := now.Sub(lastRefill); elapsed > 0 && tokens < capacityMilli {
+		credited, ok := mulDiv(uint64(elapsed), rate, uint64(window))
+		if !ok || int64(credited) >= capacityMilli-tokens {
+			tokens = capacityMilli
+			lastRefill = now
+		} else {
+			// credited*window/rate <= elapsed, so this cannot overflow
+			took, _ := mulDiv(credited, uint64(window), rate)
+			tokens += int64(credited)
+			lastRefill = lastRefill.Add(time.Duration(took))
+		}
+	} else if tokens >= capacityMilli {
+		tokens = capacityMilli
+		if now.After(lastRefill) {
+			lastRefill = now
+		}
+	}
+
+	need := int64(n) * milli
+	if tokens >= need {
+		return true, min(tokens-need, capacityMilli), lastRefill, 0
+	}
+
+	// Round up to the millisecond, matching the float path
+	wait, ok := mulDiv(uint64(need-tokens), uint64(window), rate)
+	if !ok || wait > math.MaxInt64-uint64(time.Millisecond) {
+		return false, tokens, lastRefill, time.Duration(math.MaxInt64)
+	}
+	retryAfter := (time.Duration(wait) + time.Millisecond - 1).Truncate(time.Millisecond)
+	return false, tokens, lastRefill, retryAfter
+}
+
+// mulDiv returns a*b/c using a 128-bit intermediate, and false if the result overflows
+func mulDiv(a, b, c uint64) (uint64, bool) {
+	hi, lo := bits.Mul64(a, b)
+	if hi >= c {
+		return 0, false
+	}
+	q, _ := bits.Div64(hi, lo, c)
+	return q, q <= math.MaxInt64
+}
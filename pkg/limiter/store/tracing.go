@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans stores open
+const tracerName = "github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+
+// startSpan opens a span named name for a store call, as a child of the span in ctx
+// Stores are not given a tracer; they trace only calls made under a recording span, with
+// its provider, so untraced calls cost nothing
+func startSpan(ctx context.Context, name, storeType string) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.IsRecording() {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	return parent.TracerProvider().Tracer(tracerName).Start(ctx, name,
+		trace.WithAttributes(attribute.String("store", storeType)))
+}
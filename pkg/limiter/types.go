@@ -1,35 +1,308 @@
 package limiter
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // RateLimiter is the primary interface for rate limiting operations
+// The Ctx variants honor cancellation and deadlines; the plain methods
+// call them with context.Background()
 type RateLimiter interface {
 	// Allow checks if a single request is allowed for the given key
 	Allow(key string) (bool, *LimitInfo, error)
 
 	// AllowN checks if N requests are allowed for the given key
+	// N of zero consumes and writes nothing, reporting the key's state like Status
 	AllowN(key string, n int) (bool, *LimitInfo, error)
 
 	// Reset resets the rate limit for the given key
 	Reset(key string) error
+
+	// AllowCtx is Allow with a context
+	AllowCtx(ctx context.Context, key string) (bool, *LimitInfo, error)
+
+	// AllowNCtx is AllowN with a context
+	AllowNCtx(ctx context.Context, key string, n int) (bool, *LimitInfo, error)
+
+	// ResetCtx is Reset with a context
+	ResetCtx(ctx context.Context, key string) error
+
+	// Status reports the current limit state for the given key without consuming anything
+	// or writing to the store. Keys never seen report their full limit remaining
+	Status(key string) (*LimitInfo, error)
+
+	// StatusCtx is Status with a context
+	StatusCtx(ctx context.Context, key string) (*LimitInfo, error)
+
+	// Close releases the limiter's background resources and its store, or the limiters it
+	// wraps, after which checks fail with ErrClosed. Closing twice is a no-op. Limiters
+	// sharing a store close it together, so close them together at shutdown
+	Close() error
+}
+
+// Peeker is implemented by limiters that can evaluate a request without
+// consuming anything or writing to the store
+type Peeker interface {
+	// Peek reports whether N requests would be allowed for the given key
+	Peek(ctx context.Context, key string, n int) (bool, *LimitInfo, error)
+}
+
+// Describer is implemented by limiters that can report the policy they enforce
+// Policy documentation is built from it so it cannot drift from enforcement
+type Describer interface {
+	// Config returns the limiter's effective configuration, with Algorithm set
+	Config() Config
+}
+
+// UsageReporter is implemented by limiters that report a key's consumption directly from
+// their state, rather than as what is left of the limit
+type UsageReporter interface {
+	// Usage reports key's consumption in its current window without consuming anything
+	Usage(ctx context.Context, key string) (*Usage, error)
+}
+
+// Refunder is implemented by limiters that can give back requests they consumed
+// Composite limiters use it to undo one level when another denies
+type Refunder interface {
+	// Refund returns n previously consumed requests to the given key, never beyond its capacity
+	Refund(ctx context.Context, key string, n int) error
+}
+
+// PrefixResetter is implemented by limiters that can reset every key under a prefix at once
+// Offboarding a tenant uses it to clear all of "tenant42:*" without knowing each key
+type PrefixResetter interface {
+	// ResetPrefix resets every key starting with prefix, returning how many had state
+	ResetPrefix(prefix string) (int, error)
+	// ResetPrefixCtx is ResetPrefix with a context
+	ResetPrefixCtx(ctx context.Context, prefix string) (int, error)
+}
+
+// Reconfigurer is implemented by limiters whose policy can be changed while they serve checks
+// Config reloads use it so limits change without a restart
+type Reconfigurer interface {
+	// UpdateConfig switches to config's limit, window and burst; state already in the store is kept
+	UpdateConfig(config Config) error
+}
+
+// LimitSetter is implemented by limiters that can give single keys their own limit at runtime
+// Support uses it to raise one customer's limit without changing the policy for everyone
+type LimitSetter interface {
+	// SetLimit makes key use config's limit, window and burst instead of the policy's until cleared
+	SetLimit(key string, config Config) error
+
+	// SetLimitCtx is SetLimit with a context; the override lapses after ttl (0 = never)
+	SetLimitCtx(ctx context.Context, key string, config Config, ttl time.Duration) error
+
+	// ClearLimit returns key to the configured policy; clearing a key without an override is a no-op
+	ClearLimit(key string) error
+
+	// ClearLimitCtx is ClearLimit with a context
+	ClearLimitCtx(ctx context.Context, key string) error
+}
+
+// Waiter is implemented by limiters that can block until requests are allowed
+// Callers that would rather throttle than reject use it instead of AllowN
+type Waiter interface {
+	// WaitN blocks until n requests are allowed for key and consumes them, or until ctx is done
+	WaitN(ctx context.Context, key string, n int) error
+}
+
+// WaitEstimator is implemented by limiters that can say how long until requests would be
+// allowed, so callers can schedule work instead of retrying on denials
+type WaitEstimator interface {
+	// EstimateWait returns how long until AllowN(key, n) would succeed, zero if it would
+	// now, without consuming anything. Fails with ErrRequestExceedsCapacity if it never could
+	EstimateWait(key string, n int) (time.Duration, error)
+}
+
+// BatchRequest is one check in a batch: n requests for key
+type BatchRequest struct {
+	Key string
+	N   int
+}
+
+// BatchResult is the outcome of one check in a batch
+// Err is set, and Info nil, when that check alone failed
+type BatchResult struct {
+	Allowed bool
+	Info    *LimitInfo
+	Err     error
+}
+
+// BatchLimiter is implemented by limiters that can check many keys in one store round trip
+// Gateways checking several keys per request (user, IP, route) use it to cut latency
+type BatchLimiter interface {
+	// AllowBatch checks each request as AllowN would, returning results in the same order
+	// Keys are independent: one denying or failing does not affect the others. The error
+	// is for the batch as a whole, in which case nothing was consumed
+	AllowBatch(requests []BatchRequest) ([]BatchResult, error)
+
+	// AllowBatchCtx is AllowBatch with a context
+	AllowBatchCtx(ctx context.Context, requests []BatchRequest) ([]BatchResult, error)
+}
+
+// AtomicLimiter is implemented by limiters that can consume from several keys as one
+// Coupled quotas use it, such as a user's requests that must also fit a global limit
+type AtomicLimiter interface {
+	// AllowAll consumes ns[i] requests for keys[i], for every i, if every key has room for
+	// them, and otherwise consumes nothing. It returns each key's LimitInfo; when denied,
+	// the keys without room carry RetryAfter and MostRestrictive picks the one that decided.
+	// A key given more than once must have room for the sum of its requests
+	AllowAll(keys []string, ns []int) (bool, []*LimitInfo, error)
+
+	// AllowAllCtx is AllowAll with a context
+	AllowAllCtx(ctx context.Context, keys []string, ns []int) (bool, []*LimitInfo, error)
+}
+
+// MostRestrictive returns the info that limits the most: the one that must wait longest
+// to retry, or if none must wait, the one with the fewest requests remaining
+func MostRestrictive(infos []*LimitInfo) *LimitInfo {
+	var most *LimitInfo
+	for _, info := range infos {
+		switch {
+		case info == nil:
+		case most == nil:
+			most = info
+		case info.RetryAfter != nil:
+			if most.RetryAfter == nil || *info.RetryAfter > *most.RetryAfter {
+				most = info
+			}
+		case most.RetryAfter == nil && info.Remaining < most.Remaining:
+			most = info
+		}
+	}
+	return most
 }
 
 // LimitInfo provides detailed information about rate limit status
 type LimitInfo struct {
 	Limit      int            // Maximum number of requests allowed
 	Remaining  int            // Number of requests remaining
+	Used       int            // Requests counted against the limit; Limit - Remaining (token bucket: tokens not yet refilled)
 	ResetAt    time.Time      // Time when the limit resets
 	RetryAfter *time.Duration // Duration to wait before retrying (if denied)
+	Window     time.Duration  // Period the limit applies over (token bucket: time to refill Limit tokens)
+
+	// Policy names what matched: which of several combined policies this reports, e.g.
+	// "100/1m0s", or the tier whose limits applied (empty for the default single policy)
+	Policy string
+
+	// Reason says why a request was denied, one of the Reason constants (empty when allowed)
+	Reason string
 }
 
+// RetryAfterSeconds is RetryAfter in whole seconds, rounded up so a client waiting that
+// long never retries early (0 when there is no RetryAfter)
+func (info *LimitInfo) RetryAfterSeconds() int {
+	if info.RetryAfter == nil || *info.RetryAfter <= 0 {
+		return 0
+	}
+	return int((*info.RetryAfter + time.Second - 1) / time.Second)
+}
+
+// Usage is what a key has consumed of its limit over the window it is counted in
+type Usage struct {
+	Used        float64   // Requests counted against the limit; fractional for weighted windows and token buckets
+	Limit       int       // Maximum number of requests
+	WindowStart time.Time // Start of the window Used was counted over
+	WindowEnd   time.Time // End of that window
+}
+
+// Denial reasons reported in LimitInfo.Reason
+const (
+	ReasonWindowExhausted    = "window_exhausted"    // The window's count leaves no room for the request
+	ReasonBurstExceeded      = "burst_exceeded"      // The request is larger than the limiter ever admits at once
+	ReasonInsufficientTokens = "insufficient_tokens" // The bucket has not refilled enough tokens yet
+	ReasonRateExceeded       = "rate_exceeded"       // GCRA: the key is further ahead of schedule than its burst allows
+	ReasonBucketFull         = "bucket_full"         // Leaky bucket: not enough has drained to fit the request
+	ReasonQuotaExhausted     = "quota_exhausted"     // The period's quota is used up
+	ReasonPenalized          = "penalized"           // The key is serving a penalty for repeated denials
+	ReasonReserved           = "reserved"            // The remaining capacity is reserved for higher priorities
+	ReasonLimitLowered       = "limit_lowered"       // Adaptive: the key's limit was lowered below its usage
+	ReasonBanned             = "banned"              // The key is serving a temporary ban
+)
+
 // Config represents rate limiter configuration
 type Config struct {
-	Algorithm string        // Algorithm to use: token_bucket, sliding_window, fixed_window
+	Algorithm string        // Algorithm New builds: token_bucket, sliding_window, sliding_window_log, fixed_window, leaky_bucket, gcra, or a registered name
 	Limit     int           // Maximum number of requests
 	Window    time.Duration // Time window for the limit
 	Burst     int           // Burst capacity (for token bucket)
+
+	// InitialFill is the fraction of capacity (0-1) a token bucket key starts with
+	// when first seen; nil starts full
+	InitialFill *float64
+
+	// InitialTokens is how many tokens a token bucket key starts with when first seen,
+	// clamped to capacity; it takes precedence over InitialFill (nil = use InitialFill)
+	InitialTokens *int
+
+	// Precision selects token bucket accounting: PrecisionFloat (default) or PrecisionFixed
+	Precision string
+
+	// MaxDebt lets a token bucket request overdraw the bucket by up to this many tokens,
+	// after which the key is denied until refill repays the debt (0 = no debt; float precision only)
+	MaxDebt int
+
+	// Alignment puts fixed window boundaries on the UTC calendar: AlignHour, AlignDay or
+	// AlignMonth windows are exactly one calendar unit, so a daily quota resets at 00:00 UTC
+	// and a monthly one on the 1st. AlignEpoch (default) divides time into Window-long spans
+	Alignment string
+
+	// Buckets divides a sliding window counter's window into this many sub-windows, so old
+	// requests age out a bucket at a time instead of a whole window at a time
+	// (0 or 1 = interpolate between the current and previous window)
+	Buckets int
+
+	// WeightMode decides how much of the oldest sliding window bucket still counts as the
+	// window slides off it: WeightLinear (default) in proportion to the part left inside,
+	// WeightExponential mostly in full until late in its span, WeightNone in full until it
+	// has left, as if the window were the two fixed windows it overlaps
+	WeightMode string
+
+	// WindowBurst lets fixed and sliding window counters admit up to this many requests
+	// above Limit in a window, a grace for short spikes. Remaining still counts down to
+	// Limit, and requests over it keep the key denied until they age out (0 = none)
+	// Kept apart from Burst, which sizes the token bucket and is usually set above Limit
+	WindowBurst int
+
+	// ResetJitter delays each key's reported fixed window reset by a stable offset below it,
+	// a whole number of seconds derived from a hash of the key, so clients denied together
+	// do not all retry in the same second (0 = none)
+	ResetJitter time.Duration
+
+	// JitterWindows shifts each key's actual fixed window boundaries by its offset too, so
+	// the jittered reset is when the key's count really resets rather than a later retry hint
+	JitterWindows bool
 }
 
+// Fixed window alignments
+const (
+	AlignEpoch = "epoch"
+	AlignHour  = "hour"
+	AlignDay   = "day"
+	AlignMonth = "month"
+)
+
+// Sliding window weight modes, from most accurate to strictest
+const (
+	WeightLinear      = "linear"
+	WeightExponential = "exponential"
+	WeightNone        = "none"
+)
+
+// Token bucket accounting modes
+const (
+	// PrecisionFloat keeps tokens as float64; simple, but rounding error accumulates
+	// over millions of refills on very busy keys
+	PrecisionFloat = "float"
+
+	// PrecisionFixed keeps tokens as integer milli-tokens and refills with integer math,
+	// so balances never drift; sub-milli-token refills are carried until they add up
+	PrecisionFixed = "fixed"
+)
+
 // Window represents a time window with request count
 type Window struct {
 	Timestamp time.Time
@@ -37,10 +310,15 @@ type Window struct {
 }
 
 // Store abstracts the persistence layer (Redis, in-memory, etc.)
+// The Ctx variants honor cancellation and deadlines; the plain methods
+// call them with context.Background()
 type Store interface {
 	// Increment increments the counter for a key at a specific window
 	Increment(key string, window time.Time) (int64, error)
 
+	// IncrementBy adds delta to the counter for a key at a specific window
+	IncrementBy(key string, window time.Time, delta int64) (int64, error)
+
 	// GetWindows returns all windows for a key within a time range
 	GetWindows(key string, from, to time.Time) ([]Window, error)
 
@@ -48,11 +326,279 @@ type Store interface {
 	SetTokens(key string, tokens float64, lastRefill time.Time) error
 
 	// GetTokens gets the token count and last refill time for token bucket
-	GetTokens(key string) (tokens float64, lastRefill time.Time, err error)
+	// found is false for keys with no stored state, which report zero values
+	GetTokens(key string) (tokens float64, lastRefill time.Time, found bool, err error)
+
+	// ConsumeTokens atomically refills a token bucket to now, then takes n tokens if available
+	// Keys not seen before start with initial tokens. It returns whether the tokens were taken,
+	// the tokens left afterwards, and how long until n tokens would be available if not.
+	// A negative n refunds tokens, capped at capacity
+	ConsumeTokens(key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (allowed bool, tokens float64, retryAfter time.Duration, err error)
+
+	// AddTimestamps records n request timestamps for a key (sliding window log)
+	// The log expires after ttl without new entries
+	AddTimestamps(key string, ts time.Time, n int, ttl time.Duration) error
+
+	// GetTimestamps returns the logged timestamps for a key within a time range, oldest first
+	GetTimestamps(key string, from, to time.Time) ([]time.Time, error)
+
+	// TrimTimestamps removes logged timestamps older than before
+	TrimTimestamps(key string, before time.Time) error
 
 	// Delete removes all data for a key
 	Delete(key string) error
 
+	// IncrementCtx is Increment with a context
+	IncrementCtx(ctx context.Context, key string, window time.Time) (int64, error)
+
+	// IncrementByCtx is IncrementBy with a context
+	IncrementByCtx(ctx context.Context, key string, window time.Time, delta int64) (int64, error)
+
+	// GetWindowsCtx is GetWindows with a context
+	GetWindowsCtx(ctx context.Context, key string, from, to time.Time) ([]Window, error)
+
+	// SetTokensCtx is SetTokens with a context
+	SetTokensCtx(ctx context.Context, key string, tokens float64, lastRefill time.Time) error
+
+	// GetTokensCtx is GetTokens with a context
+	GetTokensCtx(ctx context.Context, key string) (tokens float64, lastRefill time.Time, found bool, err error)
+
+	// ConsumeTokensCtx is ConsumeTokens with a context
+	ConsumeTokensCtx(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, now time.Time) (allowed bool, tokens float64, retryAfter time.Duration, err error)
+
+	// AddTimestampsCtx is AddTimestamps with a context
+	AddTimestampsCtx(ctx context.Context, key string, ts time.Time, n int, ttl time.Duration) error
+
+	// GetTimestampsCtx is GetTimestamps with a context
+	GetTimestampsCtx(ctx context.Context, key string, from, to time.Time) ([]time.Time, error)
+
+	// TrimTimestampsCtx is TrimTimestamps with a context
+	TrimTimestampsCtx(ctx context.Context, key string, before time.Time) error
+
+	// DeleteCtx is Delete with a context
+	DeleteCtx(ctx context.Context, key string) error
+
+	// Ping reports whether the store is reachable
+	Ping(ctx context.Context) error
+
 	// Close closes the store connection
 	Close() error
 }
+
+// MilliTokenConsumer is implemented by stores that can run token bucket steps in integer
+// milli-tokens for PrecisionFixed, refilling limit tokens per window
+type MilliTokenConsumer interface {
+	// ConsumeMilliTokensCtx refills the bucket to now, then takes n tokens if available
+	// Keys not seen before start with initial milli-tokens. With dry set nothing is written.
+	// It returns whether the tokens were (or would be) taken, the milli-tokens left afterwards,
+	// and how long until n tokens would be available if not
+	ConsumeMilliTokensCtx(ctx context.Context, key string, n, capacity, limit int, window time.Duration, initial int64, now time.Time, dry bool) (allowed bool, milliTokens int64, retryAfter time.Duration, err error)
+}
+
+// DebtTokenConsumer is implemented by stores that can let token bucket steps overdraw
+type DebtTokenConsumer interface {
+	// ConsumeTokensDebtCtx is ConsumeTokens, except that n tokens are also taken while the
+	// balance is not negative and would stay at or above -maxDebt afterwards
+	ConsumeTokensDebtCtx(ctx context.Context, key string, n int, capacity int, refillRate float64, initial float64, maxDebt int, now time.Time) (allowed bool, tokens float64, retryAfter time.Duration, err error)
+}
+
+// TokenRequest is one token bucket step in a batch: take n tokens from key
+type TokenRequest struct {
+	Key string
+	N   int
+}
+
+// TokenResult is the outcome of one token bucket step in a batch, as ConsumeTokens returns
+// it; Err is set when that step alone failed
+type TokenResult struct {
+	Allowed    bool
+	Tokens     float64
+	RetryAfter time.Duration
+	Err        error
+}
+
+// BatchTokenConsumer is implemented by stores that can run many token bucket steps in one
+// round trip, for buckets sharing capacity, refill rate and initial tokens
+type BatchTokenConsumer interface {
+	// ConsumeTokensBatchCtx runs ConsumeTokens for each request, each atomic on its own key,
+	// returning results in the same order. The error is for the batch as a whole
+	ConsumeTokensBatchCtx(ctx context.Context, requests []TokenRequest, capacity int, refillRate float64, initial float64, now time.Time) ([]TokenResult, error)
+}
+
+// AtomicTokenConsumer is implemented by stores that can run token bucket steps on several
+// keys as one, for buckets sharing capacity, refill rate and initial tokens
+type AtomicTokenConsumer interface {
+	// ConsumeTokensAllCtx refills each request's bucket to now and takes its n tokens if
+	// every bucket has them, and otherwise takes nothing. Keys must be distinct. Results are
+	// in request order, each as ConsumeTokens would report that key's step on its own
+	ConsumeTokensAllCtx(ctx context.Context, requests []TokenRequest, capacity int, refillRate float64, initial float64, now time.Time) (allowed bool, results []TokenResult, err error)
+}
+
+// WindowPruner is implemented by stores that can drop a key's old windows as they count
+// Sliding window counters use it so buckets that have aged out do not accumulate
+type WindowPruner interface {
+	// IncrementPruneCtx is IncrementBy, also removing the key's windows that started more than
+	// retain before window and keeping the key for at least retain
+	IncrementPruneCtx(ctx context.Context, key string, window time.Time, delta int64, retain time.Duration) (int64, error)
+}
+
+// PrefixDeleter is implemented by stores that can find and delete keys by prefix
+// Limiters use it for ResetPrefix
+type PrefixDeleter interface {
+	// DeletePrefixCtx deletes what DeleteCtx would for every key starting with prefix,
+	// returning how many keys had state. prefix must not be empty
+	DeletePrefixCtx(ctx context.Context, prefix string) (int, error)
+}
+
+// TimestampCounter is implemented by stores that can count a timestamp log without reading it
+// Callers that only need how many requests fall in a window use it instead of GetTimestamps
+type TimestampCounter interface {
+	// CountTimestampsCtx removes logged timestamps older than from, then returns how many
+	// remain up to and including to, in one atomic step
+	CountTimestampsCtx(ctx context.Context, key string, from, to time.Time) (int64, error)
+}
+
+// QuotaCounter is implemented by stores that can keep long-lived counters that only grow
+// Quota limiters use it for usage that must survive for a whole billing period
+type QuotaCounter interface {
+	// AddQuotaCtx adds n to the key's counter if it would stay within limit, in one atomic
+	// step, and keeps the key for ttl from now. A negative n refunds, never below zero, and
+	// with dry set nothing is written. It returns whether n was (or would be) added and the
+	// counter afterwards
+	AddQuotaCtx(ctx context.Context, key string, n, limit int64, ttl time.Duration, dry bool) (allowed bool, used int64, err error)
+}
+
+// LogAllower is implemented by stores that can check and append to a timestamp log in one step
+// The sliding window log uses it when available so concurrent instances cannot overshoot the limit
+type LogAllower interface {
+	// AllowLogCtx trims entries older than now-window, then appends n entries at now if the
+	// log would stay within limit. It returns whether they were appended and the in-window
+	// timestamps afterwards, oldest first
+	AllowLogCtx(ctx context.Context, key string, now time.Time, window time.Duration, limit, n int) (bool, []time.Time, error)
+}
+
+// Reservation is quota taken by a two-phase reserve, held until it is committed, canceled
+// or expires
+type Reservation struct {
+	ID        string
+	Limiter   string // Name of the limiter the quota was taken from, so it can be returned
+	Key       string
+	N         int
+	ExpiresAt time.Time
+}
+
+// ReservationStore is implemented by stores that can hold two-phase reservations
+// Each reservation is taken back exactly once, by TakeReservationCtx or
+// TakeExpiredReservationsCtx, so its quota is kept or returned once across instances
+type ReservationStore interface {
+	// AddReservationCtx records r until it is taken back
+	AddReservationCtx(ctx context.Context, r Reservation) error
+
+	// TakeReservationCtx removes and returns the reservation with id if it has not expired at
+	// now. ok is false for unknown, already taken and expired reservations; expired ones are
+	// left for TakeExpiredReservationsCtx
+	TakeReservationCtx(ctx context.Context, id string, now time.Time) (r Reservation, ok bool, err error)
+
+	// TakeExpiredReservationsCtx removes and returns up to limit reservations expired at now,
+	// soonest expired first
+	TakeExpiredReservationsCtx(ctx context.Context, now time.Time, limit int) ([]Reservation, error)
+}
+
+// PenaltyPolicy describes how repeat offenders are penalized
+type PenaltyPolicy struct {
+	Threshold int           // Denials a key may collect within Window before its level rises
+	Window    time.Duration // Span denials are counted over, starting at the first one
+	MaxLevel  int           // Highest level; a key at level L waits 2^L times the limiter's retry time
+	Decay     time.Duration // Quiet time after a penalty ends before the key's level drops back to zero
+}
+
+// Penalizer is implemented by stores that can track repeat offenders for escalating penalties
+type Penalizer interface {
+	// PenalizeCtx records a denial of key at now in one atomic step. The denial that takes the
+	// count within policy.Window past policy.Threshold raises the key's level by one, up to
+	// policy.MaxLevel, and starts a new count. The key is then penalized until at least
+	// now + retryAfter<<level. A key not denied for policy.Decay after its penalty ended
+	// starts over at level zero. It returns the level and when the penalty ends
+	PenalizeCtx(ctx context.Context, key string, now time.Time, retryAfter time.Duration, policy PenaltyPolicy) (level int, until time.Time, err error)
+
+	// PenaltyCtx returns key's level and when its penalty ends without recording a denial
+	// A key whose penalty ended more than decay ago is at level zero
+	PenaltyCtx(ctx context.Context, key string, now time.Time, decay time.Duration) (level int, until time.Time, err error)
+}
+
+// BanPolicy describes when keys that keep being denied are banned
+type BanPolicy struct {
+	Threshold int           // Denials a key may collect within Window before it is banned
+	Window    time.Duration // Span denials are counted over, starting at the first one
+	Duration  time.Duration // How long a ban lasts
+}
+
+// Banner is implemented by stores that can temporarily ban keys that keep being denied
+type Banner interface {
+	// RecordDenialCtx records a denial of key at now in one atomic step. The denial that takes
+	// the count within policy.Window past policy.Threshold bans the key until now + policy.Duration
+	// and starts a new count. It returns when the key's ban ends (zero if it is not banned)
+	RecordDenialCtx(ctx context.Context, key string, now time.Time, policy BanPolicy) (until time.Time, err error)
+
+	// BannedCtx returns when key's ban ends, or the zero time if it is not banned at now
+	// It is a single read, cheap enough to run before every check
+	BannedCtx(ctx context.Context, key string, now time.Time) (until time.Time, err error)
+
+	// UnbanCtx lifts key's ban and forgets its denials, reporting whether it was banned at now
+	UnbanCtx(ctx context.Context, key string, now time.Time) (bool, error)
+
+	// BansCtx returns every key banned at now and when its ban ends
+	BansCtx(ctx context.Context, now time.Time) (map[string]time.Time, error)
+}
+
+// LimitOverride is a limit set at runtime for one key in place of the configured policy's
+type LimitOverride struct {
+	Limit     int
+	Window    time.Duration
+	Burst     int       // Token bucket capacity (0 = the limit)
+	ExpiresAt time.Time // When the override lapses (zero = never)
+}
+
+// LimitOverrideStore is implemented by stores that can keep per-key limit overrides, so they
+// are shared by every instance and survive restarts
+type LimitOverrideStore interface {
+	// SetLimitOverrideCtx sets key's override, replacing any it had. It is gone once
+	// override.ExpiresAt is reached, measured from now
+	SetLimitOverrideCtx(ctx context.Context, key string, override LimitOverride, now time.Time) error
+
+	// LimitOverrideCtx returns key's override, if it has one at now
+	// It is a single read, cheap enough to run before every check
+	LimitOverrideCtx(ctx context.Context, key string, now time.Time) (LimitOverride, bool, error)
+
+	// DeleteLimitOverrideCtx removes key's override, reporting whether it had one at now
+	DeleteLimitOverrideCtx(ctx context.Context, key string, now time.Time) (bool, error)
+}
+
+// KeyGroupStore is implemented by stores that can map identifiers onto shared limit groups,
+// so every instance resolves them alike and the mapping can change at runtime
+type KeyGroupStore interface {
+	// SetKeyGroupCtx puts identifier in group, moving it out of any group it was in
+	SetKeyGroupCtx(ctx context.Context, identifier, group string) error
+
+	// KeyGroupCtx returns the group identifier is in, if any
+	// It is a single read, cheap enough to run before every check
+	KeyGroupCtx(ctx context.Context, identifier string) (string, bool, error)
+
+	// DeleteKeyGroupCtx takes identifier out of its group, reporting whether it was in one
+	DeleteKeyGroupCtx(ctx context.Context, identifier string) (bool, error)
+}
+
+// RequestRecorder is implemented by stores that can remember responses by request ID, so a
+// retried request replays the first response instead of consuming again
+type RequestRecorder interface {
+	// ClaimRequestCtx claims id for ttl, atomically, so of two identical retries only one
+	// runs. If id is already claimed, claimed is false and result is what RecordRequestCtx
+	// stored for it, or nil while the claiming request is still running
+	ClaimRequestCtx(ctx context.Context, id string, ttl time.Duration) (claimed bool, result []byte, err error)
+
+	// RecordRequestCtx stores the result of the request that claimed id, kept for ttl
+	RecordRequestCtx(ctx context.Context, id string, result []byte, ttl time.Duration) error
+
+	// ReleaseRequestCtx forgets id, so a request that failed can be retried
+	ReleaseRequestCtx(ctx context.Context, id string) error
+}
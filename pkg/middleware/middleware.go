@@ -0,0 +1,171 @@
+// Package middleware provides net/http middleware that enforces a rate limiter in-process
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+// DenialHandler writes the response for a request the limiter denied
+// Rate limit headers are already set when it is called
+type DenialHandler func(w http.ResponseWriter, r *http.Request, info *limiter.LimitInfo)
+
+// Option configures the middleware
+type Option func(*config)
+
+type config struct {
+	onDenied DenialHandler
+	style    string
+}
+
+// Header styles for WithHeaderStyle, naming limit, remaining and reset
+const (
+	HeaderStyleLegacy = "legacy" // X-RateLimit-Limit/Remaining/Reset, reset as a Unix time (default)
+	HeaderStyleDraft  = "draft"  // RateLimit-Limit/Remaining/Reset of the IETF draft, reset in delta-seconds
+	HeaderStyleBoth   = "both"   // Both sets
+)
+
+// WithDenialHandler replaces the default 429 JSON response for denied requests
+func WithDenialHandler(h DenialHandler) Option {
+	return func(c *config) {
+		c.onDenied = h
+	}
+}
+
+// WithHeaderStyle names the limit, remaining and reset headers; see HeaderStyleLegacy
+// X-RateLimit-Used and X-RateLimit-Window have no draft equivalent and are sent in every style
+func WithHeaderStyle(style string) Option {
+	return func(c *config) {
+		c.style = style
+	}
+}
+
+// Middleware admits each request through rl under the key returned by keyFunc
+// Allowed requests reach next with the limit, remaining and reset headers set; denied requests
+// also get Retry-After and a 429 JSON body unless a DenialHandler is supplied
+func Middleware(rl limiter.RateLimiter, keyFunc func(*http.Request) string, opts ...Option) func(http.Handler) http.Handler {
+	cfg := config{onDenied: writeDenied}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, info, err := rl.AllowNCtx(r.Context(), keyFunc(r), 1)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			writeHeaders(w.Header(), cfg.style, info, time.Now())
+
+			if !allowed {
+				cfg.onDenied(w, r, info)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// KeyByIP keys requests by the client IP from RemoteAddr
+// Behind a proxy, use keys.FromIP with the proxy's addresses instead
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// KeyByHeader keys requests by the value of the named header, e.g. an API key
+func KeyByHeader(name string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// writeHeaders sets the rate limit headers the server's check endpoint sends by default
+// now is when the decision was made, which the draft reset counts from
+func writeHeaders(h http.Header, style string, info *limiter.LimitInfo, now time.Time) {
+	if style != HeaderStyleDraft {
+		h.Set("X-RateLimit-Limit", strconv.Itoa(info.Limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(info.Remaining))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(info.ResetAt.Unix(), 10))
+	}
+	if style == HeaderStyleDraft || style == HeaderStyleBoth {
+		h.Set("RateLimit-Limit", strconv.Itoa(info.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(info.Remaining))
+		h.Set("RateLimit-Reset", strconv.FormatInt(resetDelta(info.ResetAt, now), 10))
+	}
+	h.Set("X-RateLimit-Used", strconv.Itoa(info.Used))
+	if info.Window > 0 {
+		h.Set("X-RateLimit-Window", strconv.Itoa(int(info.Window.Seconds())))
+	}
+	if info.RetryAfter != nil {
+		h.Set("Retry-After", strconv.Itoa(info.RetryAfterSeconds()))
+	}
+}
+
+// resetDelta is the whole seconds from now until resetAt, rounded up
+func resetDelta(resetAt, now time.Time) int64 {
+	d := resetAt.Sub(now)
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+// deniedResponse mirrors the server's check response body
+type deniedResponse struct {
+	Allowed    bool   `json:"allowed"`
+	Limit      int    `json:"limit"`
+	Remaining  int    `json:"remaining"`
+	Used       int    `json:"used"`
+	Window     int    `json:"window"`
+	ResetAt    string `json:"reset_at"`
+	RetryAfter *int   `json:"retry_after,omitempty"` // Seconds to wait before retrying
+	Reason     string `json:"reason,omitempty"`      // Why the request was denied, e.g. window_exhausted
+}
+
+// writeDenied is the default DenialHandler
+func writeDenied(w http.ResponseWriter, r *http.Request, info *limiter.LimitInfo) {
+	resp := deniedResponse{
+		Limit:     info.Limit,
+		Remaining: info.Remaining,
+		Used:      info.Used,
+		Window:    int(info.Window.Seconds()),
+		ResetAt:   info.ResetAt.Format(time.RFC3339),
+		Reason:    info.Reason,
+	}
+	if info.RetryAfter != nil {
+		retrySeconds := info.RetryAfterSeconds()
+		resp.RetryAfter = &retrySeconds
+	}
+	writeJSON(w, http.StatusTooManyRequests, resp)
+}
+
+// writeError responds to a failed check: 503 while the store is unreachable, 400 for a key
+// the limiter rejects, and 500 otherwise
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, limiter.ErrStoreUnavailable):
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "store unavailable", "code": "store_unavailable"})
+	case errors.Is(err, limiter.ErrKeyTooLong):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error(), "code": "invalid_request"})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "rate limit check failed"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
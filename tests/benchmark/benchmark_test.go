@@ -2,13 +2,22 @@ package benchmark
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
-	"github.com/AbubakarMahmood1/go-rate-limiter/internal/algorithms"
-	"github.com/AbubakarMahmood1/go-rate-limiter/internal/store"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
 	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Benchmark Token Bucket algorithm
@@ -54,6 +63,37 @@ func BenchmarkSlidingWindowCounter(b *testing.B) {
 	})
 }
 
+// Benchmark Sliding Window Counter with every bucket of an hour populated, comparing the
+// two-window scheme with one-minute buckets
+func BenchmarkSlidingWindowCounterBuckets(b *testing.B) {
+	for _, buckets := range []int{1, 60} {
+		b.Run(fmt.Sprintf("buckets=%d", buckets), func(b *testing.B) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+
+			clock := simulation.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			swc := algorithms.NewSlidingWindowCounter(s, limiter.Config{
+				Limit:   1000000,
+				Window:  time.Hour,
+				Buckets: buckets,
+			}, algorithms.WithClock(clock))
+
+			// Fill the previous hour so each check reads a full window of buckets
+			for minute := 0; minute < 60; minute++ {
+				for i := 0; i < 100; i++ {
+					swc.Allow(fmt.Sprintf("key-%d", i))
+				}
+				clock.Advance(time.Minute)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				swc.Allow(fmt.Sprintf("key-%d", i%100))
+			}
+		})
+	}
+}
+
 // Benchmark Fixed Window Counter algorithm
 func BenchmarkFixedWindowCounter(b *testing.B) {
 	s := store.NewMemoryStore()
@@ -75,6 +115,71 @@ func BenchmarkFixedWindowCounter(b *testing.B) {
 	})
 }
 
+// Benchmark Sliding Window Log algorithm
+func BenchmarkSlidingWindowLog(b *testing.B) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	swl := algorithms.NewSlidingWindowLog(s, limiter.Config{
+		Limit:  1000,
+		Window: 1 * time.Second,
+	})
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := fmt.Sprintf("key-%d", i%100)
+			swl.Allow(key)
+			i++
+		}
+	})
+}
+
+// Benchmark Leaky Bucket algorithm
+func BenchmarkLeakyBucket(b *testing.B) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	lb := algorithms.NewLeakyBucket(s, limiter.Config{
+		Limit:  1000000,
+		Window: 1 * time.Second,
+		Burst:  1000000,
+	})
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := fmt.Sprintf("key-%d", i%100)
+			lb.Allow(key)
+			i++
+		}
+	})
+}
+
+// Benchmark GCRA algorithm
+func BenchmarkGCRA(b *testing.B) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	g := algorithms.NewGCRA(s, limiter.Config{
+		Limit:  1000000,
+		Window: 1 * time.Second,
+		Burst:  1000000,
+	})
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := fmt.Sprintf("key-%d", i%100)
+			g.Allow(key)
+			i++
+		}
+	})
+}
+
 // Benchmark concurrent access with single key
 func BenchmarkConcurrentSingleKey(b *testing.B) {
 	s := store.NewMemoryStore()
@@ -194,6 +299,83 @@ func BenchmarkMemoryStoreSetGetTokens(b *testing.B) {
 	})
 }
 
+// Benchmark token bucket checks on Redis, directly and behind the tiered store's memory cache
+// Needs a Redis at REDIS_ADDR (e.g. localhost:6379)
+func BenchmarkTokenBucketRedisVsTiered(b *testing.B) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		b.Skip("REDIS_ADDR not set")
+	}
+
+	newRedis := func(b *testing.B) *store.RedisStore {
+		rs, err := store.NewRedisStore(store.RedisConfig{Addresses: []string{addr}, KeyPrefix: "bench:"})
+		if err != nil {
+			b.Fatal(err)
+		}
+		return rs
+	}
+	run := func(b *testing.B, s limiter.Store) {
+		tb := algorithms.NewTokenBucket(s, limiter.Config{
+			Limit:  1000000,
+			Window: 1 * time.Second,
+			Burst:  1000000,
+		})
+
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				key := fmt.Sprintf("key-%d", i%100)
+				tb.Allow(key)
+				i++
+			}
+		})
+	}
+
+	b.Run("Redis", func(b *testing.B) {
+		rs := newRedis(b)
+		defer rs.Close()
+		run(b, rs)
+	})
+	b.Run("Tiered", func(b *testing.B) {
+		ts := store.NewTieredStore(store.NewMemoryStore(), newRedis(b), store.DefaultSyncInterval)
+		defer ts.Close()
+		run(b, ts)
+	})
+}
+
+// Benchmark the check handler: binding, key building, metrics and headers around the limiter
+func BenchmarkCheckHandler(b *testing.B) {
+	gin.SetMode(gin.ReleaseMode)
+
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{
+			Limit:  1000000,
+			Window: 1 * time.Second,
+			Burst:  1000000,
+		}),
+	}
+	h := handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "token_bucket")
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	body := `{"resource":"api.bench","identifier":"user-1"}`
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/v1/check", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status %d", w.Code)
+		}
+	}
+}
+
 // Benchmark realistic workload
 func BenchmarkRealisticWorkload(b *testing.B) {
 	s := store.NewMemoryStore()
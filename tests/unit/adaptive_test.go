@@ -0,0 +1,157 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAdaptive(t *testing.T, config algorithms.AdaptiveConfig) *algorithms.AdaptiveLimiter {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 10, Window: time.Minute})
+	al, err := algorithms.NewAdaptiveLimiter(base, config)
+	require.NoError(t, err)
+	return al
+}
+
+func TestAdaptive_AIMD(t *testing.T) {
+	al := newAdaptive(t, algorithms.AdaptiveConfig{Floor: 2, Ceiling: 10, Increase: 1, Decrease: 0.5})
+
+	assert.Equal(t, 10, al.Limit("k"))
+	assert.Equal(t, 5, al.ReportOutcome("k", false))
+	assert.Equal(t, 2, al.ReportOutcome("k", false))
+	assert.Equal(t, 2, al.ReportOutcome("k", false), "never below the floor")
+
+	assert.Equal(t, 3, al.ReportOutcome("k", true))
+	for i := 0; i < 20; i++ {
+		al.ReportOutcome("k", true)
+	}
+	assert.Equal(t, 10, al.Limit("k"), "never above the ceiling")
+
+	assert.Equal(t, 10, al.Limit("other"), "keys adapt independently")
+}
+
+func TestAdaptive_EnforcesEffectiveLimit(t *testing.T) {
+	al := newAdaptive(t, algorithms.AdaptiveConfig{Floor: 1, Ceiling: 10})
+	al.ReportOutcome("k", false) // 10 -> 5
+
+	for i := 0; i < 5; i++ {
+		allowed, info, err := al.Allow("k")
+		require.NoError(t, err)
+		require.True(t, allowed, "request %d", i+1)
+		assert.Equal(t, 5, info.Limit)
+		assert.Equal(t, 4-i, info.Remaining)
+	}
+
+	allowed, info, err := al.Allow("k")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+	require.NotNil(t, info.RetryAfter)
+
+	// Denials under the effective limit do not consume the wrapped limiter's quota,
+	// so recovering the limit admits the difference immediately
+	for i := 0; i < 5; i++ {
+		al.ReportOutcome("k", true)
+	}
+	for i := 0; i < 5; i++ {
+		allowed, _, err := al.Allow("k")
+		require.NoError(t, err)
+		assert.True(t, allowed, "request %d after recovery", i+1)
+	}
+	allowed, _, err = al.Allow("k")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestAdaptive_ValidateConfig(t *testing.T) {
+	for name, config := range map[string]algorithms.AdaptiveConfig{
+		"zero floor":        {Floor: 0, Ceiling: 10},
+		"ceiling below":     {Floor: 5, Ceiling: 4},
+		"decrease too high": {Floor: 1, Ceiling: 10, Decrease: 1},
+		"negative increase": {Floor: 1, Ceiling: 10, Increase: -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, config.Validate())
+		})
+	}
+
+	config := algorithms.AdaptiveConfig{Floor: 1, Ceiling: 10}
+	require.NoError(t, config.Validate())
+	assert.Equal(t, 1, config.Increase)
+	assert.Equal(t, 0.5, config.Decrease)
+}
+
+func TestFeedback_AdjustsStatusAndGauge(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 100, Window: time.Minute})
+	al, err := algorithms.NewAdaptiveLimiter(base, algorithms.AdaptiveConfig{Floor: 10, Ceiling: 100})
+	require.NoError(t, err)
+
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	h := handlers.NewRateLimitHandler(map[string]limiter.RateLimiter{"fixed_window": al}, m, "fixed_window")
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	feedback := func(success bool) int {
+		w := doJSON(router, http.MethodPost, "/v1/feedback", map[string]interface{}{
+			"resource":   "api.orders",
+			"identifier": "alice",
+			"success":    success,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp struct {
+			Limit int `json:"limit"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Limit
+	}
+
+	assert.Equal(t, 50, feedback(false))
+	assert.Equal(t, 25, feedback(false))
+	assert.Equal(t, 26, feedback(true))
+	assert.Equal(t, 26.0, testutil.ToFloat64(m.AdaptiveLimit.WithLabelValues("fixed_window", "alice:api.orders")))
+
+	w := doJSON(router, http.MethodGet, "/v1/status/alice:api.orders", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var status handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, 26, status.Limit)
+
+	// success is required so a missing field cannot be read as a failure
+	w = doJSON(router, http.MethodPost, "/v1/feedback", map[string]interface{}{
+		"resource":   "api.orders",
+		"identifier": "alice",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestFeedback_NotEnabled(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/v1/feedback", map[string]interface{}{
+		"resource":   "api.orders",
+		"identifier": "alice",
+		"success":    false,
+	})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -1,16 +1,24 @@
 package unit
 
 import (
+	"context"
+	"math"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/AbubakarMahmood1/go-rate-limiter/internal/algorithms"
-	"github.com/AbubakarMahmood1/go-rate-limiter/internal/store"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
 	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// Starts on a second boundary, so one-second fixed windows line up with it
+var clockEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func TestTokenBucket_Allow(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
@@ -42,25 +50,25 @@ func TestTokenBucket_Refill(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
 
+	clock := simulation.NewManualClock(clockEpoch)
 	tb := algorithms.NewTokenBucket(s, limiter.Config{
 		Limit:  10,
 		Window: 1 * time.Second,
 		Burst:  10,
-	})
+	}, algorithms.WithClock(clock))
 
 	// Consume all tokens
 	for i := 0; i < 10; i++ {
 		tb.Allow("test-key")
 	}
 
-	// Wait for refill (10 tokens per second)
-	time.Sleep(500 * time.Millisecond)
+	// Half a second refills 5 tokens at 10 per second
+	clock.Advance(500 * time.Millisecond)
 
-	// Should have ~5 tokens refilled
 	allowed, info, err := tb.Allow("test-key")
 	require.NoError(t, err)
 	assert.True(t, allowed, "request should be allowed after refill")
-	assert.Greater(t, info.Remaining, 0)
+	assert.Equal(t, 4, info.Remaining)
 }
 
 func TestTokenBucket_AllowN(t *testing.T) {
@@ -117,6 +125,375 @@ func TestTokenBucket_Reset(t *testing.T) {
 	assert.Equal(t, 10, info.Limit)
 }
 
+func TestTokenBucket_InitialFill(t *testing.T) {
+	tests := []struct {
+		name      string
+		fill      *float64
+		remaining int // After the first request
+	}{
+		{"unset starts full", nil, 9},
+		{"half", floatPtr(0.5), 4},
+		{"quarter", floatPtr(0.25), 1},
+		{"clamped above one", floatPtr(2), 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+
+			tb := algorithms.NewTokenBucket(s, limiter.Config{
+				Limit:       10,
+				Window:      1 * time.Hour,
+				Burst:       10,
+				InitialFill: tt.fill,
+			})
+
+			allowed, info, err := tb.Allow("new-key")
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			assert.Equal(t, tt.remaining, info.Remaining)
+		})
+	}
+}
+
+func TestTokenBucket_InitialFillEmpty(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{
+		Limit:       10,
+		Window:      1 * time.Second,
+		Burst:       10,
+		InitialFill: floatPtr(0),
+	}, algorithms.WithClock(clock))
+
+	allowed, info, err := tb.Allow("new-key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.NotNil(t, info.RetryAfter)
+
+	// Tokens are earned at the normal refill rate
+	clock.Advance(250 * time.Millisecond)
+	allowed, _, err = tb.AllowN("new-key", 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestTokenBucket_StartEmpty(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	config := limiter.Config{Limit: 10, Window: 1 * time.Second, Burst: 10}
+	tb := algorithms.NewTokenBucket(s, config, algorithms.WithClock(clock), algorithms.WithStartEmpty())
+
+	allowed, info, err := tb.Allow("new-key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, 100*time.Millisecond, *info.RetryAfter)
+
+	// Tokens are earned at the normal refill rate, up to capacity
+	clock.Advance(300 * time.Millisecond)
+	allowed, info, err = tb.AllowN("new-key", 3)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+
+	clock.Advance(5 * time.Second)
+	allowed, info, err = tb.Allow("new-key")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 9, info.Remaining)
+
+	// The seed survives a config update
+	require.NoError(t, tb.UpdateConfig(config))
+	allowed, _, err = tb.Allow("other-key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestTokenBucket_InitialTokens(t *testing.T) {
+	tests := []struct {
+		name      string
+		tokens    int
+		remaining int // After the first request
+	}{
+		{"seeded", 4, 3},
+		{"clamped to capacity", 50, 9},
+		{"negative starts empty", -5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+
+			// The option takes precedence over the fill fraction
+			tb := algorithms.NewTokenBucket(s, limiter.Config{
+				Limit:       10,
+				Window:      1 * time.Hour,
+				Burst:       10,
+				InitialFill: floatPtr(0.5),
+			}, algorithms.WithInitialTokens(tt.tokens))
+
+			_, info, err := tb.Allow("new-key")
+			require.NoError(t, err)
+			assert.Equal(t, tt.remaining, info.Remaining)
+		})
+	}
+}
+
+func TestTokenBucket_ConfigInitialTokens(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	// Config.InitialTokens takes precedence over the fill fraction
+	clock := simulation.NewManualClock(clockEpoch)
+	config := limiter.Config{
+		Limit:         10,
+		Window:        1 * time.Second,
+		Burst:         10,
+		InitialFill:   floatPtr(0.5),
+		InitialTokens: intPtr(0),
+	}
+	tb := algorithms.NewTokenBucket(s, config, algorithms.WithClock(clock))
+
+	allowed, info, err := tb.Allow("new-key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+
+	// Tokens are earned at the normal refill rate
+	clock.Advance(200 * time.Millisecond)
+	allowed, _, err = tb.AllowN("new-key", 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// The option takes precedence over the config
+	seeded := algorithms.NewTokenBucket(s, config, algorithms.WithClock(clock), algorithms.WithInitialTokens(4))
+	_, info, err = seeded.Allow("seeded-key")
+	require.NoError(t, err)
+	assert.Equal(t, 3, info.Remaining)
+}
+
+func TestTokenBucket_InitialTokensConcurrentFirstTouch(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: 1 * time.Hour, Burst: 10},
+		algorithms.WithClock(clock), algorithms.WithInitialTokens(3))
+
+	// Every first request sees the same seeded bucket, so exactly the seed is admitted
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		admitted int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			allowed, _, err := tb.Allow("new-key")
+			assert.NoError(t, err)
+			if allowed {
+				mu.Lock()
+				admitted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 3, admitted)
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+func intPtr(i int) *int { return &i }
+
+// driftAfterMillionOps runs a million refill steps against a large bucket and returns how far
+// the stored balance ends up from the exact value. Every step takes a token, since only
+// consuming stores the refill; the bucket never fills or empties, so the exact balance
+// is initial + refilled - taken
+func driftAfterMillionOps(t *testing.T, precision string) float64 {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	const (
+		ops      = 1_000_000
+		capacity = 1_000_000_000
+		step     = 7 * time.Millisecond
+	)
+	clock := simulation.NewManualClock(simulation.DefaultStart)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{
+		Limit:       100,
+		Window:      time.Minute,
+		Burst:       capacity,
+		InitialFill: floatPtr(0.5),
+		Precision:   precision,
+	}, algorithms.WithClock(clock))
+
+	taken := 0
+	for i := 0; i < ops; i++ {
+		allowed, _, err := tb.AllowN("busy", 1)
+		require.NoError(t, err)
+		require.True(t, allowed)
+		taken++
+		clock.Advance(step)
+	}
+
+	// The last step's refill is for (ops-1) intervals
+	elapsed := float64((ops-1)*step) / float64(time.Minute)
+	exact := capacity/2 + 100*elapsed - float64(taken)
+
+	var got float64
+	if precision == limiter.PrecisionFixed {
+		milliTokens, _, _, err := s.GetTokens("milli:busy")
+		require.NoError(t, err)
+		got = milliTokens / 1000
+	} else {
+		tokens, _, _, err := s.GetTokens("busy")
+		require.NoError(t, err)
+		got = tokens
+	}
+	return math.Abs(got - exact)
+}
+
+func TestTokenBucket_FixedPrecisionDoesNotDrift(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs two million limiter operations")
+	}
+
+	fixedDrift := driftAfterMillionOps(t, limiter.PrecisionFixed)
+	floatDrift := driftAfterMillionOps(t, limiter.PrecisionFloat)
+	t.Logf("drift after 1M ops: fixed=%g float=%g", fixedDrift, floatDrift)
+
+	// Fixed-point is exact to its 1/1000-token resolution; float rounding accumulates past it
+	assert.Less(t, fixedDrift, 0.001)
+	assert.Greater(t, floatDrift, fixedDrift)
+}
+
+func TestTokenBucket_FixedPrecision(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(simulation.DefaultStart)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{
+		Limit:     3,
+		Window:    time.Second,
+		Burst:     3,
+		Precision: limiter.PrecisionFixed,
+	}, algorithms.WithClock(clock))
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := tb.Allow("k")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, info, err := tb.Allow("k")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, 334*time.Millisecond, *info.RetryAfter)
+
+	// One token every 333.33ms: 100ms steps add up without rounding the fractions away
+	for i := 0; i < 3; i++ {
+		clock.Advance(100 * time.Millisecond)
+		allowed, _, err = tb.Allow("k")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	}
+	clock.Advance(34 * time.Millisecond)
+	allowed, _, err = tb.Allow("k")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	require.NoError(t, tb.Reset("k"))
+	allowed, info, err = tb.Allow("k")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 2, info.Remaining)
+}
+
+// assertSaneInfo checks that info's reset and retry times are finite, not in the past and
+// no later than bound from now
+func assertSaneInfo(t *testing.T, info *limiter.LimitInfo, now time.Time, bound time.Duration) {
+	t.Helper()
+	assert.False(t, info.ResetAt.Before(now), "reset %s is in the past", info.ResetAt)
+	assert.False(t, info.ResetAt.After(now.Add(bound)), "reset %s is too far out", info.ResetAt)
+	if info.RetryAfter != nil {
+		assert.GreaterOrEqual(t, *info.RetryAfter, time.Duration(0))
+		assert.LessOrEqual(t, *info.RetryAfter, bound)
+	}
+}
+
+func TestTokenBucket_ZeroWindowDefaultsToOneSecond(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 5}, algorithms.WithClock(clock))
+	assert.Equal(t, time.Second, tb.Config().Window)
+
+	for i := 0; i < 5; i++ {
+		allowed, info, err := tb.Allow("key")
+		require.NoError(t, err)
+		require.True(t, allowed)
+		assertSaneInfo(t, info, clockEpoch, time.Second)
+	}
+
+	allowed, info, err := tb.Allow("key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, 200*time.Millisecond, *info.RetryAfter, "5 per second refills one every 200ms")
+	assertSaneInfo(t, info, clockEpoch, time.Second)
+}
+
+func TestTokenBucket_MillisecondWindow(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: 10 * time.Millisecond}, algorithms.WithClock(clock))
+
+	allowAll(t, tb, "key", 10)
+	allowed, info, err := tb.Allow("key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, time.Millisecond, *info.RetryAfter)
+	assertSaneInfo(t, info, clockEpoch, 10*time.Millisecond)
+
+	clock.Advance(time.Millisecond)
+	allowed, _, err = tb.Allow("key")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestTokenBucket_ZeroLimitWaitsAreFinite(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 0, Burst: 1, Window: time.Second}, algorithms.WithClock(clock))
+
+	allowAll(t, tb, "key", 1)
+	allowed, info, err := tb.Allow("key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.NotNil(t, info.RetryAfter)
+	assert.Positive(t, *info.RetryAfter, "a bucket that never refills never has room")
+	assert.True(t, info.ResetAt.After(clockEpoch))
+}
+
 func TestSlidingWindowCounter_Allow(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
@@ -144,23 +521,102 @@ func TestSlidingWindowCounter_SlidingBehavior(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
 
+	clock := simulation.NewManualClock(clockEpoch)
 	swc := algorithms.NewSlidingWindowCounter(s, limiter.Config{
 		Limit:  10,
 		Window: 1 * time.Second,
-	})
+	}, algorithms.WithClock(clock))
 
-	// Consume 5 tokens
-	for i := 0; i < 5; i++ {
-		swc.Allow("test-key")
+	// Fill the first window
+	allowAll(t, swc, "test-key", 10)
+
+	// Half way through the next window half of the first still counts
+	clock.Advance(1500 * time.Millisecond)
+	allowed, info, err := swc.Allow("test-key")
+	require.NoError(t, err)
+	assert.True(t, allowed, "the window has slid past half of the earlier requests")
+	assert.Equal(t, 4, info.Remaining)
+}
+
+func TestSlidingWindowCounter_WeightModes(t *testing.T) {
+	// Halfway through the window after a full one, each mode counts the earlier
+	// requests for a different share of the limit
+	tests := []struct {
+		name    string
+		mode    string
+		allowed int
+	}{
+		{"default", "", 50},
+		{"linear", limiter.WeightLinear, 50},
+		{"exponential", limiter.WeightExponential, 18},
+		{"none", limiter.WeightNone, 0},
 	}
 
-	// Wait for half window
-	time.Sleep(500 * time.Millisecond)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
 
-	// Should be able to make more requests as window slides
-	allowed, _, err := swc.Allow("test-key")
-	require.NoError(t, err)
-	assert.True(t, allowed)
+			clock := simulation.NewManualClock(clockEpoch)
+			swc := algorithms.NewSlidingWindowCounter(s, limiter.Config{
+				Limit:      100,
+				Window:     10 * time.Second,
+				WeightMode: tt.mode,
+			}, algorithms.WithClock(clock))
+
+			allowed, _, err := swc.AllowN("user", 100)
+			require.NoError(t, err)
+			require.True(t, allowed)
+
+			clock.Advance(15 * time.Second)
+			count := 0
+			for {
+				allowed, _, err := swc.Allow("user")
+				require.NoError(t, err)
+				if !allowed {
+					break
+				}
+				count++
+			}
+			assert.Equal(t, tt.allowed, count)
+		})
+	}
+}
+
+func TestSlidingWindowCounter_WeightModeRetryAfter(t *testing.T) {
+	for _, mode := range []string{limiter.WeightLinear, limiter.WeightExponential, limiter.WeightNone} {
+		t.Run(mode, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+
+			clock := simulation.NewManualClock(clockEpoch)
+			swc := algorithms.NewSlidingWindowCounter(s, limiter.Config{
+				Limit:      10,
+				Window:     10 * time.Second,
+				Buckets:    2,
+				WeightMode: mode,
+			}, algorithms.WithClock(clock))
+
+			allowAll(t, swc, "user", 10)
+			clock.Advance(6 * time.Second)
+
+			allowed, info, err := swc.AllowN("user", 4)
+			require.NoError(t, err)
+			require.False(t, allowed)
+			require.NotNil(t, info.RetryAfter)
+
+			// Retry-After is exactly when the oldest bucket has aged out enough
+			clock.Advance(*info.RetryAfter - time.Millisecond)
+			allowed, _, err = swc.Peek(context.Background(), "user", 4)
+			require.NoError(t, err)
+			assert.False(t, allowed, "a millisecond early")
+
+			clock.Advance(time.Millisecond)
+			allowed, _, err = swc.AllowN("user", 4)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		})
+	}
 }
 
 func TestFixedWindowCounter_Allow(t *testing.T) {
@@ -190,18 +646,19 @@ func TestFixedWindowCounter_WindowReset(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
 
+	clock := simulation.NewManualClock(clockEpoch)
 	fwc := algorithms.NewFixedWindowCounter(s, limiter.Config{
 		Limit:  10,
 		Window: 1 * time.Second,
-	})
+	}, algorithms.WithClock(clock))
 
 	// Consume all tokens
 	for i := 0; i < 10; i++ {
 		fwc.Allow("test-key")
 	}
 
-	// Wait for window to reset
-	time.Sleep(1100 * time.Millisecond)
+	// Move into the next window
+	clock.Advance(1 * time.Second)
 
 	// Should be able to make requests again
 	allowed, info, err := fwc.Allow("test-key")
@@ -210,6 +667,120 @@ func TestFixedWindowCounter_WindowReset(t *testing.T) {
 	assert.Equal(t, 9, info.Remaining)
 }
 
+func TestWindowCounters_AllowNCountsEveryRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func(s limiter.Store, config limiter.Config, clock limiter.Clock) limiter.RateLimiter
+	}{
+		{"fixed window", func(s limiter.Store, config limiter.Config, clock limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewFixedWindowCounter(s, config, algorithms.WithClock(clock))
+		}},
+		{"sliding window", func(s limiter.Store, config limiter.Config, clock limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewSlidingWindowCounter(s, config, algorithms.WithClock(clock))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+
+			rl := tt.new(s, limiter.Config{Limit: 100, Window: time.Minute}, simulation.NewManualClock(clockEpoch))
+
+			// Ten batches of ten exhaust the window exactly
+			for i := 0; i < 10; i++ {
+				allowed, info, err := rl.AllowN("test-key", 10)
+				require.NoError(t, err)
+				assert.True(t, allowed, "batch %d should be allowed", i+1)
+				assert.Equal(t, 100-10*(i+1), info.Remaining)
+			}
+
+			allowed, info, err := rl.AllowN("test-key", 10)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.Equal(t, 0, info.Remaining)
+
+			allowed, _, err = rl.Allow("test-key")
+			require.NoError(t, err)
+			assert.False(t, allowed, "a single request no longer fits either")
+		})
+	}
+}
+
+func TestWindowCounters_WindowBurst(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func(s limiter.Store, config limiter.Config, clock limiter.Clock) limiter.RateLimiter
+	}{
+		{"fixed window", func(s limiter.Store, config limiter.Config, clock limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewFixedWindowCounter(s, config, algorithms.WithClock(clock))
+		}},
+		{"sliding window", func(s limiter.Store, config limiter.Config, clock limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewSlidingWindowCounter(s, config, algorithms.WithClock(clock))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+
+			clock := simulation.NewManualClock(clockEpoch)
+			rl := tt.new(s, limiter.Config{Limit: 10, Window: time.Second, WindowBurst: 5}, clock)
+
+			allowAll(t, rl, "test-key", 10)
+
+			// The burst admits five more, with nothing left against the limit
+			for i := 0; i < 5; i++ {
+				allowed, info, err := rl.Allow("test-key")
+				require.NoError(t, err)
+				assert.True(t, allowed, "burst request %d should be allowed", i+1)
+				assert.Equal(t, 10, info.Limit)
+				assert.Equal(t, 0, info.Remaining)
+			}
+
+			allowed, info, err := rl.Allow("test-key")
+			require.NoError(t, err)
+			assert.False(t, allowed, "the burst is spent")
+			assert.Equal(t, limiter.ReasonWindowExhausted, info.Reason)
+			require.NotNil(t, info.RetryAfter)
+
+			_, info, err = rl.AllowN("other-key", 16)
+			require.NoError(t, err)
+			assert.Equal(t, limiter.ReasonBurstExceeded, info.Reason, "more than limit plus burst never fits")
+
+			// The token bucket's Burst does not widen the windows
+			unchanged := tt.new(s, limiter.Config{Limit: 10, Window: time.Second, Burst: 20}, clock)
+			allowAll(t, unchanged, "plain-key", 10)
+			allowed, _, err = unchanged.Allow("plain-key")
+			require.NoError(t, err)
+			assert.False(t, allowed)
+		})
+	}
+}
+
+func TestSlidingWindowCounter_WindowBurstWeighsOnNextWindow(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	swc := algorithms.NewSlidingWindowCounter(s, limiter.Config{
+		Limit:       10,
+		Window:      1 * time.Second,
+		WindowBurst: 5,
+	}, algorithms.WithClock(clock))
+
+	allowAll(t, swc, "test-key", 15)
+
+	// Half of the 15 still count halfway through the next window, against a limit of 10:
+	// the client has less left than the 4 it would have after a window of just 10
+	clock.Advance(1500 * time.Millisecond)
+	allowed, info, err := swc.Allow("test-key")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, info.Remaining)
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
@@ -266,3 +837,603 @@ func TestMultipleKeys(t *testing.T) {
 	assert.False(t, allowed1)
 	assert.False(t, allowed2)
 }
+
+func TestSlidingWindowLog_Allow(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	swl := algorithms.NewSlidingWindowLog(s, limiter.Config{
+		Limit:  10,
+		Window: 1 * time.Second,
+	})
+
+	// Should allow first 10 requests
+	for i := 0; i < 10; i++ {
+		allowed, info, err := swl.Allow("test-key")
+		require.NoError(t, err)
+		assert.True(t, allowed, "request %d should be allowed", i+1)
+		assert.Equal(t, 9-i, info.Remaining)
+	}
+
+	// 11th request should be denied
+	allowed, info, err := swl.Allow("test-key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+	require.NotNil(t, info.RetryAfter)
+	assert.LessOrEqual(t, *info.RetryAfter, 1*time.Second)
+}
+
+func TestSlidingWindowLog_RetryAfterFromOldestEntry(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	swl := algorithms.NewSlidingWindowLog(s, limiter.Config{
+		Limit:  2,
+		Window: 1 * time.Second,
+	}, algorithms.WithClock(clock))
+
+	swl.Allow("test-key")
+	clock.Advance(400 * time.Millisecond)
+	swl.Allow("test-key")
+
+	// Only the first entry has to expire, 600ms from now
+	allowed, info, err := swl.Allow("test-key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, 600*time.Millisecond, *info.RetryAfter)
+
+	// The window includes its start, so the entry leaves it just after that
+	clock.Advance(*info.RetryAfter + time.Millisecond)
+
+	allowed, _, err = swl.Allow("test-key")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestSlidingWindowLog_AllowN(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	swl := algorithms.NewSlidingWindowLog(s, limiter.Config{
+		Limit:  10,
+		Window: 1 * time.Second,
+	})
+
+	allowed, info, err := swl.AllowN("test-key", 7)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 3, info.Remaining)
+
+	allowed, info, err = swl.AllowN("test-key", 4)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 3, info.Remaining)
+
+	require.NoError(t, swl.Reset("test-key"))
+	allowed, info, err = swl.AllowN("test-key", 10)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+}
+
+func TestSlidingWindowLog_NoBoundaryBurst(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	limit := 10
+	window := 200 * time.Millisecond
+	clock := limitertest.NewFakeClock(clockEpoch)
+	swl := algorithms.NewSlidingWindowLog(s, limiter.Config{
+		Limit:  limit,
+		Window: window,
+	}, algorithms.WithClock(clock))
+
+	// Hammer the key across several window boundaries, recording every allow
+	var allowedAt []time.Time
+	deadline := clock.Now().Add(5 * window)
+	for clock.Now().Before(deadline) {
+		allowed, _, err := swl.Allow("test-key")
+		require.NoError(t, err)
+		if allowed {
+			allowedAt = append(allowedAt, clock.Now())
+		}
+		clock.Advance(2 * time.Millisecond)
+	}
+	require.Greater(t, len(allowedAt), limit, "limit should refill as entries slide out")
+
+	// No trailing window, wherever it starts, may contain more than limit allows
+	for i, end := range allowedAt {
+		inWindow := 0
+		for _, at := range allowedAt[:i+1] {
+			if end.Sub(at) < window {
+				inWindow++
+			}
+		}
+		assert.LessOrEqual(t, inWindow, limit, "window ending at allow %d", i)
+	}
+}
+
+func TestTokenBucket_ClockJumpBackwards(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := limitertest.NewFakeClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{
+		Limit:  10,
+		Window: time.Second,
+		Burst:  10,
+	}, algorithms.WithClock(clock))
+
+	allowed, _, err := tb.AllowN("test-key", 5)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// A clock stepped back neither refills nor drains the bucket
+	clock.Set(clockEpoch.Add(-time.Minute))
+	info, err := tb.Status("test-key")
+	require.NoError(t, err)
+	assert.Equal(t, 5, info.Remaining)
+
+	allowed, info, err = tb.Allow("test-key")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 4, info.Remaining)
+}
+
+func TestLeakyBucket_Allow(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	lb := algorithms.NewLeakyBucket(s, limiter.Config{
+		Limit:  10,
+		Window: 1 * time.Second,
+		Burst:  5,
+	})
+
+	// Bucket fills after Burst requests
+	for i := 0; i < 5; i++ {
+		allowed, info, err := lb.Allow("test-key")
+		require.NoError(t, err)
+		assert.True(t, allowed, "request %d should be allowed", i+1)
+		assert.Equal(t, 5, info.Limit)
+		assert.Equal(t, 4-i, info.Remaining)
+	}
+
+	allowed, info, err := lb.Allow("test-key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+
+	// One request drains in 100ms at 10/s
+	require.NotNil(t, info.RetryAfter)
+	assert.InDelta(t, 100*time.Millisecond, *info.RetryAfter, float64(20*time.Millisecond))
+}
+
+func TestLeakyBucket_Drain(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	lb := algorithms.NewLeakyBucket(s, limiter.Config{
+		Limit:  10,
+		Window: 1 * time.Second,
+		Burst:  5,
+	}, algorithms.WithClock(clock))
+
+	allowed, _, err := lb.AllowN("test-key", 5)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Three requests need 300ms of draining
+	allowed, info, err := lb.AllowN("test-key", 3)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, 300*time.Millisecond, *info.RetryAfter)
+
+	clock.Advance(*info.RetryAfter)
+
+	allowed, info, err = lb.AllowN("test-key", 3)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+
+	require.NoError(t, lb.Reset("test-key"))
+	allowed, info, err = lb.AllowN("test-key", 5)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+}
+
+func TestLeakyBucket_IndependentOfTokenBucket(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	config := limiter.Config{Limit: 5, Window: 1 * time.Second}
+	tb := algorithms.NewTokenBucket(s, config)
+	lb := algorithms.NewLeakyBucket(s, config)
+
+	// Draining the token bucket must not fill the leaky bucket for the same key
+	allowed, _, err := tb.AllowN("shared-key", 5)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, info, err := lb.Allow("shared-key")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 4, info.Remaining)
+}
+
+func TestGCRA_SteadyRate(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	// One request per 50ms with no burst beyond a single request
+	clock := simulation.NewManualClock(clockEpoch)
+	g := algorithms.NewGCRA(s, limiter.Config{
+		Limit:  20,
+		Window: 1 * time.Second,
+		Burst:  1,
+	}, algorithms.WithClock(clock))
+
+	allowed, _, err := g.Allow("test-key")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, info, err := g.Allow("test-key")
+	require.NoError(t, err)
+	assert.False(t, allowed, "second request arrives ahead of schedule")
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, 50*time.Millisecond, *info.RetryAfter)
+
+	clock.Advance(*info.RetryAfter)
+	allowed, _, err = g.Allow("test-key")
+	require.NoError(t, err)
+	assert.True(t, allowed, "request on schedule is allowed")
+}
+
+func TestGCRA_BurstAbsorption(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	g := algorithms.NewGCRA(s, limiter.Config{
+		Limit:  10,
+		Window: 1 * time.Second,
+		Burst:  5,
+	})
+
+	for i := 0; i < 5; i++ {
+		allowed, info, err := g.Allow("test-key")
+		require.NoError(t, err)
+		assert.True(t, allowed, "request %d should be absorbed by the burst", i+1)
+		assert.Equal(t, 5, info.Limit)
+		assert.Equal(t, 4-i, info.Remaining)
+	}
+
+	allowed, info, err := g.Allow("test-key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+	require.NotNil(t, info.RetryAfter)
+	assert.InDelta(t, 100*time.Millisecond, *info.RetryAfter, float64(10*time.Millisecond))
+
+	// AllowN needs room for all n at once
+	allowed, info, err = g.AllowN("other-key", 6)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 5, info.Remaining)
+	assert.Equal(t, 500*time.Millisecond, *info.RetryAfter)
+
+	allowed, info, err = g.AllowN("other-key", 3)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 2, info.Remaining)
+}
+
+func TestGCRA_LongIdleDoesNotBankBurst(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	g := algorithms.NewGCRA(s, limiter.Config{
+		Limit:  20,
+		Window: 1 * time.Second,
+		Burst:  3,
+	}, algorithms.WithClock(clock))
+
+	allowed, _, err := g.AllowN("test-key", 3)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Idle for many emission intervals
+	clock.Advance(time.Hour)
+
+	// Only the configured burst is available, not the idle time's worth
+	allowed, info, err := g.AllowN("test-key", 3)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+
+	allowed, _, err = g.Allow("test-key")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	require.NoError(t, g.Reset("test-key"))
+	allowed, _, err = g.AllowN("test-key", 3)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestAllowNCtx_CanceledContext(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	config := limiter.Config{Limit: 10, Window: 1 * time.Second, Burst: 10}
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket":       algorithms.NewTokenBucket(s, config),
+		"sliding_window":     algorithms.NewSlidingWindowCounter(s, config),
+		"sliding_window_log": algorithms.NewSlidingWindowLog(s, config),
+		"fixed_window":       algorithms.NewFixedWindowCounter(s, config),
+		"leaky_bucket":       algorithms.NewLeakyBucket(s, config),
+		"gcra":               algorithms.NewGCRA(s, config),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, rl := range limiters {
+		allowed, _, err := rl.AllowNCtx(ctx, "test-key", 1)
+		assert.ErrorIs(t, err, context.Canceled, name)
+		assert.False(t, allowed, name)
+
+		// The non-context wrapper keeps working
+		allowed, _, err = rl.Allow("test-key")
+		require.NoError(t, err, name)
+		assert.True(t, allowed, name)
+	}
+}
+
+// algorithmBuilders builds each built-in algorithm enforcing config on a store and clock
+func algorithmBuilders(config limiter.Config) map[string]func(limiter.Store, limiter.Clock) limiter.RateLimiter {
+	return map[string]func(limiter.Store, limiter.Clock) limiter.RateLimiter{
+		"token_bucket": func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewTokenBucket(s, config, algorithms.WithClock(c))
+		},
+		"sliding_window": func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewSlidingWindowCounter(s, config, algorithms.WithClock(c))
+		},
+		"sliding_window_log": func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewSlidingWindowLog(s, config, algorithms.WithClock(c))
+		},
+		"fixed_window": func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewFixedWindowCounter(s, config, algorithms.WithClock(c))
+		},
+		"leaky_bucket": func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewLeakyBucket(s, config, algorithms.WithClock(c))
+		},
+		"gcra": func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewGCRA(s, config, algorithms.WithClock(c))
+		},
+	}
+}
+
+func TestStatus_DoesNotConsume(t *testing.T) {
+	for name, build := range algorithmBuilders(limiter.Config{Limit: 3, Window: time.Minute}) {
+		t.Run(name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+			rl := build(s, simulation.NewManualClock(clockEpoch))
+
+			// A key never seen has its whole limit
+			info, err := rl.Status("user")
+			require.NoError(t, err)
+			assert.Equal(t, 3, info.Limit)
+			assert.Equal(t, 3, info.Remaining)
+			assert.False(t, info.ResetAt.Before(clockEpoch))
+			assert.False(t, info.ResetAt.After(clockEpoch.Add(time.Minute)))
+
+			allowed, _, err := rl.Allow("user")
+			require.NoError(t, err)
+			require.True(t, allowed)
+
+			for i := 0; i < 10; i++ {
+				info, err := rl.Status("user")
+				require.NoError(t, err)
+				assert.Equal(t, 2, info.Remaining, "status %d", i+1)
+			}
+
+			// Status calls left the rest of the limit in place
+			for i := 0; i < 2; i++ {
+				allowed, _, err := rl.Allow("user")
+				require.NoError(t, err)
+				assert.True(t, allowed)
+			}
+			allowed, _, err = rl.Allow("user")
+			require.NoError(t, err)
+			assert.False(t, allowed)
+		})
+	}
+}
+
+func TestAllowNZero_DoesNotConsume(t *testing.T) {
+	for name, build := range algorithmBuilders(limiter.Config{Limit: 3, Window: time.Minute}) {
+		t.Run(name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+			rl := build(s, simulation.NewManualClock(clockEpoch))
+
+			allowed, _, err := rl.Allow("user")
+			require.NoError(t, err)
+			require.True(t, allowed)
+
+			for i := 0; i < 1000; i++ {
+				allowed, info, err := rl.AllowN("user", 0)
+				require.NoError(t, err)
+				require.True(t, allowed)
+				require.Equal(t, 2, info.Remaining, "check %d", i+1)
+
+				info, err = rl.Status("user")
+				require.NoError(t, err)
+				require.Equal(t, 2, info.Remaining, "status %d", i+1)
+			}
+
+			// The rest of the limit is still there
+			allowAll(t, rl, "user", 2)
+			allowed, _, err = rl.Allow("user")
+			require.NoError(t, err)
+			assert.False(t, allowed)
+		})
+	}
+}
+
+func TestTokenBucket_AllowNZeroKeepsRefillTime(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := simulation.NewManualClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: 10 * time.Second}, algorithms.WithClock(clock))
+
+	allowed, _, err := tb.AllowN("user", 5)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Reading the bucket reports the refill so far without storing it
+	clock.Advance(2 * time.Second)
+	allowed, info, err := tb.AllowN("user", 0)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 7, info.Remaining)
+
+	tokens, lastRefill, _, err := s.GetTokens("user")
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, tokens)
+	assert.True(t, lastRefill.Equal(clockEpoch))
+
+	// A key never seen is not created
+	_, _, err = tb.AllowN("new-key", 0)
+	require.NoError(t, err)
+	_, _, found, err := s.GetTokens("new-key")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestTokenBucket_FractionalRefillWaits(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	// Four tokens refill per second, so waits fall between whole seconds
+	clock := simulation.NewManualClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 8, Window: 2 * time.Second}, algorithms.WithClock(clock))
+
+	allowed, info, err := tb.AllowN("user", 8)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	assert.True(t, info.ResetAt.Equal(clockEpoch.Add(2*time.Second)), "reset at %v", info.ResetAt)
+
+	clock.Advance(250 * time.Millisecond)
+	allowed, info, err = tb.AllowN("user", 3)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, 500*time.Millisecond, *info.RetryAfter, "two more tokens at 4/s")
+	assert.True(t, clock.Now().Add(1750*time.Millisecond).Equal(info.ResetAt), "reset at %v", info.ResetAt)
+	assert.Equal(t, 1, info.RetryAfterSeconds(), "rounded up, never down to 0")
+}
+
+func TestLimitInfo_RetryAfterSecondsRoundsUp(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		want       int
+	}{
+		{300 * time.Millisecond, 1},
+		{time.Second, 1},
+		{time.Second + time.Millisecond, 2},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		retryAfter := tt.retryAfter
+		info := &limiter.LimitInfo{RetryAfter: &retryAfter}
+		assert.Equal(t, tt.want, info.RetryAfterSeconds(), tt.retryAfter)
+	}
+	assert.Zero(t, (&limiter.LimitInfo{}).RetryAfterSeconds())
+}
+
+func TestRefund_ReturnsConsumedRequests(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	config := limiter.Config{Limit: 3, Window: time.Hour}
+	fixed := config
+	fixed.Precision = limiter.PrecisionFixed
+	refunders := map[string]limiter.RateLimiter{
+		"token_bucket":       algorithms.NewTokenBucket(s, config),
+		"token_bucket_fixed": algorithms.NewTokenBucket(s, fixed),
+		"leaky_bucket":       algorithms.NewLeakyBucket(s, config),
+		"gcra":               algorithms.NewGCRA(s, config),
+	}
+
+	for name, rl := range refunders {
+		t.Run(name, func(t *testing.T) {
+			key := "refund-" + name
+			refunder, ok := rl.(limiter.Refunder)
+			require.True(t, ok)
+
+			for i := 0; i < 3; i++ {
+				allowed, _, err := rl.Allow(key)
+				require.NoError(t, err)
+				require.True(t, allowed)
+			}
+
+			require.NoError(t, refunder.Refund(context.Background(), key, 2))
+			for i := 0; i < 2; i++ {
+				allowed, _, err := rl.Allow(key)
+				require.NoError(t, err)
+				assert.True(t, allowed, "refunded request %d", i+1)
+			}
+			allowed, _, err := rl.Allow(key)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+
+			// Refunds never lift a key above its capacity
+			require.NoError(t, refunder.Refund(context.Background(), key, 10))
+			_, info, err := rl.(limiter.Peeker).Peek(context.Background(), key, 1)
+			require.NoError(t, err)
+			assert.Equal(t, 3, info.Remaining)
+		})
+	}
+}
+
+func TestLimitInfo_UsedAndWindow(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	config := limiter.Config{Limit: 5, Window: time.Minute, Burst: 5}
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket":       algorithms.NewTokenBucket(s, config),
+		"leaky_bucket":       algorithms.NewLeakyBucket(s, config),
+		"fixed_window":       algorithms.NewFixedWindowCounter(s, config),
+		"sliding_window":     algorithms.NewSlidingWindowCounter(s, config),
+		"sliding_window_log": algorithms.NewSlidingWindowLog(s, config),
+		"gcra":               algorithms.NewGCRA(s, config),
+	}
+
+	for name, rl := range limiters {
+		t.Run(name, func(t *testing.T) {
+			key := "used-" + name
+			for i := 1; i <= 6; i++ {
+				_, info, err := rl.Allow(key)
+				require.NoError(t, err)
+				assert.Equal(t, info.Limit, info.Remaining+info.Used, "request %d", i)
+				assert.Equal(t, min(i, 5), info.Used, "request %d", i)
+				assert.Equal(t, time.Minute, info.Window)
+			}
+
+			info, err := rl.Status(key)
+			require.NoError(t, err)
+			assert.Equal(t, 5, info.Used)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package unit
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAlignedCounter(t *testing.T, alignment string, window time.Duration, start time.Time) (*algorithms.FixedWindowCounter, *simulation.ManualClock) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	clock := simulation.NewManualClock(start)
+	config := limiter.Config{Limit: 2, Window: window, Alignment: alignment}
+	return algorithms.NewFixedWindowCounter(s, config, algorithms.WithClock(clock)), clock
+}
+
+func TestFixedWindow_AlignedResetAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		alignment string
+		window    time.Duration
+		now       time.Time
+		resetAt   time.Time
+	}{
+		{
+			name:      "hour",
+			alignment: limiter.AlignHour,
+			window:    time.Hour,
+			now:       time.Date(2024, 3, 10, 13, 45, 12, 0, time.UTC),
+			resetAt:   time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "day",
+			alignment: limiter.AlignDay,
+			window:    24 * time.Hour,
+			now:       time.Date(2024, 3, 10, 23, 59, 59, 0, time.UTC),
+			resetAt:   time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "day across year end",
+			alignment: limiter.AlignDay,
+			window:    24 * time.Hour,
+			now:       time.Date(2024, 12, 31, 8, 0, 0, 0, time.UTC),
+			resetAt:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "month of 31 days from its last day",
+			alignment: limiter.AlignMonth,
+			window:    31 * 24 * time.Hour,
+			now:       time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+			resetAt:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "leap february",
+			alignment: limiter.AlignMonth,
+			window:    30 * 24 * time.Hour,
+			now:       time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC),
+			resetAt:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "common february",
+			alignment: limiter.AlignMonth,
+			window:    30 * 24 * time.Hour,
+			now:       time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC),
+			resetAt:   time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "month across year end",
+			alignment: limiter.AlignMonth,
+			window:    30 * 24 * time.Hour,
+			now:       time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC),
+			resetAt:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fwc, _ := newAlignedCounter(t, tt.alignment, tt.window, tt.now)
+
+			allowed, info, err := fwc.Allow("user")
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			assert.True(t, info.ResetAt.Equal(tt.resetAt), "reset at %s, want %s", info.ResetAt, tt.resetAt)
+		})
+	}
+}
+
+func TestFixedWindow_DayAlignedIgnoresLocalZone(t *testing.T) {
+	// 20:00 in New York on a DST change day is 00:00 UTC the next day
+	zone, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	now := time.Date(2024, 3, 10, 20, 30, 0, 0, zone)
+
+	fwc, _ := newAlignedCounter(t, limiter.AlignDay, 24*time.Hour, now)
+	_, info, err := fwc.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, info.ResetAt.Equal(time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.UTC, info.ResetAt.Location())
+}
+
+func TestFixedWindow_MonthAlignedResetsOnTheFirst(t *testing.T) {
+	fwc, clock := newAlignedCounter(t, limiter.AlignMonth, 30*24*time.Hour, time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC))
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := fwc.Allow("user")
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	// A 30-day window would not have ended yet; the calendar month has
+	allowed, info, err := fwc.Allow("user")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 48*time.Hour, *info.RetryAfter)
+
+	clock.Advance(48*time.Hour - time.Nanosecond)
+	allowed, _, err = fwc.Allow("user")
+	require.NoError(t, err)
+	assert.False(t, allowed, "still January")
+
+	clock.Advance(time.Nanosecond)
+	allowed, info, err = fwc.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed, "February is a new window")
+	assert.True(t, info.ResetAt.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)), "29 days in leap February")
+}
+
+func TestFixedWindow_AlignedCountsOutliveStoreExpiry(t *testing.T) {
+	// The memory store sweeps windows older than a day and Redis expires keys after its TTL,
+	// both shorter than these windows; a count dropped early would let the key start over
+	tests := []struct {
+		name      string
+		alignment string
+		window    time.Duration
+		start     time.Time
+		ttl       time.Duration
+		advance   time.Duration
+	}{
+		{"month past the memory sweep", limiter.AlignMonth, 31 * 24 * time.Hour, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), 24 * time.Hour, 3 * 24 * time.Hour},
+		{"day past a short redis ttl", limiter.AlignDay, 24 * time.Hour, time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC), time.Hour, 20 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := simulation.NewManualClock(tt.start)
+			s := &redisLikeStore{MemoryStore: store.NewMemoryStore(), clock: clock, ttl: tt.ttl, expires: map[string]time.Time{}}
+			t.Cleanup(func() { s.Close() })
+			fwc := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 5, Window: tt.window, Alignment: tt.alignment}, algorithms.WithClock(clock))
+
+			allowAll(t, fwc, "user", 5)
+
+			clock.Advance(tt.advance)
+			allowed, info, err := fwc.Allow("user")
+			require.NoError(t, err)
+			assert.False(t, allowed, "the window has not ended")
+			assert.Equal(t, 0, info.Remaining)
+		})
+	}
+}
+
+func TestFixedWindow_EpochAlignmentUnchanged(t *testing.T) {
+	now := time.Date(2024, 3, 10, 13, 45, 0, 0, time.UTC)
+	for _, alignment := range []string{"", limiter.AlignEpoch} {
+		fwc, _ := newAlignedCounter(t, alignment, 7*time.Minute, now)
+
+		_, info, err := fwc.Allow("user")
+		require.NoError(t, err)
+		assert.True(t, info.ResetAt.Equal(now.Truncate(7*time.Minute).Add(7*time.Minute)))
+	}
+}
+
+func TestCheck_AlignedResetHeader(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	daily := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 1000, Window: 24 * time.Hour, Alignment: limiter.AlignDay})
+	router, _ := newTestRouter(t, handlers.WithTierLimiters(map[string]map[string]limiter.RateLimiter{
+		"daily": {"fixed_window": daily},
+	}))
+
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+		"resource":   "api.export",
+		"identifier": "alice",
+		"tier":       "daily",
+		"algorithm":  "fixed_window",
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+	require.NoError(t, err)
+	midnight := time.Unix(reset, 0).UTC()
+	assert.Equal(t, 0, midnight.Hour()+midnight.Minute()+midnight.Second(), "resets at 00:00 UTC")
+	assert.WithinDuration(t, time.Now(), midnight, 24*time.Hour)
+}
+
+func TestPolicies_ReportAlignment(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	monthly := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 1000, Window: 30 * 24 * time.Hour, Alignment: limiter.AlignMonth})
+	router, _ := newTestRouter(t, handlers.WithTierLimiters(map[string]map[string]limiter.RateLimiter{
+		"monthly": {"fixed_window": monthly},
+	}))
+
+	req := "/v1/policies?resource=api.export&tier=monthly&algorithm=fixed_window"
+	w := doJSON(router, http.MethodGet, req, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"alignment":"month"`)
+}
@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenLimiter fails every call, with ErrStoreUnavailable when unavailable is set
+type brokenLimiter struct {
+	limiter.RateLimiter
+	unavailable bool
+}
+
+func (b brokenLimiter) err() error {
+	if b.unavailable {
+		return fmt.Errorf("store call failed: %w", limiter.ErrStoreUnavailable)
+	}
+	return errors.New("corrupt state")
+}
+
+func (b brokenLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	return false, nil, b.err()
+}
+
+func (b brokenLimiter) StatusCtx(ctx context.Context, key string) (*limiter.LimitInfo, error) {
+	return nil, b.err()
+}
+
+func (b brokenLimiter) ResetCtx(ctx context.Context, key string) error {
+	return b.err()
+}
+
+// brokenWindowStore fails the window counter calls pools make, with ErrStoreUnavailable
+// when unavailable is set
+type brokenWindowStore struct {
+	limiter.Store
+	unavailable bool
+}
+
+func (s brokenWindowStore) err() error {
+	return brokenLimiter{unavailable: s.unavailable}.err()
+}
+
+func (s brokenWindowStore) IncrementByCtx(ctx context.Context, key string, window time.Time, delta int64) (int64, error) {
+	return 0, s.err()
+}
+
+func (s brokenWindowStore) GetWindowsCtx(ctx context.Context, key string, from, to time.Time) ([]limiter.Window, error) {
+	return nil, s.err()
+}
+
+func TestAPIError_CodesForEachErrorPath(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	closed := algorithms.NewTokenBucket(store.NewMemoryStore(), limiter.Config{Limit: 10, Window: time.Minute})
+	require.NoError(t, closed.Close())
+	limiters := map[string]limiter.RateLimiter{
+		"broken":      brokenLimiter{},
+		"unavailable": brokenLimiter{unavailable: true},
+		"closed":      closed,
+	}
+	brokenRouter := gin.New()
+	handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "broken").RegisterRoutes(brokenRouter)
+
+	poolRouter := func(unavailable bool) *gin.Engine {
+		s := store.NewMemoryStore()
+		t.Cleanup(func() { s.Close() })
+		pool, err := algorithms.NewPool(brokenWindowStore{Store: s, unavailable: unavailable}, map[string]algorithms.PoolPolicy{
+			"enterprise": {Limit: 10, Window: time.Hour, SeatFraction: 0.5},
+		})
+		require.NoError(t, err)
+		r, _ := newTestRouter(t, handlers.WithPool(pool))
+		return r
+	}
+	brokenPoolRouter, unavailablePoolRouter := poolRouter(false), poolRouter(true)
+	poolCheck := map[string]interface{}{"org": "acme", "seat": "alice", "tier": "enterprise"}
+
+	readOnlyRouter, _ := newTestRouter(t, handlers.WithReadOnly(true, handlers.BiasAllow))
+
+	tests := []struct {
+		name   string
+		router *gin.Engine
+		method string
+		path   string
+		body   interface{}
+		status int
+		code   string
+	}{
+		{"check missing identifier", router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api"}, http.StatusBadRequest, handlers.CodeInvalidRequest},
+		{"check negative count", router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice", "count": -1}, http.StatusBadRequest, handlers.CodeInvalidRequest},
+		{"check invalid algorithm", router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice", "algorithm": "nope"}, http.StatusBadRequest, handlers.CodeInvalidAlgorithm},
+		{"check store error", brokenRouter, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice"}, http.StatusInternalServerError, handlers.CodeStoreError},
+		{"check store unavailable", brokenRouter, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice", "algorithm": "unavailable"}, http.StatusServiceUnavailable, handlers.CodeStoreUnavailable},
+		{"status invalid algorithm", router, http.MethodGet, "/v1/status/alice:api?algorithm=nope", nil, http.StatusBadRequest, handlers.CodeInvalidAlgorithm},
+		{"status store error", brokenRouter, http.MethodGet, "/v1/status/alice:api", nil, http.StatusInternalServerError, handlers.CodeStoreError},
+		{"reset invalid algorithm", router, http.MethodPost, "/v1/reset/alice:api?algorithm=nope", nil, http.StatusBadRequest, handlers.CodeInvalidAlgorithm},
+		{"reset store error", brokenRouter, http.MethodPost, "/v1/reset/alice:api", nil, http.StatusInternalServerError, handlers.CodeStoreError},
+		{"reset store unavailable", brokenRouter, http.MethodPost, "/v1/reset/alice:api?algorithm=unavailable", nil, http.StatusServiceUnavailable, handlers.CodeStoreUnavailable},
+		{"reset read-only", readOnlyRouter, http.MethodPost, "/v1/reset/alice:api", nil, http.StatusServiceUnavailable, handlers.CodeReadOnly},
+		{"check closed limiter", brokenRouter, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice", "algorithm": "closed"}, http.StatusServiceUnavailable, handlers.CodeStoreUnavailable},
+		{"pool check store error", brokenPoolRouter, http.MethodPost, "/v1/pool/check", poolCheck, http.StatusInternalServerError, handlers.CodeStoreError},
+		{"pool check store unavailable", unavailablePoolRouter, http.MethodPost, "/v1/pool/check", poolCheck, http.StatusServiceUnavailable, handlers.CodeStoreUnavailable},
+		{"pool check unknown tier", brokenPoolRouter, http.MethodPost, "/v1/pool/check", map[string]interface{}{"org": "acme", "seat": "alice", "tier": "nope"}, http.StatusBadRequest, handlers.CodeInvalidRequest},
+		{"pool status store unavailable", unavailablePoolRouter, http.MethodGet, "/v1/pool/status/acme/alice?tier=enterprise", nil, http.StatusServiceUnavailable, handlers.CodeStoreUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(tt.router, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+
+			var apiErr handlers.APIError
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+			assert.Equal(t, tt.code, apiErr.Code)
+			assert.NotEmpty(t, apiErr.Message)
+		})
+	}
+}
+
+func TestAPIError_RateLimitedKeepsRetryAfter(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice", "count": 100})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"code"`)
+
+	w = doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice"})
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handlers.CodeRateLimited, resp.Code)
+	assert.False(t, resp.Allowed)
+	assert.NotNil(t, resp.RetryAfter)
+}
@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket_AllowAllConsumesAllOrNothing(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := limitertest.NewFakeClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 3, Window: time.Minute}, algorithms.WithClock(clock))
+
+	// Use up most of the global bucket
+	allowed, _, err := tb.AllowN("global:search", 2)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	keys := []string{"user:alice:search", "global:search"}
+	allowed, infos, err := tb.AllowAll(keys, []int{1, 1})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	require.Len(t, infos, 2)
+	assert.Equal(t, 2, infos[0].Remaining)
+	assert.Equal(t, 0, infos[1].Remaining)
+
+	// The global bucket is empty, so alice's bucket is not touched either
+	allowed, infos, err = tb.AllowAll(keys, []int{1, 1})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Nil(t, infos[0].RetryAfter)
+	require.NotNil(t, infos[1].RetryAfter)
+	assert.Same(t, infos[1], limiter.MostRestrictive(infos))
+
+	info, err := tb.Status("user:alice:search")
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.Remaining)
+}
+
+func TestTokenBucket_AllowAllSumsRepeatedKeys(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 3, Window: time.Minute})
+
+	allowed, infos, err := tb.AllowAll([]string{"a", "b", "a"}, []int{2, 1, 2})
+	require.NoError(t, err)
+	assert.False(t, allowed, "a needs 4 of its 3 tokens")
+	assert.Same(t, infos[0], infos[2])
+
+	allowed, _, err = tb.AllowAll([]string{"a", "b", "a"}, []int{1, 1, 2})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	_, _, err = tb.AllowAll([]string{"a"}, []int{1, 2})
+	assert.ErrorIs(t, err, limiter.ErrInvalidN)
+}
+
+func TestCheckBatch_Atomic(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	count := 100
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "search", "identifier": "global", "count": count})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	batch := map[string]interface{}{
+		"atomic": true,
+		"checks": []map[string]interface{}{
+			{"resource": "search", "identifier": "alice"},
+			{"resource": "search", "identifier": "global"},
+		},
+	}
+	w = doJSON(router, http.MethodPost, "/v1/check/batch", batch)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var results []handlers.BatchCheckResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	require.Len(t, results, 2)
+	for _, r := range results {
+		require.NotNil(t, r.CheckResponse)
+		assert.False(t, r.Allowed)
+	}
+	assert.Nil(t, results[0].RetryAfter)
+	assert.NotNil(t, results[1].RetryAfter)
+	assert.Equal(t, 100, results[0].Remaining, "alice consumed nothing")
+
+	// Atomic checks must share a limiter that supports them
+	batch["checks"] = []map[string]interface{}{
+		{"resource": "search", "identifier": "alice"},
+		{"resource": "search", "identifier": "bob", "algorithm": "fixed_window"},
+	}
+	w = doJSON(router, http.MethodPost, "/v1/check/batch", batch)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	batch["checks"] = []map[string]interface{}{{"resource": "search", "identifier": "bob", "algorithm": "fixed_window"}}
+	w = doJSON(router, http.MethodPost, "/v1/check/batch", batch)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
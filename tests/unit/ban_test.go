@@ -0,0 +1,232 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ limiter.Banner = (*store.MemoryStore)(nil)
+	_ limiter.Banner = (*store.RedisStore)(nil)
+)
+
+var testBanConfig = algorithms.BanConfig{
+	Threshold: 3,
+	Window:    time.Minute,
+	Duration:  10 * time.Minute,
+	Allowlist: []string{"monitor:*", "admin:api.export"},
+}
+
+func newTestBans(t *testing.T, config algorithms.BanConfig) (*algorithms.Bans, *simulation.ManualClock) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	clock := simulation.NewManualClock(penaltyEpoch)
+	bans, err := algorithms.NewBans(s, config, algorithms.WithClock(clock))
+	require.NoError(t, err)
+	return bans, clock
+}
+
+// denyN records n denials of key and returns the ban placed by the last, if any
+func denyN(t *testing.T, bans *algorithms.Bans, key string, n int) *algorithms.Ban {
+	t.Helper()
+	var ban *algorithms.Ban
+	for i := 0; i < n; i++ {
+		var err error
+		ban, err = bans.Deny(context.Background(), key)
+		require.NoError(t, err)
+	}
+	return ban
+}
+
+func TestBans_BanAfterThresholdAndLapse(t *testing.T) {
+	bans, clock := newTestBans(t, testBanConfig)
+	ctx := context.Background()
+
+	assert.Nil(t, denyN(t, bans, "user", 3), "denials up to the threshold are not banned")
+	ban, err := bans.Banned(ctx, "user")
+	require.NoError(t, err)
+	assert.Nil(t, ban)
+
+	ban = denyN(t, bans, "user", 1)
+	require.NotNil(t, ban)
+	assert.Equal(t, penaltyEpoch.Add(10*time.Minute), ban.Until)
+
+	clock.Advance(4 * time.Minute)
+	ban, err = bans.Banned(ctx, "user")
+	require.NoError(t, err)
+	require.NotNil(t, ban)
+	assert.Equal(t, 6*time.Minute, ban.RetryAfter)
+
+	clock.Advance(6 * time.Minute)
+	ban, err = bans.Banned(ctx, "user")
+	require.NoError(t, err)
+	assert.Nil(t, ban, "bans lapse after their duration")
+}
+
+func TestBans_DenialsOutsideWindowAreNotCounted(t *testing.T) {
+	bans, clock := newTestBans(t, testBanConfig)
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, denyN(t, bans, "user", 3))
+		clock.Advance(time.Minute)
+	}
+}
+
+func TestBans_AllowlistIsNeverBanned(t *testing.T) {
+	bans, _ := newTestBans(t, testBanConfig)
+
+	for _, key := range []string{"monitor:api.export", "admin:api.export"} {
+		assert.True(t, bans.Allowlisted(key))
+		assert.Nil(t, denyN(t, bans, key, 10), key)
+	}
+	assert.False(t, bans.Allowlisted("admin:api.search"))
+	assert.NotNil(t, denyN(t, bans, "admin:api.search", 4))
+}
+
+func TestBans_UnbanAndList(t *testing.T) {
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	config := testBanConfig
+	config.Metrics = m
+	bans, _ := newTestBans(t, config)
+	ctx := context.Background()
+
+	require.NotNil(t, denyN(t, bans, "bob", 4))
+	require.NotNil(t, denyN(t, bans, "alice", 4))
+	denyN(t, bans, "carol", 1)
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.ActiveBans))
+
+	list, err := bans.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "alice", list[0].Key)
+	assert.Equal(t, "bob", list[1].Key)
+
+	unbanned, err := bans.Unban(ctx, "alice")
+	require.NoError(t, err)
+	assert.True(t, unbanned)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.ActiveBans))
+
+	unbanned, err = bans.Unban(ctx, "alice")
+	require.NoError(t, err)
+	assert.False(t, unbanned)
+
+	// Unbanning forgets the denials, so the key starts over
+	assert.Nil(t, denyN(t, bans, "alice", 3))
+}
+
+func TestNewBans_ValidatesConfig(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	for _, config := range []algorithms.BanConfig{
+		{Window: time.Minute, Duration: time.Minute},
+		{Threshold: 1, Duration: time.Minute},
+		{Threshold: 1, Window: time.Minute},
+		{Threshold: 1, Window: time.Minute, Duration: time.Minute, Allowlist: []string{"["}},
+	} {
+		_, err := algorithms.NewBans(s, config)
+		assert.Error(t, err, "%+v", config)
+	}
+}
+
+func newBanRouter(t *testing.T, rl limiter.RateLimiter, bans *algorithms.Bans) *gin.Engine {
+	t.Helper()
+	limiters := map[string]limiter.RateLimiter{"fixed_window": rl}
+	h := handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "fixed_window",
+		handlers.WithBans(bans))
+	router := gin.New()
+	h.RegisterRoutes(router)
+	return router
+}
+
+func TestCheck_BansRepeatedlyDeniedKeys(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	bans, err := algorithms.NewBans(s, algorithms.BanConfig{Threshold: 2, Window: time.Minute, Duration: 10 * time.Minute})
+	require.NoError(t, err)
+	router := newBanRouter(t, algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 1, Window: time.Hour}), bans)
+
+	check := func() (int, handlers.CheckResponse) {
+		w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api.export", "identifier": "alice"})
+		var resp handlers.CheckResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	code, _ := check()
+	require.Equal(t, http.StatusOK, code)
+	for i := 0; i < 2; i++ {
+		code, resp := check()
+		require.Equal(t, http.StatusTooManyRequests, code)
+		assert.False(t, resp.Banned, "denial %d is within the threshold", i+1)
+	}
+
+	// The denial past the threshold places the ban, and later checks are denied by it
+	for i := 0; i < 2; i++ {
+		code, resp := check()
+		require.Equal(t, http.StatusTooManyRequests, code)
+		assert.True(t, resp.Banned)
+		assert.Equal(t, 1, resp.Limit)
+		require.NotNil(t, resp.RetryAfter)
+		assert.Greater(t, *resp.RetryAfter, int(9*time.Minute/time.Second))
+	}
+
+	w := doJSON(router, http.MethodGet, "/v1/bans", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Bans []handlers.BanResponse `json:"bans"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Bans, 1)
+	assert.Equal(t, "alice:api.export", list.Bans[0].Key)
+
+	assert.Equal(t, http.StatusOK, doJSON(router, http.MethodPost, "/v1/unban/alice:api.export", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON(router, http.MethodPost, "/v1/unban/alice:api.export", nil).Code)
+
+	code, resp := check()
+	assert.Equal(t, http.StatusTooManyRequests, code, "the limit itself still applies")
+	assert.False(t, resp.Banned)
+}
+
+func TestCheck_BannedKeysSkipTheLimiter(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	bans, err := algorithms.NewBans(s, algorithms.BanConfig{Threshold: 1, Window: time.Minute, Duration: time.Minute})
+	require.NoError(t, err)
+	denyN(t, bans, "alice:api.export", 2)
+
+	// The limiter fails every check it is asked
+	router := newBanRouter(t, failingLimiter{}, bans)
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api.export", "identifier": "alice"})
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Banned)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestBanEndpoints_NotEnabled(t *testing.T) {
+	h := handlers.NewRateLimitHandler(map[string]limiter.RateLimiter{}, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "fixed_window")
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	assert.Equal(t, http.StatusNotFound, doJSON(router, http.MethodGet, "/v1/bans", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON(router, http.MethodPost, "/v1/unban/alice:api.export", nil).Code)
+}
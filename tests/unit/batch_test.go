@@ -0,0 +1,130 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket_AllowBatchKeysAreIndependent(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := limitertest.NewFakeClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 3, Window: time.Minute}, algorithms.WithClock(clock))
+
+	results, err := tb.AllowBatch([]limiter.BatchRequest{
+		{Key: "a", N: 2},
+		{Key: "b", N: 4}, // More than the bucket holds
+		{Key: "a", N: 1},
+		{Key: "c", N: -1},
+		{Key: "a", N: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	assert.True(t, results[0].Allowed)
+	assert.Equal(t, 1, results[0].Info.Remaining)
+
+	assert.False(t, results[1].Allowed)
+	require.NotNil(t, results[1].Info.RetryAfter)
+
+	// Steps on the same key apply in order
+	assert.True(t, results[2].Allowed)
+	assert.Equal(t, 0, results[2].Info.Remaining)
+
+	assert.ErrorIs(t, results[3].Err, limiter.ErrInvalidN)
+	assert.Nil(t, results[3].Info)
+
+	assert.False(t, results[4].Allowed)
+
+	// b's denial consumed nothing
+	allowed, info, err := tb.AllowN("b", 3)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+}
+
+func TestAllowBatch_FallsBackToOneAtATime(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	fw := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 2, Window: time.Minute})
+	results, err := algorithms.AllowBatch(context.Background(), fw, []limiter.BatchRequest{
+		{Key: "a", N: 1},
+		{Key: "a", N: 1},
+		{Key: "a", N: 1},
+		{Key: "b", N: 1},
+	})
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed)
+	assert.True(t, results[1].Allowed)
+	assert.False(t, results[2].Allowed)
+	assert.True(t, results[3].Allowed)
+}
+
+func TestCheckBatch_ResultsInRequestOrder(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	count := 100
+	w := doJSON(router, http.MethodPost, "/v1/check/batch", []map[string]interface{}{
+		{"resource": "api.users", "identifier": "alice", "count": count},
+		{"resource": "api.users", "identifier": "alice"},
+		{"resource": "api.users", "identifier": "bob", "algorithm": "fixed_window"},
+		{"resource": "api.users"},
+		{"resource": "api.users", "identifier": "carol", "algorithm": "nope"},
+		{"resource": "api.users", "identifier": "dave", "priority": "high"},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var results []handlers.BatchCheckResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	require.Len(t, results, 6)
+
+	require.NotNil(t, results[0].CheckResponse)
+	assert.True(t, results[0].Allowed)
+	assert.Equal(t, 0, results[0].Remaining)
+
+	// Denied for alice, without affecting bob under another algorithm
+	require.NotNil(t, results[1].CheckResponse)
+	assert.False(t, results[1].Allowed)
+	assert.NotNil(t, results[1].RetryAfter)
+
+	require.NotNil(t, results[2].CheckResponse)
+	assert.True(t, results[2].Allowed)
+	assert.Equal(t, 99, results[2].Remaining)
+
+	for _, i := range []int{3, 4, 5} {
+		assert.Nil(t, results[i].CheckResponse, "result %d", i)
+		assert.Equal(t, handlers.CodeInvalidRequest, results[i].Code, "result %d", i)
+		assert.NotEmpty(t, results[i].Error, "result %d", i)
+	}
+}
+
+func TestCheckBatch_RejectsMalformedBatches(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/v1/check/batch", map[string]interface{}{"resource": "api.users", "identifier": "alice"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(router, http.MethodPost, "/v1/check/batch", []interface{}{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	checks := make([]map[string]interface{}, 101)
+	for i := range checks {
+		checks[i] = map[string]interface{}{"resource": "api.users", "identifier": "alice"}
+	}
+	w = doJSON(router, http.MethodPost, "/v1/check/batch", checks)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "max 100")
+}
@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/benchgate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const benchOutput = `goos: linux
+goarch: amd64
+pkg: github.com/AbubakarMahmood1/go-rate-limiter/tests/benchmark
+BenchmarkGCRA         	  235737	       527.0 ns/op	     151 B/op	       5 allocs/op
+BenchmarkGCRA         	  262784	       535.5 ns/op	     151 B/op	       5 allocs/op
+BenchmarkGCRA         	  262784	       531.0 ns/op	     151 B/op	       5 allocs/op
+BenchmarkGCRA         	  262784	      2400.0 ns/op	     151 B/op	       5 allocs/op
+BenchmarkGCRA         	  262784	       529.0 ns/op	     151 B/op	       5 allocs/op
+BenchmarkTokenBucket-4	 1000000	       410 ns/op	     120 B/op	       4 allocs/op
+BenchmarkNoMem        	 1000000	       10.5 ns/op
+PASS
+ok  	github.com/AbubakarMahmood1/go-rate-limiter/tests/benchmark	0.545s
+`
+
+func TestBenchgate_ParseCollectsSamples(t *testing.T) {
+	samples, err := benchgate.Parse(strings.NewReader(benchOutput))
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+
+	assert.Equal(t, []float64{527, 535.5, 531, 2400, 529}, samples["BenchmarkGCRA"].NsPerOp)
+	assert.Equal(t, []float64{5, 5, 5, 5, 5}, samples["BenchmarkGCRA"].AllocsPerOp)
+
+	// The -GOMAXPROCS suffix is not part of the name
+	require.Contains(t, samples, "BenchmarkTokenBucket")
+	assert.Equal(t, []float64{4}, samples["BenchmarkTokenBucket"].AllocsPerOp)
+
+	// Without -benchmem there are no allocation samples
+	assert.Equal(t, []float64{10.5}, samples["BenchmarkNoMem"].NsPerOp)
+	assert.Empty(t, samples["BenchmarkNoMem"].AllocsPerOp)
+}
+
+func TestBenchgate_RejectOutliers(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   []float64
+	}{
+		{name: "slow outlier", values: []float64{527, 535.5, 531, 2400, 529}, want: []float64{527, 529, 531, 535.5}},
+		{name: "fast outlier", values: []float64{100, 101, 102, 103, 10}, want: []float64{100, 101, 102, 103}},
+		{name: "no outliers", values: []float64{100, 110, 105, 95}, want: []float64{95, 100, 105, 110}},
+		{name: "too few to judge", values: []float64{100, 1000, 10}, want: []float64{100, 1000, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, benchgate.RejectOutliers(tt.values))
+		})
+	}
+}
+
+func TestBenchgate_SummarizeIgnoresNoisyIteration(t *testing.T) {
+	samples, err := benchgate.Parse(strings.NewReader(benchOutput))
+	require.NoError(t, err)
+
+	results := benchgate.Summarize(samples)
+	// Median of 527, 529, 531, 535.5 once 2400 is rejected
+	assert.Equal(t, benchgate.Result{NsPerOp: 530, AllocsPerOp: 5}, results["BenchmarkGCRA"])
+}
+
+func TestBenchgate_CompareReportsRegressions(t *testing.T) {
+	baseline := benchgate.Baseline{
+		GOMAXPROCS: 1,
+		Benchmarks: map[string]benchgate.Result{
+			"BenchmarkA":       {NsPerOp: 100, AllocsPerOp: 2},
+			"BenchmarkB":       {NsPerOp: 100, AllocsPerOp: 2},
+			"BenchmarkC":       {NsPerOp: 100, AllocsPerOp: 0},
+			"BenchmarkRemoved": {NsPerOp: 100, AllocsPerOp: 1},
+		},
+	}
+	current := map[string]benchgate.Result{
+		"BenchmarkA":   {NsPerOp: 109, AllocsPerOp: 2}, // Within tolerance
+		"BenchmarkB":   {NsPerOp: 140, AllocsPerOp: 3}, // Slower and allocates more
+		"BenchmarkC":   {NsPerOp: 50, AllocsPerOp: 1},  // Faster, but started allocating
+		"BenchmarkNew": {NsPerOp: 1000, AllocsPerOp: 10},
+	}
+
+	regressions := benchgate.Compare(baseline, current, benchgate.Tolerance{NsPerOp: 0.10})
+	require.Len(t, regressions, 4)
+	assert.Equal(t, benchgate.Regression{Name: "BenchmarkB", Metric: "ns/op", Baseline: 100, Current: 140}, regressions[0])
+	assert.Equal(t, "BenchmarkB", regressions[1].Name)
+	assert.Equal(t, "allocs/op", regressions[1].Metric)
+	assert.Equal(t, "BenchmarkC", regressions[2].Name)
+	assert.Equal(t, "allocs/op", regressions[2].Metric)
+	assert.Equal(t, "BenchmarkRemoved: in baseline but not run", regressions[3].String())
+	assert.Equal(t, "BenchmarkB: ns/op 100.0 -> 140.0 (+40.0%)", regressions[0].String())
+
+	assert.Equal(t, []string{"BenchmarkNew"}, benchgate.Added(baseline, current))
+}
+
+func TestBenchgate_BaselineRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	want := benchgate.Baseline{GOMAXPROCS: 1, Benchmarks: map[string]benchgate.Result{"BenchmarkA": {NsPerOp: 12.5, AllocsPerOp: 1}}}
+
+	require.NoError(t, benchgate.WriteBaseline(path, want))
+	got, err := benchgate.LoadBaseline(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestBenchgate_PatternMatchesExactNames(t *testing.T) {
+	assert.Equal(t, "^(BenchmarkGCRA|BenchmarkTokenBucket)$", benchgate.Pattern([]string{"BenchmarkGCRA", "BenchmarkTokenBucket"}))
+}
@@ -0,0 +1,226 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Starts on an hour boundary, so buckets of any whole number of minutes align with it
+var bucketEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newBucketedCounter(t *testing.T, config limiter.Config) (*algorithms.SlidingWindowCounter, *simulation.ManualClock) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	clock := simulation.NewManualClock(bucketEpoch)
+	return algorithms.NewSlidingWindowCounter(s, config, algorithms.WithClock(clock)), clock
+}
+
+func allowAll(t *testing.T, rl limiter.RateLimiter, key string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		allowed, _, err := rl.Allow(key)
+		require.NoError(t, err)
+		require.True(t, allowed, "request %d", i)
+	}
+}
+
+func TestSlidingWindowCounter_BucketsTrackBurstAge(t *testing.T) {
+	coarse, coarseClock := newBucketedCounter(t, limiter.Config{Limit: 10, Window: time.Hour})
+	fine, fineClock := newBucketedCounter(t, limiter.Config{Limit: 10, Window: time.Hour, Buckets: 60})
+
+	// A burst late in the first hour
+	coarseClock.Advance(50 * time.Minute)
+	fineClock.Advance(50 * time.Minute)
+	allowAll(t, coarse, "user", 10)
+	allowAll(t, fine, "user", 10)
+
+	// 20 minutes later the burst is well inside the window
+	coarseClock.Advance(20 * time.Minute)
+	fineClock.Advance(20 * time.Minute)
+
+	allowed, _, err := coarse.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed, "two windows discount the burst by how far the current window has run")
+
+	allowed, info, err := fine.Allow("user")
+	require.NoError(t, err)
+	assert.False(t, allowed, "minute buckets still count the whole burst")
+	assert.Equal(t, 0, info.Remaining)
+
+	// Just over an hour after the burst it has aged out entirely
+	fineClock.Advance(41 * time.Minute)
+	allowed, info, err = fine.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 9, info.Remaining)
+}
+
+func TestSlidingWindowCounter_BucketsRetryAfterOldestBucket(t *testing.T) {
+	// Four one-minute buckets
+	swc, clock := newBucketedCounter(t, limiter.Config{Limit: 8, Window: 4 * time.Minute, Buckets: 4})
+	allowAll(t, swc, "user", 8)
+
+	allowed, info, err := swc.Allow("user")
+	require.NoError(t, err)
+	require.False(t, allowed)
+	assert.True(t, info.ResetAt.Equal(bucketEpoch.Add(time.Minute)), "resets when the next bucket starts")
+
+	// The burst's bucket reaches the back of the window after four minutes, then one of
+	// its eight requests has aged out an eighth of a minute later
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, 4*time.Minute+7500*time.Millisecond, *info.RetryAfter)
+
+	clock.Advance(*info.RetryAfter - time.Millisecond)
+	allowed, _, err = swc.Allow("user")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	clock.Advance(time.Millisecond)
+	allowed, _, err = swc.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed, "retrying after RetryAfter succeeds")
+}
+
+func TestSlidingWindowCounter_BucketsRetryAfterSpansBuckets(t *testing.T) {
+	swc, clock := newBucketedCounter(t, limiter.Config{Limit: 4, Window: 4 * time.Minute, Buckets: 4})
+
+	// Two requests in each of the first two buckets
+	allowAll(t, swc, "user", 2)
+	clock.Advance(time.Minute)
+	allowAll(t, swc, "user", 2)
+
+	// Three must age out: the first bucket slides out between three and four minutes from
+	// now, then half of the second over the next half minute
+	_, info, err := swc.AllowN("user", 3)
+	require.NoError(t, err)
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, 4*time.Minute+30*time.Second, *info.RetryAfter)
+}
+
+func TestSlidingWindowCounter_OneBucketIsTwoWindows(t *testing.T) {
+	one, oneClock := newBucketedCounter(t, limiter.Config{Limit: 10, Window: time.Minute, Buckets: 1})
+	def, defClock := newBucketedCounter(t, limiter.Config{Limit: 10, Window: time.Minute})
+
+	for _, step := range []time.Duration{0, 20 * time.Second, 50 * time.Second, 30 * time.Second} {
+		oneClock.Advance(step)
+		defClock.Advance(step)
+		for i := 0; i < 4; i++ {
+			a, aInfo, err := one.Allow("user")
+			require.NoError(t, err)
+			b, bInfo, err := def.Allow("user")
+			require.NoError(t, err)
+			assert.Equal(t, b, a)
+			assert.Equal(t, bInfo, aInfo)
+		}
+	}
+	assert.Equal(t, 0, one.Config().Buckets)
+}
+
+func TestMemoryStore_IncrementPrunesOldWindows(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := s.IncrementPruneCtx(ctx, "user", bucketEpoch.Add(time.Duration(i)*time.Minute), 1, 2*time.Minute)
+		require.NoError(t, err)
+	}
+
+	windows, err := s.GetWindows("user", bucketEpoch, bucketEpoch.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, windows, 3, "only windows within retain of the newest are kept")
+	for _, w := range windows {
+		assert.False(t, w.Timestamp.Before(bucketEpoch.Add(2*time.Minute)))
+	}
+}
+
+func TestSlidingWindowCounter_ConfigReportsBuckets(t *testing.T) {
+	swc, _ := newBucketedCounter(t, limiter.Config{Limit: 10, Window: time.Hour, Buckets: 60})
+	assert.Equal(t, 60, swc.Config().Buckets)
+}
+
+// redisLikeStore keys windows by the Unix second they start in and expires a key's windows
+// together, ttl after the first request in a new window or retain if longer, as Redis does
+type redisLikeStore struct {
+	*store.MemoryStore
+	clock   limiter.Clock
+	ttl     time.Duration
+	expires map[string]time.Time
+}
+
+func (s *redisLikeStore) IncrementPruneCtx(ctx context.Context, key string, window time.Time, delta int64, retain time.Duration) (int64, error) {
+	s.expire(ctx, key)
+	count, err := s.MemoryStore.IncrementPruneCtx(ctx, key, window.Truncate(time.Second), delta, retain)
+	if count == delta {
+		s.expires[key] = s.clock.Now().Add(max(s.ttl, retain))
+	}
+	return count, err
+}
+
+func (s *redisLikeStore) IncrementByCtx(ctx context.Context, key string, window time.Time, delta int64) (int64, error) {
+	s.expire(ctx, key)
+	count, err := s.MemoryStore.IncrementByCtx(ctx, key, window.Truncate(time.Second), delta)
+	if count == delta {
+		s.expires[key] = s.clock.Now().Add(s.ttl)
+	}
+	return count, err
+}
+
+func (s *redisLikeStore) GetWindowsCtx(ctx context.Context, key string, from, to time.Time) ([]limiter.Window, error) {
+	s.expire(ctx, key)
+	return s.MemoryStore.GetWindowsCtx(ctx, key, from, to)
+}
+
+func (s *redisLikeStore) expire(ctx context.Context, key string) {
+	if until, ok := s.expires[key]; ok && !s.clock.Now().Before(until) {
+		s.MemoryStore.DeleteCtx(ctx, key)
+		delete(s.expires, key)
+	}
+}
+
+func TestSlidingWindowCounter_NoSpikeAtWindowRollover(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets int
+		start   time.Duration // Offset of the burst from bucketEpoch
+		advance time.Duration // Time from the burst to the next check
+		allowed int           // Most requests the weighted count leaves room for then
+	}{
+		// The previous window outlives a store TTL shorter than the window, and still
+		// counts for 50s/60s of its 10 requests ten seconds into the next one
+		{"previous window past store ttl", 1, 50 * time.Second, 20 * time.Second, 1},
+		// Buckets of 60s/7 start between whole seconds, which the store rounds down
+		{"buckets off whole seconds", 7, 0, 60 * time.Second / 7, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := simulation.NewManualClock(bucketEpoch.Add(tt.start))
+			s := &redisLikeStore{MemoryStore: store.NewMemoryStore(), clock: clock, ttl: 10 * time.Second, expires: map[string]time.Time{}}
+			t.Cleanup(func() { s.Close() })
+			swc := algorithms.NewSlidingWindowCounter(s, limiter.Config{Limit: 10, Window: time.Minute, Buckets: tt.buckets}, algorithms.WithClock(clock))
+
+			clock.Advance(time.Second / 3)
+			allowAll(t, swc, "user", 10)
+			clock.Advance(tt.advance)
+
+			allowed := 0
+			for i := 0; i < 10; i++ {
+				if ok, _, err := swc.Allow("user"); err == nil && ok {
+					allowed++
+				}
+			}
+			assert.LessOrEqual(t, allowed, tt.allowed)
+		})
+	}
+}
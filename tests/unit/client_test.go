@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStubServer(t *testing.T, status int, headers map[string]string, body string) *client.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return client.New(srv.URL)
+}
+
+var checkReq = client.CheckRequest{Resource: "api.export", Identifier: "alice"}
+
+func TestClient_CheckAllowed(t *testing.T) {
+	c := newStubServer(t, http.StatusOK, nil,
+		`{"allowed":true,"limit":100,"remaining":42,"reset_at":"2030-01-01T00:00:00Z"}`)
+
+	info, err := c.Check(context.Background(), checkReq)
+	require.NoError(t, err)
+	assert.Equal(t, 100, info.Limit)
+	assert.Equal(t, 42, info.Remaining)
+	assert.Equal(t, 2030, info.ResetAt.Year())
+	assert.Nil(t, info.RetryAfter)
+}
+
+func TestClient_RateLimited(t *testing.T) {
+	c := newStubServer(t, http.StatusTooManyRequests, nil,
+		`{"allowed":false,"limit":100,"remaining":0,"reset_at":"2030-01-01T00:00:00Z","retry_after":7}`)
+
+	_, err := c.Check(context.Background(), checkReq)
+
+	var limited *client.ErrRateLimited
+	require.True(t, errors.As(err, &limited))
+	assert.Equal(t, 100, limited.Info.Limit)
+	assert.Equal(t, 0, limited.Info.Remaining)
+	assert.Equal(t, 7*time.Second, limited.RetryAfter())
+}
+
+func TestClient_RateLimitedMalformedBodyFallsBackToHeaders(t *testing.T) {
+	c := newStubServer(t, http.StatusTooManyRequests, map[string]string{
+		"X-RateLimit-Limit":     "10",
+		"X-RateLimit-Remaining": "0",
+		"X-RateLimit-Reset":     "1893456000",
+		"Retry-After":           "3",
+	}, `<html>too many</html>`)
+
+	_, err := c.Check(context.Background(), checkReq)
+
+	var limited *client.ErrRateLimited
+	require.True(t, errors.As(err, &limited))
+	assert.Equal(t, 10, limited.Info.Limit)
+	assert.Equal(t, 3*time.Second, limited.RetryAfter())
+}
+
+func TestClient_Unavailable(t *testing.T) {
+	c := newStubServer(t, http.StatusServiceUnavailable, map[string]string{"Retry-After": "30"},
+		`{"error":"store unavailable"}`)
+
+	_, err := c.Check(context.Background(), checkReq)
+
+	var unavailable *client.ErrUnavailable
+	require.True(t, errors.As(err, &unavailable))
+	assert.Equal(t, 30*time.Second, unavailable.RetryAfter)
+	assert.Equal(t, "store unavailable", unavailable.Message)
+}
+
+func TestClient_InvalidRequest(t *testing.T) {
+	c := newStubServer(t, http.StatusBadRequest, nil,
+		`{"code":"INVALID_ALGORITHM","message":"unknown algorithm"}`)
+
+	_, err := c.Check(context.Background(), checkReq)
+
+	var invalid *client.ErrInvalidRequest
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, http.StatusBadRequest, invalid.StatusCode)
+	assert.Equal(t, "INVALID_ALGORITHM", invalid.Code)
+	assert.Equal(t, "unknown algorithm", invalid.Message)
+}
+
+func TestClient_InvalidRequestLegacyErrorBody(t *testing.T) {
+	c := newStubServer(t, http.StatusBadRequest, nil, `{"error":"invalid algorithm"}`)
+
+	_, err := c.Check(context.Background(), checkReq)
+
+	var invalid *client.ErrInvalidRequest
+	require.True(t, errors.As(err, &invalid))
+	assert.Empty(t, invalid.Code)
+	assert.Equal(t, "invalid algorithm", invalid.Message)
+}
+
+func TestClient_MalformedSuccessBody(t *testing.T) {
+	c := newStubServer(t, http.StatusOK, nil, `{"allowed":`)
+
+	_, err := c.Check(context.Background(), checkReq)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "malformed")
+}
+
+func TestClient_DoRunsOnlyWhenAllowed(t *testing.T) {
+	allowed := newStubServer(t, http.StatusOK, nil,
+		`{"allowed":true,"limit":1,"remaining":0,"reset_at":"2030-01-01T00:00:00Z"}`)
+	denied := newStubServer(t, http.StatusTooManyRequests, nil,
+		`{"allowed":false,"limit":1,"remaining":0,"reset_at":"2030-01-01T00:00:00Z","retry_after":1}`)
+
+	ran := false
+	err := allowed.Do(context.Background(), checkReq, func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	ran = false
+	err = denied.Do(context.Background(), checkReq, func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	var limited *client.ErrRateLimited
+	assert.True(t, errors.As(err, &limited))
+	assert.False(t, ran)
+
+	// Errors from fn are returned unchanged
+	sentinel := errors.New("boom")
+	err = allowed.Do(context.Background(), checkReq, func(ctx context.Context) error {
+		return sentinel
+	})
+	assert.ErrorIs(t, err, sentinel)
+}
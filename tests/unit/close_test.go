@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClose_RegisteredAlgorithms(t *testing.T) {
+	for _, name := range limiter.Algorithms() {
+		t.Run(name, func(t *testing.T) {
+			rl, err := limiter.New(store.NewMemoryStore(), limiter.Config{Algorithm: name, Limit: 5, Window: time.Minute})
+			require.NoError(t, err)
+
+			allowed, _, err := rl.Allow("user")
+			require.NoError(t, err)
+			assert.True(t, allowed)
+
+			require.NoError(t, rl.Close())
+			require.NoError(t, rl.Close(), "Close is idempotent")
+
+			allowed, _, err = rl.Allow("user")
+			assert.ErrorIs(t, err, limiter.ErrClosed)
+			assert.False(t, allowed)
+			_, err = rl.Status("user")
+			assert.ErrorIs(t, err, limiter.ErrClosed)
+			assert.ErrorIs(t, rl.Reset("user"), limiter.ErrClosed)
+		})
+	}
+}
+
+func TestClose_WrappersCloseWhatTheyWrap(t *testing.T) {
+	config := limiter.Config{Limit: 1, Window: time.Minute}
+	newBase := func() limiter.RateLimiter {
+		return algorithms.NewTokenBucket(store.NewMemoryStore(), config)
+	}
+
+	dcBase := newBase()
+	dc, err := algorithms.NewDenyCache(dcBase, algorithms.DenyCacheConfig{Staleness: time.Second})
+	require.NoError(t, err)
+
+	ovBase := newBase()
+	ov, err := algorithms.NewOverrideLimiter(ovBase, algorithms.NewOverrides(), func(c limiter.Config) (limiter.RateLimiter, error) {
+		return algorithms.NewTokenBucket(store.NewMemoryStore(), c), nil
+	})
+	require.NoError(t, err)
+
+	a, b := newBase(), newBase()
+	multi := algorithms.NewMultiLimiter(a, b)
+
+	tests := []struct {
+		name    string
+		rl      limiter.RateLimiter
+		wrapped []limiter.RateLimiter
+	}{
+		{"deny cache", dc, []limiter.RateLimiter{dcBase}},
+		{"override", ov, []limiter.RateLimiter{ovBase}},
+		{"multi", multi, []limiter.RateLimiter{a, b}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Fill the bucket so a deny is cached where there is a cache
+			for i := 0; i < 2; i++ {
+				_, _, err := tt.rl.Allow("user")
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, tt.rl.Close())
+			require.NoError(t, tt.rl.Close())
+
+			_, _, err := tt.rl.Allow("user")
+			assert.ErrorIs(t, err, limiter.ErrClosed)
+			for _, w := range tt.wrapped {
+				_, _, err := w.Allow("user")
+				assert.ErrorIs(t, err, limiter.ErrClosed)
+			}
+		})
+	}
+}
+
+func TestClose_MemoryStoreStopsCleanup(t *testing.T) {
+	before := runtime.NumGoroutine()
+	stores := make([]*store.MemoryStore, 20)
+	for i := range stores {
+		stores[i] = store.NewMemoryStore()
+	}
+	for _, s := range stores {
+		require.NoError(t, s.Close())
+		require.NoError(t, s.Close())
+	}
+
+	assert.Eventually(t, func() bool {
+		return runtime.NumGoroutine() <= before
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/compare"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// boundarySpec bursts alice across a minute boundary while bob stays well under the limit
+var boundarySpec = simulation.Spec{
+	Streams: []simulation.Stream{
+		{Key: "alice", Start: simulation.Duration(50 * time.Second), Count: 10, Interval: simulation.Duration(time.Second)},
+		{Key: "alice", Start: simulation.Duration(60 * time.Second), Count: 10, Interval: simulation.Duration(time.Second)},
+		{Key: "bob", Start: 0, Count: 12, Interval: simulation.Duration(10 * time.Second)},
+	},
+}
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run with -update to create %s", path)
+	assert.Equal(t, string(want), string(got))
+}
+
+func runBoundaryComparison(t *testing.T) *compare.Report {
+	t.Helper()
+
+	a := compare.Target{Algorithm: "fixed_window", Store: "memory"}
+	b := compare.Target{Algorithm: "sliding_window", Store: "memory"}
+	config := limiter.Config{Limit: 10, Window: time.Minute}
+
+	report, err := compare.Run(context.Background(), a, b, config, boundarySpec.StartTime(), boundarySpec.Events(), 0)
+	require.NoError(t, err)
+	return report
+}
+
+func TestCompare_FixedVsSlidingGolden(t *testing.T) {
+	report := runBoundaryComparison(t)
+
+	got, err := json.MarshalIndent(report, "", "  ")
+	require.NoError(t, err)
+	assertGolden(t, "compare_fixed_vs_sliding.golden", append(got, '\n'))
+	assertGolden(t, "compare_fixed_vs_sliding_summary.golden", []byte(report.Summary()))
+}
+
+func TestCompare_Deterministic(t *testing.T) {
+	first, err := json.Marshal(runBoundaryComparison(t))
+	require.NoError(t, err)
+	second, err := json.Marshal(runBoundaryComparison(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, string(first), string(second))
+}
+
+func TestCompare_IdenticalTargetsNeverDiverge(t *testing.T) {
+	for _, algorithm := range []string{"token_bucket", "sliding_window", "sliding_window_log", "fixed_window", "leaky_bucket", "gcra"} {
+		t.Run(algorithm, func(t *testing.T) {
+			target := compare.Target{Algorithm: algorithm, Store: "memory"}
+			config := limiter.Config{Limit: 10, Window: time.Minute, Burst: 10}
+
+			report, err := compare.Run(context.Background(), target, target, config, boundarySpec.StartTime(), boundarySpec.Events(), 0)
+			require.NoError(t, err)
+
+			assert.Zero(t, report.Divergences)
+			assert.Nil(t, report.FirstDivergence)
+			assert.Zero(t, report.RemainingDrift.Max)
+		})
+	}
+}
+
+func TestCompare_UnknownAlgorithm(t *testing.T) {
+	a := compare.Target{Algorithm: "fixed_window", Store: "memory"}
+	b := compare.Target{Algorithm: "nope", Store: "memory"}
+
+	_, err := compare.Run(context.Background(), a, b, limiter.Config{Limit: 1, Window: time.Second}, simulation.DefaultStart, nil, 0)
+	assert.Error(t, err)
+}
+
+func TestSimulation_ManualClock(t *testing.T) {
+	clock := simulation.NewManualClock(simulation.DefaultStart)
+	assert.Equal(t, simulation.DefaultStart, clock.Now())
+
+	clock.Advance(90 * time.Second)
+	assert.Equal(t, simulation.DefaultStart.Add(90*time.Second), clock.Now())
+
+	later := simulation.DefaultStart.Add(time.Hour)
+	clock.Set(later)
+	assert.Equal(t, later, clock.Now())
+}
+
+func TestSimulation_SpecExpandsInTimeOrder(t *testing.T) {
+	spec, err := simulation.ReadSpec(strings.NewReader(`{
+		"streams": [
+			{"key": "a", "start": "2s", "count": 2, "interval": "2s"},
+			{"key": "b", "start": "1s", "count": 3, "interval": "1s", "n": 2}
+		]
+	}`))
+	require.NoError(t, err)
+
+	events := spec.Events()
+	require.Len(t, events, 5)
+
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Key+"@"+time.Duration(e.At).String())
+	}
+	assert.Equal(t, []string{"b@1s", "a@2s", "b@2s", "b@3s", "a@4s"}, got)
+	assert.Equal(t, 2, events[0].N)
+	assert.Equal(t, simulation.DefaultStart, spec.StartTime())
+}
+
+func TestSimulation_ReadEventsSortsReplayLog(t *testing.T) {
+	events, err := simulation.ReadEvents(strings.NewReader(
+		"{\"at\":\"3s\",\"key\":\"x\"}\n\n{\"at\":\"1s\",\"key\":\"y\",\"n\":4}\n"))
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "y", events[0].Key)
+	assert.Equal(t, 4, events[0].N)
+	assert.Equal(t, simulation.Duration(3*time.Second), events[1].At)
+
+	_, err = simulation.ReadEvents(strings.NewReader("not json\n"))
+	assert.Error(t, err)
+}
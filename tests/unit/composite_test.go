@@ -0,0 +1,260 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newComposite(t *testing.T, s limiter.Store, clock limiter.Clock, build func(limiter.Store, limiter.Config, ...algorithms.Option) limiter.RateLimiter, configs ...limiter.Config) *algorithms.CompositeLimiter {
+	t.Helper()
+
+	c, err := algorithms.NewCompositeLimiter(configs, func(config limiter.Config) (limiter.RateLimiter, error) {
+		return build(s, config, algorithms.WithClock(clock)), nil
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func buildTokenBucket(s limiter.Store, c limiter.Config, opts ...algorithms.Option) limiter.RateLimiter {
+	return algorithms.NewTokenBucket(s, c, opts...)
+}
+
+func buildFixedWindow(s limiter.Store, c limiter.Config, opts ...algorithms.Option) limiter.RateLimiter {
+	return algorithms.NewFixedWindowCounter(s, c, opts...)
+}
+
+var perMinuteAndHour = []limiter.Config{
+	{Limit: 3, Window: time.Minute},
+	{Limit: 5, Window: time.Hour},
+}
+
+func TestComposite_EveryWindowMustAllow(t *testing.T) {
+	builders := map[string]func(limiter.Store, limiter.Config, ...algorithms.Option) limiter.RateLimiter{
+		"token_bucket": buildTokenBucket,
+		"fixed_window": buildFixedWindow,
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+			clock := simulation.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			c := newComposite(t, s, clock, build, perMinuteAndHour...)
+
+			// The minute window trips first
+			for i := 0; i < 3; i++ {
+				allowed, _, err := c.Allow("alice")
+				require.NoError(t, err)
+				require.True(t, allowed)
+			}
+			allowed, info, err := c.Allow("alice")
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.Equal(t, "3/1m0s", info.Policy)
+			assert.NotNil(t, info.RetryAfter)
+
+			// A minute later the minute window has room but the hour window has only 2 left
+			clock.Advance(time.Minute)
+			for i := 0; i < 2; i++ {
+				allowed, info, err = c.Allow("alice")
+				require.NoError(t, err)
+				require.True(t, allowed)
+			}
+			assert.Equal(t, "5/1h0m0s", info.Policy, "allows report the tightest window")
+			assert.Equal(t, 0, info.Remaining)
+
+			allowed, info, err = c.Allow("alice")
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.Equal(t, "5/1h0m0s", info.Policy)
+		})
+	}
+}
+
+func TestComposite_LaterDenialRefundsEarlierWindows(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	clock := simulation.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := newComposite(t, s, clock, buildTokenBucket,
+		limiter.Config{Limit: 10, Window: time.Minute},
+		limiter.Config{Limit: 2, Window: time.Hour},
+	)
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := c.Allow("alice")
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	// Denied by the hour window; the minute window must not be charged for it
+	for i := 0; i < 5; i++ {
+		allowed, _, err := c.Allow("alice")
+		require.NoError(t, err)
+		require.False(t, allowed)
+	}
+
+	allowed, info, err := c.Peek(context.Background(), "alice", 1)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, "2/1h0m0s", info.Policy)
+
+	// After the hour window refills one request, the minute window still has 8 of 10
+	clock.Advance(30 * time.Minute)
+	allowed, info, err = c.Allow("alice")
+	require.NoError(t, err)
+	require.True(t, allowed)
+	assert.Equal(t, "2/1h0m0s", info.Policy)
+
+	minute := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Minute}, algorithms.WithClock(clock))
+	_, minuteInfo, err := minute.Peek(context.Background(), "composite:1m0s:alice", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 9, minuteInfo.Remaining, "refilled to 10 over 30 minutes, then 1 consumed")
+}
+
+func TestComposite_ResetClearsEveryWindow(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	clock := simulation.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := newComposite(t, s, clock, buildFixedWindow, perMinuteAndHour...)
+
+	for i := 0; i < 3; i++ {
+		_, _, err := c.Allow("alice")
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.Reset("alice"))
+
+	allowed, info, err := c.Peek(context.Background(), "alice", 1)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 3, info.Remaining)
+}
+
+func TestComposite_RejectsInvalidWindows(t *testing.T) {
+	build := func(c limiter.Config) (limiter.RateLimiter, error) {
+		return algorithms.NewFixedWindowCounter(nil, c), nil
+	}
+
+	tests := []struct {
+		name    string
+		configs []limiter.Config
+	}{
+		{name: "empty", configs: nil},
+		{name: "zero limit", configs: []limiter.Config{{Limit: 0, Window: time.Minute}}},
+		{name: "zero window", configs: []limiter.Config{{Limit: 10}}},
+		{name: "duplicate window", configs: []limiter.Config{{Limit: 10, Window: time.Minute}, {Limit: 20, Window: time.Minute}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := algorithms.NewCompositeLimiter(tt.configs, build)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCheck_CompositeReportsPolicy(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	c, err := algorithms.NewCompositeLimiter(perMinuteAndHour, func(config limiter.Config) (limiter.RateLimiter, error) {
+		return algorithms.NewFixedWindowCounter(s, config), nil
+	})
+	require.NoError(t, err)
+
+	limiters := map[string]limiter.RateLimiter{"fixed_window": c}
+	h := handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "fixed_window")
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	payload := map[string]interface{}{"resource": "api.plan", "identifier": "alice"}
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusOK, doJSON(router, http.MethodPost, "/v1/check", payload).Code)
+	}
+
+	w := doJSON(router, http.MethodPost, "/v1/check", payload)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "3/1m0s", resp.Policy)
+	assert.Equal(t, 3, resp.Limit)
+}
+
+func TestMultiLimiter_TightShortWindowAndLooseLongWindow(t *testing.T) {
+	builders := map[string]func(limiter.Store, limiter.Config, ...algorithms.Option) limiter.RateLimiter{
+		// Fixed windows cannot refund, so every limiter is peeked before any is consumed
+		"peek first": buildFixedWindow,
+		"refund":     buildTokenBucket,
+	}
+
+	for name, buildShort := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+			clock := simulation.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+			// The loose limiter goes first, so the tight one's denials must roll it back
+			day := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: 24 * time.Hour}, algorithms.WithClock(clock))
+			minute := buildShort(s, limiter.Config{Limit: 2, Window: time.Minute}, algorithms.WithClock(clock))
+			rl := algorithms.NewMultiLimiter(day, minute)
+
+			assert.Equal(t, 2, admitted(t, rl, "alice", 5))
+
+			allowed, info, err := rl.Allow("alice")
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.Equal(t, "2/1m0s", info.Policy)
+
+			_, dayInfo, err := day.Peek(context.Background(), "multi:0:alice", 1)
+			require.NoError(t, err)
+			assert.Equal(t, 8, dayInfo.Remaining, "denied requests must not be charged to the day")
+
+			// Each minute lets two more through until the day runs out
+			clock.Advance(time.Minute)
+			allowed, info, err = rl.Allow("alice")
+			require.NoError(t, err)
+			require.True(t, allowed)
+			assert.Equal(t, "2/1m0s", info.Policy, "allows report the limiter with the least room")
+			assert.Equal(t, 1, info.Remaining)
+
+			for i := 0; i < 3; i++ {
+				clock.Advance(time.Minute)
+				assert.Equal(t, 2, admitted(t, rl, "alice", 2))
+			}
+			clock.Advance(time.Minute)
+			assert.Equal(t, 1, admitted(t, rl, "alice", 1))
+			allowed, info, err = rl.Allow("alice")
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.Equal(t, "10/24h0m0s", info.Policy)
+		})
+	}
+}
+
+func TestMultiLimiter_LimitersSharingAStoreDoNotCollide(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	rl := algorithms.NewMultiLimiter(
+		algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 3, Window: time.Minute}),
+		algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 5, Window: time.Minute}),
+	)
+	assert.Equal(t, 3, admitted(t, rl, "alice", 10))
+
+	require.NoError(t, rl.Reset("alice"))
+	assert.Equal(t, 3, admitted(t, rl, "alice", 10))
+}
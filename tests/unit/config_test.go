@@ -0,0 +1,226 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *config.Config)
+		err    string // Expected error substring; empty = valid
+	}{
+		{
+			name:   "defaults",
+			modify: func(c *config.Config) {},
+		},
+		{
+			name:   "zero default window",
+			modify: func(c *config.Config) { c.Limits.Default.Window = 0 },
+			err:    "limits.default: window must be positive",
+		},
+		{
+			name: "zero tier window",
+			modify: func(c *config.Config) {
+				c.Limits.Tiers = map[string]config.LimitConfig{"free": {Requests: 10}}
+			},
+			err: "limits.tiers.free: window must be positive",
+		},
+		{
+			name:   "negative requests",
+			modify: func(c *config.Config) { c.Limits.Default.Requests = -1 },
+			err:    "requests must not be negative",
+		},
+		{
+			name:   "negative burst",
+			modify: func(c *config.Config) { c.Limits.Default.Burst = -5 },
+			err:    "burst must not be negative",
+		},
+		{
+			name: "windows replace window",
+			modify: func(c *config.Config) {
+				c.Limits.Default.Window = 0
+				c.Limits.Default.Windows = []config.WindowConfig{{Requests: 10, Window: time.Minute}}
+			},
+		},
+		{
+			name: "zero multi-window window",
+			modify: func(c *config.Config) {
+				c.Limits.Default.Windows = []config.WindowConfig{{Requests: 10, Window: time.Minute}, {Requests: 100}}
+			},
+			err: "windows[1]: window must be positive",
+		},
+		{
+			name: "negative multi-window burst",
+			modify: func(c *config.Config) {
+				c.Limits.Default.Windows = []config.WindowConfig{{Requests: 10, Window: time.Minute, Burst: -1}}
+			},
+			err: "windows[0]: burst must not be negative",
+		},
+		{
+			name: "zero parent window",
+			modify: func(c *config.Config) {
+				c.Hierarchy.Enabled = true
+				c.Hierarchy.Parent = config.LimitConfig{Requests: 1000}
+			},
+			err: "hierarchy.parent: window must be positive",
+		},
+		{
+			name:   "unknown store",
+			modify: func(c *config.Config) { c.Store = "postgres" },
+			err:    `unknown store "postgres"`,
+		},
+		{
+			name:   "redis without addresses",
+			modify: func(c *config.Config) { c.Store = "redis"; c.Redis.Addresses = nil },
+			err:    "requires redis.addresses",
+		},
+		{
+			name:   "redis",
+			modify: func(c *config.Config) { c.Store = "redis" },
+		},
+		{
+			name: "redis sentinel",
+			modify: func(c *config.Config) {
+				c.Store = "redis"
+				c.Redis.Addresses = nil
+				c.Redis.SentinelMasterName = "mymaster"
+				c.Redis.SentinelAddresses = []string{"sentinel-0:26379"}
+			},
+		},
+		{
+			name: "redis sentinel without sentinels",
+			modify: func(c *config.Config) {
+				c.Store = "redis"
+				c.Redis.Mode = "sentinel"
+				c.Redis.SentinelMasterName = "mymaster"
+			},
+			err: "requires redis.sentinel_addresses",
+		},
+		{
+			name: "redis single with several addresses",
+			modify: func(c *config.Config) {
+				c.Store = "redis"
+				c.Redis.Mode = "single"
+				c.Redis.Addresses = []string{"a:6379", "b:6379"}
+			},
+			err: "requires exactly one of redis.addresses",
+		},
+		{
+			name:   "unknown redis mode",
+			modify: func(c *config.Config) { c.Store = "redis"; c.Redis.Mode = "replicated" },
+			err:    `unknown redis.mode "replicated"`,
+		},
+		{
+			name:   "unregistered default algorithm",
+			modify: func(c *config.Config) { c.Algorithms.Default = "fancy_window" },
+			err:    `algorithms.default "fancy_window" is not registered`,
+		},
+		{
+			name:   "built-in default algorithm",
+			modify: func(c *config.Config) { c.Algorithms.Default = "gcra" },
+		},
+		{
+			name:   "unknown failure mode",
+			modify: func(c *config.Config) { c.FailureMode = "fail_sometimes" },
+			err:    `unknown failure_mode "fail_sometimes"`,
+		},
+		{
+			name:   "fail open",
+			modify: func(c *config.Config) { c.FailureMode = "fail_open" },
+		},
+		{
+			name: "identifier in two key groups",
+			modify: func(c *config.Config) {
+				c.KeyGroups.Groups = map[string][]string{"acme": {"key1"}, "globex": {"key1"}}
+			},
+			err: `identifier "key1" is in both`,
+		},
+		{
+			name: "multi-window limits scaled by discovery",
+			modify: func(c *config.Config) {
+				c.Discovery.Enabled = true
+				c.Limits.Default.Windows = []config.WindowConfig{{Requests: 10, Window: time.Minute}}
+			},
+			err: "multi-window limits can not be scaled by discovery",
+		},
+		{
+			name: "multi-window limits with discovery on a shared store",
+			modify: func(c *config.Config) {
+				c.Store = "redis"
+				c.Discovery.Enabled = true
+				c.Limits.Default.Windows = []config.WindowConfig{{Requests: 10, Window: time.Minute}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.DefaultConfig()
+			tt.modify(c)
+
+			err := c.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestConfigWarnings_BurstBelowRequests(t *testing.T) {
+	c := config.DefaultConfig()
+	c.Limits.Default.Burst = c.Limits.Default.Requests + 20
+	assert.Empty(t, c.Warnings())
+
+	c.Limits.Tiers = map[string]config.LimitConfig{"free": {Requests: 100, Window: time.Hour, Burst: 10}}
+	warnings := c.Warnings()
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "limits.tiers.free")
+}
+
+func TestLoad_RejectsInvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("limits:\n  tiers:\n    free:\n      requests: 10\n"), 0o644))
+
+	_, err := config.Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "limits.tiers.free")
+}
+
+func TestLoad_RepositoryConfigIsValid(t *testing.T) {
+	_, err := config.Load(filepath.Join("..", "..", "config.yaml"))
+	assert.NoError(t, err)
+}
+
+func TestConfig_DiscoveryScalesOnlyPerInstanceStores(t *testing.T) {
+	tests := []struct {
+		store  string
+		scales bool
+	}{
+		{"memory", true},
+		{"redis", false},
+		{"dynamodb", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.store, func(t *testing.T) {
+			c := config.DefaultConfig()
+			c.Store = tt.store
+			c.Discovery.Enabled = true
+			assert.Equal(t, tt.scales, c.ScalesLimits())
+
+			c.Discovery.Enabled = false
+			assert.False(t, c.ScalesLimits())
+		})
+	}
+}
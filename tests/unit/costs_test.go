@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCosts = handlers.Costs{
+	"api.search":          10,
+	"api.reports.*":       5,
+	"api.reports.daily.*": 7,
+}
+
+func checkCost(t *testing.T, router http.Handler, resource string, count interface{}) (int, handlers.CheckResponse) {
+	t.Helper()
+
+	body := map[string]interface{}{
+		"resource":   resource,
+		"identifier": "alice",
+	}
+	if count != nil {
+		body["count"] = count
+	}
+	w := doJSON(router, http.MethodPost, "/v1/check", body)
+
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestCheck_CostsResolveFromResource(t *testing.T) {
+	tests := []struct {
+		resource string
+		cost     int
+	}{
+		{resource: "api.search", cost: 10},
+		{resource: "api.reports.monthly", cost: 5},
+		{resource: "api.reports.daily.csv", cost: 7}, // The longer pattern wins
+		{resource: "api.ping", cost: 1},              // Unknown resources cost 1
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			router, _ := newTestRouter(t, handlers.WithCosts(testCosts))
+
+			code, resp := checkCost(t, router, tt.resource, nil)
+			require.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tt.cost, resp.Cost)
+			assert.Equal(t, 100-tt.cost, resp.Remaining)
+		})
+	}
+}
+
+func TestCheck_ExplicitCountOverridesCost(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithCosts(testCosts))
+
+	code, resp := checkCost(t, router, "api.search", 3)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, resp.Cost)
+	assert.Equal(t, 97, resp.Remaining)
+}
+
+func TestCheck_CostDeniesWhenTooExpensive(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithCosts(handlers.Costs{"api.export": 40}))
+
+	for i := 0; i < 2; i++ {
+		code, _ := checkCost(t, router, "api.export", nil)
+		require.Equal(t, http.StatusOK, code, "request %d", i)
+	}
+
+	code, resp := checkCost(t, router, "api.export", nil)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, 40, resp.Cost, "denials still report the cost they were charged against")
+}
+
+func TestCheck_NonPositiveCountRejected(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, count := range []int{0, -5} {
+		w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+			"resource":   "api.search",
+			"identifier": "bob",
+			"count":      count,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "count %d", count)
+	}
+}
+
+func TestCosts_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		costs   handlers.Costs
+		wantErr string
+	}{
+		{name: "valid", costs: testCosts},
+		{name: "zero cost", costs: handlers.Costs{"api.free": 0}, wantErr: "at least 1"},
+		{name: "negative cost", costs: handlers.Costs{"api.refund": -1}, wantErr: "at least 1"},
+		{name: "bad pattern", costs: handlers.Costs{"api.[": 2}, wantErr: "invalid cost pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.costs.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCheck_WeightsMultiplyCount(t *testing.T) {
+	weights := handlers.Weights{"api.export": 10, "api.export.bulk": 20}
+
+	tests := []struct {
+		resource string
+		count    interface{}
+		cost     int
+	}{
+		{resource: "api.export", count: nil, cost: 10},
+		{resource: "api.export", count: 2, cost: 20},
+		{resource: "api.export.csv", count: 3, cost: 30},
+		{resource: "api.export.bulk.zip", count: 1, cost: 20}, // The longest prefix wins
+		{resource: "api.exporter", count: 2, cost: 2},         // Prefixes stop at a dot
+		{resource: "api.search", count: nil, cost: 10},        // Unweighted resources keep their cost
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			router, _ := newTestRouter(t, handlers.WithCosts(testCosts), handlers.WithWeights(weights))
+
+			code, resp := checkCost(t, router, tt.resource, tt.count)
+			require.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tt.cost, resp.Cost)
+			assert.Equal(t, 100-tt.cost, resp.Remaining)
+		})
+	}
+}
+
+func TestCheck_WeightedCostMultipliesConfiguredCost(t *testing.T) {
+	router, _ := newTestRouter(t,
+		handlers.WithCosts(handlers.Costs{"api.export": 3}),
+		handlers.WithWeights(handlers.Weights{"api": 2}),
+	)
+
+	code, resp := checkCost(t, router, "api.export", nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 6, resp.Cost)
+	assert.Equal(t, 94, resp.Remaining)
+}
+
+func TestWeights_Validate(t *testing.T) {
+	assert.NoError(t, handlers.Weights{"api.export": 10}.Validate())
+	assert.ErrorContains(t, handlers.Weights{"api.export": 0}.Validate(), "at least 1")
+	assert.ErrorContains(t, handlers.Weights{"": 2}.Validate(), "must not be empty")
+}
@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// One token per second into a bucket of 10, which may be overdrawn by 20
+var debtConfig = limiter.Config{Limit: 10, Window: 10 * time.Second, Burst: 10, MaxDebt: 20}
+
+func newDebtBucket(t *testing.T) (*algorithms.TokenBucket, *simulation.ManualClock) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	clock := simulation.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	return algorithms.NewTokenBucket(s, debtConfig, algorithms.WithClock(clock)), clock
+}
+
+func TestTokenBucket_DebtAllowsOverdraw(t *testing.T) {
+	tb, clock := newDebtBucket(t)
+
+	allowed, info, err := tb.AllowN("batch", 25)
+	require.NoError(t, err)
+	assert.True(t, allowed, "25 tokens fit in 10 plus 20 of debt")
+	assert.Equal(t, 0, info.Remaining, "remaining clamps at zero in debt")
+
+	// The balance is -15, so the key waits for the debt to be repaid
+	allowed, info, err = tb.Allow("batch")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, 15*time.Second, *info.RetryAfter)
+	assert.Equal(t, 0, info.Remaining)
+
+	clock.Advance(14 * time.Second)
+	allowed, _, err = tb.Allow("batch")
+	require.NoError(t, err)
+	assert.False(t, allowed, "still a token in debt")
+
+	clock.Advance(time.Second)
+	allowed, _, err = tb.Allow("batch")
+	require.NoError(t, err)
+	assert.True(t, allowed, "debt repaid")
+}
+
+func TestTokenBucket_DebtIsBounded(t *testing.T) {
+	tb, _ := newDebtBucket(t)
+
+	allowed, info, err := tb.AllowN("batch", 31)
+	require.NoError(t, err)
+	assert.False(t, allowed, "31 tokens would overdraw by 21")
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, time.Second, *info.RetryAfter, "one more token makes the request fit")
+	assert.Equal(t, 10, info.Remaining, "a denied request takes nothing")
+}
+
+func TestTokenBucket_DebtPeekMatchesAllow(t *testing.T) {
+	tb, _ := newDebtBucket(t)
+	ctx := context.Background()
+
+	allowed, _, err := tb.Peek(ctx, "batch", 30)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	_, _, err = tb.AllowN("batch", 30)
+	require.NoError(t, err)
+
+	allowed, info, err := tb.Peek(ctx, "batch", 1)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 20*time.Second, *info.RetryAfter)
+}
+
+func TestTokenBucket_DebtRefund(t *testing.T) {
+	tb, _ := newDebtBucket(t)
+
+	_, _, err := tb.AllowN("batch", 30)
+	require.NoError(t, err)
+	require.NoError(t, tb.Refund(context.Background(), "batch", 25))
+
+	// -20 + 25 leaves 5 tokens
+	allowed, info, err := tb.AllowN("batch", 5)
+	require.NoError(t, err)
+	assert.True(t, allowed, "refunds apply while in debt")
+	assert.Equal(t, 0, info.Remaining)
+}
+
+func TestTokenBucket_NoDebtByDefault(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	config := debtConfig
+	config.MaxDebt = 0
+	tb := algorithms.NewTokenBucket(s, config)
+
+	allowed, _, err := tb.AllowN("batch", 11)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, tb.Config().MaxDebt)
+}
+
+func TestMemoryStore_NegativeTokensRoundTrip(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	now := time.Unix(1000, 0)
+
+	require.NoError(t, s.SetTokens("key", -3.5, now))
+	tokens, lastRefill, _, err := s.GetTokens("key")
+	require.NoError(t, err)
+	assert.Equal(t, -3.5, tokens)
+	assert.True(t, lastRefill.Equal(now))
+
+	// Refill continues from the negative balance
+	allowed, tokens, retryAfter, err := s.ConsumeTokensDebtCtx(context.Background(), "key", 1, 10, 1, 10, 5, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, -2.5, tokens)
+	assert.Equal(t, 2500*time.Millisecond, retryAfter)
+}
+
+func TestCheck_DebtRemainingNeverNegative(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	router, _ := newTestRouter(t, handlers.WithTierLimiters(map[string]map[string]limiter.RateLimiter{
+		"batch": {"token_bucket": algorithms.NewTokenBucket(s, debtConfig)},
+	}))
+
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+			"resource":   "jobs.export",
+			"identifier": "nightly",
+			"tier":       "batch",
+			"count":      25,
+		})
+		require.Equal(t, want, w.Code)
+
+		var resp handlers.CheckResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 0, resp.Remaining)
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ limiter.Peeker = (*algorithms.DenyCache)(nil)
+
+// Starts on a minute boundary, so fixed windows reset a whole minute later
+var denyCacheEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestDenyCache(t *testing.T, limit int, staleness time.Duration) (*algorithms.DenyCache, *metrics.Metrics, *simulation.ManualClock) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	clock := simulation.NewManualClock(denyCacheEpoch)
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: limit, Window: time.Minute}, algorithms.WithClock(clock))
+
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	dc, err := algorithms.NewDenyCache(base, algorithms.DenyCacheConfig{
+		Staleness: staleness,
+		Metrics:   m,
+		Algorithm: "fixed_window",
+	}, algorithms.WithClock(clock))
+	require.NoError(t, err)
+	return dc, m, clock
+}
+
+func denyCacheDecisions(m *metrics.Metrics, source string) float64 {
+	return testutil.ToFloat64(m.DenyCache.WithLabelValues("fixed_window", source))
+}
+
+func TestDenyCache_ServesRepeatDenialsLocally(t *testing.T) {
+	dc, m, clock := newTestDenyCache(t, 2, time.Second)
+	ctx := context.Background()
+
+	allowAll(t, dc, "hot", 2)
+	allowed, cached, _, err := dc.AllowNCached(ctx, "hot", 1)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	assert.False(t, cached, "the first denial comes from the store")
+
+	for i := 0; i < 100; i++ {
+		allowed, cached, info, err := dc.AllowNCached(ctx, "hot", 1)
+		require.NoError(t, err)
+		require.False(t, allowed)
+		require.True(t, cached)
+		require.NotNil(t, info.RetryAfter)
+		assert.Equal(t, time.Minute, *info.RetryAfter, "the store's retry time is reported")
+	}
+	assert.Equal(t, float64(3), denyCacheDecisions(m, "store"))
+	assert.Equal(t, float64(100), denyCacheDecisions(m, "cache"))
+
+	// After the staleness budget the store is asked again
+	clock.Advance(time.Second)
+	_, cached, _, err = dc.AllowNCached(ctx, "hot", 1)
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, float64(4), denyCacheDecisions(m, "store"))
+}
+
+func TestDenyCache_NeverOutlivesRetryAfter(t *testing.T) {
+	// A staleness far beyond the window: the store's retry time bounds the cache instead
+	dc, _, clock := newTestDenyCache(t, 2, time.Hour)
+
+	allowAll(t, dc, "hot", 2)
+	allowed, info, err := dc.Allow("hot")
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	clock.Advance(*info.RetryAfter)
+	allowed, _, err = dc.Allow("hot")
+	require.NoError(t, err)
+	assert.True(t, allowed, "the window reset and the cached denial expired with it")
+}
+
+func TestDenyCache_CoversOnlyLargerRequests(t *testing.T) {
+	dc, _, _ := newTestDenyCache(t, 10, time.Minute)
+	ctx := context.Background()
+
+	allowAll(t, dc, "key", 9)
+	allowed, _, err := dc.AllowN("key", 10)
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	allowed, cached, _, err := dc.AllowNCached(ctx, "key", 1)
+	require.NoError(t, err)
+	assert.True(t, allowed, "a smaller request is not covered by the cached denial")
+	assert.False(t, cached)
+}
+
+func TestDenyCache_StatusAndResetBypassCache(t *testing.T) {
+	dc, _, _ := newTestDenyCache(t, 2, time.Minute)
+
+	allowAll(t, dc, "hot", 2)
+	allowed, _, err := dc.Allow("hot")
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	_, info, err := dc.AllowN("hot", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, info.Remaining)
+	assert.Nil(t, info.RetryAfter, "status reads the store")
+
+	require.NoError(t, dc.Reset("hot"))
+	allowed, _, err = dc.Allow("hot")
+	require.NoError(t, err)
+	assert.True(t, allowed, "a reset forgets the cached denial")
+}
+
+func TestDenyCache_PeekUsesCache(t *testing.T) {
+	dc, _, _ := newTestDenyCache(t, 2, time.Minute)
+	ctx := context.Background()
+
+	allowed, _, err := dc.Peek(ctx, "hot", 1)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowAll(t, dc, "hot", 2)
+	_, _, err = dc.Allow("hot")
+	require.NoError(t, err)
+
+	allowed, info, err := dc.Peek(ctx, "hot", 1)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.NotNil(t, info.RetryAfter)
+}
+
+func TestNewDenyCache_RequiresStaleness(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 1, Window: time.Minute})
+
+	_, err := algorithms.NewDenyCache(base, algorithms.DenyCacheConfig{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/discovery"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubResolver returns a configurable number of SRV targets
+type stubResolver struct {
+	mu    sync.Mutex
+	peers int
+	err   error
+}
+
+func (r *stubResolver) setPeers(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.peers = n
+}
+
+func (r *stubResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return "", nil, r.err
+	}
+
+	records := make([]*net.SRV, 0, r.peers)
+	for i := 0; i < r.peers; i++ {
+		records = append(records, &net.SRV{Target: fmt.Sprintf("limiter-%d.%s.", i, name), Port: 8080})
+	}
+	return name, records, nil
+}
+
+func TestDiscovery_OrdinalAndInstances(t *testing.T) {
+	resolver := &stubResolver{peers: 3}
+	d := discovery.New(discovery.Config{Service: "limiter.default.svc.cluster.local"},
+		discovery.WithResolver(resolver),
+		discovery.WithHostname(func() (string, error) { return "limiter-2", nil }),
+	)
+
+	require.NoError(t, d.Refresh(context.Background()))
+	assert.Equal(t, discovery.Topology{Instances: 3, Ordinal: 2}, d.Topology())
+}
+
+func TestDiscovery_UnknownOrdinal(t *testing.T) {
+	d := discovery.New(discovery.Config{Service: "svc"},
+		discovery.WithResolver(&stubResolver{peers: 1}),
+		discovery.WithHostname(func() (string, error) { return "my-laptop", nil }),
+	)
+
+	require.NoError(t, d.Refresh(context.Background()))
+	assert.Equal(t, -1, d.Topology().Ordinal)
+}
+
+func TestDiscovery_ResolverErrorKeepsTopology(t *testing.T) {
+	resolver := &stubResolver{peers: 4}
+	d := discovery.New(discovery.Config{Service: "svc"},
+		discovery.WithResolver(resolver),
+		discovery.WithHostname(func() (string, error) { return "limiter-0", nil }),
+	)
+	require.NoError(t, d.Refresh(context.Background()))
+
+	resolver.err = errors.New("dns down")
+	assert.Error(t, d.Refresh(context.Background()))
+	assert.Equal(t, 4, d.Topology().Instances)
+}
+
+func TestDiscovery_ChangesPropagateToSubscribers(t *testing.T) {
+	resolver := &stubResolver{peers: 2}
+	d := discovery.New(discovery.Config{Service: "svc"},
+		discovery.WithResolver(resolver),
+		discovery.WithHostname(func() (string, error) { return "limiter-1", nil }),
+	)
+
+	var seen []discovery.Topology
+	d.Subscribe(func(t discovery.Topology) { seen = append(seen, t) })
+
+	require.NoError(t, d.Refresh(context.Background()))
+	require.NoError(t, d.Refresh(context.Background())) // unchanged, no notification
+	resolver.setPeers(5)
+	require.NoError(t, d.Refresh(context.Background()))
+
+	require.Len(t, seen, 2)
+	assert.Equal(t, 2, seen[0].Instances)
+	assert.Equal(t, 5, seen[1].Instances)
+}
+
+func TestDiscovery_ScaledLimitRampsSmoothly(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	resolver := &stubResolver{peers: 2}
+	d := discovery.New(discovery.Config{Service: "svc", Ramp: 10 * time.Second},
+		discovery.WithResolver(resolver),
+		discovery.WithHostname(func() (string, error) { return "limiter-0", nil }),
+		discovery.WithNow(func() time.Time { return now }),
+	)
+
+	require.NoError(t, d.Refresh(context.Background()))
+	now = now.Add(time.Minute) // let the initial 1 -> 2 ramp finish
+	assert.Equal(t, 500, d.ScaleLimit(1000))
+
+	// Scale out from 2 to 4 peers: the per-instance share shrinks gradually
+	resolver.setPeers(4)
+	require.NoError(t, d.Refresh(context.Background()))
+	assert.Equal(t, 500, d.ScaleLimit(1000))
+
+	now = now.Add(5 * time.Second)
+	assert.InDelta(t, 3.0, d.EffectiveInstances(), 0.001)
+	assert.Equal(t, 334, d.ScaleLimit(1000))
+
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, 250, d.ScaleLimit(1000))
+}
+
+func TestDiscovery_FollowRescalesLimiters(t *testing.T) {
+	clock := simulation.NewManualClock(clockEpoch)
+	resolver := &stubResolver{peers: 2}
+	d := discovery.New(discovery.Config{Service: "svc", Ramp: 10 * time.Second},
+		discovery.WithResolver(resolver),
+		discovery.WithHostname(func() (string, error) { return "limiter-0", nil }),
+		discovery.WithNow(clock.Now),
+	)
+	require.NoError(t, d.Refresh(context.Background()))
+	clock.Advance(time.Minute)
+
+	base := limiter.Config{Limit: 100, Window: time.Minute, Burst: 40}
+	tb := algorithms.NewTokenBucket(store.NewMemoryStore(), d.ScaleConfig(base))
+	limitOf := func() int {
+		info, err := tb.Status("client")
+		require.NoError(t, err)
+		return info.Limit
+	}
+	assert.Equal(t, 20, limitOf())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Follow(ctx, time.Millisecond, func() {
+		assert.NoError(t, tb.UpdateConfig(d.ScaleConfig(base)))
+	})
+
+	// Halfway through a 2 -> 4 ramp the bucket holds a third of the burst, then a quarter
+	resolver.setPeers(4)
+	require.NoError(t, d.Refresh(context.Background()))
+	clock.Advance(5 * time.Second)
+	assert.Eventually(t, func() bool { return limitOf() == 14 }, time.Second, time.Millisecond)
+
+	clock.Advance(5 * time.Second)
+	assert.Eventually(t, func() bool { return limitOf() == 10 }, time.Second, time.Millisecond)
+}
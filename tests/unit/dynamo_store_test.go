@@ -0,0 +1,204 @@
+//go:build dynamodb
+
+// Run against DynamoDB Local with: make test-dynamodb
+// DYNAMODB_ENDPOINT overrides the default http://localhost:8000
+
+package unit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLocalDynamoStore creates a fresh table in DynamoDB Local and a store on it
+func newLocalDynamoStore(t *testing.T) *store.DynamoStore {
+	t.Helper()
+
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "http://localhost:8000"
+	}
+	client := newDynamoClient(endpoint)
+
+	ctx := context.Background()
+	table := fmt.Sprintf("rate-limiter-%d", time.Now().UnixNano())
+	_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("sk"), AttributeType: types.ScalarAttributeTypeN},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("sk"), KeyType: types.KeyTypeRange},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	require.NoError(t, err, "is DynamoDB Local running at %s?", endpoint)
+	t.Cleanup(func() {
+		client.DeleteTable(context.Background(), &dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+
+	ds := store.NewDynamoStore(table, client)
+	require.NoError(t, ds.Ping(ctx))
+	return ds
+}
+
+func TestDynamoStore_Increment(t *testing.T) {
+	ds := newLocalDynamoStore(t)
+	window := time.Unix(1_700_000_000, 0)
+
+	for i := int64(1); i <= 3; i++ {
+		count, err := ds.Increment("user", window)
+		require.NoError(t, err)
+		assert.Equal(t, i, count)
+	}
+	_, err := ds.Increment("user", window.Add(time.Minute))
+	require.NoError(t, err)
+
+	windows, err := ds.GetWindows("user", window, window.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []limiter.Window{
+		{Timestamp: window, Count: 3},
+		{Timestamp: window.Add(time.Minute), Count: 1},
+	}, windows)
+
+	windows, err = ds.GetWindows("user", window.Add(time.Second), window.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, windows, 1, "windows before from are excluded")
+}
+
+func TestDynamoStore_ConcurrentIncrementsAreNotLost(t *testing.T) {
+	ds := newLocalDynamoStore(t)
+	window := time.Unix(1_700_000_000, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ds.Increment("user", window)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	windows, err := ds.GetWindows("user", window, window)
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.Equal(t, int64(20), windows[0].Count)
+}
+
+func TestDynamoStore_Tokens(t *testing.T) {
+	ds := newLocalDynamoStore(t)
+	now := time.Now()
+
+	tokens, lastRefill, found, err := ds.GetTokens("user")
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Zero(t, tokens)
+	assert.True(t, lastRefill.IsZero())
+
+	require.NoError(t, ds.SetTokens("user", 2.5, now))
+	tokens, lastRefill, found, err = ds.GetTokens("user")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 2.5, tokens)
+	assert.Equal(t, now.UnixNano(), lastRefill.UnixNano())
+}
+
+func TestDynamoStore_ConcurrentConsumeNeverOverAdmits(t *testing.T) {
+	ds := newLocalDynamoStore(t)
+	now := time.Now()
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, _, _, err := ds.ConsumeTokens("user", 1, 5, 0, 5, now)
+			if assert.NoError(t, err) && ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(5), allowed.Load())
+}
+
+func TestDynamoStore_Timestamps(t *testing.T) {
+	ds := newLocalDynamoStore(t)
+	base := time.UnixMicro(1_700_000_000_000_000)
+
+	require.NoError(t, ds.AddTimestamps("user", base, 2, time.Minute))
+	require.NoError(t, ds.AddTimestamps("user", base.Add(time.Second), 1, time.Minute))
+
+	timestamps, err := ds.GetTimestamps("user", base, base.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{base, base, base.Add(time.Second)}, timestamps)
+
+	require.NoError(t, ds.TrimTimestamps("user", base.Add(time.Second)))
+	timestamps, err = ds.GetTimestamps("user", base, base.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{base.Add(time.Second)}, timestamps, "entries at before are kept")
+}
+
+func TestDynamoStore_Delete(t *testing.T) {
+	ds := newLocalDynamoStore(t)
+	now := time.Now()
+
+	_, err := ds.Increment("user", now)
+	require.NoError(t, err)
+	require.NoError(t, ds.SetTokens("user", 1, now))
+	require.NoError(t, ds.AddTimestamps("user", now, 1, time.Minute))
+
+	require.NoError(t, ds.Delete("user"))
+
+	windows, err := ds.GetWindows("user", now.Add(-time.Minute), now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, windows)
+	_, lastRefill, _, err := ds.GetTokens("user")
+	require.NoError(t, err)
+	assert.True(t, lastRefill.IsZero())
+	timestamps, err := ds.GetTimestamps("user", now.Add(-time.Minute), now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, timestamps)
+}
+
+func TestDynamoStore_Algorithms(t *testing.T) {
+	ds := newLocalDynamoStore(t)
+	config := limiter.Config{Limit: 3, Window: time.Minute, Burst: 3}
+
+	for _, name := range algorithms.Builtin {
+		t.Run(name, func(t *testing.T) {
+			config.Algorithm = name
+			rl, err := limiter.New(ds, config)
+			require.NoError(t, err)
+
+			for i := 0; i < 3; i++ {
+				allowed, _, err := rl.Allow("user-" + name)
+				require.NoError(t, err)
+				assert.True(t, allowed, "request %d", i+1)
+			}
+			allowed, _, err := rl.Allow("user-" + name)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+		})
+	}
+}
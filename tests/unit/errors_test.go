@@ -0,0 +1,204 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errorsConfig = limiter.Config{Limit: 10, Window: time.Minute, Burst: 10}
+
+func errorLimiters(s limiter.Store) map[string]limiter.RateLimiter {
+	return map[string]limiter.RateLimiter{
+		"token_bucket":       algorithms.NewTokenBucket(s, errorsConfig),
+		"leaky_bucket":       algorithms.NewLeakyBucket(s, errorsConfig),
+		"fixed_window":       algorithms.NewFixedWindowCounter(s, errorsConfig),
+		"sliding_window":     algorithms.NewSlidingWindowCounter(s, errorsConfig),
+		"sliding_window_log": algorithms.NewSlidingWindowLog(s, errorsConfig),
+		"gcra":               algorithms.NewGCRA(s, errorsConfig),
+	}
+}
+
+func TestErrors_InvalidInput(t *testing.T) {
+	longKey := strings.Repeat("k", limiter.MaxKeyLength+1)
+
+	for name, rl := range errorLimiters(store.NewMemoryStore()) {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := rl.AllowN("user", -1)
+			assert.ErrorIs(t, err, limiter.ErrInvalidN)
+
+			_, _, err = rl.Allow(longKey)
+			assert.ErrorIs(t, err, limiter.ErrKeyTooLong)
+
+			// A key at the limit is still accepted
+			allowed, _, err := rl.Allow(longKey[:limiter.MaxKeyLength])
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		})
+	}
+}
+
+func TestErrors_NegativeOrHugeNCannotRefill(t *testing.T) {
+	for name, rl := range errorLimiters(store.NewMemoryStore()) {
+		t.Run(name, func(t *testing.T) {
+			// The limiters share a store, so each checks its own key
+			key := "user-" + name
+			allowAll(t, rl, key, 4)
+
+			for _, n := range []int{-1, -1000, limiter.MaxN + 1, math.MaxInt} {
+				_, _, err := rl.AllowN(key, n)
+				assert.ErrorIs(t, err, limiter.ErrInvalidN, "n = %d", n)
+			}
+
+			// The largest valid request is simply denied
+			allowed, _, err := rl.AllowN(key, limiter.MaxN)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+
+			info, err := rl.Status(key)
+			require.NoError(t, err)
+			assert.Equal(t, 6, info.Remaining)
+		})
+	}
+}
+
+func TestErrors_WaitExceedsCapacity(t *testing.T) {
+	tb := algorithms.NewTokenBucket(store.NewMemoryStore(), errorsConfig)
+
+	err := tb.WaitN(context.Background(), "user", 11)
+	assert.ErrorIs(t, err, limiter.ErrRequestExceedsCapacity)
+
+	_, err = tb.Reserve("user", 11)
+	assert.ErrorIs(t, err, limiter.ErrRequestExceedsCapacity)
+
+	// Checks deny rather than fail
+	allowed, _, err := tb.AllowN("user", 11)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestErrors_RedisStoreUnavailable(t *testing.T) {
+	rs := newStalledRedisStore(t, 50*time.Millisecond)
+
+	for name, rl := range errorLimiters(rs) {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := rl.Allow("user")
+			assert.ErrorIs(t, err, limiter.ErrStoreUnavailable)
+		})
+	}
+}
+
+func TestErrors_RedisStoreUnavailableOnReads(t *testing.T) {
+	rs := newStalledRedisStore(t, 50*time.Millisecond)
+
+	// Reads must fail too: unreadable state passing for a full bucket would admit everything
+	for name, rl := range errorLimiters(rs) {
+		t.Run(name, func(t *testing.T) {
+			_, err := rl.Status("user")
+			assert.ErrorIs(t, err, limiter.ErrStoreUnavailable)
+
+			if peeker, ok := rl.(limiter.Peeker); ok {
+				_, _, err = peeker.Peek(context.Background(), "user", 1)
+				assert.ErrorIs(t, err, limiter.ErrStoreUnavailable)
+			}
+			if reporter, ok := rl.(limiter.UsageReporter); ok {
+				_, err = reporter.Usage(context.Background(), "user")
+				assert.ErrorIs(t, err, limiter.ErrStoreUnavailable)
+			}
+		})
+	}
+}
+
+func TestErrors_RedisCallerCancelIsNotUnavailable(t *testing.T) {
+	rs := newStalledRedisStore(t, time.Second)
+	tb := algorithms.NewTokenBucket(rs, errorsConfig)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := tb.AllowNCtx(ctx, "user", 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, errors.Is(err, limiter.ErrStoreUnavailable))
+}
+
+// newDynamoClient returns a DynamoDB client for endpoint with static local credentials
+func newDynamoClient(endpoint string) *dynamodb.Client {
+	return dynamodb.New(dynamodb.Options{
+		Region:           "us-east-1",
+		BaseEndpoint:     aws.String(endpoint),
+		Credentials:      credentials.NewStaticCredentialsProvider("local", "local", ""),
+		RetryMaxAttempts: 1,
+	})
+}
+
+func TestErrors_DynamoStoreUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	endpoint := "http://" + ln.Addr().String()
+	ln.Close() // Nothing listens there any more
+
+	ds := store.NewDynamoStore("rate-limiter", newDynamoClient(endpoint))
+	assert.ErrorIs(t, ds.Ping(context.Background()), limiter.ErrStoreUnavailable)
+
+	for name, rl := range errorLimiters(ds) {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := rl.Allow("user")
+			assert.ErrorIs(t, err, limiter.ErrStoreUnavailable)
+		})
+	}
+}
+
+type unavailableLimiter struct{ limiter.RateLimiter }
+
+func (unavailableLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	return false, nil, fmt.Errorf("failed to consume tokens: %w", limiter.ErrStoreUnavailable)
+}
+
+func TestHandler_StoreUnavailableIs503(t *testing.T) {
+	rs := newStalledRedisStore(t, 50*time.Millisecond)
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket": algorithms.NewTokenBucket(rs, errorsConfig),
+	}
+	h := handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "token_bucket")
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	w := doJSON(r, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice"})
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), handlers.CodeStoreUnavailable)
+}
+
+func TestHandler_InvalidInputIs400(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	w := doJSON(r, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": strings.Repeat("k", limiter.MaxKeyLength)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), handlers.CodeInvalidRequest)
+}
+
+func TestMiddleware_StoreUnavailableIs503(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := middleware.Middleware(unavailableLimiter{}, middleware.KeyByIP)(next)
+
+	w := serve(h, "10.0.0.1:1")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
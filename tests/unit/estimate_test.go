@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket_EstimateWait(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := limitertest.NewFakeClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Second, Burst: 10}, algorithms.WithClock(clock))
+
+	wait, err := tb.EstimateWait("key", 10)
+	require.NoError(t, err)
+	assert.Zero(t, wait, "a full bucket allows the request now")
+
+	allowed, _, err := tb.AllowN("key", 10)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	wait, err = tb.EstimateWait("key", 5)
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Estimating consumes nothing, and the estimate is when the request succeeds
+	clock.Advance(wait - time.Millisecond)
+	allowed, _, err = tb.AllowN("key", 5)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	clock.Advance(time.Millisecond)
+	allowed, _, err = tb.AllowN("key", 5)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	_, err = tb.EstimateWait("key", 11)
+	assert.ErrorIs(t, err, limiter.ErrRequestExceedsCapacity)
+}
+
+func TestEstimateWait_WindowCounters(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := limitertest.NewFakeClock(clockEpoch.Truncate(time.Minute).Add(15 * time.Second))
+	config := limiter.Config{Limit: 3, Window: time.Minute}
+	tests := []struct {
+		name      string
+		estimator limiter.WaitEstimator
+		want      time.Duration
+	}{
+		// Fixed windows reset on the minute; logged requests expire a window after they were made
+		{"fixed_window", algorithms.NewFixedWindowCounter(s, config, algorithms.WithClock(clock)), 45 * time.Second},
+		{"sliding_window_log", algorithms.NewSlidingWindowLog(s, config, algorithms.WithClock(clock)), time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := tt.estimator.(limiter.RateLimiter)
+			assert.Equal(t, 3, admitted(t, rl, tt.name, 3))
+
+			wait, err := tt.estimator.EstimateWait(tt.name, 1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, wait)
+
+			_, err = tt.estimator.EstimateWait(tt.name, 4)
+			assert.ErrorIs(t, err, limiter.ErrRequestExceedsCapacity)
+		})
+	}
+}
+
+func TestEstimate_HTTP(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	estimate := func(query string) (int, handlers.EstimateResponse) {
+		w := doJSON(router, http.MethodGet, "/v1/estimate?"+query, nil)
+		var resp handlers.EstimateResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w.Code, resp
+	}
+
+	code, resp := estimate("key=user:api")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "token_bucket", resp.Algorithm)
+	assert.Equal(t, 1, resp.N)
+	assert.Zero(t, resp.WaitMs)
+
+	for i := 0; i < 100; i++ {
+		doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "user"})
+	}
+
+	// 100/min refills one request every 600ms
+	code, resp = estimate("key=user:api&n=2")
+	require.Equal(t, http.StatusOK, code)
+	assert.InDelta(t, 1200, resp.WaitMs, 50)
+
+	code, resp = estimate("key=user:api&algorithm=fixed_window")
+	require.Equal(t, http.StatusOK, code)
+	assert.Zero(t, resp.WaitMs, "other algorithms keep their own state")
+
+	code, _ = estimate("key=user:api&n=101")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = estimate("n=1")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = estimate("key=user:api&algorithm=nope")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
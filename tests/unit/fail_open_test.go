@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plainStore hides a store's optional interfaces, leaving only limiter.Store
+type plainStore struct{ limiter.Store }
+
+func TestFailOpenLimiter_AllowsOnStoreError(t *testing.T) {
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+
+	fl := algorithms.NewFailOpenLimiter(brokenLimiter{unavailable: true}, algorithms.FailOpenConfig{Metrics: m, Algorithm: "broken"})
+
+	allowed, info, err := fl.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	require.NotNil(t, info)
+	assert.Nil(t, info.RetryAfter)
+
+	allowed, _, err = fl.Peek(context.Background(), "user", 1)
+	require.Error(t, err, "brokenLimiter cannot peek")
+	assert.False(t, allowed)
+
+	_, err = fl.Status("user")
+	assert.Error(t, err, "status reads are not failed open")
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.FailOpen.WithLabelValues("broken")))
+}
+
+// brokenPeeker is a brokenLimiter that can peek and be closed
+type brokenPeeker struct {
+	brokenLimiter
+}
+
+func (b brokenPeeker) Peek(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	return false, nil, b.err()
+}
+
+func (b brokenPeeker) Close() error { return nil }
+
+func TestFailOpenLimiter_PeekHonorsCloseAndContext(t *testing.T) {
+	fl := algorithms.NewFailOpenLimiter(brokenPeeker{brokenLimiter{unavailable: true}}, algorithms.FailOpenConfig{})
+
+	allowed, _, err := fl.Peek(context.Background(), "user", 1)
+	require.NoError(t, err)
+	assert.True(t, allowed, "an unavailable store fails peeks open")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	allowed, _, err = fl.Peek(ctx, "user", 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, allowed)
+
+	require.NoError(t, fl.Close())
+	allowed, _, err = fl.Peek(context.Background(), "user", 1)
+	assert.ErrorIs(t, err, limiter.ErrClosed)
+	assert.False(t, allowed)
+}
+
+func TestFailOpenLimiter_PassesOtherErrorsThrough(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	fl := algorithms.NewFailOpenLimiter(algorithms.NewTokenBucket(s, limiter.Config{Limit: 1, Window: time.Minute}), algorithms.FailOpenConfig{})
+
+	allowed, info, err := fl.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, info, err = fl.Allow("user")
+	require.NoError(t, err)
+	assert.False(t, allowed, "denials are not store failures")
+	assert.NotNil(t, info.RetryAfter)
+
+	_, _, err = fl.AllowN("user", -1)
+	assert.ErrorIs(t, err, limiter.ErrInvalidN)
+
+	// Errors that are not outages, such as a store lacking a feature the limiter needs
+	_, _, err = algorithms.NewFailOpenLimiter(brokenLimiter{}, algorithms.FailOpenConfig{}).Allow("user")
+	assert.EqualError(t, err, "corrupt state")
+	debt := algorithms.NewTokenBucket(plainStore{s}, limiter.Config{Limit: 1, Window: time.Minute, MaxDebt: 5})
+	_, _, err = algorithms.NewFailOpenLimiter(debt, algorithms.FailOpenConfig{}).Allow("user")
+	assert.ErrorContains(t, err, "does not support token debt")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = algorithms.NewFailOpenLimiter(brokenLimiter{}, algorithms.FailOpenConfig{}).AllowCtx(ctx, "user")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	require.NoError(t, fl.Close())
+	_, _, err = fl.Allow("user")
+	assert.ErrorIs(t, err, limiter.ErrClosed)
+}
+
+func TestCheck_FailureMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		wrap        bool
+		unavailable bool
+		status      int
+	}{
+		{"fail closed", false, true, http.StatusServiceUnavailable},
+		{"fail open", true, true, http.StatusOK},
+		{"fail open passes other errors", true, false, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rl limiter.RateLimiter = brokenLimiter{unavailable: tt.unavailable}
+			if tt.wrap {
+				rl = algorithms.NewFailOpenLimiter(rl, algorithms.FailOpenConfig{})
+			}
+			router := gin.New()
+			handlers.NewRateLimitHandler(map[string]limiter.RateLimiter{"broken": rl},
+				metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "broken").RegisterRoutes(router)
+
+			w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice"})
+			require.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.status == http.StatusOK {
+				var resp handlers.CheckResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.True(t, resp.Allowed)
+			}
+		})
+	}
+}
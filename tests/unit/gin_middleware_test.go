@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLimitedRouter(mw gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.Use(mw)
+	router.GET("/orders/:id", func(c *gin.Context) { c.String(http.StatusOK, "order") })
+	router.GET("/healthz", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return router
+}
+
+func ginGet(router http.Handler, path, clientIP string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = clientIP + ":1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGinMiddleware_LimitsRoutes(t *testing.T) {
+	rl := newMiddlewareLimiter(t, 2)
+	router := newLimitedRouter(handlers.GinMiddleware(rl, func(c *gin.Context) string { return c.ClientIP() }))
+
+	w := ginGet(router, "/orders/1", "10.0.0.1")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "order", w.Body.String())
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset"))
+
+	require.Equal(t, http.StatusOK, ginGet(router, "/orders/2", "10.0.0.1").Code)
+
+	w = ginGet(router, "/orders/3", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.False(t, strings.Contains(w.Body.String(), "order"), "handler must not run once aborted")
+
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, 2, resp.Limit)
+	require.NotNil(t, resp.RetryAfter)
+
+	assert.Equal(t, http.StatusOK, ginGet(router, "/orders/1", "10.0.0.2").Code, "other clients keep their own quota")
+}
+
+func TestGinMiddleware_SkipBypassesLimit(t *testing.T) {
+	rl := newMiddlewareLimiter(t, 1)
+	skipHealth := func(c *gin.Context) bool { return c.FullPath() == "/healthz" }
+	router := newLimitedRouter(handlers.GinMiddleware(rl, func(c *gin.Context) string { return c.ClientIP() }, handlers.WithSkip(skipHealth)))
+
+	for i := 0; i < 3; i++ {
+		w := ginGet(router, "/healthz", "10.0.0.1")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+	}
+
+	// Skipped requests consumed nothing
+	assert.Equal(t, http.StatusOK, ginGet(router, "/orders/1", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, ginGet(router, "/orders/1", "10.0.0.1").Code)
+}
+
+func TestGinMiddleware_RecordsMetricsByRoute(t *testing.T) {
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	rl := newMiddlewareLimiter(t, 1)
+	router := newLimitedRouter(handlers.GinMiddleware(rl, func(c *gin.Context) string { return c.ClientIP() },
+		handlers.WithMiddlewareMetrics(m, "fixed_window")))
+
+	ginGet(router, "/orders/1", "10.0.0.1")
+	ginGet(router, "/orders/2", "10.0.0.1")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.RequestsTotal.WithLabelValues("fixed_window", "/orders/:id")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RequestsAllowed.WithLabelValues("fixed_window", "/orders/:id")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RequestsDenied.WithLabelValues("fixed_window", "/orders/:id")))
+}
@@ -0,0 +1,155 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	ratelimitv1 "github.com/AbubakarMahmood1/go-rate-limiter/api/ratelimit/v1"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/grpcserver"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// dialTestGRPC serves srv over an in-memory listener and returns a client for it
+func dialTestGRPC(t *testing.T, srv *grpcserver.Server) ratelimitv1.RateLimitServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	srv.Register(gs)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return ratelimitv1.NewRateLimitServiceClient(conn)
+}
+
+func newTestGRPCServer(t *testing.T, opts ...grpcserver.Option) (ratelimitv1.RateLimitServiceClient, *metrics.Metrics) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	config := limiter.Config{Limit: 2, Window: time.Hour}
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket": algorithms.NewTokenBucket(s, config),
+		"fixed_window": algorithms.NewFixedWindowCounter(s, config),
+	}
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	return dialTestGRPC(t, grpcserver.NewServer(limiters, m, "token_bucket", opts...)), m
+}
+
+func TestGRPCServer_CheckStatusReset(t *testing.T) {
+	client, m := newTestGRPCServer(t)
+	ctx := context.Background()
+	check := &ratelimitv1.CheckRequest{Resource: "api.export", Identifier: "alice", Algorithm: "fixed_window"}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Check(ctx, check)
+		require.NoError(t, err)
+		assert.True(t, resp.GetAllowed())
+		assert.Equal(t, int32(2), resp.GetLimit())
+		assert.Equal(t, int32(1-i), resp.GetRemaining())
+		assert.Nil(t, resp.RetryAfter)
+		assert.True(t, resp.GetResetAt().AsTime().After(time.Now()))
+	}
+
+	resp, err := client.Check(ctx, check)
+	require.NoError(t, err)
+	assert.False(t, resp.GetAllowed())
+	require.NotNil(t, resp.RetryAfter)
+	assert.Positive(t, resp.GetRetryAfter())
+	assert.Equal(t, float64(3), testutil.ToFloat64(m.RequestsTotal.WithLabelValues("fixed_window", "api")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.RequestsDenied.WithLabelValues("fixed_window", "api")))
+
+	// The default algorithm keeps its own state
+	resp, err = client.GetStatus(ctx, &ratelimitv1.GetStatusRequest{Key: "alice:api.export"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.GetRemaining())
+
+	for i := 0; i < 2; i++ {
+		resp, err = client.GetStatus(ctx, &ratelimitv1.GetStatusRequest{Key: "alice:api.export", Algorithm: "fixed_window"})
+		require.NoError(t, err)
+		assert.False(t, resp.GetAllowed())
+		assert.Equal(t, int32(0), resp.GetRemaining())
+	}
+
+	_, err = client.Reset(ctx, &ratelimitv1.ResetRequest{Key: "alice:api.export", Algorithm: "fixed_window"})
+	require.NoError(t, err)
+	resp, err = client.Check(ctx, check)
+	require.NoError(t, err)
+	assert.True(t, resp.GetAllowed())
+}
+
+func TestGRPCServer_Count(t *testing.T) {
+	client, _ := newTestGRPCServer(t)
+	ctx := context.Background()
+
+	resp, err := client.Check(ctx, &ratelimitv1.CheckRequest{Resource: "api.export", Identifier: "alice", Count: proto.Int32(2)})
+	require.NoError(t, err)
+	assert.True(t, resp.GetAllowed())
+	assert.Equal(t, int32(0), resp.GetRemaining())
+
+	_, err = client.Check(ctx, &ratelimitv1.CheckRequest{Resource: "api.export", Identifier: "alice", Count: proto.Int32(0)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCServer_MaxCount(t *testing.T) {
+	client, _ := newTestGRPCServer(t, grpcserver.WithMaxCount(50))
+	ctx := context.Background()
+
+	_, err := client.Check(ctx, &ratelimitv1.CheckRequest{Resource: "api.export", Identifier: "alice", Count: proto.Int32(51)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// A count within the cap is checked, and denied here by the limit of 2
+	resp, err := client.Check(ctx, &ratelimitv1.CheckRequest{Resource: "api.export", Identifier: "alice", Count: proto.Int32(50)})
+	require.NoError(t, err)
+	assert.False(t, resp.GetAllowed())
+}
+
+func TestGRPCServer_InvalidRequests(t *testing.T) {
+	client, _ := newTestGRPCServer(t)
+	ctx := context.Background()
+
+	_, err := client.Check(ctx, &ratelimitv1.CheckRequest{Resource: "api.export"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.Check(ctx, &ratelimitv1.CheckRequest{Resource: "api.export", Identifier: "alice", Algorithm: "nope"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.GetStatus(ctx, &ratelimitv1.GetStatusRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.Reset(ctx, &ratelimitv1.ResetRequest{Key: "alice:api.export", Algorithm: "nope"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCServer_ReadOnly(t *testing.T) {
+	client, _ := newTestGRPCServer(t, grpcserver.WithReadOnly(func() bool { return true }))
+	ctx := context.Background()
+
+	// Checks peek, so repeating one consumes nothing
+	for i := 0; i < 3; i++ {
+		resp, err := client.Check(ctx, &ratelimitv1.CheckRequest{Resource: "api.export", Identifier: "alice"})
+		require.NoError(t, err)
+		assert.True(t, resp.GetAllowed())
+		assert.Equal(t, int32(2), resp.GetRemaining())
+	}
+
+	_, err := client.Reset(ctx, &ratelimitv1.ResetRequest{Key: "alice:api.export"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
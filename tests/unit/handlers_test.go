@@ -0,0 +1,591 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter builds a router around a handler backed by a fresh memory store
+func newTestRouter(t *testing.T, opts ...handlers.Option) (*gin.Engine, limiter.Store) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{
+			Limit:  100,
+			Window: 1 * time.Minute,
+			Burst:  100,
+		}),
+		"fixed_window": algorithms.NewFixedWindowCounter(s, limiter.Config{
+			Limit:  100,
+			Window: 1 * time.Minute,
+		}),
+	}
+
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	h := handlers.NewRateLimitHandler(limiters, m, "token_bucket", opts...)
+
+	router := gin.New()
+	h.RegisterRoutes(router)
+	return router, s
+}
+
+func doJSON(router http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCheck_DedupRejectsIdenticalPayloads(t *testing.T) {
+	dedupStore := store.NewMemoryStore()
+	defer dedupStore.Close()
+
+	dedup := algorithms.NewSlidingWindowLog(dedupStore, limiter.Config{
+		Limit:  1,
+		Window: 1 * time.Minute,
+	})
+	router, _ := newTestRouter(t, handlers.WithDedup(dedup, nil))
+
+	payload := map[string]interface{}{"resource": "api.export", "identifier": "alice"}
+
+	w := doJSON(router, http.MethodPost, "/v1/check", payload)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(router, http.MethodPost, "/v1/check", payload)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handlers.CodeDuplicateRequest, resp["code"])
+
+	// A different payload is not a duplicate
+	w = doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+		"resource": "api.export", "identifier": "bob",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCheck_DedupWindowStartsAtFirstPayload(t *testing.T) {
+	dedupStore := store.NewMemoryStore()
+	defer dedupStore.Close()
+
+	// A millisecond before a minute boundary, where a fixed window would start over
+	clock := simulation.NewManualClock(time.Date(2024, 1, 1, 12, 0, 59, 999_000_000, time.UTC))
+	dedup := algorithms.NewSlidingWindowLog(dedupStore, limiter.Config{
+		Limit:  1,
+		Window: 1 * time.Minute,
+	}, algorithms.WithClock(clock))
+	router, _ := newTestRouter(t, handlers.WithDedup(dedup, nil))
+
+	payload := map[string]interface{}{"resource": "api.export", "identifier": "alice"}
+	w := doJSON(router, http.MethodPost, "/v1/check", payload)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	clock.Advance(time.Millisecond)
+	w = doJSON(router, http.MethodPost, "/v1/check", payload)
+	assert.Equal(t, http.StatusConflict, w.Code, "the boundary does not end the window")
+
+	clock.Advance(time.Minute - 2*time.Millisecond)
+	w = doJSON(router, http.MethodPost, "/v1/check", payload)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	clock.Advance(2 * time.Millisecond)
+	w = doJSON(router, http.MethodPost, "/v1/check", payload)
+	assert.Equal(t, http.StatusOK, w.Code, "once a window has passed since the first payload it is accepted again")
+}
+
+func TestCheck_DedupHashesOnlyConfiguredFields(t *testing.T) {
+	dedupStore := store.NewMemoryStore()
+	defer dedupStore.Close()
+
+	dedup := algorithms.NewFixedWindowCounter(dedupStore, limiter.Config{
+		Limit:  1,
+		Window: 1 * time.Minute,
+	})
+	router, _ := newTestRouter(t, handlers.WithDedup(dedup, []string{"identifier", "payload"}))
+
+	first := map[string]interface{}{
+		"resource":   "api.search",
+		"identifier": "alice",
+		"payload":    map[string]interface{}{"q": "go", "page": 1},
+	}
+	w := doJSON(router, http.MethodPost, "/v1/check", first)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Same hashed fields (with different key order) but a different resource is still a duplicate
+	second := map[string]interface{}{
+		"resource":   "api.other",
+		"identifier": "alice",
+		"payload":    map[string]interface{}{"page": 1, "q": "go"},
+	}
+	w = doJSON(router, http.MethodPost, "/v1/check", second)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Changing a hashed field makes it distinct
+	third := map[string]interface{}{
+		"resource":   "api.search",
+		"identifier": "alice",
+		"payload":    map[string]interface{}{"q": "go", "page": 2},
+	}
+	w = doJSON(router, http.MethodPost, "/v1/check", third)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCheck_WithoutDedupAllowsIdenticalPayloads(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	payload := map[string]interface{}{"resource": "api.export", "identifier": "alice"}
+	for i := 0; i < 3; i++ {
+		w := doJSON(router, http.MethodPost, "/v1/check", payload)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestCheck_HeaderSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  handlers.HeaderConfig
+		present []string
+		absent  []string
+	}{
+		{
+			name:    "default emits all",
+			config:  handlers.HeaderConfig{},
+			present: []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+		},
+		{
+			name:    "subset",
+			config:  handlers.HeaderConfig{Include: []string{handlers.HeaderRemaining}},
+			present: []string{"X-RateLimit-Remaining"},
+			absent:  []string{"X-RateLimit-Limit", "X-RateLimit-Reset", "Retry-After"},
+		},
+		{
+			name:   "disabled",
+			config: handlers.HeaderConfig{Disabled: true, Include: []string{handlers.HeaderLimit}},
+			absent: []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newTestRouter(t, handlers.WithHeaders(tt.config))
+
+			w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+				"resource": "api.users", "identifier": "alice",
+			})
+			require.Equal(t, http.StatusOK, w.Code)
+
+			for _, name := range tt.present {
+				assert.NotEmpty(t, w.Header().Get(name), name)
+			}
+			for _, name := range tt.absent {
+				assert.Empty(t, w.Header().Get(name), name)
+			}
+
+			// The body always carries the limit state
+			var resp handlers.CheckResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, 100, resp.Limit)
+		})
+	}
+}
+
+func TestCheck_HeaderStyles(t *testing.T) {
+	// Evaluated at a fixed time, an emptied bucket refills fully in one minute
+	reset := fmt.Sprintf("%d", clockEpoch.Add(time.Minute).Unix())
+
+	tests := []struct {
+		style  string
+		want   map[string]string
+		absent []string
+	}{
+		{
+			style:  "",
+			want:   map[string]string{"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+			absent: []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
+		},
+		{
+			style:  handlers.HeaderStyleLegacy,
+			want:   map[string]string{"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+			absent: []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
+		},
+		{
+			style:  handlers.HeaderStyleDraft,
+			want:   map[string]string{"RateLimit-Limit": "100", "RateLimit-Remaining": "0", "RateLimit-Reset": "60"},
+			absent: []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+		},
+		{
+			style: handlers.HeaderStyleBoth,
+			want: map[string]string{
+				"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset,
+				"RateLimit-Limit": "100", "RateLimit-Remaining": "0", "RateLimit-Reset": "60",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("style "+tt.style, func(t *testing.T) {
+			config := handlers.HeaderConfig{Style: tt.style}
+			require.NoError(t, config.Validate())
+			router, _ := newTestRouter(t, handlers.WithHeaders(config), handlers.WithClientTimestamps())
+
+			w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+				"resource": "api.users", "identifier": "alice", "count": 100,
+				"timestamp": clockEpoch.Format(time.RFC3339),
+			})
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			for name, value := range tt.want {
+				assert.Equal(t, value, w.Header().Get(name), name)
+			}
+			for _, name := range tt.absent {
+				assert.Empty(t, w.Header().Get(name), name)
+			}
+			// Headers without a draft equivalent keep their names
+			assert.Equal(t, "100", w.Header().Get("X-RateLimit-Used"))
+		})
+	}
+
+	assert.Error(t, handlers.HeaderConfig{Style: "ietf"}.Validate())
+}
+
+func TestCheck_RetryAfterHeaderSelection(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithHeaders(handlers.HeaderConfig{
+		Include: []string{handlers.HeaderRetryAfter},
+	}))
+
+	body := map[string]interface{}{"resource": "api.users", "identifier": "alice", "count": 100}
+	doJSON(router, http.MethodPost, "/v1/check", body)
+
+	w := doJSON(router, http.MethodPost, "/v1/check", body)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestCheck_RetryAfterRoundsUp(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	// 100 a minute refills a token every 0.6s, which must not be reported as 0 seconds
+	body := map[string]interface{}{"resource": "api.users", "identifier": "alice", "count": 100}
+	doJSON(router, http.MethodPost, "/v1/check", body)
+
+	body["count"] = 1
+	w := doJSON(router, http.MethodPost, "/v1/check", body)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.RetryAfter)
+	assert.Equal(t, 1, *resp.RetryAfter)
+}
+
+func TestCheck_RejectsNegativeAndExcessiveCounts(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithMaxCount(50))
+
+	body := map[string]interface{}{"resource": "api.users", "identifier": "alice", "count": 10}
+	w := doJSON(router, http.MethodPost, "/v1/check", body)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	for _, count := range []int{-1, -100, 0, 51, math.MaxInt} {
+		body["count"] = count
+		w = doJSON(router, http.MethodPost, "/v1/check", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "count %d", count)
+	}
+
+	// Nothing was refunded or consumed by the rejected checks
+	w = doJSON(router, http.MethodGet, "/v1/status/alice:api.users", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 90, resp.Remaining)
+
+	body["count"] = 50
+	w = doJSON(router, http.MethodPost, "/v1/check", body)
+	assert.Equal(t, http.StatusOK, w.Code, "the maximum itself is allowed")
+}
+
+func TestResetPrefix(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	check := func(identifier string) int {
+		body := map[string]interface{}{"resource": "api", "identifier": identifier, "count": 10, "algorithm": "fixed_window"}
+		w := doJSON(router, http.MethodPost, "/v1/check", body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp handlers.CheckResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Remaining
+	}
+	for _, identifier := range []string{"tenant42:alice", "tenant42:bob", "tenant420:carol", "tenant7:dave"} {
+		check(identifier)
+	}
+
+	// Prefixes match stored keys, where ':' in identifiers is escaped
+	w := doJSON(router, http.MethodPost, "/v1/reset-prefix", map[string]interface{}{"prefix": "tenant42%3A", "algorithm": "fixed_window"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Prefix string `json:"prefix"`
+		Reset  int    `json:"reset"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "tenant42%3A", resp.Prefix)
+	assert.Equal(t, 2, resp.Reset)
+
+	// The tenant starts over; keys outside it keep their counts
+	assert.Equal(t, 90, check("tenant42:alice"))
+	assert.Equal(t, 90, check("tenant42:bob"))
+	assert.Equal(t, 80, check("tenant420:carol"))
+	assert.Equal(t, 80, check("tenant7:dave"))
+
+	w = doJSON(router, http.MethodPost, "/v1/reset-prefix", map[string]interface{}{"prefix": ""})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(router, http.MethodPost, "/v1/reset-prefix", map[string]interface{}{"prefix": "tenant42:", "algorithm": "nope"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCheck_HeaderBudgetTruncatesInPriorityOrder(t *testing.T) {
+	// A denied read-only check with every group enabled. Sizes are name + value + 4:
+	// core trio 81 bytes, Retry-After 17, X-RateLimit-Warning 61, X-RateLimit-Policy 34
+	everything := []string{"limit", "remaining", "reset", "retry_after", "policy", "warning"}
+	core := []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"}
+
+	tests := []struct {
+		name     string
+		budget   int
+		priority []string
+		present  []string
+		omitted  []string
+	}{
+		{
+			name:    "unlimited",
+			present: []string{"Retry-After", "X-RateLimit-Warning", "X-RateLimit-Policy"},
+		},
+		{
+			name:    "room for retry after only",
+			budget:  127,
+			present: []string{"Retry-After"},
+			omitted: []string{"warning", "policy"},
+		},
+		{
+			name:    "exact fit keeps warning",
+			budget:  159,
+			present: []string{"Retry-After", "X-RateLimit-Warning"},
+			omitted: []string{"policy"},
+		},
+		{
+			name:    "everything fits",
+			budget:  193,
+			present: []string{"Retry-After", "X-RateLimit-Warning", "X-RateLimit-Policy"},
+		},
+		{
+			name:     "custom priority stops at the first group that does not fit",
+			budget:   127,
+			priority: []string{"policy", "warning"},
+			present:  []string{"X-RateLimit-Policy"},
+			omitted:  []string{"warning", "retry_after"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+
+			config := handlers.HeaderConfig{Include: everything, Budget: tt.budget, Priority: tt.priority}
+			require.NoError(t, config.Validate())
+
+			limiters := map[string]limiter.RateLimiter{
+				"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{Limit: 100, Window: time.Minute, Burst: 100}),
+			}
+			m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+			h := handlers.NewRateLimitHandler(limiters, m, "token_bucket", handlers.WithHeaders(config))
+			router := gin.New()
+			h.RegisterRoutes(router)
+
+			body := map[string]interface{}{"resource": "api.users", "identifier": "alice", "count": 100}
+			require.Equal(t, http.StatusOK, doJSON(router, http.MethodPost, "/v1/check", body).Code)
+			require.Equal(t, http.StatusOK, doJSON(router, http.MethodPut, "/v1/read-only", map[string]bool{"enabled": true}).Code)
+
+			w := doJSON(router, http.MethodPost, "/v1/check", body)
+			require.Equal(t, http.StatusTooManyRequests, w.Code)
+
+			for _, name := range append(core, tt.present...) {
+				assert.NotEmpty(t, w.Header().Get(name), name)
+			}
+			for _, group := range tt.omitted {
+				assert.Equal(t, 1.0, testutil.ToFloat64(m.HeaderTruncation.WithLabelValues(group)), group)
+			}
+			assert.Equal(t, len(tt.omitted), testutil.CollectAndCount(m.HeaderTruncation))
+			assert.Equal(t, 3+len(tt.present), countRateLimitHeaders(w.Header()))
+		})
+	}
+}
+
+func countRateLimitHeaders(h http.Header) int {
+	n := 0
+	for name := range h {
+		if strings.HasPrefix(name, "X-Ratelimit-") || name == "Retry-After" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestHeaderConfig_Validate(t *testing.T) {
+	assert.NoError(t, handlers.HeaderConfig{Include: []string{"limit", "retry_after"}}.Validate())
+	assert.Error(t, handlers.HeaderConfig{Include: []string{"X-RateLimit-Limit"}}.Validate())
+	assert.NoError(t, handlers.HeaderConfig{Budget: 127, Priority: []string{"policy"}}.Validate())
+	assert.Error(t, handlers.HeaderConfig{Budget: 126}.Validate(), "below the core reserve")
+	assert.NoError(t, handlers.HeaderConfig{Budget: 121, Style: handlers.HeaderStyleDraft}.Validate())
+	assert.Error(t, handlers.HeaderConfig{Budget: 127, Style: handlers.HeaderStyleBoth}.Validate(), "both sets are reserved")
+	assert.Error(t, handlers.HeaderConfig{Priority: []string{"limit"}}.Validate(), "core headers are not ranked")
+}
+
+func TestGetStatus_AllAlgorithms(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for algorithm, requests := range map[string]int{"token_bucket": 3, "fixed_window": 5} {
+		for i := 0; i < requests; i++ {
+			w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+				"resource":   "api.users",
+				"identifier": "alice",
+				"algorithm":  algorithm,
+			})
+			require.Equal(t, http.StatusOK, w.Code)
+		}
+	}
+
+	// Repeated status calls peek, so the remaining counts stay put
+	for i := 0; i < 2; i++ {
+		w := doJSON(router, http.MethodGet, "/v1/status/alice:api.users?algorithm=all", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Key        string                            `json:"key"`
+			Algorithms map[string]handlers.CheckResponse `json:"algorithms"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "alice:api.users", resp.Key)
+		require.Len(t, resp.Algorithms, 2)
+		assert.Equal(t, 97, resp.Algorithms["token_bucket"].Remaining)
+		assert.Equal(t, 95, resp.Algorithms["fixed_window"].Remaining)
+		assert.Equal(t, 100, resp.Algorithms["fixed_window"].Limit)
+	}
+}
+
+func TestGetStatus_DoesNotConsume(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	status := func() handlers.CheckResponse {
+		w := doJSON(router, http.MethodGet, "/v1/status/alice:api.users?algorithm=fixed_window", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp handlers.CheckResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	// A key never checked has its whole limit
+	resp := status()
+	assert.True(t, resp.Allowed)
+	assert.Equal(t, 100, resp.Remaining)
+
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+		"resource":   "api.users",
+		"identifier": "alice",
+		"algorithm":  "fixed_window",
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// However often a dashboard polls, the count stays where the check left it
+	for i := 0; i < 1000; i++ {
+		require.Equal(t, 99, status().Remaining, "status %d", i+1)
+	}
+}
+
+func TestCheck_RecordsRemainingByKeyPrefix(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Minute, Burst: 10}),
+	}
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	h := handlers.NewRateLimitHandler(limiters, m, "token_bucket")
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	body := map[string]interface{}{"resource": "api.users", "identifier": "alice", "count": 3}
+	require.Equal(t, http.StatusOK, doJSON(router, http.MethodPost, "/v1/check", body).Code)
+	assert.Equal(t, 7.0, testutil.ToFloat64(m.RemainingGauge.WithLabelValues("token_bucket", "api")))
+
+	// Keys share their prefix's gauge, which follows the latest check
+	body = map[string]interface{}{"resource": "api.orders", "identifier": "bob", "count": 1}
+	require.Equal(t, http.StatusOK, doJSON(router, http.MethodPost, "/v1/check", body).Code)
+	assert.Equal(t, 9.0, testutil.ToFloat64(m.RemainingGauge.WithLabelValues("token_bucket", "api")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.RemainingGauge))
+}
+
+// unreachableStore is a store whose ping fails, like Redis during an outage
+type unreachableStore struct{ limiter.Store }
+
+func (unreachableStore) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestHealth_ReportsStoreReachability(t *testing.T) {
+	t.Run("reachable", func(t *testing.T) {
+		s := store.NewMemoryStore()
+		defer s.Close()
+		router, _ := newTestRouter(t, handlers.WithStore(s))
+
+		w := doJSON(router, http.MethodGet, "/health", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"healthy"`)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		router, _ := newTestRouter(t, handlers.WithStore(unreachableStore{}))
+
+		w := doJSON(router, http.MethodGet, "/health", nil)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "unhealthy", resp["status"])
+		assert.Equal(t, "connection refused", resp["store"])
+		assert.NotEmpty(t, resp["time"])
+	})
+}
@@ -0,0 +1,144 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func orgOf(key string) string { return "org:acme" }
+
+func TestHierarchical_ParentDenialRefundsChild(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	parent := algorithms.NewTokenBucket(s, limiter.Config{Limit: 3, Window: time.Hour})
+	child := algorithms.NewTokenBucket(s, limiter.Config{Limit: 2, Window: time.Hour})
+	h := algorithms.NewHierarchical(parent, child, orgOf)
+
+	// alice uses 2 of the org's 3
+	for i := 0; i < 2; i++ {
+		allowed, _, err := h.Allow("alice")
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	// bob's first request takes the org's last slot, the second is denied by the org
+	allowed, _, err := h.Allow("bob")
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, info, err := h.Allow("bob")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 3, info.Limit, "denial reports the parent's limit")
+	assert.NotNil(t, info.RetryAfter)
+
+	// The child allowed but the parent denied: bob's token was given back
+	_, bobInfo, err := child.Peek(context.Background(), "bob", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, bobInfo.Remaining)
+}
+
+func TestHierarchical_ChildDenialLeavesParent(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	parent := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Hour})
+	child := algorithms.NewTokenBucket(s, limiter.Config{Limit: 1, Window: time.Hour})
+	h := algorithms.NewHierarchical(parent, child, orgOf)
+
+	allowed, info, err := h.Allow("alice")
+	require.NoError(t, err)
+	require.True(t, allowed)
+	assert.Equal(t, 0, info.Remaining, "allowed requests report the tighter level")
+
+	allowed, info, err = h.Allow("alice")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 1, info.Limit, "denial reports the child's limit")
+
+	_, orgInfo, err := parent.Peek(context.Background(), "org:acme", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 9, orgInfo.Remaining)
+}
+
+func TestHierarchical_ChildWithoutRefundIsNotConsumed(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	// Window counters cannot refund, so the parent is peeked before the child is consumed
+	parent := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 1, Window: time.Hour})
+	child := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 5, Window: time.Hour})
+	h := algorithms.NewHierarchical(parent, child, orgOf)
+
+	allowed, _, err := h.Allow("alice")
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, _, err = h.Allow("bob")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	_, bobInfo, err := child.Peek(context.Background(), "bob", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 5, bobInfo.Remaining)
+}
+
+func TestCheck_ParentIdentifier(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	parents := map[string]limiter.RateLimiter{
+		"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{Limit: 3, Window: time.Hour}),
+	}
+	router, _ := newTestRouter(t, handlers.WithParentLimiters(parents))
+
+	check := func(identifier string) (int, handlers.CheckResponse) {
+		w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+			"resource":          "api.users",
+			"identifier":        identifier,
+			"parent_identifier": "acme",
+		})
+		var resp handlers.CheckResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	for _, user := range []string{"alice", "bob", "carol"} {
+		code, _ := check(user)
+		require.Equal(t, http.StatusOK, code, user)
+	}
+	code, resp := check("dave")
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, 3, resp.Limit)
+
+	// dave's own quota is untouched
+	w := doJSON(router, http.MethodGet, "/v1/status/dave:api.users?algorithm=all", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var status struct {
+		Algorithms map[string]handlers.CheckResponse `json:"algorithms"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, 100, status.Algorithms["token_bucket"].Remaining)
+}
+
+func TestCheck_ParentIdentifierNotEnabled(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+		"resource":          "api.users",
+		"identifier":        "alice",
+		"parent_identifier": "acme",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
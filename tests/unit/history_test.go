@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory_QueryMatchesRecordedWindows(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	h := store.NewHistory(s, time.Minute)
+	ctx := context.Background()
+	base := time.Now().Truncate(time.Minute).Add(-10 * time.Minute)
+
+	// window offset -> (attempted, allowed)
+	recorded := []struct {
+		offset    int
+		attempted int
+		allowed   int
+	}{
+		{0, 5, 5},
+		{1, 8, 3},
+		{3, 2, 0},
+		{4, 1, 1},
+	}
+	for _, r := range recorded {
+		at := base.Add(time.Duration(r.offset)*time.Minute + 30*time.Second)
+		for i := 0; i < r.attempted; i++ {
+			require.NoError(t, h.Record(ctx, "alice", i < r.allowed, at))
+		}
+	}
+	require.NoError(t, h.Record(ctx, "bob", true, base))
+
+	history, err := h.Query(ctx, "alice", base, base.Add(5*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, history, len(recorded))
+
+	for i, r := range recorded {
+		assert.True(t, base.Add(time.Duration(r.offset)*time.Minute).Equal(history[i].Start))
+		assert.Equal(t, int64(r.attempted), history[i].Attempted)
+		assert.Equal(t, int64(r.allowed), history[i].Allowed)
+	}
+
+	// A narrower range only returns the windows inside it
+	history, err = h.Query(ctx, "alice", base.Add(time.Minute+10*time.Second), base.Add(3*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, int64(8), history[0].Attempted)
+	assert.Equal(t, int64(2), history[1].Attempted)
+}
+
+func TestHistory_SurvivesReset(t *testing.T) {
+	hs := store.NewMemoryStore()
+	defer hs.Close()
+	h := store.NewHistory(hs, time.Minute)
+	router, _ := newTestRouter(t, handlers.WithHistory(h))
+	payload := map[string]interface{}{"resource": "api.users", "identifier": "alice"}
+
+	for i := 0; i < 3; i++ {
+		w := doJSON(router, http.MethodPost, "/v1/check", payload)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w := doJSON(router, http.MethodPost, "/v1/reset/alice:api.users", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(router, http.MethodGet, "/v1/history/alice:api.users?windows=2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Key     string               `json:"key"`
+		History []store.HistoryEntry `json:"history"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "alice:api.users", resp.Key)
+
+	// The checks may straddle a window boundary, so compare totals
+	var attempted, allowed int64
+	for _, e := range resp.History {
+		attempted += e.Attempted
+		allowed += e.Allowed
+	}
+	assert.Equal(t, int64(3), attempted)
+	assert.Equal(t, int64(3), allowed)
+}
+
+func TestHistory_Endpoint(t *testing.T) {
+	router, _ := newTestRouter(t)
+	w := doJSON(router, http.MethodGet, "/v1/history/alice:api.users", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	hs := store.NewMemoryStore()
+	defer hs.Close()
+	h := store.NewHistory(hs, time.Minute)
+	router, _ = newTestRouter(t, handlers.WithHistory(h))
+
+	w = doJSON(router, http.MethodGet, "/v1/history/alice:api.users?from=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(router, http.MethodGet, "/v1/history/alice:api.users?from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
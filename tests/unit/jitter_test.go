@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ten-minute windows whose resets spread over the first minute of the next window
+var jitterConfig = limiter.Config{Limit: 2, Window: 10 * time.Minute, ResetJitter: time.Minute}
+
+// Starts on a ten-minute boundary
+var jitterEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newJitteredCounter(t *testing.T, config limiter.Config) (*algorithms.FixedWindowCounter, *simulation.ManualClock) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	clock := simulation.NewManualClock(jitterEpoch)
+	return algorithms.NewFixedWindowCounter(s, config, algorithms.WithClock(clock)), clock
+}
+
+func TestFixedWindow_JitterSpreadsResetsOverKeys(t *testing.T) {
+	fwc, _ := newJitteredCounter(t, jitterConfig)
+	boundary := jitterEpoch.Add(10 * time.Minute)
+
+	// Ten-second bins over the one-minute spread
+	const keys = 6000
+	bins := make([]int, 6)
+	for i := 0; i < keys; i++ {
+		_, info, err := fwc.Allow(fmt.Sprintf("client-%d", i))
+		require.NoError(t, err)
+
+		offset := info.ResetAt.Sub(boundary)
+		require.GreaterOrEqual(t, offset, time.Duration(0))
+		require.Less(t, offset, time.Minute)
+		require.Equal(t, offset, offset.Truncate(time.Second), "offsets are whole seconds")
+		bins[offset/(10*time.Second)]++
+	}
+
+	// Each bin expects 1000; a fair spread stays well within 15% of that
+	for i, n := range bins {
+		assert.InDelta(t, keys/len(bins), n, 150, "bin %d", i)
+	}
+}
+
+func TestFixedWindow_JitterIsStablePerKey(t *testing.T) {
+	fwc, clock := newJitteredCounter(t, jitterConfig)
+
+	_, first, err := fwc.Allow("client")
+	require.NoError(t, err)
+
+	clock.Advance(3 * time.Minute)
+	_, _, err = fwc.Allow("client")
+	require.NoError(t, err)
+	_, denied, err := fwc.Allow("client")
+	require.NoError(t, err)
+
+	assert.True(t, denied.ResetAt.Equal(first.ResetAt), "the reset does not move between calls")
+	assert.Equal(t, denied.ResetAt.Sub(clock.Now()), *denied.RetryAfter, "retry after agrees with the reset")
+
+	// The same key gets the same offset from another limiter
+	other, _ := newJitteredCounter(t, jitterConfig)
+	_, again, err := other.Allow("client")
+	require.NoError(t, err)
+	assert.True(t, again.ResetAt.Equal(first.ResetAt))
+}
+
+// jitteredKey returns a key whose reset offset under jitterConfig is at least min, so
+// tests can tell the jittered boundary from the plain one
+func jitteredKey(t *testing.T, min time.Duration) (string, time.Duration) {
+	t.Helper()
+	fwc, _ := newJitteredCounter(t, jitterConfig)
+	boundary := jitterEpoch.Add(10 * time.Minute)
+	for i := 0; ; i++ {
+		key := fmt.Sprintf("client-%d", i)
+		allowed, info, err := fwc.Peek(context.Background(), key, 1)
+		require.NoError(t, err)
+		require.True(t, allowed)
+		if offset := info.ResetAt.Sub(boundary); offset >= min {
+			return key, offset
+		}
+	}
+}
+
+func TestFixedWindow_JitterReportedOnly(t *testing.T) {
+	fwc, clock := newJitteredCounter(t, jitterConfig)
+	key, _ := jitteredKey(t, 10*time.Second)
+
+	allowAll(t, fwc, key, 2)
+
+	// The window still really ends on the boundary; only the hint is later
+	clock.Advance(10 * time.Minute)
+	allowed, _, err := fwc.Allow(key)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestFixedWindow_JitterShiftsWindows(t *testing.T) {
+	config := jitterConfig
+	config.JitterWindows = true
+	fwc, clock := newJitteredCounter(t, config)
+
+	// The shifted window that contains the epoch started offset into the previous window
+	key, offset := jitteredKey(t, 10*time.Second)
+	clock.Advance(offset)
+
+	allowAll(t, fwc, key, 2)
+	allowed, info, err := fwc.Allow(key)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	assert.True(t, info.ResetAt.Equal(jitterEpoch.Add(10*time.Minute+offset)))
+
+	clock.Advance(*info.RetryAfter - time.Second)
+	allowed, _, err = fwc.Allow(key)
+	require.NoError(t, err)
+	assert.False(t, allowed, "the unshifted boundary has passed but the key's window has not")
+
+	clock.Advance(time.Second)
+	allowed, _, err = fwc.Allow(key)
+	require.NoError(t, err)
+	assert.True(t, allowed, "the key's window resets at the reported time")
+}
+
+func TestFixedWindow_NoJitterByDefault(t *testing.T) {
+	fwc, _ := newJitteredCounter(t, limiter.Config{Limit: 2, Window: 10 * time.Minute})
+
+	for i := 0; i < 20; i++ {
+		_, info, err := fwc.Allow(fmt.Sprintf("client-%d", i))
+		require.NoError(t, err)
+		assert.True(t, info.ResetAt.Equal(jitterEpoch.Add(10*time.Minute)))
+	}
+	assert.Zero(t, fwc.Config().ResetJitter)
+}
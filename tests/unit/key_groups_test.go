@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_KeyGroups(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	ctx := context.Background()
+
+	_, ok, err := s.KeyGroupCtx(ctx, "key1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.SetKeyGroupCtx(ctx, "key1", "acme"))
+	require.NoError(t, s.SetKeyGroupCtx(ctx, "key1", "globex"))
+	group, ok, err := s.KeyGroupCtx(ctx, "key1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "globex", group, "setting a group moves the identifier")
+
+	removed, err := s.DeleteKeyGroupCtx(ctx, "key1")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = s.DeleteKeyGroupCtx(ctx, "key1")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+// newKeyGroupRouter serves a token bucket of limit 3 with key groups kept in a memory store
+func newKeyGroupRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{Limit: 3, Window: time.Hour}),
+	}
+	router := gin.New()
+	handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "token_bucket",
+		handlers.WithKeyGroups(s)).RegisterRoutes(router)
+	return router
+}
+
+func TestCheck_KeyGroupsShareOneBucket(t *testing.T) {
+	router := newKeyGroupRouter(t)
+
+	for _, identifier := range []string{"key1", "key2"} {
+		w := doJSON(router, http.MethodPut, "/v1/groups/"+identifier, map[string]string{"group": "acme"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	check := func(identifier string, count int) (int, handlers.CheckResponse) {
+		w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": identifier, "count": count})
+		var resp handlers.CheckResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	status, resp := check("key1", 2)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 1, resp.Remaining)
+	assert.Equal(t, "acme", resp.Group)
+
+	status, resp = check("key2", 1)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 0, resp.Remaining, "key2 draws from the pool key1 used")
+
+	status, _ = check("key2", 1)
+	assert.Equal(t, http.StatusTooManyRequests, status)
+
+	// Status on any member reports the group's state
+	w := doJSON(router, http.MethodGet, "/v1/status/key1:api", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.Remaining)
+	assert.Equal(t, "acme", resp.Group)
+
+	// Removal takes effect on the next check
+	w = doJSON(router, http.MethodDelete, "/v1/groups/key2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	status, resp = check("key2", 1)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Group)
+	assert.Equal(t, 2, resp.Remaining)
+}
+
+func TestKeyGroups_AdminEndpoints(t *testing.T) {
+	router := newKeyGroupRouter(t)
+
+	w := doJSON(router, http.MethodGet, "/v1/groups/key1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(router, http.MethodPut, "/v1/groups/key1", map[string]string{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(router, http.MethodPut, "/v1/groups/key1", map[string]string{"group": "acme"})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(router, http.MethodGet, "/v1/groups/key1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"identifier": "key1", "group": "acme"}`, w.Body.String())
+
+	w = doJSON(router, http.MethodDelete, "/v1/groups/key1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(router, http.MethodDelete, "/v1/groups/key1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Without key groups the endpoints are not found
+	plain, _ := newTestRouter(t)
+	w = doJSON(plain, http.MethodGet, "/v1/groups/key1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,191 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/keys"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromIP(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "192.0.2.1"}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		want       string
+	}{
+		{
+			name:       "no proxy",
+			remoteAddr: "203.0.113.7:4242",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "spoofed X-Forwarded-For from an untrusted client is ignored",
+			remoteAddr: "203.0.113.7:4242",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "spoofed X-Real-IP from an untrusted client is ignored",
+			remoteAddr: "203.0.113.7:4242",
+			headers:    map[string][]string{"X-Real-IP": {"198.51.100.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.1.2.3:4242",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "trusted proxy given as a single IP",
+			remoteAddr: "192.0.2.1:4242",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "entries prepended by the client are ignored",
+			remoteAddr: "10.1.2.3:4242",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.1, 10.9.9.9"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "repeated X-Forwarded-For headers are one list",
+			remoteAddr: "10.1.2.3:4242",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4", "198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "malformed hop stops the walk",
+			remoteAddr: "10.1.2.3:4242",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1, garbage"}},
+			want:       "10.1.2.3",
+		},
+		{
+			name:       "X-Real-IP from a trusted proxy",
+			remoteAddr: "10.1.2.3:4242",
+			headers:    map[string][]string{"X-Real-IP": {"198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "IPv6 client",
+			remoteAddr: "[2001:db8::1]:4242",
+			want:       "2001:db8::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, values := range tt.headers {
+				for _, v := range values {
+					req.Header.Add(name, v)
+				}
+			}
+			assert.Equal(t, tt.want, keys.FromIP(req, trusted))
+		})
+	}
+}
+
+func TestFromCIDR(t *testing.T) {
+	assert.Equal(t, "203.0.113.0/24", keys.FromCIDR("203.0.113.7", 24))
+	assert.Equal(t, keys.FromCIDR("203.0.113.7", 24), keys.FromCIDR("203.0.113.200", 24))
+	assert.Equal(t, "2001:db8:1:2::/64", keys.FromCIDR("2001:db8:1:2:3:4:5:6", 64))
+	assert.Equal(t, "203.0.113.0/24", keys.FromCIDR("::ffff:203.0.113.7", 24), "IPv4-mapped addresses collapse as IPv4")
+	assert.Equal(t, "203.0.113.7/32", keys.FromCIDR("203.0.113.7", 64), "mask is clamped to the address length")
+	assert.Equal(t, "not-an-ip", keys.FromCIDR("not-an-ip", 24))
+}
+
+func TestEscapedScheme(t *testing.T) {
+	scheme := keys.Escaped{}
+
+	key, err := scheme.Join("alice", "api")
+	require.NoError(t, err)
+	assert.Equal(t, "alice:api", key, "plain identifiers keep their legacy keys")
+
+	key, err = scheme.Join("alice:admin", "reset")
+	require.NoError(t, err)
+	assert.Equal(t, "alice%3Aadmin:reset", key)
+	_, err = scheme.Join("alice", "admin:reset")
+	assert.ErrorIs(t, err, keys.ErrInvalidPart, "a resource holding the separator could collide")
+
+	key, err = scheme.Join("2001:db8::1%eth0", "api")
+	require.NoError(t, err)
+	identifier, resource, ok := scheme.Split(key)
+	require.True(t, ok)
+	assert.Equal(t, "2001:db8::1%eth0", identifier)
+	assert.Equal(t, "api", resource)
+
+	escapedFirst, err := scheme.Join("a%3Ab", "c")
+	require.NoError(t, err)
+	colonFirst, err := scheme.Join("a:b", "c")
+	require.NoError(t, err)
+	assert.NotEqual(t, escapedFirst, colonFirst, "a literal escape must not alias the separator")
+
+	_, err = scheme.Join("alice\n", "api")
+	assert.ErrorIs(t, err, keys.ErrInvalidPart)
+	_, err = scheme.Join("alice", "api\x00")
+	assert.ErrorIs(t, err, keys.ErrInvalidPart)
+
+	_, err = scheme.Join(strings.Repeat("a", limiter.MaxKeyLength), "api")
+	assert.ErrorIs(t, err, limiter.ErrKeyTooLong)
+}
+
+func TestLegacyScheme(t *testing.T) {
+	scheme := keys.Legacy{}
+
+	first, err := scheme.Join("alice:admin", "reset")
+	require.NoError(t, err)
+	second, err := scheme.Join("alice", "admin:reset")
+	require.NoError(t, err)
+	assert.Equal(t, "alice:admin:reset", first)
+	assert.Equal(t, first, second, "legacy keys are joined unescaped")
+
+	_, err = keys.ParseScheme("legacy")
+	assert.NoError(t, err)
+	_, err = keys.ParseScheme("base64")
+	assert.Error(t, err)
+}
+
+func TestCheck_IdentifiersCannotShareAnotherResourceKey(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/v1/check", handlers.CheckRequest{
+		Resource: "reset", Identifier: "alice:admin", Algorithm: "token_bucket", Count: intPtr(100),
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(router, http.MethodPost, "/v1/check", handlers.CheckRequest{
+		Resource: "admin:reset", Identifier: "alice", Algorithm: "token_bucket",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = doJSON(router, http.MethodPost, "/v1/check", handlers.CheckRequest{
+		Resource: "reset", Identifier: "alice%3Aadmin", Algorithm: "token_bucket",
+	})
+	assert.Equal(t, http.StatusOK, w.Code, "an identifier spelling the escape gets its own key")
+
+	w = doJSON(router, http.MethodPost, "/v1/check", handlers.CheckRequest{
+		Resource: "reset", Identifier: "alice:admin", Algorithm: "token_bucket",
+	})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	legacy, _ := newTestRouter(t, handlers.WithKeyScheme(keys.Legacy{}))
+	w = doJSON(legacy, http.MethodPost, "/v1/check", handlers.CheckRequest{
+		Resource: "reset", Identifier: "alice:admin", Algorithm: "token_bucket", Count: intPtr(100),
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(legacy, http.MethodPost, "/v1/check", handlers.CheckRequest{
+		Resource: "admin:reset", Identifier: "alice", Algorithm: "token_bucket",
+	})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "legacy keys collide as before")
+}
@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLeased returns a leased token bucket of capacity limit, refilling once an hour, on s
+func newLeased(t *testing.T, s limiter.Store, limit, leaseSize int, opts ...algorithms.Option) *algorithms.LeasedLimiter {
+	t.Helper()
+	clock := limitertest.NewFakeClock(clockEpoch)
+	bucket := algorithms.NewTokenBucket(s, limiter.Config{Limit: limit, Window: time.Hour}, algorithms.WithClock(clock))
+	leased, err := algorithms.NewLeasedLimiter(bucket, s, leaseSize, append(opts, algorithms.WithClock(clock))...)
+	require.NoError(t, err)
+	return leased
+}
+
+func TestLeasedLimiter_ServesFromLease(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	ll := newLeased(t, s, 100, 10, algorithms.WithLeaseMetrics(m))
+
+	for i := 0; i < 10; i++ {
+		allowed, _, err := ll.Allow("user")
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+	tokens, _, _, err := s.GetTokens("user")
+	require.NoError(t, err)
+	assert.InDelta(t, 90, tokens, 0.01, "one lease of 10 served all ten checks")
+	assert.Equal(t, float64(9), testutil.ToFloat64(m.Leases.WithLabelValues("hit")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.Leases.WithLabelValues("miss")))
+
+	// The eleventh check leases again
+	_, info, err := ll.Allow("user")
+	require.NoError(t, err)
+	assert.Equal(t, 89, info.Remaining)
+	tokens, _, _, err = s.GetTokens("user")
+	require.NoError(t, err)
+	assert.InDelta(t, 80, tokens, 0.01)
+}
+
+func TestLeasedLimiter_LeasesWhatIsLeft(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	ll := newLeased(t, s, 15, 10)
+
+	admitted := 0
+	for i := 0; i < 20; i++ {
+		allowed, info, err := ll.Allow("user")
+		require.NoError(t, err)
+		if !allowed {
+			assert.NotNil(t, info.RetryAfter)
+			assert.Equal(t, limiter.ReasonInsufficientTokens, info.Reason)
+			break
+		}
+		admitted++
+	}
+	assert.Equal(t, 15, admitted, "the last 5 tokens are leased one check at a time")
+
+	allowed, info, err := ll.AllowN("user", 20)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, limiter.ReasonBurstExceeded, info.Reason)
+}
+
+func TestLeasedLimiter_InstancesNeverAdmitMoreThanTheBucket(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	instances := []*algorithms.LeasedLimiter{newLeased(t, s, 100, 7), newLeased(t, s, 100, 7)}
+
+	var admitted atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(ll *algorithms.LeasedLimiter) {
+			defer wg.Done()
+			for {
+				allowed, _, err := ll.Allow("user")
+				if err != nil || !allowed {
+					return
+				}
+				admitted.Add(1)
+			}
+		}(instances[g%2])
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 100, admitted.Load(), "leases drain the bucket exactly")
+}
+
+func TestLeasedLimiter_RequiresTokenBucket(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	config := limiter.Config{Limit: 10, Window: time.Minute}
+
+	_, err := algorithms.NewLeasedLimiter(algorithms.NewTokenBucket(s, config), s, 0)
+	assert.Error(t, err)
+	_, err = algorithms.NewLeasedLimiter(algorithms.NewFixedWindowCounter(s, config), s, 5)
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata limiter.Metadata
+		wantErr  string
+	}{
+		{name: "nil", metadata: nil},
+		{name: "within limits", metadata: limiter.Metadata{"trace_id": "abc", "t": "acme"}},
+		{name: "too many keys", metadata: limiter.Metadata{"a": "1", "b": "2", "c": "3"}, wantErr: "3 keys"},
+		{name: "too many bytes", metadata: limiter.Metadata{"trace_id": strings.Repeat("x", 20)}, wantErr: "28 bytes"},
+		{name: "empty key", metadata: limiter.Metadata{"": "x"}, wantErr: "must not be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.metadata.Validate(2, 20)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestMetadata_RedactCopies(t *testing.T) {
+	m := limiter.Metadata{"trace_id": "abc", "Email": "a@example.com", "api_key": "secret"}
+
+	redacted := m.Redact([]string{"email", "API_KEY"})
+	assert.Equal(t, limiter.Metadata{"trace_id": "abc", "Email": limiter.Redacted, "api_key": limiter.Redacted}, redacted)
+	assert.Equal(t, "secret", m["api_key"], "the original is untouched")
+
+	assert.Nil(t, limiter.Metadata(nil).Redact([]string{"email"}))
+}
+
+func TestCheck_MetadataReachesHooksRedacted(t *testing.T) {
+	var decisions []handlers.Decision
+	hook := func(ctx context.Context, d handlers.Decision) { decisions = append(decisions, d) }
+	router, _ := newTestRouter(t,
+		handlers.WithDecisionHook(hook),
+		handlers.WithMetadata(handlers.MetadataConfig{Redact: []string{"email"}}),
+	)
+
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+		"resource":   "api.orders",
+		"identifier": "alice",
+		"algorithm":  "fixed_window",
+		"metadata":   map[string]string{"trace_id": "trace-1", "email": "alice@example.com"},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "trace-1", "metadata is not echoed in the response")
+
+	require.Len(t, decisions, 1)
+	d := decisions[0]
+	assert.Equal(t, "alice:api.orders", d.Key, "metadata never changes the key")
+	assert.Equal(t, "fixed_window", d.Algorithm)
+	assert.True(t, d.Allowed)
+	assert.Equal(t, 99, d.Info.Remaining)
+	assert.Equal(t, limiter.Metadata{"trace_id": "trace-1", "email": limiter.Redacted}, d.Metadata)
+}
+
+func TestCheck_MetadataDoesNotAffectDecision(t *testing.T) {
+	var remaining []int
+	hook := func(ctx context.Context, d handlers.Decision) { remaining = append(remaining, d.Info.Remaining) }
+	router, _ := newTestRouter(t, handlers.WithDecisionHook(hook))
+
+	for i, metadata := range []map[string]string{nil, {"tenant": "acme"}, {"tenant": "globex", "trace_id": "t"}} {
+		w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+			"resource":   "api.orders",
+			"identifier": "bob",
+			"algorithm":  "fixed_window",
+			"metadata":   metadata,
+		})
+		require.Equal(t, http.StatusOK, w.Code, "request %d", i)
+	}
+
+	// All three requests drew from the same key
+	assert.Equal(t, []int{99, 98, 97}, remaining)
+}
+
+func TestCheck_MetadataOverLimitsRejected(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithMetadata(handlers.MetadataConfig{MaxKeys: 1, MaxBytes: 32}))
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+	}{
+		{name: "too many keys", metadata: map[string]string{"a": "1", "b": "2"}},
+		{name: "too large", metadata: map[string]string{"trace_id": strings.Repeat("x", 64)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+				"resource":   "api.orders",
+				"identifier": "carol",
+				"metadata":   tt.metadata,
+			})
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, handlers.CodeInvalidRequest, body["code"])
+			assert.Contains(t, body["message"], "metadata")
+		})
+	}
+}
+
+func TestCheck_DedupIgnoresMetadata(t *testing.T) {
+	dedupStore := store.NewMemoryStore()
+	defer dedupStore.Close()
+
+	dedup := algorithms.NewFixedWindowCounter(dedupStore, limiter.Config{Limit: 1, Window: time.Minute})
+	router, _ := newTestRouter(t, handlers.WithDedup(dedup, nil))
+
+	payload := func(traceID string) map[string]interface{} {
+		return map[string]interface{}{
+			"resource":   "api.export",
+			"identifier": "dave",
+			"metadata":   map[string]string{"trace_id": traceID},
+		}
+	}
+
+	require.Equal(t, http.StatusOK, doJSON(router, http.MethodPost, "/v1/check", payload("t1")).Code)
+	assert.Equal(t, http.StatusConflict, doJSON(router, http.MethodPost, "/v1/check", payload("t2")).Code,
+		"a different trace ID does not make a repeated payload unique")
+}
@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func newMiddlewareLimiter(t *testing.T, limit int) limiter.RateLimiter {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	return algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: limit, Window: time.Minute})
+}
+
+func serve(h http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_AllowsThenDenies(t *testing.T) {
+	h := middleware.Middleware(newMiddlewareLimiter(t, 2), middleware.KeyByIP)(okHandler)
+
+	for i := 0; i < 2; i++ {
+		w := serve(h, "10.0.0.1:5000")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+		assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset"))
+		assert.Empty(t, w.Header().Get("Retry-After"))
+	}
+	assert.Equal(t, "0", serve(h, "10.0.0.1:5001").Header().Get("X-RateLimit-Remaining"), "keyed by IP, not port")
+
+	w := serve(h, "10.0.0.1:5000")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, false, body["allowed"])
+	assert.EqualValues(t, 2, body["limit"])
+	assert.Contains(t, body, "retry_after")
+
+	// Other clients are limited separately
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.2:5000").Code)
+}
+
+func TestMiddleware_DraftHeaderStyle(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	rl := algorithms.NewTokenBucket(s, limiter.Config{Limit: 1, Window: time.Minute})
+
+	h := middleware.Middleware(rl, middleware.KeyByIP, middleware.WithHeaderStyle(middleware.HeaderStyleDraft))(okHandler)
+	w := serve(h, "10.0.0.1:5000")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"), "seconds until the bucket is full again")
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+	assert.Empty(t, w.Header().Get("X-RateLimit-Reset"))
+}
+
+func TestMiddleware_KeyByHeader(t *testing.T) {
+	h := middleware.Middleware(newMiddlewareLimiter(t, 1), middleware.KeyByHeader("X-API-Key"))(okHandler)
+
+	do := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do("key-a"))
+	assert.Equal(t, http.StatusTooManyRequests, do("key-a"))
+	assert.Equal(t, http.StatusOK, do("key-b"))
+}
+
+func TestMiddleware_CustomDenialHandler(t *testing.T) {
+	var denied *limiter.LimitInfo
+	onDenied := func(w http.ResponseWriter, r *http.Request, info *limiter.LimitInfo) {
+		denied = info
+		http.Error(w, "slow down", http.StatusServiceUnavailable)
+	}
+	h := middleware.Middleware(newMiddlewareLimiter(t, 1), middleware.KeyByIP, middleware.WithDenialHandler(onDenied))(okHandler)
+
+	require.Equal(t, http.StatusOK, serve(h, "10.0.0.1:1").Code)
+
+	w := serve(h, "10.0.0.1:1")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "slow down\n", w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Retry-After"), "headers are set before the denial handler runs")
+	require.NotNil(t, denied)
+	assert.Equal(t, 1, denied.Limit)
+}
+
+// failingLimiter returns an error from every check
+type failingLimiter struct{ limiter.RateLimiter }
+
+func (failingLimiter) AllowNCtx(ctx context.Context, key string, n int) (bool, *limiter.LimitInfo, error) {
+	return false, nil, errors.New("store unavailable")
+}
+
+func TestMiddleware_LimiterErrorIsServerError(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	h := middleware.Middleware(failingLimiter{}, middleware.KeyByIP)(next)
+
+	w := serve(h, "10.0.0.1:1")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.False(t, called)
+}
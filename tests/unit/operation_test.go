@@ -0,0 +1,161 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timeoutError is a network timeout, as returned when a response is lost in transit
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// dropFirstResponse wraps call so its first attempt executes but reports a timeout,
+// like a command that ran server-side after the client gave up on it
+func dropFirstResponse(attempts *int, call func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*attempts++
+		if err := call(ctx); err != nil {
+			return err
+		}
+		if *attempts == 1 {
+			return timeoutError{}
+		}
+		return nil
+	}
+}
+
+func TestRetryOperation_IncrementAppliedOnce(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	window := time.Unix(1000, 0)
+
+	var attempts int
+	var count int64
+	err := store.RetryOperation(context.Background(), 2, dropFirstResponse(&attempts, func(ctx context.Context) (err error) {
+		count, err = s.IncrementCtx(ctx, "alice", window)
+		return err
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts, "the dropped response was retried")
+	assert.Equal(t, int64(1), count, "the retry replayed the first result")
+
+	windows, err := s.GetWindows("alice", window, window)
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.Equal(t, int64(1), windows[0].Count, "the counter was incremented once")
+}
+
+func TestRetryOperation_ConsumeAppliedOnce(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	now := time.Unix(1000, 0)
+
+	var attempts int
+	var tokens float64
+	err := store.RetryOperation(context.Background(), 2, dropFirstResponse(&attempts, func(ctx context.Context) (err error) {
+		_, tokens, _, err = s.ConsumeTokensCtx(ctx, "bob", 3, 10, 1, 10, now)
+		return err
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 7.0, tokens)
+
+	stored, _, _, err := s.GetTokens("bob")
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, stored, "three tokens were taken, not six")
+}
+
+func TestRetryOperation_WithoutOperationIDDoubleCounts(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	window := time.Unix(1000, 0)
+
+	// The failure mode operation IDs exist for: a blind retry applies twice
+	for i := 0; i < 2; i++ {
+		_, err := s.IncrementCtx(context.Background(), "carol", window)
+		require.NoError(t, err)
+	}
+
+	windows, err := s.GetWindows("carol", window, window)
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.Equal(t, int64(2), windows[0].Count)
+}
+
+func TestRetryOperation_ReusesCallerID(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 5, Window: time.Minute, Burst: 5})
+
+	// A client retrying a whole request under the same ID is charged once
+	ctx := store.WithOperationID(context.Background(), "req-1")
+	for i := 0; i < 3; i++ {
+		allowed, info, err := tb.AllowNCtx(ctx, "dave", 2)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 3, info.Remaining, "attempt %d", i)
+	}
+
+	var seen []string
+	err := store.RetryOperation(ctx, 1, func(ctx context.Context) error {
+		seen = append(seen, store.OperationID(ctx))
+		return timeoutError{}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"req-1", "req-1"}, seen)
+}
+
+func TestRetryOperation_DistinguishesStepsOfOneOperation(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	now := time.Unix(1000, 0)
+
+	// Consuming then refunding under one ID are two steps, and both apply
+	ctx := store.WithOperationID(context.Background(), "req-2")
+	_, _, _, err := s.ConsumeTokensCtx(ctx, "erin", 4, 10, 1, 10, now)
+	require.NoError(t, err)
+	_, tokens, _, err := s.ConsumeTokensCtx(ctx, "erin", -4, 10, 1, 10, now)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, tokens)
+}
+
+func TestRetryOperation_StopsOnOtherErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "not a timeout", err: errors.New("WRONGTYPE")},
+		{name: "caller deadline", err: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			err := store.RetryOperation(context.Background(), 3, func(ctx context.Context) error {
+				attempts++
+				return tt.err
+			})
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, 1, attempts)
+		})
+	}
+
+	var attempts int
+	err := store.RetryOperation(context.Background(), 3, func(ctx context.Context) error {
+		attempts++
+		return timeoutError{}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 4, attempts, "timeouts are retried up to the limit")
+}
@@ -0,0 +1,251 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOverrideLimiter(t *testing.T, overrides *algorithms.Overrides) *algorithms.OverrideLimiter {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 5, Window: time.Minute})
+	ol, err := algorithms.NewOverrideLimiter(base, overrides, func(c limiter.Config) (limiter.RateLimiter, error) {
+		return limiter.New(s, c)
+	})
+	require.NoError(t, err)
+	return ol
+}
+
+// admitted counts how many of n single requests for key are allowed
+func admitted(t *testing.T, rl limiter.RateLimiter, key string, n int) int {
+	t.Helper()
+	count := 0
+	for i := 0; i < n; i++ {
+		allowed, _, err := rl.Allow(key)
+		require.NoError(t, err)
+		if allowed {
+			count++
+		}
+	}
+	return count
+}
+
+func TestOverrideLimiter_OverriddenKeyHasItsOwnLimit(t *testing.T) {
+	overrides := algorithms.NewOverrides()
+	ol := newTestOverrideLimiter(t, overrides)
+
+	require.NoError(t, overrides.SetKeyLimit("abuser", limiter.Config{Limit: 2, Window: time.Minute}))
+	require.NoError(t, overrides.SetKeyLimit("partner", limiter.Config{Limit: 20, Window: time.Minute}))
+
+	assert.Equal(t, 5, admitted(t, ol, "normal", 30))
+	assert.Equal(t, 2, admitted(t, ol, "abuser", 30))
+	assert.Equal(t, 20, admitted(t, ol, "partner", 30))
+
+	info, err := ol.Status("partner")
+	require.NoError(t, err)
+	assert.Equal(t, 20, info.Limit)
+	assert.Equal(t, 5, ol.Config().Limit, "the policy for other keys is unchanged")
+}
+
+func TestOverrideLimiter_ClearReturnsKeyToPolicy(t *testing.T) {
+	overrides := algorithms.NewOverrides()
+	ol := newTestOverrideLimiter(t, overrides)
+
+	require.NoError(t, overrides.SetKeyLimit("partner", limiter.Config{Limit: 8, Window: time.Minute}))
+	assert.Equal(t, 8, admitted(t, ol, "partner", 10))
+
+	// Usage carries over, so the cleared key is already past the policy's 5
+	cleared, err := overrides.ClearKeyLimit("partner")
+	require.NoError(t, err)
+	assert.True(t, cleared)
+	assert.Zero(t, admitted(t, ol, "partner", 1))
+	cleared, err = overrides.ClearKeyLimit("partner")
+	require.NoError(t, err)
+	assert.False(t, cleared)
+
+	require.NoError(t, ol.Reset("partner"))
+	assert.Equal(t, 5, admitted(t, ol, "partner", 10))
+}
+
+func TestOverrides_Validate(t *testing.T) {
+	overrides := algorithms.NewOverrides()
+
+	assert.Error(t, overrides.SetKeyLimit("", limiter.Config{Limit: 1, Window: time.Minute}))
+	assert.Error(t, overrides.SetKeyLimit("user", limiter.Config{Window: time.Minute}))
+	assert.Error(t, overrides.SetKeyLimit("user", limiter.Config{Limit: 1}))
+	assert.Error(t, overrides.SetKeyLimit("user", limiter.Config{Limit: 1, Window: time.Minute, Burst: -1}))
+	assert.Error(t, overrides.SetKeyLimitCtx(context.Background(), "user", limiter.Config{Limit: 1, Window: time.Minute}, -time.Second))
+
+	_, ok, err := overrides.KeyLimit("user")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestOverrideLimiter_SetLimitSharedThroughStore(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	build := func(c limiter.Config) (limiter.RateLimiter, error) { return limiter.New(s, c) }
+
+	// Two instances, each with its own override set over the shared store
+	newInstance := func() *algorithms.OverrideLimiter {
+		base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 5, Window: time.Minute})
+		ol, err := algorithms.NewOverrideLimiter(base, algorithms.NewStoreOverrides(s), build)
+		require.NoError(t, err)
+		return ol
+	}
+	a, b := newInstance(), newInstance()
+	var _ limiter.LimitSetter = a
+
+	require.NoError(t, a.SetLimit("partner", limiter.Config{Limit: 8, Window: time.Minute}))
+	assert.Equal(t, 8, admitted(t, b, "partner", 10), "b sees the override a set")
+
+	info, err := b.Status("partner")
+	require.NoError(t, err)
+	assert.Equal(t, 8, info.Limit)
+
+	require.NoError(t, b.ClearLimit("partner"))
+	require.NoError(t, b.ClearLimit("partner"), "clearing a key without an override is a no-op")
+	info, err = a.Status("partner")
+	require.NoError(t, err)
+	assert.Equal(t, 5, info.Limit)
+}
+
+func TestOverrideLimiter_SetLimitExpires(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+
+	clock := limitertest.NewFakeClock(clockEpoch)
+	overrides := algorithms.NewStoreOverrides(s, algorithms.WithClock(clock))
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 5, Window: time.Minute}, algorithms.WithClock(clock))
+	ol, err := algorithms.NewOverrideLimiter(base, overrides, func(c limiter.Config) (limiter.RateLimiter, error) {
+		return algorithms.NewFixedWindowCounter(s, c, algorithms.WithClock(clock)), nil
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, ol.SetLimitCtx(ctx, "partner", limiter.Config{Limit: 20, Window: time.Minute}, 30*time.Second))
+	override, ok, err := overrides.KeyLimitCtx(ctx, "partner")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, override.ExpiresAt.Equal(clockEpoch.Add(30*time.Second)))
+
+	info, err := ol.Status("partner")
+	require.NoError(t, err)
+	assert.Equal(t, 20, info.Limit)
+
+	clock.Advance(30 * time.Second)
+	info, err = ol.Status("partner")
+	require.NoError(t, err)
+	assert.Equal(t, 5, info.Limit, "the lapsed override no longer applies")
+
+	cleared, err := overrides.ClearKeyLimitCtx(ctx, "partner")
+	require.NoError(t, err)
+	assert.False(t, cleared)
+}
+
+func TestOverride_HTTP(t *testing.T) {
+	overrides := algorithms.NewOverrides()
+	limiters := map[string]limiter.RateLimiter{"fixed_window": newTestOverrideLimiter(t, overrides)}
+	h := handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "fixed_window", handlers.WithOverrides(overrides))
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	check := func(identifier string) int {
+		return doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+			"resource":   "api",
+			"identifier": identifier,
+		}).Code
+	}
+
+	w := doJSON(router, http.MethodPost, "/v1/override/abuser:api", map[string]interface{}{"limit": 1, "window": "1m"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Equal(t, http.StatusOK, check("abuser"))
+	assert.Equal(t, http.StatusTooManyRequests, check("abuser"))
+	assert.Equal(t, http.StatusOK, check("normal"))
+	assert.Equal(t, http.StatusOK, check("normal"))
+
+	w = doJSON(router, http.MethodPost, "/v1/override/abuser:api", map[string]interface{}{"limit": 1, "window": "soon"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(router, http.MethodDelete, "/v1/override/abuser:api", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(router, http.MethodDelete, "/v1/override/abuser:api", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestOverride_HTTPLimits(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+
+	overrides := algorithms.NewStoreOverrides(s)
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 5, Window: time.Minute})
+	ol, err := algorithms.NewOverrideLimiter(base, overrides, func(c limiter.Config) (limiter.RateLimiter, error) {
+		return limiter.New(s, c)
+	})
+	require.NoError(t, err)
+	limiters := map[string]limiter.RateLimiter{"fixed_window": ol}
+	h := handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "fixed_window", handlers.WithOverrides(overrides))
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	w := doJSON(router, http.MethodPut, "/v1/limits/partner:api", map[string]interface{}{"limit": 50, "window": "1m", "ttl": "1h"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var set handlers.OverrideStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	assert.Equal(t, 50, set.Limit)
+	assert.NotEmpty(t, set.ExpiresAt)
+
+	// Status reports the effective limit and where it comes from
+	w = doJSON(router, http.MethodGet, "/v1/status/partner:api", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var status handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, 50, status.Limit)
+	require.NotNil(t, status.Override)
+	assert.Equal(t, "1m0s", status.Override.Window)
+
+	w = doJSON(router, http.MethodGet, "/v1/status/normal:api", nil)
+	var normal handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &normal))
+	assert.Equal(t, 5, normal.Limit)
+	assert.Nil(t, normal.Override)
+
+	for _, body := range []map[string]interface{}{
+		{"limit": 50, "window": "1m", "ttl": "-1h"},
+		{"limit": 50, "window": "0s"},
+		{"limit": 50, "window": "1m", "burst": -1},
+	} {
+		w = doJSON(router, http.MethodPut, "/v1/limits/partner:api", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	w = doJSON(router, http.MethodDelete, "/v1/limits/partner:api", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(router, http.MethodDelete, "/v1/limits/partner:api", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestOverride_HTTPDisabled(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/v1/override/user:api", map[string]interface{}{"limit": 1, "window": "1m"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,221 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ limiter.Peeker       = (*algorithms.PenaltyLimiter)(nil)
+	_ limiter.Reconfigurer = (*algorithms.PenaltyLimiter)(nil)
+	_ limiter.Penalizer    = (*store.MemoryStore)(nil)
+	_ limiter.Penalizer    = (*store.RedisStore)(nil)
+)
+
+// Starts on a minute boundary, so fixed windows deny with a whole minute to wait
+var penaltyEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var testPenaltyConfig = algorithms.PenaltyConfig{
+	Threshold: 2,
+	Window:    time.Minute,
+	MaxLevel:  2,
+	Decay:     10 * time.Minute,
+}
+
+func newTestPenalties(t *testing.T) (*algorithms.PenaltyLimiter, *algorithms.Penalties, *simulation.ManualClock) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	clock := simulation.NewManualClock(penaltyEpoch)
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 2, Window: time.Minute}, algorithms.WithClock(clock))
+
+	penalties, err := algorithms.NewPenalties(s, testPenaltyConfig, algorithms.WithClock(clock))
+	require.NoError(t, err)
+	return algorithms.NewPenaltyLimiter(base, penalties), penalties, clock
+}
+
+// denyRetryAfter checks that key is denied and returns the retry time it was given
+func denyRetryAfter(t *testing.T, rl limiter.RateLimiter, key string) time.Duration {
+	t.Helper()
+	allowed, info, err := rl.Allow(key)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.NotNil(t, info.RetryAfter)
+	return *info.RetryAfter
+}
+
+func TestPenaltyLimiter_EscalatesRepeatOffenders(t *testing.T) {
+	pl, penalties, clock := newTestPenalties(t)
+	ctx := context.Background()
+
+	allowAll(t, pl, "user", 2)
+	assert.Equal(t, time.Minute, denyRetryAfter(t, pl, "user"), "a first offense waits as long as the limiter says")
+
+	// Retrying while penalized counts, and the third offense within the window raises the level
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, 50*time.Second, denyRetryAfter(t, pl, "user"))
+	assert.Equal(t, 50*time.Second, denyRetryAfter(t, pl, "user"), "raising the level does not extend the current penalty")
+
+	status, err := penalties.Status(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Level)
+	assert.Equal(t, 2, status.Multiplier)
+	assert.Equal(t, penaltyEpoch.Add(time.Minute), status.Until)
+
+	// The next denial by the limiter waits twice as long
+	clock.Set(penaltyEpoch.Add(time.Minute))
+	allowAll(t, pl, "user", 2)
+	assert.Equal(t, 2*time.Minute, denyRetryAfter(t, pl, "user"))
+
+	// While penalized the key is denied even though its window has reset
+	clock.Advance(time.Minute)
+	assert.Equal(t, time.Minute, denyRetryAfter(t, pl, "user"))
+}
+
+func TestPenaltyLimiter_LevelIsCapped(t *testing.T) {
+	pl, penalties, _ := newTestPenalties(t)
+
+	// Every third offense raises the level: four raises, capped at two
+	allowAll(t, pl, "user", 2)
+	for i := 0; i < 12; i++ {
+		denyRetryAfter(t, pl, "user")
+	}
+
+	status, err := penalties.Status(context.Background(), "user")
+	require.NoError(t, err)
+	assert.Equal(t, testPenaltyConfig.MaxLevel, status.Level)
+	assert.Equal(t, 4, status.Multiplier)
+}
+
+func TestPenaltyLimiter_DecaysAfterQuietPeriod(t *testing.T) {
+	pl, penalties, clock := newTestPenalties(t)
+	ctx := context.Background()
+
+	allowAll(t, pl, "user", 2)
+	for i := 0; i < 3; i++ {
+		denyRetryAfter(t, pl, "user")
+	}
+	status, err := penalties.Status(ctx, "user")
+	require.NoError(t, err)
+	require.Equal(t, 1, status.Level)
+
+	// Quiet until just before the decay ends: the level holds
+	clock.Set(penaltyEpoch.Add(time.Minute + testPenaltyConfig.Decay - time.Second))
+	status, err = penalties.Status(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Level)
+	assert.True(t, status.Until.IsZero(), "the penalty itself is over")
+
+	clock.Advance(time.Second)
+	status, err = penalties.Status(ctx, "user")
+	require.NoError(t, err)
+	assert.Zero(t, status.Level)
+	assert.Equal(t, 1, status.Multiplier)
+
+	allowAll(t, pl, "user", 2)
+	assert.Equal(t, time.Minute, denyRetryAfter(t, pl, "user"), "the next offense starts over")
+}
+
+func TestPenaltyLimiter_StatusAndPeekAreNotOffenses(t *testing.T) {
+	pl, penalties, _ := newTestPenalties(t)
+	ctx := context.Background()
+
+	allowAll(t, pl, "user", 2)
+	for i := 0; i < 5; i++ {
+		_, _, err := pl.AllowN("user", 0)
+		require.NoError(t, err)
+		allowed, _, err := pl.Peek(ctx, "user", 1)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	}
+
+	status, err := penalties.Status(ctx, "user")
+	require.NoError(t, err)
+	assert.Zero(t, status.Level)
+	assert.True(t, status.Until.IsZero())
+}
+
+func TestPenaltyLimiter_ResetClearsPenalty(t *testing.T) {
+	pl, penalties, _ := newTestPenalties(t)
+	ctx := context.Background()
+
+	allowAll(t, pl, "user", 2)
+	for i := 0; i < 3; i++ {
+		denyRetryAfter(t, pl, "user")
+	}
+
+	require.NoError(t, pl.Reset("user"))
+	status, err := penalties.Status(ctx, "user")
+	require.NoError(t, err)
+	assert.Zero(t, status.Level)
+
+	allowed, _, err := pl.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestNewPenalties_ValidatesConfig(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	for _, config := range []algorithms.PenaltyConfig{
+		{Threshold: -1, Window: time.Minute, MaxLevel: 2, Decay: time.Minute},
+		{Threshold: 2, MaxLevel: 2, Decay: time.Minute},
+		{Threshold: 2, Window: time.Minute, Decay: time.Minute},
+		{Threshold: 2, Window: time.Minute, MaxLevel: 11, Decay: time.Minute},
+		{Threshold: 2, Window: time.Minute, MaxLevel: 2},
+	} {
+		_, err := algorithms.NewPenalties(s, config)
+		assert.Error(t, err, "%+v", config)
+	}
+}
+
+func TestCheck_PenalizedRetryAfterAndStatus(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	clock := simulation.NewManualClock(penaltyEpoch)
+
+	// With no threshold every denial raises the level
+	penalties, err := algorithms.NewPenalties(s, algorithms.PenaltyConfig{
+		Window:   time.Minute,
+		MaxLevel: 3,
+		Decay:    time.Minute,
+	}, algorithms.WithClock(clock))
+	require.NoError(t, err)
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 1, Window: time.Minute}, algorithms.WithClock(clock))
+	limiters := map[string]limiter.RateLimiter{"fixed_window": algorithms.NewPenaltyLimiter(base, penalties)}
+	h := handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "fixed_window",
+		handlers.WithPenalties(penalties))
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	payload := map[string]interface{}{"resource": "api.export", "identifier": "alice"}
+	require.Equal(t, http.StatusOK, doJSON(router, http.MethodPost, "/v1/check", payload).Code)
+	w := doJSON(router, http.MethodPost, "/v1/check", payload)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "120", w.Header().Get("Retry-After"), "twice the minute the window has left")
+
+	w = doJSON(router, http.MethodGet, "/v1/status/alice:api.export", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Penalty)
+	assert.Equal(t, 1, resp.Penalty.Level)
+	assert.Equal(t, 2, resp.Penalty.Multiplier)
+	assert.Equal(t, penaltyEpoch.Add(2*time.Minute).Format(time.RFC3339), resp.Penalty.Until)
+}
@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getPolicy(t *testing.T, router http.Handler, query string) handlers.PolicyResponse {
+	t.Helper()
+
+	w := doJSON(router, http.MethodGet, "/v1/policies?"+query, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var policy handlers.PolicyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &policy))
+	return policy
+}
+
+func TestPolicies_MatchCheckResolution(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	tests := []struct {
+		name      string
+		algorithm string
+		resolved  string
+	}{
+		{name: "default", algorithm: "", resolved: "token_bucket"},
+		{name: "token bucket", algorithm: "token_bucket", resolved: "token_bucket"},
+		{name: "fixed window", algorithm: "fixed_window", resolved: "fixed_window"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := getPolicy(t, router, "resource=api.docs&algorithm="+tt.algorithm)
+			assert.Equal(t, "api.docs", policy.Resource)
+			assert.Equal(t, tt.resolved, policy.Algorithm)
+			assert.Equal(t, time.Minute.String(), policy.Window)
+
+			w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+				"resource":   "api.docs",
+				"identifier": "parity-" + tt.name,
+				"algorithm":  tt.algorithm,
+			})
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var check handlers.CheckResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &check))
+
+			// Buckets report their burst as the limit on checks; windows report the limit
+			enforced := policy.Limit
+			if policy.Burst > 0 {
+				enforced = policy.Burst
+			}
+			assert.Equal(t, check.Limit, enforced)
+		})
+	}
+}
+
+func TestPolicies_IncludesParentAndRollout(t *testing.T) {
+	rollout, err := algorithms.NewRollout(25)
+	require.NoError(t, err)
+	parents := map[string]limiter.RateLimiter{
+		"fixed_window": algorithms.NewFixedWindowCounter(nil, limiter.Config{Limit: 1000, Window: time.Minute}),
+	}
+	router, _ := newTestRouter(t, handlers.WithRollout(rollout), handlers.WithParentLimiters(parents))
+
+	policy := getPolicy(t, router, "resource=api.docs&algorithm=fixed_window")
+	require.NotNil(t, policy.Parent)
+	assert.Equal(t, 1000, policy.Parent.Limit)
+	require.NotNil(t, policy.RolloutPercent)
+	assert.Equal(t, 25.0, *policy.RolloutPercent)
+
+	// The token bucket has no parent configured
+	policy = getPolicy(t, router, "resource=api.docs&algorithm=token_bucket")
+	assert.Nil(t, policy.Parent)
+}
+
+func TestPolicies_Markdown(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/policies?resource=api.docs&algorithm=token_bucket", nil)
+	req.Header.Set("Accept", "text/markdown")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/markdown")
+	assert.Equal(t, "## Rate limit policy for `api.docs`\n\n"+
+		"| Setting | Value |\n"+
+		"| --- | --- |\n"+
+		"| Algorithm | token_bucket |\n"+
+		"| Limit | 100 per 1m0s |\n"+
+		"| Burst | 100 |\n"+
+		"| Precision | float |\n", w.Body.String())
+}
+
+func TestPolicies_RejectsUnknownInputs(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "missing resource", query: ""},
+		{name: "unknown algorithm", query: "resource=api.docs&algorithm=nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(router, http.MethodGet, "/v1/policies?"+tt.query, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/keys"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPool(t *testing.T, limit int, fraction float64) *algorithms.Pool {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+
+	pool, err := algorithms.NewPool(s, map[string]algorithms.PoolPolicy{
+		"enterprise": {Limit: limit, Window: time.Hour, SeatFraction: fraction},
+	})
+	require.NoError(t, err)
+	return pool
+}
+
+func TestPool_SeatCapAndOrgLimit(t *testing.T) {
+	pool := newTestPool(t, 10, 0.4)
+	ctx := context.Background()
+
+	// Each seat may use 4 of the org's 10
+	for i := 0; i < 4; i++ {
+		allowed, info, err := pool.AllowN(ctx, "acme", "enterprise", "alice", 1)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, i+1, info.SeatUsed)
+	}
+
+	allowed, info, err := pool.AllowN(ctx, "acme", "enterprise", "alice", 1)
+	require.NoError(t, err)
+	assert.False(t, allowed, "alice is at her seat cap")
+	assert.Equal(t, 4, info.SeatLimit)
+	assert.Equal(t, 6, info.OrgRemaining, "a seat denial must not consume org quota")
+	require.NotNil(t, info.RetryAfter)
+
+	allowed, _, err = pool.AllowN(ctx, "acme", "enterprise", "bob", 4)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Carol has seat room but the org only has 2 left
+	allowed, info, err = pool.AllowN(ctx, "acme", "enterprise", "carol", 3)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, info.SeatUsed, "an org denial must not consume seat quota")
+	assert.Equal(t, 2, info.OrgRemaining)
+
+	allowed, _, err = pool.AllowN(ctx, "acme", "enterprise", "carol", 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Other orgs have their own pool
+	allowed, _, err = pool.AllowN(ctx, "globex", "enterprise", "alice", 1)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestPool_StatusAndOverride(t *testing.T) {
+	pool := newTestPool(t, 100, 0.2)
+	ctx := context.Background()
+
+	_, _, err := pool.AllowN(ctx, "acme", "enterprise", "alice", 7)
+	require.NoError(t, err)
+
+	info, err := pool.Status(ctx, "acme", "enterprise", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 93, info.OrgRemaining)
+	assert.Equal(t, 7, info.SeatUsed)
+	assert.Equal(t, 20, info.SeatLimit)
+
+	// Status does not consume
+	info, err = pool.Status(ctx, "acme", "enterprise", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 7, info.SeatUsed)
+
+	require.NoError(t, pool.SetOverride("acme", algorithms.PoolPolicy{Limit: 200, Window: time.Hour, SeatFraction: 0.5}))
+	info, err = pool.Status(ctx, "acme", "enterprise", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 200, info.OrgLimit)
+	assert.Equal(t, 100, info.SeatLimit)
+
+	assert.Error(t, pool.SetOverride("acme", algorithms.PoolPolicy{Limit: 200, Window: time.Hour, SeatFraction: 1.5}))
+
+	pool.ClearOverride("acme")
+	info, err = pool.Status(ctx, "acme", "enterprise", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 100, info.OrgLimit)
+
+	_, err = pool.Status(ctx, "acme", "free", "alice")
+	assert.ErrorIs(t, err, algorithms.ErrUnknownTier)
+}
+
+func TestPool_ConcurrentNeverExceedsOrgOrSeat(t *testing.T) {
+	const (
+		orgLimit = 200
+		seats    = 8
+		perSeat  = 100 // Attempts per seat, well past every cap
+	)
+	// Two pools on one store stand in for two instances sharing Redis
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	tiers := map[string]algorithms.PoolPolicy{
+		"enterprise": {Limit: orgLimit, Window: time.Hour, SeatFraction: 0.2}, // Seat cap 40; 8 seats could take 320 > 200
+	}
+	var pools [2]*algorithms.Pool
+	for i := range pools {
+		pool, err := algorithms.NewPool(s, tiers)
+		require.NoError(t, err)
+		pools[i] = pool
+	}
+
+	var total int64
+	seatAllowed := make([]int64, seats)
+
+	var wg sync.WaitGroup
+	for seat := 0; seat < seats; seat++ {
+		for worker := 0; worker < 4; worker++ {
+			wg.Add(1)
+			go func(seat int, pool *algorithms.Pool) {
+				defer wg.Done()
+				for i := 0; i < perSeat/4; i++ {
+					allowed, _, err := pool.AllowN(context.Background(), "acme", "enterprise", fmt.Sprintf("seat-%d", seat), 1)
+					if err == nil && allowed {
+						atomic.AddInt64(&total, 1)
+						atomic.AddInt64(&seatAllowed[seat], 1)
+					}
+				}
+			}(seat, pools[worker%2])
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(orgLimit), total, "org pool is fully used but never exceeded")
+	for seat, n := range seatAllowed {
+		assert.LessOrEqual(t, n, int64(40), "seat %d exceeded its fraction", seat)
+	}
+}
+
+func TestPool_NegativeCountCannotRefill(t *testing.T) {
+	pool := newTestPool(t, 10, 1)
+	ctx := context.Background()
+
+	allowed, _, err := pool.AllowN(ctx, "acme", "enterprise", "alice", 10)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	_, _, err = pool.AllowN(ctx, "acme", "enterprise", "alice", -100)
+	assert.ErrorIs(t, err, limiter.ErrInvalidN)
+	_, _, err = pool.Peek(ctx, "acme", "enterprise", "alice", -100)
+	assert.ErrorIs(t, err, limiter.ErrInvalidN)
+
+	info, err := pool.Status(ctx, "acme", "enterprise", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 0, info.OrgRemaining)
+	assert.Equal(t, 10, info.SeatUsed)
+
+	router, _ := newTestRouter(t, handlers.WithPool(pool), handlers.WithMaxCount(50))
+	for _, count := range []int{-100, 0, 51} {
+		w := doJSON(router, http.MethodPost, "/v1/pool/check", map[string]interface{}{"org": "acme", "seat": "bob", "tier": "enterprise", "count": count})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "count %d", count)
+	}
+
+	w := doJSON(router, http.MethodGet, "/v1/pool/status/acme/bob?tier=enterprise", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp handlers.PoolResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.OrgRemaining, "a negative count must not raise org_remaining")
+}
+
+func TestPool_SeatKeysDoNotCollideAcrossOrgs(t *testing.T) {
+	pool := newTestPool(t, 10, 0.4)
+	ctx := context.Background()
+
+	// Org "a:b" exhausts seat "c"; org "a" seat "b:c" would have shared its counter unescaped
+	allowed, _, err := pool.AllowN(ctx, "a:b", "enterprise", "c", 4)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	_, _, err = pool.AllowN(ctx, "a", "enterprise", "b:c", 1)
+	assert.ErrorIs(t, err, keys.ErrInvalidPart)
+
+	info, err := pool.Status(ctx, "a", "enterprise", "b")
+	require.NoError(t, err)
+	assert.Equal(t, 0, info.SeatUsed)
+	assert.Equal(t, 10, info.OrgRemaining)
+}
+
+// refundFailingStore fails every decrement, as a store going down mid-check would
+type refundFailingStore struct {
+	limiter.Store
+}
+
+func (s refundFailingStore) IncrementByCtx(ctx context.Context, key string, window time.Time, delta int64) (int64, error) {
+	if delta < 0 {
+		return 0, fmt.Errorf("%w: connection reset", limiter.ErrStoreUnavailable)
+	}
+	return s.Store.IncrementByCtx(ctx, key, window, delta)
+}
+
+func TestPool_FailedRefundsAreReported(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	pool, err := algorithms.NewPool(refundFailingStore{s}, map[string]algorithms.PoolPolicy{
+		"enterprise": {Limit: 10, Window: time.Hour, SeatFraction: 0.4},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Over the seat cap: the seat refund fails
+	allowed, _, err := pool.AllowN(ctx, "acme", "enterprise", "alice", 5)
+	assert.False(t, allowed)
+	assert.ErrorIs(t, err, limiter.ErrStoreUnavailable)
+
+	// Over the org limit: both refunds fail
+	for _, seat := range []string{"bob", "carol"} {
+		allowed, _, err = pool.AllowN(ctx, "acme", "enterprise", seat, 4)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+	allowed, _, err = pool.AllowN(ctx, "acme", "enterprise", "dave", 4)
+	assert.False(t, allowed)
+	assert.ErrorIs(t, err, limiter.ErrStoreUnavailable)
+	assert.ErrorContains(t, err, "failed to refund pool usage")
+}
+
+func TestPool_Endpoints(t *testing.T) {
+	pool := newTestPool(t, 10, 0.5)
+	router, _ := newTestRouter(t, handlers.WithPool(pool))
+
+	check := map[string]interface{}{"org": "acme", "seat": "alice", "tier": "enterprise", "count": 5}
+	w := doJSON(router, http.MethodPost, "/v1/pool/check", check)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(router, http.MethodPost, "/v1/pool/check", check)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = doJSON(router, http.MethodGet, "/v1/pool/status/acme/alice?tier=enterprise", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp handlers.PoolResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 5, resp.OrgRemaining)
+	assert.Equal(t, 5, resp.SeatUsed)
+	assert.Equal(t, 0, resp.SeatRemaining)
+
+	w = doJSON(router, http.MethodPut, "/v1/pool/overrides/acme", map[string]interface{}{"limit": 20, "window": "1h", "seat_fraction": 0.75})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(router, http.MethodPost, "/v1/pool/check", check)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(router, http.MethodDelete, "/v1/pool/overrides/acme", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(router, http.MethodPost, "/v1/pool/check", map[string]interface{}{"org": "acme", "seat": "alice", "tier": "free"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,181 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPriorities = handlers.Priorities{"normal": 0.1, "low": 0.2}
+
+func TestPriorityLimiter_ShedsIntoReserveForEveryAlgorithm(t *testing.T) {
+	config := limiter.Config{Limit: 10, Window: time.Minute, Burst: 10}
+	builders := map[string]func(limiter.Store, ...algorithms.Option) limiter.RateLimiter{
+		"token_bucket": func(s limiter.Store, opts ...algorithms.Option) limiter.RateLimiter {
+			return algorithms.NewTokenBucket(s, config, opts...)
+		},
+		"sliding_window": func(s limiter.Store, opts ...algorithms.Option) limiter.RateLimiter {
+			return algorithms.NewSlidingWindowCounter(s, config, opts...)
+		},
+		"fixed_window": func(s limiter.Store, opts ...algorithms.Option) limiter.RateLimiter {
+			return algorithms.NewFixedWindowCounter(s, config, opts...)
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+			clock := simulation.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			base := build(s, algorithms.WithClock(clock))
+
+			low, err := algorithms.NewPriorityLimiter(base, 0.2, algorithms.WithClock(clock))
+			require.NoError(t, err)
+			ctx := context.Background()
+
+			// 20% of 10 is held back, so low priority gets 8
+			for i := 0; i < 8; i++ {
+				allowed, shed, _, err := low.AllowNShed(ctx, "shared", 1)
+				require.NoError(t, err)
+				require.True(t, allowed, "request %d", i)
+				require.False(t, shed)
+			}
+
+			allowed, shed, info, err := low.AllowNShed(ctx, "shared", 1)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.True(t, shed, "denied by the reserve, not the limit")
+			assert.Equal(t, 2, info.Remaining, "shedding consumes nothing")
+			require.NotNil(t, info.RetryAfter)
+
+			// The reserve is still there for unreserved traffic
+			for i := 0; i < 2; i++ {
+				allowed, _, err := base.Allow("shared")
+				require.NoError(t, err)
+				assert.True(t, allowed)
+			}
+
+			allowed, shed, _, err = low.AllowNShed(ctx, "shared", 1)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.False(t, shed, "an exhausted limit denies outright")
+		})
+	}
+}
+
+func TestPriorityLimiter_PeekIncludesReserve(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Minute, Burst: 10})
+	low, err := algorithms.NewPriorityLimiter(tb, 0.2)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	allowed, _, err := low.Peek(ctx, "shared", 8)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, _, err = low.Peek(ctx, "shared", 9)
+	require.NoError(t, err)
+	assert.False(t, allowed, "9 would leave 1 of the 2 reserved")
+}
+
+func TestPriorityLimiter_RejectsInvalidReserve(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Minute, Burst: 10})
+
+	for _, reserve := range []float64{-0.1, 1, 1.5} {
+		_, err := algorithms.NewPriorityLimiter(tb, reserve)
+		assert.Error(t, err, "reserve %v", reserve)
+	}
+}
+
+func TestCheck_PriorityShedsLowFirst(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithPriorities(testPriorities))
+
+	check := func(priority string, count int) *handlers.CheckResponse {
+		w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+			"resource":   "api.search",
+			"identifier": "shared",
+			"priority":   priority,
+			"count":      count,
+		})
+		var resp handlers.CheckResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return &resp
+	}
+
+	// 100 per minute: low may take 80, normal 90, high all 100
+	assert.True(t, check("low", 80).Allowed)
+	assert.False(t, check("low", 1).Allowed)
+	assert.True(t, check("", 10).Allowed, "no priority is normal")
+	assert.False(t, check("normal", 1).Allowed)
+
+	resp := check("high", 10)
+	assert.True(t, resp.Allowed)
+	assert.Equal(t, 0, resp.Remaining)
+}
+
+func TestCheck_InvalidPriority(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+		"resource":   "api.search",
+		"identifier": "shared",
+		"priority":   "urgent",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCheck_PriorityMetrics(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Minute, Burst: 10}),
+	}
+	h := handlers.NewRateLimitHandler(limiters, m, "token_bucket", handlers.WithPriorities(testPriorities))
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	// Low takes 7 of 10, then 2 more would leave 1 of its reserved 2, and 8 exceeds what is left
+	checks := []struct {
+		priority string
+		count    int
+	}{{"low", 7}, {"low", 2}, {"high", 8}}
+	for _, c := range checks {
+		doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+			"resource":   "api.search",
+			"identifier": "shared",
+			"priority":   c.priority,
+			"count":      c.count,
+		})
+	}
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.PriorityRequests.WithLabelValues("low", "allowed")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.PriorityRequests.WithLabelValues("low", "shed")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.PriorityRequests.WithLabelValues("high", "denied")))
+}
+
+func TestPriorities_Validate(t *testing.T) {
+	assert.NoError(t, testPriorities.Validate())
+	assert.NoError(t, handlers.Priorities(nil).Validate())
+	assert.Error(t, handlers.Priorities{"high": 0.1}.Validate(), "high priority is never held back")
+	assert.Error(t, handlers.Priorities{"low": 1}.Validate())
+	assert.Error(t, handlers.Priorities{"normal": 0.3, "low": 0.2}.Validate(), "low must not get more than normal")
+}
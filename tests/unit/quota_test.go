@@ -0,0 +1,263 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ limiter.QuotaCounter = (*store.MemoryStore)(nil)
+	_ limiter.QuotaCounter = (*store.RedisStore)(nil)
+	_ limiter.Peeker       = (*algorithms.QuotaLimiter)(nil)
+	_ limiter.Refunder     = (*algorithms.QuotaLimiter)(nil)
+)
+
+// Mid-June, so the monthly period is 2024-06 and ends on July 1st
+var quotaEpoch = time.Date(2024, 6, 17, 9, 30, 0, 0, time.UTC)
+
+func newTestQuota(t *testing.T, limit int64, period string) (*algorithms.QuotaLimiter, *store.MemoryStore, *simulation.ManualClock) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	clock := simulation.NewManualClock(quotaEpoch)
+	q, err := algorithms.NewQuotaLimiter(s, limit, period, algorithms.WithClock(clock))
+	require.NoError(t, err)
+	return q, s, clock
+}
+
+func TestQuotaLimiter_MonthlyPeriod(t *testing.T) {
+	q, _, clock := newTestQuota(t, 3, limiter.AlignMonth)
+	july := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	allowAll(t, q, "user", 3)
+
+	// Weeks later in the same month the quota is still used up
+	clock.Advance(10 * 24 * time.Hour)
+	allowed, info, err := q.Allow("user")
+	require.NoError(t, err)
+	require.False(t, allowed)
+	assert.Equal(t, 3, info.Limit)
+	assert.Equal(t, 0, info.Remaining)
+	assert.True(t, info.ResetAt.Equal(july), "resets at the end of the month")
+	require.NotNil(t, info.RetryAfter)
+	assert.Equal(t, july.Sub(clock.Now()), *info.RetryAfter)
+	assert.Equal(t, "3/month", info.Policy)
+
+	// A new month starts a new counter
+	clock.Set(july)
+	allowed, info, err = q.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 2, info.Remaining)
+	assert.True(t, info.ResetAt.Equal(time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestQuotaLimiter_PeriodInStoreKey(t *testing.T) {
+	q, s, _ := newTestQuota(t, 10, limiter.AlignMonth)
+	ctx := context.Background()
+
+	_, _, err := q.AllowN("user", 4)
+	require.NoError(t, err)
+
+	_, used, err := s.AddQuotaCtx(ctx, "user:2024-06", 0, 10, time.Hour, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), used)
+
+	usage, err := q.Usage(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, algorithms.QuotaUsage{
+		Used:    4,
+		Limit:   10,
+		Period:  "2024-06",
+		ResetAt: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+	}, usage)
+}
+
+func TestQuotaLimiter_PeriodIDs(t *testing.T) {
+	for period, want := range map[string]string{
+		limiter.AlignHour:  "2024-06-17T09",
+		limiter.AlignDay:   "2024-06-17",
+		limiter.AlignMonth: "2024-06",
+	} {
+		q, _, _ := newTestQuota(t, 10, period)
+		usage, err := q.Usage(context.Background(), "user")
+		require.NoError(t, err)
+		assert.Equal(t, want, usage.Period, period)
+	}
+}
+
+func TestQuotaLimiter_StatusAndPeekDoNotConsume(t *testing.T) {
+	q, _, _ := newTestQuota(t, 2, limiter.AlignDay)
+	ctx := context.Background()
+
+	allowed, info, err := q.AllowNCtx(ctx, "user", 0)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 2, info.Remaining)
+
+	allowed, _, err = q.Peek(ctx, "user", 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, _, err = q.Peek(ctx, "user", 3)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowAll(t, q, "user", 2)
+}
+
+func TestQuotaLimiter_RefundAndReset(t *testing.T) {
+	q, _, _ := newTestQuota(t, 5, limiter.AlignMonth)
+	ctx := context.Background()
+
+	_, _, err := q.AllowN("user", 3)
+	require.NoError(t, err)
+	require.NoError(t, q.Refund(ctx, "user", 2))
+	usage, err := q.Usage(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), usage.Used)
+
+	require.NoError(t, q.Refund(ctx, "user", 5))
+	usage, err = q.Usage(ctx, "user")
+	require.NoError(t, err)
+	assert.Zero(t, usage.Used, "refunds never go below zero")
+
+	_, _, err = q.AllowN("user", 5)
+	require.NoError(t, err)
+	require.NoError(t, q.Reset("user"))
+	allowed, _, err := q.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestQuotaLimiter_NegativeCountCannotIncreaseRemaining(t *testing.T) {
+	q, _, _ := newTestQuota(t, 5, limiter.AlignDay)
+	ctx := context.Background()
+
+	allowAll(t, q, "user", 5)
+
+	_, _, err := q.AllowN("user", -100)
+	assert.ErrorIs(t, err, limiter.ErrInvalidN)
+	_, _, err = q.Peek(ctx, "user", -100)
+	assert.ErrorIs(t, err, limiter.ErrInvalidN)
+	assert.ErrorIs(t, q.Refund(ctx, "user", -100), limiter.ErrInvalidN, "a negative refund would consume")
+
+	info, err := q.Status("user")
+	require.NoError(t, err)
+	assert.Equal(t, 0, info.Remaining)
+	allowed, _, err := q.Allow("user")
+	require.NoError(t, err)
+	assert.False(t, allowed, "the quota stays used up")
+}
+
+func TestQuotaLimiter_StacksWithShortWindow(t *testing.T) {
+	q, s, clock := newTestQuota(t, 5, limiter.AlignMonth)
+	perMinute := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 2, Window: time.Minute}, algorithms.WithClock(clock))
+	stacked := algorithms.NewHierarchical(q, perMinute, func(key string) string { return key })
+
+	// The per-minute limit denies the third request and the quota does not count it
+	allowAll(t, stacked, "user", 2)
+	allowed, info, err := stacked.Allow("user")
+	require.NoError(t, err)
+	require.False(t, allowed)
+	assert.Equal(t, 2, info.Limit)
+
+	usage, err := q.Usage(context.Background(), "user")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), usage.Used)
+
+	// Over the next minutes the quota runs out first
+	clock.Advance(time.Minute)
+	allowAll(t, stacked, "user", 2)
+	clock.Advance(time.Minute)
+	allowAll(t, stacked, "user", 1)
+	allowed, info, err = stacked.Allow("user")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, "5/month", info.Policy)
+}
+
+func TestNewQuotaLimiter_Validates(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	_, err := algorithms.NewQuotaLimiter(s, 0, limiter.AlignMonth)
+	assert.Error(t, err)
+	_, err = algorithms.NewQuotaLimiter(s, 10, "week")
+	assert.Error(t, err)
+	_, err = algorithms.NewQuotaLimiter(s, 10, limiter.AlignEpoch)
+	assert.Error(t, err)
+}
+
+func TestCheck_QuotaStacksOnLimit(t *testing.T) {
+	quotaStore := store.NewMemoryStore()
+	defer quotaStore.Close()
+	q, err := algorithms.NewQuotaLimiter(quotaStore, 3, limiter.AlignMonth)
+	require.NoError(t, err)
+	router, _ := newTestRouter(t, handlers.WithQuota(q))
+
+	payload := map[string]interface{}{"resource": "api.export", "identifier": "alice"}
+	for i := 0; i < 3; i++ {
+		w := doJSON(router, http.MethodPost, "/v1/check", payload)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w := doJSON(router, http.MethodPost, "/v1/check", payload)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "3/month", resp.Policy)
+	require.NotNil(t, resp.Quota)
+	assert.Equal(t, int64(3), resp.Quota.Used)
+	assert.Equal(t, int64(3), resp.Quota.Limit)
+	assert.Equal(t, time.Now().UTC().Format("2006-01"), resp.Quota.Period)
+
+	// A check the per-minute limit denies is not counted against another key's quota
+	w = doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+		"resource": "api.export", "identifier": "bob", "count": 150,
+	})
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	usage, err := q.Usage(context.Background(), "bob:api.export")
+	require.NoError(t, err)
+	assert.Zero(t, usage.Used)
+}
+
+func TestStatus_ReportsQuota(t *testing.T) {
+	quotaStore := store.NewMemoryStore()
+	defer quotaStore.Close()
+	q, err := algorithms.NewQuotaLimiter(quotaStore, 10, limiter.AlignDay)
+	require.NoError(t, err)
+	router, _ := newTestRouter(t, handlers.WithQuota(q))
+
+	payload := map[string]interface{}{"resource": "api.export", "identifier": "alice", "count": 4}
+	require.Equal(t, http.StatusOK, doJSON(router, http.MethodPost, "/v1/check", payload).Code)
+
+	for i := 0; i < 2; i++ {
+		w := doJSON(router, http.MethodGet, "/v1/status/alice:api.export", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp handlers.CheckResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Quota)
+		assert.Equal(t, int64(4), resp.Quota.Used, "status does not consume quota")
+	}
+
+	w := doJSON(router, http.MethodGet, "/v1/status/alice:api.export?algorithm=all", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var all struct {
+		Quota *handlers.QuotaStatus `json:"quota"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &all))
+	require.NotNil(t, all.Quota)
+	assert.Equal(t, int64(4), all.Quota.Used)
+}
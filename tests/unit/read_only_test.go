@@ -0,0 +1,208 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// opaqueLimiter hides the Peeker implementation of the wrapped limiter
+type opaqueLimiter struct {
+	limiter.RateLimiter
+}
+
+func TestReadOnly_RejectsEveryMutatingRoute(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithReadOnly(true, handlers.BiasAllow))
+
+	// Checks are answered from peeks and the toggle must stay reachable
+	exempt := map[string]bool{
+		"POST /v1/check":       true,
+		"POST /v1/check/batch": true,
+		"POST /v1/pool/check":  true,
+		"PUT /v1/read-only":    true,
+	}
+
+	checked := 0
+	for _, route := range router.Routes() {
+		if route.Method == http.MethodGet || exempt[route.Method+" "+route.Path] {
+			continue
+		}
+		path := strings.NewReplacer(":key", "some-key", ":org", "acme").Replace(route.Path)
+
+		w := doJSON(router, route.Method, path, map[string]interface{}{"percent": 50})
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "%s %s", route.Method, route.Path)
+		assert.Contains(t, w.Body.String(), `"code":"READ_ONLY"`)
+		checked++
+	}
+	assert.Greater(t, checked, 0)
+}
+
+func TestReadOnly_GuardedRoutes(t *testing.T) {
+	// Valid bodies, so a route that skipped the guard would not fail for another reason
+	tests := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{http.MethodPost, "/v1/reserve", map[string]interface{}{"resource": "api.users", "identifier": "alice", "count": 1}},
+		{http.MethodPost, "/v1/commit/res-1", nil},
+		{http.MethodPost, "/v1/cancel/res-1", nil},
+		{http.MethodPost, "/v1/reset/alice", nil},
+		{http.MethodPost, "/v1/reset-prefix", map[string]interface{}{"prefix": "tenant42%3A"}},
+		{http.MethodPost, "/v1/feedback", map[string]interface{}{"resource": "api.users", "identifier": "alice", "success": true}},
+		{http.MethodPost, "/v1/unban/alice", nil},
+		{http.MethodPut, "/v1/limits/alice", map[string]interface{}{"limit": 10, "window": "1m"}},
+		{http.MethodDelete, "/v1/limits/alice", nil},
+		{http.MethodPost, "/v1/override/alice", map[string]interface{}{"limit": 10, "window": "1m"}},
+		{http.MethodDelete, "/v1/override/alice", nil},
+		{http.MethodPut, "/v1/groups/alice", map[string]interface{}{"group": "team"}},
+		{http.MethodDelete, "/v1/groups/alice", nil},
+		{http.MethodPut, "/v1/rollout", map[string]interface{}{"percent": 50}},
+		{http.MethodPut, "/v1/pool/overrides/acme", map[string]interface{}{"limit": 100, "window": "1h", "seat_fraction": 0.5}},
+		{http.MethodDelete, "/v1/pool/overrides/acme", nil},
+	}
+
+	router, _ := newTestRouter(t, handlers.WithReadOnly(true, handlers.BiasAllow))
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := doJSON(router, tt.method, tt.path, tt.body)
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+			assert.Contains(t, w.Body.String(), `"code":"READ_ONLY"`)
+		})
+	}
+}
+
+func TestReadOnly_CheckPeeksWithoutConsuming(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithReadOnly(true, handlers.BiasAllow))
+	payload := map[string]interface{}{"resource": "api.users", "identifier": "alice", "algorithm": "fixed_window"}
+
+	for i := 0; i < 3; i++ {
+		w := doJSON(router, http.MethodPost, "/v1/check", payload)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.CheckResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.Allowed)
+		assert.Equal(t, 100, resp.Remaining, "read-only checks must not consume quota")
+	}
+}
+
+func TestReadOnly_CheckFallsBackToBias(t *testing.T) {
+	tests := []struct {
+		bias    string
+		allowed bool
+		status  int
+	}{
+		{handlers.BiasAllow, true, http.StatusOK},
+		{handlers.BiasDeny, false, http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bias, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+
+			limiters := map[string]limiter.RateLimiter{
+				"token_bucket": opaqueLimiter{algorithms.NewTokenBucket(s, limiter.Config{
+					Limit:  100,
+					Window: 1 * time.Minute,
+				})},
+			}
+			m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+			h := handlers.NewRateLimitHandler(limiters, m, "token_bucket", handlers.WithReadOnly(true, tt.bias))
+			router := gin.New()
+			h.RegisterRoutes(router)
+
+			w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api.users", "identifier": "alice"})
+			assert.Equal(t, tt.status, w.Code)
+
+			var resp handlers.CheckResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.allowed, resp.Allowed)
+		})
+	}
+}
+
+func TestReadOnly_Toggle(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/v1/reset/some-key", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(router, http.MethodPut, "/v1/read-only", map[string]interface{}{"enabled": true})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(router, http.MethodPost, "/v1/reset/some-key", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = doJSON(router, http.MethodGet, "/health", nil)
+	assert.Contains(t, w.Body.String(), `"read_only":true`)
+
+	w = doJSON(router, http.MethodGet, "/version", nil)
+	assert.Contains(t, w.Body.String(), `"read_only":true`)
+	assert.Contains(t, w.Body.String(), `"version":"`+handlers.Version+`"`)
+
+	w = doJSON(router, http.MethodPut, "/v1/read-only", map[string]interface{}{"enabled": false})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(router, http.MethodPost, "/v1/reset/some-key", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestPeek_DoesNotMutateState(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	config := limiter.Config{Limit: 2, Window: 1 * time.Minute}
+	fixed := config
+	fixed.Precision = limiter.PrecisionFixed
+	peekers := map[string]limiter.RateLimiter{
+		"token_bucket":       algorithms.NewTokenBucket(s, config),
+		"token_bucket_fixed": algorithms.NewTokenBucket(s, fixed),
+		"sliding_window":     algorithms.NewSlidingWindowCounter(s, config),
+		"sliding_window_log": algorithms.NewSlidingWindowLog(s, config),
+		"fixed_window":       algorithms.NewFixedWindowCounter(s, config),
+		"leaky_bucket":       algorithms.NewLeakyBucket(s, config),
+		"gcra":               algorithms.NewGCRA(s, config),
+	}
+
+	for name, rl := range peekers {
+		t.Run(name, func(t *testing.T) {
+			key := "peek-" + name
+			peeker, ok := rl.(limiter.Peeker)
+			require.True(t, ok)
+
+			for i := 0; i < 5; i++ {
+				allowed, info, err := peeker.Peek(context.Background(), key, 1)
+				require.NoError(t, err)
+				assert.True(t, allowed)
+				assert.Equal(t, 2, info.Remaining)
+			}
+
+			// Peeks left the full limit available
+			for i := 0; i < 2; i++ {
+				allowed, _, err := rl.Allow(key)
+				require.NoError(t, err)
+				assert.True(t, allowed)
+			}
+
+			allowed, info, err := peeker.Peek(context.Background(), key, 1)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.NotNil(t, info.RetryAfter)
+		})
+	}
+}
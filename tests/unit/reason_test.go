@@ -0,0 +1,134 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitInfo_ReasonPerAlgorithm(t *testing.T) {
+	config := limiter.Config{Limit: 3, Window: time.Minute}
+
+	tests := []struct {
+		name   string
+		build  func(limiter.Store, limiter.Clock) limiter.RateLimiter
+		reason string
+	}{
+		{"token bucket", func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewTokenBucket(s, config, algorithms.WithClock(c))
+		}, limiter.ReasonInsufficientTokens},
+		{"fixed window", func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewFixedWindowCounter(s, config, algorithms.WithClock(c))
+		}, limiter.ReasonWindowExhausted},
+		{"sliding window", func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewSlidingWindowCounter(s, config, algorithms.WithClock(c))
+		}, limiter.ReasonWindowExhausted},
+		{"sliding window log", func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewSlidingWindowLog(s, config, algorithms.WithClock(c))
+		}, limiter.ReasonWindowExhausted},
+		{"leaky bucket", func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewLeakyBucket(s, config, algorithms.WithClock(c))
+		}, limiter.ReasonBucketFull},
+		{"gcra", func(s limiter.Store, c limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewGCRA(s, config, algorithms.WithClock(c))
+		}, limiter.ReasonRateExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+			rl := tt.build(s, limitertest.NewFakeClock(clockEpoch))
+
+			for i := 0; i < 3; i++ {
+				allowed, info, err := rl.Allow("user")
+				require.NoError(t, err)
+				require.True(t, allowed)
+				assert.Empty(t, info.Reason, "allowed checks carry no reason")
+			}
+
+			allowed, info, err := rl.Allow("user")
+			require.NoError(t, err)
+			require.False(t, allowed)
+			assert.Equal(t, tt.reason, info.Reason)
+
+			// More than the limiter ever admits at once, on a key with room
+			allowed, info, err = rl.AllowN("other", 4)
+			require.NoError(t, err)
+			require.False(t, allowed)
+			assert.Equal(t, limiter.ReasonBurstExceeded, info.Reason)
+		})
+	}
+}
+
+func TestLimitInfo_ReasonFromWrappers(t *testing.T) {
+	t.Run("quota", func(t *testing.T) {
+		s := store.NewMemoryStore()
+		defer s.Close()
+		q, err := algorithms.NewQuotaLimiter(s, 2, "day", algorithms.WithClock(limitertest.NewFakeClock(clockEpoch)))
+		require.NoError(t, err)
+
+		allowAll(t, q, "user", 2)
+		_, info, err := q.Allow("user")
+		require.NoError(t, err)
+		assert.Equal(t, limiter.ReasonQuotaExhausted, info.Reason)
+	})
+
+	t.Run("penalty", func(t *testing.T) {
+		pl, _, _ := newTestPenalties(t)
+
+		allowAll(t, pl, "user", 2)
+		_, info, err := pl.Allow("user")
+		require.NoError(t, err)
+		assert.Equal(t, limiter.ReasonWindowExhausted, info.Reason, "the first denial is the limiter's own")
+		_, info, err = pl.Allow("user")
+		require.NoError(t, err)
+		assert.Equal(t, limiter.ReasonPenalized, info.Reason)
+	})
+
+	t.Run("priority reserve", func(t *testing.T) {
+		s := store.NewMemoryStore()
+		defer s.Close()
+		base := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Minute}, algorithms.WithClock(limitertest.NewFakeClock(clockEpoch)))
+		p, err := algorithms.NewPriorityLimiter(base, 0.5)
+		require.NoError(t, err)
+
+		allowed, _, err := p.AllowN("user", 5)
+		require.NoError(t, err)
+		require.True(t, allowed)
+		allowed, info, err := p.Allow("user")
+		require.NoError(t, err)
+		require.False(t, allowed)
+		assert.Equal(t, limiter.ReasonReserved, info.Reason)
+	})
+}
+
+func TestCheck_ReportsReasonWhenDenied(t *testing.T) {
+	router, _ := newTestRouter(t)
+	check := map[string]interface{}{"resource": "api", "identifier": "alice", "count": 100}
+
+	w := doJSON(router, http.MethodPost, "/v1/check", check)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"reason"`)
+
+	check["count"] = 1
+	w = doJSON(router, http.MethodPost, "/v1/check", check)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, limiter.ReasonInsufficientTokens, resp.Reason)
+
+	w = doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "bob", "count": 101})
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, limiter.ReasonBurstExceeded, resp.Reason)
+}
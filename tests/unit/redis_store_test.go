@@ -0,0 +1,556 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"net"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stalledRedis is a server that completes the connection handshake and PING, then never
+// answers another command, standing in for a Redis that has stopped responding
+func stalledRedis(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveStalled(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveStalled(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			_, err = io.WriteString(conn, "+PONG\r\n")
+		case "HELLO":
+			_, err = io.WriteString(conn, "-ERR unknown command 'HELLO'\r\n")
+		case "CLIENT":
+			_, err = io.WriteString(conn, "+OK\r\n")
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads one RESP array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, errors.New("malformed command")
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil { // $<len>
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func newStalledRedisStore(t *testing.T, timeout time.Duration) *store.RedisStore {
+	t.Helper()
+	rs, err := store.NewRedisStore(store.RedisConfig{
+		Addresses:        []string{stalledRedis(t)},
+		Timeout:          timeout,
+		OperationRetries: -1,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { rs.Close() })
+	return rs
+}
+
+func TestRedisStore_CanceledContextReturnsPromptly(t *testing.T) {
+	rs := newStalledRedisStore(t, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	_, _, _, err := rs.GetTokensCtx(ctx, "user")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRedisStore_CancelDuringTimedOutCommand(t *testing.T) {
+	rs := newStalledRedisStore(t, 100*time.Millisecond)
+
+	// A command already sent runs out its timeout, but reports the caller's cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, _, _, err := rs.GetTokensCtx(ctx, "user")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRedisStore_CallerDeadline(t *testing.T) {
+	rs := newStalledRedisStore(t, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, _, err := rs.GetTokensCtx(ctx, "user")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRedisStore_TimeoutBoundsEachCommand(t *testing.T) {
+	rs := newStalledRedisStore(t, 100*time.Millisecond)
+
+	start := time.Now()
+	_, _, _, err := rs.GetTokensCtx(context.Background(), "user")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, context.Canceled, "the caller did not cancel")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestRedisStore_RecordsErrors(t *testing.T) {
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	rs, err := store.NewRedisStore(store.RedisConfig{
+		Addresses:        []string{stalledRedis(t)},
+		Metrics:          m,
+		OperationRetries: -1,
+	})
+	require.NoError(t, err)
+
+	// Every call on a closed client fails
+	require.NoError(t, rs.Close())
+	now := time.Now()
+	_, err = rs.Increment("user", now)
+	assert.Error(t, err)
+	_, err = rs.GetWindows("user", now.Add(-time.Minute), now)
+	assert.Error(t, err)
+	assert.Error(t, rs.SetTokens("user", 1, now))
+	_, _, _, err = rs.GetTokens("user")
+	assert.Error(t, err)
+	assert.Error(t, rs.Delete("user"))
+
+	for _, operation := range []string{"increment", "get_windows", "set_tokens", "get_tokens", "delete"} {
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.RedisErrors.WithLabelValues(operation)), operation)
+	}
+}
+
+func TestStores_RecordOperationLatency(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := metrics.NewMetricsWithRegistry(reg)
+
+	ms := store.NewMemoryStore(store.WithStoreMetrics(m))
+	defer ms.Close()
+	_, err := ms.Increment("user", time.Now())
+	require.NoError(t, err)
+
+	// Failed calls are timed too
+	rs, err := store.NewRedisStore(store.RedisConfig{
+		Addresses:        []string{stalledRedis(t)},
+		Metrics:          m,
+		OperationRetries: -1,
+	})
+	require.NoError(t, err)
+	require.NoError(t, rs.Close())
+	_, err = rs.Increment("user", time.Now())
+	assert.Error(t, err)
+
+	assert.Equal(t, uint64(1), storeOperationSamples(t, reg, "memory", "increment"))
+	assert.Equal(t, uint64(1), storeOperationSamples(t, reg, "redis", "increment"))
+	assert.Zero(t, storeOperationSamples(t, reg, "memory", "get_windows"))
+}
+
+// storeOperationSamples counts the latencies recorded for a store type and operation
+func storeOperationSamples(t *testing.T, reg *prometheus.Registry, storeType, operation string) uint64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "rate_limiter_store_operations_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["store_type"] == storeType && labels["operation"] == operation {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+// hashRedis is a server keeping hashes in memory, enough of Redis for stores to share one
+// in tests: HSET, HGETALL, EXPIRE, DEL and single-page SCAN with MATCH. It returns the
+// server's address and its hashes
+func hashRedis(t *testing.T) (string, func() map[string]map[string]string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	hashes := make(map[string]map[string]string)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			args, err := readCommand(r)
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			var reply string
+			switch strings.ToUpper(args[0]) {
+			case "PING":
+				reply = "+PONG\r\n"
+			case "HELLO":
+				reply = "-ERR unknown command 'HELLO'\r\n"
+			case "HSET":
+				if hashes[args[1]] == nil {
+					hashes[args[1]] = make(map[string]string)
+				}
+				for i := 2; i+1 < len(args); i += 2 {
+					hashes[args[1]][args[i]] = args[i+1]
+				}
+				reply = ":1\r\n"
+			case "HGETALL":
+				fields := hashes[args[1]]
+				reply = "*" + strconv.Itoa(2*len(fields)) + "\r\n"
+				for field, value := range fields {
+					reply += "$" + strconv.Itoa(len(field)) + "\r\n" + field + "\r\n"
+					reply += "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+				}
+			case "DEL":
+				_, ok := hashes[args[1]]
+				delete(hashes, args[1])
+				reply = ":0\r\n"
+				if ok {
+					reply = ":1\r\n"
+				}
+			case "EXPIRE":
+				reply = ":1\r\n"
+			case "SCAN":
+				pattern := "*"
+				for i := 2; i+1 < len(args); i += 2 {
+					if strings.EqualFold(args[i], "MATCH") {
+						pattern = args[i+1]
+					}
+				}
+				var keys []string
+				for key := range hashes {
+					if ok, _ := path.Match(pattern, key); ok {
+						keys = append(keys, key)
+					}
+				}
+				reply = "*2\r\n$1\r\n0\r\n*" + strconv.Itoa(len(keys)) + "\r\n"
+				for _, key := range keys {
+					reply += "$" + strconv.Itoa(len(key)) + "\r\n" + key + "\r\n"
+				}
+			default:
+				reply = "+OK\r\n"
+			}
+			mu.Unlock()
+
+			if _, err := io.WriteString(conn, reply); err != nil {
+				return
+			}
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	snapshot := func() map[string]map[string]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return maps.Clone(hashes)
+	}
+	return ln.Addr().String(), snapshot
+}
+
+func TestRedisStore_DeletePrefix(t *testing.T) {
+	addr, hashes := hashRedis(t)
+	newStore := func(prefix string) *store.RedisStore {
+		rs, err := store.NewRedisStore(store.RedisConfig{Addresses: []string{addr}, KeyPrefix: prefix})
+		require.NoError(t, err)
+		t.Cleanup(func() { rs.Close() })
+		return rs
+	}
+	prod, staging := newStore("prod:"), newStore("staging:")
+	refill := time.Unix(1_700_000_000, 0)
+
+	for _, key := range []string{"tenant42:alice", "tenant42:bob", "tenant420:carol", "tenant7:alice", "gcra:tenant42:alice"} {
+		require.NoError(t, prod.SetTokens(key, 1, refill))
+	}
+	require.NoError(t, staging.SetTokens("tenant42:alice", 1, refill))
+
+	deleted, err := prod.DeletePrefixCtx(context.Background(), "tenant42:")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	left := slices.Sorted(maps.Keys(hashes()))
+	assert.Equal(t, []string{
+		"prod:tokens:gcra:tenant42:alice",
+		"prod:tokens:tenant420:carol",
+		"prod:tokens:tenant7:alice",
+		"staging:tokens:tenant42:alice",
+	}, left)
+
+	// Pattern characters in the prefix are matched literally
+	deleted, err = prod.DeletePrefixCtx(context.Background(), "tenant*")
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	_, err = prod.DeletePrefixCtx(context.Background(), "")
+	assert.ErrorIs(t, err, limiter.ErrEmptyPrefix)
+}
+
+func TestRedisStore_KeyPrefixesDoNotInterfere(t *testing.T) {
+	addr, hashes := hashRedis(t)
+	newStore := func(prefix string) *store.RedisStore {
+		rs, err := store.NewRedisStore(store.RedisConfig{Addresses: []string{addr}, KeyPrefix: prefix})
+		require.NoError(t, err)
+		t.Cleanup(func() { rs.Close() })
+		return rs
+	}
+	prod, staging := newStore("prod:"), newStore("staging:")
+	refill := time.Unix(1_700_000_000, 0)
+
+	require.NoError(t, prod.SetTokens("user", 3, refill))
+	require.NoError(t, staging.SetTokens("user", 7, refill))
+	assert.Contains(t, hashes(), "prod:tokens:user")
+	assert.Contains(t, hashes(), "staging:tokens:user")
+
+	tokens, _, _, err := prod.GetTokens("user")
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, tokens)
+
+	// Deleting a key in one namespace leaves the other's state alone
+	require.NoError(t, staging.Delete("user"))
+	_, lastRefill, _, err := staging.GetTokens("user")
+	require.NoError(t, err)
+	assert.True(t, lastRefill.IsZero())
+
+	tokens, _, _, err = prod.GetTokens("user")
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, tokens)
+}
+
+func TestRedisStore_GetTokensReportsFound(t *testing.T) {
+	addr, _ := hashRedis(t)
+	rs, err := store.NewRedisStore(store.RedisConfig{Addresses: []string{addr}})
+	require.NoError(t, err)
+	t.Cleanup(func() { rs.Close() })
+
+	_, _, found, err := rs.GetTokens("user")
+	require.NoError(t, err)
+	assert.False(t, found, "a fresh key has no state")
+
+	// An empty bucket is state, not a missing key
+	refill := time.Unix(1_700_000_000, 0)
+	require.NoError(t, rs.SetTokens("user", 0, refill))
+	tokens, lastRefill, found, err := rs.GetTokens("user")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Zero(t, tokens)
+	assert.True(t, lastRefill.Equal(refill))
+
+	// A fresh token bucket key reports its full capacity
+	tb := algorithms.NewTokenBucket(rs, limiter.Config{Limit: 10, Window: time.Minute})
+	info, err := tb.Status("fresh")
+	require.NoError(t, err)
+	assert.Equal(t, 10, info.Remaining)
+
+	// And so does a key right after a reset
+	require.NoError(t, tb.Reset("user"))
+	_, _, found, err = rs.GetTokens("user")
+	require.NoError(t, err)
+	assert.False(t, found)
+	info, err = tb.Status("user")
+	require.NoError(t, err)
+	assert.Equal(t, 10, info.Remaining)
+}
+
+// fakeSentinel is a sentinel that names master as the address of every master it is asked for
+func fakeSentinel(t *testing.T, master string) string {
+	t.Helper()
+
+	host, port, err := net.SplitHostPort(master)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			args, err := readCommand(r)
+			if err != nil {
+				return
+			}
+			var reply string
+			switch cmd := strings.ToUpper(args[0]); {
+			case cmd == "PING":
+				reply = "+PONG\r\n"
+			case cmd == "CLIENT":
+				reply = "+OK\r\n"
+			case cmd == "SENTINEL" && len(args) > 1 && strings.EqualFold(args[1], "get-master-addr-by-name"):
+				reply = "*2\r\n" + bulk(host) + bulk(port)
+			case cmd == "SENTINEL":
+				reply = "*0\r\n" // No other sentinels or replicas
+			case cmd == "SUBSCRIBE":
+				for i, channel := range args[1:] {
+					reply += "*3\r\n" + bulk("subscribe") + bulk(channel) + ":" + strconv.Itoa(i+1) + "\r\n"
+				}
+			default:
+				reply = "-ERR unknown command '" + args[0] + "'\r\n"
+			}
+			if _, err := io.WriteString(conn, reply); err != nil {
+				return
+			}
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func TestNewRedisClient_Modes(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  store.RedisConfig
+		mode    string
+		cluster bool
+		err     string // Expected error substring; empty = valid
+	}{
+		{"one address is single", store.RedisConfig{Addresses: []string{"a:6379"}}, store.RedisModeSingle, false, ""},
+		{"several addresses are a cluster", store.RedisConfig{Addresses: []string{"a:6379", "b:6379"}}, store.RedisModeCluster, true, ""},
+		{"master name is sentinel", store.RedisConfig{SentinelMasterName: "mymaster", SentinelAddresses: []string{"s:26379"}}, store.RedisModeSentinel, false, ""},
+		{"explicit cluster of one", store.RedisConfig{Mode: store.RedisModeCluster, Addresses: []string{"a:6379"}}, store.RedisModeCluster, true, ""},
+		{"single with several addresses", store.RedisConfig{Mode: store.RedisModeSingle, Addresses: []string{"a:6379", "b:6379"}}, store.RedisModeSingle, false, "exactly one address"},
+		{"cluster without addresses", store.RedisConfig{Mode: store.RedisModeCluster}, store.RedisModeCluster, false, "at least one address"},
+		{"sentinel without master name", store.RedisConfig{Mode: store.RedisModeSentinel, SentinelAddresses: []string{"s:26379"}}, store.RedisModeSentinel, false, "master name"},
+		{"sentinel without sentinels", store.RedisConfig{SentinelMasterName: "mymaster"}, store.RedisModeSentinel, false, "sentinel address"},
+		{"unknown mode", store.RedisConfig{Mode: "replicated", Addresses: []string{"a:6379"}}, "replicated", false, `unknown redis mode "replicated"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.mode, tt.config.ResolvedMode())
+
+			client, err := store.NewRedisClient(tt.config)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			defer client.Close()
+
+			_, isCluster := client.(*redis.ClusterClient)
+			assert.Equal(t, tt.cluster, isCluster)
+			if tt.mode == store.RedisModeSentinel {
+				assert.Equal(t, "FailoverClient", client.(*redis.Client).Options().Addr)
+			}
+		})
+	}
+}
+
+func TestRedisStore_SingleAndSentinelModesConnect(t *testing.T) {
+	master := stalledRedis(t)
+
+	single, err := store.NewRedisStore(store.RedisConfig{Mode: store.RedisModeSingle, Addresses: []string{master}})
+	require.NoError(t, err)
+	t.Cleanup(func() { single.Close() })
+
+	// The sentinel names the master the store then connects to and pings
+	sentinel, err := store.NewRedisStore(store.RedisConfig{
+		Mode:               store.RedisModeSentinel,
+		SentinelMasterName: "mymaster",
+		SentinelAddresses:  []string{fakeSentinel(t, master)},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { sentinel.Close() })
+	assert.NoError(t, sentinel.Ping(context.Background()))
+
+	// A sentinel that cannot name a master fails construction
+	_, err = store.NewRedisStore(store.RedisConfig{
+		Mode:               store.RedisModeSentinel,
+		SentinelMasterName: "mymaster",
+		SentinelAddresses:  []string{stalledRedis(t)},
+		Timeout:            100 * time.Millisecond,
+	})
+	assert.Error(t, err)
+}
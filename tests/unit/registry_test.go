@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/config"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_BuildsBuiltinAlgorithms(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	for _, name := range algorithms.Builtin {
+		t.Run(name, func(t *testing.T) {
+			rl, err := limiter.New(s, limiter.Config{Algorithm: name, Limit: 3, Window: time.Minute})
+			require.NoError(t, err)
+
+			describer, ok := rl.(limiter.Describer)
+			require.True(t, ok)
+			assert.Equal(t, name, describer.Config().Algorithm)
+
+			for i := 0; i < 3; i++ {
+				allowed, _, err := rl.Allow("user-" + name)
+				require.NoError(t, err)
+				assert.True(t, allowed, "request %d", i+1)
+			}
+			allowed, _, err := rl.Allow("user-" + name)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+		})
+	}
+}
+
+func TestAlgorithms_ListsBuiltins(t *testing.T) {
+	names := limiter.Algorithms()
+	assert.Subset(t, names, algorithms.Builtin)
+	assert.IsNonDecreasing(t, names)
+}
+
+func TestNew_UnknownAlgorithm(t *testing.T) {
+	_, err := limiter.New(store.NewMemoryStore(), limiter.Config{Algorithm: "fancy_window", Limit: 1, Window: time.Second})
+	assert.ErrorIs(t, err, limiter.ErrUnknownAlgorithm)
+	assert.ErrorContains(t, err, "fancy_window")
+
+	_, err = limiter.New(store.NewMemoryStore(), limiter.Config{Limit: 1, Window: time.Second})
+	assert.ErrorIs(t, err, limiter.ErrUnknownAlgorithm)
+}
+
+func TestNew_ValidatesConfig(t *testing.T) {
+	fill := 1.5
+	tokens := -1
+
+	tests := []struct {
+		name    string
+		config  limiter.Config
+		wantErr string
+	}{
+		{name: "zero limit", config: limiter.Config{Window: time.Minute}, wantErr: "must be positive"},
+		{name: "zero window", config: limiter.Config{Limit: 10}, wantErr: "must be positive"},
+		{name: "negative burst", config: limiter.Config{Limit: 10, Window: time.Minute, Burst: -1}, wantErr: "burst"},
+		{name: "initial fill", config: limiter.Config{Limit: 10, Window: time.Minute, InitialFill: &fill}, wantErr: "initial fill"},
+		{name: "negative initial tokens", config: limiter.Config{Limit: 10, Window: time.Minute, InitialTokens: &tokens}, wantErr: "initial tokens"},
+		{name: "precision", config: limiter.Config{Limit: 10, Window: time.Minute, Precision: "decimal"}, wantErr: "precision"},
+		{name: "debt in fixed precision", config: limiter.Config{Limit: 10, Window: time.Minute, Precision: limiter.PrecisionFixed, MaxDebt: 5}, wantErr: "max debt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Algorithm = algorithms.TokenBucketAlgorithm
+			_, err := limiter.New(store.NewMemoryStore(), tt.config)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	built := false
+	factory := func(s limiter.Store, c limiter.Config) (limiter.RateLimiter, error) {
+		built = true
+		return algorithms.NewFixedWindowCounter(s, c), nil
+	}
+
+	require.NoError(t, limiter.Register("test_register", factory))
+	_, err := limiter.New(store.NewMemoryStore(), limiter.Config{Algorithm: "test_register", Limit: 1, Window: time.Second})
+	require.NoError(t, err)
+	assert.True(t, built)
+	assert.Contains(t, limiter.Algorithms(), "test_register")
+	assert.True(t, limiter.Registered("test_register"))
+
+	c := config.DefaultConfig()
+	c.Algorithms.Default = "test_register"
+	assert.NoError(t, c.Validate(), "registered algorithms are valid defaults")
+
+	assert.ErrorContains(t, limiter.Register("test_register", factory), "already registered")
+	assert.Error(t, limiter.Register(algorithms.TokenBucketAlgorithm, factory), "built-ins can not be replaced")
+	assert.Error(t, limiter.Register("", factory))
+}
+
+func TestAlgorithmFor_PicksByConfig(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	tests := []struct {
+		name   string
+		config limiter.Config
+		want   interface{}
+	}{
+		{"burst above limit", limiter.Config{Limit: 10, Window: time.Minute, Burst: 50}, &algorithms.TokenBucket{}},
+		{"burst wins over a long window", limiter.Config{Limit: 10, Window: 24 * time.Hour, Burst: 50}, &algorithms.TokenBucket{}},
+		{"day window", limiter.Config{Limit: 10000, Window: 24 * time.Hour}, &algorithms.FixedWindowCounter{}},
+		{"hour window", limiter.Config{Limit: 1000, Window: time.Hour}, &algorithms.FixedWindowCounter{}},
+		{"minute window", limiter.Config{Limit: 100, Window: time.Minute}, &algorithms.SlidingWindowCounter{}},
+		{"burst at the limit", limiter.Config{Limit: 100, Window: time.Second, Burst: 100}, &algorithms.SlidingWindowCounter{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := limiter.AlgorithmFor(s, tt.config)
+			require.NoError(t, err)
+			assert.IsType(t, tt.want, rl)
+
+			// Asking New for auto builds the same
+			tt.config.Algorithm = limiter.AlgorithmAuto
+			rl, err = limiter.New(s, tt.config)
+			require.NoError(t, err)
+			assert.IsType(t, tt.want, rl)
+		})
+	}
+
+	c := config.DefaultConfig()
+	c.Algorithms.Default = limiter.AlgorithmAuto
+	assert.NoError(t, c.Validate(), "auto is a valid default")
+}
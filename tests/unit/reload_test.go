@@ -0,0 +1,172 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/config"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ limiter.Reconfigurer = (*algorithms.TokenBucket)(nil)
+	_ limiter.Reconfigurer = (*algorithms.SlidingWindowCounter)(nil)
+	_ limiter.Reconfigurer = (*algorithms.SlidingWindowLog)(nil)
+	_ limiter.Reconfigurer = (*algorithms.FixedWindowCounter)(nil)
+	_ limiter.Reconfigurer = (*algorithms.LeakyBucket)(nil)
+	_ limiter.Reconfigurer = (*algorithms.GCRA)(nil)
+	_ limiter.Reconfigurer = (*algorithms.RolloutLimiter)(nil)
+	_ limiter.Reconfigurer = (*algorithms.AdaptiveLimiter)(nil)
+	_ limiter.Reconfigurer = (*algorithms.DenyCache)(nil)
+	_ limiter.Reconfigurer = (*algorithms.ShadowLimiter)(nil)
+)
+
+func writeConfig(t *testing.T, path, data string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+}
+
+// watchConfig watches a config file holding data, delivering each reload on the returned channel
+func watchConfig(t *testing.T, data string) (string, <-chan *config.Config) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, data)
+
+	changes := make(chan *config.Config, 4)
+	w, err := config.Watch(path, func(c *config.Config) { changes <- c })
+	require.NoError(t, err)
+	t.Cleanup(func() { w.Close() })
+	return path, changes
+}
+
+func TestWatch_CallsBackWithNewConfig(t *testing.T) {
+	path, changes := watchConfig(t, "limits:\n  default:\n    requests: 100\n    window: 1m\n")
+
+	writeConfig(t, path, "limits:\n  default:\n    requests: 250\n    window: 30s\n")
+
+	select {
+	case c := <-changes:
+		assert.Equal(t, 250, c.Limits.Default.Requests)
+		assert.Equal(t, 30*time.Second, c.Limits.Default.Window)
+		assert.Equal(t, "token_bucket", c.Algorithms.Default, "defaults are applied as on startup")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after the file changed")
+	}
+}
+
+func TestWatch_FollowsRenamedFiles(t *testing.T) {
+	path, changes := watchConfig(t, "limits:\n  default:\n    requests: 100\n    window: 1m\n")
+
+	// Editors and config mounts replace the file rather than writing it in place
+	replacement := filepath.Join(filepath.Dir(path), "config.yaml.tmp")
+	writeConfig(t, replacement, "limits:\n  default:\n    requests: 5\n    window: 1s\n")
+	require.NoError(t, os.Rename(replacement, path))
+
+	select {
+	case c := <-changes:
+		assert.Equal(t, 5, c.Limits.Default.Requests)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after the file was replaced")
+	}
+}
+
+func TestWatch_KeepsPreviousConfigOnInvalidFile(t *testing.T) {
+	path, changes := watchConfig(t, "limits:\n  default:\n    requests: 100\n    window: 1m\n")
+
+	writeConfig(t, path, "limits:\n  default:\n    requests: [\n")
+	select {
+	case c := <-changes:
+		t.Fatalf("reloaded an invalid file: %+v", c.Limits.Default)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// Saving the file unchanged is not a change either
+	writeConfig(t, path, "limits:\n  default:\n    requests: 100\n    window: 1m\n")
+	select {
+	case c := <-changes:
+		t.Fatalf("reloaded an unchanged file: %+v", c.Limits.Default)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestWatch_RequiresLoadableFile(t *testing.T) {
+	_, err := config.Watch(filepath.Join(t.TempDir(), "missing.yaml"), func(*config.Config) {})
+	assert.Error(t, err)
+}
+
+func TestUpdateConfig_RaisesLimitKeepingCounts(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	fw := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 2, Window: time.Minute})
+
+	allowAll(t, fw, "user", 2)
+	allowed, _, err := fw.Allow("user")
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	require.NoError(t, fw.UpdateConfig(limiter.Config{Limit: 3, Window: time.Minute}))
+	assert.Equal(t, 3, fw.Config().Limit)
+
+	allowed, info, err := fw.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed, "the raised limit applies to the current window")
+	assert.Equal(t, 0, info.Remaining, "requests counted before the update still count")
+}
+
+func TestUpdateConfig_ResizesTokenBucket(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Minute})
+
+	require.NoError(t, tb.UpdateConfig(limiter.Config{Limit: 2, Window: time.Minute}))
+
+	allowAll(t, tb, "user", 2)
+	allowed, info, err := tb.Allow("user")
+	require.NoError(t, err)
+	assert.False(t, allowed, "a new bucket holds the new capacity")
+	assert.Equal(t, 2, info.Limit)
+}
+
+func TestUpdateConfig_RejectsInvalidConfig(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	gcra := algorithms.NewGCRA(s, limiter.Config{Limit: 10, Window: time.Minute})
+	before := gcra.Config()
+
+	assert.Error(t, gcra.UpdateConfig(limiter.Config{Limit: 0, Window: time.Minute}))
+	assert.Error(t, gcra.UpdateConfig(limiter.Config{Limit: 10}))
+	assert.Equal(t, before, gcra.Config(), "a rejected update changes nothing")
+}
+
+func TestUpdateConfig_ThroughWrappers(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 1, Window: time.Minute})
+	dc, err := algorithms.NewDenyCache(base, algorithms.DenyCacheConfig{Staleness: time.Minute})
+	require.NoError(t, err)
+
+	allowAll(t, dc, "user", 1)
+	allowed, _, err := dc.Allow("user")
+	require.NoError(t, err)
+	require.False(t, allowed, "the denial is cached")
+
+	require.NoError(t, dc.UpdateConfig(limiter.Config{Limit: 2, Window: time.Minute}))
+	allowed, _, err = dc.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed, "denials decided under the old limit are forgotten")
+
+	composite, err := algorithms.NewCompositeLimiter([]limiter.Config{{Limit: 1, Window: time.Second}},
+		func(c limiter.Config) (limiter.RateLimiter, error) {
+			return algorithms.NewFixedWindowCounter(s, c), nil
+		})
+	require.NoError(t, err)
+	shadow := algorithms.NewShadowLimiter(composite, base, algorithms.ShadowConfig{})
+	assert.Error(t, shadow.UpdateConfig(limiter.Config{Limit: 2, Window: time.Minute}))
+}
@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_ClaimRequest(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	ctx := context.Background()
+
+	claimed, result, err := s.ClaimRequestCtx(ctx, "op-1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Claimed but not yet recorded
+	claimed, result, err = s.ClaimRequestCtx(ctx, "op-1", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Nil(t, result)
+
+	require.NoError(t, s.RecordRequestCtx(ctx, "op-1", []byte(`{"allowed":true}`), time.Minute))
+	claimed, result, err = s.ClaimRequestCtx(ctx, "op-1", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, `{"allowed":true}`, string(result))
+
+	require.NoError(t, s.ReleaseRequestCtx(ctx, "op-1"))
+	claimed, _, err = s.ClaimRequestCtx(ctx, "op-1", 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, claimed, "a released ID can be claimed again")
+
+	time.Sleep(30 * time.Millisecond)
+	claimed, _, err = s.ClaimRequestCtx(ctx, "op-1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed, "an ID is forgotten after its TTL")
+}
+
+func TestMemoryStore_ClaimRequestConcurrent(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	var (
+		wg      sync.WaitGroup
+		claimed atomic.Int32
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, _, err := s.ClaimRequestCtx(context.Background(), "op-1", time.Minute)
+			assert.NoError(t, err)
+			if ok {
+				claimed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, claimed.Load())
+}
+
+// newRequestIDRouter serves checks with request IDs recorded in recorder, returning the
+// router and its metrics
+func newRequestIDRouter(t *testing.T, limiters map[string]limiter.RateLimiter, recorder limiter.RequestRecorder) (*gin.Engine, *metrics.Metrics) {
+	t.Helper()
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	h := handlers.NewRateLimitHandler(limiters, m, "token_bucket", handlers.WithRequestIDs(recorder, time.Minute))
+	router := gin.New()
+	h.RegisterRoutes(router)
+	return router, m
+}
+
+func TestCheck_RequestIDReplaysResponse(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{Limit: 2, Window: time.Hour}),
+	}
+	router, m := newRequestIDRouter(t, limiters, s)
+
+	check := map[string]interface{}{"resource": "api", "identifier": "alice", "request_id": "op-1"}
+	first := doJSON(router, http.MethodPost, "/v1/check", check)
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get(handlers.HeaderIdempotentReplayed))
+
+	// The retry replays the first response, headers included, without consuming
+	retry := doJSON(router, http.MethodPost, "/v1/check", check)
+	require.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(handlers.HeaderIdempotentReplayed))
+	assert.JSONEq(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, first.Header().Get("X-RateLimit-Remaining"), retry.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RequestIDReplays.WithLabelValues("replayed")))
+
+	var resp handlers.CheckResponse
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice", "request_id": "op-2"})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.Remaining, "only the two distinct operations were charged")
+
+	// Denials replay too
+	check["request_id"] = "op-3"
+	require.Equal(t, http.StatusTooManyRequests, doJSON(router, http.MethodPost, "/v1/check", check).Code)
+	assert.Equal(t, http.StatusTooManyRequests, doJSON(router, http.MethodPost, "/v1/check", check).Code)
+
+	// IDs are scoped to the identifier and resource
+	w = doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "bob", "request_id": "op-1"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(handlers.HeaderIdempotentReplayed))
+}
+
+func TestCheck_RequestIDInProgress(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	limiters := map[string]limiter.RateLimiter{"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{Limit: 2, Window: time.Hour})}
+	router, m := newRequestIDRouter(t, limiters, s)
+
+	// Another instance is still running the first check with this ID
+	claimed, _, err := s.ClaimRequestCtx(context.Background(), "alice:api:op-1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice", "request_id": "op-1"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var apiErr handlers.APIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+	assert.Equal(t, handlers.CodeInProgress, apiErr.Code)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RequestIDReplays.WithLabelValues("in_progress")))
+}
+
+func TestCheck_RequestIDReleasedOnFailure(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	router, _ := newRequestIDRouter(t, map[string]limiter.RateLimiter{"token_bucket": brokenLimiter{}}, s)
+
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice", "request_id": "op-1"})
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	claimed, _, err := s.ClaimRequestCtx(context.Background(), "alice:api:op-1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed, "a failed check leaves its ID free for a retry")
+}
+
+func TestCheck_RequestIDRejected(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{"resource": "api", "identifier": "alice", "request_id": "op-1"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "request IDs are off by default")
+
+	s := store.NewMemoryStore()
+	defer s.Close()
+	enabled, _ := newRequestIDRouter(t, map[string]limiter.RateLimiter{"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{Limit: 2, Window: time.Hour})}, s)
+	w = doJSON(enabled, http.MethodPost, "/v1/check/batch", []map[string]interface{}{{"resource": "api", "identifier": "alice", "request_id": "op-1"}})
+	require.Equal(t, http.StatusOK, w.Code)
+	var results []handlers.BatchCheckResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	require.Len(t, results, 1)
+	assert.Equal(t, handlers.CodeInvalidRequest, results[0].Code)
+}
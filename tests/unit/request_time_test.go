@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixedWindowCounter_AllowNAtUsesItsWindow(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := limitertest.NewFakeClock(clockEpoch)
+	fwc := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 2, Window: time.Minute}, algorithms.WithClock(clock))
+
+	// Replayed requests count against the window they were made in, not the current one
+	past := clockEpoch.Add(-time.Hour)
+	for i := 0; i < 2; i++ {
+		allowed, _, err := fwc.AllowNAt("replay", 1, past)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, info, err := fwc.AllowNAt("replay", 1, past.Add(time.Second))
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, past.Truncate(time.Minute).Add(time.Minute), info.ResetAt)
+
+	allowed, _, err = fwc.Allow("replay")
+	require.NoError(t, err)
+	assert.True(t, allowed, "the current window is untouched")
+}
+
+func TestWindowCounters_AllowNAtNormalizesOffsets(t *testing.T) {
+	counters := map[string]func(limiter.Store, limiter.Config, ...algorithms.Option) limiter.RateLimiter{
+		"fixed_window": func(s limiter.Store, c limiter.Config, opts ...algorithms.Option) limiter.RateLimiter {
+			return algorithms.NewFixedWindowCounter(s, c, opts...)
+		},
+		"sliding_window": func(s limiter.Store, c limiter.Config, opts ...algorithms.Option) limiter.RateLimiter {
+			return algorithms.NewSlidingWindowCounter(s, c, opts...)
+		},
+	}
+
+	for name, build := range counters {
+		t.Run(name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+
+			clock := limitertest.NewFakeClock(clockEpoch)
+			rl := build(s, limiter.Config{Limit: 2, Window: time.Minute}, algorithms.WithClock(clock))
+			at := rl.(interface {
+				AllowNAt(key string, n int, at time.Time) (bool, *limiter.LimitInfo, error)
+			})
+
+			// One instant in three zones is one window
+			instant := clockEpoch.Add(10 * time.Second)
+			zones := []*time.Location{time.UTC, time.FixedZone("+01:00", 3600), time.FixedZone("+02:00", 7200)}
+			var allowed []bool
+			for _, zone := range zones {
+				ok, _, err := at.AllowNAt("client", 1, instant.In(zone))
+				require.NoError(t, err)
+				allowed = append(allowed, ok)
+			}
+			assert.Equal(t, []bool{true, true, false}, allowed)
+		})
+	}
+}
+
+func TestTokenBucket_AllowNAtDoesNotRewind(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := limitertest.NewFakeClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Second, Burst: 10}, algorithms.WithClock(clock))
+
+	allowed, _, err := tb.AllowN("key", 10)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// A request dated before the last refill neither refills the bucket nor moves it back
+	allowed, _, err = tb.AllowNAt("key", 1, clockEpoch.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	clock.Advance(500 * time.Millisecond)
+	info, err := tb.Status("key")
+	require.NoError(t, err)
+	assert.Equal(t, 5, info.Remaining)
+}
+
+func TestLeakyBucket_AllowNAtDoesNotRewind(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	clock := limitertest.NewFakeClock(clockEpoch)
+	lb := algorithms.NewLeakyBucket(s, limiter.Config{Limit: 10, Window: time.Second, Burst: 5}, algorithms.WithClock(clock))
+
+	allowed, _, err := lb.AllowN("key", 5)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, _, err = lb.AllowNAt("key", 1, clockEpoch.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	clock.Advance(200 * time.Millisecond)
+	info, err := lb.Status("key")
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.Remaining)
+}
+
+func TestCheck_ClientTimestamp(t *testing.T) {
+	newRouter := func(opts ...handlers.Option) *gin.Engine {
+		s := store.NewMemoryStore()
+		t.Cleanup(func() { s.Close() })
+
+		limiters := map[string]limiter.RateLimiter{
+			"fixed_window": algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 1, Window: time.Minute}),
+		}
+		h := handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "fixed_window", opts...)
+		router := gin.New()
+		h.RegisterRoutes(router)
+		return router
+	}
+	check := func(router *gin.Engine, at time.Time) int {
+		return doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+			"resource":   "api",
+			"identifier": "user",
+			"timestamp":  at.Format(time.RFC3339),
+		}).Code
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		router := newRouter(handlers.WithClientTimestamps())
+		past := time.Now().Add(-time.Hour)
+
+		assert.Equal(t, http.StatusOK, check(router, past))
+		assert.Equal(t, http.StatusTooManyRequests, check(router, past))
+		assert.Equal(t, http.StatusOK, check(router, past.Add(-time.Hour)), "an earlier window has its own count")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		router := newRouter()
+		assert.Equal(t, http.StatusBadRequest, check(router, time.Now()))
+	})
+}
@@ -0,0 +1,227 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newReservations returns reservations held for a minute on a token bucket of 10 per hour,
+// slow enough that refill does not blur what reservations hold
+func newReservations(t *testing.T) (*algorithms.Reservations, *algorithms.TokenBucket, *limitertest.FakeClock) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+
+	clock := limitertest.NewFakeClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Hour}, algorithms.WithClock(clock))
+	lookup := func(name string) (limiter.RateLimiter, bool) { return tb, name == "token_bucket" }
+	return algorithms.NewReservations(s, time.Minute, lookup, algorithms.WithClock(clock)), tb, clock
+}
+
+func remaining(t *testing.T, rl limiter.RateLimiter, key string) int {
+	t.Helper()
+	info, err := rl.Status(key)
+	require.NoError(t, err)
+	return info.Remaining
+}
+
+func TestReservations_CommitKeepsAndCancelReturns(t *testing.T) {
+	ctx := context.Background()
+	r, tb, _ := newReservations(t)
+
+	allowed, info, kept, err := r.Reserve(ctx, "token_bucket", "alice", 4)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	assert.Equal(t, 6, info.Remaining)
+
+	allowed, _, returned, err := r.Reserve(ctx, "token_bucket", "alice", 3)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	assert.NotEqual(t, kept.ID, returned.ID)
+	assert.Equal(t, 3, remaining(t, tb, "alice"))
+
+	// More than is left is denied and holds nothing
+	allowed, info, res, err := r.Reserve(ctx, "token_bucket", "alice", 4)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Nil(t, res)
+	assert.NotNil(t, info.RetryAfter)
+
+	committed, err := r.Commit(ctx, kept.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, committed.N)
+
+	canceled, err := r.Cancel(ctx, returned.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", canceled.Key)
+	assert.Equal(t, 6, remaining(t, tb, "alice"))
+
+	// Each reservation is finished once
+	for _, id := range []string{kept.ID, returned.ID, "unknown"} {
+		_, err = r.Commit(ctx, id)
+		assert.ErrorIs(t, err, limiter.ErrReservationNotFound)
+		_, err = r.Cancel(ctx, id)
+		assert.ErrorIs(t, err, limiter.ErrReservationNotFound)
+	}
+	assert.Equal(t, 6, remaining(t, tb, "alice"))
+
+	_, _, _, err = r.Reserve(ctx, "fixed_window", "alice", 1)
+	assert.Error(t, err)
+}
+
+func TestReservations_ExpiredCannotBeCommitted(t *testing.T) {
+	ctx := context.Background()
+	r, tb, clock := newReservations(t)
+
+	_, _, expiring, err := r.Reserve(ctx, "token_bucket", "alice", 5)
+	require.NoError(t, err)
+	clock.Advance(30 * time.Second)
+	_, _, live, err := r.Reserve(ctx, "token_bucket", "alice", 5)
+	require.NoError(t, err)
+
+	// Nothing has expired yet
+	n, err := r.Reclaim(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	clock.Advance(30 * time.Second)
+	_, err = r.Commit(ctx, expiring.ID)
+	assert.ErrorIs(t, err, limiter.ErrReservationNotFound, "expired at its TTL")
+	_, err = r.Cancel(ctx, expiring.ID)
+	assert.ErrorIs(t, err, limiter.ErrReservationNotFound)
+
+	before := remaining(t, tb, "alice")
+	n, err = r.Reclaim(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, before+5, remaining(t, tb, "alice"), "expired quota is returned")
+
+	// Reclaiming again returns nothing more, and reclaiming did not make it committable
+	n, err = r.Reclaim(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	_, err = r.Commit(ctx, expiring.ID)
+	assert.ErrorIs(t, err, limiter.ErrReservationNotFound)
+
+	_, err = r.Commit(ctx, live.ID)
+	assert.NoError(t, err)
+}
+
+func TestReservations_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	r, tb, clock := newReservations(t)
+
+	// Twenty callers race for ten tokens
+	var mu sync.Mutex
+	var ids []string
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			allowed, _, res, err := r.Reserve(ctx, "token_bucket", "alice", 1)
+			if assert.NoError(t, err) && allowed {
+				mu.Lock()
+				ids = append(ids, res.ID)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, ids, 10)
+
+	// Racing commits and cancels of the same reservation: exactly one wins each
+	var finished atomic.Int32
+	for _, id := range ids[:5] {
+		for _, finish := range []func(context.Context, string) (*limiter.Reservation, error){r.Commit, r.Cancel, r.Commit, r.Cancel} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := finish(ctx, id); err == nil {
+					finished.Add(1)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	assert.EqualValues(t, 5, finished.Load())
+
+	// Racing reclaims return each expired reservation once
+	clock.Advance(time.Minute)
+	before := remaining(t, tb, "alice")
+	var reclaimed atomic.Int32
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := r.Reclaim(ctx)
+			assert.NoError(t, err)
+			reclaimed.Add(int32(n))
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 5, reclaimed.Load())
+	assert.Equal(t, min(10, before+5), remaining(t, tb, "alice"))
+}
+
+func TestReserve_HTTP(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	router, _ := newTestRouter(t, handlers.WithReservations(s, time.Minute))
+
+	body := map[string]interface{}{"resource": "api.export", "identifier": "alice", "count": 60}
+	w := doJSON(router, http.MethodPost, "/v1/reserve", body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp handlers.ReserveResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Allowed)
+	assert.Equal(t, 40, resp.Remaining)
+	assert.Equal(t, "40", w.Header().Get("X-RateLimit-Remaining"))
+	require.NotEmpty(t, resp.ReservationID)
+	assert.NotEmpty(t, resp.ExpiresAt)
+
+	// The held quota counts against checks
+	w = doJSON(router, http.MethodPost, "/v1/reserve", body)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = doJSON(router, http.MethodPost, "/v1/cancel/"+resp.ReservationID, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var done handlers.ReservationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &done))
+	assert.Equal(t, handlers.ReservationResponse{ReservationID: resp.ReservationID, Key: "alice:api.export", Count: 60, Status: "canceled"}, done)
+
+	w = doJSON(router, http.MethodPost, "/v1/commit/"+resp.ReservationID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// The canceled quota is back
+	w = doJSON(router, http.MethodPost, "/v1/reserve", body)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	w = doJSON(router, http.MethodPost, "/v1/commit/"+resp.ReservationID, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"committed"`)
+}
+
+func TestReserve_NotEnabled(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/v1/reserve", map[string]interface{}{"resource": "api.export", "identifier": "alice"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(router, http.MethodPost, "/v1/commit/abc", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
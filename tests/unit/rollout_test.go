@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollout_EnforcesApproximatePercentage(t *testing.T) {
+	r, err := algorithms.NewRollout(30)
+	require.NoError(t, err)
+
+	enforced := 0
+	for i := 0; i < 10000; i++ {
+		if r.Enforced(fmt.Sprintf("key-%d", i)) {
+			enforced++
+		}
+	}
+
+	assert.InDelta(t, 3000, enforced, 300)
+}
+
+func TestRollout_StablePerKeyAndMonotonic(t *testing.T) {
+	r, err := algorithms.NewRollout(25)
+	require.NoError(t, err)
+
+	before := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		before[key] = r.Enforced(key)
+		assert.Equal(t, before[key], r.Enforced(key), "decision must be stable for %s", key)
+	}
+
+	// Ramping up never moves an enforced key back to shadow mode
+	require.NoError(t, r.SetPercent(60))
+	for key, wasEnforced := range before {
+		if wasEnforced {
+			assert.True(t, r.Enforced(key), "%s should stay enforced after ramp-up", key)
+		}
+	}
+}
+
+func TestRollout_RejectsOutOfRangePercent(t *testing.T) {
+	_, err := algorithms.NewRollout(101)
+	assert.Error(t, err)
+
+	r, err := algorithms.NewRollout(0)
+	require.NoError(t, err)
+	assert.Error(t, r.SetPercent(-1))
+}
+
+func TestRolloutLimiter_ShadowsUnenforcedKeys(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	r, err := algorithms.NewRollout(50)
+	require.NoError(t, err)
+
+	base := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 2, Window: time.Minute})
+	rl := algorithms.NewRolloutLimiter(base, r)
+
+	var enforcedKey, shadowKey string
+	for i := 0; enforcedKey == "" || shadowKey == ""; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if r.Enforced(key) {
+			enforcedKey = key
+		} else {
+			shadowKey = key
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		rl.Allow(enforcedKey)
+		rl.Allow(shadowKey)
+	}
+
+	allowed, _, err := rl.Allow(enforcedKey)
+	require.NoError(t, err)
+	assert.False(t, allowed, "enforced key should be denied over the limit")
+
+	allowed, info, err := rl.Allow(shadowKey)
+	require.NoError(t, err)
+	assert.True(t, allowed, "shadowed key should be let through")
+	assert.Equal(t, 0, info.Remaining, "shadowed key still reports its real state")
+	assert.Nil(t, info.RetryAfter)
+}
+
+func TestHandler_SetRollout(t *testing.T) {
+	r, err := algorithms.NewRollout(10)
+	require.NoError(t, err)
+
+	router, _ := newTestRouter(t, handlers.WithRollout(r))
+
+	w := doJSON(router, http.MethodPut, "/v1/rollout", map[string]interface{}{"percent": 75})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 75.0, r.Percent())
+
+	w = doJSON(router, http.MethodPut, "/v1/rollout", map[string]interface{}{"percent": 150})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 75.0, r.Percent())
+
+	w = doJSON(router, http.MethodGet, "/v1/rollout", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"percent":75}`, w.Body.String())
+}
@@ -0,0 +1,221 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptReplyError is an error reply from Redis, as go-redis reports a failing script
+type scriptReplyError string
+
+func (e scriptReplyError) Error() string { return string(e) }
+
+func (e scriptReplyError) RedisError() {}
+
+var errScript error = scriptReplyError("NOSCRIPT no matching script")
+
+// fakePaths counts calls to a legacy and a script implementation
+// The script fails whenever fail returns true
+type fakePaths struct {
+	legacyCalls int
+	scriptCalls int
+	fail        func(call int) bool
+	err         error // Error failing calls return (default errScript)
+	scriptValue int
+}
+
+func (f *fakePaths) legacy() (int, error) {
+	f.legacyCalls++
+	return 1, nil
+}
+
+func (f *fakePaths) script() (int, error) {
+	f.scriptCalls++
+	if f.fail != nil && f.fail(f.scriptCalls) {
+		if f.err != nil {
+			return 0, f.err
+		}
+		return 0, errScript
+	}
+	return f.scriptValue, nil
+}
+
+func equalInts(a, b int) bool { return a == b }
+
+func TestParseScriptMode(t *testing.T) {
+	for in, want := range map[string]store.ScriptMode{
+		"":       store.ScriptOff,
+		"off":    store.ScriptOff,
+		"shadow": store.ScriptShadow,
+		"on":     store.ScriptOn,
+	} {
+		got, err := store.ParseScriptMode(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := store.ParseScriptMode("maybe")
+	assert.Error(t, err)
+}
+
+func TestScriptGate_OffRunsLegacyOnly(t *testing.T) {
+	g := store.NewScriptGate("test", store.ScriptGateConfig{Mode: store.ScriptOff})
+	f := &fakePaths{scriptValue: 1}
+
+	for i := 0; i < 10; i++ {
+		v, err := store.RunGated(g, f.legacy, f.script, equalInts)
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+	}
+	assert.Equal(t, 10, f.legacyCalls)
+	assert.Equal(t, 0, f.scriptCalls)
+}
+
+func TestScriptGate_DemotesAfterThresholdAndRecovers(t *testing.T) {
+	now := time.Unix(1000, 0)
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	g := store.NewScriptGate("test", store.ScriptGateConfig{
+		Mode:           store.ScriptOn,
+		ErrorThreshold: 3,
+		Cooldown:       time.Minute,
+		Metrics:        m,
+		Now:            func() time.Time { return now },
+	})
+
+	// Every script call fails
+	failing := true
+	f := &fakePaths{scriptValue: 2, fail: func(int) bool { return failing }}
+
+	// Errors fall back to legacy per call until the threshold demotes the path
+	for i := 0; i < 3; i++ {
+		v, err := store.RunGated(g, f.legacy, f.script, equalInts)
+		require.NoError(t, err)
+		assert.Equal(t, 1, v, "script errors fall back to legacy")
+	}
+	assert.True(t, g.Demoted())
+	assert.Equal(t, 3, f.scriptCalls)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.ScriptDemotions.WithLabelValues("test")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.ScriptDemoted.WithLabelValues("test")))
+
+	// While demoted the script is not attempted
+	for i := 0; i < 5; i++ {
+		store.RunGated(g, f.legacy, f.script, equalInts)
+	}
+	assert.Equal(t, 3, f.scriptCalls)
+
+	// After the cooldown one trial runs; failing keeps the path demoted
+	now = now.Add(time.Minute)
+	store.RunGated(g, f.legacy, f.script, equalInts)
+	assert.Equal(t, 4, f.scriptCalls)
+	assert.True(t, g.Demoted())
+
+	store.RunGated(g, f.legacy, f.script, equalInts)
+	assert.Equal(t, 4, f.scriptCalls, "a failed trial restarts the cooldown")
+
+	// Once the script is healthy again the next trial recovers the path
+	failing = false
+	now = now.Add(time.Minute)
+	v, err := store.RunGated(g, f.legacy, f.script, equalInts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, v)
+	assert.False(t, g.Demoted())
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.ScriptDemoted.WithLabelValues("test")))
+
+	v, _ = store.RunGated(g, f.legacy, f.script, equalInts)
+	assert.Equal(t, 2, v)
+	assert.Equal(t, float64(4), testutil.ToFloat64(m.ScriptErrors.WithLabelValues("test")))
+}
+
+func TestScriptGate_IntermittentErrorsBelowThreshold(t *testing.T) {
+	g := store.NewScriptGate("test", store.ScriptGateConfig{
+		Mode:           store.ScriptOn,
+		ErrorThreshold: 3,
+	})
+
+	// Every other call fails, so errors are never consecutive enough to demote
+	f := &fakePaths{scriptValue: 2, fail: func(call int) bool { return call%2 == 0 }}
+	for i := 0; i < 100; i++ {
+		_, err := store.RunGated(g, f.legacy, f.script, equalInts)
+		require.NoError(t, err)
+	}
+	assert.False(t, g.Demoted())
+	assert.Equal(t, 100, f.scriptCalls)
+	assert.Equal(t, 50, f.legacyCalls)
+}
+
+func TestScriptGate_ShadowComparesAndReturnsLegacy(t *testing.T) {
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	g := store.NewScriptGate("test", store.ScriptGateConfig{
+		Mode:        store.ScriptShadow,
+		SampleEvery: 10,
+		Metrics:     m,
+	})
+
+	// Script disagrees with legacy
+	f := &fakePaths{scriptValue: 2}
+	for i := 0; i < 25; i++ {
+		v, err := store.RunGated(g, f.legacy, f.script, equalInts)
+		require.NoError(t, err)
+		assert.Equal(t, 1, v, "shadow mode returns the legacy result")
+	}
+	assert.Equal(t, 25, f.legacyCalls)
+	assert.Equal(t, 25, f.scriptCalls)
+	assert.Equal(t, int64(25), g.Mismatches())
+	assert.Equal(t, float64(25), testutil.ToFloat64(m.ScriptMismatches.WithLabelValues("test")))
+
+	// Agreement is not counted
+	f.scriptValue = 1
+	store.RunGated(g, f.legacy, f.script, equalInts)
+	assert.Equal(t, int64(25), g.Mismatches())
+}
+
+func TestScriptGate_ShadowDemotesFailingScript(t *testing.T) {
+	g := store.NewScriptGate("test", store.ScriptGateConfig{
+		Mode:           store.ScriptShadow,
+		ErrorThreshold: 2,
+	})
+
+	f := &fakePaths{fail: func(int) bool { return true }}
+	for i := 0; i < 10; i++ {
+		v, err := store.RunGated(g, f.legacy, f.script, equalInts)
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+	}
+	assert.True(t, g.Demoted())
+	assert.Equal(t, 2, f.scriptCalls)
+	assert.Equal(t, int64(0), g.Mismatches(), "script errors are not mismatches")
+}
+
+func TestScriptGate_StoreFailuresDoNotFallBackOrDemote(t *testing.T) {
+	for _, failure := range []error{
+		fmt.Errorf("%w: i/o timeout", limiter.ErrStoreUnavailable),
+		fmt.Errorf("%w: read tcp: i/o timeout", context.DeadlineExceeded),
+		context.Canceled,
+	} {
+		t.Run(failure.Error(), func(t *testing.T) {
+			g := store.NewScriptGate("test", store.ScriptGateConfig{
+				Mode:           store.ScriptOn,
+				ErrorThreshold: 2,
+			})
+
+			// The script may have applied before the reply was lost, so legacy must not rerun it
+			f := &fakePaths{scriptValue: 2, fail: func(int) bool { return true }, err: failure}
+			for i := 0; i < 5; i++ {
+				_, err := store.RunGated(g, f.legacy, f.script, equalInts)
+				assert.ErrorIs(t, err, failure)
+			}
+			assert.Equal(t, 0, f.legacyCalls)
+			assert.False(t, g.Demoted())
+		})
+	}
+}
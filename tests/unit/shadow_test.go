@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ limiter.Peeker = (*algorithms.ShadowLimiter)(nil)
+
+func newTestShadow(t *testing.T) (*algorithms.ShadowLimiter, *store.MemoryStore, *metrics.Metrics, *simulation.ManualClock) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	clock := simulation.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	config := limiter.Config{Limit: 3, Window: time.Minute}
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+
+	sl := algorithms.NewShadowLimiter(
+		algorithms.NewFixedWindowCounter(s, config, algorithms.WithClock(clock)),
+		algorithms.NewSlidingWindowCounter(s, config, algorithms.WithClock(clock)),
+		algorithms.ShadowConfig{Primary: "fixed_window", Shadow: "sliding_window", Metrics: m},
+	)
+	return sl, s, m, clock
+}
+
+// storedWindows returns every window counted for key
+func storedWindows(t *testing.T, s *store.MemoryStore, key string) []limiter.Window {
+	t.Helper()
+	windows, err := s.GetWindows(key, time.Time{}, time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	return windows
+}
+
+func shadowDivergence(m *metrics.Metrics, allowed string) float64 {
+	return testutil.ToFloat64(m.ShadowDivergence.WithLabelValues("fixed_window", "sliding_window", allowed))
+}
+
+func TestShadowLimiter_CountsDivergence(t *testing.T) {
+	sl, _, m, clock := newTestShadow(t)
+
+	allowAll(t, sl, "user", 3)
+	allowed, _, err := sl.Allow("user")
+	require.NoError(t, err)
+	require.False(t, allowed)
+	assert.Zero(t, shadowDivergence(m, "primary"), "both deny a full window")
+
+	// The fixed window resets on the boundary; the sliding window still counts the burst
+	clock.Advance(time.Minute)
+	allowed, info, err := sl.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed, "the primary's decision is enforced")
+	assert.Equal(t, 2, info.Remaining, "and reported")
+	assert.Equal(t, float64(1), shadowDivergence(m, "primary"))
+	assert.Zero(t, shadowDivergence(m, "shadow"))
+}
+
+func TestShadowLimiter_NamespacesShadowKeys(t *testing.T) {
+	sl, s, _, _ := newTestShadow(t)
+
+	allowAll(t, sl, "user", 2)
+
+	windows := storedWindows(t, s, algorithms.ShadowKeyPrefix+"user")
+	require.Len(t, windows, 1)
+	assert.Equal(t, int64(2), windows[0].Count, "the shadow counted under its own key")
+
+	windows = storedWindows(t, s, "user")
+	require.Len(t, windows, 1)
+	assert.Equal(t, int64(2), windows[0].Count, "the primary's key holds only its own count")
+}
+
+func TestShadowLimiter_IgnoresShadowErrors(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	primary := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 1, Window: time.Minute})
+	sl := algorithms.NewShadowLimiter(primary, failingLimiter{}, algorithms.ShadowConfig{})
+
+	allowed, _, err := sl.Allow("user")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, _, err = sl.Allow("user")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestShadowLimiter_StatusDoesNotCompare(t *testing.T) {
+	sl, s, m, _ := newTestShadow(t)
+
+	_, _, err := sl.AllowN("user", 0)
+	require.NoError(t, err)
+
+	assert.Empty(t, storedWindows(t, s, algorithms.ShadowKeyPrefix+"user"))
+	assert.Zero(t, shadowDivergence(m, "primary")+shadowDivergence(m, "shadow"))
+}
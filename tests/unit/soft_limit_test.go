@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSoftLimitRouter(t *testing.T, softLimits handlers.SoftLimits, opts ...handlers.Option) (*gin.Engine, *handlers.RateLimitHandler, *metrics.Metrics) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	config := limiter.Config{Limit: 10, Window: time.Hour}
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket":       algorithms.NewTokenBucket(s, config),
+		"sliding_window_log": algorithms.NewSlidingWindowLog(s, config),
+		"fixed_window":       algorithms.NewFixedWindowCounter(s, config),
+	}
+	tiers := map[string]map[string]limiter.RateLimiter{
+		"premium": {"fixed_window": algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 100, Window: time.Hour})},
+	}
+
+	m := metrics.NewMetricsWithRegistry(prometheus.NewRegistry())
+	opts = append([]handlers.Option{handlers.WithSoftLimits(softLimits), handlers.WithTierLimiters(tiers)}, opts...)
+	h := handlers.NewRateLimitHandler(limiters, m, "fixed_window", opts...)
+	router := gin.New()
+	h.RegisterRoutes(router)
+	return router, h, m
+}
+
+// checkWarning runs a check and returns whether it was warned, by body and by header
+func checkWarning(t *testing.T, router *gin.Engine, payload map[string]interface{}) (bool, string) {
+	t.Helper()
+	w := doJSON(router, http.MethodPost, "/v1/check", payload)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Warning, w.Header().Get("X-RateLimit-Warning")
+}
+
+func TestCheck_WarnsPastSoftLimit(t *testing.T) {
+	for _, algorithm := range []string{"token_bucket", "sliding_window_log", "fixed_window"} {
+		t.Run(algorithm, func(t *testing.T) {
+			router, _, m := newSoftLimitRouter(t, handlers.SoftLimits{"": 80})
+			payload := map[string]interface{}{"resource": "api.export", "identifier": "alice", "algorithm": algorithm}
+
+			for i := 0; i < 7; i++ {
+				warning, header := checkWarning(t, router, payload)
+				require.False(t, warning, "check %d is below the soft limit", i+1)
+				require.Empty(t, header)
+			}
+
+			for i := 0; i < 3; i++ {
+				warning, header := checkWarning(t, router, payload)
+				assert.True(t, warning, "8 of 10 used is past 80%")
+				assert.NotEmpty(t, header)
+			}
+			assert.Equal(t, float64(1), testutil.ToFloat64(m.SoftLimit.WithLabelValues("api")),
+				"entering the warning zone is counted once")
+		})
+	}
+}
+
+func TestCheck_CountsLargeChecksCrossingSoftLimit(t *testing.T) {
+	router, _, m := newSoftLimitRouter(t, handlers.SoftLimits{"": 50})
+
+	warning, _ := checkWarning(t, router, map[string]interface{}{"resource": "api.export", "identifier": "alice", "algorithm": "token_bucket", "count": 9})
+	assert.True(t, warning)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.SoftLimit.WithLabelValues("api")))
+}
+
+func TestCheck_SoftLimitPerTier(t *testing.T) {
+	router, h, _ := newSoftLimitRouter(t, handlers.SoftLimits{"": 10})
+
+	warning, _ := checkWarning(t, router, map[string]interface{}{"resource": "api.export", "identifier": "alice", "tier": "premium"})
+	assert.False(t, warning, "tiers without a soft limit are not warned")
+
+	h.UpdateSoftLimits(handlers.SoftLimits{"premium": 1})
+	warning, _ = checkWarning(t, router, map[string]interface{}{"resource": "api.export", "identifier": "alice", "tier": "premium"})
+	assert.True(t, warning, "2 of 100 used is past 1%")
+	warning, _ = checkWarning(t, router, map[string]interface{}{"resource": "api.export", "identifier": "alice"})
+	assert.False(t, warning, "the default limits no longer have a soft limit")
+}
+
+func TestCheck_ReadOnlyIsNotSoftWarned(t *testing.T) {
+	router, _, m := newSoftLimitRouter(t, handlers.SoftLimits{"": 10}, handlers.WithReadOnly(true, handlers.BiasAllow))
+
+	payload := map[string]interface{}{"resource": "api.export", "identifier": "alice", "count": 5}
+	w := doJSON(router, http.MethodPost, "/v1/check", payload)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp.Warning)
+	assert.Contains(t, w.Header().Get("X-RateLimit-Warning"), "read-only")
+	assert.Zero(t, testutil.ToFloat64(m.SoftLimit.WithLabelValues("api")))
+}
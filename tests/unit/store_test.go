@@ -0,0 +1,208 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_ConsumeTokens(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	now := time.Unix(1000, 0)
+
+	// A new key starts with the initial tokens
+	allowed, tokens, _, err := s.ConsumeTokens("key", 3, 10, 2, 5, now)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 2.0, tokens)
+
+	allowed, tokens, retryAfter, err := s.ConsumeTokens("key", 3, 10, 2, 5, now)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 2.0, tokens)
+	assert.Equal(t, 500*time.Millisecond, retryAfter, "one token at 2/s")
+
+	// Refill is capped at capacity
+	allowed, tokens, _, err = s.ConsumeTokens("key", 1, 10, 2, 5, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 9.0, tokens)
+
+	// The stored state is what GetTokens reads back
+	stored, lastRefill, _, err := s.GetTokens("key")
+	require.NoError(t, err)
+	assert.Equal(t, 9.0, stored)
+	assert.True(t, lastRefill.Equal(now.Add(time.Hour)))
+}
+
+func TestMemoryStore_IncrementBy(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	window := time.Unix(1000, 0)
+
+	count, err := s.IncrementBy("key", window, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), count)
+
+	count, err = s.Increment("key", window)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), count)
+
+	windows, err := s.GetWindows("key", window, window)
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.Equal(t, int64(11), windows[0].Count)
+}
+
+func TestTokenBucket_MissingKeyStartsWithInitialTokens(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	_, _, found, err := s.GetTokens("user")
+	require.NoError(t, err)
+	assert.False(t, found, "a fresh key has no state")
+
+	clock := simulation.NewManualClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Minute, InitialFill: floatPtr(0.5)}, algorithms.WithClock(clock))
+
+	// A fresh key starts with its initial tokens, not with what a bucket refilling since
+	// the zero time would hold
+	info, err := tb.Status("user")
+	require.NoError(t, err)
+	assert.Equal(t, 5, info.Remaining)
+
+	// An empty bucket written just now stays empty
+	require.NoError(t, s.SetTokens("empty", 0, clockEpoch))
+	_, _, found, err = s.GetTokens("empty")
+	require.NoError(t, err)
+	assert.True(t, found)
+	allowed, _, err := tb.Allow("empty")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// After a reset the key is missing again and starts over
+	require.NoError(t, tb.Reset("empty"))
+	_, _, found, err = s.GetTokens("empty")
+	require.NoError(t, err)
+	assert.False(t, found)
+	allowed, info, err = tb.Allow("empty")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 4, info.Remaining)
+}
+
+func TestMemoryStore_KeyPrefix(t *testing.T) {
+	s := store.NewMemoryStore(store.WithKeyPrefix("prod:"))
+	defer s.Close()
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+
+	require.NoError(t, s.SetTokens("user", 3, now))
+	tokens, _, _, err := s.GetTokens("user")
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, tokens, "reads see the prefixed key they wrote")
+
+	// Listed keys come back as callers named them
+	policy := limiter.BanPolicy{Threshold: 0, Window: time.Minute, Duration: time.Minute}
+	_, err = s.RecordDenialCtx(ctx, "user", now, policy)
+	require.NoError(t, err)
+	bans, err := s.BansCtx(ctx, now)
+	require.NoError(t, err)
+	assert.Contains(t, bans, "user")
+
+	banned, err := s.UnbanCtx(ctx, "user", now)
+	require.NoError(t, err)
+	assert.True(t, banned)
+
+	require.NoError(t, s.SetLimitOverrideCtx(ctx, "user", limiter.LimitOverride{Limit: 7, Window: time.Minute}, now))
+	override, ok, err := s.LimitOverrideCtx(ctx, "user", now)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, 7, override.Limit)
+	deleted, err := s.DeleteLimitOverrideCtx(ctx, "user", now)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	require.NoError(t, s.Delete("user"))
+	_, lastRefill, _, err := s.GetTokens("user")
+	require.NoError(t, err)
+	assert.True(t, lastRefill.IsZero())
+}
+
+func TestTokenBucket_SharedStoreDoesNotOverAdmit(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	// Two limiters over one store stand in for two server instances sharing Redis
+	config := limiter.Config{Limit: 100, Window: time.Hour, Burst: 100}
+	instances := []*algorithms.TokenBucket{
+		algorithms.NewTokenBucket(s, config),
+		algorithms.NewTokenBucket(s, config),
+	}
+
+	var allowedCount int64
+	var wg sync.WaitGroup
+	for i := 0; i < 400; i++ {
+		wg.Add(1)
+		go func(tb *algorithms.TokenBucket) {
+			defer wg.Done()
+			if allowed, _, err := tb.Allow("shared-key"); err == nil && allowed {
+				atomic.AddInt64(&allowedCount, 1)
+			}
+		}(instances[i%2])
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(100), allowedCount)
+}
+
+func TestMemoryStore_DeletePrefix(t *testing.T) {
+	s := store.NewMemoryStore(store.WithKeyPrefix("prod:"))
+	defer s.Close()
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+
+	// State of every kind under the prefix, and keys that only look alike
+	_, err := s.Increment("tenant42:alice", now)
+	require.NoError(t, err)
+	require.NoError(t, s.SetTokens("tenant42:alice", 1, now))
+	require.NoError(t, s.AddTimestamps("tenant42:bob", now, 1, time.Minute))
+	for _, key := range []string{"tenant420:carol", "tenant7:alice", "leaky:tenant42:alice"} {
+		require.NoError(t, s.SetTokens(key, 1, now))
+	}
+
+	deleted, err := s.DeletePrefixCtx(ctx, "tenant42:")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	windows, err := s.GetWindows("tenant42:alice", now.Add(-time.Minute), now)
+	require.NoError(t, err)
+	assert.Empty(t, windows)
+	_, _, found, err := s.GetTokens("tenant42:alice")
+	require.NoError(t, err)
+	assert.False(t, found)
+	timestamps, err := s.GetTimestamps("tenant42:bob", now.Add(-time.Minute), now)
+	require.NoError(t, err)
+	assert.Empty(t, timestamps)
+
+	for _, key := range []string{"tenant420:carol", "tenant7:alice", "leaky:tenant42:alice"} {
+		_, _, found, err := s.GetTokens(key)
+		require.NoError(t, err)
+		assert.True(t, found, key)
+	}
+
+	_, err = s.DeletePrefixCtx(ctx, "")
+	assert.ErrorIs(t, err, limiter.ErrEmptyPrefix)
+}
@@ -0,0 +1,247 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/limitertest"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sharedMemory stands in for Redis as the L2 of tiered stores, adding the log check Redis
+// runs as a script (not atomic here)
+type sharedMemory struct {
+	*store.MemoryStore
+}
+
+func (sm sharedMemory) AllowLogCtx(ctx context.Context, key string, now time.Time, window time.Duration, limit, n int) (bool, []time.Time, error) {
+	if err := sm.TrimTimestampsCtx(ctx, key, now.Add(-window)); err != nil {
+		return false, nil, err
+	}
+	entries, err := sm.GetTimestampsCtx(ctx, key, now.Add(-window), now)
+	if err != nil || len(entries)+n > limit {
+		return false, entries, err
+	}
+	if err := sm.AddTimestampsCtx(ctx, key, now, n, window); err != nil {
+		return false, nil, err
+	}
+	entries, err = sm.GetTimestampsCtx(ctx, key, now.Add(-window), now)
+	return true, entries, err
+}
+
+// newTieredPair returns two tiered stores, as on two instances, sharing one L2
+func newTieredPair(t *testing.T, syncInterval time.Duration) (*store.TieredStore, *store.TieredStore, sharedMemory) {
+	t.Helper()
+	l2 := sharedMemory{store.NewMemoryStore()}
+	a := store.NewTieredStore(store.NewMemoryStore(), l2, syncInterval)
+	b := store.NewTieredStore(store.NewMemoryStore(), l2, syncInterval)
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return a, b, l2
+}
+
+// noRefill is a refill rate too slow to matter within a test at a fixed now
+const noRefill = 1e-9
+
+func TestTieredStore_TokensServedFromL1UntilSync(t *testing.T) {
+	a, b, l2 := newTieredPair(t, time.Hour)
+	ctx := context.Background()
+
+	// The first step runs in L2, later ones only in L1
+	for i := 0; i < 3; i++ {
+		allowed, _, _, err := a.ConsumeTokensCtx(ctx, "user", 1, 10, noRefill, 10, clockEpoch)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+	tokens, _, _, err := l2.GetTokens("user")
+	require.NoError(t, err)
+	assert.InDelta(t, 9, tokens, 0.01)
+
+	// Sync pushes the local steps
+	require.NoError(t, a.Sync(ctx))
+	tokens, _, _, err = l2.GetTokens("user")
+	require.NoError(t, err)
+	assert.InDelta(t, 7, tokens, 0.01)
+
+	// Another instance's steps reach a after its next sync
+	_, tokens, _, err = b.ConsumeTokensCtx(ctx, "user", 2, 10, noRefill, 10, clockEpoch)
+	require.NoError(t, err)
+	assert.InDelta(t, 5, tokens, 0.01)
+
+	tokens, _, _, err = a.GetTokensCtx(ctx, "user")
+	require.NoError(t, err)
+	assert.InDelta(t, 7, tokens, 0.01, "stale until sync")
+	require.NoError(t, a.Sync(ctx))
+	tokens, _, _, err = a.GetTokensCtx(ctx, "user")
+	require.NoError(t, err)
+	assert.InDelta(t, 5, tokens, 0.01)
+}
+
+func TestTieredStore_OvershootBecomesDebt(t *testing.T) {
+	a, b, l2 := newTieredPair(t, time.Hour)
+	ctx := context.Background()
+
+	// Each instance's first step runs in L2, then each spends what it saw locally
+	admitted := 0
+	for _, s := range []*store.TieredStore{a, b} {
+		_, _, _, err := s.ConsumeTokensCtx(ctx, "user", 1, 5, noRefill, 5, clockEpoch)
+		require.NoError(t, err)
+		admitted++
+	}
+	for _, s := range []*store.TieredStore{a, b} {
+		for {
+			allowed, _, _, err := s.ConsumeTokensCtx(ctx, "user", 1, 5, noRefill, 5, clockEpoch)
+			require.NoError(t, err)
+			if !allowed {
+				break
+			}
+			admitted++
+		}
+	}
+	assert.Equal(t, 9, admitted, "a saw 4 tokens left and b 3")
+
+	require.NoError(t, a.Sync(ctx))
+	require.NoError(t, b.Sync(ctx))
+	tokens, _, _, err := l2.GetTokens("user")
+	require.NoError(t, err)
+	// a's overshoot is owed; b's arrives while L2 is already in debt and is forgiven
+	assert.InDelta(t, -1, tokens, 0.01)
+
+	require.NoError(t, a.Sync(ctx))
+	allowed, _, _, err := a.ConsumeTokensCtx(ctx, "user", 1, 5, noRefill, 5, clockEpoch)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestTieredStore_IdleBucketsAreDropped(t *testing.T) {
+	a, _, l2 := newTieredPair(t, time.Hour)
+	ctx := context.Background()
+
+	_, _, _, err := a.ConsumeTokensCtx(ctx, "user", 1, 10, noRefill, 10, clockEpoch)
+	require.NoError(t, err)
+	require.NoError(t, a.Sync(ctx))
+	require.NoError(t, a.Sync(ctx)) // Not stepped since the last sync
+
+	// Uncached again, so the next step runs in L2
+	_, _, _, err = a.ConsumeTokensCtx(ctx, "user", 1, 10, noRefill, 10, clockEpoch)
+	require.NoError(t, err)
+	tokens, _, _, err := l2.GetTokens("user")
+	require.NoError(t, err)
+	assert.InDelta(t, 8, tokens, 0.01)
+}
+
+func TestTieredStore_IncrementWritesThrough(t *testing.T) {
+	a, b, l2 := newTieredPair(t, time.Hour)
+	ctx := context.Background()
+	window := clockEpoch.Truncate(time.Minute)
+
+	// Windows read once are served from L1, with this instance's own increments patched in
+	windows, err := a.GetWindowsCtx(ctx, "user", window, clockEpoch)
+	require.NoError(t, err)
+	assert.Empty(t, windows)
+
+	count, err := a.IncrementCtx(ctx, "user", window)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	count, err = b.IncrementCtx(ctx, "user", window)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count, "counts are exact across instances")
+
+	l2Windows, err := l2.GetWindows("user", window, clockEpoch)
+	require.NoError(t, err)
+	require.Len(t, l2Windows, 1)
+	assert.EqualValues(t, 2, l2Windows[0].Count)
+
+	windows, err = a.GetWindowsCtx(ctx, "user", window, clockEpoch)
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.EqualValues(t, 1, windows[0].Count, "b's increment is not seen within the staleness window")
+}
+
+func TestTieredStore_WindowReadsExpireAfterSyncInterval(t *testing.T) {
+	a, b, _ := newTieredPair(t, 20*time.Millisecond)
+	ctx := context.Background()
+	window := clockEpoch.Truncate(time.Minute)
+
+	_, err := a.GetWindowsCtx(ctx, "user", window, clockEpoch)
+	require.NoError(t, err)
+	_, err = b.IncrementCtx(ctx, "user", window)
+	require.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+	windows, err := a.GetWindowsCtx(ctx, "user", window, clockEpoch)
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.EqualValues(t, 1, windows[0].Count)
+}
+
+func TestTieredStore_DeleteAndCloseReachL2(t *testing.T) {
+	a, _, l2 := newTieredPair(t, time.Hour)
+	ctx := context.Background()
+
+	_, _, _, err := a.ConsumeTokensCtx(ctx, "user", 1, 10, noRefill, 10, clockEpoch)
+	require.NoError(t, err)
+	require.NoError(t, a.DeleteCtx(ctx, "user"))
+	_, lastRefill, _, err := l2.GetTokens("user")
+	require.NoError(t, err)
+	assert.True(t, lastRefill.IsZero())
+
+	// Steps not yet synced are pushed on Close
+	for i := 0; i < 4; i++ {
+		_, _, _, err := a.ConsumeTokensCtx(ctx, "user", 1, 10, noRefill, 10, clockEpoch)
+		require.NoError(t, err)
+	}
+	require.NoError(t, a.Close())
+	tokens, _, _, err := l2.GetTokens("user")
+	require.NoError(t, err)
+	assert.InDelta(t, 6, tokens, 0.01)
+}
+
+func TestTieredStore_DeletePrefixDropsL1(t *testing.T) {
+	a, _, l2 := newTieredPair(t, time.Hour)
+	ctx := context.Background()
+
+	for _, key := range []string{"tenant42:alice", "tenant7:alice"} {
+		_, _, _, err := a.ConsumeTokensCtx(ctx, key, 4, 10, noRefill, 10, clockEpoch)
+		require.NoError(t, err)
+	}
+
+	deleted, err := a.DeletePrefixCtx(ctx, "tenant42:")
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	// The next step starts from a full bucket, not the cached one
+	_, tokens, _, err := a.ConsumeTokensCtx(ctx, "tenant42:alice", 1, 10, noRefill, 10, clockEpoch)
+	require.NoError(t, err)
+	assert.InDelta(t, 9, tokens, 0.01)
+	_, tokens, _, err = a.ConsumeTokensCtx(ctx, "tenant7:alice", 1, 10, noRefill, 10, clockEpoch)
+	require.NoError(t, err)
+	assert.InDelta(t, 5, tokens, 0.01)
+
+	_, _, found, err := l2.GetTokens("tenant7:alice")
+	require.NoError(t, err)
+	assert.True(t, found)
+}
+
+func TestTieredStore_TokenBucket(t *testing.T) {
+	a, _, _ := newTieredPair(t, time.Hour)
+	clock := limitertest.NewFakeClock(clockEpoch)
+	tb := algorithms.NewTokenBucket(a, limiter.Config{Limit: 3, Window: time.Hour}, algorithms.WithClock(clock))
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := tb.Allow("user")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, info, err := tb.Allow("user")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.NotNil(t, info.RetryAfter)
+}
@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTieredRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+
+	tier := func(limit int) map[string]limiter.RateLimiter {
+		return map[string]limiter.RateLimiter{
+			"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{Limit: limit, Window: time.Hour, Burst: limit}),
+			"fixed_window": algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: limit, Window: time.Hour}),
+		}
+	}
+
+	router, _ := newTestRouter(t, handlers.WithTierLimiters(map[string]map[string]limiter.RateLimiter{
+		"free":    tier(2),
+		"premium": tier(5),
+	}))
+	return router
+}
+
+func checkTier(t *testing.T, router http.Handler, identifier, tier string) (int, http.Header, handlers.CheckResponse) {
+	t.Helper()
+
+	w := doJSON(router, http.MethodPost, "/v1/check", map[string]interface{}{
+		"resource":   "api.search",
+		"identifier": identifier,
+		"algorithm":  "fixed_window",
+		"tier":       tier,
+	})
+
+	var resp handlers.CheckResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, w.Header(), resp
+}
+
+func TestCheck_TiersSelectLimits(t *testing.T) {
+	router := newTieredRouter(t)
+
+	tests := []struct {
+		tier    string
+		limit   int
+		applied string
+	}{
+		{tier: "free", limit: 2, applied: "free"},
+		{tier: "premium", limit: 5, applied: "premium"},
+		{tier: "", limit: 100, applied: ""},
+		{tier: "enterprise", limit: 100, applied: ""}, // Unknown tiers fall back to the default limits
+	}
+
+	for _, tt := range tests {
+		t.Run("tier="+tt.tier, func(t *testing.T) {
+			identifier := "user-" + tt.tier
+			for i := 0; i < tt.limit; i++ {
+				code, header, resp := checkTier(t, router, identifier, tt.tier)
+				require.Equal(t, http.StatusOK, code, "request %d", i)
+				assert.Equal(t, tt.applied, resp.Tier)
+				assert.Equal(t, tt.limit, resp.Limit)
+				assert.Equal(t, strconv.Itoa(tt.limit), header.Get("X-RateLimit-Limit"))
+			}
+
+			code, header, _ := checkTier(t, router, identifier, tt.tier)
+			assert.Equal(t, http.StatusTooManyRequests, code)
+			assert.Equal(t, "0", header.Get("X-RateLimit-Remaining"))
+		})
+	}
+}
+
+func TestPolicies_ResolveTier(t *testing.T) {
+	router := newTieredRouter(t)
+
+	policy := getPolicy(t, router, "resource=api.search&algorithm=fixed_window&tier=premium")
+	assert.Equal(t, "premium", policy.Tier)
+	assert.Equal(t, 5, policy.Limit)
+	assert.Equal(t, time.Hour.String(), policy.Window)
+
+	// Unknown tiers document the default limits, as checks enforce them
+	policy = getPolicy(t, router, "resource=api.search&algorithm=fixed_window&tier=enterprise")
+	assert.Empty(t, policy.Tier)
+	assert.Equal(t, 100, policy.Limit)
+
+	_, _, resp := checkTier(t, router, "parity", "enterprise")
+	assert.Equal(t, policy.Limit, resp.Limit)
+}
+
+func TestCheck_ReportsUsedWindowAndPolicy(t *testing.T) {
+	router := newTieredRouter(t)
+
+	code, header, resp := checkTier(t, router, "alice", "free")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, resp.Used)
+	assert.Equal(t, 3600, resp.Window)
+	assert.Equal(t, "free", resp.Policy)
+	assert.Equal(t, "1", header.Get("X-RateLimit-Used"))
+	assert.Equal(t, "3600", header.Get("X-RateLimit-Window"))
+
+	// The default limits have no policy name
+	_, header, resp = checkTier(t, router, "bob", "")
+	assert.Equal(t, resp.Limit, resp.Used+resp.Remaining)
+	assert.Empty(t, resp.Policy)
+	assert.Equal(t, "60", header.Get("X-RateLimit-Window"))
+}
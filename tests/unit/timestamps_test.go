@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ limiter.TimestampCounter = (*store.MemoryStore)(nil)
+	_ limiter.TimestampCounter = (*store.RedisStore)(nil)
+)
+
+func TestMemoryStore_CountTimestampsTrims(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	ctx := context.Background()
+	t0 := time.Unix(1000, 0)
+
+	require.NoError(t, s.AddTimestamps("user", t0, 3, time.Minute))
+	require.NoError(t, s.AddTimestamps("user", t0.Add(time.Second), 2, time.Minute))
+	require.NoError(t, s.AddTimestamps("user", t0.Add(2*time.Second), 1, time.Minute))
+
+	count, err := s.CountTimestampsCtx(ctx, "user", t0.Add(time.Second), t0.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count, "both bounds are inclusive and later entries are not counted")
+
+	// The entries before from are gone, not just uncounted
+	timestamps, err := s.GetTimestamps("user", t0.Add(-time.Hour), t0.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, timestamps, 3)
+
+	count, err = s.CountTimestampsCtx(ctx, "user", t0, t0.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	count, err = s.CountTimestampsCtx(ctx, "missing", t0, t0.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestMemoryStore_TimestampLogExpires(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	ctx := context.Background()
+	t0 := time.Unix(1000, 0)
+
+	require.NoError(t, s.AddTimestamps("user", t0, 2, 20*time.Millisecond))
+	count, err := s.CountTimestampsCtx(ctx, "user", t0, t0)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	time.Sleep(40 * time.Millisecond)
+
+	count, err = s.CountTimestampsCtx(ctx, "user", t0, t0)
+	require.NoError(t, err)
+	assert.Zero(t, count, "the log expired without new entries")
+	timestamps, err := s.GetTimestamps("user", t0, t0)
+	require.NoError(t, err)
+	assert.Empty(t, timestamps)
+
+	// A new entry starts a fresh log rather than reviving the expired one
+	require.NoError(t, s.AddTimestamps("user", t0, 1, time.Minute))
+	count, err = s.CountTimestampsCtx(ctx, "user", t0, t0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMemoryStore_TimestampLogTTLRefreshes(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	ctx := context.Background()
+
+	// Timestamps from a simulated clock long past still live for ttl of wall time
+	t0 := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, s.AddTimestamps("user", t0, 1, 60*time.Millisecond))
+	time.Sleep(40 * time.Millisecond)
+	require.NoError(t, s.AddTimestamps("user", t0.Add(time.Second), 1, 60*time.Millisecond))
+	time.Sleep(40 * time.Millisecond)
+
+	count, err := s.CountTimestampsCtx(ctx, "user", t0, t0.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count, "each add extends the log's lifetime")
+}
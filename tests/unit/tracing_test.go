@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecordedTracer(t *testing.T) (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return provider, recorder
+}
+
+// spanAttribute returns the value of the named attribute on span
+func spanAttribute(span sdktrace.ReadOnlySpan, name string) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == name {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestWithTracer_SpansAllowNAndStoreCalls(t *testing.T) {
+	provider, recorder := newRecordedTracer(t)
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	fwc := algorithms.NewFixedWindowCounter(s, limiter.Config{Limit: 1, Window: time.Hour},
+		algorithms.WithTracer(provider.Tracer("test")))
+
+	for _, want := range []bool{true, false} {
+		allowed, _, err := fwc.AllowN("user:alice", 1)
+		require.NoError(t, err)
+		require.Equal(t, want, allowed)
+	}
+
+	var allows []sdktrace.ReadOnlySpan
+	children := map[string]int{}
+	for _, span := range recorder.Ended() {
+		if span.Name() == "limiter.AllowN" {
+			allows = append(allows, span)
+			continue
+		}
+		children[span.Name()]++
+		assert.True(t, span.Parent().IsValid(), "%s is a child span", span.Name())
+		storeType, _ := spanAttribute(span, "store")
+		assert.Equal(t, "memory", storeType.AsString())
+	}
+
+	require.Len(t, allows, 2)
+	for i, want := range []bool{true, false} {
+		allowed, ok := spanAttribute(allows[i], "allowed")
+		require.True(t, ok)
+		assert.Equal(t, want, allowed.AsBool())
+		algorithm, _ := spanAttribute(allows[i], "algorithm")
+		assert.Equal(t, "fixed_window", algorithm.AsString())
+		prefix, _ := spanAttribute(allows[i], "key_prefix")
+		assert.Equal(t, "user", prefix.AsString())
+		n, _ := spanAttribute(allows[i], "n")
+		assert.Equal(t, int64(1), n.AsInt64())
+	}
+	assert.Equal(t, map[string]int{"store.GetWindows": 2, "store.Increment": 1}, children)
+}
+
+func TestWithTracer_StoreCallsUntracedWithoutTracer(t *testing.T) {
+	_, recorder := newRecordedTracer(t)
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	_, _, err := algorithms.NewTokenBucket(s, limiter.Config{Limit: 1, Window: time.Hour}).AllowN("user", 1)
+	require.NoError(t, err)
+	assert.Empty(t, recorder.Ended())
+}
+
+func TestHandlerWithTracer_ContinuesIncomingTrace(t *testing.T) {
+	provider, recorder := newRecordedTracer(t)
+	tracer := provider.Tracer("test")
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	limiters := map[string]limiter.RateLimiter{
+		"token_bucket": algorithms.NewTokenBucket(s, limiter.Config{Limit: 10, Window: time.Minute}, algorithms.WithTracer(tracer)),
+	}
+	h := handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "token_bucket",
+		handlers.WithTracer(tracer))
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/v1/check", strings.NewReader(`{"resource":"api.export","identifier":"alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	names := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		names[span.Name()] = true
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String(), "%s continues the incoming trace", span.Name())
+	}
+	assert.True(t, names["POST /v1/check"])
+	assert.True(t, names["limiter.AllowN"])
+	assert.True(t, names["store.ConsumeTokens"])
+}
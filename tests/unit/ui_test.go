@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testUI = handlers.UIConfig{Username: "ops", Password: "secret"}
+
+func getUI(router *gin.Engine, path string, auth bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if auth {
+		req.SetBasicAuth(testUI.Username, testUI.Password)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUI_DisabledByDefault(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	assert.Equal(t, http.StatusNotFound, getUI(router, "/ui", true).Code)
+}
+
+func TestUI_RequiresCredentials(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithUI(testUI))
+
+	assert.Equal(t, http.StatusUnauthorized, getUI(router, "/ui", false).Code)
+	assert.Equal(t, http.StatusUnauthorized, getUI(router, "/ui/static/ui.js", false).Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/ui", nil)
+	req.SetBasicAuth(testUI.Username, "wrong")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestUI_PageRenders(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithUI(testUI))
+
+	w := getUI(router, "/ui", true)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+
+	body := w.Body.String()
+	assert.Contains(t, body, `<option value="fixed_window">fixed_window</option>`)
+	assert.Contains(t, body, `<option value="token_bucket" selected>token_bucket</option>`, "the default algorithm is preselected")
+	assert.Contains(t, body, `data-history=""`, "no history lookups when history is disabled")
+}
+
+func TestUI_FormsTargetAPI(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithUI(testUI))
+	body := getUI(router, "/ui", true).Body.String()
+
+	// Each form is a client of an existing /v1 route, which RegisterRoutes must serve
+	targets := []struct {
+		markup string
+		method string
+		path   string
+	}{
+		{markup: `method="get" action="/v1/policies"`, method: http.MethodGet, path: "/v1/policies?resource=api.users"},
+		{markup: `data-status="/v1/status/"`, method: http.MethodGet, path: "/v1/status/alice:api.users?algorithm=all"},
+		{markup: `data-endpoint="/v1/reset/"`, method: http.MethodPost, path: "/v1/reset/alice:api.users"},
+		{markup: `data-endpoint="/v1/read-only"`, method: http.MethodGet, path: "/v1/read-only"},
+	}
+
+	for _, tt := range targets {
+		t.Run(tt.markup, func(t *testing.T) {
+			assert.Contains(t, body, tt.markup)
+			assert.Equal(t, http.StatusOK, doJSON(router, tt.method, tt.path, nil).Code)
+		})
+	}
+}
+
+func TestUI_ServesEmbeddedAssets(t *testing.T) {
+	router, _ := newTestRouter(t, handlers.WithUI(testUI))
+
+	for _, path := range []string{"/ui/static/ui.js", "/ui/static/ui.css"} {
+		w := getUI(router, path, true)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.NotEmpty(t, w.Body.String(), path)
+	}
+}
+
+func TestUIConfig_Validate(t *testing.T) {
+	assert.NoError(t, testUI.Validate())
+	assert.Error(t, handlers.UIConfig{Username: "ops"}.Validate(), "a page without a password is not gated")
+}
@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/handlers"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/metrics"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsage_Algorithms(t *testing.T) {
+	config := limiter.Config{Limit: 10, Window: 10 * time.Second}
+	start := clockEpoch.Add(2 * time.Second)
+
+	tests := []struct {
+		name        string
+		new         func(s limiter.Store, clock limiter.Clock) limiter.RateLimiter
+		used        float64
+		windowStart time.Time
+		windowEnd   time.Time
+	}{
+		{"fixed window", func(s limiter.Store, clock limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewFixedWindowCounter(s, config, algorithms.WithClock(clock))
+		}, 3, clockEpoch, clockEpoch.Add(10 * time.Second)},
+		{"sliding window", func(s limiter.Store, clock limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewSlidingWindowCounter(s, config, algorithms.WithClock(clock))
+		}, 3, start.Add(500*time.Millisecond - 10*time.Second), start.Add(500 * time.Millisecond)},
+		// One token refills per second, so half a second later 2.5 are still drawn and the
+		// bucket is full again two and a half seconds after that
+		{"token bucket", func(s limiter.Store, clock limiter.Clock) limiter.RateLimiter {
+			return algorithms.NewTokenBucket(s, config, algorithms.WithClock(clock))
+		}, 2.5, start.Add(3*time.Second - 10*time.Second), start.Add(3 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			defer s.Close()
+			clock := simulation.NewManualClock(start)
+			rl := tt.new(s, clock)
+
+			reporter, ok := rl.(limiter.UsageReporter)
+			require.True(t, ok)
+
+			usage, err := reporter.Usage(context.Background(), "user")
+			require.NoError(t, err)
+			assert.Zero(t, usage.Used, "an unseen key has used nothing")
+
+			allowed, _, err := rl.AllowN("user", 3)
+			require.NoError(t, err)
+			require.True(t, allowed)
+			clock.Advance(500 * time.Millisecond)
+
+			usage, err = reporter.Usage(context.Background(), "user")
+			require.NoError(t, err)
+			assert.InDelta(t, tt.used, usage.Used, 1e-9)
+			assert.Equal(t, 10, usage.Limit)
+			assert.True(t, tt.windowStart.Equal(usage.WindowStart), "window start %v", usage.WindowStart)
+			assert.True(t, tt.windowEnd.Equal(usage.WindowEnd), "window end %v", usage.WindowEnd)
+
+			// Reporting usage consumes nothing
+			info, err := rl.Status("user")
+			require.NoError(t, err)
+			assert.Equal(t, 7, info.Remaining)
+		})
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	s := store.NewMemoryStore()
+	t.Cleanup(func() { s.Close() })
+	clock := simulation.NewManualClock(clockEpoch)
+	config := limiter.Config{Limit: 10, Window: time.Minute}
+
+	fixed := algorithms.NewFixedWindowCounter(s, config, algorithms.WithClock(clock))
+	limiters := map[string]limiter.RateLimiter{
+		"fixed_window": fixed,
+		// Wrapped limiters that do not report usage fall back to their status
+		"fail_open": algorithms.NewFailOpenLimiter(fixed, algorithms.FailOpenConfig{}),
+	}
+	router := gin.New()
+	handlers.NewRateLimitHandler(limiters, metrics.NewMetricsWithRegistry(prometheus.NewRegistry()), "fixed_window").RegisterRoutes(router)
+
+	allowed, _, err := fixed.AllowN("alice:api", 4)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	for _, algorithm := range []string{"fixed_window", "fail_open"} {
+		w := doJSON(router, http.MethodGet, "/v1/usage/alice:api?algorithm="+algorithm, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp handlers.UsageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "alice:api", resp.Key)
+		assert.Equal(t, algorithm, resp.Algorithm)
+		assert.Equal(t, 4.0, resp.Used)
+		assert.Equal(t, 10, resp.Limit)
+		assert.Equal(t, "2024-01-01T00:00:00Z", resp.WindowStart)
+		assert.Equal(t, "2024-01-01T00:01:00Z", resp.WindowEnd)
+	}
+
+	w := doJSON(router, http.MethodGet, "/v1/usage/alice:api?algorithm=nope", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
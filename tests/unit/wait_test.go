@@ -0,0 +1,173 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/algorithms"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ten per second with a burst of one, so each further token takes 100ms
+var tenPerSecond = limiter.Config{Limit: 10, Window: time.Second, Burst: 1}
+
+func TestTokenBucket_ImplementsWaiter(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	var _ limiter.Waiter = algorithms.NewTokenBucket(s, tenPerSecond)
+}
+
+func TestTokenBucket_WaitNReturnsPromptlyWithTokens(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	tb := algorithms.NewTokenBucket(s, tenPerSecond)
+
+	start := time.Now()
+	require.NoError(t, tb.WaitN(context.Background(), "alice", 1))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	allowed, _, err := tb.Peek(context.Background(), "alice", 1)
+	require.NoError(t, err)
+	assert.False(t, allowed, "the wait consumed the token")
+}
+
+func TestTokenBucket_WaitNBlocksUntilRefill(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	tb := algorithms.NewTokenBucket(s, tenPerSecond)
+
+	require.NoError(t, tb.WaitN(context.Background(), "bob", 1))
+
+	start := time.Now()
+	require.NoError(t, tb.WaitN(context.Background(), "bob", 1))
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 80*time.Millisecond)
+	assert.Less(t, elapsed, 300*time.Millisecond)
+}
+
+func TestTokenBucket_WaitNRespectsContext(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	tb := algorithms.NewTokenBucket(s, limiter.Config{Limit: 1, Window: time.Minute, Burst: 1})
+	require.NoError(t, tb.WaitN(context.Background(), "carol", 1))
+
+	t.Run("deadline before refill", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		start := time.Now()
+		err := tb.WaitN(ctx, "carol", 1)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, time.Since(start), 50*time.Millisecond, "gives up without sleeping")
+	})
+
+	t.Run("canceled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		err := tb.WaitN(ctx, "carol", 1)
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("more than capacity", func(t *testing.T) {
+		assert.Error(t, tb.WaitN(context.Background(), "carol", 2))
+	})
+}
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	clock := simulation.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tb := algorithms.NewTokenBucket(s, tenPerSecond, algorithms.WithClock(clock))
+
+	first, err := tb.Reserve("dave", 1)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), first.Delay())
+
+	second, err := tb.Reserve("dave", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, second.Delay())
+
+	// Later checks wait behind the reservation
+	allowed, info, err := tb.Peek(context.Background(), "dave", 1)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 200*time.Millisecond, *info.RetryAfter)
+
+	clock.Advance(40 * time.Millisecond)
+	assert.Equal(t, 60*time.Millisecond, second.Delay())
+
+	require.NoError(t, second.Cancel())
+	require.NoError(t, second.Cancel(), "canceling twice is harmless")
+
+	allowed, info, err = tb.Peek(context.Background(), "dave", 1)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 60*time.Millisecond, *info.RetryAfter, "only the first reservation is still held")
+
+	clock.Advance(60 * time.Millisecond)
+	allowed, _, err = tb.Allow("dave")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestTokenBucket_ReserveRejects(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	_, err := algorithms.NewTokenBucket(s, tenPerSecond).Reserve("erin", 2)
+	assert.Error(t, err, "more than capacity can never be reserved")
+
+	fixed := limiter.Config{Limit: 10, Window: time.Second, Precision: limiter.PrecisionFixed}
+	_, err = algorithms.NewTokenBucket(s, fixed).Reserve("erin", 1)
+	assert.Error(t, err)
+}
+
+func TestTokenBucket_WaitConsumesOneToken(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	tb := algorithms.NewTokenBucket(s, tenPerSecond)
+
+	require.NoError(t, tb.Wait(context.Background(), "frank"))
+	allowed, _, err := tb.Peek(context.Background(), "frank", 1)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestTokenBucket_MaxWait(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+	clock := simulation.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tb := algorithms.NewTokenBucket(s, tenPerSecond, algorithms.WithClock(clock), algorithms.WithMaxWait(150*time.Millisecond))
+
+	// One token in the bucket and one reserved ahead: the next waits 200ms
+	require.NoError(t, tb.Wait(context.Background(), "gina"))
+	reservation, err := tb.Reserve("gina", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, reservation.Delay(), "within the max wait")
+
+	start := time.Now()
+	err = tb.Wait(context.Background(), "gina")
+	var exceeded *algorithms.ErrMaxWaitExceeded
+	require.True(t, errors.As(err, &exceeded), "got %v", err)
+	assert.Equal(t, 200*time.Millisecond, exceeded.Wait)
+	assert.Equal(t, 150*time.Millisecond, exceeded.MaxWait)
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "gives up without sleeping")
+
+	_, err = tb.Reserve("gina", 1)
+	require.True(t, errors.As(err, &exceeded), "got %v", err)
+
+	// Rejected reservations take nothing
+	require.NoError(t, reservation.Cancel())
+	clock.Advance(100 * time.Millisecond)
+	allowed, _, err := tb.Allow("gina")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
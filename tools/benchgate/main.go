@@ -0,0 +1,116 @@
+// Command benchgate runs the hot-path benchmarks and fails if any regressed
+// against the committed baseline beyond the tolerance
+//
+//	go run ./tools/benchgate
+//	go run ./tools/benchgate -update   # deliberately accept the current numbers
+//
+// Run it from the module root
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/benchgate"
+)
+
+// gated is the curated subset: Allow per algorithm, memory store primitives, and the check handler
+var gated = []string{
+	"BenchmarkTokenBucket",
+	"BenchmarkSlidingWindowCounter",
+	"BenchmarkFixedWindowCounter",
+	"BenchmarkSlidingWindowLog",
+	"BenchmarkLeakyBucket",
+	"BenchmarkGCRA",
+	"BenchmarkMemoryStoreIncrement",
+	"BenchmarkMemoryStoreGetWindows",
+	"BenchmarkMemoryStoreSetGetTokens",
+	"BenchmarkCheckHandler",
+}
+
+func main() {
+	baselinePath := flag.String("baseline", "tools/benchgate/baseline.json", "Baseline JSON to compare against")
+	pkg := flag.String("pkg", "./tests/benchmark", "Package containing the benchmarks")
+	procs := flag.Int("gomaxprocs", 1, "GOMAXPROCS pinned for every run")
+	count := flag.Int("count", 8, "Iterations per benchmark; outliers are rejected before taking the median")
+	benchtime := flag.String("benchtime", "200ms", "Duration or iterations per benchmark run")
+	nsTolerance := flag.Float64("tolerance", 0.15, "Allowed relative ns/op increase")
+	allocTolerance := flag.Float64("alloc-tolerance", 0, "Allowed relative allocs/op increase")
+	update := flag.Bool("update", false, "Write the current results as the new baseline instead of comparing")
+	verbose := flag.Bool("v", false, "Echo go test output")
+	flag.Parse()
+
+	output, err := runBenchmarks(*pkg, *procs, *count, *benchtime, *verbose)
+	if err != nil {
+		log.Fatalf("Benchmarks failed: %v", err)
+	}
+
+	samples, err := benchgate.Parse(bytes.NewReader(output))
+	if err != nil {
+		log.Fatalf("Failed to parse benchmark output: %v", err)
+	}
+	current := benchgate.Summarize(samples)
+
+	if *update {
+		baseline := benchgate.Baseline{GOMAXPROCS: *procs, Benchmarks: current}
+		if err := benchgate.WriteBaseline(*baselinePath, baseline); err != nil {
+			log.Fatalf("Failed to write baseline: %v", err)
+		}
+		fmt.Printf("Updated %s with %d benchmarks\n", *baselinePath, len(current))
+		return
+	}
+
+	baseline, err := benchgate.LoadBaseline(*baselinePath)
+	if err != nil {
+		log.Fatalf("Failed to load baseline: %v", err)
+	}
+	if baseline.GOMAXPROCS != *procs {
+		log.Fatalf("Baseline was recorded with GOMAXPROCS=%d, not %d", baseline.GOMAXPROCS, *procs)
+	}
+
+	for _, name := range benchgate.Added(baseline, current) {
+		fmt.Printf("new benchmark not in baseline: %s\n", name)
+	}
+
+	regressions := benchgate.Compare(baseline, current, benchgate.Tolerance{NsPerOp: *nsTolerance, AllocsPerOp: *allocTolerance})
+	if len(regressions) == 0 {
+		fmt.Printf("No regressions across %d benchmarks\n", len(baseline.Benchmarks))
+		return
+	}
+
+	fmt.Printf("%d regressions:\n", len(regressions))
+	for _, r := range regressions {
+		fmt.Printf("  %s\n", r)
+	}
+	os.Exit(1)
+}
+
+// runBenchmarks runs the gated benchmarks with GOMAXPROCS pinned and returns the go test output
+func runBenchmarks(pkg string, procs, count int, benchtime string, verbose bool) ([]byte, error) {
+	cmd := exec.Command("go", "test", "-run", "^$",
+		"-bench", benchgate.Pattern(gated),
+		"-benchmem",
+		"-count", strconv.Itoa(count),
+		"-cpu", strconv.Itoa(procs),
+		"-benchtime", benchtime,
+		pkg)
+	cmd.Env = append(os.Environ(), "GOMAXPROCS="+strconv.Itoa(procs))
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if verbose {
+		cmd.Stdout = io.MultiWriter(&out, os.Stdout)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,111 @@
+// Command compare replays a workload against two (algorithm, store) pairs and
+// reports where their decisions diverge, to dry-run a migration before cutover
+//
+//	go run ./tools/compare -spec workload.json -a fixed_window -b sliding_window -limit 100 -window 1m
+//	go run ./tools/compare -replay requests.jsonl -a token_bucket -b token_bucket -b-store redis
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/compare"
+	"github.com/AbubakarMahmood1/go-rate-limiter/internal/simulation"
+	"github.com/AbubakarMahmood1/go-rate-limiter/pkg/limiter"
+)
+
+func main() {
+	specFile := flag.String("spec", "", "Synthetic workload spec (JSON)")
+	replayFile := flag.String("replay", "", "Replay log, one JSON event per line")
+	start := flag.String("start", "", "Simulated start time for -replay (RFC3339, default 2024-01-01T00:00:00Z)")
+
+	algA := flag.String("a", "fixed_window", "Algorithm for target A")
+	storeA := flag.String("a-store", "memory", "Store for target A: memory or redis")
+	algB := flag.String("b", "sliding_window", "Algorithm for target B")
+	storeB := flag.String("b-store", "memory", "Store for target B: memory or redis")
+	redisAddr := flag.String("redis", "localhost:6379", "Redis address for redis targets")
+
+	limit := flag.Int("limit", 100, "Requests allowed per window")
+	window := flag.Duration("window", time.Minute, "Rate limit window")
+	burst := flag.Int("burst", 0, "Burst capacity (token bucket, leaky bucket, GCRA)")
+	bucket := flag.Duration("bucket", 0, "Timeline resolution (default: window)")
+
+	asJSON := flag.Bool("json", false, "Print the full report as JSON instead of a summary")
+	flag.Parse()
+
+	if (*specFile == "") == (*replayFile == "") {
+		log.Fatal("Exactly one of -spec or -replay is required")
+	}
+
+	events, startTime, err := loadEvents(*specFile, *replayFile, *start)
+	if err != nil {
+		log.Fatalf("Failed to load workload: %v", err)
+	}
+
+	a := compare.Target{Algorithm: *algA, Store: *storeA}
+	b := compare.Target{Algorithm: *algB, Store: *storeB}
+	if a.Store == "redis" {
+		a.RedisAddr = *redisAddr
+	}
+	if b.Store == "redis" {
+		b.RedisAddr = *redisAddr
+	}
+
+	config := limiter.Config{Limit: *limit, Window: *window, Burst: *burst}
+	report, err := compare.Run(context.Background(), a, b, config, startTime, events, *bucket)
+	if err != nil {
+		log.Fatalf("Comparison failed: %v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+		return
+	}
+	fmt.Print(report.Summary())
+}
+
+func loadEvents(specFile, replayFile, start string) ([]simulation.Event, time.Time, error) {
+	if specFile != "" {
+		f, err := os.Open(specFile)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		defer f.Close()
+
+		spec, err := simulation.ReadSpec(f)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return spec.Events(), spec.StartTime(), nil
+	}
+
+	startTime := simulation.DefaultStart
+	if start != "" {
+		parsed, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("invalid -start: %w", err)
+		}
+		startTime = parsed
+	}
+
+	f, err := os.Open(replayFile)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+
+	events, err := simulation.ReadEvents(f)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return events, startTime, nil
+}